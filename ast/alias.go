@@ -0,0 +1,143 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Alias is a textual alias of a character class, declared before the rules
+// of a grammar with "@alias NAME = [...]".
+type Alias struct {
+	p     Pos
+	Name  *Identifier
+	Class *CharClassMatcher
+}
+
+// NewAlias creates a new alias at the specified position, named name, of
+// the character class cc.
+func NewAlias(p Pos, name *Identifier, cc *CharClassMatcher) *Alias {
+	return &Alias{p: p, Name: name, Class: cc}
+}
+
+// Pos returns the starting position of the node.
+func (a *Alias) Pos() Pos { return a.p }
+
+// String returns the textual representation of a node.
+func (a *Alias) String() string {
+	return fmt.Sprintf("%s: %T{Name: %v, Class: %v}", a.p, a, a.Name, a.Class)
+}
+
+var (
+	exprType      = reflect.TypeOf((*Expression)(nil)).Elem()
+	charClassType = reflect.TypeOf((*CharClassMatcher)(nil))
+)
+
+// ExpandAliases expands the aliases in the rules of g: a reference to an
+// alias is replaced by its character class, and in a character class, a
+// word separated by spaces that is the name of an alias is replaced by the
+// characters of its class, e.g. "[DIGIT A-F]" becomes "[0-9A-F]". The
+// spaces of a character class are removed when it uses an alias, and are
+// kept otherwise. An error is returned if an alias is declared twice or
+// has the name of a rule, or if an inverted or case-insensitive class is
+// used in a character class.
+func ExpandAliases(g *Grammar, aliases []*Alias) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	rules := make(map[string]bool, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = true
+	}
+	byName := make(map[string]*Alias, len(aliases))
+	for _, a := range aliases {
+		if byName[a.Name.Val] != nil {
+			return fmt.Errorf("alias %s redeclared", a.Name.Val)
+		}
+		if rules[a.Name.Val] {
+			return fmt.Errorf("alias %s has the name of a rule", a.Name.Val)
+		}
+		byName[a.Name.Val] = a
+	}
+	for _, r := range g.Rules {
+		if err := expandAliases(reflect.ValueOf(r).Elem().FieldByName("Expr"), byName); err != nil {
+			return fmt.Errorf("rule %s: %v", r.Name.Val, err)
+		}
+	}
+	return nil
+}
+
+// expandAliases expands the aliases in the node v, which is an expression
+// or a field of an expression, in place.
+func expandAliases(v reflect.Value, aliases map[string]*Alias) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if ref, ok := v.Interface().(*RuleRefExpr); ok && v.Type() == exprType {
+			if a := aliases[ref.Name.Val]; a != nil {
+				v.Set(reflect.ValueOf(NewCharClassMatcher(ref.Pos(), a.Class.Val)))
+			}
+			return nil
+		}
+		return expandAliases(v.Elem(), aliases)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type() == charClassType {
+			return expandClass(v.Interface().(*CharClassMatcher), aliases)
+		}
+		return expandAliases(v.Elem(), aliases)
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				if err := expandAliases(f, aliases); err != nil {
+					return err
+				}
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandAliases(v.Index(i), aliases); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandClass replaces the names of the aliases in the character class cc
+// by the characters of their class.
+func expandClass(cc *CharClassMatcher, aliases map[string]*Alias) error {
+	start := strings.IndexByte(cc.Val, '[') + 1
+	if strings.HasPrefix(cc.Val[start:], "^") {
+		start++
+	}
+	end := strings.LastIndexByte(cc.Val, ']')
+	if end < start {
+		return nil
+	}
+
+	words := strings.Split(cc.Val[start:end], " ")
+	found := false
+	for i, w := range words {
+		a := aliases[w]
+		if a == nil {
+			continue
+		}
+		if a.Class.Inverted || a.Class.IgnoreCase {
+			return fmt.Errorf("alias %s is inverted or case-insensitive and cannot be used in a character class", w)
+		}
+		words[i] = a.Class.Val[1:strings.LastIndexByte(a.Class.Val, ']')]
+		found = true
+	}
+	if found {
+		*cc = *NewCharClassMatcher(cc.Pos(), cc.Val[:start]+strings.Join(words, "")+cc.Val[end:])
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestExpandAliases(t *testing.T) {
+	g := parseGrammar(t, "A = [x DIGIT] ( [a b] / DIGIT ) !DIGIT\nB = [^DIGIT _]\n")
+	aliases := []*ast.Alias{
+		ast.NewAlias(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "DIGIT"), ast.NewCharClassMatcher(ast.Pos{}, "[0-9]")),
+	}
+	if err := ast.ExpandAliases(g, aliases); err != nil {
+		t.Fatal(err)
+	}
+
+	var classes []string
+	for _, r := range g.Rules {
+		ast.Walk(r.Expr, func(expr ast.Expression) {
+			switch expr := expr.(type) {
+			case *ast.CharClassMatcher:
+				classes = append(classes, expr.Val)
+			case *ast.RuleRefExpr:
+				t.Errorf("want no rule reference, got %s", expr.Name.Val)
+			}
+		})
+	}
+	want := []string{"[x0-9]", "[a b]", "[0-9]", "[0-9]", "[^0-9_]"}
+	if len(classes) != len(want) {
+		t.Fatalf("want %d classes, got %q", len(want), classes)
+	}
+	for i, w := range want {
+		if classes[i] != w {
+			t.Errorf("%d: want %s, got %s", i, w, classes[i])
+		}
+	}
+}
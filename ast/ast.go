@@ -30,9 +30,19 @@ func (p Pos) String() string {
 
 // Grammar is the top-level node of the AST for the PEG grammar.
 type Grammar struct {
-	p     Pos
-	Init  *CodeBlock
-	Rules []*Rule
+	p    Pos
+	Init *CodeBlock
+	// Fields is the code block of the fields added to the current struct
+	// of the generated parser, nil if the grammar has none.
+	Fields *CodeBlock
+	// State is the code block of the fields of the state struct shared by
+	// the code blocks of the generated parser as c.state, nil if the
+	// grammar has none.
+	State *CodeBlock
+	// Examples is the inputs declared with @example, that the test
+	// generated by builder.BuildExamplesTest parses.
+	Examples []*StringLit
+	Rules    []*Rule
 }
 
 // NewGrammar creates a new grammar at the specified position.
@@ -47,8 +57,13 @@ func (g *Grammar) Pos() Pos { return g.p }
 func (g *Grammar) String() string {
 	var buf bytes.Buffer
 
-	buf.WriteString(fmt.Sprintf("%s: %T{Init: %v, Rules: [\n",
-		g.p, g, g.Init))
+	if g.Fields != nil {
+		buf.WriteString(fmt.Sprintf("%s: %T{Init: %v, Fields: %v, Rules: [\n",
+			g.p, g, g.Init, g.Fields))
+	} else {
+		buf.WriteString(fmt.Sprintf("%s: %T{Init: %v, Rules: [\n",
+			g.p, g, g.Init))
+	}
 	for _, r := range g.Rules {
 		buf.WriteString(fmt.Sprintf("%s,\n", r))
 	}
@@ -57,12 +72,38 @@ func (g *Grammar) String() string {
 }
 
 // Rule represents a rule in the PEG grammar. It has a name, an optional
-// display name to be used in error messages, and an expression.
+// display name to be used in error messages, and an expression. If Cond
+// is set, the rule is only generated if that feature is defined. If
+// Lexical is set, the rule is generated as written when a rule to skip is
+// set. If Silent is set, the matchers of the rule, and of the rules it
+// references, are not listed in the expected set of the syntax error. If
+// Entry is set, a function that starts parsing at this rule is
+// generated. If Inline is set, the rule is not generated, its expression
+// replaces the references to the rule. If NLSignificant is set, the rule
+// to skip does not match the newlines in the rule. If Type is set, it is
+// the Go type of the value of the rule, and a function that parses into a
+// value of that type is generated for the first rule and the entrypoint
+// rules. If Budget is set, it is the maximum number of expressions that a
+// match of the rule evaluates, including the rules it references. Meta
+// holds the metadata of the @meta annotations, it is not used by the
+// generated parser.
 type Rule struct {
-	p           Pos
-	Name        *Identifier
-	DisplayName *StringLit
-	Expr        Expression
+	p             Pos
+	Name          *Identifier
+	DisplayName   *StringLit
+	Cond          *Identifier
+	Entry         bool
+	Inline        bool
+	Lexical       bool
+	Silent        bool
+	NLSignificant bool
+	Type          string
+	Budget        int
+	Meta          map[string]string
+	Expr          Expression
+	// End is the position following the rule's expression, the zero
+	// value if the parser did not record it.
+	End Pos
 }
 
 // NewRule creates a rule with at the specified position and with the
@@ -87,10 +128,13 @@ type Expression interface {
 
 // ChoiceExpr is an ordered sequence of expressions. The parser tries to
 // match any of the alternatives in sequence and stops at the first one
-// that matches.
+// that matches. If Longest is true, for a @longest choice, the parser
+// tries all the alternatives and keeps the first one of those that match
+// the most input.
 type ChoiceExpr struct {
 	p            Pos
 	Alternatives []Expression
+	Longest      bool
 }
 
 // NewChoiceExpr creates a choice expression at the specified position.
@@ -113,6 +157,49 @@ func (c *ChoiceExpr) String() string {
 	return buf.String()
 }
 
+// IfExpr is an alternative of a choice expression that is only generated
+// if the feature identified by Cond is defined.
+type IfExpr struct {
+	p    Pos
+	Cond *Identifier
+	Expr Expression
+}
+
+// NewIfExpr creates a new conditional expression at the specified position.
+func NewIfExpr(p Pos) *IfExpr {
+	return &IfExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (i *IfExpr) Pos() Pos { return i.p }
+
+// String returns the textual representation of a node.
+func (i *IfExpr) String() string {
+	return fmt.Sprintf("%s: %T{Cond: %v, Expr: %v}", i.p, i, i.Cond, i.Expr)
+}
+
+// WhenExpr is an alternative of a choice expression that only matches if
+// the flag identified by Flag is set when parsing.
+type WhenExpr struct {
+	p    Pos
+	Flag *Identifier
+	Expr Expression
+}
+
+// NewWhenExpr creates a new flag-dependent expression at the specified
+// position.
+func NewWhenExpr(p Pos) *WhenExpr {
+	return &WhenExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (w *WhenExpr) Pos() Pos { return w.p }
+
+// String returns the textual representation of a node.
+func (w *WhenExpr) String() string {
+	return fmt.Sprintf("%s: %T{Flag: %v, Expr: %v}", w.p, w, w.Flag, w.Expr)
+}
+
 // ActionExpr is an expression that has an associated block of code to
 // execute when the expression matches.
 type ActionExpr struct {
@@ -185,10 +272,12 @@ func (l *LabeledExpr) String() string {
 }
 
 // AndExpr is a zero-length matcher that is considered a match if the
-// expression it contains is a match.
+// expression it contains is a match. If Peek is set, it is a @peek
+// expression, whose value is that of the expression instead of nil.
 type AndExpr struct {
 	p    Pos
 	Expr Expression
+	Peek bool
 }
 
 // NewAndExpr creates a new and (&) expression at the specified position.
@@ -201,7 +290,7 @@ func (a *AndExpr) Pos() Pos { return a.p }
 
 // String returns the textual representation of a node.
 func (a *AndExpr) String() string {
-	return fmt.Sprintf("%s: %T{Expr: %v}", a.p, a, a.Expr)
+	return fmt.Sprintf("%s: %T{Expr: %v, Peek: %t}", a.p, a, a.Expr, a.Peek)
 }
 
 // NotExpr is a zero-length matcher that is considered a match if the
@@ -225,9 +314,13 @@ func (n *NotExpr) String() string {
 }
 
 // ZeroOrOneExpr is an expression that can be matched zero or one time.
+// If Default is set, its code block computes the value of the expression
+// when Expr does not match, e.g. for "expr ?? { return 0, nil }".
 type ZeroOrOneExpr struct {
-	p    Pos
-	Expr Expression
+	p       Pos
+	Expr    Expression
+	Default *CodeBlock
+	FuncIx  int
 }
 
 // NewZeroOrOneExpr creates a new zero or one expression at the specified
@@ -241,13 +334,19 @@ func (z *ZeroOrOneExpr) Pos() Pos { return z.p }
 
 // String returns the textual representation of a node.
 func (z *ZeroOrOneExpr) String() string {
+	if z.Default != nil {
+		return fmt.Sprintf("%s: %T{Expr: %v, Default: %v}", z.p, z, z.Expr, z.Default)
+	}
 	return fmt.Sprintf("%s: %T{Expr: %v}", z.p, z, z.Expr)
 }
 
 // ZeroOrMoreExpr is an expression that can be matched zero or more times.
+// If While is set, its code block is evaluated before each repetition with
+// the values matched so far, and the repetition stops when it returns false.
 type ZeroOrMoreExpr struct {
-	p    Pos
-	Expr Expression
+	p     Pos
+	Expr  Expression
+	While *AndCodeExpr
 }
 
 // NewZeroOrMoreExpr creates a new zero or more expression at the specified
@@ -261,13 +360,19 @@ func (z *ZeroOrMoreExpr) Pos() Pos { return z.p }
 
 // String returns the textual representation of a node.
 func (z *ZeroOrMoreExpr) String() string {
+	if z.While != nil {
+		return fmt.Sprintf("%s: %T{Expr: %v, While: %v}", z.p, z, z.Expr, z.While)
+	}
 	return fmt.Sprintf("%s: %T{Expr: %v}", z.p, z, z.Expr)
 }
 
 // OneOrMoreExpr is an expression that can be matched one or more times.
+// If While is set, it behaves as for ZeroOrMoreExpr, and the expression
+// fails if the repetition stops before the first match.
 type OneOrMoreExpr struct {
-	p    Pos
-	Expr Expression
+	p     Pos
+	Expr  Expression
+	While *AndCodeExpr
 }
 
 // NewOneOrMoreExpr creates a new one or more expression at the specified
@@ -281,6 +386,9 @@ func (o *OneOrMoreExpr) Pos() Pos { return o.p }
 
 // String returns the textual representation of a node.
 func (o *OneOrMoreExpr) String() string {
+	if o.While != nil {
+		return fmt.Sprintf("%s: %T{Expr: %v, While: %v}", o.p, o, o.Expr, o.While)
+	}
 	return fmt.Sprintf("%s: %T{Expr: %v}", o.p, o, o.Expr)
 }
 
@@ -304,6 +412,333 @@ func (r *RuleRefExpr) String() string {
 	return fmt.Sprintf("%s: %T{Name: %v}", r.p, r, r.Name)
 }
 
+// OperatorsExpr is an expression that matches one or more operands
+// separated by binary operators, grouping them according to the
+// precedence and associativity of the operators.
+type OperatorsExpr struct {
+	p         Pos
+	Operand   Expression
+	Operators []*Operator
+}
+
+// NewOperatorsExpr creates a new operators expression at the specified
+// position.
+func NewOperatorsExpr(p Pos) *OperatorsExpr {
+	return &OperatorsExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (o *OperatorsExpr) Pos() Pos { return o.p }
+
+// String returns the textual representation of a node.
+func (o *OperatorsExpr) String() string {
+	var buf bytes.Buffer
+
+	buf.WriteString(fmt.Sprintf("%s: %T{Operand: %v, Operators: [\n", o.p, o, o.Operand))
+	for _, op := range o.Operators {
+		buf.WriteString(fmt.Sprintf("%s,\n", op))
+	}
+	buf.WriteString("]}")
+	return buf.String()
+}
+
+// BackRefExpr is an expression that matches the same text as the last
+// match of the labeled expression of the rule with the label Label.
+type BackRefExpr struct {
+	p     Pos
+	Label *Identifier
+}
+
+// NewBackRefExpr creates a new back-reference expression at the specified
+// position.
+func NewBackRefExpr(p Pos) *BackRefExpr {
+	return &BackRefExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (b *BackRefExpr) Pos() Pos { return b.p }
+
+// String returns the textual representation of a node.
+func (b *BackRefExpr) String() string {
+	return fmt.Sprintf("%s: %T{Label: %v}", b.p, b, b.Label)
+}
+
+// SeenExpr is an expression that matches the longest of the texts matched
+// so far in the parse by the labeled expressions with the label Label, in
+// any rule.
+type SeenExpr struct {
+	p     Pos
+	Label *Identifier
+}
+
+// NewSeenExpr creates a new seen expression at the specified position.
+func NewSeenExpr(p Pos) *SeenExpr {
+	return &SeenExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (s *SeenExpr) Pos() Pos { return s.p }
+
+// String returns the textual representation of a node.
+func (s *SeenExpr) String() string {
+	return fmt.Sprintf("%s: %T{Label: %v}", s.p, s, s.Label)
+}
+
+// LookbehindExpr is a zero-length matcher that is considered a match if
+// the input that precedes the current position is matched by its
+// expression, a literal, character class or any matcher. It does not
+// match at the start of the input.
+type LookbehindExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+// NewLookbehindExpr creates a new lookbehind (<=) expression at the
+// specified position.
+func NewLookbehindExpr(p Pos) *LookbehindExpr {
+	return &LookbehindExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (l *LookbehindExpr) Pos() Pos { return l.p }
+
+// String returns the textual representation of a node.
+func (l *LookbehindExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", l.p, l, l.Expr)
+}
+
+// UnreservedExpr is an expression that matches its expression only if the
+// matched text is not one of the words provided to the generated parser by
+// the Keywords option.
+type UnreservedExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+// NewUnreservedExpr creates a new unreserved expression at the specified
+// position.
+func NewUnreservedExpr(p Pos) *UnreservedExpr {
+	return &UnreservedExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (u *UnreservedExpr) Pos() Pos { return u.p }
+
+// String returns the textual representation of a node.
+func (u *UnreservedExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", u.p, u, u.Expr)
+}
+
+// VerbatimExpr is an expression that matches its expression as written,
+// without the skip rule of the Skip option of the builder before its
+// matchers, so that the text it matches is captured exactly.
+type VerbatimExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+// NewVerbatimExpr creates a new verbatim expression at the specified
+// position.
+func NewVerbatimExpr(p Pos) *VerbatimExpr {
+	return &VerbatimExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (v *VerbatimExpr) Pos() Pos { return v.p }
+
+// String returns the textual representation of a node.
+func (v *VerbatimExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", v.p, v, v.Expr)
+}
+
+// CompactExpr is an expression that matches its expression and removes the
+// nil values from its value if it is a slice, such as the value of a
+// sequence with optional expressions that did not match.
+type CompactExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+// NewCompactExpr creates a new compact expression at the specified
+// position.
+func NewCompactExpr(p Pos) *CompactExpr {
+	return &CompactExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (c *CompactExpr) Pos() Pos { return c.p }
+
+// String returns the textual representation of a node.
+func (c *CompactExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", c.p, c, c.Expr)
+}
+
+// TrimExpr is an expression that matches its expression, its value is the
+// text of the match with the leading and trailing whitespace removed.
+type TrimExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+// NewTrimExpr creates a new trim expression at the specified position.
+func NewTrimExpr(p Pos) *TrimExpr {
+	return &TrimExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (t *TrimExpr) Pos() Pos { return t.p }
+
+// String returns the textual representation of a node.
+func (t *TrimExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", t.p, t, t.Expr)
+}
+
+// ConvertExpr is an expression that matches its expression, its value is
+// the text of the match converted by the converter named Name, e.g. to an
+// int or to a custom type registered with the generated parser.
+type ConvertExpr struct {
+	p    Pos
+	Name *Identifier
+	Expr Expression
+}
+
+// NewConvertExpr creates a new convert expression at the specified
+// position.
+func NewConvertExpr(p Pos) *ConvertExpr {
+	return &ConvertExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (c *ConvertExpr) Pos() Pos { return c.p }
+
+// String returns the textual representation of a node.
+func (c *ConvertExpr) String() string {
+	return fmt.Sprintf("%s: %T{Name: %v, Expr: %v}", c.p, c, c.Name, c.Expr)
+}
+
+// ArrayExpr is an expression that matches its expression exactly N times,
+// its value is an array of N elements of the Go type Type, e.g. [3]int,
+// or of empty interfaces if Type is empty.
+type ArrayExpr struct {
+	p    Pos
+	Expr Expression
+	N    int
+	Type string
+}
+
+// NewArrayExpr creates a new array expression at the specified position.
+func NewArrayExpr(p Pos) *ArrayExpr {
+	return &ArrayExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (a *ArrayExpr) Pos() Pos { return a.p }
+
+// String returns the textual representation of a node.
+func (a *ArrayExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v, N: %d, Type: %q}", a.p, a, a.Expr, a.N, a.Type)
+}
+
+// MapExpr is an expression that matches its expression zero or more times,
+// its value is a map[string]interface{} of the values of the Val label of
+// the matches, keyed by the text matched by their Key label. If Last is
+// set, the value of the last match of a duplicate key is kept, otherwise a
+// duplicate key is an error.
+type MapExpr struct {
+	p    Pos
+	Expr Expression
+	Key  *Identifier
+	Val  *Identifier
+	Last bool
+}
+
+// NewMapExpr creates a new map expression at the specified position.
+func NewMapExpr(p Pos) *MapExpr {
+	return &MapExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (m *MapExpr) Pos() Pos { return m.p }
+
+// String returns the textual representation of a node.
+func (m *MapExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v, Key: %v, Val: %v, Last: %t}", m.p, m, m.Expr, m.Key, m.Val, m.Last)
+}
+
+// SepExpr is an expression that matches one or more expressions separated
+// by a separator expression, optionally followed by a trailing separator.
+// If Terminated is set, the separator is a terminator that must follow each
+// expression, including the last one. If Keep is set, the values of the
+// separators are kept in its value, interleaved with the values of the
+// expressions.
+type SepExpr struct {
+	p          Pos
+	Expr       Expression
+	Sep        Expression
+	Trailing   bool
+	Terminated bool
+	Keep       bool
+}
+
+// NewSepExpr creates a new separated list expression at the specified
+// position.
+func NewSepExpr(p Pos) *SepExpr {
+	return &SepExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (s *SepExpr) Pos() Pos { return s.p }
+
+// String returns the textual representation of a node.
+func (s *SepExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v, Sep: %v, Trailing: %t, Terminated: %t, Keep: %t}",
+		s.p, s, s.Expr, s.Sep, s.Trailing, s.Terminated, s.Keep)
+}
+
+// FoldExpr is an expression that folds the value of a sequence of an
+// operand followed by a repetition of operator and operand pairs into
+// binary operations, grouped to the left or to the right.
+type FoldExpr struct {
+	p     Pos
+	Expr  Expression
+	Right bool
+}
+
+// NewFoldExpr creates a new fold expression at the specified position.
+func NewFoldExpr(p Pos) *FoldExpr {
+	return &FoldExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (f *FoldExpr) Pos() Pos { return f.p }
+
+// String returns the textual representation of a node.
+func (f *FoldExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v, Right: %t}", f.p, f, f.Expr, f.Right)
+}
+
+// Operator is a binary operator of an operators expression. Operators
+// with a higher precedence bind tighter.
+type Operator struct {
+	p          Pos
+	Lit        *LitMatcher
+	Prec       int
+	RightAssoc bool
+}
+
+// NewOperator creates a new binary operator at the specified position.
+func NewOperator(p Pos, lit *LitMatcher, prec int, rightAssoc bool) *Operator {
+	return &Operator{p: p, Lit: lit, Prec: prec, RightAssoc: rightAssoc}
+}
+
+// Pos returns the starting position of the node.
+func (o *Operator) Pos() Pos { return o.p }
+
+// String returns the textual representation of a node.
+func (o *Operator) String() string {
+	return fmt.Sprintf("%s: %T{Lit: %v, Prec: %d, RightAssoc: %t}", o.p, o, o.Lit, o.Prec, o.RightAssoc)
+}
+
 // AndCodeExpr is a zero-length matcher that is considered a match if the
 // code block returns true.
 type AndCodeExpr struct {
@@ -524,6 +959,255 @@ func (a *AnyMatcher) String() string {
 	return fmt.Sprintf("%s: %T{Val: %q}", a.p, a, a.Val)
 }
 
+// UntilMatcher is a matcher that consumes all characters up to, but not
+// including, the first occurrence of its delimiter, or up to the end of
+// file if the delimiter is not found.
+type UntilMatcher struct {
+	posValue
+}
+
+// NewUntilMatcher creates a new until matcher at the specified position and
+// with the specified delimiter value.
+func NewUntilMatcher(p Pos, delim string) *UntilMatcher {
+	return &UntilMatcher{posValue{p: p, Val: delim}}
+}
+
+// Pos returns the starting position of the node.
+func (u *UntilMatcher) Pos() Pos { return u.p }
+
+// String returns the textual representation of a node.
+func (u *UntilMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", u.p, u, u.Val)
+}
+
+// RestOfLineMatcher is a matcher that consumes all characters up to, but
+// not including, the next newline, "\n" or "\r\n", or up to the end of file
+// if there is no newline.
+type RestOfLineMatcher struct {
+	posValue
+}
+
+// NewRestOfLineMatcher creates a new rest of line matcher at the specified
+// position.
+func NewRestOfLineMatcher(p Pos) *RestOfLineMatcher {
+	return &RestOfLineMatcher{posValue{p: p, Val: "RestOfLine()"}}
+}
+
+// Pos returns the starting position of the node.
+func (r *RestOfLineMatcher) Pos() Pos { return r.p }
+
+// String returns the textual representation of a node.
+func (r *RestOfLineMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", r.p, r, r.Val)
+}
+
+// IndentMatcher is a matcher for the indentation of a line. Its value is
+// "indent", "samedent" or "dedent" to match the start of a line indented
+// more than, the same as or less than the current indentation level.
+type IndentMatcher struct {
+	posValue
+}
+
+// NewIndentMatcher creates a new indentation matcher at the specified
+// position and with the specified kind of indentation.
+func NewIndentMatcher(p Pos, kind string) *IndentMatcher {
+	return &IndentMatcher{posValue{p: p, Val: kind}}
+}
+
+// Pos returns the starting position of the node.
+func (i *IndentMatcher) Pos() Pos { return i.p }
+
+// String returns the textual representation of a node.
+func (i *IndentMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", i.p, i, i.Val)
+}
+
+// KeywordMatcher is a matcher for one of the words provided to the
+// generated parser by the Keywords option. Its value is always "@keyword".
+type KeywordMatcher struct {
+	posValue
+}
+
+// NewKeywordMatcher creates a new keyword matcher at the specified
+// position.
+func NewKeywordMatcher(p Pos) *KeywordMatcher {
+	return &KeywordMatcher{posValue{p: p, Val: "@keyword"}}
+}
+
+// Pos returns the starting position of the node.
+func (k *KeywordMatcher) Pos() Pos { return k.p }
+
+// String returns the textual representation of a node.
+func (k *KeywordMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", k.p, k, k.Val)
+}
+
+// WordListMatcher is a matcher for one of the words provided to the
+// generated parser by the WordList option. Its value is always "@wordlist".
+type WordListMatcher struct {
+	posValue
+}
+
+// NewWordListMatcher creates a new word list matcher at the specified
+// position.
+func NewWordListMatcher(p Pos) *WordListMatcher {
+	return &WordListMatcher{posValue{p: p, Val: "@wordlist"}}
+}
+
+// Pos returns the starting position of the node.
+func (w *WordListMatcher) Pos() Pos { return w.p }
+
+// String returns the textual representation of a node.
+func (w *WordListMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", w.p, w, w.Val)
+}
+
+// TableMatcher is a matcher for a rune of the Unicode range table provided
+// to the generated parser by the Table option. Its value is the name of the
+// table.
+type TableMatcher struct {
+	posValue
+}
+
+// NewTableMatcher creates a new table matcher at the specified position,
+// for the table named name.
+func NewTableMatcher(p Pos, name string) *TableMatcher {
+	return &TableMatcher{posValue{p: p, Val: name}}
+}
+
+// Pos returns the starting position of the node.
+func (t *TableMatcher) Pos() Pos { return t.p }
+
+// String returns the textual representation of a node.
+func (t *TableMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", t.p, t, t.Val)
+}
+
+// ByteMatcher is a matcher for a single byte of the input, regardless of
+// its encoding.
+type ByteMatcher struct {
+	p   Pos
+	Val byte
+}
+
+// NewByteMatcher creates a new byte matcher at the specified position and
+// with the specified byte value.
+func NewByteMatcher(p Pos, b byte) *ByteMatcher {
+	return &ByteMatcher{p: p, Val: b}
+}
+
+// Pos returns the starting position of the node.
+func (b *ByteMatcher) Pos() Pos { return b.p }
+
+// String returns the textual representation of a node.
+func (b *ByteMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %#02x}", b.p, b, b.Val)
+}
+
+// BytesMatcher is a matcher for a fixed number of bytes of the input,
+// regardless of their encoding. The number of bytes is N, or the integer
+// value of the expression labeled Label in the same rule if Label is set.
+type BytesMatcher struct {
+	p     Pos
+	N     int
+	Label *Identifier
+}
+
+// NewBytesMatcher creates a new bytes matcher at the specified position.
+func NewBytesMatcher(p Pos) *BytesMatcher {
+	return &BytesMatcher{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (b *BytesMatcher) Pos() Pos { return b.p }
+
+// String returns the textual representation of a node.
+func (b *BytesMatcher) String() string {
+	if b.Label != nil {
+		return fmt.Sprintf("%s: %T{Label: %s}", b.p, b, b.Label.Val)
+	}
+	return fmt.Sprintf("%s: %T{N: %d}", b.p, b, b.N)
+}
+
+// NestedMatcher is a matcher for a block that starts with the Open
+// delimiter and ends with the matching Close delimiter, such as a block
+// comment that may contain nested block comments. Its value is the
+// matched bytes, delimiters included.
+type NestedMatcher struct {
+	p     Pos
+	Open  string
+	Close string
+}
+
+// NewNestedMatcher creates a new nested matcher at the specified position
+// and with the specified delimiters.
+func NewNestedMatcher(p Pos, open, close string) *NestedMatcher {
+	return &NestedMatcher{p: p, Open: open, Close: close}
+}
+
+// Pos returns the starting position of the node.
+func (n *NestedMatcher) Pos() Pos { return n.p }
+
+// String returns the textual representation of a node.
+func (n *NestedMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Open: %q, Close: %q}", n.p, n, n.Open, n.Close)
+}
+
+// NumberMatcher is a matcher for a number of the input, made of digits
+// of the Radix, with a leading '-' or '+' if Sign is set. If Float is set,
+// the digits can be followed by a fraction and an exponent, as in
+// "-1.5e3". If Prefix is set, the digits follow a radix prefix, "0x",
+// "0b" or "0o", that sets their radix instead of Radix. If Thousands is
+// set, the digits before the fraction can be grouped by three with that
+// separator, as in "1,234". If Decimal is set, it separates the fraction
+// instead of '.'. Its value is the number as an int64, or as a float64 if
+// Float is set.
+type NumberMatcher struct {
+	p         Pos
+	Float     bool
+	Sign      bool
+	Prefix    bool
+	Radix     int
+	Thousands rune
+	Decimal   rune
+}
+
+// NewNumberMatcher creates a new number matcher at the specified position,
+// for the integers of radix 10.
+func NewNumberMatcher(p Pos) *NumberMatcher {
+	return &NumberMatcher{p: p, Radix: 10}
+}
+
+// Pos returns the starting position of the node.
+func (n *NumberMatcher) Pos() Pos { return n.p }
+
+// String returns the textual representation of a node.
+func (n *NumberMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Float: %t, Sign: %t, Prefix: %t, Radix: %d, Thousands: %q, Decimal: %q}",
+		n.p, n, n.Float, n.Sign, n.Prefix, n.Radix, n.Thousands, n.Decimal)
+}
+
+// TokenMatcher is a matcher for a token of the input of a parser in token
+// mode. Its value is the Go expression of the kind of the token, or the
+// empty string to match any token.
+type TokenMatcher struct {
+	posValue
+}
+
+// NewTokenMatcher creates a new token matcher at the specified position and
+// with the specified Go expression of the kind of the token.
+func NewTokenMatcher(p Pos, kind string) *TokenMatcher {
+	return &TokenMatcher{posValue{p: p, Val: kind}}
+}
+
+// Pos returns the starting position of the node.
+func (t *TokenMatcher) Pos() Pos { return t.p }
+
+// String returns the textual representation of a node.
+func (t *TokenMatcher) String() string {
+	return fmt.Sprintf("%s: %T{Val: %q}", t.p, t, t.Val)
+}
+
 // CodeBlock represents a code block.
 type CodeBlock struct {
 	posValue
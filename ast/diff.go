@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// Diff returns the differences between the grammars a and b, one per
+// line, such as "changed rule X", so that the effect of a change to a
+// grammar or to a transformation of the grammar can be reviewed. The
+// rules are compared by name and as Fingerprint compares grammars, so that
+// the formatting and the comments are ignored. The removed and changed
+// rules are listed in the order of a, followed by the added rules in the
+// order of b. Diff returns nil if the grammars have the same semantics.
+func Diff(a, b *Grammar) []string {
+	var diffs []string
+	if canonical(a.Init) != canonical(b.Init) {
+		diffs = append(diffs, "changed initializer")
+	}
+	if canonical(a.Fields) != canonical(b.Fields) {
+		diffs = append(diffs, "changed fields")
+	}
+
+	rules := make(map[string]*Rule, len(b.Rules))
+	for _, r := range b.Rules {
+		rules[r.Name.Val] = r
+	}
+	seen := make(map[string]bool, len(a.Rules))
+	for _, r := range a.Rules {
+		seen[r.Name.Val] = true
+		other, ok := rules[r.Name.Val]
+		switch {
+		case !ok:
+			diffs = append(diffs, "removed rule "+r.Name.Val)
+		case canonical(r) != canonical(other):
+			diffs = append(diffs, "changed rule "+r.Name.Val)
+		}
+	}
+	for _, r := range b.Rules {
+		if !seen[r.Name.Val] {
+			diffs = append(diffs, "added rule "+r.Name.Val)
+		}
+	}
+	return diffs
+}
+
+// canonical returns the canonical form of the node v, as hashed by
+// Fingerprint.
+func canonical(v interface{}) string {
+	var buf bytes.Buffer
+	fingerprint(&buf, reflect.ValueOf(v))
+	return buf.String()
+}
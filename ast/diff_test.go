@@ -0,0 +1,29 @@
+package ast_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestDiff(t *testing.T) {
+	base := parseGrammar(t, "A = B C / 'a'\nB = 'b'+\nC = [c-d]\nD = 'd'\n")
+
+	cases := map[string][]string{
+		"A = B C / 'a'\nB = 'b'+\nC = [c-d]\nD = 'd'\n":          nil,
+		"A ← B  C  /  'a' \n\nB ← ( 'b' )+\nC ← [c-d]\nD = 'd'":  nil,
+		"A = B C / 'a'\nB = 'b'*\nC = [c-d]\nD = 'd'\n":          {"changed rule B"},
+		"A = B C / 'a'\nB = 'b'+\nC = [c-d]\n":                   {"removed rule D"},
+		"A = B C / 'a'\nB = 'b'+\nC = [c-d]\nD = 'd'\nE = 'e'\n": {"added rule E"},
+		"{ package p }\nA = B C / 'a'\nB = 'b'+\nC = [c-e]\nE = 'e'": {
+			"changed initializer", "changed rule C", "removed rule D", "added rule E",
+		},
+	}
+	for src, want := range cases {
+		got := ast.Diff(base, parseGrammar(t, src))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", src, want, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// ToDOT returns the graph of the rule references of the grammar in the DOT
+// language of Graphviz, for documentation: a node per rule, in the order
+// of the grammar, and an edge per rule that references another rule. The
+// rules and the references that are part of a left-recursive cycle, where
+// a rule may reference itself before any input is consumed, are colored
+// in red.
+func ToDOT(g *Grammar) string {
+	nullable := nullableRules(g)
+	leftRefs := make(map[string][]string, len(g.Rules))
+	for _, r := range g.Rules {
+		leftRefs[r.Name.Val] = leftRuleRefs(r.Expr, nullable)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph grammar {\n")
+	for _, r := range g.Rules {
+		nm := r.Name.Val
+		if reaches(leftRefs, nm, nm) {
+			fmt.Fprintf(&buf, "\t%s [color=red];\n", strconv.Quote(nm))
+		} else {
+			fmt.Fprintf(&buf, "\t%s;\n", strconv.Quote(nm))
+		}
+	}
+	for _, r := range g.Rules {
+		from := r.Name.Val
+		left := make(map[string]bool)
+		for _, nm := range leftRefs[from] {
+			left[nm] = true
+		}
+		seen := make(map[string]bool)
+		for _, to := range ruleRefs(r.Expr) {
+			if seen[to] {
+				continue
+			}
+			seen[to] = true
+			edge := strconv.Quote(from) + " -> " + strconv.Quote(to)
+			if left[to] && (to == from || reaches(leftRefs, to, from)) {
+				fmt.Fprintf(&buf, "\t%s [color=red];\n", edge)
+			} else {
+				fmt.Fprintf(&buf, "\t%s;\n", edge)
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,48 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestToDOT(t *testing.T) {
+	g := parseGrammar(t, `
+A = B
+B = A
+`)
+	want := `digraph grammar {
+	"A" [color=red];
+	"B" [color=red];
+	"A" -> "B" [color=red];
+	"B" -> "A" [color=red];
+}
+`
+	if got := ast.ToDOT(g); got != want {
+		t.Errorf("want DOT\n%s\ngot\n%s", want, got)
+	}
+
+	g = parseGrammar(t, `
+Expr = Expr '+' Term / Term
+Term = '(' Expr ')' / Num Num
+Num = [0-9]
+`)
+	got := ast.ToDOT(g)
+	for _, want := range []string{
+		"\t\"Expr\" [color=red];\n",
+		"\t\"Term\";\n",
+		"\t\"Num\";\n",
+		"\t\"Expr\" -> \"Expr\" [color=red];\n",
+		"\t\"Expr\" -> \"Term\";\n",
+		"\t\"Term\" -> \"Expr\";\n",
+		"\t\"Term\" -> \"Num\";\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want DOT line %q, got\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "->"); n != 4 {
+		t.Errorf("want 4 edges, got %d", n)
+	}
+}
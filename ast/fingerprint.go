@@ -0,0 +1,76 @@
+package ast
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+var posType = reflect.TypeOf(Pos{})
+
+// Fingerprint returns a hash that identifies the semantics of the grammar,
+// as a string of hexadecimal digits. The positions of the nodes are
+// ignored, so grammars that only differ by their formatting and comments
+// have the same fingerprint. Code blocks are hashed verbatim.
+func Fingerprint(g *Grammar) string {
+	h := sha256.New()
+	fingerprint(h, reflect.ValueOf(g))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprint writes the canonical form of v to w. It skips the positions
+// and the FuncIx fields, which the builder sets when it writes the code.
+func fingerprint(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			io.WriteString(w, "nil;")
+			return
+		}
+		fingerprint(w, v.Elem())
+
+	case reflect.Struct:
+		t := v.Type()
+		fmt.Fprintf(w, "%s{", t.Name())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Type == posType || f.Name == "FuncIx" || (f.PkgPath != "" && !f.Anonymous) {
+				continue
+			}
+			fmt.Fprintf(w, "%s:", f.Name)
+			fingerprint(w, v.Field(i))
+		}
+		io.WriteString(w, "}")
+
+	case reflect.Slice:
+		fmt.Fprintf(w, "[%d:", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			fingerprint(w, v.Index(i))
+		}
+		io.WriteString(w, "]")
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		fmt.Fprintf(w, "map[%d:", v.Len())
+		for _, k := range keys {
+			fingerprint(w, k)
+			fingerprint(w, v.MapIndex(k))
+		}
+		io.WriteString(w, "]")
+
+	case reflect.String:
+		fmt.Fprintf(w, "%q;", v.String())
+	case reflect.Bool:
+		fmt.Fprintf(w, "%t;", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(w, "%d;", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(w, "%d;", v.Uint())
+	default:
+		panic(fmt.Sprintf("ast: cannot fingerprint a value of type %s", v.Type()))
+	}
+}
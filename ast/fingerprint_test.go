@@ -0,0 +1,36 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := ast.Fingerprint(parseGrammar(t, "A = B 'a'* / [a-z] { return nil, nil }\nB = \"b\"\n"))
+
+	same := []string{
+		"A = B 'a'* / [a-z] { return nil, nil }\nB = \"b\"\n",
+		"A ←\tB ( 'a' )*   /   [a-z] { return nil, nil }\n\n\nB ← \"b\"\n",
+		"A = B 'a'* / [a-z] { return nil, nil } ; B = `b`",
+	}
+	for _, src := range same {
+		if got := ast.Fingerprint(parseGrammar(t, src)); got != base {
+			t.Errorf("%q: want fingerprint %s, got %s", src, base, got)
+		}
+	}
+
+	different := []string{
+		"A = B 'a'+ / [a-z] { return nil, nil }\nB = \"b\"\n",
+		"A = B 'a'* / [a-y] { return nil, nil }\nB = \"b\"\n",
+		"A = B 'a'* / [a-z] { return 1, nil }\nB = \"b\"\n",
+		"A = B 'a'* / [a-z] { return nil, nil }\nB = \"b\"i\n",
+		"B = \"b\"\nA = B 'a'* / [a-z] { return nil, nil }\n",
+		"A = 'a'* B / [a-z] { return nil, nil }\nB = \"b\"\n",
+	}
+	for _, src := range different {
+		if got := ast.Fingerprint(parseGrammar(t, src)); got == base {
+			t.Errorf("%q: want a fingerprint different from %s", src, base)
+		}
+	}
+}
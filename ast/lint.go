@@ -0,0 +1,147 @@
+package ast
+
+import "fmt"
+
+// Severity is the severity level of a diagnostic.
+type Severity int
+
+// The severity levels of the diagnostics, from the most to the least
+// severe.
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+var severityNames = [...]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+}
+
+// String returns the name of the severity level.
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// The codes of the diagnostics returned by Lint.
+const (
+	// CodeUndefinedRule reports a reference to a rule that is not defined.
+	CodeUndefinedRule = "undefined-rule"
+	// CodeLeftRecursion reports a rule that may reference itself without
+	// consuming any input.
+	CodeLeftRecursion = "left-recursion"
+	// CodeNullableRepeat reports a repetition of an expression that can
+	// match the empty string, see EmptyLoops.
+	CodeNullableRepeat = "nullable-repeat"
+	// CodeShadowedAlt reports a shadowed alternative, see Shadows.
+	CodeShadowedAlt = "shadowed-alt"
+	// CodeUnusedRule reports a rule that cannot be reached from the
+	// starting rule of the grammar.
+	CodeUnusedRule = "unused-rule"
+)
+
+// Diagnostic is a problem reported by Lint.
+type Diagnostic struct {
+	Severity Severity
+	// Code identifies the kind of problem, one of the Code constants.
+	Code string
+	// Rule is the name of the rule where the problem is, and Pos its
+	// position in the grammar.
+	Rule string
+	Pos  Pos
+	// Message describes the problem.
+	Message string
+}
+
+// String returns the diagnostic formatted as position, severity, message
+// and code.
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", d.Pos, d.Severity, d.Message, d.Code)
+}
+
+// Lint returns the diagnostics of the analyses of the grammar, grouped by
+// code in the order of the Code constants and then in the order of the
+// rules. The first rule of the grammar is its starting rule.
+func Lint(g *Grammar) []*Diagnostic {
+	var diags []*Diagnostic
+
+	defined := make(map[string]bool, len(g.Rules))
+	for _, r := range g.Rules {
+		defined[r.Name.Val] = true
+	}
+	for _, r := range g.Rules {
+		Walk(r.Expr, func(expr Expression) {
+			if ref, ok := expr.(*RuleRefExpr); ok && !defined[ref.Name.Val] {
+				diags = append(diags, &Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeUndefinedRule,
+					Rule:     r.Name.Val,
+					Pos:      ref.Pos(),
+					Message:  fmt.Sprintf("rule %s references undefined rule %s", r.Name.Val, ref.Name.Val),
+				})
+			}
+		})
+	}
+
+	for i, m := range Metrics(g) {
+		if m.LeftRecursive {
+			r := g.Rules[i]
+			diags = append(diags, &Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeLeftRecursion,
+				Rule:     r.Name.Val,
+				Pos:      r.Pos(),
+				Message:  fmt.Sprintf("rule %s is left-recursive", r.Name.Val),
+			})
+		}
+	}
+
+	nullable := nullableRules(g)
+	for _, r := range g.Rules {
+		for _, loop := range emptyLoops(r.Expr, nullable) {
+			diags = append(diags, &Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeNullableRepeat,
+				Rule:     r.Name.Val,
+				Pos:      loop.Pos(),
+				Message:  fmt.Sprintf("rule %s repeats an expression that can match the empty string", r.Name.Val),
+			})
+		}
+	}
+
+	for _, sh := range Shadows(g) {
+		diags = append(diags, &Diagnostic{
+			Severity: SeverityWarning,
+			Code:     CodeShadowedAlt,
+			Rule:     sh.Rule,
+			Pos:      sh.Alt.Pos(),
+			Message: fmt.Sprintf("rule %s: alternative %d (%s) is shadowed by alternative %d (%s)",
+				sh.Rule, sh.Index+1, sh.altLit, sh.ByIndex+1, sh.byLit),
+		})
+	}
+
+	if len(g.Rules) > 0 {
+		refs := make(map[string][]string, len(g.Rules))
+		for _, r := range g.Rules {
+			refs[r.Name.Val] = append(refs[r.Name.Val], ruleRefs(r.Expr)...)
+		}
+		start := g.Rules[0].Name.Val
+		for _, r := range g.Rules {
+			nm := r.Name.Val
+			if nm != start && !reaches(refs, start, nm) {
+				diags = append(diags, &Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeUnusedRule,
+					Rule:     nm,
+					Pos:      r.Pos(),
+					Message:  fmt.Sprintf("rule %s is not used", nm),
+				})
+			}
+		}
+	}
+	return diags
+}
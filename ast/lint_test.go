@@ -0,0 +1,59 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestLint(t *testing.T) {
+	cases := map[string][]string{
+		"A = 'a' B*\nB = 'b'?\nC = 'c'": {
+			`1:9 (8): error: rule A repeats an expression that can match the empty string [nullable-repeat]`,
+			`3:1 (20): warning: rule C is not used [unused-rule]`,
+		},
+		"A = 'a' B": {
+			`1:9 (8): error: rule A references undefined rule B [undefined-rule]`,
+		},
+		"A = B 'a' / 'b'\nB = A": {
+			`1:1 (0): error: rule A is left-recursive [left-recursion]`,
+			`2:1 (16): error: rule B is left-recursive [left-recursion]`,
+		},
+		`A = 'a' / "ab"`: {
+			`1:11 (10): warning: rule A: alternative 2 ("ab") is shadowed by alternative 1 ("a") [shadowed-alt]`,
+		},
+		"A = 'a' B\nB = 'b' A?": nil,
+	}
+	for src, want := range cases {
+		got := ast.Lint(parseGrammar(t, src))
+		if len(got) != len(want) {
+			t.Errorf("%q: want %d diagnostics, got %d: %v", src, len(want), len(got), got)
+			continue
+		}
+		for i, w := range want {
+			if s := got[i].String(); s != w {
+				t.Errorf("%q: want diagnostic %q, got %q", src, w, s)
+			}
+		}
+	}
+}
+
+func TestLintSeverity(t *testing.T) {
+	g := parseGrammar(t, "A = 'a' B*\nB = 'b'?\nC = 'c'")
+	want := map[string]ast.Severity{
+		ast.CodeNullableRepeat: ast.SeverityError,
+		ast.CodeUnusedRule:     ast.SeverityWarning,
+	}
+	got := ast.Lint(g)
+	if len(got) != len(want) {
+		t.Fatalf("want %d diagnostics, got %d: %v", len(want), len(got), got)
+	}
+	for _, d := range got {
+		if sev, ok := want[d.Code]; !ok || d.Severity != sev {
+			t.Errorf("%s: want severity %v for code %s, got %v", d.Pos, sev, d.Code, d.Severity)
+		}
+	}
+	if s := ast.SeverityInfo.String(); s != "info" {
+		t.Errorf("want info, got %s", s)
+	}
+}
@@ -0,0 +1,107 @@
+package ast
+
+import "fmt"
+
+// MatcherTable is the table of the distinct matchers of a grammar. Matchers
+// that match the same input, regardless of their position, are interned as
+// a single entry of the table.
+type MatcherTable struct {
+	// Matchers is the first occurrence of each distinct matcher, in the
+	// order of the rules.
+	Matchers []Expression
+
+	index map[string]int
+	users [][]int
+}
+
+// NewMatcherTable returns the table of the distinct matchers of g.
+func NewMatcherTable(g *Grammar) *MatcherTable {
+	t := &MatcherTable{index: make(map[string]int)}
+	for ri, r := range g.Rules {
+		Walk(r.Expr, func(expr Expression) {
+			key, ok := matcherKey(expr)
+			if !ok {
+				return
+			}
+			i, ok := t.index[key]
+			if !ok {
+				i = len(t.Matchers)
+				t.index[key] = i
+				t.Matchers = append(t.Matchers, expr)
+				t.users = append(t.users, nil)
+			}
+			if us := t.users[i]; len(us) == 0 || us[len(us)-1] != ri {
+				t.users[i] = append(us, ri)
+			}
+		})
+	}
+	return t
+}
+
+// Index returns the index in the table of the matcher expr, and false if
+// expr is not a matcher of the table.
+func (t *MatcherTable) Index(expr Expression) (int, bool) {
+	key, ok := matcherKey(expr)
+	if !ok {
+		return 0, false
+	}
+	i, ok := t.index[key]
+	return i, ok
+}
+
+// MatcherUsers returns the indices of the rules of the grammar that use the
+// matcher at index i of the table, in increasing order.
+func (t *MatcherTable) MatcherUsers(i int) []int {
+	if i < 0 || i >= len(t.users) {
+		return nil
+	}
+	return t.users[i]
+}
+
+// IgnoreCase makes the literal matchers of expr and of its sub-expressions
+// case-insensitive, as if they were followed by "i", for the region of an
+// @ignorecase expression. The rules referenced by expr are not changed.
+func IgnoreCase(expr Expression) {
+	Walk(expr, func(expr Expression) {
+		if lit, ok := expr.(*LitMatcher); ok {
+			lit.IgnoreCase = true
+		}
+	})
+}
+
+// matcherKey returns the key that identifies the input matched by expr,
+// and false if expr is not a matcher.
+func matcherKey(expr Expression) (string, bool) {
+	switch expr := expr.(type) {
+	case *AnyMatcher:
+		return "any", true
+	case *ByteMatcher:
+		return fmt.Sprintf("byte %d", expr.Val), true
+	case *BytesMatcher:
+		if expr.Label != nil {
+			return "bytes " + expr.Label.Val, true
+		}
+		return fmt.Sprintf("bytes %d", expr.N), true
+	case *CharClassMatcher:
+		return "class " + expr.Val, true
+	case *IndentMatcher:
+		return "indent " + expr.Val, true
+	case *KeywordMatcher:
+		return "keyword", true
+	case *LitMatcher:
+		return fmt.Sprintf("lit %t %q", expr.IgnoreCase, expr.Val), true
+	case *TokenMatcher:
+		return "token " + expr.Val, true
+	case *NestedMatcher:
+		return fmt.Sprintf("nested %q %q", expr.Open, expr.Close), true
+	case *RestOfLineMatcher:
+		return "rest of line", true
+	case *UntilMatcher:
+		return fmt.Sprintf("until %q", expr.Val), true
+	case *WordListMatcher:
+		return "wordlist", true
+	case *TableMatcher:
+		return "table " + expr.Val, true
+	}
+	return "", false
+}
@@ -0,0 +1,51 @@
+package ast_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestMatcherTable(t *testing.T) {
+	g := parseGrammar(t, `
+A = 'a' B "b"i
+B = 'a' 'a' [a-z]
+C = "b" [a-z] .
+`)
+
+	tbl := ast.NewMatcherTable(g)
+	cases := []struct {
+		expr  ast.Expression
+		users []int
+	}{
+		{g.Rules[0].Expr.(*ast.SeqExpr).Exprs[0], []int{0, 1}},
+		{g.Rules[0].Expr.(*ast.SeqExpr).Exprs[2], []int{0}},
+		{g.Rules[1].Expr.(*ast.SeqExpr).Exprs[2], []int{1, 2}},
+		{g.Rules[2].Expr.(*ast.SeqExpr).Exprs[0], []int{2}},
+		{g.Rules[2].Expr.(*ast.SeqExpr).Exprs[2], []int{2}},
+	}
+	if len(tbl.Matchers) != len(cases) {
+		t.Fatalf("want %d distinct matchers, got %d", len(cases), len(tbl.Matchers))
+	}
+	for i, tc := range cases {
+		ix, ok := tbl.Index(tc.expr)
+		if !ok {
+			t.Errorf("%d: want %s in the table", i, tc.expr)
+			continue
+		}
+		if ix != i {
+			t.Errorf("%d: want index %d, got %d", i, i, ix)
+		}
+		if got := tbl.MatcherUsers(ix); !reflect.DeepEqual(got, tc.users) {
+			t.Errorf("%d: want users %v, got %v", i, tc.users, got)
+		}
+	}
+
+	if _, ok := tbl.Index(g.Rules[0].Expr); ok {
+		t.Errorf("want sequence not in the table")
+	}
+	if got := tbl.MatcherUsers(len(cases)); got != nil {
+		t.Errorf("want no user out of range, got %v", got)
+	}
+}
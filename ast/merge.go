@@ -0,0 +1,108 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MergeGrammars returns a new grammar with the rules of ext followed by
+// the rules of base, so that the rules of an extension grammar can
+// reference those of a base grammar. The first rule of ext is the start
+// rule of the merged grammar. The initializer is that of ext, or that of
+// base if ext has none, and likewise for the fields and the state.
+//
+// An error is returned if both grammars declare a rule with the same name,
+// or if both grammars have an initializer, fields or a state.
+func MergeGrammars(base, ext *Grammar) (*Grammar, error) {
+	if base.Init != nil && ext.Init != nil {
+		return nil, fmt.Errorf("%s: both grammars have an initializer", ext.Init.Pos())
+	}
+
+	if base.Fields != nil && ext.Fields != nil {
+		return nil, fmt.Errorf("%s: both grammars have fields", ext.Fields.Pos())
+	}
+	if base.State != nil && ext.State != nil {
+		return nil, fmt.Errorf("%s: both grammars have a state", ext.State.Pos())
+	}
+
+	names := make(map[string]*Rule, len(base.Rules))
+	for _, r := range base.Rules {
+		names[r.Name.Val] = r
+	}
+	for _, r := range ext.Rules {
+		if prev, ok := names[r.Name.Val]; ok {
+			return nil, fmt.Errorf("%s: rule %s already declared at %s", r.Pos(), r.Name.Val, prev.Pos())
+		}
+	}
+
+	g := NewGrammar(ext.Pos())
+	g.Init = ext.Init
+	if g.Init == nil {
+		g.Init = base.Init
+	}
+	g.Fields = ext.Fields
+	if g.Fields == nil {
+		g.Fields = base.Fields
+	}
+	g.State = ext.State
+	if g.State == nil {
+		g.State = base.State
+	}
+	g.Rules = make([]*Rule, 0, len(ext.Rules)+len(base.Rules))
+	g.Rules = append(g.Rules, ext.Rules...)
+	g.Rules = append(g.Rules, base.Rules...)
+	return g, nil
+}
+
+// MergeNamespace returns a new grammar with the rules of g followed by the
+// rules of sub in the namespace ns, so that the rules of g can reference
+// those of another grammar, e.g. of an embedded language, as ns::Rule. The
+// rules of sub are renamed in place to ns::Rule, and so are the references
+// to them that are not already qualified. They are not entrypoints of the
+// merged grammar. The initializer, the fields and the examples are those
+// of g: the code blocks of sub must not depend on its initializer.
+//
+// An error is returned if ns is not a valid identifier or if g already
+// declares a rule of the namespace.
+func MergeNamespace(g *Grammar, ns string, sub *Grammar) (*Grammar, error) {
+	if !isIdentifier(ns) {
+		return nil, fmt.Errorf("invalid namespace %q", ns)
+	}
+	names := make(map[string]*Rule, len(g.Rules))
+	for _, r := range g.Rules {
+		names[r.Name.Val] = r
+	}
+
+	prefix := ns + "::"
+	for _, r := range sub.Rules {
+		if prev, ok := names[prefix+r.Name.Val]; ok {
+			return nil, fmt.Errorf("%s: rule %s already declared at %s", r.Pos(), prefix+r.Name.Val, prev.Pos())
+		}
+	}
+	for _, r := range sub.Rules {
+		r.Name.Val = prefix + r.Name.Val
+		r.Entry = false
+		Walk(r.Expr, func(expr Expression) {
+			if ref, ok := expr.(*RuleRefExpr); ok && !strings.Contains(ref.Name.Val, "::") {
+				ref.Name.Val = prefix + ref.Name.Val
+			}
+		})
+	}
+
+	cp := *g
+	cp.Rules = make([]*Rule, 0, len(g.Rules)+len(sub.Rules))
+	cp.Rules = append(cp.Rules, g.Rules...)
+	cp.Rules = append(cp.Rules, sub.Rules...)
+	return &cp, nil
+}
+
+// isIdentifier returns true if s is a valid identifier of a rule.
+func isIdentifier(s string) bool {
+	for i, rn := range s {
+		if rn != '_' && !unicode.IsLetter(rn) && (i == 0 || !unicode.IsDigit(rn)) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,119 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestMergeGrammars(t *testing.T) {
+	base := parseGrammar(t, `A = 'a'`)
+	ext := parseGrammar(t, `B = A 'b'`)
+
+	g, err := ast.MergeGrammars(base, ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Rules) != 2 || g.Rules[0].Name.Val != "B" || g.Rules[1].Name.Val != "A" {
+		t.Fatalf("want rules [B A], got %v", g.Rules)
+	}
+	if g.Rules[1] != base.Rules[0] {
+		t.Errorf("want rule A of the base grammar")
+	}
+	if len(base.Rules) != 1 || len(ext.Rules) != 1 {
+		t.Errorf("want source grammars unchanged")
+	}
+}
+
+func TestMergeGrammarsCollision(t *testing.T) {
+	base := parseGrammar(t, "A = 'a'\nC = 'c'")
+	ext := parseGrammar(t, "B = A 'b'\nC = 'x'")
+
+	_, err := ast.MergeGrammars(base, ext)
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if !strings.Contains(err.Error(), "rule C already declared") {
+		t.Errorf("want rule collision error, got %v", err)
+	}
+}
+
+func TestMergeGrammarsFields(t *testing.T) {
+	base := parseGrammar(t, `A = 'a'`)
+	base.Fields = ast.NewCodeBlock(ast.Pos{}, "{ n int }")
+	ext := parseGrammar(t, `B = A 'b'`)
+
+	g, err := ast.MergeGrammars(base, ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Fields != base.Fields {
+		t.Errorf("want fields of the base grammar")
+	}
+
+	ext.Fields = ast.NewCodeBlock(ast.Pos{}, "{ m int }")
+	if _, err := ast.MergeGrammars(base, ext); err == nil {
+		t.Error("want error, got none")
+	}
+}
+
+func TestMergeGrammarsState(t *testing.T) {
+	base := parseGrammar(t, `A = 'a'`)
+	ext := parseGrammar(t, `B = A 'b'`)
+	ext.State = ast.NewCodeBlock(ast.Pos{}, "{ depth int }")
+
+	g, err := ast.MergeGrammars(base, ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.State != ext.State {
+		t.Errorf("want state of the extension grammar")
+	}
+
+	base.State = ast.NewCodeBlock(ast.Pos{}, "{ n int }")
+	if _, err := ast.MergeGrammars(base, ext); err == nil {
+		t.Error("want error, got none")
+	}
+}
+
+func TestMergeNamespace(t *testing.T) {
+	g := parseGrammar(t, `A = 'a'`)
+	sub := parseGrammar(t, "Expr = Term ( '+' Term )*\nTerm = [0-9]+")
+	sub.Rules[0].Entry = true
+
+	merged, err := ast.MergeNamespace(g, "math", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range merged.Rules {
+		names = append(names, r.Name.Val)
+	}
+	if want := "A math::Expr math::Term"; strings.Join(names, " ") != want {
+		t.Fatalf("want rules %s, got %v", want, names)
+	}
+	if merged.Rules[1].Entry {
+		t.Errorf("want the rules of the namespace not to be entrypoints")
+	}
+	var refs []string
+	ast.Walk(merged.Rules[1].Expr, func(expr ast.Expression) {
+		if ref, ok := expr.(*ast.RuleRefExpr); ok {
+			refs = append(refs, ref.Name.Val)
+		}
+	})
+	if want := "math::Term math::Term"; strings.Join(refs, " ") != want {
+		t.Errorf("want references %s, got %v", want, refs)
+	}
+	if len(g.Rules) != 1 {
+		t.Errorf("want the main grammar unchanged")
+	}
+
+	if _, err := ast.MergeNamespace(merged, "math", parseGrammar(t, `Term = 'x'`)); err == nil ||
+		!strings.Contains(err.Error(), "rule math::Term already declared") {
+		t.Errorf("want rule collision error, got %v", err)
+	}
+	if _, err := ast.MergeNamespace(g, "1x", parseGrammar(t, `B = 'b'`)); err == nil {
+		t.Errorf("want invalid namespace error, got none")
+	}
+}
@@ -0,0 +1,371 @@
+package ast
+
+// RuleMetrics holds complexity metrics for a rule of the grammar.
+type RuleMetrics struct {
+	// Name is the name of the rule.
+	Name string
+
+	// Alternatives is the number of alternatives of the rule's top-level
+	// choice expression, 1 if the rule's expression is not a choice.
+	Alternatives int
+
+	// MaxSeqLen is the number of expressions of the longest sequence
+	// in the rule, 1 if the rule has no sequence expression.
+	MaxSeqLen int
+
+	// Depth is the maximum nesting depth of the rule's expression, a
+	// rule that is a single matcher has a depth of 1.
+	Depth int
+
+	// Recursive is true if the rule may reference itself, directly or
+	// through other rules.
+	Recursive bool
+
+	// LeftRecursive is true if the rule may reference itself without
+	// consuming any input, which would result in an infinite recursion.
+	LeftRecursive bool
+
+	// TailRecursive is true if the rule references itself at the end of
+	// some of its alternatives, so that the generated parser matches it as
+	// a loop instead of nested calls. See TailRecursive.
+	TailRecursive bool
+}
+
+// Metrics returns the complexity metrics of each rule of the grammar, in
+// the same order as the rules of the grammar.
+func Metrics(g *Grammar) []*RuleMetrics {
+	nullable := nullableRules(g)
+	refs := make(map[string][]string, len(g.Rules))
+	leftRefs := make(map[string][]string, len(g.Rules))
+	for _, r := range g.Rules {
+		refs[r.Name.Val] = ruleRefs(r.Expr)
+		leftRefs[r.Name.Val] = leftRuleRefs(r.Expr, nullable)
+	}
+
+	metrics := make([]*RuleMetrics, 0, len(g.Rules))
+	for _, r := range g.Rules {
+		m := &RuleMetrics{
+			Name:          r.Name.Val,
+			Alternatives:  1,
+			MaxSeqLen:     1,
+			Depth:         depth(r.Expr),
+			Recursive:     reaches(refs, r.Name.Val, r.Name.Val),
+			LeftRecursive: reaches(leftRefs, r.Name.Val, r.Name.Val),
+			TailRecursive: TailRecursive(r),
+		}
+		if ch, ok := r.Expr.(*ChoiceExpr); ok {
+			m.Alternatives = len(ch.Alternatives)
+		}
+		Walk(r.Expr, func(expr Expression) {
+			if seq, ok := expr.(*SeqExpr); ok && len(seq.Exprs) > m.MaxSeqLen {
+				m.MaxSeqLen = len(seq.Exprs)
+			}
+		})
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// TailRecursive returns true if the rule r is a choice with an alternative
+// that ends with a reference to r, optionally labeled, in a sequence that
+// may be the expression of an action, and an alternative that does not,
+// e.g. List = Item ',' List / Item.
+func TailRecursive(r *Rule) bool {
+	ch, ok := r.Expr.(*ChoiceExpr)
+	if !ok || ch.Longest {
+		return false
+	}
+	var tail, base bool
+	for _, alt := range ch.Alternatives {
+		if tailCall(r, alt) {
+			tail = true
+		} else {
+			base = true
+		}
+	}
+	return tail && base
+}
+
+// tailCall returns true if the alternative alt of the rule r is a sequence
+// that ends with a reference to r, optionally labeled, or an action of such
+// a sequence.
+func tailCall(r *Rule, alt Expression) bool {
+	if act, ok := alt.(*ActionExpr); ok {
+		alt = act.Expr
+	}
+	seq, ok := alt.(*SeqExpr)
+	if !ok || len(seq.Exprs) < 2 {
+		return false
+	}
+	last := seq.Exprs[len(seq.Exprs)-1]
+	if lab, ok := last.(*LabeledExpr); ok {
+		last = lab.Expr
+	}
+	ref, ok := last.(*RuleRefExpr)
+	return ok && ref.Name.Val == r.Name.Val
+}
+
+// MatchesEmpty returns true if the rule named name can match the empty
+// string, directly or through the rules it references. It returns false
+// if the grammar has no such rule.
+func MatchesEmpty(g *Grammar, name string) bool {
+	return nullableRules(g)[name]
+}
+
+// EmptyLoops returns the zero-or-more and one-or-more expressions of the
+// grammar whose expression can match the empty string, and the separated
+// list expressions whose expression and separator can both match the empty
+// string, in the order of the rules. Such a repetition never terminates.
+func EmptyLoops(g *Grammar) []Expression {
+	nullable := nullableRules(g)
+	var loops []Expression
+	for _, r := range g.Rules {
+		loops = append(loops, emptyLoops(r.Expr, nullable)...)
+	}
+	return loops
+}
+
+// emptyLoops returns the repetitions in expr that never terminate, given
+// the set of nullable rules.
+func emptyLoops(expr Expression, nullable map[string]bool) []Expression {
+	var loops []Expression
+	Walk(expr, func(expr Expression) {
+		switch expr := expr.(type) {
+		case *ZeroOrMoreExpr:
+			if isNullable(expr.Expr, nullable) {
+				loops = append(loops, expr)
+			}
+		case *OneOrMoreExpr:
+			if isNullable(expr.Expr, nullable) {
+				loops = append(loops, expr)
+			}
+		case *MapExpr:
+			if isNullable(expr.Expr, nullable) {
+				loops = append(loops, expr)
+			}
+		case *SepExpr:
+			if isNullable(expr.Expr, nullable) && isNullable(expr.Sep, nullable) {
+				loops = append(loops, expr)
+			}
+		}
+	})
+	return loops
+}
+
+// Children returns the direct sub-expressions of expr, in the order in
+// which they are parsed. The code blocks are not expressions and are not
+// returned.
+func Children(expr Expression) []Expression {
+	return children(expr)
+}
+
+// children returns the direct sub-expressions of expr.
+func children(expr Expression) []Expression {
+	switch expr := expr.(type) {
+	case *ActionExpr:
+		return []Expression{expr.Expr}
+	case *AndExpr:
+		return []Expression{expr.Expr}
+	case *ChoiceExpr:
+		return expr.Alternatives
+	case *CompactExpr:
+		return []Expression{expr.Expr}
+	case *ArrayExpr:
+		return []Expression{expr.Expr}
+	case *MapExpr:
+		return []Expression{expr.Expr}
+	case *FoldExpr:
+		return []Expression{expr.Expr}
+	case *IfExpr:
+		return []Expression{expr.Expr}
+	case *LabeledExpr:
+		return []Expression{expr.Expr}
+	case *LookbehindExpr:
+		return []Expression{expr.Expr}
+	case *NotExpr:
+		return []Expression{expr.Expr}
+	case *OneOrMoreExpr:
+		return []Expression{expr.Expr}
+	case *OperatorsExpr:
+		return []Expression{expr.Operand}
+	case *SepExpr:
+		return []Expression{expr.Expr, expr.Sep}
+	case *SeqExpr:
+		return expr.Exprs
+	case *TrimExpr:
+		return []Expression{expr.Expr}
+	case *ConvertExpr:
+		return []Expression{expr.Expr}
+	case *UnreservedExpr:
+		return []Expression{expr.Expr}
+	case *VerbatimExpr:
+		return []Expression{expr.Expr}
+	case *WhenExpr:
+		return []Expression{expr.Expr}
+	case *ZeroOrMoreExpr:
+		return []Expression{expr.Expr}
+	case *ZeroOrOneExpr:
+		return []Expression{expr.Expr}
+	}
+	return nil
+}
+
+// Walk calls fn for expr and all its sub-expressions, depth-first. The
+// code blocks of the expressions are not visited.
+func Walk(expr Expression, fn func(Expression)) {
+	if expr == nil {
+		return
+	}
+	fn(expr)
+	for _, sub := range children(expr) {
+		Walk(sub, fn)
+	}
+}
+
+func depth(expr Expression) int {
+	max := 0
+	for _, sub := range children(expr) {
+		if d := depth(sub); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
+
+// ruleRefs returns the names of the rules referenced by expr.
+func ruleRefs(expr Expression) []string {
+	var names []string
+	Walk(expr, func(expr Expression) {
+		if ref, ok := expr.(*RuleRefExpr); ok {
+			names = append(names, ref.Name.Val)
+		}
+	})
+	return names
+}
+
+// leftRuleRefs returns the names of the rules that may be referenced by
+// expr at the starting position of expr, before any input is consumed.
+func leftRuleRefs(expr Expression, nullable map[string]bool) []string {
+	switch expr := expr.(type) {
+	case *RuleRefExpr:
+		return []string{expr.Name.Val}
+	case *SeqExpr:
+		var names []string
+		for _, sub := range expr.Exprs {
+			names = append(names, leftRuleRefs(sub, nullable)...)
+			if !isNullable(sub, nullable) {
+				break
+			}
+		}
+		return names
+	default:
+		var names []string
+		for _, sub := range children(expr) {
+			names = append(names, leftRuleRefs(sub, nullable)...)
+		}
+		return names
+	}
+}
+
+// reaches returns true if the rule named to can be reached by following
+// the graph of rule references from the rule named from.
+func reaches(graph map[string][]string, from, to string) bool {
+	seen := make(map[string]bool)
+	var visit func(string) bool
+	visit = func(nm string) bool {
+		for _, next := range graph[nm] {
+			if next == to {
+				return true
+			}
+			if !seen[next] {
+				seen[next] = true
+				if visit(next) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+// nullableRules returns the set of rules that can match the empty string,
+// computed as a fixpoint over the rule references.
+func nullableRules(g *Grammar) map[string]bool {
+	nullable := make(map[string]bool, len(g.Rules))
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			if !nullable[r.Name.Val] && isNullable(r.Expr, nullable) {
+				nullable[r.Name.Val] = true
+				changed = true
+			}
+		}
+	}
+	return nullable
+}
+
+// isNullable returns true if expr can match the empty string, given the
+// set of nullable rules.
+func isNullable(expr Expression, nullable map[string]bool) bool {
+	switch expr := expr.(type) {
+	case *ActionExpr:
+		return isNullable(expr.Expr, nullable)
+	case *AndCodeExpr, *AndExpr, *BackRefExpr, *IndentMatcher, *LookbehindExpr,
+		*MapExpr, *NotCodeExpr, *NotExpr, *RestOfLineMatcher, *SeenExpr, *UntilMatcher, *ZeroOrMoreExpr, *ZeroOrOneExpr:
+		return true
+	case *AnyMatcher, *ByteMatcher, *CharClassMatcher, *KeywordMatcher, *NestedMatcher,
+		*NumberMatcher, *TableMatcher, *TokenMatcher, *WordListMatcher:
+		return false
+	case *BytesMatcher:
+		return expr.Label != nil || expr.N == 0
+	case *ChoiceExpr:
+		for _, alt := range expr.Alternatives {
+			if isNullable(alt, nullable) {
+				return true
+			}
+		}
+		return false
+	case *CompactExpr:
+		return isNullable(expr.Expr, nullable)
+	case *TrimExpr:
+		return isNullable(expr.Expr, nullable)
+	case *ConvertExpr:
+		return isNullable(expr.Expr, nullable)
+	case *ArrayExpr:
+		return expr.N == 0 || isNullable(expr.Expr, nullable)
+	case *FoldExpr:
+		return isNullable(expr.Expr, nullable)
+	case *IfExpr:
+		return isNullable(expr.Expr, nullable)
+	case *LabeledExpr:
+		return isNullable(expr.Expr, nullable)
+	case *LitMatcher:
+		return expr.Val == ""
+	case *OneOrMoreExpr:
+		return isNullable(expr.Expr, nullable)
+	case *OperatorsExpr:
+		return isNullable(expr.Operand, nullable)
+	case *RuleRefExpr:
+		return nullable[expr.Name.Val]
+	case *SepExpr:
+		if expr.Terminated {
+			return isNullable(expr.Expr, nullable) && isNullable(expr.Sep, nullable)
+		}
+		return isNullable(expr.Expr, nullable)
+	case *SeqExpr:
+		for _, sub := range expr.Exprs {
+			if !isNullable(sub, nullable) {
+				return false
+			}
+		}
+		return true
+	case *UnreservedExpr:
+		return isNullable(expr.Expr, nullable)
+	case *VerbatimExpr:
+		return isNullable(expr.Expr, nullable)
+	case *WhenExpr:
+		return isNullable(expr.Expr, nullable)
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+	"github.com/craiggwilson/pigeon/bootstrap"
+)
+
+func parseGrammar(t *testing.T, src string) *ast.Grammar {
+	g, err := bootstrap.NewParser().Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestMetrics(t *testing.T) {
+	g := parseGrammar(t, `
+A = A 'a' / 'b'
+B = 'x' B / 'y'
+C = D 'c' / ( 'e' ( 'f' 'g' 'h' )* )
+D = E? C
+E = 'e'
+`)
+
+	want := []ast.RuleMetrics{
+		{Name: "A", Alternatives: 2, MaxSeqLen: 2, Depth: 3, Recursive: true, LeftRecursive: true},
+		{Name: "B", Alternatives: 2, MaxSeqLen: 2, Depth: 3, Recursive: true, LeftRecursive: false, TailRecursive: true},
+		{Name: "C", Alternatives: 2, MaxSeqLen: 3, Depth: 5, Recursive: true, LeftRecursive: true},
+		{Name: "D", Alternatives: 1, MaxSeqLen: 2, Depth: 3, Recursive: true, LeftRecursive: true},
+		{Name: "E", Alternatives: 1, MaxSeqLen: 1, Depth: 1, Recursive: false, LeftRecursive: false},
+	}
+	got := ast.Metrics(g)
+	if len(got) != len(want) {
+		t.Fatalf("want %d rule metrics, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if *got[i] != w {
+			t.Errorf("%s: want %+v, got %+v", w.Name, w, *got[i])
+		}
+	}
+}
+
+func TestTailRecursive(t *testing.T) {
+	g := parseGrammar(t, `
+A = 'a' A / 'a'
+B = x:'b' rest:B { return nil, nil } / 'b'
+C = 'c' C
+D = 'd' D 'd' / 'd'
+E = E 'e' / 'e'
+F = 'f' ( ',' F )?
+G = 'g' / 'g' A
+`)
+
+	cases := map[string]bool{
+		"A": true,
+		"B": true,
+		"C": false,
+		"D": false,
+		"E": false,
+		"F": false,
+		"G": false,
+	}
+	for _, r := range g.Rules {
+		if got := ast.TailRecursive(r); got != cases[r.Name.Val] {
+			t.Errorf("%s: want %t, got %t", r.Name.Val, cases[r.Name.Val], got)
+		}
+	}
+}
+
+func TestMatchesEmpty(t *testing.T) {
+	g := parseGrammar(t, `
+A = 'a'?
+B = 'a'
+C = D 'c'?
+D = A B*
+E = B / C
+F = 'x' F / B
+G = G 'g'
+`)
+
+	cases := map[string]bool{
+		"A": true,
+		"B": false,
+		"C": true,
+		"D": true,
+		"E": true,
+		"F": false,
+		"G": false,
+		"H": false,
+	}
+	for nm, want := range cases {
+		if got := ast.MatchesEmpty(g, nm); got != want {
+			t.Errorf("%s: want %t, got %t", nm, want, got)
+		}
+	}
+}
+
+func TestEmptyLoops(t *testing.T) {
+	g := parseGrammar(t, `
+A = B* ( 'a' / C )+ 'b'*
+B = 'b'?
+C = D
+D = &'d'
+`)
+
+	loops := ast.EmptyLoops(g)
+	if len(loops) != 2 {
+		t.Fatalf("want 2 empty loops, got %d", len(loops))
+	}
+	if _, ok := loops[0].(*ast.ZeroOrMoreExpr); !ok || loops[0].Pos().Col != 5 {
+		t.Errorf("want B* as first loop, got %s", loops[0])
+	}
+	if _, ok := loops[1].(*ast.OneOrMoreExpr); !ok || loops[1].Pos().Col != 10 {
+		t.Errorf("want ( 'a' / C )+ as second loop, got %s", loops[1])
+	}
+}
@@ -0,0 +1,52 @@
+package ast
+
+import "strconv"
+
+// RuleSignature describes a rule of the grammar, for tools that list the
+// rules without walking the AST, such as editors.
+type RuleSignature struct {
+	// Name is the name of the rule.
+	Name string
+
+	// DisplayName is the unquoted display name of the rule, empty if the
+	// rule has none.
+	DisplayName string
+
+	// Pos is the starting position of the rule and End the position
+	// following its expression. End is the zero value if the parser did
+	// not record it.
+	Pos, End Pos
+}
+
+// Signatures returns the signature of each rule of the grammar, in the
+// same order as the rules of the grammar.
+func Signatures(g *Grammar) []*RuleSignature {
+	sigs := make([]*RuleSignature, 0, len(g.Rules))
+	for _, r := range g.Rules {
+		sig := &RuleSignature{
+			Name: r.Name.Val,
+			Pos:  r.Pos(),
+			End:  r.End,
+		}
+		if r.DisplayName != nil {
+			sig.DisplayName = r.DisplayName.Val
+			if s, err := strconv.Unquote(sig.DisplayName); err == nil {
+				sig.DisplayName = s
+			}
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
+
+// RuleIndex returns the index in the rules of the grammar of the first rule
+// named name, so that tools can go to the rule without walking the
+// grammar. It returns false if the grammar has no such rule.
+func RuleIndex(g *Grammar, name string) (int, bool) {
+	for i, r := range g.Rules {
+		if r.Name != nil && r.Name.Val == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,40 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestSignatures(t *testing.T) {
+	g := parseGrammar(t, "A \"alpha\" = 'a'\nB = A 'b'\n")
+
+	want := []ast.RuleSignature{
+		{Name: "A", DisplayName: "alpha", Pos: ast.Pos{Line: 1, Col: 1, Off: 0}},
+		{Name: "B", Pos: ast.Pos{Line: 2, Col: 1, Off: 16}},
+	}
+	got := ast.Signatures(g)
+	if len(got) != len(want) {
+		t.Fatalf("want %d signatures, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if *got[i] != w {
+			t.Errorf("%d: want %+v, got %+v", i, w, *got[i])
+		}
+	}
+}
+
+func TestRuleIndex(t *testing.T) {
+	g := parseGrammar(t, "S = B\nB = A 'b'\nC = 'c'\nA = 'a'\n")
+
+	cases := map[string]int{"S": 0, "B": 1, "C": 2, "A": 3}
+	for nm, want := range cases {
+		got, ok := ast.RuleIndex(g, nm)
+		if !ok || got != want {
+			t.Errorf("%s: want %d, true, got %d, %t", nm, want, got, ok)
+		}
+	}
+	if got, ok := ast.RuleIndex(g, "D"); ok || got != -1 {
+		t.Errorf("D: want -1, false, got %d, %t", got, ok)
+	}
+}
@@ -0,0 +1,319 @@
+package ast
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TerminalSet is a FIRST or FOLLOW set of a rule of the grammar.
+type TerminalSet struct {
+	// Terminals is the matchers of the set, in the order of their first
+	// occurrence in the grammar. Matchers of the same kind and with the
+	// same value are listed once.
+	Terminals []Expression
+
+	// Empty is true in a FIRST set if the rule can match the empty
+	// string.
+	Empty bool
+
+	// EOF is true in a FOLLOW set if the end of the input can follow the
+	// rule.
+	EOF bool
+
+	keys map[string]bool
+}
+
+// Has returns true if the set has a matcher of the same kind and with the
+// same value as expr.
+func (s *TerminalSet) Has(expr Expression) bool {
+	key, _ := matcherKey(expr)
+	return s.keys[key]
+}
+
+// String returns the textual representation of the set, e.g. {"a", [0-9],
+// EOF}.
+func (s *TerminalSet) String() string {
+	var parts []string
+	for _, t := range s.Terminals {
+		parts = append(parts, terminalString(t))
+	}
+	if s.Empty {
+		parts = append(parts, "ε")
+	}
+	if s.EOF {
+		parts = append(parts, "EOF")
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// add adds the matchers of o to the set, and returns true if it changed.
+func (s *TerminalSet) add(o *TerminalSet) bool {
+	changed := false
+	for _, t := range o.Terminals {
+		if s.addTerminal(t) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// addTerminal adds the matcher expr to the set, and returns true if it
+// was not in it.
+func (s *TerminalSet) addTerminal(expr Expression) bool {
+	key, _ := matcherKey(expr)
+	if s.keys[key] {
+		return false
+	}
+	if s.keys == nil {
+		s.keys = make(map[string]bool)
+	}
+	s.keys[key] = true
+	s.Terminals = append(s.Terminals, expr)
+	return true
+}
+
+// terminalString returns the textual representation of the matcher expr
+// in a set.
+func terminalString(expr Expression) string {
+	switch expr := expr.(type) {
+	case *LitMatcher:
+		s := strconv.Quote(expr.Val)
+		if expr.IgnoreCase {
+			s += "i"
+		}
+		return s
+	case *CharClassMatcher:
+		return expr.Val
+	case *AnyMatcher:
+		return "."
+	}
+	key, _ := matcherKey(expr)
+	return key
+}
+
+// FirstSets returns the FIRST set of each rule of the grammar, by rule
+// name: the matchers that can match the start of a match of the rule,
+// computed as a fixpoint over the rule references. The set is Empty if
+// the rule can match the empty string, as for MatchesEmpty. The
+// predicates and the code blocks consume no input and add no matcher; the
+// matchers that may match the empty string, such as the indentation
+// matchers, are in the set only if they can also consume input.
+func FirstSets(g *Grammar) map[string]*TerminalSet {
+	nullable := nullableRules(g)
+	first := make(map[string]*TerminalSet, len(g.Rules))
+	for _, r := range g.Rules {
+		first[r.Name.Val] = &TerminalSet{Empty: nullable[r.Name.Val]}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			if first[r.Name.Val].add(firstSet(r.Expr, first, nullable)) {
+				changed = true
+			}
+		}
+	}
+	sortSets(g, first)
+	return first
+}
+
+// FollowSets returns the FOLLOW set of each rule of the grammar, by rule
+// name: the matchers that can match the input that follows a match of the
+// rule, computed as a fixpoint over the rule references. A nullable rule
+// is transparent, so that the matchers that follow it follow the rule
+// before it too. The end of the input follows the first rule, the
+// entrypoint rules, and the rules at the end of the rules that it follows.
+// The rules referenced in a predicate are not followed by anything from
+// that reference, as the predicate does not consume their match.
+func FollowSets(g *Grammar) map[string]*TerminalSet {
+	nullable := nullableRules(g)
+	first := FirstSets(g)
+	follow := make(map[string]*TerminalSet, len(g.Rules))
+	for i, r := range g.Rules {
+		follow[r.Name.Val] = &TerminalSet{EOF: i == 0 || r.Entry}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			fw := &followWalker{first: first, follow: follow, nullable: nullable, rule: r.Name.Val}
+			fw.walk(r.Expr, &TerminalSet{}, true)
+			if fw.changed {
+				changed = true
+			}
+		}
+	}
+	sortSets(g, follow)
+	return follow
+}
+
+// firstSet returns the matchers that can match the start of expr, given
+// the FIRST sets of the rules computed so far.
+func firstSet(expr Expression, first map[string]*TerminalSet, nullable map[string]bool) *TerminalSet {
+	set := &TerminalSet{}
+	switch expr := expr.(type) {
+	case *AndCodeExpr, *AndExpr, *BackRefExpr, *IndentMatcher, *LookbehindExpr, *NotCodeExpr, *NotExpr, *SeenExpr:
+	case *ChoiceExpr:
+		for _, alt := range expr.Alternatives {
+			set.add(firstSet(alt, first, nullable))
+		}
+	case *LitMatcher:
+		if expr.Val != "" {
+			set.addTerminal(expr)
+		}
+	case *OperatorsExpr:
+		set.add(firstSet(expr.Operand, first, nullable))
+	case *RuleRefExpr:
+		if f := first[expr.Name.Val]; f != nil {
+			set.add(f)
+		}
+	case *SepExpr:
+		set.add(firstSet(expr.Expr, first, nullable))
+		if isNullable(expr.Expr, nullable) {
+			set.add(firstSet(expr.Sep, first, nullable))
+		}
+	case *SeqExpr:
+		for _, sub := range expr.Exprs {
+			set.add(firstSet(sub, first, nullable))
+			if !isNullable(sub, nullable) {
+				break
+			}
+		}
+	default:
+		if _, ok := matcherKey(expr); ok {
+			set.addTerminal(expr)
+			break
+		}
+		for _, sub := range children(expr) {
+			set.add(firstSet(sub, first, nullable))
+		}
+	}
+	return set
+}
+
+// followWalker adds to the FOLLOW sets the matchers that follow the rule
+// references of a rule.
+type followWalker struct {
+	first    map[string]*TerminalSet
+	follow   map[string]*TerminalSet
+	nullable map[string]bool
+	rule     string
+	changed  bool
+}
+
+// walk walks expr, which is followed by the matchers of after, and by the
+// FOLLOW set of the rule if atEnd is true.
+func (fw *followWalker) walk(expr Expression, after *TerminalSet, atEnd bool) {
+	switch expr := expr.(type) {
+	case *AndExpr, *NotExpr, *LookbehindExpr:
+		// the predicates do not consume their match
+	case *RuleRefExpr:
+		f := fw.follow[expr.Name.Val]
+		if f == nil {
+			return
+		}
+		if f.add(after) {
+			fw.changed = true
+		}
+		if atEnd {
+			own := fw.follow[fw.rule]
+			if f.add(own) {
+				fw.changed = true
+			}
+			if own.EOF && !f.EOF {
+				f.EOF = true
+				fw.changed = true
+			}
+		}
+	case *OneOrMoreExpr:
+		fw.walk(expr.Expr, fw.union(fw.firstOf(expr.Expr), after), atEnd)
+	case *ZeroOrMoreExpr:
+		fw.walk(expr.Expr, fw.union(fw.firstOf(expr.Expr), after), atEnd)
+	case *MapExpr:
+		fw.walk(expr.Expr, fw.union(fw.firstOf(expr.Expr), after), atEnd)
+	case *OperatorsExpr:
+		ops := &TerminalSet{}
+		for _, op := range expr.Operators {
+			ops.addTerminal(op.Lit)
+		}
+		fw.walk(expr.Operand, fw.union(ops, after), atEnd)
+	case *SepExpr:
+		sep := fw.firstOf(expr.Sep)
+		if expr.Terminated {
+			// each expression is followed by a terminator, that is followed
+			// by the next expression or by the end of the list
+			exprAfter, exprAtEnd := sep, false
+			if fw.isNullable(expr.Sep) {
+				exprAfter, exprAtEnd = fw.union(sep, after), atEnd
+			}
+			fw.walk(expr.Expr, exprAfter, exprAtEnd)
+			fw.walk(expr.Sep, fw.union(fw.firstOf(expr.Expr), after), atEnd)
+			break
+		}
+		fw.walk(expr.Expr, fw.union(sep, after), atEnd)
+		fw.walk(expr.Sep, fw.firstOf(expr.Expr), atEnd && fw.isNullable(expr.Expr))
+	case *SeqExpr:
+		for i := len(expr.Exprs) - 1; i >= 0; i-- {
+			sub := expr.Exprs[i]
+			fw.walk(sub, after, atEnd)
+			if fw.isNullable(sub) {
+				after = fw.union(fw.firstOf(sub), after)
+			} else {
+				after, atEnd = fw.firstOf(sub), false
+			}
+		}
+	default:
+		for _, sub := range children(expr) {
+			fw.walk(sub, after, atEnd)
+		}
+	}
+}
+
+func (fw *followWalker) firstOf(expr Expression) *TerminalSet {
+	return firstSet(expr, fw.first, fw.nullable)
+}
+
+func (fw *followWalker) isNullable(expr Expression) bool {
+	return isNullable(expr, fw.nullable)
+}
+
+// union returns a new set with the matchers of a and b.
+func (fw *followWalker) union(a, b *TerminalSet) *TerminalSet {
+	set := &TerminalSet{}
+	set.add(a)
+	set.add(b)
+	return set
+}
+
+// sortSets sorts the matchers of the sets in the order of their first
+// occurrence in the grammar, so that the sets do not depend on the order
+// of the fixpoint iterations.
+func sortSets(g *Grammar, sets map[string]*TerminalSet) {
+	index := make(map[string]int)
+	for _, r := range g.Rules {
+		Walk(r.Expr, func(expr Expression) {
+			var exprs []Expression
+			if ops, ok := expr.(*OperatorsExpr); ok {
+				for _, op := range ops.Operators {
+					exprs = append(exprs, op.Lit)
+				}
+			} else {
+				exprs = append(exprs, expr)
+			}
+			for _, e := range exprs {
+				if key, ok := matcherKey(e); ok {
+					if _, seen := index[key]; !seen {
+						index[key] = len(index)
+					}
+				}
+			}
+		})
+	}
+	for _, set := range sets {
+		terms := set.Terminals
+		sort.SliceStable(terms, func(i, j int) bool {
+			ki, _ := matcherKey(terms[i])
+			kj, _ := matcherKey(terms[j])
+			return index[ki] < index[kj]
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestFirstSets(t *testing.T) {
+	g := parseGrammar(t, `
+A = 'a' / 'b'
+B = C 'c' / 'd'
+C = 'e'? [0-9]*
+D = &'x' 'y' / !. 'z'
+`)
+
+	want := map[string]string{
+		"A": `{"a", "b"}`,
+		"B": `{"c", "d", "e", [0-9]}`,
+		"C": `{"e", [0-9], ε}`,
+		"D": `{"y", "z"}`,
+	}
+	got := ast.FirstSets(g)
+	for name, w := range want {
+		if s := got[name].String(); s != w {
+			t.Errorf("%s: want FIRST %s, got %s", name, w, s)
+		}
+	}
+}
+
+func TestFollowSets(t *testing.T) {
+	g := parseGrammar(t, `
+S = A B 'c' / L
+A = 'a'?
+B = 'b'*
+L = ( I ',' )* I
+I = 'i' A
+`)
+
+	want := map[string]string{
+		"S": `{EOF}`,
+		"A": `{"c", "b", ",", EOF}`,
+		"B": `{"c"}`,
+		"L": `{EOF}`,
+		"I": `{",", EOF}`,
+	}
+	got := ast.FollowSets(g)
+	for name, w := range want {
+		if s := got[name].String(); s != w {
+			t.Errorf("%s: want FOLLOW %s, got %s", name, w, s)
+		}
+	}
+
+	first := ast.FirstSets(g)
+	if s := first["S"].String(); s != `{"c", "a", "b", "i"}` {
+		t.Errorf("S: want FIRST {\"c\", \"a\", \"b\", \"i\"}, got %s", s)
+	}
+}
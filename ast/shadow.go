@@ -0,0 +1,174 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Shadow describes an alternative of a choice expression that is never
+// tried on the input it could match, because a preceding alternative
+// always matches a literal that is a prefix of the alternative's literal.
+type Shadow struct {
+	// Rule is the name of the rule of the choice expression.
+	Rule string
+
+	// Alt is the shadowed alternative, and Index its 0-based index in
+	// the choice expression.
+	Alt   Expression
+	Index int
+
+	// By is the preceding alternative that shadows Alt, and ByIndex its
+	// 0-based index in the choice expression.
+	By      Expression
+	ByIndex int
+
+	altLit, byLit string
+}
+
+// String returns the warning message for the shadowed alternative.
+func (s *Shadow) String() string {
+	return fmt.Sprintf("%s: rule %s: alternative %d (%s) is shadowed by alternative %d (%s), which matches a prefix of it",
+		s.Alt.Pos(), s.Rule, s.Index+1, s.altLit, s.ByIndex+1, s.byLit)
+}
+
+// Shadows returns the alternatives of the choice expressions of the
+// grammar that are shadowed by a preceding alternative, in the order of
+// the rules. The analysis is limited to the literals that the
+// alternatives start with, following the references to other rules, so
+// that it only reports alternatives that are certainly shadowed.
+func Shadows(g *Grammar) []*Shadow {
+	rules := make(map[string]*Rule, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = r
+	}
+
+	var shadows []*Shadow
+	for _, r := range g.Rules {
+		Walk(r.Expr, func(expr Expression) {
+			ch, ok := expr.(*ChoiceExpr)
+			if !ok || ch.Longest {
+				return
+			}
+			prefixes := make([]litPrefix, len(ch.Alternatives))
+			for i, alt := range ch.Alternatives {
+				prefixes[i] = prefixOf(alt, rules, make(map[string]bool))
+			}
+			for j, alt := range ch.Alternatives {
+				for i := 0; i < j; i++ {
+					if prefixes[i].shadows(prefixes[j]) {
+						shadows = append(shadows, &Shadow{
+							Rule:    r.Name.Val,
+							Alt:     alt,
+							Index:   j,
+							By:      ch.Alternatives[i],
+							ByIndex: i,
+							altLit:  prefixes[j].String(),
+							byLit:   prefixes[i].String(),
+						})
+						break
+					}
+				}
+			}
+		})
+	}
+	return shadows
+}
+
+// RulePrefix returns the literal that all the matches of the rule name
+// start with, following the references to other rules, and whether that
+// literal is case-insensitive. The last value is false if the rule is not
+// declared or if its matches do not start with a non-empty literal.
+func RulePrefix(g *Grammar, name string) (string, bool, bool) {
+	rules := make(map[string]*Rule, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = r
+	}
+	r := rules[name]
+	if r == nil {
+		return "", false, false
+	}
+	p := prefixOf(r.Expr, rules, map[string]bool{name: true})
+	if !p.known || p.val == "" {
+		return "", false, false
+	}
+	return p.val, p.ignoreCase, true
+}
+
+// litPrefix is the literal that all the matches of an expression start
+// with.
+type litPrefix struct {
+	val        string
+	ignoreCase bool
+	// known is true if the expression starts with a literal, and complete
+	// is true if the expression always matches exactly that literal.
+	known    bool
+	complete bool
+}
+
+// shadows returns true if an expression with prefix p that precedes an
+// expression with prefix q in a choice always matches before q is tried.
+func (p litPrefix) shadows(q litPrefix) bool {
+	if !p.complete || !q.known {
+		return false
+	}
+	if p.ignoreCase {
+		return strings.HasPrefix(strings.ToLower(q.val), strings.ToLower(p.val))
+	}
+	return !q.ignoreCase && strings.HasPrefix(q.val, p.val)
+}
+
+func (p litPrefix) String() string {
+	s := strconv.Quote(p.val)
+	if p.ignoreCase {
+		s += "i"
+	}
+	if !p.complete {
+		s += "..."
+	}
+	return s
+}
+
+// prefixOf returns the literal prefix of expr, following the references
+// to the rules that are not in seen.
+func prefixOf(expr Expression, rules map[string]*Rule, seen map[string]bool) litPrefix {
+	switch expr := expr.(type) {
+	case *ActionExpr:
+		return prefixOf(expr.Expr, rules, seen)
+	case *FoldExpr:
+		return prefixOf(expr.Expr, rules, seen)
+	case *SepExpr:
+		p := prefixOf(expr.Expr, rules, seen)
+		p.complete = false
+		return p
+	case *LabeledExpr:
+		return prefixOf(expr.Expr, rules, seen)
+	case *LitMatcher:
+		return litPrefix{val: expr.Val, ignoreCase: expr.IgnoreCase, known: true, complete: true}
+	case *RuleRefExpr:
+		r := rules[expr.Name.Val]
+		if r == nil || seen[r.Name.Val] || r.Cond != nil {
+			return litPrefix{}
+		}
+		seen[r.Name.Val] = true
+		return prefixOf(r.Expr, rules, seen)
+	case *SeqExpr:
+		var p litPrefix
+		for i, sub := range expr.Exprs {
+			q := prefixOf(sub, rules, seen)
+			if !q.known || (i > 0 && q.ignoreCase != p.ignoreCase) {
+				p.complete = false
+				return p
+			}
+			p.val += q.val
+			p.ignoreCase = q.ignoreCase
+			p.known = true
+			if !q.complete {
+				return p
+			}
+		}
+		p.complete = p.known
+		return p
+	}
+	return litPrefix{}
+}
@@ -0,0 +1,65 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestShadows(t *testing.T) {
+	cases := map[string][]string{
+		`X = 'a' / "ab"`: {
+			`1:11 (10): rule X: alternative 2 ("ab") is shadowed by alternative 1 ("a"), which matches a prefix of it`,
+		},
+		`X = "if" / "if" "else" / [a-z]+`: {
+			`1:12 (11): rule X: alternative 2 ("ifelse") is shadowed by alternative 1 ("if"), which matches a prefix of it`,
+		},
+		"X = K / \"fori\" Y\nK = \"for\"\nY = 'y'": {
+			`1:9 (8): rule X: alternative 2 ("foriy") is shadowed by alternative 1 ("for"), which matches a prefix of it`,
+		},
+		`X = "a"i / "AB"`: {
+			`1:12 (11): rule X: alternative 2 ("AB") is shadowed by alternative 1 ("a"i), which matches a prefix of it`,
+		},
+		`X = "ab" / 'a'`:         nil,
+		`X = "a" / "AB"i`:        nil,
+		`X = 'a' 'x'* / "ab"`:    nil,
+		`X = ( 'b' / 'a' ) "ab"`: nil,
+	}
+	for src, want := range cases {
+		got := ast.Shadows(parseGrammar(t, src))
+		if len(got) != len(want) {
+			t.Errorf("%q: want %d warnings, got %d: %v", src, len(want), len(got), got)
+			continue
+		}
+		for i, w := range want {
+			if s := got[i].String(); s != w {
+				t.Errorf("%q: want warning %q, got %q", src, w, s)
+			}
+		}
+	}
+}
+
+func TestRulePrefix(t *testing.T) {
+	g := parseGrammar(t, "S = G / P / X\nG = \"GET \" path\nP = verb ' ' path\nverb = \"POST\"i\nX = path\npath = [a-z]+\nE = \"\"\n")
+
+	type prefix struct {
+		val        string
+		ignoreCase bool
+		ok         bool
+	}
+	cases := map[string]prefix{
+		"G":    {"GET ", false, true},
+		"P":    {"POST", true, true},
+		"verb": {"POST", true, true},
+		"X":    {},
+		"S":    {},
+		"E":    {},
+		"D":    {},
+	}
+	for nm, want := range cases {
+		val, ignoreCase, ok := ast.RulePrefix(g, nm)
+		if got := (prefix{val, ignoreCase, ok}); got != want {
+			t.Errorf("%s: want %v, got %v", nm, want, got)
+		}
+	}
+}
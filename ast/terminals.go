@@ -0,0 +1,42 @@
+package ast
+
+// Terminal is a terminal of the grammar, a literal, character class or
+// any matcher, with the rules that use it.
+type Terminal struct {
+	// Expr is the first occurrence of the terminal in the grammar, a
+	// *LitMatcher, *CharClassMatcher or *AnyMatcher.
+	Expr Expression
+
+	// Rules is the names of the rules that use the terminal, in the order
+	// of the rules of the grammar.
+	Rules []string
+}
+
+// Terminals returns the terminals of the grammar, in the order of their
+// first occurrence. Terminals of the same kind and with the same value are
+// returned once, a case-insensitive literal being distinct from the
+// case-sensitive one.
+func Terminals(g *Grammar) []*Terminal {
+	var terms []*Terminal
+	byKey := make(map[string]*Terminal)
+	for _, r := range g.Rules {
+		Walk(r.Expr, func(expr Expression) {
+			switch expr.(type) {
+			case *LitMatcher, *CharClassMatcher, *AnyMatcher:
+			default:
+				return
+			}
+			key, _ := matcherKey(expr)
+			t := byKey[key]
+			if t == nil {
+				t = &Terminal{Expr: expr}
+				byKey[key] = t
+				terms = append(terms, t)
+			}
+			if n := len(t.Rules); n == 0 || t.Rules[n-1] != r.Name.Val {
+				t.Rules = append(t.Rules, r.Name.Val)
+			}
+		})
+	}
+	return terms
+}
@@ -0,0 +1,55 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+func TestTerminals(t *testing.T) {
+	g := parseGrammar(t, `
+Stmt = "if" _ Expr / "while"i _ Expr / Expr
+Expr = [0-9]+ / "if" . / "while"
+_ = [ \t]*
+`)
+
+	want := []struct {
+		name  string
+		rules []string
+	}{
+		{`*ast.LitMatcher "if"`, []string{"Stmt", "Expr"}},
+		{`*ast.LitMatcher "while"i`, []string{"Stmt"}},
+		{`*ast.CharClassMatcher "[0-9]"`, []string{"Expr"}},
+		{`*ast.AnyMatcher "."`, []string{"Expr"}},
+		{`*ast.LitMatcher "while"`, []string{"Expr"}},
+		{`*ast.CharClassMatcher "[ \\t]"`, []string{"_"}},
+	}
+	got := ast.Terminals(g)
+	if len(got) != len(want) {
+		t.Fatalf("want %d terminals, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		name := terminalName(got[i].Expr)
+		if name != w.name || !reflect.DeepEqual(got[i].Rules, w.rules) {
+			t.Errorf("%d: want %s %v, got %s %v", i, w.name, w.rules, name, got[i].Rules)
+		}
+	}
+}
+
+func terminalName(expr ast.Expression) string {
+	switch expr := expr.(type) {
+	case *ast.LitMatcher:
+		s := fmt.Sprintf("%T %q", expr, expr.Val)
+		if expr.IgnoreCase {
+			s += "i"
+		}
+		return s
+	case *ast.CharClassMatcher:
+		return fmt.Sprintf("%T %q", expr, expr.Val)
+	case *ast.AnyMatcher:
+		return fmt.Sprintf("%T %q", expr, expr.Val)
+	}
+	return fmt.Sprintf("%T", expr)
+}
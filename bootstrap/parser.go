@@ -165,10 +165,21 @@ func (p *Parser) expect(ids ...tid) bool {
 func (p *Parser) rule() *ast.Rule {
 	defer p.out(p.in("rule"))
 
+	pos := p.tok.pos
+	var meta map[string]string
+	for p.tok.id == at {
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		p.ruleMeta(meta)
+		p.skip(eol)
+	}
+
 	if !p.expect(ident) {
 		return nil
 	}
-	r := ast.NewRule(p.tok.pos, ast.NewIdentifier(p.tok.pos, p.tok.lit))
+	r := ast.NewRule(pos, ast.NewIdentifier(p.tok.pos, p.tok.lit))
+	r.Meta = meta
 	p.read()
 
 	if p.tok.id == str || p.tok.id == rstr || p.tok.id == char {
@@ -205,6 +216,76 @@ func (p *Parser) rule() *ast.Rule {
 	return r
 }
 
+// ruleMeta parses a @meta annotation and adds its key-value pairs to meta.
+// On error, it skips to the end of the annotation so that the rule can
+// still be parsed.
+func (p *Parser) ruleMeta(meta map[string]string) {
+	defer p.out(p.in("ruleMeta"))
+
+	if !p.metaPairs(meta) {
+		for p.tok.id != rparen && p.tok.id != eol && p.tok.id != eof {
+			p.read()
+		}
+	}
+	if p.tok.id == rparen {
+		p.read()
+	}
+}
+
+// metaPairs parses the name and the key-value pairs of a @meta annotation,
+// up to its closing parenthesis.
+func (p *Parser) metaPairs(meta map[string]string) bool {
+	p.read()
+	if !p.expect(ident) {
+		return false
+	}
+	if p.tok.lit != "meta" {
+		p.errs.add(p.tok.pos, fmt.Errorf("unknown annotation %q", p.tok.lit))
+		return false
+	}
+	p.read()
+	if !p.expect(lparen) {
+		return false
+	}
+
+	for {
+		p.read()
+		if !p.expect(ident) {
+			return false
+		}
+		key, keyPos := p.tok.lit, p.tok.pos
+		p.read()
+		if !p.expect(ruledef) {
+			return false
+		}
+		if p.tok.lit != "=" {
+			p.errs.add(p.tok.pos, fmt.Errorf("expected \"=\", got %q", p.tok.lit))
+			return false
+		}
+		p.read()
+		if !p.expect(str, rstr, char) {
+			return false
+		}
+		val, err := strconv.Unquote(p.tok.lit)
+		if err != nil {
+			p.errs.add(p.tok.pos, err)
+			return false
+		}
+		if _, ok := meta[key]; ok {
+			p.errs.add(keyPos, fmt.Errorf("duplicate metadata key %q", key))
+			return false
+		}
+		meta[key] = val
+		p.read()
+		if !p.expect(comma, rparen) {
+			return false
+		}
+		if p.tok.id == rparen {
+			return true
+		}
+	}
+}
+
 func (p *Parser) expression() ast.Expression {
 	defer p.out(p.in("expression"))
 
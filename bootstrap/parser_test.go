@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -67,14 +68,45 @@ func TestParseValid(t *testing.T) {
 	}
 }
 
+func TestParseMeta(t *testing.T) {
+	src := "A = B\n@meta(doc=\"first\") @meta(tag='t', x=`y`)\nB = 'b'\n@meta(doc=\"\")\nC <- 'c'"
+	p := NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		nil,
+		{"doc": "first", "tag": "t", "x": "y"},
+		{"doc": ""},
+	}
+	if len(g.Rules) != len(want) {
+		t.Fatalf("want %d rules, got %d", len(want), len(g.Rules))
+	}
+	for i, r := range g.Rules {
+		if !reflect.DeepEqual(r.Meta, want[i]) {
+			t.Errorf("%s: want metadata %v, got %v", r.Name.Val, want[i], r.Meta)
+		}
+	}
+	if pos := g.Rules[1].Pos(); pos.Line != 2 || pos.Col != 1 {
+		t.Errorf("want rule B at its annotation, got %s", pos)
+	}
+}
+
 var parseInvalidCases = []string{
 	"a",
 	`R = )`,
+	`@meta(a="x", a="y") R = 'r'`,
+	`@other(a="x") R = 'r'`,
+	`@meta(a<-"x") R = 'r'`,
 }
 
 var parseExpErrs = [][]string{
 	{"1:1 (0): expected ruledef, got eof"},
 	{"1:5 (4): no expression in sequence", "1:5 (4): no expression in choice", "1:5 (4): missing expression"},
+	{`1:14 (13): duplicate metadata key "a"`},
+	{`1:2 (1): unknown annotation "other"`},
+	{`1:8 (7): expected "=", got "<-"`},
 }
 
 func TestParseInvalid(t *testing.T) {
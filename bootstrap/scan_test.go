@@ -79,6 +79,8 @@ var scanValidCases = []string{
 	"?",
 	"+",
 	"*",
+	"@",
+	",",
 	"\n",
 	"pockage = a",
 	`Rule <-
@@ -160,6 +162,8 @@ var scanExpTokens = [][]string{
 	{"1:1 (0): question \"?\"", `1:1 (0): eof ""`},
 	{"1:1 (0): plus \"+\"", `1:1 (0): eof ""`},
 	{"1:1 (0): star \"*\"", `1:1 (0): eof ""`},
+	{"1:1 (0): at \"@\"", `1:1 (0): eof ""`},
+	{"1:1 (0): comma \",\"", `1:1 (0): eof ""`},
 	{"2:0 (0): eol \"\\n\"", `2:0 (0): eof ""`},
 	{"1:1 (0): ident \"pockage\"", `1:9 (8): ruledef "="`, `1:11 (10): ident "a"`, `1:11 (10): eof ""`},
 	{
@@ -38,6 +38,8 @@ const (
 	plus        tid = '+'  // one-or-more '+'
 	star        tid = '*'  // zero-or-more '*'
 	slash       tid = '/'  // ordered choice '/'
+	at          tid = '@'  // rule annotation '@'
+	comma       tid = ','  // separate annotation values ','
 )
 
 var lookup = map[tid]string{
@@ -64,6 +66,8 @@ var lookup = map[tid]string{
 	plus:        "plus",
 	star:        "star",
 	slash:       "slash",
+	at:          "at",
+	comma:       "comma",
 }
 
 func (t tid) String() string {
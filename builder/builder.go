@@ -5,21 +5,30 @@ package builder
 import (
 	"bytes"
 	"fmt"
+	"go/scanner"
+	"go/token"
 	"io"
+	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/craiggwilson/pigeon/ast"
 )
 
+// maxCommentLen is the maximum length of the grammar's source line in
+// the comments added with the Comments option.
+const maxCommentLen = 60
+
 // generated function templates
 var (
-	onFuncTemplate = `func (%s *current) %s(%s) (interface{}, error) {
+	onFuncTemplate = `func (%s *%s) %s(%s) (interface{}, error) {
 %s
 }
 `
-	onPredFuncTemplate = `func (%s *current) %s(%s) (bool, error) {
+	onPredFuncTemplate = `func (%s *%s) %s(%s) (bool, error) {
 %s
 }
 `
@@ -34,6 +43,12 @@ var (
 	_ = stack
 	return p.cur.%[1]s(%s)
 }
+`
+	callWhileFuncTemplate = `func (p *parser) call%s(acc []interface{}) (bool, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.%[1]s(%s)
+}
 `
 )
 
@@ -52,11 +67,351 @@ func ReceiverName(nm string) Option {
 	}
 }
 
+// ContextType returns an option that specifies the name of the type of
+// the current struct, so that it does not collide with a type of the
+// package of the generated code. The default is current.
+func ContextType(nm string) Option {
+	return func(b *builder) Option {
+		prev := b.ctxType
+		b.ctxType = nm
+		return ContextType(prev)
+	}
+}
+
+// Imports returns an option that specifies the import paths of packages
+// used by the code blocks, imported by the generated code after the
+// package clause. The paths already imported by the initializer are not
+// imported again.
+func Imports(paths ...string) Option {
+	return func(b *builder) Option {
+		prev := b.extraImports
+		b.extraImports = paths
+		return Imports(prev...)
+	}
+}
+
+// PackageName returns an option that specifies the package name of the
+// generated code. When set, the package clause is written as the first
+// line of the generated code, replacing any package clause found in the
+// grammar's initializer. The name must be a valid Go package name.
+func PackageName(nm string) Option {
+	return func(b *builder) Option {
+		prev := b.pkgName
+		b.pkgName = nm
+		return PackageName(prev)
+	}
+}
+
+// EmitHTTPHandler returns an option that specifies whether the ServeParse
+// HTTP handler is generated. It parses the body of the request and writes
+// the result of the parse or its errors as JSON.
+func EmitHTTPHandler(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.httpHandler
+		bld.httpHandler = b
+		return EmitHTTPHandler(prev)
+	}
+}
+
+// EmitMain returns an option that specifies whether a main function is
+// generated, so that the parser is a command that parses the file named by
+// its first argument, or its standard input, and prints the result or the
+// errors. The package of the parser must be main.
+func EmitMain(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.emitMain
+		bld.emitMain = b
+		return EmitMain(prev)
+	}
+}
+
+// EmitDispatch returns an option that specifies whether the Dispatch
+// function is generated. It parses the data starting at the entrypoint
+// rule whose literal prefix the data starts with, or at the first rule of
+// the grammar if there is none.
+func EmitDispatch(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.dispatch
+		bld.dispatch = b
+		return EmitDispatch(prev)
+	}
+}
+
+// EmitLexer returns an option that specifies whether the Tokenize function
+// is generated. It splits the input into the tokens matched by the lexical
+// rules of the grammar, skipping the whitespace between them, and returns
+// the kind, the text and the position of each token.
+func EmitLexer(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.lexer
+		bld.lexer = b
+		return EmitLexer(prev)
+	}
+}
+
+// EmbedSource returns an option that specifies whether the source text of
+// the grammar is embedded in the generated code. The source is set with the
+// Source option; when embedded, it is available as the grammarSource
+// constant and returned by the generated GrammarSource function.
+func EmbedSource(embed bool) Option {
+	return func(b *builder) Option {
+		prev := b.embedSrc
+		b.embedSrc = embed
+		return EmbedSource(prev)
+	}
+}
+
+// Source returns an option that sets the source text of the grammar, as
+// embedded in the generated code when EmbedSource is set.
+func Source(src []byte) Option {
+	return func(b *builder) Option {
+		prev := b.src
+		b.src = src
+		return Source(prev)
+	}
+}
+
+// Define returns an option that defines the feature nm, so that the rules
+// and alternatives conditioned on nm with "@if(nm)" are generated.
+func Define(nm string) Option {
+	return setDefine(nm, true)
+}
+
+func setDefine(nm string, defined bool) Option {
+	return func(b *builder) Option {
+		prev := b.defines[nm]
+		if b.defines == nil {
+			b.defines = make(map[string]bool)
+		}
+		b.defines[nm] = defined
+		return setDefine(nm, prev)
+	}
+}
+
+// Comments returns an option that specifies whether comments are added to
+// the generated code to identify the position of the rules and code blocks
+// in the grammar. If the source text of the grammar is set with the Source
+// option, the comments include the corresponding line of the grammar.
+func Comments(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.comments
+		bld.comments = b
+		return Comments(prev)
+	}
+}
+
+// OnlyRules returns an option that specifies the names of the rules to
+// generate, to generate a parser for a part of a large grammar, e.g. for
+// focused testing. The rules they reference, directly or indirectly, and
+// the rule to skip, if any, are also generated. The first named rule is
+// the start rule of the parser. If no name is specified, all the rules
+// are generated.
+func OnlyRules(names ...string) Option {
+	return func(b *builder) Option {
+		prev := b.onlyRules
+		b.onlyRules = names
+		return OnlyRules(prev...)
+	}
+}
+
+// Structs returns an option that specifies whether a struct type is
+// generated for each rule whose expression is a sequence with labeled
+// expressions and no action. The type is named after the rule, with an
+// exported field of type interface{} for each label, and the value of
+// the rule is an instance of that type with the values of the labeled
+// expressions.
+func Structs(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.structs
+		bld.structs = b
+		return Structs(prev)
+	}
+}
+
+// EmitStringers returns an option that specifies whether a String method
+// is generated for the struct types of the Structs option. The method
+// formats the value as the name of the type followed by the fields and
+// their values in braces, e.g. "Point{X:1, Y:2}".
+func EmitStringers(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.stringers
+		bld.stringers = b
+		return EmitStringers(prev)
+	}
+}
+
+// EmitVisitor returns an option that specifies whether a Visitor interface
+// and a Walk function are generated for the struct types of the Structs
+// option. The interface has an EnterX and an ExitX method for each struct
+// type X, and Walk calls them in depth-first order for the struct values
+// found in a value of the parser, following the fields and the slices.
+func EmitVisitor(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.visitor
+		bld.visitor = b
+		return EmitVisitor(prev)
+	}
+}
+
+// CheckLabels returns an option that specifies whether the code blocks are
+// checked for the identifiers that are not declared, typically a label
+// that is not in the scope of the code block, reported as an error with
+// the position of the identifier in the grammar. The identifiers declared
+// by the initializer and by the generated code are considered declared,
+// but not those declared in other files of the package, so the check is
+// only useful for grammars whose code blocks are self-contained.
+func CheckLabels(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.checkLabels
+		bld.checkLabels = b
+		return CheckLabels(prev)
+	}
+}
+
+// FailUndefinedRules returns an option that specifies whether the builder
+// returns an *UndefinedRuleError for the first reference to a rule that the
+// grammar does not define, naming the referencing rule and the missing
+// one, instead of generating a parser whose reference fails to match.
+func FailUndefinedRules(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.failUndefined
+		bld.failUndefined = b
+		return FailUndefinedRules(prev)
+	}
+}
+
+// StructSpans returns an option that specifies whether the struct types
+// of the Structs option have the fields Pos and End, set to the start and
+// end positions of the match of the rule.
+func StructSpans(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.spans
+		bld.spans = b
+		return StructSpans(prev)
+	}
+}
+
+// CaptureSpans returns an option that specifies whether the generated
+// parser records the start and end positions of the match of each labeled
+// expression, returned by the span method of the current struct in the
+// code blocks: c.span("x") returns the positions of the match of the
+// label x.
+func CaptureSpans(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.capture
+		bld.capture = b
+		return CaptureSpans(prev)
+	}
+}
+
+// TagBranches returns an option that specifies whether the choice
+// expressions repeated by "*" or "+" tag the value of each iteration with
+// the alternative that matched: the value of the choice is a Branch with
+// the index of the alternative, the name of the rule that it references,
+// if any, and its value.
+func TagBranches(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.tagBranches
+		bld.tagBranches = b
+		return TagBranches(prev)
+	}
+}
+
+// StripActions returns an option that specifies whether the code blocks of
+// the grammar are ignored, to generate a recognizer. The action code blocks
+// are not written, the code predicates always match, and only the package
+// clause of the initializer is written, so that the generated code does not
+// depend on the imports of the code blocks.
+func StripActions(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.strip
+		bld.strip = b
+		return StripActions(prev)
+	}
+}
+
+// NoInline returns an option that specifies whether the references to the
+// rules that consist of a single matcher are kept, instead of being
+// replaced by the matcher, so that the matches of those rules are reported
+// to the OnMatch and Events functions of the generated parser.
+func NoInline(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.noInline
+		bld.noInline = b
+		return NoInline(prev)
+	}
+}
+
+// NoPanic returns an option that specifies whether the generated parser
+// is written without panic handler, so that its code never calls panic
+// nor recover, e.g. for the environments where they are forbidden. The
+// limits of the options and the invalid expressions always stop the parse
+// with an error, but the Recover and PanicContext options of the
+// generated parser have no effect, and a panic in a code block is not
+// recovered.
+func NoPanic(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.noPanic
+		bld.noPanic = b
+		return NoPanic(prev)
+	}
+}
+
+// Transform returns an option that sets the function called with the
+// grammar before the parser is generated, e.g. to expand macros or to
+// desugar expressions. The parser is generated from the grammar it
+// returns, and the build fails with its error if it returns one. The
+// default is nil, the grammar is generated as parsed.
+func Transform(fn func(*ast.Grammar) (*ast.Grammar, error)) Option {
+	return func(b *builder) Option {
+		prev := b.transform
+		b.transform = fn
+		return Transform(prev)
+	}
+}
+
+// DefaultMemoize returns an option that specifies whether the generated
+// parser memoizes by default, as if the Memoize option was passed to its
+// Parse functions. Callers can still disable it with Memoize(false).
+func DefaultMemoize(b bool) Option {
+	return func(bld *builder) Option {
+		prev := bld.memoize
+		bld.memoize = b
+		return DefaultMemoize(prev)
+	}
+}
+
+// MemoLevel returns an option that specifies what the generated parser
+// memoizes when the Memoize option is set: "none" for nothing, so that the
+// Memoize option has no effect, "rule" for the results of the rules only,
+// or "expr" for the results of the rules and of their expressions. The
+// level is fixed when the parser is generated, to trade the memory of the
+// memoization table for speed. The default is "expr".
+func MemoLevel(level string) Option {
+	return func(b *builder) Option {
+		prev := b.memoLevel
+		b.memoLevel = level
+		return MemoLevel(prev)
+	}
+}
+
+// memoLevels maps the levels of the MemoLevel option to the constants of
+// the static code, empty for the default level.
+var memoLevels = map[string]string{
+	"":     "",
+	"expr": "",
+	"rule": "memoRules",
+	"none": "memoNone",
+}
+
 // BuildParser builds the PEG parser using the provider grammar. The code is
 // written to the specified w.
 func BuildParser(w io.Writer, g *ast.Grammar, opts ...Option) error {
-	b := &builder{w: w, recvName: "c"}
+	b := &builder{w: w, recvName: "c", ctxType: "current"}
 	b.setOptions(opts)
+	if b.goVersion != "" {
+		return b.buildChecked(g)
+	}
 	return b.buildParser(g)
 }
 
@@ -65,11 +420,79 @@ type builder struct {
 	err error
 
 	// options
-	recvName string
+	recvName    string
+	ctxType     string
+	pkgName     string
+	embedSrc    bool
+	httpHandler bool
+	emitMain    bool
+	dispatch    bool
+	lexer       bool
+	checkLabels bool
+	src         []byte
+	srcLines    [][]byte
+	defines     map[string]bool
+	comments    bool
+	structs     bool
+	stringers   bool
+	visitor     bool
+	spans       bool
+	capture     bool
+	tagBranches bool
+	strip       bool
+	noPanic     bool
+	cache       *Cache
+	skip        string
+	onlyRules   []string
+	goVersion   string
+	memoize     bool
+	memoLevel   string
+	transform   func(*ast.Grammar) (*ast.Grammar, error)
+
+	// whether a reference to an undefined rule is an error
+	failUndefined bool
+
+	// import paths of the Imports option
+	extraImports []string
+
+	// files of the BuildParserDir function, nil if the parser is written
+	// to a single file, with the package clause and the imports of the
+	// initializer written at the start of each file.
+	files     []*splitFile
+	pkgClause string
+	imports   string
+
+	// trivial rules, inlined where they are referenced unless noInline
+	// is set
+	trivial  map[string]ast.Expression
+	noInline bool
+	// labels of the rules that get a struct type
+	structLabels map[string][]string
+	// code of the rules found in or added to the cache
+	ruleCodes map[*ast.Rule]ruleCode
+	// initializer of the grammar, for the CheckLabels option
+	init *ast.CodeBlock
+	// choice about to be written as the expression of a repetition, that
+	// is tagged with its branches for the TagBranches option
+	tagChoice *ast.ChoiceExpr
 
 	ruleName  string
 	exprIndex int
-	argsStack [][]string
+	argsStack [][]*ast.Identifier
+
+	// labels is the set of labels of the current rule, backRefs the set
+	// of those labels that are referenced by back-references and lengths
+	// the set of those that are the length of a bytes matcher.
+	labels, backRefs, lengths map[string]bool
+	// labels referenced by the @seen expressions of the grammar
+	seenLabels map[string]bool
+	// key and value labels of the @map expressions of the grammar
+	mapKeys, mapVals map[string]bool
+	// labels referenced by the code blocks that receive them, the other
+	// labels are not stored by the generated parser
+	usedLabels map[*ast.Identifier]bool
+	// whether the code of the rules is only generated to find usedLabels
+	findingLabels bool
 }
 
 func (b *builder) setOptions(opts []Option) {
@@ -79,88 +502,923 @@ func (b *builder) setOptions(opts []Option) {
 }
 
 func (b *builder) buildParser(g *ast.Grammar) error {
+	if b.pkgName != "" {
+		if !isPackageName(b.pkgName) {
+			return fmt.Errorf("builder: invalid package name %q", b.pkgName)
+		}
+		b.writelnf("package %s", b.pkgName)
+	}
+	if _, ok := memoLevels[b.memoLevel]; !ok {
+		return fmt.Errorf("builder: invalid memo level %q", b.memoLevel)
+	}
+	if b.emitMain {
+		clause := "package " + b.pkgName
+		if b.pkgName == "" && g.Init != nil {
+			clause = packageClause(g.Init.Val[1 : len(g.Init.Val)-1])
+		}
+		if clause != "package main" {
+			return fmt.Errorf("builder: the package must be main to generate the main function")
+		}
+	}
+	if b.transform != nil {
+		var err error
+		if g, err = b.transform(g); err != nil {
+			return err
+		}
+	}
+	if b.failUndefined {
+		if err := checkRuleRefs(g); err != nil {
+			return err
+		}
+	}
+	if len(b.onlyRules) > 0 {
+		var err error
+		if g, err = b.subsetRules(g); err != nil {
+			return err
+		}
+	}
+	if loops := ast.EmptyLoops(g); len(loops) > 0 {
+		return fmt.Errorf("builder: %s: repetition of an expression that can match the empty string", loops[0].Pos())
+	}
+	if b.structs {
+		var err error
+		if g, err = b.structRules(g); err != nil {
+			return err
+		}
+	}
+	if b.skip != "" {
+		var err error
+		if g, err = b.skipRules(g); err != nil {
+			return err
+		}
+	}
+	var err error
+	if b.seenLabels, err = seenLabels(g); err != nil {
+		return err
+	}
+	if b.mapKeys, b.mapVals, err = mapLabels(g); err != nil {
+		return err
+	}
+	b.trivial = b.trivialRules(g)
+	if g, err = b.inlineRules(g); err != nil {
+		return err
+	}
+	b.findUsedLabels(g)
+	if b.cache != nil && !b.comments {
+		b.ruleCodes = b.cachedRules(g)
+	}
+	fields, state := g.Fields, g.State
+	if b.strip {
+		fields, state = nil, nil
+	}
+	b.init = g.Init
 	b.writeInit(g.Init)
+	if b.structs {
+		b.writeStructs(g)
+		if b.visitor {
+			b.writeVisitor(g)
+		}
+	}
+	if b.embedSrc {
+		b.writeSource()
+	}
+	b.writeEntrypoints(g)
+	if b.dispatch {
+		b.writeDispatch(g)
+	}
+	b.startFile("parser_grammar.go")
 	b.writeGrammar(g)
+	b.writeDefaultOptions()
 
+	b.startFile("parser_actions.go")
 	for _, rule := range g.Rules {
+		if !b.enabled(rule.Cond) || b.strip {
+			continue
+		}
+		if rc, ok := b.ruleCodes[rule]; ok {
+			b.writef("%s", rc.funcs)
+			continue
+		}
 		b.writeRuleCode(rule)
 	}
-	b.writeStaticCode()
+	b.startFile("parser_runtime.go")
+	b.writeStaticCode(fields, state)
+	if b.httpHandler {
+		b.writelnf("%s", serveParseCode)
+	}
+	if b.emitMain {
+		b.writelnf("%s", mainCode)
+	}
+	if b.lexer {
+		b.writeLexer(g)
+	}
 
 	return b.err
 }
 
 func (b *builder) writeInit(init *ast.CodeBlock) {
 	if init == nil {
+		if decl := b.importDecl(""); decl != "" && !b.strip {
+			b.writelnf("\n%s\n", decl)
+		}
 		return
 	}
 
 	// remove opening and closing braces
 	val := init.Val[1 : len(init.Val)-1]
+	if b.strip {
+		// the rest of the initializer is only used by the code blocks
+		if b.pkgName == "" {
+			b.writelnf("%s", packageClause(val))
+		}
+		return
+	}
+	if b.pkgName != "" {
+		val = removePackageClause(val)
+	}
+	if decl := b.importDecl(val); decl != "" {
+		// the imports go after the package clause, if there is one
+		if _, end := packageClauseOffsets(val); end >= 0 {
+			val = val[:end] + "\n\n" + decl + val[end:]
+		} else {
+			val = "\n" + decl + "\n" + val
+		}
+	}
 	b.writelnf("%s", val)
 }
 
+// importDecl returns the import declaration of the paths of the Imports
+// option that are not imported by the initializer code, or an empty string
+// if there is none.
+func (b *builder) importDecl(code string) string {
+	imported := make(map[string]bool)
+	for _, path := range importPaths(code) {
+		imported[path] = true
+	}
+	var buf bytes.Buffer
+	for _, path := range b.extraImports {
+		if imported[path] {
+			continue
+		}
+		imported[path] = true
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+	if buf.Len() == 0 {
+		return ""
+	}
+	return "import (\n" + buf.String() + ")"
+}
+
+func (b *builder) writeSource() {
+	b.writelnf("// grammarSource is the source text of the grammar used to generate")
+	b.writelnf("// this parser.")
+	b.writelnf("const grammarSource = %s", quoteSource(string(b.src)))
+	b.writelnf("")
+	b.writelnf("// GrammarSource returns the source text of the grammar used to")
+	b.writelnf("// generate this parser.")
+	b.writelnf("func GrammarSource() string {")
+	b.writelnf("\treturn grammarSource")
+	b.writelnf("}")
+	b.writelnf("")
+}
+
+// writeEntrypoints writes a ParseX function for each enabled rule X marked
+// with @entry, that parses like Parse but starts at that rule.
+func (b *builder) writeEntrypoints(g *ast.Grammar) {
+	for _, r := range g.Rules {
+		if b.enabled(r.Cond) {
+			if r.Type != "" {
+				b.writeParseInto("ParseInto", "Parse", r)
+			}
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range g.Rules {
+		if !r.Entry || !b.enabled(r.Cond) || seen[r.Name.Val] {
+			continue
+		}
+		seen[r.Name.Val] = true
+		fn := "Parse" + exportedName(r.Name.Val)
+		switch fn {
+		case "ParseFile", "ParseInto", "ParsePartial", "ParseReader", "ParseTokens":
+			if b.err == nil {
+				b.err = fmt.Errorf("builder: %s: entrypoint function %s of rule %s is already generated",
+					r.Pos(), fn, r.Name.Val)
+			}
+			return
+		}
+		b.writelnf("// %s parses the data from b like Parse, starting at the rule %s", fn, r.Name.Val)
+		b.writelnf("// instead of the first rule of the grammar.")
+		b.writelnf("func %s(filename string, b []byte, opts ...Option) (interface{}, error) {", fn)
+		b.writelnf("\tp := newParser(filename, b, opts...)")
+		b.writelnf("\tp.entry = %q", r.Name.Val)
+		b.writelnf("\treturn p.parse(g)")
+		b.writelnf("}")
+		b.writelnf("")
+		if r.Type != "" {
+			b.writeParseInto(fn+"Into", fn, r)
+		}
+	}
+}
+
+// writeParseInto writes the function fn that calls the parse function
+// parseFn of the rule r and stores its value in a pointer to the @type of
+// the rule.
+func (b *builder) writeParseInto(fn, parseFn string, r *ast.Rule) {
+	b.writelnf("// %s parses the data from b like %s and stores the value of the", fn, parseFn)
+	b.writelnf("// rule %s in *v. The value is stored even if the parse returns the", r.Name.Val)
+	b.writelnf("// errors of error productions along with it.")
+	b.writelnf("func %s(filename string, b []byte, v *%s, opts ...Option) error {", fn, r.Type)
+	b.writelnf("\tval, err := %s(filename, b, opts...)", parseFn)
+	b.writelnf("\tif val == nil {")
+	b.writelnf("\t\treturn err")
+	b.writelnf("\t}")
+	b.writelnf("\tt, ok := val.(%s)", r.Type)
+	b.writelnf("\tif !ok {")
+	b.writelnf("\t\treturn fmt.Errorf(\"rule %s: value of type %%T, want %s\", val)", r.Name.Val, strings.Replace(r.Type, "%", "%%", -1))
+	b.writelnf("\t}")
+	b.writelnf("\t*v = t")
+	b.writelnf("\treturn err")
+	b.writelnf("}")
+	b.writelnf("")
+}
+
+// writeDispatch writes the Dispatch function, that tries the literal
+// prefixes of the entrypoint rules from the longest to the shortest.
+func (b *builder) writeDispatch(g *ast.Grammar) {
+	type entry struct {
+		prefix     string
+		ignoreCase bool
+		fn         string
+	}
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, r := range g.Rules {
+		if !r.Entry || !b.enabled(r.Cond) || seen[r.Name.Val] {
+			continue
+		}
+		seen[r.Name.Val] = true
+		if prefix, ignoreCase, ok := ast.RulePrefix(g, r.Name.Val); ok {
+			entries = append(entries, entry{prefix, ignoreCase, "Parse" + exportedName(r.Name.Val)})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return len(entries[i].prefix) > len(entries[j].prefix)
+	})
+
+	b.writelnf("// Dispatch parses the data from b like Parse, starting at the entrypoint")
+	b.writelnf("// rule whose literal prefix the data starts with, or at the first rule of")
+	b.writelnf("// the grammar if there is none.")
+	b.writelnf("func Dispatch(filename string, b []byte, opts ...Option) (interface{}, error) {")
+	for _, e := range entries {
+		if e.ignoreCase {
+			b.writelnf("\tif n := len(%q); len(b) >= n && bytes.EqualFold(b[:n], []byte(%[1]q)) {", e.prefix)
+		} else {
+			b.writelnf("\tif bytes.HasPrefix(b, []byte(%q)) {", e.prefix)
+		}
+		b.writelnf("\t\treturn %s(filename, b, opts...)", e.fn)
+		b.writelnf("\t}")
+	}
+	b.writelnf("\treturn Parse(filename, b, opts...)")
+	b.writelnf("}")
+	b.writelnf("")
+}
+
+// writeLexer writes the Tokenize function and the list of the enabled
+// lexical rules that it tries, in the order of the grammar. The skip rule
+// is not a token.
+func (b *builder) writeLexer(g *ast.Grammar) {
+	var names []string
+	for _, r := range g.Rules {
+		if r.Lexical && b.enabled(r.Cond) && r.Name.Val != b.skip {
+			names = append(names, r.Name.Val)
+		}
+	}
+	if len(names) == 0 {
+		if b.err == nil {
+			b.err = fmt.Errorf("builder: the lexer requires at least one lexical rule")
+		}
+		return
+	}
+
+	b.writelnf("\n// lexicalRules are the rules tried by Tokenize.")
+	b.writelnf("var lexicalRules = []string{")
+	for _, nm := range names {
+		b.writelnf("\t%q,", nm)
+	}
+	b.writelnf("}")
+	b.writelnf("%s", tokenizeCode)
+}
+
+// quoteSource returns src as a Go string literal, using a raw string
+// literal when possible so that the source is readable in the generated
+// code.
+func quoteSource(src string) string {
+	if utf8.ValidString(src) && !strings.ContainsAny(src, "`\r\x00\ufeff") {
+		return "`" + src + "`"
+	}
+	return strconv.Quote(src)
+}
+
+// removePackageClause removes the package clause from the code, if the
+// code starts with one (ignoring whitespace and comments).
+func removePackageClause(code string) string {
+	start, end := packageClauseOffsets(code)
+	if start < 0 {
+		return code
+	}
+	return code[:start] + code[end:]
+}
+
+// packageClause returns the package clause that starts the code, or an
+// empty string if there is none.
+func packageClause(code string) string {
+	start, end := packageClauseOffsets(code)
+	if start < 0 {
+		return ""
+	}
+	return code[start:end]
+}
+
+// packageClauseOffsets returns the start and end offsets of the package
+// clause that starts the code (ignoring whitespace and comments), or -1, -1
+// if there is none.
+func packageClauseOffsets(code string) (int, int) {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	src := []byte(code)
+	s.Init(fset.AddFile("", fset.Base(), len(src)), src, nil, 0)
+
+	pos, tok, _ := s.Scan()
+	if tok != token.PACKAGE {
+		return -1, -1
+	}
+	identPos, tok, lit := s.Scan()
+	if tok != token.IDENT {
+		return -1, -1
+	}
+	return fset.Position(pos).Offset, fset.Position(identPos).Offset + len(lit)
+}
+
+// isPackageName returns true if nm is a valid Go package name.
+func isPackageName(nm string) bool {
+	if nm == "_" || token.Lookup(nm).IsKeyword() {
+		return false
+	}
+	for i, rn := range nm {
+		if !unicode.IsLetter(rn) && rn != '_' && (i == 0 || !unicode.IsDigit(rn)) {
+			return false
+		}
+	}
+	return nm != ""
+}
+
 func (b *builder) writeGrammar(g *ast.Grammar) {
 	// transform the ast grammar to the self-contained, no dependency version
 	// of the parser-generator grammar.
 	b.writelnf("var g = &grammar {")
 	b.writelnf("\trules: []*rule{")
 	for _, r := range g.Rules {
+		if !b.enabled(r.Cond) {
+			continue
+		}
+		if rc, ok := b.ruleCodes[r]; ok {
+			b.writef("%s", rc.entry)
+			continue
+		}
 		b.writeRule(r)
 	}
 	b.writelnf("\t},")
+	if b.skip != "" {
+		b.writelnf("\tskip: %q,", b.skip)
+		for _, r := range g.Rules {
+			if r.NLSignificant && b.enabled(r.Cond) {
+				b.writelnf("\tnlSignificant: true,")
+				break
+			}
+		}
+	}
+	if level := memoLevels[b.memoLevel]; level != "" {
+		b.writelnf("\tmemoLevel: %s,", level)
+	}
 	b.writelnf("}")
 }
 
-func (b *builder) writeRule(r *ast.Rule) {
-	if r == nil || r.Name == nil {
+// writeDefaultOptions writes the options applied to the generated parser
+// before the options passed to the Parse functions.
+func (b *builder) writeDefaultOptions() {
+	if !b.memoize {
+		b.writelnf("var defaultOptions []Option")
 		return
 	}
+	b.writelnf("var defaultOptions = []Option{")
+	b.writelnf("\tMemoize(true),")
+	b.writelnf("}")
+}
 
-	b.exprIndex = 0
-	b.ruleName = r.Name.Val
+// enabled returns true if the feature cond is defined, or if there is no
+// condition.
+func (b *builder) enabled(cond *ast.Identifier) bool {
+	return cond == nil || b.defines[cond.Val]
+}
 
-	b.writelnf("{")
-	b.writelnf("\tname: %q,", r.Name.Val)
-	if r.DisplayName != nil && r.DisplayName.Val != "" {
-		b.writelnf("\tdisplayName: %q,", r.DisplayName.Val)
+// UndefinedRuleError is the error returned with the FailUndefinedRules
+// option when a rule of the grammar references a rule that it does not
+// define. It is exported, rather than an errUndefinedRule value, so that
+// the callers of BuildParser can get the names of the referencing rule and
+// of the missing one. A reference to a rule that is defined but not
+// generated because of its @if condition is not an error, it fails to
+// match at runtime.
+type UndefinedRuleError struct {
+	Pos  ast.Pos
+	Rule string
+	Ref  string
+}
+
+// Error returns the error message, with the position of the reference.
+func (e *UndefinedRuleError) Error() string {
+	return fmt.Sprintf("builder: %s: rule %s references undefined rule %s", e.Pos, e.Rule, e.Ref)
+}
+
+// checkRuleRefs returns an UndefinedRuleError for the first reference to
+// an undefined rule in the rules of g, in the order of the grammar.
+func checkRuleRefs(g *ast.Grammar) error {
+	defined := make(map[string]bool, len(g.Rules))
+	for _, r := range g.Rules {
+		defined[r.Name.Val] = true
 	}
-	pos := r.Pos()
-	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writef("\texpr: ")
-	b.writeExpr(r.Expr)
-	b.writelnf("},")
+	var err error
+	for _, r := range g.Rules {
+		ast.Walk(r.Expr, func(expr ast.Expression) {
+			if ref, ok := expr.(*ast.RuleRefExpr); ok && err == nil && !defined[ref.Name.Val] {
+				err = &UndefinedRuleError{Pos: ref.Pos(), Rule: r.Name.Val, Ref: ref.Name.Val}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (b *builder) writeExpr(expr ast.Expression) {
-	b.exprIndex++
-	switch expr := expr.(type) {
-	case *ast.ActionExpr:
-		b.writeActionExpr(expr)
-	case *ast.AndCodeExpr:
-		b.writeAndCodeExpr(expr)
-	case *ast.AndExpr:
-		b.writeAndExpr(expr)
-	case *ast.AnyMatcher:
-		b.writeAnyMatcher(expr)
-	case *ast.CharClassMatcher:
-		b.writeCharClassMatcher(expr)
-	case *ast.ChoiceExpr:
-		b.writeChoiceExpr(expr)
-	case *ast.LabeledExpr:
-		b.writeLabeledExpr(expr)
+// trivialRules returns the rules that consist of a single matcher, mapped
+// to that matcher. References to those rules are replaced by the matcher to
+// avoid the overhead of parsing a rule. Rules with a display name are not
+// trivial, as the display name is used in error messages, nor are the
+// silent and the @nlsignificant rules.
+func (b *builder) trivialRules(g *ast.Grammar) map[string]ast.Expression {
+	trivial := make(map[string]ast.Expression)
+	if b.noInline {
+		return trivial
+	}
+	for _, r := range g.Rules {
+		if r.Name == nil || r.DisplayName != nil || r.Silent || r.NLSignificant || !b.enabled(r.Cond) {
+			continue
+		}
+		switch r.Expr.(type) {
+		case *ast.AnyMatcher, *ast.ByteMatcher, *ast.CharClassMatcher, *ast.LitMatcher, *ast.NestedMatcher,
+			*ast.RestOfLineMatcher, *ast.UntilMatcher:
+			trivial[r.Name.Val] = r.Expr
+		}
+	}
+	return trivial
+}
+
+// inlineRules adds the enabled @inline rules of g to the trivial rules, so
+// that their expression replaces their references, and returns a copy of g
+// without them. An @inline rule must not be the first rule, have
+// annotations other than "@if" and "@lexical", have code blocks or labels,
+// or reference itself through @inline rules.
+func (b *builder) inlineRules(g *ast.Grammar) (*ast.Grammar, error) {
+	inline := make(map[string]*ast.Rule)
+	for i, r := range g.Rules {
+		if !r.Inline || !b.enabled(r.Cond) {
+			continue
+		}
+		nm := r.Name.Val
+		switch {
+		case i == 0:
+			return nil, fmt.Errorf("builder: %s: the first rule %s cannot be @inline", r.Pos(), nm)
+		case r.Entry || r.Silent || r.NLSignificant || r.DisplayName != nil || r.Type != "" || r.Budget > 0:
+			return nil, fmt.Errorf("builder: %s: the @inline rule %s cannot have a display name or other annotations", r.Pos(), nm)
+		}
+		var err error
+		ast.Walk(r.Expr, func(expr ast.Expression) {
+			code := false
+			switch expr := expr.(type) {
+			case *ast.ActionExpr, *ast.AndCodeExpr, *ast.NotCodeExpr, *ast.LabeledExpr:
+				code = true
+			case *ast.ZeroOrOneExpr:
+				code = expr.Default != nil
+			}
+			if code && err == nil {
+				err = fmt.Errorf("builder: %s: the @inline rule %s cannot have code blocks or labels", expr.Pos(), nm)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		inline[nm] = r
+	}
+	if len(inline) == 0 {
+		return g, nil
+	}
+
+	// the expressions of the @inline rules are written where they are
+	// referenced, a cycle would not end
+	state := make(map[string]int)
+	var visit func(nm string) error
+	visit = func(nm string) error {
+		switch state[nm] {
+		case 1:
+			return fmt.Errorf("builder: %s: the @inline rule %s references itself", inline[nm].Pos(), nm)
+		case 2:
+			return nil
+		}
+		state[nm] = 1
+		var err error
+		ast.Walk(inline[nm].Expr, func(expr ast.Expression) {
+			if ref, ok := expr.(*ast.RuleRefExpr); ok && err == nil && inline[ref.Name.Val] != nil {
+				err = visit(ref.Name.Val)
+			}
+		})
+		state[nm] = 2
+		return err
+	}
+
+	cp := *g
+	cp.Rules = nil
+	for _, r := range g.Rules {
+		if inline[r.Name.Val] != r {
+			cp.Rules = append(cp.Rules, r)
+			continue
+		}
+		if err := visit(r.Name.Val); err != nil {
+			return nil, err
+		}
+		b.trivial[r.Name.Val] = r.Expr
+	}
+	return &cp, nil
+}
+
+// structLabels returns the labels of the expressions of the sequence that
+// is the expression of r, or nil if no struct is generated for r.
+func structLabels(r *ast.Rule) []string {
+	seq, ok := r.Expr.(*ast.SeqExpr)
+	if !ok {
+		return nil
+	}
+	var labels []string
+	for _, expr := range seq.Exprs {
+		if lab, ok := expr.(*ast.LabeledExpr); ok {
+			labels = append(labels, lab.Label.Val)
+		}
+	}
+	return labels
+}
+
+// subsetRules returns a copy of g with only the rules of the OnlyRules
+// option and the rules they depend on. The named rules come first, in the
+// order of the option, followed by their dependencies in the order of the
+// grammar.
+func (b *builder) subsetRules(g *ast.Grammar) (*ast.Grammar, error) {
+	byName := make(map[string][]*ast.Rule, len(g.Rules))
+	for _, r := range g.Rules {
+		byName[r.Name.Val] = append(byName[r.Name.Val], r)
+	}
+
+	keep := make(map[string]bool)
+	var visit func(string)
+	visit = func(nm string) {
+		if keep[nm] {
+			return
+		}
+		keep[nm] = true
+		for _, r := range byName[nm] {
+			ast.Walk(r.Expr, func(expr ast.Expression) {
+				if ref, ok := expr.(*ast.RuleRefExpr); ok {
+					visit(ref.Name.Val)
+				}
+			})
+		}
+	}
+	for _, nm := range b.onlyRules {
+		if len(byName[nm]) == 0 {
+			return nil, fmt.Errorf("builder: rule %s is not declared", nm)
+		}
+		visit(nm)
+	}
+	if b.skip != "" {
+		visit(b.skip)
+	}
+
+	cp := *g
+	cp.Rules = nil
+	named := make(map[string]bool, len(b.onlyRules))
+	for _, nm := range b.onlyRules {
+		if !named[nm] {
+			named[nm] = true
+			cp.Rules = append(cp.Rules, byName[nm]...)
+		}
+	}
+	for _, r := range g.Rules {
+		if keep[r.Name.Val] && !named[r.Name.Val] {
+			cp.Rules = append(cp.Rules, r)
+		}
+	}
+	return &cp, nil
+}
+
+// exportedName returns nm with its first letter in upper case.
+func exportedName(nm string) string {
+	rn, n := utf8.DecodeRuneInString(nm)
+	return string(unicode.ToUpper(rn)) + goName(nm[n:])
+}
+
+// goName returns the rule name nm as a part of a Go identifier: the "::"
+// of the names of the rules of a namespace become "__".
+func goName(nm string) string {
+	return strings.Replace(nm, "::", "__", -1)
+}
+
+// structRules returns a copy of g where the rules that get a struct type
+// have an action that returns an instance of that type.
+func (b *builder) structRules(g *ast.Grammar) (*ast.Grammar, error) {
+	b.structLabels = make(map[string][]string)
+	types := make(map[string]string)
+	cp := *g
+	cp.Rules = make([]*ast.Rule, len(g.Rules))
+	for i, r := range g.Rules {
+		cp.Rules[i] = r
+		labels := structLabels(r)
+		if len(labels) == 0 || !b.enabled(r.Cond) {
+			continue
+		}
+
+		nm := exportedName(r.Name.Val)
+		if prev, ok := types[nm]; ok {
+			return nil, fmt.Errorf("builder: %s: rules %s and %s have the same struct type %s",
+				r.Pos(), prev, r.Name.Val, nm)
+		}
+		types[nm] = r.Name.Val
+
+		var buf bytes.Buffer
+		fields := make(map[string]string, len(labels))
+		fmt.Fprintf(&buf, "{\n\treturn %s{", nm)
+		for j, lab := range labels {
+			fld := exportedName(lab)
+			if b.spans && (fld == "Pos" || fld == "End") {
+				return nil, fmt.Errorf("builder: %s: rule %s: label %s has the same field name as the span field %s",
+					r.Pos(), r.Name.Val, lab, fld)
+			}
+			if prev, ok := fields[fld]; ok {
+				return nil, fmt.Errorf("builder: %s: rule %s: labels %s and %s have the same field name %s",
+					r.Pos(), r.Name.Val, prev, lab, fld)
+			}
+			fields[fld] = lab
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "%s: %s", fld, lab)
+		}
+		if b.spans {
+			fmt.Fprintf(&buf, ", Pos: %[1]s.pos, End: %[1]s.end", b.recvName)
+		}
+		buf.WriteString("}, nil\n}")
+
+		act := ast.NewActionExpr(r.Expr.Pos())
+		act.Expr = r.Expr
+		act.Code = ast.NewCodeBlock(r.Expr.Pos(), buf.String())
+		rcp := *r
+		rcp.Expr = act
+		cp.Rules[i] = &rcp
+		b.structLabels[r.Name.Val] = labels
+	}
+	return &cp, nil
+}
+
+// writeStructs writes the struct types of the rules, whose actions were
+// added by structRules.
+func (b *builder) writeStructs(g *ast.Grammar) {
+	for _, r := range g.Rules {
+		labels, ok := b.structLabels[r.Name.Val]
+		if !ok {
+			continue
+		}
+		nm := exportedName(r.Name.Val)
+		b.writelnf("// %s is the value of the rule %s.", nm, r.Name.Val)
+		b.writelnf("type %s struct {", nm)
+		for _, lab := range labels {
+			b.writelnf("\t%s interface{}", exportedName(lab))
+		}
+		if b.spans {
+			b.writelnf("\tPos, End position")
+		}
+		b.writelnf("}")
+		b.writelnf("")
+		if b.stringers {
+			b.writeStringer(nm, labels)
+		}
+	}
+}
+
+// writeVisitor writes the Visitor interface and the Walk function of the
+// struct types written by writeStructs.
+func (b *builder) writeVisitor(g *ast.Grammar) {
+	var names []string
+	for _, r := range g.Rules {
+		if _, ok := b.structLabels[r.Name.Val]; ok {
+			names = append(names, exportedName(r.Name.Val))
+		}
+	}
+
+	b.writelnf("// Visitor is implemented by the types that visit the values of the")
+	b.writelnf("// struct types of the rules, see Walk.")
+	b.writelnf("type Visitor interface {")
+	for _, nm := range names {
+		b.writelnf("\tEnter%[1]s(v %[1]s)", nm)
+		b.writelnf("\tExit%[1]s(v %[1]s)", nm)
+	}
+	b.writelnf("}")
+	b.writelnf("")
+
+	b.writelnf("// Walk traverses tree in depth-first order, calling the Enter method of")
+	b.writelnf("// vis for each struct value before walking its fields, and the Exit")
+	b.writelnf("// method after. The elements of the []interface{} values are walked in")
+	b.writelnf("// order, the other values are ignored.")
+	b.writelnf("func Walk(vis Visitor, tree interface{}) {")
+	b.writelnf("\tswitch v := tree.(type) {")
+	b.writelnf("\tcase []interface{}:")
+	b.writelnf("\t\tfor _, e := range v {")
+	b.writelnf("\t\t\tWalk(vis, e)")
+	b.writelnf("\t\t}")
+	for _, r := range g.Rules {
+		labels, ok := b.structLabels[r.Name.Val]
+		if !ok {
+			continue
+		}
+		nm := exportedName(r.Name.Val)
+		b.writelnf("\tcase %s:", nm)
+		b.writelnf("\t\tvis.Enter%s(v)", nm)
+		for _, lab := range labels {
+			b.writelnf("\t\tWalk(vis, v.%s)", exportedName(lab))
+		}
+		b.writelnf("\t\tvis.Exit%s(v)", nm)
+	}
+	b.writelnf("\t}")
+	b.writelnf("}")
+	b.writelnf("")
+}
+
+// writeStringer writes the String method of the struct type nm.
+func (b *builder) writeStringer(nm string, labels []string) {
+	format := make([]string, len(labels))
+	args := make([]string, len(labels))
+	for i, lab := range labels {
+		fld := exportedName(lab)
+		format[i] = fld + ":%v"
+		args[i] = "v." + fld
+	}
+	b.writelnf("// String returns the textual representation of the %s value.", nm)
+	b.writelnf("func (v %s) String() string {", nm)
+	b.writelnf("\treturn fmt.Sprintf(%q, %s)", nm+"{"+strings.Join(format, ", ")+"}", strings.Join(args, ", "))
+	b.writelnf("}")
+	b.writelnf("")
+}
+
+func (b *builder) writeRule(r *ast.Rule) {
+	if r == nil || r.Name == nil {
+		return
+	}
+
+	b.exprIndex = 0
+	b.ruleName = r.Name.Val
+	b.labels, b.backRefs, b.lengths = ruleLabels(r.Expr)
+
+	// the rule starts at its annotations, identify it by its name's line
+	b.writeComment(r.Name.Pos(), "rule "+r.Name.Val)
+	b.writeMetaComment(r.Meta)
+	b.writelnf("{")
+	b.writelnf("\tname: %q,", r.Name.Val)
+	if r.DisplayName != nil && r.DisplayName.Val != "" {
+		b.writelnf("\tdisplayName: %q,", r.DisplayName.Val)
+	}
+	if r.Lexical {
+		b.writelnf("\tlexical: true,")
+	}
+	if r.Silent {
+		b.writelnf("\tsilent: true,")
+	}
+	if r.NLSignificant {
+		b.writelnf("\tnlSignificant: true,")
+	}
+	if r.Budget > 0 {
+		b.writelnf("\tbudget: %d,", r.Budget)
+	}
+	if r.Type != "" {
+		b.writelnf("\ttyp: %q,", r.Type)
+		b.writelnf("\tisType: func(v interface{}) bool {")
+		b.writelnf("\t\t_, ok := v.(%s)", r.Type)
+		b.writelnf("\t\treturn ok")
+		b.writelnf("\t},")
+	}
+	if ast.TailRecursive(r) {
+		b.writelnf("\ttail: true,")
+	}
+	pos := r.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\texpr: ")
+	b.writeExpr(r.Expr)
+	b.writelnf("},")
+}
+
+func (b *builder) writeExpr(expr ast.Expression) {
+	b.exprIndex++
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		b.writeActionExpr(expr)
+	case *ast.AndCodeExpr:
+		b.writeAndCodeExpr(expr)
+	case *ast.AndExpr:
+		b.writeAndExpr(expr)
+	case *ast.AnyMatcher:
+		b.writeAnyMatcher(expr)
+	case *ast.BackRefExpr:
+		b.writeBackRefExpr(expr)
+	case *ast.SeenExpr:
+		b.writeSeenExpr(expr)
+	case *ast.CharClassMatcher:
+		b.writeCharClassMatcher(expr)
+	case *ast.ChoiceExpr:
+		b.writeChoiceExpr(expr)
+	case *ast.CompactExpr:
+		b.writeCompactExpr(expr)
+	case *ast.TrimExpr:
+		b.writeTrimExpr(expr)
+	case *ast.ConvertExpr:
+		b.writeConvertExpr(expr)
+	case *ast.ArrayExpr:
+		b.writeArrayExpr(expr)
+	case *ast.MapExpr:
+		b.writeMapExpr(expr)
+	case *ast.IfExpr:
+		b.writeIfExpr(expr)
+	case *skipExpr:
+		b.writeSkipExpr(expr)
+	case *ast.ByteMatcher:
+		b.writeByteMatcher(expr)
+	case *ast.BytesMatcher:
+		b.writeBytesMatcher(expr)
+	case *ast.FoldExpr:
+		b.writeFoldExpr(expr)
+	case *ast.IndentMatcher:
+		b.writeIndentMatcher(expr)
+	case *ast.KeywordMatcher:
+		b.writeKeywordMatcher(expr)
+	case *ast.WordListMatcher:
+		b.writeWordListMatcher(expr)
+	case *ast.TableMatcher:
+		b.writeTableMatcher(expr)
+	case *ast.TokenMatcher:
+		b.writeTokenMatcher(expr)
+	case *ast.LabeledExpr:
+		b.writeLabeledExpr(expr)
 	case *ast.LitMatcher:
 		b.writeLitMatcher(expr)
+	case *ast.LookbehindExpr:
+		b.writeLookbehindExpr(expr)
 	case *ast.NotCodeExpr:
 		b.writeNotCodeExpr(expr)
 	case *ast.NotExpr:
 		b.writeNotExpr(expr)
+	case *ast.NestedMatcher:
+		b.writeNestedMatcher(expr)
+	case *ast.NumberMatcher:
+		b.writeNumberMatcher(expr)
 	case *ast.OneOrMoreExpr:
 		b.writeOneOrMoreExpr(expr)
+	case *ast.OperatorsExpr:
+		b.writeOperatorsExpr(expr)
 	case *ast.RuleRefExpr:
 		b.writeRuleRefExpr(expr)
+	case *ast.SepExpr:
+		b.writeSepExpr(expr)
 	case *ast.SeqExpr:
 		b.writeSeqExpr(expr)
+	case *ast.UnreservedExpr:
+		b.writeUnreservedExpr(expr)
+	case *ast.VerbatimExpr:
+		// the skip rule is not added inside the verbatim expression, it
+		// is otherwise generated as its expression.
+		b.writeExpr(expr.Expr)
+	case *ast.RestOfLineMatcher:
+		b.writeRestOfLineMatcher(expr)
+	case *ast.UntilMatcher:
+		b.writeUntilMatcher(expr)
+	case *ast.WhenExpr:
+		b.writeWhenExpr(expr)
 	case *ast.ZeroOrMoreExpr:
 		b.writeZeroOrMoreExpr(expr)
 	case *ast.ZeroOrOneExpr:
@@ -175,183 +1433,797 @@ func (b *builder) writeActionExpr(act *ast.ActionExpr) {
 		b.writelnf("nil,")
 		return
 	}
-	act.FuncIx = b.exprIndex
-	b.writelnf("&actionExpr{")
-	pos := act.Pos()
+	if b.strip {
+		b.writeExpr(act.Expr)
+		return
+	}
+	act.FuncIx = b.exprIndex
+	b.writelnf("&actionExpr{")
+	pos := act.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\trun: (*parser).call%s,", b.funcName(act.FuncIx))
+	b.writef("\texpr: ")
+	b.writeExpr(act.Expr)
+	b.writelnf("},")
+}
+
+func (b *builder) writeAndCodeExpr(and *ast.AndCodeExpr) {
+	if and == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&andCodeExpr{")
+	pos := and.Pos()
+	and.FuncIx = b.exprIndex
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if b.strip {
+		b.writelnf("\trun: func(*parser) (bool, error) { return true, nil },")
+	} else {
+		b.writelnf("\trun: (*parser).call%s,", b.funcName(and.FuncIx))
+	}
+	b.writelnf("},")
+}
+
+func (b *builder) writeAndExpr(and *ast.AndExpr) {
+	if and == nil {
+		b.writelnf("nil,")
+		return
+	}
+	if and.Peek {
+		b.writelnf("&peekExpr{")
+	} else {
+		b.writelnf("&andExpr{")
+	}
+	pos := and.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\texpr: ")
+	b.writeExpr(and.Expr)
+	b.writelnf("},")
+}
+
+func (b *builder) writeAnyMatcher(any *ast.AnyMatcher) {
+	if any == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&anyMatcher{")
+	pos := any.Pos()
+	b.writelnf("\tline: %d, col: %d, offset: %d,", pos.Line, pos.Col, pos.Off)
+	b.writelnf("},")
+}
+
+func (b *builder) writeByteMatcher(by *ast.ByteMatcher) {
+	if by == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&byteMatcher{")
+	pos := by.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tval: %#02x,", by.Val)
+	b.writelnf("},")
+}
+
+func (b *builder) writeBackRefExpr(ref *ast.BackRefExpr) {
+	if ref == nil {
+		b.writelnf("nil,")
+		return
+	}
+	pos := ref.Pos()
+	if !b.labels[ref.Label.Val] {
+		b.err = fmt.Errorf("builder: %s: back-reference to undefined label %s", pos, ref.Label.Val)
+		return
+	}
+	b.writelnf("&backRefExpr{")
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tlabel: %q,", ref.Label.Val)
+	b.writelnf("},")
+}
+
+func (b *builder) writeSeenExpr(seen *ast.SeenExpr) {
+	if seen == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&seenExpr{")
+	pos := seen.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tlabel: %q,", seen.Label.Val)
+	b.writelnf("},")
+}
+
+// ruleLabels returns the set of labels of the labeled expressions in expr,
+// the set of labels referenced by its back-references and the set of
+// labels referenced by its bytes matchers as their length.
+func ruleLabels(expr ast.Expression) (labels, backRefs, lengths map[string]bool) {
+	labels, backRefs, lengths = make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	var visit func(ast.Expression)
+	visit = func(expr ast.Expression) {
+		ast.Walk(expr, func(expr ast.Expression) {
+			switch expr := expr.(type) {
+			case *ast.BackRefExpr:
+				backRefs[expr.Label.Val] = true
+			case *ast.BytesMatcher:
+				if expr.Label != nil {
+					lengths[expr.Label.Val] = true
+				}
+			case *ast.LabeledExpr:
+				if expr.Label != nil {
+					labels[expr.Label.Val] = true
+				}
+			case *skipExpr:
+				visit(expr.Expr)
+			}
+		})
+	}
+	visit(expr)
+	return labels, backRefs, lengths
+}
+
+// seenLabels returns the set of the labels referenced by the @seen
+// expressions of g, whose matches are recorded by the generated parser. It
+// returns an error if a label is not defined in any rule.
+func seenLabels(g *ast.Grammar) (map[string]bool, error) {
+	defined := make(map[string]bool)
+	var refs []*ast.SeenExpr
+	for _, r := range g.Rules {
+		ast.Walk(r.Expr, func(expr ast.Expression) {
+			switch expr := expr.(type) {
+			case *ast.LabeledExpr:
+				if expr.Label != nil {
+					defined[expr.Label.Val] = true
+				}
+			case *ast.SeenExpr:
+				refs = append(refs, expr)
+			}
+		})
+	}
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if !defined[ref.Label.Val] {
+			return nil, fmt.Errorf("builder: %s: @seen reference to undefined label %s", ref.Pos(), ref.Label.Val)
+		}
+		seen[ref.Label.Val] = true
+	}
+	return seen, nil
+}
+
+// mapLabels returns the sets of the key and the value labels of the @map
+// expressions of g, that are stored by the generated parser, the text of
+// the matches of the keys included. It returns an error if a label is not
+// defined in the expression of its @map.
+func mapLabels(g *ast.Grammar) (keys, vals map[string]bool, err error) {
+	keys, vals = make(map[string]bool), make(map[string]bool)
+	for _, r := range g.Rules {
+		ast.Walk(r.Expr, func(expr ast.Expression) {
+			m, ok := expr.(*ast.MapExpr)
+			if !ok || err != nil {
+				return
+			}
+			defined := make(map[string]bool)
+			ast.Walk(m.Expr, func(expr ast.Expression) {
+				if lab, ok := expr.(*ast.LabeledExpr); ok && lab.Label != nil {
+					defined[lab.Label.Val] = true
+				}
+			})
+			for _, lab := range []*ast.Identifier{m.Key, m.Val} {
+				if !defined[lab.Val] {
+					err = fmt.Errorf("builder: %s: @map label %s is not defined in its expression", m.Pos(), lab.Val)
+					return
+				}
+			}
+			keys[m.Key.Val], vals[m.Val.Val] = true, true
+		})
+	}
+	return keys, vals, err
+}
+
+// findUsedLabels sets usedLabels to the labels of the rules of g that are
+// referenced by the code blocks that receive them. The code of the rules
+// is generated and discarded, so that the scopes of the labels are those
+// of the generated code.
+func (b *builder) findUsedLabels(g *ast.Grammar) {
+	w, checkLabels := b.w, b.checkLabels
+	defer func() {
+		b.w, b.checkLabels, b.findingLabels = w, checkLabels, false
+	}()
+	b.w, b.checkLabels, b.findingLabels = ioutil.Discard, false, true
+	b.usedLabels = make(map[*ast.Identifier]bool)
+	if b.strip {
+		return
+	}
+	for _, rule := range g.Rules {
+		if b.enabled(rule.Cond) {
+			b.writeRuleCode(rule)
+		}
+	}
+}
+
+// keepLabel returns true if the value of the label lab must be stored by
+// the generated parser, because a code block, a back-reference, a @seen
+// expression, a @map expression, a bytes matcher or the span method may use
+// it.
+func (b *builder) keepLabel(lab *ast.Identifier) bool {
+	return b.usedLabels == nil || b.usedLabels[lab] || b.backRefs[lab.Val] || b.lengths[lab.Val] ||
+		b.seenLabels[lab.Val] || b.mapKeys[lab.Val] || b.mapVals[lab.Val] || b.capture
+}
+
+// hasLabels returns true if the expression expr has a labeled expression.
+func hasLabels(expr ast.Expression) bool {
+	found := false
+	ast.Walk(expr, func(expr ast.Expression) {
+		if _, ok := expr.(*ast.LabeledExpr); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+func (b *builder) writeBytesMatcher(by *ast.BytesMatcher) {
+	if by == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&bytesMatcher{")
+	pos := by.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if by.Label != nil {
+		b.writelnf("\tlabel: %q,", by.Label.Val)
+	} else {
+		b.writelnf("\tn: %d,", by.N)
+	}
+	b.writelnf("},")
+}
+
+func (b *builder) writeCharClassMatcher(ch *ast.CharClassMatcher) {
+	if ch == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&charClassMatcher{")
+	pos := ch.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tval: %q,", ch.Val)
+	if len(ch.Chars) > 0 {
+		b.writef("\tchars: []rune{")
+		for _, rn := range ch.Chars {
+			if ch.IgnoreCase {
+				b.writef("%q,", unicode.ToLower(rn))
+			} else {
+				b.writef("%q,", rn)
+			}
+		}
+		b.writelnf("},")
+	}
+	if len(ch.Ranges) > 0 {
+		b.writef("\tranges: []rune{")
+		for _, rn := range ch.Ranges {
+			if ch.IgnoreCase {
+				b.writef("%q,", unicode.ToLower(rn))
+			} else {
+				b.writef("%q,", rn)
+			}
+		}
+		b.writelnf("},")
+	}
+	if len(ch.UnicodeClasses) > 0 {
+		b.writef("\tclasses: []*unicode.RangeTable{")
+		for _, cl := range ch.UnicodeClasses {
+			b.writef("rangeTable(%q),", cl)
+		}
+		b.writelnf("},")
+		b.writef("\tclassNames: []string{")
+		for _, cl := range ch.UnicodeClasses {
+			b.writef("%q,", cl)
+		}
+		b.writelnf("},")
+	}
+	b.writelnf("\tignoreCase: %t,", ch.IgnoreCase)
+	b.writelnf("\tinverted: %t,", ch.Inverted)
+	b.writelnf("},")
+}
+
+func (b *builder) writeChoiceExpr(ch *ast.ChoiceExpr) {
+	if ch == nil {
+		b.writelnf("nil,")
+		return
+	}
+	if set := literalSet(ch); set != nil && ch != b.tagChoice && !ch.Longest {
+		b.writeLitSetMatcher(set)
+		return
+	}
+	tagged := ch == b.tagChoice
+	b.tagChoice = nil
+	b.writelnf("&choiceExpr{")
+	pos := ch.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if len(ch.Alternatives) > 0 {
+		b.writelnf("\talternatives: []interface{}{")
+		for _, alt := range ch.Alternatives {
+			if ifx, ok := alt.(*ast.IfExpr); ok && !b.enabled(ifx.Cond) {
+				continue
+			}
+			b.writeExpr(alt)
+		}
+		b.writelnf("\t},")
+	}
+	if ch.Longest {
+		b.writelnf("\tlongest: true,")
+	}
+	if tagged {
+		b.writeBranches(ch)
+	}
+	b.writelnf("},")
+}
+
+// writeBranches writes the names of the rules referenced by the
+// alternatives of the tagged choice ch, empty for the alternatives that
+// are not a rule reference.
+func (b *builder) writeBranches(ch *ast.ChoiceExpr) {
+	b.writef("\tbranches: []string{")
+	first := true
+	for _, alt := range ch.Alternatives {
+		if ifx, ok := alt.(*ast.IfExpr); ok {
+			if !b.enabled(ifx.Cond) {
+				continue
+			}
+			alt = ifx.Expr
+		}
+		var name string
+		if ref, ok := alt.(*ast.RuleRefExpr); ok {
+			name = ref.Name.Val
+		}
+		if !first {
+			b.writef(", ")
+		}
+		b.writef("%q", name)
+		first = false
+	}
+	b.writelnf("},")
+}
+
+// tagRepeated marks the choice repeated by expr, if any, to be tagged
+// with its branches when the TagBranches option is set.
+func (b *builder) tagRepeated(expr ast.Expression) {
+	if ch, ok := expr.(*ast.ChoiceExpr); ok && b.tagBranches {
+		b.tagChoice = ch
+	}
+}
+
+func (b *builder) writeIfExpr(ifx *ast.IfExpr) {
+	if ifx == nil {
+		b.writelnf("nil,")
+		return
+	}
+	if b.enabled(ifx.Cond) {
+		b.writeExpr(ifx.Expr)
+		return
+	}
+	// an excluded expression is an empty choice, that never matches.
+	b.writelnf("&choiceExpr{")
+	pos := ifx.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("},")
+}
+
+func (b *builder) writeIndentMatcher(ind *ast.IndentMatcher) {
+	if ind == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&indentMatcher{")
+	pos := ind.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tval: %q,", ind.Val)
+	b.writelnf("},")
+}
+
+func (b *builder) writeKeywordMatcher(kw *ast.KeywordMatcher) {
+	if kw == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&keywordMatcher{")
+	pos := kw.Pos()
+	b.writelnf("\tline: %d, col: %d, offset: %d,", pos.Line, pos.Col, pos.Off)
+	b.writelnf("},")
+}
+
+func (b *builder) writeWordListMatcher(wl *ast.WordListMatcher) {
+	if wl == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&wordListMatcher{")
+	pos := wl.Pos()
+	b.writelnf("\tline: %d, col: %d, offset: %d,", pos.Line, pos.Col, pos.Off)
+	b.writelnf("},")
+}
+
+func (b *builder) writeTableMatcher(tm *ast.TableMatcher) {
+	if tm == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&tableMatcher{")
+	pos := tm.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tname: %q,", tm.Val)
+	b.writelnf("},")
+}
+
+func (b *builder) writeTokenMatcher(tm *ast.TokenMatcher) {
+	if tm == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&tokenMatcher{")
+	pos := tm.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if tm.Val == "" {
+		b.writelnf("\tname: %q,", "token")
+		b.writelnf("\tany: true,")
+	} else {
+		b.writelnf("\tkind: %s,", tm.Val)
+		b.writelnf("\tname: %q,", tm.Val)
+	}
+	b.writelnf("},")
+}
+
+func (b *builder) writeLabeledExpr(lab *ast.LabeledExpr) {
+	if lab == nil {
+		b.writelnf("nil,")
+		return
+	}
+	keep := lab.Label != nil && lab.Label.Val != "" && b.keepLabel(lab.Label)
+	if !keep && !hasLabels(lab.Expr) {
+		// the value is not stored and the expression declares no label
+		// that needs a scope of its own, the label is dropped
+		b.writeExpr(lab.Expr)
+		return
+	}
+	b.writelnf("&labeledExpr{")
+	pos := lab.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if keep {
+		b.writelnf("\tlabel: %q,", lab.Label.Val)
+		if b.backRefs[lab.Label.Val] || b.mapKeys[lab.Label.Val] {
+			b.writelnf("\tcapture: true,")
+		}
+		if b.seenLabels[lab.Label.Val] {
+			b.writelnf("\tseen: true,")
+		}
+		if b.capture {
+			b.writelnf("\tspan: true,")
+		}
+	}
+	b.writef("\texpr: ")
+	b.writeExpr(lab.Expr)
+	b.writelnf("},")
+}
+
+func (b *builder) writeLitMatcher(lit *ast.LitMatcher) {
+	if lit == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&litMatcher{")
+	pos := lit.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if lit.IgnoreCase {
+		b.writelnf("\tval: %q,", strings.ToLower(lit.Val))
+	} else {
+		b.writelnf("\tval: %q,", lit.Val)
+	}
+	b.writelnf("\tignoreCase: %t,", lit.IgnoreCase)
+	b.writelnf("},")
+}
+
+func (b *builder) writeLookbehindExpr(lb *ast.LookbehindExpr) {
+	if lb == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&lookbehindExpr{")
+	pos := lb.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(act.FuncIx))
 	b.writef("\texpr: ")
-	b.writeExpr(act.Expr)
+	b.writeExpr(lb.Expr)
 	b.writelnf("},")
 }
 
-func (b *builder) writeAndCodeExpr(and *ast.AndCodeExpr) {
-	if and == nil {
+func (b *builder) writeNotCodeExpr(not *ast.NotCodeExpr) {
+	if not == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&andCodeExpr{")
-	pos := and.Pos()
-	and.FuncIx = b.exprIndex
+	b.writelnf("&notCodeExpr{")
+	pos := not.Pos()
+	not.FuncIx = b.exprIndex
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(and.FuncIx))
+	if b.strip {
+		// the predicate matches if the code returns false
+		b.writelnf("\trun: func(*parser) (bool, error) { return false, nil },")
+	} else {
+		b.writelnf("\trun: (*parser).call%s,", b.funcName(not.FuncIx))
+	}
 	b.writelnf("},")
 }
 
-func (b *builder) writeAndExpr(and *ast.AndExpr) {
-	if and == nil {
+func (b *builder) writeNotExpr(not *ast.NotExpr) {
+	if not == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&andExpr{")
-	pos := and.Pos()
+	b.writelnf("&notExpr{")
+	pos := not.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
 	b.writef("\texpr: ")
-	b.writeExpr(and.Expr)
+	b.writeExpr(not.Expr)
 	b.writelnf("},")
 }
 
-func (b *builder) writeAnyMatcher(any *ast.AnyMatcher) {
-	if any == nil {
+func (b *builder) writeNestedMatcher(nest *ast.NestedMatcher) {
+	if nest == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&anyMatcher{")
-	pos := any.Pos()
-	b.writelnf("\tline: %d, col: %d, offset: %d,", pos.Line, pos.Col, pos.Off)
+	b.writelnf("&nestedMatcher{")
+	pos := nest.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\topen: %q,", nest.Open)
+	b.writelnf("\tclose: %q,", nest.Close)
 	b.writelnf("},")
 }
 
-func (b *builder) writeCharClassMatcher(ch *ast.CharClassMatcher) {
-	if ch == nil {
+func (b *builder) writeNumberMatcher(num *ast.NumberMatcher) {
+	if num == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&charClassMatcher{")
-	pos := ch.Pos()
+	b.writelnf("&numberMatcher{")
+	pos := num.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\tval: %q,", ch.Val)
-	if len(ch.Chars) > 0 {
-		b.writef("\tchars: []rune{")
-		for _, rn := range ch.Chars {
-			if ch.IgnoreCase {
-				b.writef("%q,", unicode.ToLower(rn))
-			} else {
-				b.writef("%q,", rn)
-			}
-		}
-		b.writelnf("},")
+	if num.Float {
+		b.writelnf("\tfloat: true,")
 	}
-	if len(ch.Ranges) > 0 {
-		b.writef("\tranges: []rune{")
-		for _, rn := range ch.Ranges {
-			if ch.IgnoreCase {
-				b.writef("%q,", unicode.ToLower(rn))
-			} else {
-				b.writef("%q,", rn)
-			}
-		}
-		b.writelnf("},")
+	if num.Sign {
+		b.writelnf("\tsign: true,")
 	}
-	if len(ch.UnicodeClasses) > 0 {
-		b.writef("\tclasses: []*unicode.RangeTable{")
-		for _, cl := range ch.UnicodeClasses {
-			b.writef("rangeTable(%q),", cl)
-		}
-		b.writelnf("},")
+	if num.Prefix {
+		b.writelnf("\tprefix: true,")
+	}
+	b.writelnf("\tradix: %d,", num.Radix)
+	if num.Thousands != 0 {
+		b.writelnf("\tthousands: %q,", num.Thousands)
+	}
+	if num.Decimal != 0 {
+		b.writelnf("\tdecimal: %q,", num.Decimal)
 	}
-	b.writelnf("\tignoreCase: %t,", ch.IgnoreCase)
-	b.writelnf("\tinverted: %t,", ch.Inverted)
 	b.writelnf("},")
 }
 
-func (b *builder) writeChoiceExpr(ch *ast.ChoiceExpr) {
-	if ch == nil {
+func (b *builder) writeOneOrMoreExpr(one *ast.OneOrMoreExpr) {
+	if one == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&choiceExpr{")
-	pos := ch.Pos()
+	b.writelnf("&oneOrMoreExpr{")
+	pos := one.Pos()
+	ix := b.exprIndex
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	if len(ch.Alternatives) > 0 {
-		b.writelnf("\talternatives: []interface{}{")
-		for _, alt := range ch.Alternatives {
-			b.writeExpr(alt)
+	b.writef("\texpr: ")
+	b.tagRepeated(one.Expr)
+	b.writeExpr(one.Expr)
+	b.writeWhile(one.While, ix)
+	b.writelnf("},")
+}
+
+func (b *builder) writeOperatorsExpr(ops *ast.OperatorsExpr) {
+	if ops == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&operatorsExpr{")
+	pos := ops.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\toperand: ")
+	b.writeExpr(ops.Operand)
+	if len(ops.Operators) > 0 {
+		// try the longest operators first, so that e.g. "**" is not
+		// matched as "*".
+		sorted := make([]*ast.Operator, len(ops.Operators))
+		copy(sorted, ops.Operators)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return len(sorted[i].Lit.Val) > len(sorted[j].Lit.Val)
+		})
+
+		b.writelnf("\tops: []*binaryOp{")
+		for _, op := range sorted {
+			b.writelnf("{")
+			b.writef("\tlit: ")
+			b.writeLitMatcher(op.Lit)
+			b.writelnf("\tprec: %d,", op.Prec)
+			b.writelnf("\trightAssoc: %t,", op.RightAssoc)
+			b.writelnf("},")
 		}
 		b.writelnf("\t},")
 	}
 	b.writelnf("},")
 }
 
-func (b *builder) writeLabeledExpr(lab *ast.LabeledExpr) {
-	if lab == nil {
+func (b *builder) writeFoldExpr(fold *ast.FoldExpr) {
+	if fold == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&labeledExpr{")
-	pos := lab.Pos()
+	if !isFoldable(fold.Expr) {
+		b.err = fmt.Errorf("builder: %s: fold expression must be a sequence of an operand and a repetition of operator and operand pairs", fold.Pos())
+		return
+	}
+	b.writelnf("&foldExpr{")
+	pos := fold.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	if lab.Label != nil && lab.Label.Val != "" {
-		b.writelnf("\tlabel: %q,", lab.Label.Val)
+	b.writef("\texpr: ")
+	b.writeExpr(fold.Expr)
+	b.writelnf("\tright: %t,", fold.Right)
+	b.writelnf("},")
+}
+
+// isFoldable returns true if expr is a sequence of an operand followed by
+// a repetition of sequences of an operator and an operand, the structure
+// expected by the fold expression.
+func isFoldable(expr ast.Expression) bool {
+	unlabel := func(expr ast.Expression) ast.Expression {
+		if lab, ok := expr.(*ast.LabeledExpr); ok {
+			return lab.Expr
+		}
+		return expr
+	}
+
+	seq, ok := expr.(*ast.SeqExpr)
+	if !ok || len(seq.Exprs) != 2 {
+		return false
+	}
+	var pair ast.Expression
+	switch rep := unlabel(seq.Exprs[1]).(type) {
+	case *ast.ZeroOrMoreExpr:
+		pair = rep.Expr
+	case *ast.OneOrMoreExpr:
+		pair = rep.Expr
+	default:
+		return false
+	}
+	seq, ok = pair.(*ast.SeqExpr)
+	return ok && len(seq.Exprs) == 2
+}
+
+func (b *builder) writeUnreservedExpr(un *ast.UnreservedExpr) {
+	if un == nil {
+		b.writelnf("nil,")
+		return
 	}
+	b.writelnf("&unreservedExpr{")
+	pos := un.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
 	b.writef("\texpr: ")
-	b.writeExpr(lab.Expr)
+	b.writeExpr(un.Expr)
 	b.writelnf("},")
 }
 
-func (b *builder) writeLitMatcher(lit *ast.LitMatcher) {
-	if lit == nil {
+func (b *builder) writeCompactExpr(comp *ast.CompactExpr) {
+	if comp == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&litMatcher{")
-	pos := lit.Pos()
+	b.writelnf("&compactExpr{")
+	pos := comp.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	if lit.IgnoreCase {
-		b.writelnf("\tval: %q,", strings.ToLower(lit.Val))
-	} else {
-		b.writelnf("\tval: %q,", lit.Val)
+	b.writef("\texpr: ")
+	b.writeExpr(comp.Expr)
+	b.writelnf("},")
+}
+
+func (b *builder) writeTrimExpr(trim *ast.TrimExpr) {
+	if trim == nil {
+		b.writelnf("nil,")
+		return
 	}
-	b.writelnf("\tignoreCase: %t,", lit.IgnoreCase)
+	b.writelnf("&trimExpr{")
+	pos := trim.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\texpr: ")
+	b.writeExpr(trim.Expr)
 	b.writelnf("},")
 }
 
-func (b *builder) writeNotCodeExpr(not *ast.NotCodeExpr) {
-	if not == nil {
+func (b *builder) writeConvertExpr(conv *ast.ConvertExpr) {
+	if conv == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&notCodeExpr{")
-	pos := not.Pos()
-	not.FuncIx = b.exprIndex
+	b.writelnf("&convertExpr{")
+	pos := conv.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(not.FuncIx))
+	b.writelnf("\tname: %q,", conv.Name.Val)
+	b.writef("\texpr: ")
+	b.writeExpr(conv.Expr)
 	b.writelnf("},")
 }
 
-func (b *builder) writeNotExpr(not *ast.NotExpr) {
-	if not == nil {
+func (b *builder) writeMapExpr(m *ast.MapExpr) {
+	if m == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&notExpr{")
-	pos := not.Pos()
+	b.writelnf("&mapExpr{")
+	pos := m.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tkey: %q,", m.Key.Val)
+	b.writelnf("\tval: %q,", m.Val.Val)
+	if m.Last {
+		b.writelnf("\tlast: true,")
+	}
 	b.writef("\texpr: ")
-	b.writeExpr(not.Expr)
+	b.writeExpr(m.Expr)
 	b.writelnf("},")
 }
 
-func (b *builder) writeOneOrMoreExpr(one *ast.OneOrMoreExpr) {
-	if one == nil {
+func (b *builder) writeArrayExpr(arr *ast.ArrayExpr) {
+	if arr == nil {
 		b.writelnf("nil,")
 		return
 	}
-	b.writelnf("&oneOrMoreExpr{")
-	pos := one.Pos()
+	typ := arr.Type
+	if typ == "" {
+		typ = "interface{}"
+	}
+	b.writelnf("&arrayExpr{")
+	pos := arr.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\ttyp: %q,", typ)
+	// the elements are stored in an array of the type, without an
+	// intermediate slice of values
+	b.writelnf("\tcollect: func(p *parser, arr *arrayExpr) (interface{}, interface{}, bool) {")
+	b.writelnf("\t\tvar a [%d]%s", arr.N, typ)
+	b.writelnf("\t\tfor i := range a {")
+	b.writelnf("\t\t\tval, ok := p.parseArrayElem(arr)")
+	b.writelnf("\t\t\tif !ok {")
+	b.writelnf("\t\t\t\treturn nil, nil, false")
+	b.writelnf("\t\t\t}")
+	b.writelnf("\t\t\tif val == nil {")
+	b.writelnf("\t\t\t\tcontinue")
+	b.writelnf("\t\t\t}")
+	b.writelnf("\t\t\tif a[i], ok = val.(%s); !ok {", typ)
+	b.writelnf("\t\t\t\treturn nil, val, false")
+	b.writelnf("\t\t\t}")
+	b.writelnf("\t\t}")
+	b.writelnf("\t\treturn a, nil, true")
+	b.writelnf("\t},")
 	b.writef("\texpr: ")
-	b.writeExpr(one.Expr)
+	b.writeExpr(arr.Expr)
+	b.writelnf("},")
+}
+
+func (b *builder) writeSepExpr(sep *ast.SepExpr) {
+	if sep == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&sepExpr{")
+	pos := sep.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\texpr: ")
+	b.writeExpr(sep.Expr)
+	b.writef("\tsep: ")
+	b.writeExpr(sep.Sep)
+	b.writelnf("\ttrailing: %t,", sep.Trailing)
+	if sep.Terminated {
+		b.writelnf("\tterminated: true,")
+	}
+	if sep.Keep {
+		b.writelnf("\tkeep: true,")
+	}
 	b.writelnf("},")
 }
 
@@ -360,6 +2232,16 @@ func (b *builder) writeRuleRefExpr(ref *ast.RuleRefExpr) {
 		b.writelnf("nil,")
 		return
 	}
+	if ref.Name != nil {
+		if m, ok := b.trivial[ref.Name.Val]; ok {
+			// keep the expression index unchanged, so that the names
+			// of the code blocks' functions do not depend on inlining.
+			ix := b.exprIndex
+			b.writeExpr(m)
+			b.exprIndex = ix
+			return
+		}
+	}
 	b.writelnf("&ruleRefExpr{")
 	pos := ref.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -374,6 +2256,10 @@ func (b *builder) writeSeqExpr(seq *ast.SeqExpr) {
 		b.writelnf("nil,")
 		return
 	}
+	if set := literalSet(seq); set != nil {
+		b.writeLitSetMatcher(set)
+		return
+	}
 	b.writelnf("&seqExpr{")
 	pos := seq.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -387,6 +2273,54 @@ func (b *builder) writeSeqExpr(seq *ast.SeqExpr) {
 	b.writelnf("},")
 }
 
+func (b *builder) writeRestOfLineMatcher(rest *ast.RestOfLineMatcher) {
+	if rest == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&restOfLineMatcher{")
+	pos := rest.Pos()
+	b.writelnf("\tline: %d, col: %d, offset: %d,", pos.Line, pos.Col, pos.Off)
+	b.writelnf("},")
+}
+
+func (b *builder) writeUntilMatcher(until *ast.UntilMatcher) {
+	if until == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&untilMatcher{")
+	pos := until.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tval: %q,", until.Val)
+	b.writelnf("},")
+}
+
+// writeWhile writes the condition of a repetition. The condition's
+// function is named after the index ix of the repetition itself, as
+// the condition is not an expression of its own.
+func (b *builder) writeWhile(while *ast.AndCodeExpr, ix int) {
+	if while == nil || b.strip {
+		return
+	}
+	while.FuncIx = ix
+	b.writelnf("\twhile: (*parser).call%s,", b.funcName(while.FuncIx))
+}
+
+func (b *builder) writeWhenExpr(when *ast.WhenExpr) {
+	if when == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.writelnf("&whenExpr{")
+	pos := when.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tflag: %q,", when.Flag.Val)
+	b.writef("\texpr: ")
+	b.writeExpr(when.Expr)
+	b.writelnf("},")
+}
+
 func (b *builder) writeZeroOrMoreExpr(zero *ast.ZeroOrMoreExpr) {
 	if zero == nil {
 		b.writelnf("nil,")
@@ -394,9 +2328,12 @@ func (b *builder) writeZeroOrMoreExpr(zero *ast.ZeroOrMoreExpr) {
 	}
 	b.writelnf("&zeroOrMoreExpr{")
 	pos := zero.Pos()
+	ix := b.exprIndex
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
 	b.writef("\texpr: ")
+	b.tagRepeated(zero.Expr)
 	b.writeExpr(zero.Expr)
+	b.writeWhile(zero.While, ix)
 	b.writelnf("},")
 }
 
@@ -407,9 +2344,14 @@ func (b *builder) writeZeroOrOneExpr(zero *ast.ZeroOrOneExpr) {
 	}
 	b.writelnf("&zeroOrOneExpr{")
 	pos := zero.Pos()
+	ix := b.exprIndex
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
 	b.writef("\texpr: ")
 	b.writeExpr(zero.Expr)
+	if zero.Default != nil && !b.strip {
+		zero.FuncIx = ix
+		b.writelnf("\tdflt: (*parser).call%s,", b.funcName(zero.FuncIx))
+	}
 	b.writelnf("},")
 }
 
@@ -439,7 +2381,7 @@ func (b *builder) addArg(arg *ast.Identifier) {
 		return
 	}
 	ix := len(b.argsStack) - 1
-	b.argsStack[ix] = append(b.argsStack[ix], arg.Val)
+	b.argsStack[ix] = append(b.argsStack[ix], arg)
 }
 
 func (b *builder) writeExprCode(expr ast.Expression) {
@@ -470,6 +2412,30 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 			b.writeExprCode(alt)
 			b.popArgsSet()
 		}
+	case *ast.CompactExpr:
+		b.writeExprCode(expr.Expr)
+	case *ast.TrimExpr:
+		b.writeExprCode(expr.Expr)
+	case *ast.ConvertExpr:
+		b.writeExprCode(expr.Expr)
+	case *ast.ArrayExpr:
+		b.pushArgsSet()
+		b.writeExprCode(expr.Expr)
+		b.popArgsSet()
+	case *ast.MapExpr:
+		b.pushArgsSet()
+		b.writeExprCode(expr.Expr)
+		b.popArgsSet()
+	case *ast.FoldExpr:
+		b.writeExprCode(expr.Expr)
+	case *ast.IfExpr:
+		if b.enabled(expr.Cond) {
+			b.writeExprCode(expr.Expr)
+		}
+	case *ast.WhenExpr:
+		b.writeExprCode(expr.Expr)
+	case *skipExpr:
+		b.writeExprCode(expr.Expr)
 	case *ast.NotExpr:
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
@@ -478,6 +2444,22 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
 		b.popArgsSet()
+		b.writeWhileCode(expr.While)
+	case *ast.OperatorsExpr:
+		b.pushArgsSet()
+		b.writeExprCode(expr.Operand)
+		b.popArgsSet()
+	case *ast.UnreservedExpr:
+		b.writeExprCode(expr.Expr)
+	case *ast.VerbatimExpr:
+		b.writeExprCode(expr.Expr)
+	case *ast.SepExpr:
+		b.pushArgsSet()
+		b.writeExprCode(expr.Expr)
+		b.popArgsSet()
+		b.pushArgsSet()
+		b.writeExprCode(expr.Sep)
+		b.popArgsSet()
 	case *ast.SeqExpr:
 		for _, sub := range expr.Exprs {
 			b.writeExprCode(sub)
@@ -486,10 +2468,14 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
 		b.popArgsSet()
+		b.writeWhileCode(expr.While)
 	case *ast.ZeroOrOneExpr:
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
 		b.popArgsSet()
+		if !b.strip {
+			b.writeFunc(expr.FuncIx, expr.Default, callFuncTemplate, onFuncTemplate)
+		}
 	}
 }
 
@@ -514,7 +2500,16 @@ func (b *builder) writeNotCodeExprCode(not *ast.NotCodeExpr) {
 	b.writeFunc(not.FuncIx, not.Code, callPredFuncTemplate, onPredFuncTemplate)
 }
 
-func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl string) {
+// writeWhileCode writes the condition of a repetition, which receives the
+// values matched so far as its last argument, named acc.
+func (b *builder) writeWhileCode(while *ast.AndCodeExpr) {
+	if while == nil {
+		return
+	}
+	b.writeFunc(while.FuncIx, while.Code, callWhileFuncTemplate, onPredFuncTemplate, "acc")
+}
+
+func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl string, acc ...string) {
 	if code == nil {
 		return
 	}
@@ -527,20 +2522,48 @@ func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl st
 	}
 	var args bytes.Buffer
 	ix := len(b.argsStack) - 1
+	if b.findingLabels {
+		if ix >= 0 {
+			idents, ok := codeIdents(code)
+			for _, arg := range b.argsStack[ix] {
+				if !ok || idents[arg.Val] {
+					b.usedLabels[arg] = true
+				}
+			}
+		}
+		return
+	}
 	if ix >= 0 {
 		for i, arg := range b.argsStack[ix] {
 			if i > 0 {
 				args.WriteString(", ")
 			}
-			args.WriteString(arg)
+			args.WriteString(arg.Val)
 		}
 	}
 	if args.Len() > 0 {
 		args.WriteString(" interface{}")
 	}
+	for _, arg := range acc {
+		if args.Len() > 0 {
+			args.WriteString(", ")
+		}
+		args.WriteString(arg + " []interface{}")
+	}
 
 	fnNm := b.funcName(funcIx)
-	b.writelnf(funcTpl, b.recvName, fnNm, args.String(), val)
+	b.writeComment(code.Pos(), "code block of rule "+b.ruleName)
+	b.writelnf(funcTpl, b.recvName, b.ctxType, fnNm, args.String(), val)
+
+	if b.checkLabels && b.err == nil {
+		params := []string{b.recvName}
+		if ix >= 0 {
+			for _, arg := range b.argsStack[ix] {
+				params = append(params, arg.Val)
+			}
+		}
+		b.err = b.checkCode(code, append(params, acc...))
+	}
 
 	args.Reset()
 	if ix >= 0 {
@@ -548,18 +2571,98 @@ func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl st
 			if i > 0 {
 				args.WriteString(", ")
 			}
-			args.WriteString(fmt.Sprintf(`stack[%q]`, arg))
+			args.WriteString(fmt.Sprintf(`stack[%q]`, arg.Val))
+		}
+	}
+	for _, arg := range acc {
+		if args.Len() > 0 {
+			args.WriteString(", ")
 		}
+		args.WriteString(arg)
 	}
 	b.writelnf(callTpl, fnNm, args.String())
 }
 
-func (b *builder) writeStaticCode() {
-	b.writelnf(staticCode)
+// writeComment writes a comment identifying the line of pos in the grammar,
+// if the Comments option is set. The comment contains the line of the
+// grammar if the source is available, otherwise the description desc.
+func (b *builder) writeComment(pos ast.Pos, desc string) {
+	if !b.comments {
+		return
+	}
+	if line := b.sourceLine(pos.Line); line != "" {
+		desc = line
+	}
+	b.writelnf("// line %d: %s", pos.Line, desc)
+}
+
+// writeMetaComment writes a comment line for each key of the metadata of a
+// rule, sorted by key, if the Comments option is set.
+func (b *builder) writeMetaComment(meta map[string]string) {
+	if !b.comments || len(meta) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.writelnf("// %s: %s", k, strings.Replace(meta[k], "\n", " ", -1))
+	}
+}
+
+// sourceLine returns the trimmed line n of the grammar's source, truncated
+// to maxCommentLen bytes.
+func (b *builder) sourceLine(n int) string {
+	if b.src == nil {
+		return ""
+	}
+	if b.srcLines == nil {
+		b.srcLines = bytes.Split(b.src, []byte("\n"))
+	}
+	if n < 1 || n > len(b.srcLines) {
+		return ""
+	}
+	line := strings.TrimSpace(string(b.srcLines[n-1]))
+	if len(line) > maxCommentLen {
+		i := maxCommentLen
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		line = line[:i] + "..."
+	}
+	return line
+}
+
+// writeStaticCode writes the code common to all parsers, with the fields
+// of the grammar added to the current struct, and the state struct with
+// the fields of the state of the grammar.
+func (b *builder) writeStaticCode(fields, state *ast.CodeBlock) {
+	b.writelnf(staticCode, blockFields(fields), b.ctxType)
+	if state != nil {
+		b.writelnf(stateCode, blockFields(state))
+	} else {
+		b.writelnf("%s", noStateCode)
+	}
+	if b.noPanic {
+		b.writelnf("%s", noPanicCode)
+	} else {
+		b.writelnf("%s", panicCode)
+	}
+}
+
+// blockFields returns the content of the code block of fields without its
+// braces, or an empty string if it is nil.
+func blockFields(fields *ast.CodeBlock) string {
+	if fields == nil {
+		return ""
+	}
+	return strings.TrimSpace(fields.Val[1:len(fields.Val)-1]) + "\n"
 }
 
 func (b *builder) funcName(ix int) string {
-	return "on" + b.ruleName + strconv.Itoa(ix)
+	return "on" + goName(b.ruleName) + strconv.Itoa(ix)
 }
 
 func (b *builder) writef(f string, args ...interface{}) {
@@ -1,10 +1,22 @@
 package builder
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/craiggwilson/pigeon/ast"
 	"github.com/craiggwilson/pigeon/bootstrap"
 )
 
@@ -38,3 +50,1662 @@ func TestBuildParser(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBuildUntilMatcher(t *testing.T) {
+	seq := ast.NewSeqExpr(ast.Pos{})
+	seq.Exprs = []ast.Expression{
+		ast.NewLitMatcher(ast.Pos{}, "/*"),
+		ast.NewUntilMatcher(ast.Pos{Line: 1, Col: 15, Off: 14}, "*/"),
+		ast.NewLitMatcher(ast.Pos{}, "*/"),
+	}
+	r := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "Comment"))
+	r.Expr = seq
+	g := ast.NewGrammar(ast.Pos{})
+	g.Rules = []*ast.Rule{r}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	want := "&untilMatcher{\n\tpos: position{line: 1, col: 15, offset: 14},\n\tval: \"*/\",\n},"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+}
+
+func TestBuildPackageName(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("{\npackage main\n\nvar x = 1\n}\nstart = 'a'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, PackageName("foo")); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "package foo\n") {
+		t.Errorf("want first line %q, got %q", "package foo", strings.SplitN(out, "\n", 2)[0])
+	}
+	if strings.Contains(out, "package main") {
+		t.Errorf("want initializer's package clause removed")
+	}
+	if !strings.Contains(out, "var x = 1") {
+		t.Errorf("want initializer's code preserved")
+	}
+
+	for _, nm := range []string{"1abc", "func", "_", "a-b"} {
+		if err := BuildParser(ioutil.Discard, g, PackageName(nm)); err == nil {
+			t.Errorf("%q: want error, got none", nm)
+		}
+	}
+}
+
+func TestBuildEmbedSource(t *testing.T) {
+	srcs := []string{
+		grammar,
+		"{\npackage main\n}\nstart = \"`\" '\\r'\r\n",
+	}
+	for _, src := range srcs {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, PackageName("test"), EmbedSource(true), Source([]byte(src))); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "func GrammarSource() string {") {
+			t.Errorf("want generated code to contain the GrammarSource accessor")
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), "", out, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := constValue(f, "grammarSource")
+		if !ok {
+			t.Fatal("want grammarSource constant, got none")
+		}
+		if got != src {
+			t.Errorf("want grammarSource %q, got %q", src, got)
+		}
+	}
+}
+
+func TestBuildHTTPHandler(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, PackageName("test")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "func ServeParse(") {
+		t.Errorf("want no ServeParse handler by default")
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, PackageName("test"), EmitHTTPHandler(true)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "func ServeParse(w http.ResponseWriter, r *http.Request) {") {
+		t.Errorf("want generated code to contain the ServeParse handler")
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, 0); err != nil {
+		t.Errorf("want valid Go code, got %v", err)
+	}
+}
+
+func TestBuildMain(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, PackageName("main")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "func main() {") {
+		t.Errorf("want no main function by default")
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, PackageName("main"), EmitMain(true)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "func main() {") {
+		t.Errorf("want generated code to contain the main function")
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, 0); err != nil {
+		t.Errorf("want valid Go code, got %v", err)
+	}
+
+	buf.Reset()
+	want := "builder: the package must be main to generate the main function"
+	if err := BuildParser(&buf, g, PackageName("test"), EmitMain(true)); err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+// constValue returns the value of the string constant nm declared in f.
+func constValue(f *goast.File, nm string) (string, bool) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*goast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*goast.ValueSpec)
+			if len(vs.Names) != 1 || vs.Names[0].Name != nm || len(vs.Values) != 1 {
+				continue
+			}
+			lit, ok := vs.Values[0].(*goast.BasicLit)
+			if !ok {
+				return "", false
+			}
+			s, err := strconv.Unquote(lit.Value)
+			return s, err == nil
+		}
+	}
+	return "", false
+}
+
+func TestBuildDefine(t *testing.T) {
+	ch := ast.NewChoiceExpr(ast.Pos{})
+	ifx := ast.NewIfExpr(ast.Pos{})
+	ifx.Cond = ast.NewIdentifier(ast.Pos{}, "ext")
+	ifx.Expr = ast.NewLitMatcher(ast.Pos{}, "gated")
+	ch.Alternatives = []ast.Expression{ast.NewLitMatcher(ast.Pos{}, "a"), ifx}
+	r1 := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "A"))
+	r1.Expr = ch
+	r2 := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "Ext"))
+	r2.Cond = ast.NewIdentifier(ast.Pos{}, "ext")
+	r2.Expr = ast.NewLitMatcher(ast.Pos{}, "b")
+	g := ast.NewGrammar(ast.Pos{})
+	g.Rules = []*ast.Rule{r1, r2}
+
+	cases := []struct {
+		opts []Option
+		want bool
+	}{
+		{nil, false},
+		{[]Option{Define("other")}, false},
+		{[]Option{Define("ext")}, true},
+	}
+	for i, tc := range cases {
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if got := strings.Contains(out, `val: "gated",`); got != tc.want {
+			t.Errorf("%d: want gated alternative %t, got %t", i, tc.want, got)
+		}
+		if got := strings.Contains(out, `name: "Ext",`); got != tc.want {
+			t.Errorf("%d: want gated rule %t, got %t", i, tc.want, got)
+		}
+		if !strings.Contains(out, `val: "a",`) {
+			t.Errorf("%d: want ungated alternative", i)
+		}
+	}
+}
+
+func TestBuildInlineTrivialRules(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(`
+start = Digit+ Named { return nil, nil }
+Digit = [0-9]
+Named "named" = 'x'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "&ruleRefExpr{\n\tpos: position{line: 2, col: 9, offset: 9},\n\tname: \"Digit\",") {
+		t.Errorf("want reference to Digit inlined")
+	}
+	if !strings.Contains(out, "&oneOrMoreExpr{\n\tpos: position{line: 2, col: 9, offset: 9},\n\texpr: &charClassMatcher{") {
+		t.Errorf("want Digit's character class at the reference")
+	}
+	if !strings.Contains(out, "\tname: \"Named\",\n},") {
+		t.Errorf("want reference to a rule with a display name not inlined")
+	}
+	// the action's function is named after its expression index, which
+	// does not depend on inlining.
+	if !strings.Contains(out, "onstart1()") {
+		t.Errorf("want action function onstart1")
+	}
+}
+
+func TestBuildSilentRule(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = 'a' Sp 'b'\nSp = ' '\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Rules[1].Silent = true
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "\tname: \"Sp\",\n\tsilent: true,") {
+		t.Errorf("want the silent rule marked as silent")
+	}
+	if !strings.Contains(out, "&ruleRefExpr{\n\tpos: position{line: 1, col: 13, offset: 12},\n\tname: \"Sp\",") {
+		t.Errorf("want reference to the silent rule not inlined")
+	}
+}
+
+func TestBuildInlineRule(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = Digit ( ',' Digit )*\nDigit = '0' / [1-9] [0-9]*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Rules[1].Inline = true
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "name: \"Digit\"") {
+		t.Errorf("want the @inline rule not in the rules of the grammar")
+	}
+	if n := strings.Count(out, "\tval: \"[1-9]\","); n != 2 {
+		t.Errorf("want the expression of the @inline rule at its 2 references, got %d", n)
+	}
+}
+
+func TestBuildInlineRuleErrors(t *testing.T) {
+	cases := map[string]string{
+		"a = b\nb = 'b' { return nil, nil }": "builder: 2:5 (10): the @inline rule b cannot have code blocks or labels",
+		"a = b\nb = x:'b'":                   "builder: 2:5 (10): the @inline rule b cannot have code blocks or labels",
+		"a = b\nb = 'b' b?":                  "builder: 2:1 (6): the @inline rule b references itself",
+		"a = 'a'":                            "builder: 1:1 (0): the first rule a cannot be @inline",
+	}
+	for src, want := range cases {
+		g, err := bootstrap.NewParser().Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Rules[len(g.Rules)-1].Inline = true
+		err = BuildParser(ioutil.Discard, g)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != want {
+			t.Errorf("%q: want error %q, got %q", src, want, got)
+		}
+	}
+}
+
+func TestBuildImports(t *testing.T) {
+	src := "{\npackage test\n\nimport \"strconv\"\n}\nA = [0-9]+ {\n\treturn strconv.Atoi(string(c.text))\n}\n"
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, Imports("strconv", "math/big", "strconv")); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "\"strconv\""); n != 1 {
+		t.Errorf("want strconv imported once, got %d imports", n)
+	}
+	want := "package test\n\nimport (\n\t\"math/big\"\n)\n\nimport \"strconv\""
+	if !strings.Contains(out, want) {
+		t.Errorf("want the import block %q after the package clause", want)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("want valid Go code, got %v", err)
+	}
+	var paths []string
+	for _, spec := range f.Imports {
+		paths = append(paths, spec.Path.Value)
+	}
+	if got := strings.Join(paths, " "); got != "\"math/big\" \"strconv\"" {
+		t.Errorf("want imports of math/big and strconv, got %s", got)
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, PackageName("other"), Imports("strconv", "math/big")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "package other\n\nimport (\n\t\"math/big\"\n)\n") {
+		t.Errorf("want the import block after the package clause of the PackageName option, got %.60q", buf.String())
+	}
+}
+
+func TestBuildContextType(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("A = 'a' {\n\treturn nil, nil\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, PackageName("test"), ContextType("pctx")); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"type pctx struct {", "\tcur      pctx\n", "func (cur *pctx) warn(", "func (c *pctx) onA1() (interface{}, error) {"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+	if strings.Contains(out, "current struct") || strings.Contains(out, "*current)") {
+		t.Errorf("want no current type in the generated code")
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, 0); err != nil {
+		t.Errorf("want valid Go code, got %v", err)
+	}
+}
+
+func TestBuildLongestChoice(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = 'a' / \"ab\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Rules[0].Expr.(*ast.ChoiceExpr).Longest = true
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "\tlongest: true,\n},") {
+		t.Errorf("want the choice marked as longest")
+	}
+	if strings.Contains(out, "&litSetMatcher{") {
+		t.Errorf("want the longest choice not replaced by a set of literals")
+	}
+}
+
+func TestBuildTrimExpr(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = [a-z ]+\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trim := ast.NewTrimExpr(ast.Pos{})
+	trim.Expr = g.Rules[0].Expr
+	g.Rules[0].Expr = trim
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "expr: &trimExpr{") {
+		t.Errorf("want the trim expression in the grammar")
+	}
+	if strings.Contains(out, "func (c *current) on") || strings.Contains(out, "callonstart") {
+		t.Errorf("want no action thunk for the trim expression")
+	}
+}
+
+func TestBuildConvertExpr(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = [0-9]+\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv := ast.NewConvertExpr(ast.Pos{})
+	conv.Name = ast.NewIdentifier(ast.Pos{}, "int")
+	conv.Expr = g.Rules[0].Expr
+	g.Rules[0].Expr = conv
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "expr: &convertExpr{\n\tpos: position{line: 0, col: 0, offset: 0},\n\tname: \"int\",") {
+		t.Errorf("want the convert expression in the grammar")
+	}
+	if strings.Contains(out, "func (c *current) on") || strings.Contains(out, "callonstart") {
+		t.Errorf("want no action thunk for the convert expression")
+	}
+}
+
+func TestBuildMapExpr(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = k:[a-z]+ '=' v:[0-9]+\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ast.NewMapExpr(ast.Pos{})
+	m.Key = ast.NewIdentifier(ast.Pos{}, "k")
+	m.Val = ast.NewIdentifier(ast.Pos{}, "v")
+	m.Expr = g.Rules[0].Expr
+	g.Rules[0].Expr = m
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"expr: &mapExpr{\n\tpos: position{line: 0, col: 0, offset: 0},\n\tkey: \"k\",\n\tval: \"v\",",
+		// the labels are kept without a code block, the text of the key
+		// too
+		"\tlabel: \"k\",\n\tcapture: true,",
+		"\tlabel: \"v\",\n\texpr:",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	m.Val = ast.NewIdentifier(ast.Pos{}, "x")
+	err = BuildParser(&buf, g)
+	if err == nil || !strings.Contains(err.Error(), "@map label x is not defined in its expression") {
+		t.Errorf("want an undefined label error, got %v", err)
+	}
+}
+
+func TestBuildComments(t *testing.T) {
+	src := "{\npackage main\n}\nA = 'a' B {\n\treturn nil, nil\n}\nB = 'b'\n"
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, Comments(true), Source([]byte(src))); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"// line 4: A = 'a' B {\n{\n\tname: \"A\",",
+		"// line 4: A = 'a' B {\nfunc (c *current) onA1()",
+		"// line 7: B = 'b'\n{\n\tname: \"B\",",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	// without the source, the comment describes the node
+	buf.Reset()
+	if err := BuildParser(&buf, g, Comments(true)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "// line 4: rule A\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+
+	// no comment by default
+	buf.Reset()
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "// line ") {
+		t.Errorf("want no position comment by default")
+	}
+}
+
+func TestBuildStripActions(t *testing.T) {
+	lab := ast.NewLabeledExpr(ast.Pos{})
+	lab.Label = ast.NewIdentifier(ast.Pos{}, "n")
+	lab.Expr = ast.NewCharClassMatcher(ast.Pos{}, "[0-9]")
+	and := ast.NewAndCodeExpr(ast.Pos{Line: 9, Col: 13, Off: 52})
+	and.Code = ast.NewCodeBlock(ast.Pos{}, "{ return c.n < 10, nil }")
+	not := ast.NewNotCodeExpr(ast.Pos{})
+	not.Code = ast.NewCodeBlock(ast.Pos{}, "{ return false, nil }")
+	seq := ast.NewSeqExpr(ast.Pos{})
+	seq.Exprs = []ast.Expression{lab, and, not}
+	act := ast.NewActionExpr(ast.Pos{})
+	act.Expr = seq
+	act.Code = ast.NewCodeBlock(ast.Pos{}, "{\n\treturn strconv.Atoi(string(c.text))\n}")
+	r := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "A"))
+	r.Expr = act
+	g := ast.NewGrammar(ast.Pos{})
+	g.Init = ast.NewCodeBlock(ast.Pos{}, "{\npackage x\n\nimport \"strconv\"\n}")
+	g.Fields = ast.NewCodeBlock(ast.Pos{}, "{\n\tn int\n}")
+	g.Rules = []*ast.Rule{r}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, StripActions(true)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "package x\n") {
+		t.Errorf("want the package clause of the initializer first, got %q", out[:20])
+	}
+	for _, notWant := range []string{"strconv", "actionExpr{", "func (c *current)", "callonA", "\tn int"} {
+		if strings.Contains(out, notWant) {
+			t.Errorf("want generated code not to contain %q", notWant)
+		}
+	}
+	for _, want := range []string{
+		"&andCodeExpr{\n\tpos: position{line: 9, col: 13, offset: 52},\n\trun: func(*parser) (bool, error) { return true, nil },",
+		"\trun: func(*parser) (bool, error) { return false, nil },",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	// with a package name, the initializer is not written at all
+	buf.Reset()
+	if err := BuildParser(&buf, g, StripActions(true), PackageName("y")); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.HasPrefix(out, "package y\nvar g = ") {
+		t.Errorf("want only the package name before the grammar, got %q", out[:20])
+	}
+}
+
+func TestBuildMetaComments(t *testing.T) {
+	src := "A = B\n@meta(doc=\"the B rule\", see=`A`)\nB = 'b'\n"
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, Comments(true), Source([]byte(src))); err != nil {
+		t.Fatal(err)
+	}
+	if want := "// line 3: B = 'b'\n// doc: the B rule\n// see: A\n{\n\tname: \"B\","; !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+
+	// the metadata does not change the generated code without comments
+	var meta bytes.Buffer
+	if err := BuildParser(&meta, g); err != nil {
+		t.Fatal(err)
+	}
+	g.Rules[1].Meta = nil
+	buf.Reset()
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	if meta.String() != buf.String() {
+		t.Errorf("want the same code with and without metadata")
+	}
+}
+
+func TestBuildNegatedCharClass(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(`Line = [^\n]+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	want := "expr: &charClassMatcher{\n\tpos: position{line: 1, col: 8, offset: 7},\n\tval: \"[^\\\\n]\",\n\tchars: []rune{'\\n',},\n\tignoreCase: false,\n\tinverted: true,\n},"
+	if !strings.Contains(out, want) {
+		t.Errorf("want a single inverted character class matcher, got %s", out[:strings.Index(out, "func (")])
+	}
+	if strings.Contains(out, "&notExpr{") || strings.Contains(out, "&anyMatcher{") {
+		t.Errorf("want no lookahead or any matcher")
+	}
+}
+
+func TestBuildEmptyLoop(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("A = B*\nB = 'b'?\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = BuildParser(ioutil.Discard, g)
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := "builder: 1:5 (4): repetition of an expression that can match the empty string"; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+}
+
+func TestBuildFold(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("A = B ('-' B)* C\nB = 'b'\nC = 'c'\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := g.Rules[0].Expr
+	fold := ast.NewFoldExpr(ast.Pos{Line: 1, Col: 5, Off: 4})
+	fold.Expr = seq
+	g.Rules[0].Expr = fold
+
+	err = BuildParser(ioutil.Discard, g)
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := "builder: 1:5 (4): fold expression must be a sequence of an operand and a repetition of operator and operand pairs"; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+
+	seq.(*ast.SeqExpr).Exprs = seq.(*ast.SeqExpr).Exprs[:2]
+	if err := BuildParser(ioutil.Discard, g); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
+
+func TestBuildSep(t *testing.T) {
+	item := ast.NewRuleRefExpr(ast.Pos{Line: 1, Col: 10, Off: 9})
+	item.Name = ast.NewIdentifier(ast.Pos{}, "item")
+	list := ast.NewSepExpr(ast.Pos{Line: 1, Col: 5, Off: 4})
+	list.Expr = item
+	list.Sep = ast.NewLitMatcher(ast.Pos{Line: 1, Col: 16, Off: 15}, ",")
+	list.Trailing = true
+	r := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "list"))
+	r.Expr = list
+	r2 := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "item"))
+	r2.Expr = ast.NewLitMatcher(ast.Pos{}, "a")
+	r2.DisplayName = ast.NewStringLit(ast.Pos{}, `"item"`)
+	g := ast.NewGrammar(ast.Pos{})
+	g.Rules = []*ast.Rule{r, r2}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	want := `&sepExpr{
+	pos: position{line: 1, col: 5, offset: 4},
+	expr: &ruleRefExpr{
+	pos: position{line: 1, col: 10, offset: 9},
+	name: "item",
+},
+	sep: &litMatcher{
+	pos: position{line: 1, col: 16, offset: 15},
+	val: ",",
+	ignoreCase: false,
+},
+	trailing: true,
+},`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+
+	list.Sep = ast.NewZeroOrOneExpr(ast.Pos{})
+	list.Sep.(*ast.ZeroOrOneExpr).Expr = ast.NewLitMatcher(ast.Pos{}, ",")
+	r2.Expr = list.Sep
+	if err := BuildParser(ioutil.Discard, g); err == nil {
+		t.Errorf("want error for a list of empty items and separators, got none")
+	}
+}
+
+func TestBuildBackRef(t *testing.T) {
+	lab := ast.NewLabeledExpr(ast.Pos{})
+	lab.Label = ast.NewIdentifier(ast.Pos{}, "open")
+	lab.Expr = ast.NewCharClassMatcher(ast.Pos{}, "[a-z]")
+	other := ast.NewLabeledExpr(ast.Pos{})
+	other.Label = ast.NewIdentifier(ast.Pos{}, "other")
+	other.Expr = ast.NewAnyMatcher(ast.Pos{}, ".")
+	ref := ast.NewBackRefExpr(ast.Pos{Line: 1, Col: 20, Off: 19})
+	ref.Label = ast.NewIdentifier(ast.Pos{}, "open")
+	seq := ast.NewSeqExpr(ast.Pos{})
+	seq.Exprs = []ast.Expression{lab, other, ref}
+	// the action uses other, so that its value is stored
+	act := ast.NewActionExpr(ast.Pos{})
+	act.Expr = seq
+	act.Code = ast.NewCodeBlock(ast.Pos{}, "{ return other, nil }")
+	r := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "tag"))
+	r.Expr = act
+	g := ast.NewGrammar(ast.Pos{})
+	g.Rules = []*ast.Rule{r}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"label: \"open\",\n\tcapture: true,",
+		"label: \"other\",\n\texpr:",
+		"&backRefExpr{\n\tpos: position{line: 1, col: 20, offset: 19},\n\tlabel: \"open\",\n},",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	ref.Label = ast.NewIdentifier(ast.Pos{}, "close")
+	err := BuildParser(ioutil.Discard, g)
+	if want := "builder: 1:20 (19): back-reference to undefined label close"; err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestBuildSeen(t *testing.T) {
+	lab := ast.NewLabeledExpr(ast.Pos{})
+	lab.Label = ast.NewIdentifier(ast.Pos{}, "block")
+	lab.Expr = ast.NewCharClassMatcher(ast.Pos{}, "[a-z]")
+	def := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "def"))
+	def.Expr = lab
+	seen := ast.NewSeenExpr(ast.Pos{Line: 2, Col: 7, Off: 20})
+	seen.Label = ast.NewIdentifier(ast.Pos{}, "block")
+	use := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "use"))
+	use.Expr = seen
+	g := ast.NewGrammar(ast.Pos{})
+	g.Rules = []*ast.Rule{def, use}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"label: \"block\",\n\tseen: true,",
+		"&seenExpr{\n\tpos: position{line: 2, col: 7, offset: 20},\n\tlabel: \"block\",\n},",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	seen.Label = ast.NewIdentifier(ast.Pos{}, "other")
+	err := BuildParser(ioutil.Discard, g)
+	if want := "builder: 2:7 (20): @seen reference to undefined label other"; err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestBuildLookbehind(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(`a = 'x' 'y'`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := ast.NewLookbehindExpr(ast.Pos{Line: 1, Col: 9, Off: 8})
+	lb.Expr = g.Rules[0].Expr.(*ast.SeqExpr).Exprs[0]
+	g.Rules[0].Expr.(*ast.SeqExpr).Exprs[0] = lb
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	want := `&lookbehindExpr{
+	pos: position{line: 1, col: 9, offset: 8},
+	expr: &litMatcher{
+	pos: position{line: 1, col: 5, offset: 4},
+	val: "x",
+	ignoreCase: false,
+},
+},`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+}
+
+func TestBuildCheckLabels(t *testing.T) {
+	cases := map[string]string{
+		"A = x:'a' { return y, nil }":                 "builder: 1:20 (19): rule A: code block uses undefined identifier y",
+		"A = x:( y:'a' ) { return y, nil }":           "builder: 1:26 (25): rule A: code block uses undefined identifier y",
+		"A = x:'a' {\n\treturn x != nil || z, nil\n}": "builder: 2:21 (32): rule A: code block uses undefined identifier z",
+		"A = x:'a' b:B {\n\tn, err := strconv.Atoi(string(c.text))\n\treturn []interface{}{x, b, n, errNoMatch, Parse}, err\n}\nB = 'b'": "",
+		"{\npackage p\nvar n = 1\nfunc f() int { return n }\n}\nA = x:'a' { return f() + n, x.(error) }":                                 "",
+		"A = x:'a' { return x, ; }": "",
+	}
+	for src, want := range cases {
+		g, err := bootstrap.NewParser().Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := BuildParser(ioutil.Discard, g); err != nil {
+			t.Errorf("%q: want no error without the option, got %v", src, err)
+		}
+		err = BuildParser(ioutil.Discard, g, CheckLabels(true))
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != want {
+			t.Errorf("%q: want error %q, got %q", src, want, got)
+		}
+	}
+}
+
+func TestBuildEntrypoints(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("doc = expr\nexpr = term\nterm = 'x'\nfile = 'f'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Rules[1].Entry = true
+	g.Rules[2].Entry = true
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for nm, fn := range map[string]string{"expr": "ParseExpr", "term": "ParseTerm"} {
+		want := "func " + fn + "(filename string, b []byte, opts ...Option) (interface{}, error) {\n\tp := newParser(filename, b, opts...)\n\tp.entry = \"" + nm + "\"\n\treturn p.parse(g)\n}"
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+	if strings.Contains(out, "func ParseDoc(") {
+		t.Errorf("want no entrypoint function for a rule without @entry")
+	}
+
+	g.Rules[3].Entry = true
+	if err := BuildParser(ioutil.Discard, g); err == nil {
+		t.Errorf("want error for an entrypoint function that is already generated, got none")
+	}
+}
+
+func TestBuildOnlyRules(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("C = 'c' A\nA = 'a' D?\nB = A 'b' / B\nD = 'd'\nE = 'e'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, OnlyRules("B")); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	var rules []string
+	for _, m := range regexp.MustCompile(`(?m)^\tname: "(\w+)",\n\tpos:`).FindAllStringSubmatch(out, -1) {
+		rules = append(rules, m[1])
+	}
+	if want := []string{"B", "A", "D"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("want rules %v, got %v", want, rules)
+	}
+	declared := make(map[string]bool)
+	for _, nm := range rules {
+		declared[nm] = true
+	}
+	for _, m := range regexp.MustCompile(`&ruleRefExpr\{\n\tpos: .*\n\tname: "(\w+)"`).FindAllStringSubmatch(out, -1) {
+		if !declared[m[1]] {
+			t.Errorf("want rule %s to be generated, it is referenced", m[1])
+		}
+	}
+
+	if err := BuildParser(ioutil.Discard, g, OnlyRules("B", "X")); err == nil {
+		t.Errorf("want error for an undeclared rule, got none")
+	}
+}
+
+func TestBuildNoInline(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = b b\nb = 'x'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, noInline := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, NoInline(noInline)); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(buf.String(), "&ruleRefExpr{"); (got == 2) != noInline {
+			t.Errorf("NoInline(%t): got %d rule references", noInline, got)
+		}
+	}
+}
+
+func TestBuildLitSet(t *testing.T) {
+	cases := map[string]string{
+		`a = '.' ( "go" / "mod" / "sum" )`: `&litSetMatcher{
+	pos: position{line: 1, col: 5, offset: 4},
+	alts: []*litMatcher{
+&litMatcher{
+	pos: position{line: 1, col: 11, offset: 10},
+	val: ".go",
+	ignoreCase: false,
+},
+&litMatcher{
+	pos: position{line: 1, col: 18, offset: 17},
+	val: ".mod",
+	ignoreCase: false,
+},
+&litMatcher{
+	pos: position{line: 1, col: 26, offset: 25},
+	val: ".sum",
+	ignoreCase: false,
+},
+	},
+	parts: [][]int{
+		{1, 2},
+		{1, 3},
+		{1, 3},
+	},
+},`,
+		`a = "x"i / "Y"`: `&litSetMatcher{
+	pos: position{line: 1, col: 5, offset: 4},
+	alts: []*litMatcher{
+&litMatcher{
+	pos: position{line: 1, col: 5, offset: 4},
+	val: "x",
+	ignoreCase: true,
+},
+&litMatcher{
+	pos: position{line: 1, col: 12, offset: 11},
+	val: "Y",
+	ignoreCase: false,
+},
+	},
+},`,
+		// the sequence does not backtrack into a choice that is not last,
+		// only its choice is replaced
+		`a = ( 'x' / 'y' ) 'z'`:  "",
+		`a = 'x'i ( 'y' / 'z' )`: "",
+		`a = 'x' ( 'y' / [z] )`:  "",
+	}
+	for src, want := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if want == "" {
+			if !strings.Contains(out, "&seqExpr{") {
+				t.Errorf("%q: want the sequence to be generated", src)
+			}
+		} else if !strings.Contains(out, want) {
+			t.Errorf("%q: want generated code to contain %q", src, want)
+		}
+	}
+}
+
+func TestBuildWhen(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(`a = 'x' / 'y'`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := ast.NewWhenExpr(ast.Pos{Line: 1, Col: 5, Off: 4})
+	when.Flag = ast.NewIdentifier(ast.Pos{}, "strict")
+	when.Expr = g.Rules[0].Expr.(*ast.ChoiceExpr).Alternatives[0]
+	g.Rules[0].Expr.(*ast.ChoiceExpr).Alternatives[0] = when
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	want := `&whenExpr{
+	pos: position{line: 1, col: 5, offset: 4},
+	flag: "strict",
+	expr: &litMatcher{
+	pos: position{line: 1, col: 5, offset: 4},
+	val: "x",
+	ignoreCase: false,
+},
+},`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+}
+
+func TestBuildStructs(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(`
+line = first:point rest:point* { return nil, nil }
+point = x:num ',' y:num
+num = [0-9]+
+pair = a:num b:num
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, Structs(true)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"type Point struct {\n\tX interface{}\n\tY interface{}\n}",
+		"type Pair struct {\n\tA interface{}\n\tB interface{}\n}",
+		"return Point{X: x, Y: y}, nil",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+	// a rule with an action or without labels has no struct
+	if strings.Contains(out, "type Line struct") || strings.Contains(out, "type Num struct") {
+		t.Errorf("want no struct for rules line and num")
+	}
+	if strings.Contains(out, "func (v Point) String() string") {
+		t.Errorf("want no String method by default")
+	}
+	if len(g.Rules[1].Expr.(*ast.SeqExpr).Exprs) != 3 {
+		t.Errorf("want grammar unchanged")
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, Structs(true), EmitStringers(true)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "func (v Point) String() string {\n\treturn fmt.Sprintf(\"Point{X:%v, Y:%v}\", v.X, v.Y)\n}"; !strings.Contains(buf.String(), want) {
+		t.Errorf("want generated code to contain %q", want)
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, Structs(true), StructSpans(true)); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, want := range []string{
+		"type Point struct {\n\tX interface{}\n\tY interface{}\n\tPos, End position\n}",
+		"return Point{X: x, Y: y, Pos: c.pos, End: c.end}, nil",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	if strings.Contains(out, "type Visitor interface") {
+		t.Errorf("want no Visitor interface by default")
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, Structs(true), EmitVisitor(true)); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, want := range []string{
+		"type Visitor interface {\n\tEnterPoint(v Point)\n\tExitPoint(v Point)\n\tEnterPair(v Pair)\n\tExitPair(v Pair)\n}",
+		"func Walk(vis Visitor, tree interface{}) {",
+		"\tcase Point:\n\t\tvis.EnterPoint(v)\n\t\tWalk(vis, v.X)\n\t\tWalk(vis, v.Y)\n\t\tvis.ExitPoint(v)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+
+	g2, err := p.Parse("", strings.NewReader("point = pos:num ',' y:num\nnum = [0-9]+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildParser(ioutil.Discard, g2, Structs(true), StructSpans(true)); err == nil {
+		t.Errorf("want error for a label with the same field name as a span field, got none")
+	}
+
+	g, err = p.Parse("", strings.NewReader("point = x:num ',' X:num\nnum = [0-9]+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildParser(ioutil.Discard, g, Structs(true)); err == nil {
+		t.Errorf("want error for labels with the same field name, got none")
+	}
+}
+
+func TestBuildRuleCache(t *testing.T) {
+	src := "A = B C { return nil, nil }\nB = 'b'+ { return nil, nil }\nD = 'd'\nC = 'c'+\n"
+	cases := []struct {
+		src          string
+		hits, misses int
+	}{
+		{src, 0, 4},
+		{src, 4, 0},
+		// edit the last rule
+		{strings.Replace(src, "'c'+", "'x'+", 1), 3, 1},
+		// change the trivial rule D, that may be inlined in all rules
+		{strings.Replace(src, "'d'", "'e'", 1), 0, 4},
+	}
+
+	c := NewCache()
+	for i, tc := range cases {
+		g, err := bootstrap.NewParser().Parse("", strings.NewReader(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want bytes.Buffer
+		if err := BuildParser(&want, g); err != nil {
+			t.Fatal(err)
+		}
+
+		c.Hits, c.Misses = 0, 0
+		g, err = bootstrap.NewParser().Parse("", strings.NewReader(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if err := BuildParser(&got, g, RuleCache(c)); err != nil {
+			t.Fatal(err)
+		}
+		if c.Hits != tc.hits || c.Misses != tc.misses {
+			t.Errorf("%d: want %d hits and %d misses, got %d and %d", i, tc.hits, tc.misses, c.Hits, c.Misses)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%d: want the same code with and without the cache", i)
+		}
+	}
+}
+
+func TestBuildSkip(t *testing.T) {
+	num := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "num"))
+	num.Lexical = true
+	num.Expr = ast.NewCharClassMatcher(ast.Pos{Line: 2}, "[0-9]")
+	act := ast.NewActionExpr(ast.Pos{})
+	seq := ast.NewSeqExpr(ast.Pos{})
+	ref := ast.NewRuleRefExpr(ast.Pos{Line: 1, Col: 7})
+	ref.Name = ast.NewIdentifier(ast.Pos{}, "num")
+	seq.Exprs = []ast.Expression{ref, ast.NewLitMatcher(ast.Pos{Line: 1, Col: 11}, "+")}
+	act.Expr = seq
+	act.Code = ast.NewCodeBlock(ast.Pos{}, "{ return nil, nil }")
+	sum := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "sum"))
+	sum.Expr = act
+	ws := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "ws"))
+	ws.Expr = ast.NewZeroOrMoreExpr(ast.Pos{})
+	ws.Expr.(*ast.ZeroOrMoreExpr).Expr = ast.NewLitMatcher(ast.Pos{}, " ")
+	g := ast.NewGrammar(ast.Pos{})
+	g.Rules = []*ast.Rule{sum, num, ws}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, Skip("ws")); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"&skipExpr{\n\tpos: position{line: 1, col: 7, offset: 0},\n\tskip: &ruleRefExpr{\n\tpos: position{line: 1, col: 7, offset: 0},\n\tname: \"ws\",\n},",
+		"&skipExpr{\n\tpos: position{line: 1, col: 11, offset: 0},",
+		// the name of the action's function does not depend on the option
+		"onsum1()",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+	// the lexical rule is unchanged
+	if strings.Contains(out, "pos: position{line: 2, col: 0, offset: 0},\n\tskip:") {
+		t.Errorf("want no skip in the lexical rule")
+	}
+	if _, ok := seq.Exprs[0].(*ast.RuleRefExpr); !ok {
+		t.Errorf("want grammar unchanged")
+	}
+
+	if err := BuildParser(ioutil.Discard, g, Skip("missing")); err == nil {
+		t.Errorf("want error for an undeclared skip rule, got none")
+	}
+}
+
+func TestBuildGoVersion(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = b:b { return b, nil }\nb = 'x' / [a-z]+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	opts := []Option{GoVersion("1.5"), Structs(true), EmitVisitor(true), EmitStringers(true), EmitHTTPHandler(true)}
+	if err := BuildParser(&buf, g, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() == 0 {
+		t.Error("want generated code, got none")
+	}
+	for path := range goPackages {
+		if strings.Contains(buf.String(), strconv.Quote(path)) {
+			t.Errorf("generated code imports %s", path)
+		}
+	}
+
+	tests := []struct {
+		grammar string
+		minor   int
+	}{
+		{"{\npackage p\nimport \"context\"\nvar _ = context.Background\n}\na = 'x'", 7},
+		{"{\npackage p\nfunc first[T any](s []T) T { return s[0] }\n}\na = 'x'", 18},
+		{"a = 'x' { var v any = 1; return v, nil }", 18},
+		{"a = s:'x'* { return slices.Clip(s.([]interface{})), nil }", 21},
+	}
+	for _, tc := range tests {
+		g, err := p.Parse("", strings.NewReader(tc.grammar))
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev := fmt.Sprintf("1.%d", tc.minor-1)
+		if err := BuildParser(ioutil.Discard, g, GoVersion(prev)); err == nil {
+			t.Errorf("%q: GoVersion(%s): want error", tc.grammar, prev)
+		}
+		if err := BuildParser(ioutil.Discard, g, GoVersion(fmt.Sprintf("go1.%d.1", tc.minor))); err != nil {
+			t.Errorf("%q: %v", tc.grammar, err)
+		}
+	}
+
+	if err := BuildParser(ioutil.Discard, g, GoVersion("2")); err == nil {
+		t.Error("GoVersion(2): want error")
+	}
+}
+
+func TestBuildDefaultMemoize(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = 'x'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, memoize := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, DefaultMemoize(memoize)); err != nil {
+			t.Fatal(err)
+		}
+		re := regexp.MustCompile(`var defaultOptions = \[\]Option\{\n\tMemoize\(true\),\n\}`)
+		if got := re.Match(buf.Bytes()); got != memoize {
+			t.Errorf("DefaultMemoize(%t): got Memoize(true) default %t", memoize, got)
+		}
+		if !memoize && !strings.Contains(buf.String(), "var defaultOptions []Option\n") {
+			t.Error("DefaultMemoize(false): want empty default options")
+		}
+	}
+}
+
+func TestBuildMemoLevel(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = 'x' b\nb = 'y'+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"":     "",
+		"expr": "",
+		"rule": "\tmemoLevel: memoRules,\n}",
+		"none": "\tmemoLevel: memoNone,\n}",
+	}
+	for level, want := range cases {
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, MemoLevel(level)); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if want == "" {
+			if strings.Contains(out, "\tmemoLevel: ") {
+				t.Errorf("MemoLevel(%q): want no memo level in the grammar", level)
+			}
+			continue
+		}
+		if !strings.Contains(out, want) {
+			t.Errorf("MemoLevel(%q): want the grammar to contain %q", level, want)
+		}
+	}
+
+	if err := BuildParser(ioutil.Discard, g, MemoLevel("all")); err == nil {
+		t.Error("MemoLevel(all): want error")
+	}
+}
+
+func TestBuildNoPanic(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = 'x'+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, noPanic := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, NoPanic(noPanic)); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, call := range []string{"panic(", "recover()"} {
+			if got := strings.Contains(out, call); got == noPanic {
+				t.Errorf("NoPanic(%t): want code to contain %q: %t, got %t", noPanic, call, !noPanic, got)
+			}
+		}
+	}
+}
+
+func TestBuildDispatch(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("msg = get / all / post / path\nget = \"GET \" path\nall = \"GET ALL\"\npost = verb ' ' path\nverb = \"POST\"i\nany = path\npath = [a-z]+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range g.Rules[1:6] {
+		r.Entry = true
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "func Dispatch(") {
+		t.Error("want no Dispatch function by default")
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, EmitDispatch(true)); err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`(?m)^\t\treturn (\w+)\(filename, b, opts\.\.\.\)$`)
+	var got []string
+	for _, m := range re.FindAllStringSubmatch(buf.String(), -1) {
+		got = append(got, m[1])
+	}
+	want := []string{"ParseAll", "ParseGet", "ParsePost", "ParseVerb"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want dispatch to %v, got %v", want, got)
+	}
+	if !strings.Contains(buf.String(), "\treturn Parse(filename, b, opts...)\n}") {
+		t.Error("want Dispatch to fall back to Parse")
+	}
+}
+
+func TestBuildParserDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pigeon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := bootstrap.NewParser()
+	src := "{\npackage split\n\nimport \"strings\"\n}\na = 'x'+ { return strings.ToUpper(string(c.text)), nil }"
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildParserDir(dir, g, EmitHTTPHandler(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	decls := make(map[string]string)
+	for _, nm := range []string{"parser.go", "parser_grammar.go", "parser_actions.go", "parser_runtime.go"} {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, nm), nil, 0)
+		if err != nil {
+			t.Errorf("%s: %v", nm, err)
+			continue
+		}
+		if f.Name.Name != "split" {
+			t.Errorf("%s: want package split, got %s", nm, f.Name.Name)
+		}
+		if nm != "parser_grammar.go" && nm != "parser.go" && len(f.Imports) != 1 {
+			t.Errorf("%s: want the import of the initializer, got %d imports", nm, len(f.Imports))
+		}
+		for id := range f.Scope.Objects {
+			if prev, ok := decls[id]; ok {
+				t.Errorf("%s: %s is also declared in %s", nm, id, prev)
+			}
+			decls[id] = nm
+		}
+	}
+	for id, want := range map[string]string{"Parse": "parser_runtime.go", "g": "parser_grammar.go", "ServeParse": "parser_runtime.go"} {
+		if decls[id] != want {
+			t.Errorf("%s: want declared in %s, got %q", id, want, decls[id])
+		}
+	}
+
+	g.Init = nil
+	if err := BuildParserDir(dir, g); err == nil {
+		t.Error("want error without package name, got none")
+	}
+}
+
+func TestBuildTransform(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = b+\nb = 'x' / 'y'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rename := func(g *ast.Grammar) (*ast.Grammar, error) {
+		for _, r := range g.Rules {
+			ast.Walk(r.Expr, func(expr ast.Expression) {
+				if ref, ok := expr.(*ast.RuleRefExpr); ok && ref.Name.Val == "b" {
+					ref.Name.Val = "letter"
+				}
+			})
+			if r.Name.Val == "b" {
+				r.Name.Val = "letter"
+			}
+		}
+		return g, nil
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, Transform(rename), NoInline(true)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, re := range []string{`(?m)^\tname: "letter",\n\tpos:`, `&ruleRefExpr\{\n\tpos: .*\n\tname: "letter"`} {
+		if !regexp.MustCompile(re).MatchString(out) {
+			t.Errorf("want generated code to match %s", re)
+		}
+	}
+	if strings.Contains(out, "\tname: \"b\",") {
+		t.Error("want no rule named b after the transform")
+	}
+
+	fail := func(*ast.Grammar) (*ast.Grammar, error) { return nil, errors.New("transform failed") }
+	if err := BuildParser(ioutil.Discard, g, Transform(fail)); err == nil || err.Error() != "transform failed" {
+		t.Errorf("want transform error, got %v", err)
+	}
+}
+
+func TestBuildLexer(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("expr = num op num\nnum = [0-9]+\nop = [+-]\n_ = [ ]*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "func Tokenize(") {
+		t.Error("want no Tokenize function by default")
+	}
+	want := "builder: the lexer requires at least one lexical rule"
+	if err := BuildParser(ioutil.Discard, g, EmitLexer(true)); err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	for _, r := range g.Rules[1:] {
+		r.Lexical = true
+	}
+	buf.Reset()
+	if err := BuildParser(&buf, g, EmitLexer(true), Skip("_")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "var lexicalRules = []string{\n\t\"num\",\n\t\"op\",\n}") {
+		t.Error("want the lexical rules other than the skip rule")
+	}
+	if !strings.Contains(buf.String(), "func Tokenize(") {
+		t.Error("want Tokenize function")
+	}
+}
+
+func TestBuildUndefinedRule(t *testing.T) {
+	cases := map[string]string{
+		"A = B":                   "builder: 1:5 (4): rule A references undefined rule B",
+		"A = 'a' C\nC = 'c' / D+": "builder: 2:11 (20): rule C references undefined rule D",
+	}
+	for src, want := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// without the option, the reference fails to match at runtime
+		if err := BuildParser(ioutil.Discard, g); err != nil {
+			t.Errorf("%q: want no error by default, got %v", src, err)
+		}
+		err = BuildParser(ioutil.Discard, g, FailUndefinedRules(true))
+		if err == nil || err.Error() != want {
+			t.Errorf("%q: want error %q, got %v", src, want, err)
+			continue
+		}
+		if _, ok := err.(*UndefinedRuleError); !ok {
+			t.Errorf("%q: want error type %T, got %T", src, &UndefinedRuleError{}, err)
+		}
+	}
+}
+
+func TestBuildExamplesTest(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("{ package calc }\nexpr = [0-9]+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "builder: the grammar has no examples"
+	if err := BuildExamplesTest(ioutil.Discard, g); err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	g.Examples = []*ast.StringLit{
+		ast.NewStringLit(ast.Pos{}, `"12"`),
+		ast.NewStringLit(ast.Pos{}, "`3\\n`"),
+	}
+	var buf bytes.Buffer
+	if err := BuildExamplesTest(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package calc\n", "func TestGrammarExamples(t *testing.T) {", "\t\t\"12\",\n\t\t\"3\\\\n\",\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want the test to contain %q, got\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := BuildExamplesTest(&buf, g, PackageName("other")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "package other\n") {
+		t.Errorf("want package other, got\n%s", buf.String())
+	}
+
+	g.Init = nil
+	want = "builder: the package name is required to generate the examples test"
+	if err := BuildExamplesTest(ioutil.Discard, g); err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestBuildListing(t *testing.T) {
+	cases := map[string]string{
+		"A = 'a'": `; listing of the rules of the grammar, generated by pigeon
+
+A:                                      ; rule 1:1
+  lit "a"                               ; 1:5
+`,
+		"A = x:'a'i B { return x, nil }\nB \"b\" = [a-z]+ / !'x' .": `; listing of the rules of the grammar, generated by pigeon
+
+A:                                      ; rule 1:1
+  action { return x, nil }              ; 1:5
+    seq                                 ; 1:5
+      labeled x                         ; 1:5
+        lit "a"i                        ; 1:7
+      ruleref B                         ; 1:12
+
+B "b":                                  ; rule 2:1
+  choice                                ; 2:9
+    oneormore                           ; 2:9
+      charclass [a-z]                   ; 2:9
+    seq                                 ; 2:18
+      not                               ; 2:18
+        lit "x"                         ; 2:19
+      any .                             ; 2:23
+`,
+	}
+	for src, want := range cases {
+		g, err := bootstrap.NewParser().Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := BuildListing(&buf, g); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%q: want listing\n%s\ngot\n%s", src, want, buf.String())
+		}
+	}
+}
+
+func TestBuildDefault(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("a = 'a' b\nb = 'b'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := g.Rules[0].Expr.(*ast.SeqExpr)
+	zero := ast.NewZeroOrOneExpr(ast.Pos{})
+	zero.Expr = seq.Exprs[1]
+	zero.Default = ast.NewCodeBlock(ast.Pos{}, "{ return 0, nil }")
+	seq.Exprs[1] = zero
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	code := buf.String()
+	for _, want := range []string{"\tdflt: (*parser).callona3,", "func (c *current) ona3() (interface{}, error) {\n return 0, nil \n}"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("want the generated code to contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, StripActions(true)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "dflt:") {
+		t.Error("want no default with StripActions")
+	}
+}
+
+func TestBuildState(t *testing.T) {
+	r := ast.NewRule(ast.Pos{}, ast.NewIdentifier(ast.Pos{}, "A"))
+	r.Expr = ast.NewLitMatcher(ast.Pos{}, "a")
+	g := ast.NewGrammar(ast.Pos{})
+	g.Init = ast.NewCodeBlock(ast.Pos{}, "{\npackage x\n}")
+	g.Rules = []*ast.Rule{r}
+
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "type parseState struct{}") {
+		t.Errorf("want an empty state struct without a state block")
+	}
+	if strings.Contains(out, "func WithState(") {
+		t.Errorf("want no WithState option without a state block")
+	}
+
+	g.State = ast.NewCodeBlock(ast.Pos{}, "{\n\tdepth int\n}")
+	buf.Reset()
+	if err := BuildParser(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, want := range []string{"type parseState struct {\ndepth int\n}", "func WithState(s *parseState) Option {"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want generated code to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// Cache holds the code generated for the rules of a grammar, so that the
+// parser of an edited grammar can be built again without generating the
+// code of the rules that did not change. The code of a rule is keyed by a
+// hash of the rule's AST, which includes the positions of its nodes, so
+// that the rules that follow an edit in the same lines are generated
+// again. The cache is not used with the Comments option.
+//
+// A Cache is not safe for concurrent use.
+type Cache struct {
+	entries map[[sha256.Size]byte]ruleCode
+
+	// Hits is the number of rules whose code was found in the cache, and
+	// Misses the number of rules whose code was generated.
+	Hits, Misses int
+}
+
+// ruleCode is the generated code of a rule, its entry in the grammar and
+// the functions of its code blocks.
+type ruleCode struct {
+	entry string
+	funcs string
+}
+
+// NewCache creates a new empty cache.
+func NewCache() *Cache {
+	return &Cache{entries: make(map[[sha256.Size]byte]ruleCode)}
+}
+
+// RuleCache returns an option that sets the cache used to reuse the code
+// generated for the rules in previous builds. The default is nil, the code
+// of all rules is generated.
+func RuleCache(c *Cache) Option {
+	return func(b *builder) Option {
+		prev := b.cache
+		b.cache = c
+		return RuleCache(prev)
+	}
+}
+
+// cachedRules returns the code of the enabled rules of g, from the cache
+// or generated. The entries of the cache for the rules that are not part
+// of g are removed.
+func (b *builder) cachedRules(g *ast.Grammar) map[*ast.Rule]ruleCode {
+	// the code of a rule also depends on these settings and on the trivial
+	// rules, that are inlined where they are referenced.
+	var ctx bytes.Buffer
+	fmt.Fprintf(&ctx, "receiver: %s\n", b.recvName)
+	fmt.Fprintf(&ctx, "strip actions: %t\n", b.strip)
+	fmt.Fprintf(&ctx, "tag branches: %t\n", b.tagBranches)
+	names := make([]string, 0, len(b.trivial))
+	for nm := range b.trivial {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+	for _, nm := range names {
+		fmt.Fprintf(&ctx, "trivial %s: %s\n", nm, b.trivial[nm])
+	}
+	names = names[:0]
+	for nm, ok := range b.defines {
+		if ok {
+			names = append(names, nm)
+		}
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&ctx, "defines: %v\n", names)
+	names = names[:0]
+	for nm := range b.seenLabels {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&ctx, "seen: %v\n", names)
+
+	codes := make(map[*ast.Rule]ruleCode, len(g.Rules))
+	entries := make(map[[sha256.Size]byte]ruleCode, len(g.Rules))
+	for _, r := range g.Rules {
+		if !b.enabled(r.Cond) {
+			continue
+		}
+		key := sha256.Sum256([]byte(ctx.String() + r.String()))
+		rc, ok := b.cache.entries[key]
+		if ok {
+			b.cache.Hits++
+		} else {
+			b.cache.Misses++
+			rc = b.generateRule(r)
+		}
+		codes[r] = rc
+		entries[key] = rc
+	}
+	b.cache.entries = entries
+	return codes
+}
+
+// generateRule returns the code generated for r.
+func (b *builder) generateRule(r *ast.Rule) ruleCode {
+	w := b.w
+	defer func() { b.w = w }()
+
+	var buf bytes.Buffer
+	b.w = &buf
+	b.writeRule(r)
+	entry := buf.String()
+	buf.Reset()
+	b.writeRuleCode(r)
+	return ruleCode{entry: entry, funcs: buf.String()}
+}
@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+var (
+	generatedOnce sync.Once
+	// generatedNames is the set of the package-level identifiers declared
+	// by the generated code.
+	generatedNames map[string]bool
+)
+
+// loadGeneratedNames computes generatedNames from the static code and the
+// identifiers written by the builder.
+func loadGeneratedNames() {
+	generatedNames = map[string]bool{
+		"g":              true,
+		"defaultOptions": true,
+		"grammarSource":  true,
+		"GrammarSource":  true,
+		"Visitor":        true,
+		"Dispatch":       true,
+		"Walk":           true,
+		"lexicalRules":   true,
+		"parseState":     true,
+		"WithState":      true,
+	}
+	src := "package p\n" + fmt.Sprintf(staticCode, "", "current") + serveParseCode + tokenizeCode
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return
+	}
+	for nm := range f.Scope.Objects {
+		generatedNames[nm] = true
+	}
+}
+
+// checkCode returns an error if the code block of the current rule uses
+// an identifier that is not declared, given the names of its parameters.
+// The identifiers declared by the initializer and the generated code, the
+// predeclared identifiers and the operands of selector expressions, which
+// may be packages, are considered declared. Code that is not valid Go is
+// not checked, so that the compiler reports the error.
+func (b *builder) checkCode(code *ast.CodeBlock, params []string) error {
+	generatedOnce.Do(loadGeneratedNames)
+
+	var src bytes.Buffer
+	src.WriteString("package p\n")
+	if b.init != nil {
+		src.WriteString(removePackageClause(b.init.Val[1 : len(b.init.Val)-1]))
+	}
+	src.WriteString("\nfunc _(")
+	for _, nm := range params {
+		fmt.Fprintf(&src, "%s interface{}, ", nm)
+	}
+	src.WriteString(") ")
+	start := src.Len()
+	src.WriteString(code.Val)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src.Bytes(), 0)
+	if err != nil {
+		return nil
+	}
+	operands := make(map[*goast.Ident]bool)
+	goast.Inspect(f, func(n goast.Node) bool {
+		if sel, ok := n.(*goast.SelectorExpr); ok {
+			if id, ok := sel.X.(*goast.Ident); ok {
+				operands[id] = true
+			}
+		}
+		return true
+	})
+	for _, id := range f.Unresolved {
+		off := fset.Position(id.Pos()).Offset - start
+		if off < 0 || operands[id] || b.declared(id.Name) {
+			continue
+		}
+		return fmt.Errorf("builder: %s: rule %s: code block uses undefined identifier %s",
+			codePos(code, off), b.ruleName, id.Name)
+	}
+	return nil
+}
+
+// declared returns true if nm is a predeclared identifier or is declared
+// by the generated code, including the struct types of the Structs option.
+func (b *builder) declared(nm string) bool {
+	if generatedNames[nm] || nm == b.ctxType || types.Universe.Lookup(nm) != nil {
+		return true
+	}
+	for rule := range b.structLabels {
+		if exportedName(rule) == nm {
+			return true
+		}
+	}
+	return false
+}
+
+// codePos returns the position in the grammar of the byte at offset off in
+// the code block.
+func codePos(code *ast.CodeBlock, off int) ast.Pos {
+	pos := code.Pos()
+	prefix := code.Val[:off]
+	if i := strings.LastIndexByte(prefix, '\n'); i >= 0 {
+		pos.Line += strings.Count(prefix, "\n")
+		pos.Col = utf8.RuneCountInString(prefix[i+1:]) + 1
+	} else {
+		pos.Col += utf8.RuneCountInString(prefix)
+	}
+	pos.Off += off
+	return pos
+}
+
+// codeIdents returns the set of identifiers of the code block code, and
+// false if it is not valid Go code.
+func codeIdents(code *ast.CodeBlock) (map[string]bool, bool) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\nfunc _() "+code.Val, 0)
+	if err != nil {
+		return nil, false
+	}
+	idents := make(map[string]bool)
+	goast.Inspect(f, func(n goast.Node) bool {
+		if id, ok := n.(*goast.Ident); ok {
+			idents[id.Name] = true
+		}
+		return true
+	})
+	return idents, true
+}
@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// BuildExamplesTest writes to w a table-driven test of the parser
+// generated from the grammar, that parses each example input declared in
+// the grammar with @example and fails if an input does not parse. The
+// test is in the package of the PackageName option, or of the package
+// clause of the initializer, so that it can be written next to the
+// parser, e.g. to a file named parser_examples_test.go. An error is
+// returned if the grammar has no examples.
+func BuildExamplesTest(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, recvName: "c"}
+	b.setOptions(opts)
+
+	pkgClause := ""
+	if b.pkgName != "" {
+		if !isPackageName(b.pkgName) {
+			return fmt.Errorf("builder: invalid package name %q", b.pkgName)
+		}
+		pkgClause = "package " + b.pkgName
+	} else if g.Init != nil {
+		pkgClause = packageClause(g.Init.Val[1 : len(g.Init.Val)-1])
+	}
+	if pkgClause == "" {
+		return errors.New("builder: the package name is required to generate the examples test")
+	}
+	if len(g.Examples) == 0 {
+		return errors.New("builder: the grammar has no examples")
+	}
+
+	b.writelnf("%s\n", pkgClause)
+	b.writelnf("import \"testing\"\n")
+	b.writelnf("func TestGrammarExamples(t *testing.T) {")
+	b.writelnf("\texamples := []string{")
+	for _, ex := range g.Examples {
+		s, err := strconv.Unquote(ex.Val)
+		if err != nil {
+			return fmt.Errorf("builder: %s: invalid example %s", ex.Pos(), ex.Val)
+		}
+		b.writelnf("\t\t%q,", s)
+	}
+	b.writelnf("\t}")
+	b.writelnf("\tfor _, ex := range examples {")
+	b.writelnf("\t\tif _, err := Parse(\"\", []byte(ex)); err != nil {")
+	b.writelnf("\t\t\tt.Errorf(\"%%q: %%v\", ex, err)")
+	b.writelnf("\t\t}")
+	b.writelnf("\t}")
+	b.writelnf("}")
+	return b.err
+}
@@ -0,0 +1,151 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// GoVersion returns an option that specifies the version of Go that the
+// generated code must compile with, such as "1.18". The generated code is
+// checked once it is written, including the code blocks of the grammar,
+// and an error is returned if it uses a package of the standard library
+// or a language feature that is more recent than that version. The
+// default is no version, the generated code is not checked.
+func GoVersion(v string) Option {
+	return func(b *builder) Option {
+		prev := b.goVersion
+		b.goVersion = v
+		return GoVersion(prev)
+	}
+}
+
+// goPackages is the minor version of Go 1 that added each package of the
+// standard library that is more recent than Go 1.0.
+var goPackages = map[string]int{
+	"context":      7,
+	"math/bits":    9,
+	"hash/maphash": 14,
+	"io/fs":        16,
+	"embed":        16,
+	"net/netip":    18,
+	"slices":       21,
+	"maps":         21,
+	"cmp":          21,
+	"log/slog":     21,
+	"iter":         23,
+	"unique":       23,
+	"weak":         24,
+}
+
+// goMinor returns the minor version of a Go 1 version such as "1.18" or
+// "go1.18.2".
+func goMinor(v string) (int, error) {
+	s := strings.TrimPrefix(v, "go")
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "1" {
+		return 0, fmt.Errorf("builder: invalid Go version %q", v)
+	}
+	for _, p := range parts[1:] {
+		if _, err := strconv.Atoi(p); err != nil {
+			return 0, fmt.Errorf("builder: invalid Go version %q", v)
+		}
+	}
+	n, _ := strconv.Atoi(parts[1])
+	return n, nil
+}
+
+// checkGoVersion returns an error if the generated code src uses a package
+// or a language feature that is not in the Go version of the GoVersion
+// option. Code that is not valid Go is not checked, so that the compiler
+// reports the error.
+func (b *builder) checkGoVersion(src []byte) error {
+	minor, err := goMinor(b.goVersion)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		f, err = parser.ParseFile(fset, "", append([]byte("package p\n"), src...), 0)
+		if err != nil {
+			return nil
+		}
+	}
+	tooRecent := func(what string, pos token.Pos, n int) error {
+		if n <= minor {
+			return nil
+		}
+		return fmt.Errorf("builder: %s: generated code uses %s, which requires Go 1.%d, the target is Go %s",
+			fset.Position(pos), what, n, b.goVersion)
+	}
+
+	// the packages are those imported by the initializer, or used without
+	// import and added by goimports.
+	imported := make(map[string]bool)
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		imported[path[strings.LastIndex(path, "/")+1:]] = true
+		if err := tooRecent("package "+path, imp.Pos(), goPackages[path]); err != nil {
+			return err
+		}
+	}
+	unresolved := make(map[*goast.Ident]bool, len(f.Unresolved))
+	for _, id := range f.Unresolved {
+		unresolved[id] = true
+	}
+
+	goast.Inspect(f, func(n goast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *goast.FuncType:
+			if n.TypeParams != nil {
+				err = tooRecent("type parameters", n.TypeParams.Pos(), 18)
+			}
+		case *goast.TypeSpec:
+			if n.TypeParams != nil {
+				err = tooRecent("type parameters", n.TypeParams.Pos(), 18)
+			}
+		case *goast.Ident:
+			if unresolved[n] && (n.Name == "any" || n.Name == "comparable") {
+				err = tooRecent("the predeclared identifier "+n.Name, n.Pos(), 18)
+			}
+		case *goast.SelectorExpr:
+			if id, ok := n.X.(*goast.Ident); ok && unresolved[id] && !imported[id.Name] {
+				for path, v := range goPackages {
+					if path[strings.LastIndex(path, "/")+1:] == id.Name {
+						err = tooRecent("package "+path, id.Pos(), v)
+						break
+					}
+				}
+			}
+		}
+		return true
+	})
+	return err
+}
+
+// buildChecked builds the parser in a buffer, checks it for the GoVersion
+// option and writes it to the writer of b.
+func (b *builder) buildChecked(g *ast.Grammar) error {
+	w := b.w
+	var buf bytes.Buffer
+	b.w = &buf
+	if err := b.buildParser(g); err != nil {
+		return err
+	}
+	if err := b.checkGoVersion(buf.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
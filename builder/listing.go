@@ -0,0 +1,186 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// BuildListing writes to w a text listing of the rules of the grammar, for
+// documentation and review: the header of each enabled rule, with its
+// annotations, is followed by a line per expression of the rule,
+// indented by its depth, with the kind of the expression, its operands and
+// its position in the grammar as a comment. E.g., for A = 'a':
+//
+//	A:                                      ; rule 1:1
+//	  lit "a"                               ; 1:5
+func BuildListing(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, recvName: "c"}
+	b.setOptions(opts)
+
+	b.writelnf("; listing of the rules of the grammar, generated by pigeon")
+	for _, r := range g.Rules {
+		if !b.enabled(r.Cond) {
+			continue
+		}
+		head := r.Name.Val
+		if r.DisplayName != nil && r.DisplayName.Val != "" {
+			head += " " + strconv.Quote(r.DisplayName.Val)
+		}
+		for _, annot := range []struct {
+			set  bool
+			name string
+		}{{r.Entry, "@entry"}, {r.Inline, "@inline"}, {r.Lexical, "@lexical"}, {r.Silent, "@silent"}, {r.NLSignificant, "@nlsignificant"}} {
+			if annot.set {
+				head += " " + annot.name
+			}
+		}
+		if r.Type != "" {
+			head += fmt.Sprintf(" @type(%q)", r.Type)
+		}
+		if r.Budget > 0 {
+			head += fmt.Sprintf(" @budget(%d)", r.Budget)
+		}
+		b.writelnf("")
+		b.writeListingLine(head+":", "rule ", r.Pos())
+		b.writeListingExpr(r.Expr, 1)
+	}
+	return b.err
+}
+
+// writeListingExpr writes the listing lines of expr and of its
+// sub-expressions at the indentation depth.
+func (b *builder) writeListingExpr(expr ast.Expression, depth int) {
+	if expr == nil {
+		return
+	}
+	name := fmt.Sprintf("%T", expr)
+	name = strings.TrimPrefix(name, "*ast.")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "Expr"), "Matcher")
+	line := strings.Repeat("  ", depth) + strings.ToLower(name)
+	if ops := listingOperands(expr); ops != "" {
+		line += " " + ops
+	}
+	b.writeListingLine(line, "", expr.Pos())
+	for _, sub := range ast.Children(expr) {
+		b.writeListingExpr(sub, depth+1)
+	}
+}
+
+// writeListingLine writes the listing line s with the position pos in a
+// comment aligned on the 41st column.
+func (b *builder) writeListingLine(s, prefix string, pos ast.Pos) {
+	b.writelnf("%-39s ; %s%d:%d", s, prefix, pos.Line, pos.Col)
+}
+
+// listingOperands returns the operands of expr in the listing, the empty
+// string if it has none.
+func listingOperands(expr ast.Expression) string {
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		return listingCode(expr.Code)
+	case *ast.AndCodeExpr:
+		return listingCode(expr.Code)
+	case *ast.NotCodeExpr:
+		return listingCode(expr.Code)
+	case *ast.ArrayExpr:
+		if expr.Type != "" {
+			return fmt.Sprintf("%d %q", expr.N, expr.Type)
+		}
+		return strconv.Itoa(expr.N)
+	case *ast.MapExpr:
+		if expr.Last {
+			return expr.Key.Val + " " + expr.Val.Val + " last"
+		}
+		return expr.Key.Val + " " + expr.Val.Val
+	case *ast.AndExpr:
+		if expr.Peek {
+			return "peek"
+		}
+	case *ast.BackRefExpr:
+		return expr.Label.Val
+	case *ast.SeenExpr:
+		return expr.Label.Val
+	case *ast.ChoiceExpr:
+		if expr.Longest {
+			return "longest"
+		}
+	case *ast.FoldExpr:
+		if expr.Right {
+			return "right"
+		}
+		return "left"
+	case *ast.IfExpr:
+		return expr.Cond.Val
+	case *ast.LabeledExpr:
+		return expr.Label.Val
+	case *ast.LitMatcher:
+		s := strconv.Quote(expr.Val)
+		if expr.IgnoreCase {
+			s += "i"
+		}
+		return s
+	case *ast.OperatorsExpr:
+		ops := make([]string, len(expr.Operators))
+		for i, op := range expr.Operators {
+			assoc := "left"
+			if op.RightAssoc {
+				assoc = "right"
+			}
+			ops[i] = fmt.Sprintf("%s/%d/%s", strconv.Quote(op.Lit.Val), op.Prec, assoc)
+		}
+		return strings.Join(ops, " ")
+	case *ast.RuleRefExpr:
+		return expr.Name.Val
+	case *ast.SepExpr:
+		var flags []string
+		if expr.Trailing {
+			flags = append(flags, "trailing")
+		}
+		if expr.Terminated {
+			flags = append(flags, "terminated")
+		}
+		if expr.Keep {
+			flags = append(flags, "keep")
+		}
+		return strings.Join(flags, " ")
+	case *ast.WhenExpr:
+		return expr.Flag.Val
+	case *ast.ZeroOrMoreExpr:
+		if expr.While != nil {
+			return "while " + listingCode(expr.While.Code)
+		}
+	case *ast.OneOrMoreExpr:
+		if expr.While != nil {
+			return "while " + listingCode(expr.While.Code)
+		}
+	case *ast.ZeroOrOneExpr:
+		if expr.Default != nil {
+			return "default " + listingCode(expr.Default)
+		}
+	default:
+		// the other matchers are described by their value as written in
+		// the grammar
+		if v := reflect.ValueOf(expr).Elem().FieldByName("Val"); v.IsValid() && v.Kind() == reflect.String {
+			return v.String()
+		}
+	}
+	return ""
+}
+
+// listingCode returns the code block in the listing, its first line
+// followed by an ellipsis if it has more than one.
+func listingCode(code *ast.CodeBlock) string {
+	if code == nil {
+		return ""
+	}
+	s := strings.TrimSpace(code.Val[1 : len(code.Val)-1])
+	if ix := strings.IndexByte(s, '\n'); ix >= 0 {
+		s = strings.TrimSpace(s[:ix]) + " ..."
+	}
+	return "{ " + s + " }"
+}
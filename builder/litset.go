@@ -0,0 +1,121 @@
+package builder
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// litSet is a set of literals that replaces an expression, matched by a
+// litSetMatcher.
+type litSet struct {
+	expr ast.Expression
+	alts []*ast.LitMatcher
+	// number of runes of each expression of the sequence, for each
+	// literal, nil if expr is a choice
+	parts [][]int
+	// number of expressions of expr, not including expr
+	size int
+}
+
+// literalSet returns the set of literals that matches the same input as
+// expr, with the same value, or nil if there is none. That is the case of
+// a choice of literals, and of a sequence of literals that ends with a
+// choice of literals, such as '.' ( "go" / "mod" ), that matches the
+// literals ".go" and ".mod". A choice elsewhere in the sequence is not
+// replaced, as the sequence does not backtrack into its alternatives.
+func literalSet(expr ast.Expression) *litSet {
+	switch expr := expr.(type) {
+	case *ast.ChoiceExpr:
+		alts := choiceLits(expr)
+		if len(alts) < 2 {
+			return nil
+		}
+		return &litSet{expr: expr, alts: alts, size: len(alts)}
+
+	case *ast.SeqExpr:
+		n := len(expr.Exprs)
+		if n < 2 {
+			return nil
+		}
+		ch, ok := expr.Exprs[n-1].(*ast.ChoiceExpr)
+		if !ok {
+			return nil
+		}
+		last := choiceLits(ch)
+		if len(last) == 0 {
+			return nil
+		}
+
+		var prefix []string
+		var parts []int
+		ignoreCase := last[0].IgnoreCase
+		for _, e := range expr.Exprs[:n-1] {
+			lit, ok := e.(*ast.LitMatcher)
+			if !ok || lit.IgnoreCase != ignoreCase {
+				return nil
+			}
+			prefix = append(prefix, lit.Val)
+			parts = append(parts, utf8.RuneCountInString(lit.Val))
+		}
+
+		set := &litSet{expr: expr, size: n + len(last)}
+		for _, lit := range last {
+			if lit.IgnoreCase != ignoreCase {
+				return nil
+			}
+			alt := ast.NewLitMatcher(lit.Pos(), strings.Join(prefix, "")+lit.Val)
+			alt.IgnoreCase = ignoreCase
+			set.alts = append(set.alts, alt)
+			set.parts = append(set.parts, append(parts[:len(parts):len(parts)], utf8.RuneCountInString(lit.Val)))
+		}
+		return set
+	}
+	return nil
+}
+
+// choiceLits returns the literals of the alternatives of ch, or nil if an
+// alternative is not a literal.
+func choiceLits(ch *ast.ChoiceExpr) []*ast.LitMatcher {
+	lits := make([]*ast.LitMatcher, 0, len(ch.Alternatives))
+	for _, alt := range ch.Alternatives {
+		lit, ok := alt.(*ast.LitMatcher)
+		if !ok {
+			return nil
+		}
+		lits = append(lits, lit)
+	}
+	return lits
+}
+
+// writeLitSetMatcher writes the litSetMatcher of set.
+func (b *builder) writeLitSetMatcher(set *litSet) {
+	b.writelnf("&litSetMatcher{")
+	pos := set.expr.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\talts: []*litMatcher{")
+	for _, alt := range set.alts {
+		b.writeLitMatcher(alt)
+	}
+	b.writelnf("\t},")
+	if set.parts != nil {
+		b.writelnf("\tparts: [][]int{")
+		for _, parts := range set.parts {
+			b.writef("\t\t{")
+			for i, n := range parts {
+				if i > 0 {
+					b.writef(", ")
+				}
+				b.writef("%d", n)
+			}
+			b.writelnf("},")
+		}
+		b.writelnf("\t},")
+	}
+	b.writelnf("},")
+
+	// keep the expression index unchanged, so that the names of the code
+	// blocks' functions do not depend on the replaced expressions.
+	b.exprIndex += set.size
+}
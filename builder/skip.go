@@ -0,0 +1,195 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// Skip returns an option that sets the name of the rule that is matched
+// automatically before the matchers and the references to lexical rules in
+// the other rules, typically a rule that matches optional whitespace. The
+// rules marked with "@lexical" and the rule nm itself are lexical, they are
+// generated as written, as are the expressions of the "@verbatim(expr)"
+// expressions. The default is no rule, all rules are generated as written.
+func Skip(nm string) Option {
+	return func(b *builder) Option {
+		prev := b.skip
+		b.skip = nm
+		return Skip(prev)
+	}
+}
+
+// skipExpr matches the skip rule, then its expression. Its value is the
+// value of its expression.
+type skipExpr struct {
+	p    ast.Pos
+	Skip *ast.RuleRefExpr
+	Expr ast.Expression
+}
+
+func (s *skipExpr) Pos() ast.Pos { return s.p }
+
+func (s *skipExpr) String() string {
+	return fmt.Sprintf("%s: %T{Skip: %v, Expr: %v}", s.p, s, s.Skip, s.Expr)
+}
+
+// skipRules returns a copy of g where the expressions of the rules that
+// are not lexical match the skip rule before their matchers and their
+// references to lexical rules.
+func (b *builder) skipRules(g *ast.Grammar) (*ast.Grammar, error) {
+	lexical := map[string]bool{b.skip: true}
+	found := false
+	for _, r := range g.Rules {
+		if r.Lexical {
+			lexical[r.Name.Val] = true
+		}
+		found = found || r.Name.Val == b.skip
+	}
+	if !found {
+		return nil, fmt.Errorf("builder: skip rule %s is not declared", b.skip)
+	}
+
+	cp := *g
+	cp.Rules = make([]*ast.Rule, len(g.Rules))
+	for i, r := range g.Rules {
+		cp.Rules[i] = r
+		if lexical[r.Name.Val] {
+			continue
+		}
+		rcp := *r
+		rcp.Expr = b.withSkip(r.Expr, lexical)
+		cp.Rules[i] = &rcp
+	}
+	return &cp, nil
+}
+
+// withSkip returns a copy of expr where the matchers and the references
+// to lexical rules are preceded by the skip rule.
+func (b *builder) withSkip(expr ast.Expression, lexical map[string]bool) ast.Expression {
+	skip := func(expr ast.Expression) ast.Expression {
+		ref := ast.NewRuleRefExpr(expr.Pos())
+		ref.Name = ast.NewIdentifier(expr.Pos(), b.skip)
+		return &skipExpr{p: expr.Pos(), Skip: ref, Expr: expr}
+	}
+
+	switch expr := expr.(type) {
+	case *ast.AnyMatcher, *ast.BackRefExpr, *ast.SeenExpr, *ast.ByteMatcher, *ast.BytesMatcher,
+		*ast.CharClassMatcher, *ast.KeywordMatcher, *ast.LitMatcher, *ast.NestedMatcher,
+		*ast.NumberMatcher, *ast.RestOfLineMatcher, *ast.TableMatcher, *ast.TokenMatcher,
+		*ast.UntilMatcher, *ast.WordListMatcher:
+		return skip(expr)
+	case *ast.RuleRefExpr:
+		if lexical[expr.Name.Val] {
+			return skip(expr)
+		}
+		return expr
+	case *ast.VerbatimExpr:
+		return expr
+	case *ast.ActionExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.AndExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.CompactExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.TrimExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.ConvertExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.ArrayExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.MapExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.ChoiceExpr:
+		cp := *expr
+		cp.Alternatives = make([]ast.Expression, len(expr.Alternatives))
+		for i, alt := range expr.Alternatives {
+			cp.Alternatives[i] = b.withSkip(alt, lexical)
+		}
+		return &cp
+	case *ast.FoldExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.IfExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.LabeledExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.WhenExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.NotExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.OneOrMoreExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.OperatorsExpr:
+		cp := *expr
+		cp.Operand = b.withSkip(expr.Operand, lexical)
+		return &cp
+	case *ast.SepExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		cp.Sep = b.withSkip(expr.Sep, lexical)
+		return &cp
+	case *ast.SeqExpr:
+		cp := *expr
+		cp.Exprs = make([]ast.Expression, len(expr.Exprs))
+		for i, sub := range expr.Exprs {
+			cp.Exprs[i] = b.withSkip(sub, lexical)
+		}
+		return &cp
+	case *ast.UnreservedExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.ZeroOrMoreExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	case *ast.ZeroOrOneExpr:
+		cp := *expr
+		cp.Expr = b.withSkip(expr.Expr, lexical)
+		return &cp
+	}
+	return expr
+}
+
+func (b *builder) writeSkipExpr(s *skipExpr) {
+	// the skip expression and rule reference are not in the grammar, keep
+	// the expression index unchanged so that the names of the code blocks'
+	// functions do not depend on the Skip option.
+	b.exprIndex--
+	b.writelnf("&skipExpr{")
+	pos := s.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\tskip: ")
+	ix := b.exprIndex
+	b.writeExpr(s.Skip)
+	b.exprIndex = ix
+	b.writef("\texpr: ")
+	b.writeExpr(s.Expr)
+	b.writelnf("},")
+}
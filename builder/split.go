@@ -0,0 +1,141 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/craiggwilson/pigeon/ast"
+)
+
+// splitFile is a file of a parser generated by BuildParserDir.
+type splitFile struct {
+	name string
+	buf  bytes.Buffer
+}
+
+// BuildParserDir builds the PEG parser using the provider grammar like
+// BuildParser, but writes it to several files in the directory dir:
+// parser.go holds the initializer, the generated types and the entrypoint
+// functions, parser_grammar.go the grammar tables, parser_actions.go the
+// code blocks of the rules and parser_runtime.go the code common to all
+// parsers. Each file starts with the package clause and the imports of the
+// initializer, so the files must be processed by a tool such as goimports
+// that removes the unused imports and adds the missing ones. The package
+// name is that of the PackageName option, or of the package clause of the
+// initializer.
+func BuildParserDir(dir string, g *ast.Grammar, opts ...Option) error {
+	b := &builder{recvName: "c", ctxType: "current", files: []*splitFile{}}
+	b.setOptions(opts)
+
+	if b.pkgName != "" {
+		b.pkgClause = "package " + b.pkgName
+	} else if g.Init != nil {
+		b.pkgClause = packageClause(g.Init.Val[1 : len(g.Init.Val)-1])
+	}
+	if b.pkgClause == "" {
+		return errors.New("builder: the package name is required to generate the parser in a directory")
+	}
+	if !b.strip {
+		var code string
+		if g.Init != nil {
+			code = g.Init.Val[1 : len(g.Init.Val)-1]
+		}
+		b.imports = initImports(code)
+		if decl := b.importDecl(code); decl != "" {
+			b.imports = strings.TrimPrefix(b.imports+"\n"+decl, "\n")
+		}
+	}
+	b.startFile("parser.go")
+	if err := b.buildParser(g); err != nil {
+		return err
+	}
+
+	for _, f := range b.files {
+		if b.goVersion != "" {
+			if err := b.checkGoVersion(f.buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range b.files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f.name), f.buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startFile starts the file name of the BuildParserDir function, so that
+// the code is written to it. The first file gets the package clause of the
+// generated code, the next ones get the package clause and the imports of
+// the initializer. It does nothing if the parser is written to a single
+// file.
+func (b *builder) startFile(name string) {
+	if b.files == nil {
+		return
+	}
+	f := &splitFile{name: name}
+	b.w = &f.buf
+	if len(b.files) > 0 {
+		b.writelnf("%s", b.pkgClause)
+		if b.imports != "" {
+			b.writelnf("\n%s", b.imports)
+		}
+		b.writelnf("")
+	}
+	b.files = append(b.files, f)
+}
+
+// initImports returns the import declarations of the initializer code, or
+// an empty string if there is none or if the code is not valid Go.
+func initImports(code string) string {
+	src, f := parseImports(code)
+	if f == nil {
+		return ""
+	}
+	var decls []string
+	for _, d := range f.Decls {
+		if gen, ok := d.(*goast.GenDecl); ok && gen.Tok == token.IMPORT {
+			decls = append(decls, src[gen.Pos()-1:gen.End()-1])
+		}
+	}
+	return strings.Join(decls, "\n")
+}
+
+// importPaths returns the import paths of the initializer code, or nil if
+// there is none or if the code is not valid Go.
+func importPaths(code string) []string {
+	_, f := parseImports(code)
+	if f == nil {
+		return nil
+	}
+	var paths []string
+	for _, spec := range f.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// parseImports parses the imports of the initializer code, with a package
+// clause added if it has none, and returns the parsed source and file, or
+// a nil file if the code is not valid Go.
+func parseImports(code string) (string, *goast.File) {
+	src := code
+	if packageClause(code) == "" {
+		src = "package p\n" + code
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return src, nil
+	}
+	return src, f
+}
@@ -11,6 +11,31 @@ var (
 
 	// errNoMatch is returned if no match could be found.
 	errNoMatch         = errors.New("no match found")
+
+	// errInputTooLarge is returned when the input exceeds the limit set
+	// by the MaxInputRunes option.
+	errInputTooLarge   = &InputTooLarge{}
+
+	// errNumberRange is returned when a number matched by the Number
+	// matcher is out of the range of its type.
+	errNumberRange     = errors.New("number out of range")
+
+	// errMaxDepth is returned when the rules are nested deeper than the
+	// limit set by the MaxDepth option.
+	errMaxDepth        = &MaxDepthExceeded{}
+
+	// errMaxRepeat is returned when a repetition matches more times than
+	// the limit set by the MaxRepeat option.
+	errMaxRepeat       = &MaxRepeatExceeded{}
+
+	// errStopRepeat is returned by an action code block to fail its match
+	// without an error and without consuming the input, so that the
+	// repetition that it is an iteration of ends before it.
+	errStopRepeat      = errors.New("stop repeat")
+
+	// errTrailingInput is returned when the start rule does not match the
+	// whole input and the RequireTrailingEOF option is set.
+	errTrailingInput   = &TrailingInput{}
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -18,7 +43,8 @@ var (
 type Option func(*parser) Option
 
 // Debug creates an Option to set the debug flag to b. When set to true,
-// debugging information is printed to stdout while parsing.
+// debugging information is printed to stdout while parsing, or to the
+// Logger set by the WithLogger option.
 //
 // The default is false.
 func Debug(b bool) Option {
@@ -29,12 +55,57 @@ func Debug(b bool) Option {
 	}
 }
 
+// Logger is the interface of the destination of the debugging information
+// of the Debug option, such as a *log.Logger or an adapter to another
+// logging package. Printf is called once for each line.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// WithLogger creates an Option to set the Logger of the debugging
+// information to l.
+//
+// The default is nil, the debugging information is printed to stdout.
+func WithLogger(l Logger) Option {
+	return func(p *parser) Option {
+		old := p.logger
+		p.logger = l
+		return WithLogger(old)
+	}
+}
+
+// Tracer is the interface of the tracer of the WithTracer option, such as
+// an adapter to an OpenTelemetry tracer. StartSpan is called when the
+// parse starts at the entrypoint rule, and the End method of the span it
+// returns when the parse ends, with the error of the parse.
+type Tracer interface {
+	StartSpan(rule string) Span
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	End(err error)
+}
+
+// WithTracer creates an Option to set the Tracer of the parse to t, which
+// records a span for the entrypoint rule of each parse.
+//
+// The default is nil, no span is recorded.
+func WithTracer(t Tracer) Option {
+	return func(p *parser) Option {
+		old := p.tracer
+		p.tracer = t
+		return WithTracer(old)
+	}
+}
+
 // Memoize creates an Option to set the memoize flag to b. When set to true,
 // the parser will cache all results so each expression is evaluated only
 // once. This guarantees linear parsing time even for pathological cases,
 // at the expense of more memory and slower times for typical cases.
 //
-// The default is false.
+// The default is false, unless the parser is generated with the
+// -default-memoize flag.
 func Memoize(b bool) Option {
 	return func(p *parser) Option {
 		old := p.memoize
@@ -43,821 +114,5206 @@ func Memoize(b bool) Option {
 	}
 }
 
-// Recover creates an Option to set the recover flag to b. When set to
-// true, this causes the parser to recover from panics and convert it
-// to an error. Setting it to false can be useful while debugging to
-// access the full stack trace.
-//
-// The default is true.
-func Recover(b bool) Option {
-	return func(p *parser) Option {
-		old := p.recover
-		p.recover = b
-		return Recover(old)
-	}
+// MemoStore is the interface of the memoization table used when the
+// Memoize option is set, such as a table that logs its accesses or that
+// persists the results. Get returns the result stored by Set for the node
+// at offset, where node is an opaque key that identifies a rule or an
+// expression of the grammar. A MemoStore must not be shared by parses.
+type MemoStore interface {
+	Get(node interface{}, offset int) (MemoResult, bool)
+	Set(node interface{}, offset int, res MemoResult)
 }
 
-// ParseFile parses the file identified by filename.
-func ParseFile(filename string, opts ...Option) (interface{}, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	return ParseReader(filename, f, opts...)
+// MemoResult is the result of the match of a node at an offset, stored in
+// a MemoStore. Its content is private to the parser.
+type MemoResult struct {
+	tuple resultTuple
+	// end of the input examined for the result, set with the ReuseMemo
+	// option, and parse of the MemoCache that stored the result
+	reach int
+	gen   int
 }
 
-// ParseReader parses the data from r using filename as information in the
-// error messages.
-func ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
-
-	return Parse(filename, b, opts...)
+// MemoCache keeps the memoization table of a parse for the next parses
+// with the ReuseMemo option. Its zero value is an empty cache.
+type MemoCache struct {
+	data    []byte
+	version interface{}
+	table   memoTable
+	gen     int
+	hits    int
 }
 
-// Parse parses the data from b using filename as information in the
-// error messages.
-func Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
-	return newParser(filename, b, opts...).parse(g)
-}
+// Hits returns the number of results of the last parse that were taken
+// from the previous parses.
+func (c *MemoCache) Hits() int { return c.hits }
 
-// position records a position in the text.
-type position struct {
-	line, col, offset int
+// prepare removes from the cache the results that depend on the input
+// after the common prefix of data and of the input of the previous parse,
+// and starts the parse of data at version. Only the results of the rules
+// are kept: those of the expressions do not bind the labels of the rule
+// that is parsed again. All the results are removed if the version differs
+// from that of the previous parse.
+func (c *MemoCache) prepare(data []byte, version interface{}) {
+	if version != c.version {
+		c.table = nil
+		c.data = c.data[:0]
+		c.version = version
+	}
+	n := 0
+	for n < len(data) && n < len(c.data) && data[n] == c.data[n] {
+		n++
+	}
+	if n == len(data) && n == len(c.data) {
+		// the end of the input is the same too
+		n++
+	}
+	for off, m := range c.table {
+		for node, res := range m {
+			_, isRule := node.(*rule)
+			end := res.tuple.end
+			// the logs of the previous parse are not kept
+			if !isRule || res.reach > n || end.owned > 0 || end.matched > 0 || end.warned > 0 ||
+				end.errored > 0 || end.evented > 0 || end.seen > 0 {
+				delete(m, node)
+			}
+		}
+		if len(m) == 0 {
+			delete(c.table, off)
+		}
+	}
+	if c.table == nil {
+		c.table = make(memoTable)
+	}
+	c.data = append(c.data[:0], data...)
+	c.gen++
+	c.hits = 0
 }
 
-func (p position) String() string {
-	return fmt.Sprintf("%%d:%%d [%%d]", p.line, p.col, p.offset)
+// cacheStore is the MemoStore of a MemoCache.
+type cacheStore struct {
+	c *MemoCache
 }
 
-// savepoint stores all state required to go back to this point in the
-// parser.
-type savepoint struct {
-	position
-	rn rune
-	w  int
+func (s cacheStore) Get(node interface{}, offset int) (MemoResult, bool) {
+	res, ok := s.c.table.Get(node, offset)
+	if ok && res.gen != s.c.gen {
+		s.c.hits++
+	}
+	return res, ok
 }
 
-type current struct {
-	pos  position // start position of the match
-	text []byte   // raw text of the match
+func (s cacheStore) Set(node interface{}, offset int, res MemoResult) {
+	res.gen = s.c.gen
+	s.c.table.Set(node, offset, res)
 }
 
-// the AST types...
-
-type grammar struct {
-	pos   position
-	rules []*rule
+// ReuseMemo creates an Option to keep the memoization table of the parse
+// in c and to reuse the results of the previous parses with c, so that
+// inputs that share a long prefix are parsed faster. A result is reused
+// only if the part of the input that was examined to compute it is the
+// same, so that the result of the parse does not change, but the values of
+// the reused results may refer to the input of the previous parses. The
+// parses must use the same options, and c must not be used by concurrent
+// parses. It sets the Memoize option, and replaces the WithMemoStore
+// option. It has no effect when parsing tokens.
+//
+// The default is nil, the results are not reused.
+func ReuseMemo(c *MemoCache) Option {
+	return func(p *parser) Option {
+		old := p.memoCache
+		p.memoCache = c
+		return ReuseMemo(old)
+	}
 }
 
-type rule struct {
-	pos         position
-	name        string
-	displayName string
-	expr        interface{}
+// MemoVersion creates an Option to set the version of the input to v, with
+// the ReuseMemo option. The results of the previous parses are not reused
+// if the version differs from that of the last parse with the MemoCache, so
+// that a cache used for several inputs, such as the documents of an editor,
+// never returns the results of another input. v must be comparable with
+// ==.
+//
+// The default is nil, the inputs of the parses with the same cache are only
+// compared to find their common prefix.
+func MemoVersion(v interface{}) Option {
+	return func(p *parser) Option {
+		old := p.memoVersion
+		p.memoVersion = v
+		return MemoVersion(old)
+	}
 }
 
-type choiceExpr struct {
-	pos          position
-	alternatives []interface{}
+// WithMemoStore creates an Option to set the memoization table to s.
+//
+// The default is nil, the results are stored in a map.
+func WithMemoStore(s MemoStore) Option {
+	return func(p *parser) Option {
+		old := p.memoStore
+		p.memoStore = s
+		return WithMemoStore(old)
+	}
 }
 
-type actionExpr struct {
-	pos    position
-	expr   interface{}
-	run    func(*parser) (interface{}, error)
+// Flag creates an Option to set the flag identified by name to b. The
+// alternatives prefixed with "@when(name)" in the grammar only match if
+// the flag is set, so that a single parser can support several dialects.
+//
+// The default is false for all flags.
+func Flag(name string, b bool) Option {
+	return func(p *parser) Option {
+		old := p.flags[name]
+		if p.flags == nil {
+			p.flags = make(map[string]bool)
+		}
+		p.flags[name] = b
+		return Flag(name, old)
+	}
 }
 
-type seqExpr struct {
-	pos   position
-	exprs []interface{}
+// Table creates an Option to set the Unicode range table named name to t.
+// The @table(name) matcher matches a rune of the table, so that a set of
+// characters specific to a domain, such as the runes that can start an
+// identifier, can be provided at parse time. A nil table removes it.
+//
+// The default is no table, the @table matcher never matches.
+func Table(name string, t *unicode.RangeTable) Option {
+	return func(p *parser) Option {
+		old := p.tables[name]
+		if p.tables == nil {
+			p.tables = make(map[string]*unicode.RangeTable)
+		}
+		p.tables[name] = t
+		return Table(name, old)
+	}
 }
 
-type labeledExpr struct {
-	pos   position
-	label string
-	expr  interface{}
+// Converter creates an Option to set the converter named name to fn. The
+// value of the convert expression @name(expr) is the value returned by fn
+// for the text matched by expr, so that no action is needed to convert
+// it. If fn returns an error, the expression fails with that error. The
+// built-in converters are int, float and bool, that convert the text to an
+// int, a float64 and a bool as fmt.Sscan does, and that fn replaces if it
+// has their name. A nil fn removes the converter.
+//
+// The default is the built-in converters only.
+func Converter(name string, fn func(string) (interface{}, error)) Option {
+	return func(p *parser) Option {
+		old := p.converters[name]
+		if p.converters == nil {
+			p.converters = make(map[string]func(string) (interface{}, error))
+		}
+		if fn == nil {
+			delete(p.converters, name)
+		} else {
+			p.converters[name] = fn
+		}
+		return Converter(name, old)
+	}
 }
 
-type expr struct {
-	pos  position
-	expr interface{}
+// ClassTable creates an Option to set the Unicode range table of the class
+// named class in the character classes, e.g. "L" for "[\pL]", to t instead
+// of the table of the unicode package, so that the runes of a class can be
+// chosen at parse time, e.g. with the tables of a newer version of Unicode
+// or the identifier tables built with golang.org/x/text/unicode/rangetable.
+// A nil table restores the table of the unicode package.
+//
+// The default is no table, the classes use the tables of the unicode
+// package.
+func ClassTable(class string, t *unicode.RangeTable) Option {
+	return func(p *parser) Option {
+		old := p.classTables[class]
+		if p.classTables == nil {
+			p.classTables = make(map[string]*unicode.RangeTable)
+		}
+		p.classTables[class] = t
+		if t == nil {
+			delete(p.classTables, class)
+		}
+		p.classCopies = nil
+		return ClassTable(class, old)
+	}
 }
 
-type andExpr expr
-type notExpr expr
-type zeroOrOneExpr expr
-type zeroOrMoreExpr expr
-type oneOrMoreExpr expr
-
-type ruleRefExpr struct {
-	pos  position
-	name string
+// Keywords creates an Option to set the words matched by the @keyword
+// matcher to words. The keyword matcher matches the longest of the words
+// found at the current position that is not immediately followed by a
+// letter, a digit or an underscore. The @unreserved expression fails if
+// its match is one of the words.
+//
+// The default is no word, the @keyword matcher never matches.
+func Keywords(words ...string) Option {
+	return func(p *parser) Option {
+		old := p.keywords
+		p.keywords = words
+		return Keywords(old...)
+	}
 }
 
-type andCodeExpr struct {
-	pos position
-	run func(*parser) (bool, error)
+// SkipFunc creates an Option to set the function that decides the runes
+// skipped before the matchers and the references to lexical rules when the
+// parser is generated with a skip rule. If fn is not nil, the parser skips
+// the runes for which fn returns true instead of matching the skip rule,
+// so that what is skipped can be chosen at parse time. It has no effect
+// when parsing tokens.
+//
+// The default is nil, the skip rule is matched.
+func SkipFunc(fn func(rune) bool) Option {
+	return func(p *parser) Option {
+		old := p.skipFunc
+		p.skipFunc = fn
+		return SkipFunc(old)
+	}
 }
 
-type notCodeExpr struct {
-	pos position
-	run func(*parser) (bool, error)
+// LineComment creates an Option to also skip the line comments that
+// start with prefix, up to the end of the line, wherever the whitespace is
+// skipped when the parser is generated with a skip rule, by the skip rule
+// or the SkipFunc option, and by the SkipLeading, RequireTrailingEOF options
+// and the Tokenize function. The newline that ends the comment is left to
+// the skipping of the whitespace, so that a rule marked with
+// @nlsignificant still sees it. It has no effect when parsing tokens.
+//
+// The default is "", no comment is skipped.
+func LineComment(prefix string) Option {
+	return func(p *parser) Option {
+		old := p.lineComment
+		p.lineComment = prefix
+		return LineComment(old)
+	}
 }
 
-type litMatcher struct {
-	pos        position
-	val        string
-	ignoreCase bool
+// SkipLeading creates an Option to set the skip leading flag to b. When
+// set to true, the whitespace at the start of the input is skipped before
+// the start rule is matched: the runes of the SkipFunc option if it is
+// set, else the skip rule if the parser is generated with one, else the
+// Unicode white space.
+//
+// The default is false.
+func SkipLeading(b bool) Option {
+	return func(p *parser) Option {
+		old := p.skipLeading
+		p.skipLeading = b
+		return SkipLeading(old)
+	}
 }
 
-type charClassMatcher struct {
-	pos        position
-	val        string
-	chars      []rune
-	ranges     []rune
-	classes    []*unicode.RangeTable
-	ignoreCase bool
-	inverted   bool
+// RequireTrailingEOF creates an Option to set the require trailing EOF
+// flag to b. When set to true, the whitespace that follows the match of
+// the start rule is skipped as for the SkipLeading option, and the parse
+// fails if the input does not end there, so that the start rule does not
+// have to end with "!.".
+//
+// The default is false.
+func RequireTrailingEOF(b bool) Option {
+	return func(p *parser) Option {
+		old := p.requireEOF
+		p.requireEOF = b
+		return RequireTrailingEOF(old)
+	}
 }
 
-type anyMatcher position
-
-// errList cumulates the errors found by the parser.
-type errList []error
-
-func (e *errList) add(err error) {
-	*e = append(*e, err)
+// WordList creates an Option to set the words matched by the @wordlist
+// matcher to words. The words are stored in a trie when the option is
+// applied, so that the matcher finds the longest of the words at the
+// current position in a single pass over the input, whatever their number.
+// As for @keyword, a word does not match if it is immediately followed by
+// a letter, a digit or an underscore. The empty words are ignored.
+//
+// The default is no word, the @wordlist matcher never matches.
+func WordList(words ...string) Option {
+	return func(p *parser) Option {
+		old := p.wordList
+		p.wordList = words
+		p.wordTrie = newWordNode(words)
+		return WordList(old...)
+	}
 }
 
-func (e errList) err() error {
-	if len(e) == 0 {
-		return nil
+// MaxBacktrack creates an Option to set the maximum number of times a
+// rule can backtrack to the same offset to n. When this limit is exceeded,
+// parsing stops with an error identifying the rule. This is useful during
+// development to detect ambiguous rules that result in exponential parsing
+// time. A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxBacktrack(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxBacktrack
+		p.maxBacktrack = n
+		return MaxBacktrack(old)
 	}
-	e.dedupe()
-	return e
 }
 
-func (e *errList) dedupe() {
-	var cleaned []error
-	set := make(map[string]bool)
-	for _, err := range *e {
-		if msg := err.Error(); !set[msg] {
-			set[msg] = true
-			cleaned = append(cleaned, err)
-		}
+// MaxDepth creates an Option to set the maximum number of rules that can
+// be nested during the parse to n. When this limit is exceeded, parsing
+// stops with a *MaxDepthExceeded error, so that a deeply nested input against
+// a recursive grammar cannot overflow the stack, e.g. on a server. The
+// inlined rules do not count. A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxDepth(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxDepth
+		p.maxDepth = n
+		return MaxDepth(old)
 	}
-	*e = cleaned
 }
 
-func (e errList) Error() string {
-	switch len(e) {
-	case 0:
-		return ""
-	case 1:
-		return e[0].Error()
-	default:
-		var buf bytes.Buffer
-
-		for i, err := range e {
-			if i > 0 {
-				buf.WriteRune('\n')
-			}
-			buf.WriteString(err.Error())
-		}
-		return buf.String()
+// MaxRepeat creates an Option to set the maximum number of times that a
+// single zero-or-more or one-or-more repetition can match to n. When this
+// limit is exceeded, parsing stops with a *MaxRepeatExceeded error, so
+// that a pathological input cannot make the parser accumulate an unbounded
+// number of values, e.g. on a server. A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxRepeat(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxRepeat
+		p.maxRepeat = n
+		return MaxRepeat(old)
 	}
 }
 
-// parserError wraps an error with a prefix indicating the rule in which
-// the error occurred. The original error is stored in the Inner field.
-type parserError struct {
-	Inner  error
-	pos    position
-	prefix string
+// MaxInputRunes creates an Option to set the maximum size of the input to
+// n runes, or n tokens in token mode. A larger input is rejected with an
+// error before parsing, e.g. to protect a server from oversized documents.
+// A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxInputRunes(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxInputRunes
+		p.maxInputRunes = n
+		return MaxInputRunes(old)
+	}
 }
 
-// Error returns the error message.
-func (p *parserError) Error() string {
-	return p.prefix + ": " + p.Inner.Error()
+// InitialStackCap creates an Option to set the initial capacity of the
+// stacks of the values and of the rules of the parse to n, so that the
+// parse of an input whose nesting is known does not grow them, e.g. for
+// frequent small parses. A value of 0 lets the stacks grow from empty.
+//
+// The default is 0.
+func InitialStackCap(n int) Option {
+	return func(p *parser) Option {
+		old := p.stackCap
+		p.stackCap = n
+		return InitialStackCap(old)
+	}
 }
 
-// newParser creates a parser with the specified input source and options.
-func newParser(filename string, b []byte, opts ...Option) *parser {
-	p := &parser{
-		filename: filename,
-		errs: new(errList),
-		data: b,
-		pt: savepoint{position: position{line: 1}},
-		recover: true,
+// Ownership creates an Option to record in m the number of runes owned by
+// each rule in the successful parse, keyed by rule name. A rune is owned
+// by the innermost rule that matched it, so that the numbers of runes sum
+// to the number of runes consumed by the parse. The runes of the inlined
+// rules are owned by the referencing rule. The ownership is not accurate
+// if the Memoize option is set.
+//
+// The default is nil, the ownership is not recorded.
+func Ownership(m map[string]int) Option {
+	return func(p *parser) Option {
+		old := p.owned
+		p.owned = m
+		return Ownership(old)
 	}
-	p.setOptions(opts)
-	return p
 }
 
-// setOptions applies the options to the parser.
-func (p *parser) setOptions(opts []Option) {
-	for _, opt := range opts {
-		opt(p)
+// Statistics creates an Option to record in *s the number of times each
+// rule of the grammar matched and failed to match during the parse, whether
+// it succeeds or not, and the peak depths of its stacks. A rule that is
+// tried often but rarely matches is a candidate for reordering the
+// alternatives of a choice. The inlined rules are never tried. With the
+// Memoize option, a result taken from the memoization table is counted
+// like a new attempt.
+//
+// The default is nil, the statistics are not recorded.
+func Statistics(s *Stats) Option {
+	return func(p *parser) Option {
+		old := p.stats
+		p.stats = s
+		return Statistics(old)
 	}
 }
 
-type resultTuple struct {
-	v interface{}
-	b bool
-	end savepoint
+// TokenCounts creates an Option to record in m the number of times each
+// lexical rule of the grammar, marked with @lexical, matched during the
+// parse, keyed by rule name, e.g. to get a histogram of the identifiers and
+// numbers of the input. The counts are the success counts of the
+// Statistics option, so the matches that were backtracked over are
+// counted, and the inlined lexical rules are not counted.
+//
+// The default is nil, the counts are not recorded.
+func TokenCounts(m map[string]int) Option {
+	return func(p *parser) Option {
+		old := p.tokenCounts
+		p.tokenCounts = m
+		return TokenCounts(old)
+	}
 }
 
-type parser struct {
-	filename string
-	pt       savepoint
-	cur      current
+// Ambiguities creates an Option to append to *a an Ambiguity for each
+// ordered choice at which more than one alternative matches at the same
+// offset, which the ordered choice silently resolves in favor of the first
+// one. This is an instrumented mode for debugging a grammar: once an
+// alternative matches, the next ones are tried too and then backtracked
+// over, so that their code blocks run and the parse is slower. A choice is
+// reported once per offset.
+//
+// The default is nil, the ambiguities are not recorded.
+func Ambiguities(a *[]Ambiguity) Option {
+	return func(p *parser) Option {
+		old := p.ambiguities
+		p.ambiguities = a
+		return Ambiguities(old)
+	}
+}
 
-	// errors
-	maxSavePoint savepoint
-	maxFound string
-	maxExpected []string
-	
-	data []byte
-	errs *errList
+// OnMatch creates an Option to set the function called for each match of
+// a rule in the successful parse, with the name of the rule, the start and
+// end positions of the match and its value. The matches of rules that were
+// backtracked over are not reported. The function is called in the order
+// the matches completed, once the input is parsed, so the matches of the
+// rules referenced by a rule are reported before the match of that rule.
+// The inlined rules are not reported. The matches are not accurate if the
+// Memoize option is set.
+//
+// The default is nil, the matches are not reported.
+func OnMatch(fn func(rule string, start, end Pos, value interface{})) Option {
+	return func(p *parser) Option {
+		old := p.onMatch
+		p.onMatch = fn
+		return OnMatch(old)
+	}
+}
 
-	recover bool
-	debug bool
-	depth  int
+// Events creates an Option to set the function called for the events of
+// the successful parse, to process the matches of the rules without
+// building a value for the whole input. The match of a rule is reported as
+// an EventStart, followed by the events of the rules it references and an
+// EventText for the text matched by each of its matchers, followed by an
+// EventEnd. The events of the rules that were backtracked over are not
+// reported. The function is called in the order of the events, once the
+// input is parsed. The inlined rules are not reported. The events are not
+// accurate if the Memoize option is set.
+//
+// The default is nil, the events are not reported.
+func Events(fn func(Event)) Option {
+	return func(p *parser) Option {
+		old := p.events
+		p.events = fn
+		return Events(old)
+	}
+}
 
-	memoize bool
-	// memoization table for the packrat algorithm:
-	// map[offset in source] map[expression or rule] {value, match}
-	memo map[int]map[interface{}]resultTuple
+// Warnings creates an Option to set *w to the warnings recorded by the code
+// blocks of the grammar with c.warn in the successful parse, in the order
+// they were recorded. The warnings are not accurate if the Memoize option
+// is set.
+//
+// The default is nil, the warnings are not returned.
+func Warnings(w *[]Warning) Option {
+	return func(p *parser) Option {
+		old := p.warnings
+		p.warnings = w
+		return Warnings(old)
+	}
+}
 
-	// rules table, maps the rule identifier to the rule node
-	rules  map[string]*rule
-	// variables stack, map of label to value
-	vstack []map[string]interface{}
-	// rule stack, allows identification of the current rule in errors
-	rstack []*rule
+// LongestPrefix creates an Option to set *prefix to the longest prefix of
+// the input that the parse matched. If the parse fails, it is the input up
+// to the farthest position that an expression matched up to, outside of
+// the and and not predicates, so that the caller can show the valid part
+// of an input that goes wrong. Otherwise it is the input matched by the
+// start rule, before any trailing input of the RequireTrailingEOF option.
+// The prefix is not set in token mode, nor if the parse is stopped by a
+// limit such as the one of MaxDepth.
+//
+// The default is nil, the prefix is not returned.
+func LongestPrefix(prefix *Prefix) Option {
+	return func(p *parser) Option {
+		old := p.prefix
+		p.prefix = prefix
+		return LongestPrefix(old)
+	}
+}
 
-	// stats
-	exprCnt int
+// Trace creates an Option to append to *t a line for each rule and each
+// expression evaluated by the parser, in the order of evaluation. A line
+// is the kind of expression, e.g. "litMatcher", or "rule" and the name of
+// the rule, followed by the position where it is evaluated. Comparing the
+// trace to a known one tells if the code generated for a grammar still
+// parses its input the same way. Memoized results are not evaluated again
+// and are not in the trace.
+//
+// The default is nil, no trace is recorded.
+func Trace(t *[]string) Option {
+	return func(p *parser) Option {
+		old := p.trace
+		p.trace = t
+		return Trace(old)
+	}
 }
 
-func (p *parser) setMaxSavePoint(current string, expected string) {
-	if p.pt.offset > p.maxSavePoint.offset {
-		p.maxFound = current
-		p.maxSavePoint = p.pt
-		p.maxExpected = []string{expected}
-	} else if p.pt.offset == p.maxSavePoint.offset {
-		for _, e := range p.maxExpected {
-			if e == expected {
-				return
-			}
+// TextNormalizer creates an Option to set the function that rewrites the
+// text of the current match before the action code blocks run to fn, e.g.
+// to lowercase the identifiers or to normalize them to Unicode NFC. The
+// function is called with the name of the rule of the action and the text
+// matched by its expression, and c.text is set to the text it returns.
+// The input and the positions are not changed.
+//
+// The default is nil, the text is not rewritten.
+func TextNormalizer(fn func(rule, text string) string) Option {
+	return func(p *parser) Option {
+		old := p.normalizer
+		p.normalizer = fn
+		return TextNormalizer(old)
+	}
+}
+
+// Stream creates an Option to set the function that receives the values of
+// the repetitions of the rule named rule to fn. In a "*" or "+" expression
+// whose expression is a reference to the rule, the value of each match of
+// the rule is passed to fn instead of being collected, so that a long list
+// of items, e.g. at the top level of a large file, is processed without
+// keeping the values in memory: the value of the repetition is nil. If fn
+// returns an error, the repetition fails and the error is added to the
+// list of errors. The values are passed as the rule matches, even if the
+// parser backtracks before the repetition afterwards, so the repetition
+// should be one that does not backtrack. The inlined rules described in
+// the package documentation are not streamed. A nil fn removes the
+// function.
+//
+// The default is no function, the values are collected.
+func Stream(rule string, fn func(interface{}) error) Option {
+	return func(p *parser) Option {
+		old := p.streams[rule]
+		if p.streams == nil {
+			p.streams = make(map[string]func(interface{}) error)
 		}
-		p.maxExpected = append(p.maxExpected, expected)
+		p.streams[rule] = fn
+		if fn == nil {
+			delete(p.streams, rule)
+		}
+		return Stream(rule, old)
 	}
 }
 
-// push a variable set on the vstack.
-func (p *parser) pushV() {
-	if cap(p.vstack) == len(p.vstack) {
-		// create new empty slot in the stack
-		p.vstack = append(p.vstack, nil)
-	} else {
-		// slice to 1 more
-		p.vstack = p.vstack[:len(p.vstack)+1]
+// StrictNodes creates an Option to set the strict nodes flag to b. When
+// set to true, the value of each match of a rule with a @type is checked
+// against that type, after its action and its transformation, and the
+// match fails with an error that names the rule if the value is of
+// another type, to catch the actions that do not return the declared type
+// during development. A nil value is not checked, nor are the values of
+// the inlined rules.
+//
+// The default is false.
+func StrictNodes(b bool) Option {
+	return func(p *parser) Option {
+		old := p.strictNodes
+		p.strictNodes = b
+		return StrictNodes(old)
 	}
+}
 
-	// get the last args set
-	m := p.vstack[len(p.vstack)-1]
-	if m != nil && len(m) == 0 {
-		// empty map, all good
-		return
+// Transform creates an Option to set the function that transforms the
+// value of the rule named rule to fn. The function is called with the
+// value of each match of the rule, after its action, and its result
+// replaces that value. If it returns an error, the match fails and the
+// error is added to the list of errors. The values of the inlined rules
+// described in the package documentation are not transformed. A nil fn
+// removes the transformation.
+//
+// The default is no transformation.
+func Transform(rule string, fn func(interface{}) (interface{}, error)) Option {
+	return func(p *parser) Option {
+		old := p.transforms[rule]
+		if p.transforms == nil {
+			p.transforms = make(map[string]func(interface{}) (interface{}, error))
+		}
+		p.transforms[rule] = fn
+		if fn == nil {
+			delete(p.transforms, rule)
+		}
+		return Transform(rule, old)
 	}
+}
 
-	m = make(map[string]interface{})
-	p.vstack[len(p.vstack)-1] = m
+// ContextLines creates an Option to set the number of context lines of
+// the error messages to n. When n is 0 or more, the message of each error
+// is followed by the line of the input where it occurred, up to n lines
+// before and after it, and a line with a caret (^) under the column of
+// the error. The lines are prefixed with their line number.
+//
+// The default is -1, the messages have no context.
+func ContextLines(n int) Option {
+	return func(p *parser) Option {
+		old := p.contextLines
+		p.contextLines = n
+		return ContextLines(old)
+	}
 }
 
-// pop a variable set from the vstack.
-func (p *parser) popV() {
-	// if the map is not empty, clear it
-	m := p.vstack[len(p.vstack)-1]
-	if len(m) > 0 {
-		// GC that map
-		p.vstack[len(p.vstack)-1] = nil
+// RulePath creates an Option to set the rule path flag to b. When set to
+// true, the errors are prefixed with the path of the rules that were being
+// parsed where they occurred, from the start rule to the innermost one,
+// e.g. "rule Program > Stmt > Expr" instead of "rule Expr". The syntax
+// error gets the path of the rules at the farthest position of the input
+// that the parser reached.
+//
+// The default is false.
+func RulePath(b bool) Option {
+	return func(p *parser) Option {
+		old := p.rulePath
+		p.rulePath = b
+		return RulePath(old)
 	}
-	p.vstack = p.vstack[:len(p.vstack)-1]
 }
 
-func (p *parser) print(prefix, s string) string {
-	if !p.debug {
-		return s
+// JSONErrors creates an Option to set the JSON errors flag to b. When set
+// to true, the Error method of each error returned by the parser returns
+// a JSON object with the "offset", "line" and "col" of the error, the
+// "rule" where it occurred, by its display name if it has one, and its
+// "message", and for the syntax error the input "found" and the matchers
+// "expected", e.g. {"offset":4,"line":1,"col":5,"message":"..."}. The
+// Error method of the list of errors returns a JSON array of those
+// objects if there is more than one.
+//
+// The default is false.
+func JSONErrors(b bool) Option {
+	return func(p *parser) Option {
+		old := p.jsonErrors
+		p.jsonErrors = b
+		return JSONErrors(old)
 	}
+}
 
-	fmt.Printf("%%s %%d:%%d:%%d: %%s [%%#U]\n",
-		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
-	return s
+// DedupeErrors creates an Option to collapse the repeated errors within
+// window bytes of the input. An error with the same message and rule as
+// the last error that was kept, at most window bytes after it, is dropped,
+// so that the error productions that fail the same way on consecutive
+// statements of malformed input report a single error. A value of 0 or
+// less disables it, and only the errors at the same position are
+// collapsed.
+//
+// The default is 0.
+func DedupeErrors(window int) Option {
+	return func(p *parser) Option {
+		old := p.errWindow
+		p.errWindow = window
+		return DedupeErrors(old)
+	}
 }
 
-func (p *parser) in(s string) string {
-	p.depth++
-	return p.print(strings.Repeat(" ", p.depth) + ">", s)
+// SkipBOM creates an Option to set the skip BOM flag to b. When set to
+// true, a byte order mark (U+FEFF) at the start of the input is removed
+// before parsing, after the input is decoded if the Encoding option is
+// set.
+//
+// The default is false.
+func SkipBOM(b bool) Option {
+	return func(p *parser) Option {
+		old := p.skipBOM
+		p.skipBOM = b
+		return SkipBOM(old)
+	}
 }
 
-func (p *parser) out(s string) string {
-	p.depth--
-	return p.print(strings.Repeat(" ", p.depth) + "<", s)
+// NormalizeNewlines creates an Option to set the normalize newlines flag
+// to b. When set to true, the "\r\n" and "\r" line endings of the input
+// are converted to "\n" before parsing, after the input is decoded, so that
+// the grammar only has to match "\n". The offsets of the positions of the
+// errors, of the warnings and of the OnMatch and Events functions refer to
+// the input before the conversion, the positions of the matches in the
+// code blocks refer to the converted input.
+//
+// The default is false.
+func NormalizeNewlines(b bool) Option {
+	return func(p *parser) Option {
+		old := p.normalize
+		p.normalize = b
+		return NormalizeNewlines(old)
+	}
 }
 
-func (p *parser) addErr(err error) {
-	p.addErrAt(err, p.pt.position)
+// LatinFold creates an Option to fold the accented Latin letters of the
+// input with mode, "compose" or "decompose", before parsing, after it is
+// decoded. With "compose", an ASCII letter followed by a combining mark,
+// e.g. "e\u0301", is replaced by the precomposed letter, e.g. 'é', so that
+// a grammar written with precomposed letters matches it, and "decompose"
+// does the reverse. This is not a Unicode normalization: only the letters
+// of the Latin-1 Supplement and Latin Extended-A blocks that decompose to
+// an ASCII letter and a single combining mark are folded, and the other
+// sequences, e.g. of several marks, are kept as they are. The positions
+// and the text of the matches refer to the folded input. An unknown mode
+// is reported as an error of the parse.
+//
+// The default is "", the input is not folded.
+func LatinFold(mode string) Option {
+	return func(p *parser) Option {
+		old := p.latinFold
+		p.latinFold = mode
+		return LatinFold(old)
+	}
 }
 
-func (p *parser) addErrAt(err error, pos position) {
-	var buf bytes.Buffer
-	if p.filename != "" {
-		buf.WriteString(p.filename)
+// AssumeValidUTF8 creates an Option to set the assume valid UTF-8 flag to
+// b. When set to true, the input is trusted to be valid UTF-8: it is not
+// validated as it is read, and ASCII characters are decoded without a call
+// to the utf8 package, which is faster for ASCII-heavy input. The result of
+// the parse of an invalid input is undefined.
+//
+// The default is false.
+func AssumeValidUTF8(b bool) Option {
+	return func(p *parser) Option {
+		old := p.assumeValid
+		p.assumeValid = b
+		return AssumeValidUTF8(old)
 	}
-	if buf.Len() > 0 {
-		buf.WriteString(":")
+}
+
+// Encoding creates an Option to set the encoding of the input to enc, one
+// of "utf-8", "utf-16le", "utf-16be", "utf-16" (big endian unless the input
+// starts with a little endian byte order mark) and "latin1" (ISO-8859-1).
+// The input is decoded to UTF-8 before parsing, so the positions and the
+// text of the matches refer to the decoded input. An unknown encoding is
+// reported as an error of the parse.
+//
+// The default is "utf-8", the input is not decoded.
+func Encoding(enc string) Option {
+	return func(p *parser) Option {
+		old := p.encoding
+		p.encoding = enc
+		return Encoding(old)
 	}
-	buf.WriteString(fmt.Sprintf("%%d:%%d (%%d)", pos.line, pos.col, pos.offset))
-	if len(p.rstack) > 0 {
-		if buf.Len() > 0 {
-			buf.WriteString(": ")
-		}
-		rule := p.rstack[len(p.rstack)-1]
-		if rule.displayName != "" {
-			buf.WriteString("rule " + rule.displayName)
-		} else {
-			buf.WriteString("rule " + rule.name)
-		}
+}
+
+// Decoder creates an Option to set the function that decodes the input to
+// fn, for encodings that the Encoding option does not support. The input
+// is decoded to the runes returned by fn before parsing, and the Encoding
+// option is ignored. An error returned by fn is reported as an error of
+// the parse.
+//
+// The default is nil, the input is decoded according to the Encoding
+// option.
+func Decoder(fn func([]byte) ([]rune, error)) Option {
+	return func(p *parser) Option {
+		old := p.decoder
+		p.decoder = fn
+		return Decoder(old)
 	}
-	pe := &parserError{Inner: err, pos: pos, prefix: buf.String()}
-	p.errs.add(pe)
 }
 
-// read advances the parser to the next rune.
-func (p *parser) read() {
-	p.pt.offset += p.pt.w
-	rn, n := utf8.DecodeRune(p.data[p.pt.offset:])
-	p.pt.rn = rn
-	p.pt.w = n
-	p.pt.col++
-	if rn == '\n' {
-		p.pt.line++
-		p.pt.col = 0
+// Recover creates an Option to set the recover flag to b. When set to
+// true, this causes the parser to recover from panics and convert it
+// to an error. Setting it to false can be useful while debugging to
+// access the full stack trace. It has no effect in a parser generated with
+// the -no-panic flag, that does not recover from panics.
+//
+// The default is true.
+func Recover(b bool) Option {
+	return func(p *parser) Option {
+		old := p.recover
+		p.recover = b
+		return Recover(old)
 	}
+}
 
-	if rn == utf8.RuneError {
-		if n == 1 {
-			p.addErr(errInvalidEncoding)
-		}
+// KeepPartial creates an Option to set the keep partial flag to b. When
+// set to true and the parse fails, the Parse functions return the partial
+// result along with the error: the value of the longest match of a rule
+// other than the start rule that starts at the beginning of the input, the
+// outermost rule for matches of the same length, or nil if there is none.
+// E.g. an editor can still show the structure of an input that it is
+// typing.
+//
+// The default is false.
+func KeepPartial(b bool) Option {
+	return func(p *parser) Option {
+		old := p.keepPartial
+		p.keepPartial = b
+		return KeepPartial(old)
 	}
 }
 
-// restore parser position to the savepoint pt.
-func (p *parser) restore(pt savepoint) {
-	if p.debug {
-		defer p.out(p.in("restore"))
+// PanicContext creates an Option to set the panic context flag to b. When
+// set to true and the Recover option is false, a panic during the parse,
+// e.g. in a code block, is recovered and panics again with a *RulePanic
+// value that wraps the original value with the rule being parsed and the
+// position of the parser, to ease debugging. The stack trace of the new
+// panic still shows where the original panic happened. It has no effect
+// in a parser generated with the -no-panic flag.
+//
+// The default is false.
+func PanicContext(b bool) Option {
+	return func(p *parser) Option {
+		old := p.panicContext
+		p.panicContext = b
+		return PanicContext(old)
 	}
-	if pt.offset == p.pt.offset {
-		return
+}
+
+// ParseFile parses the file identified by filename.
+func ParseFile(filename string, opts ...Option) (interface{}, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
 	}
-	p.pt = pt
+	defer f.Close()
+	return ParseReader(filename, f, opts...)
 }
 
-// get the slice of bytes from the savepoint start to the current position.
-func (p *parser) sliceFrom(start savepoint) []byte {
-	return p.data[start.position.offset:p.pt.position.offset]
+// ParseReader parses the data from r using filename as information in the
+// error messages.
+func ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(filename, b, opts...)
 }
 
-func (p *parser) getMemoized(node interface{}) (resultTuple, bool) {
-	if len(p.memo) == 0 {
-		return resultTuple{}, false
+// Parse parses the data from b using filename as information in the
+// error messages.
+func Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+	return newParser(filename, b, opts...).parse(g)
+}
+
+// ParsePartial parses the data from b like Parse, and also returns the
+// remainder of b that follows the match of the start rule. The start rule
+// does not have to match all of b, unless it ends with a not predicate on
+// the any matcher (!.). The remainder is nil if there is an error, and it
+// is decoded to UTF-8 if the Encoding option is set.
+func ParsePartial(filename string, b []byte, opts ...Option) (interface{}, []byte, error) {
+	p := newParser(filename, b, opts...)
+	val, err := p.parse(g)
+	if err != nil {
+		return val, nil, err
+	}
+	return val, p.data[p.pt.offset:], nil
+}
+
+// Parser parses inputs with a set of options fixed at construction. It is
+// safe for concurrent use by multiple goroutines: the grammar is shared
+// read-only, and the state of a parse, including the memoization cache,
+// is confined to the call.
+type Parser struct {
+	opts []Option
+}
+
+// NewParser returns a Parser that applies the options opts to each parse.
+func NewParser(opts ...Option) *Parser {
+	return &Parser{opts: append([]Option(nil), opts...)}
+}
+
+// Parse parses the data from b like the package's Parse function. The
+// options opts are applied after those of the Parser.
+func (pr *Parser) Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+	all := make([]Option, 0, len(pr.opts)+len(opts))
+	all = append(append(all, pr.opts...), opts...)
+	return Parse(filename, b, all...)
+}
+
+// ParseReader reads all the data from r and parses it like Parse.
+func (pr *Parser) ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return pr.Parse(filename, b, opts...)
+}
+
+// Explain parses b with the options of the Parser, starting at the rule
+// named rule, and returns a human-readable account of the parse, e.g. for
+// teaching: a line for each expression that the parse evaluated, indented
+// by its nesting, with its position and the text that it matched or the
+// input at which it failed, and the numbers of the alternatives of the
+// choices, followed by the result of the parse. If the rule did not match,
+// the result has the expression that failed farthest in the input, the
+// last one evaluated at that offset, and the error of the parse. The
+// memoization is disabled, so that all the evaluations are in the account.
+func (pr *Parser) Explain(b []byte, rule string) string {
+	p := newParser("", b, pr.opts...)
+	p.entry = rule
+	p.explain = new(explainer)
+	p.memoize, p.memoCache = false, nil
+	_, err := p.parse(g)
+	return p.explain.format(p, rule, err)
+}
+
+// ReusableParser parses a sequence of inputs with a set of options fixed at
+// construction, and keeps the buffers allocated by a parse for the next
+// ones, e.g. the stacks of the parser and the memoization table, which
+// saves allocations when many inputs are parsed. Unlike Parser, it is not
+// safe for concurrent use.
+type ReusableParser struct {
+	p        *parser
+	opts     []Option
+	filename string
+	data     []byte
+	parsed   bool
+}
+
+// NewReusableParser returns a ReusableParser that applies the options opts
+// to each parse. Its input is empty until Reset is called.
+func NewReusableParser(opts ...Option) *ReusableParser {
+	return &ReusableParser{p: new(parser), opts: append([]Option(nil), opts...)}
+}
+
+// Reset sets the input of the parser to b, using filename as information
+// in the error messages, and clears the state of the previous parse.
+func (rp *ReusableParser) Reset(filename string, b []byte) {
+	rp.filename, rp.data = filename, b
+	rp.p.reset(filename, b, rp.opts)
+	rp.parsed = false
+}
+
+// Parse parses the input set by Reset like the package's Parse function.
+// Another call to Parse without a call to Reset parses the same input
+// again.
+func (rp *ReusableParser) Parse() (interface{}, error) {
+	if rp.parsed {
+		rp.p.reset(rp.filename, rp.data, rp.opts)
+	}
+	rp.parsed = true
+	return rp.p.parse(g)
+}
+
+// Token is a token of the input of ParseTokens, as produced by an external
+// lexer. The @token matchers of the grammar match the tokens by kind.
+type Token interface {
+	Kind() int
+	Text() string
+	Pos() TokenPos
+}
+
+// TokenPos is the position of a token in the input of the lexer.
+type TokenPos struct {
+	Line, Col, Offset int
+}
+
+// ParseTokens parses the tokens toks using filename as information in the
+// error messages. The positions in the error messages are those of the
+// tokens. Only the @token matchers and the predicates match the tokens,
+// the value of a @token matcher is the Token it matched and the text of a
+// match is the concatenation of the text of its tokens.
+func ParseTokens(filename string, toks []Token, opts ...Option) (interface{}, error) {
+	p := newParser(filename, nil, opts...)
+	p.tokMode = true
+	p.toks = toks
+	return p.parse(g)
+}
+
+// Pos is the position of a match reported to the OnMatch function.
+type Pos struct {
+	Line, Col, Offset int
+}
+
+// position records a position in the text.
+type position struct {
+	line, col, offset int
+}
+
+func (p position) String() string {
+	return fmt.Sprintf("%%d:%%d [%%d]", p.line, p.col, p.offset)
+}
+
+// savepoint stores all state required to go back to this point in the
+// parser.
+type savepoint struct {
+	position
+	rn rune
+	w  int
+
+	// indentation levels, nil at the top level
+	indents *indentLevel
+	// length of the ownership log
+	owned int
+	// length of the log of matches reported to OnMatch
+	matched int
+	// length of the log of warnings
+	warned int
+	// length of the log of the errors of the error productions
+	errored int
+	// length of the log of events
+	evented int
+	// length of the log of the spans matched by the labels of the @seen
+	// expressions
+	seen int
+}
+
+// indentLevel is an immutable stack of indentation widths, so that it is
+// restored along with the savepoint when the parser backtracks.
+type indentLevel struct {
+	width int
+	prev  *indentLevel
+}
+
+type %[2]s struct {
+	pos  position // start position of the match
+	end  position // end position of the match
+	text []byte   // raw text of the match
+
+	// parser of the match, for the warn method
+	parser *parser
+	// state of the @state block of the grammar, shared by the code blocks
+	// of the parse
+	state *parseState
+%[1]s}
+
+// warn records a warning with the message msg at the start position of the
+// current match. The warnings of the successful parse are returned with the
+// Warnings option, the warnings of the matches that were backtracked over
+// are dropped.
+func (cur *%[2]s) warn(msg string) {
+	p := cur.parser
+	w := Warning{Pos: p.exportPos(cur.pos), Msg: msg}
+	p.warnLog = append(p.warnLog[:p.pt.warned], w)
+	p.pt.warned = len(p.warnLog)
+}
+
+// parseRule parses text starting at the rule name, like the ParseX
+// function of an entrypoint, e.g. to parse the contents of a string
+// captured by the current match with another rule. The sub-parse has the
+// flags, the keywords, the word list, the tables and the skip function of
+// the current parse, the remaining depth of the MaxDepth option and the
+// limit of the MaxRepeat option, but not its other options. The positions
+// in its errors are relative to text.
+func (cur *%[2]s) parseRule(name, text string) (interface{}, error) {
+	p := cur.parser
+	sub := newParser(p.filename, []byte(text))
+	sub.entry = name
+	sub.debug = p.debug
+	sub.logger = p.logger
+	sub.recover = p.recover
+	sub.cur.state = p.cur.state
+	sub.flags = p.flags
+	sub.keywords = p.keywords
+	sub.wordList = p.wordList
+	sub.wordTrie = p.wordTrie
+	sub.tables = p.tables
+	sub.classTables = p.classTables
+	sub.skipFunc = p.skipFunc
+	sub.lineComment = p.lineComment
+	sub.maxRepeat = p.maxRepeat
+	if p.maxDepth > 0 {
+		sub.maxDepth = p.maxDepth - len(p.rstack)
+		if sub.maxDepth <= 0 {
+			p.abort(errMaxDepth)
+			return nil, errMaxDepth
+		}
+	}
+	return sub.parse(p.grammar)
+}
+
+// posAt returns the position of the byte offset offset of the input, e.g.
+// to report an error at an offset computed by the code block. Its line is
+// found by a binary search of the starts of the lines of the input, that
+// are computed once per parse.
+func (cur *%[2]s) posAt(offset int) Pos {
+	return cur.parser.offsetPos(offset)
+}
+
+// span returns the start and end positions of the match of the label in
+// the code block. The positions are only recorded if the parser is
+// generated with the -capture-spans flag, they are the zero Pos otherwise,
+// as for a label that is not in scope.
+func (cur *%[2]s) span(label string) (start, end Pos) {
+	p := cur.parser
+	sp, ok := p.vstack[len(p.vstack)-1]["@"+label].([2]position)
+	if !ok {
+		return Pos{}, Pos{}
+	}
+	return p.exportPos(sp[0]), p.exportPos(sp[1])
+}
+
+// error returns an error with the message msg at the start position of the
+// current match, for the error productions of the grammar. An action code
+// block that returns it matches with the value returned with it, so that
+// the parse continues, and the error is returned by the parse along with
+// its value. The errors of the matches that were backtracked over are
+// dropped.
+func (cur *%[2]s) error(msg string) error {
+	p := cur.parser
+	var rule *rule
+	if len(p.rstack) > 0 {
+		rule = p.rstack[len(p.rstack)-1]
+	}
+	return &productionError{msg: msg, pos: cur.pos, rule: rule}
+}
+
+// productionError is an error returned by c.error, recorded in the log of
+// the errors of the error productions.
+type productionError struct {
+	msg  string
+	pos  position
+	rule *rule
+}
+
+func (e *productionError) Error() string {
+	return e.msg
+}
+
+// Stats holds the statistics of a parse, recorded with the Statistics
+// option.
+type Stats struct {
+	// Rules has the counts of each rule, in the order of the grammar.
+	Rules []RuleStats
+
+	// PeakRuleStack is the maximum number of nested rules, PeakExprStack
+	// the maximum number of nested expressions, including those of the
+	// rules, and PeakValueStack the maximum number of variable sets of
+	// the labels, pushed for each rule and each alternative of a choice.
+	PeakRuleStack  int
+	PeakExprStack  int
+	PeakValueStack int
+}
+
+// Add adds the counts of the rules of o to those of s, and keeps the
+// maximum of the peaks, so that s accumulates the statistics of several
+// parses of the same grammar.
+func (s *Stats) Add(o Stats) {
+	if len(s.Rules) == 0 {
+		s.Rules = make([]RuleStats, len(o.Rules))
+		for i, r := range o.Rules {
+			s.Rules[i].Name = r.Name
+		}
+	}
+	for i, r := range o.Rules {
+		if i < len(s.Rules) {
+			s.Rules[i].Success += r.Success
+			s.Rules[i].Fail += r.Fail
+		}
+	}
+	if o.PeakRuleStack > s.PeakRuleStack {
+		s.PeakRuleStack = o.PeakRuleStack
+	}
+	if o.PeakExprStack > s.PeakExprStack {
+		s.PeakExprStack = o.PeakExprStack
+	}
+	if o.PeakValueStack > s.PeakValueStack {
+		s.PeakValueStack = o.PeakValueStack
+	}
+}
+
+// Prometheus returns the statistics in the Prometheus text exposition
+// format, for scraping: the counts of the rules are the counters
+// pigeon_rule_success_total and pigeon_rule_fail_total with a rule label,
+// the peaks of the stacks are the gauges pigeon_peak_rule_stack,
+// pigeon_peak_expr_stack and pigeon_peak_value_stack.
+func (s *Stats) Prometheus() string {
+	var buf bytes.Buffer
+	for _, counter := range []struct {
+		name, help string
+		count      func(RuleStats) int
+	}{
+		{"pigeon_rule_success_total", "Number of matches of the rule.", func(r RuleStats) int { return r.Success }},
+		{"pigeon_rule_fail_total", "Number of failed matches of the rule.", func(r RuleStats) int { return r.Fail }},
+	} {
+		fmt.Fprintf(&buf, "# HELP %%s %%s\n# TYPE %%s counter\n", counter.name, counter.help, counter.name)
+		for _, r := range s.Rules {
+			fmt.Fprintf(&buf, "%%s{rule=%%q} %%d\n", counter.name, r.Name, counter.count(r))
+		}
+	}
+	for _, gauge := range []struct {
+		name, help string
+		val        int
+	}{
+		{"pigeon_peak_rule_stack", "Maximum number of nested rules.", s.PeakRuleStack},
+		{"pigeon_peak_expr_stack", "Maximum number of nested expressions.", s.PeakExprStack},
+		{"pigeon_peak_value_stack", "Maximum number of variable sets of the labels.", s.PeakValueStack},
+	} {
+		fmt.Fprintf(&buf, "# HELP %%s %%s\n# TYPE %%s gauge\n%%s %%d\n", gauge.name, gauge.help, gauge.name, gauge.name, gauge.val)
+	}
+	return buf.String()
+}
+
+// RuleStats holds the number of times the rule Name matched and failed to
+// match.
+type RuleStats struct {
+	Name    string
+	Success int
+	Fail    int
+}
+
+// Ambiguity is an ordered choice of a rule at which several alternatives
+// match at the same position, returned with the Ambiguities option. Alts
+// are the numbers of the alternatives that match, starting at 1.
+type Ambiguity struct {
+	Rule string
+	Pos  Pos
+	Alts []int
+}
+
+// String returns the ambiguity formatted as its position, its rule and the
+// alternatives that match.
+func (a Ambiguity) String() string {
+	return fmt.Sprintf("%%d:%%d (%%d): rule %%s: alternatives %%v match", a.Pos.Line, a.Pos.Col, a.Pos.Offset, a.Rule, a.Alts)
+}
+
+// Branch is the value of an iteration of a choice repeated by "*" or "+"
+// when the parser is generated with the -tag-branches flag. Index is the
+// 0-based index of the alternative that matched, Name the name of the rule
+// that it references, empty if it is not a rule reference, and Value its
+// value.
+type Branch struct {
+	Index int
+	Name  string
+	Value interface{}
+}
+
+// RulePanic is the value of a panic raised again with the PanicContext
+// option. Value is the value of the original panic, Rule the name of the
+// rule being parsed, empty if there is none, and Pos the position of the
+// parser when it panicked.
+type RulePanic struct {
+	Rule  string
+	Pos   Pos
+	Value interface{}
+}
+
+// Error returns the position, the rule and the value of the panic.
+func (e *RulePanic) Error() string {
+	return fmt.Sprintf("%%d:%%d (%%d): rule %%s: panic: %%v", e.Pos.Line, e.Pos.Col, e.Pos.Offset, e.Rule, e.Value)
+}
+
+// Prefix is a prefix of the input matched by the parse, returned with the
+// LongestPrefix option. End is the position in the input that follows
+// it.
+type Prefix struct {
+	Text string
+	End  Pos
+}
+
+// Warning is a warning recorded by a code block of the grammar, returned
+// with the Warnings option.
+type Warning struct {
+	Pos Pos
+	Msg string
+}
+
+// String returns the warning formatted as its position and its message.
+func (w Warning) String() string {
+	return fmt.Sprintf("%%d:%%d (%%d): %%s", w.Pos.Line, w.Pos.Col, w.Pos.Offset, w.Msg)
+}
+
+// EventKind is the kind of an event reported to the Events function.
+type EventKind int
+
+// The kinds of events.
+const (
+	// EventStart is the start of the match of a rule.
+	EventStart EventKind = iota
+	// EventEnd is the end of the match of a rule.
+	EventEnd
+	// EventText is the text matched by a matcher of a rule.
+	EventText
+)
+
+var eventKindNames = [...]string{
+	EventStart: "start",
+	EventEnd:   "end",
+	EventText:  "text",
+}
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	if k >= 0 && int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return fmt.Sprintf("EventKind(%%d)", int(k))
+}
+
+// Event is an event of the parse reported to the Events function. Pos is
+// the position of the event, the start or the end of the match of the rule
+// named Rule, or the start of the text. Text is the matched text of an
+// EventText, and is empty for the other kinds.
+type Event struct {
+	Kind EventKind
+	Rule string
+	Pos  Pos
+	Text string
+}
+
+// the AST types...
+
+type grammar struct {
+	pos   position
+	rules []*rule
+	// name of the skip rule, if any
+	skip string
+	// some rules are marked with @nlsignificant
+	nlSignificant bool
+	// what is memoized with the Memoize option, set by the -memo-level
+	// flag
+	memoLevel int
+}
+
+// levels of the memoization of a grammar.
+const (
+	// the rules and their expressions are memoized
+	memoExprs = iota
+	// only the rules are memoized
+	memoRules
+	// nothing is memoized
+	memoNone
+)
+
+type rule struct {
+	pos         position
+	name        string
+	displayName string
+	lexical     bool
+	// the matchers of the rule are not in the expected set of the errors
+	silent bool
+	// the skip rule does not match the newlines in the rule
+	nlSignificant bool
+	budget int
+	// the @type of the rule and the function that checks that a value is
+	// of that type, for the StrictNodes option
+	typ    string
+	isType func(interface{}) bool
+	// the rule references itself at the end of some alternatives, it is
+	// parsed as a loop
+	tail bool
+	expr interface{}
+}
+
+type choiceExpr struct {
+	pos          position
+	alternatives []interface{}
+	// whether the longest match of the alternatives wins, for @longest
+	longest bool
+	// names of the rules referenced by the alternatives, only set if the
+	// value of the choice is a Branch
+	branches []string
+}
+
+type actionExpr struct {
+	pos    position
+	expr   interface{}
+	run    func(*parser) (interface{}, error)
+}
+
+type seqExpr struct {
+	pos   position
+	exprs []interface{}
+}
+
+type labeledExpr struct {
+	pos     position
+	label   string
+	capture bool
+	seen    bool
+	span    bool
+	expr    interface{}
+}
+
+type backRefExpr struct {
+	pos   position
+	label string
+}
+
+type seenExpr struct {
+	pos   position
+	label string
+}
+
+type expr struct {
+	pos  position
+	expr interface{}
+}
+
+type andExpr expr
+type notExpr expr
+type peekExpr expr
+
+type zeroOrOneExpr struct {
+	pos  position
+	expr interface{}
+	// default value of the expression when it does not match, nil for
+	// the nil value
+	dflt func(*parser) (interface{}, error)
+}
+
+type zeroOrMoreExpr struct {
+	pos   position
+	expr  interface{}
+	while func(*parser, []interface{}) (bool, error)
+}
+
+type oneOrMoreExpr struct {
+	pos   position
+	expr  interface{}
+	while func(*parser, []interface{}) (bool, error)
+}
+
+type ruleRefExpr struct {
+	pos  position
+	name string
+}
+
+type operatorsExpr struct {
+	pos     position
+	operand interface{}
+	ops     []*binaryOp
+}
+
+type unreservedExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type compactExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type convertExpr struct {
+	pos  position
+	name string
+	expr interface{}
+}
+
+type trimExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type arrayExpr struct {
+	pos     position
+	typ     string
+	collect func(*parser, *arrayExpr) (interface{}, interface{}, bool)
+	expr    interface{}
+}
+
+type mapExpr struct {
+	pos  position
+	key  string
+	val  string
+	last bool
+	expr interface{}
+}
+
+type sepExpr struct {
+	pos        position
+	expr       interface{}
+	sep        interface{}
+	trailing   bool
+	terminated bool
+	keep       bool
+}
+
+type foldExpr struct {
+	pos   position
+	expr  interface{}
+	right bool
+}
+
+type whenExpr struct {
+	pos  position
+	flag string
+	expr interface{}
+}
+
+type binaryOp struct {
+	lit        *litMatcher
+	prec       int
+	rightAssoc bool
+}
+
+type andCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+type notCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+type litMatcher struct {
+	pos        position
+	val        string
+	ignoreCase bool
+}
+
+// litSetMatcher matches the first of its literals that matches, in a
+// single pass over the input. It replaces a choice of literals, or a
+// sequence of literals that ends with a choice of literals, in which case
+// parts has the number of runes of each expression of the sequence for
+// each literal, so that the value is that of the sequence.
+type litSetMatcher struct {
+	pos   position
+	alts  []*litMatcher
+	parts [][]int
+}
+
+type charClassMatcher struct {
+	pos        position
+	val        string
+	chars      []rune
+	ranges     []rune
+	classes    []*unicode.RangeTable
+	classNames []string
+	ignoreCase bool
+	inverted   bool
+}
+
+type anyMatcher position
+
+type lookbehindExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type untilMatcher struct {
+	pos position
+	val string
+}
+
+type nestedMatcher struct {
+	pos   position
+	open  string
+	close string
+}
+
+type keywordMatcher position
+
+type wordListMatcher position
+
+type tableMatcher struct {
+	pos  position
+	name string
+}
+
+// wordNode is a node of the trie of the words of the WordList option,
+// with word set if the bytes that lead to it form one of the words.
+type wordNode struct {
+	next map[byte]*wordNode
+	word bool
+}
+
+// newWordNode returns the root of the trie of words, nil if there is no
+// word.
+func newWordNode(words []string) *wordNode {
+	var root *wordNode
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if root == nil {
+			root = &wordNode{}
+		}
+		n := root
+		for i := 0; i < len(word); i++ {
+			if n.next == nil {
+				n.next = make(map[byte]*wordNode)
+			}
+			next := n.next[word[i]]
+			if next == nil {
+				next = &wordNode{}
+				n.next[word[i]] = next
+			}
+			n = next
+		}
+		n.word = true
+	}
+	return root
+}
+
+type restOfLineMatcher position
+
+type numberMatcher struct {
+	pos       position
+	float     bool
+	sign      bool
+	prefix    bool
+	radix     int
+	thousands rune
+	decimal   rune
+}
+
+type skipExpr struct {
+	pos  position
+	skip interface{}
+	expr interface{}
+}
+
+type tokenMatcher struct {
+	pos  position
+	kind int
+	name string
+	any  bool
+}
+
+type byteMatcher struct {
+	pos position
+	val byte
+}
+
+type bytesMatcher struct {
+	pos   position
+	n     int
+	label string
+}
+
+type indentMatcher struct {
+	pos position
+	val string
+}
+
+// bigEndianUint returns the unsigned integer encoded in big-endian order in
+// b, which is at most 8 bytes long. It can be used in the code blocks of
+// grammars for binary formats.
+func bigEndianUint(b []byte) uint64 {
+	var n uint64
+	for _, by := range b {
+		n = n<<8 | uint64(by)
+	}
+	return n
+}
+
+// littleEndianUint returns the unsigned integer encoded in little-endian
+// order in b, which is at most 8 bytes long. It can be used in the code
+// blocks of grammars for binary formats.
+func littleEndianUint(b []byte) uint64 {
+	var n uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		n = n<<8 | uint64(b[i])
+	}
+	return n
+}
+
+// errList cumulates the errors found by the parser.
+type errList []error
+
+func (e *errList) add(err error) {
+	*e = append(*e, err)
+}
+
+func (e errList) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	e.dedupe()
+	return e
+}
+
+func (e *errList) dedupe() {
+	var cleaned []error
+	set := make(map[string]bool)
+	for _, err := range *e {
+		if msg := err.Error(); !set[msg] {
+			set[msg] = true
+			cleaned = append(cleaned, err)
+		}
+	}
+	*e = cleaned
+}
+
+func (e errList) Error() string {
+	switch len(e) {
+	case 0:
+		return ""
+	case 1:
+		return e[0].Error()
+	default:
+		var buf bytes.Buffer
+
+		if e.isJSON() {
+			buf.WriteRune('[')
+			for i, err := range e {
+				if i > 0 {
+					buf.WriteRune(',')
+				}
+				buf.WriteString(err.Error())
+			}
+			buf.WriteRune(']')
+			return buf.String()
+		}
+		for i, err := range e {
+			if i > 0 {
+				buf.WriteRune('\n')
+			}
+			buf.WriteString(err.Error())
+		}
+		return buf.String()
+	}
+}
+
+// isJSON returns true if the errors are formatted as JSON objects, as
+// set by the JSONErrors option.
+func (e errList) isJSON() bool {
+	for _, err := range e {
+		if pe, ok := err.(*parserError); !ok || !pe.json {
+			return false
+		}
+	}
+	return true
+}
+
+// parserError wraps an error with a prefix indicating the rule in which
+// the error occurred. The original error is stored in the Inner field.
+type parserError struct {
+	Inner   error
+	pos     position
+	prefix  string
+	context string
+	rule    string
+	json    bool
+	// prefix of the rule, compared by the DedupeErrors option
+	rulePrefix string
+}
+
+// Error returns the error message.
+func (p *parserError) Error() string {
+	if p.json {
+		return p.jsonError()
+	}
+	return p.prefix + ": " + p.Inner.Error() + p.context
+}
+
+// jsonErrorObject is the JSON object of an error set by the JSONErrors
+// option.
+type jsonErrorObject struct {
+	Offset   int      ` + "`json:\"offset\"`" + `
+	Line     int      ` + "`json:\"line\"`" + `
+	Col      int      ` + "`json:\"col\"`" + `
+	Rule     string   ` + "`json:\"rule,omitempty\"`" + `
+	Message  string   ` + "`json:\"message\"`" + `
+	Found    *string  ` + "`json:\"found,omitempty\"`" + `
+	Expected []string ` + "`json:\"expected\"`" + `
+}
+
+// jsonError returns the error as a JSON object.
+func (p *parserError) jsonError() string {
+	obj := jsonErrorObject{
+		Offset:   p.pos.offset,
+		Line:     p.pos.line,
+		Col:      p.pos.col,
+		Rule:     p.rule,
+		Message:  p.Inner.Error(),
+		Expected: []string{},
+	}
+	switch e := p.Inner.(type) {
+	case *UnexpectedToken:
+		obj.Found = &e.Found
+		obj.Expected = e.Expected
+	case *UnexpectedEOF:
+		obj.Found = &e.found
+		obj.Expected = e.Expected
+	}
+	b, _ := json.Marshal(obj)
+	return string(b)
+}
+
+// Unwrap returns the errors of the list, so that errors.Is and errors.As
+// find the errors of the parse.
+func (e errList) Unwrap() []error {
+	return e
+}
+
+// Unwrap returns the original error.
+func (p *parserError) Unwrap() error {
+	return p.Inner
+}
+
+// UnexpectedToken is the syntax error of a parse that fails before the end
+// of the input, with the input Found at the farthest position that the
+// parser reached and the matchers Expected there. It is the Inner error of
+// the *parserError.
+type UnexpectedToken struct {
+	Found    string
+	Expected []string
+}
+
+// Error returns the error message, with up to 5 expected matchers.
+func (e *UnexpectedToken) Error() string {
+	return syntaxErrorMessage(e.Found, e.Expected)
+}
+
+// UnexpectedEOF is the syntax error of a parse that fails at the end of the
+// input, with the matchers Expected there. It is the Inner error of the
+// *parserError.
+type UnexpectedEOF struct {
+	Expected []string
+	// text of the message at the end of the input
+	found string
+}
+
+// Error returns the error message, with up to 5 expected matchers.
+func (e *UnexpectedEOF) Error() string {
+	return syntaxErrorMessage(e.found, e.Expected)
+}
+
+func syntaxErrorMessage(found string, expected []string) string {
+	msg := "'" + expected[0] + "'"
+	for i := 1; i < len(expected) && i < 5; i++ {
+		msg += ", '" + expected[i] + "'"
+	}
+	if len(expected) > 5 {
+		msg += fmt.Sprintf(", and %%d others", len(expected) - 5)
+	}
+	return fmt.Sprintf("syntax error, unexpected '%%s', expecting %%s", found, msg)
+}
+
+// MaxDepthExceeded is the error of a parse whose rules are nested deeper
+// than the limit set by the MaxDepth option.
+type MaxDepthExceeded struct{}
+
+func (*MaxDepthExceeded) Error() string { return "max depth exceeded" }
+
+// MaxRepeatExceeded is the error of a parse where a repetition matches more
+// times than the limit set by the MaxRepeat option.
+type MaxRepeatExceeded struct{}
+
+func (*MaxRepeatExceeded) Error() string { return "max repeat exceeded" }
+
+// InputTooLarge is the error of a parse whose input exceeds the limit set
+// by the MaxInputRunes option.
+type InputTooLarge struct{}
+
+func (*InputTooLarge) Error() string { return "input too large" }
+
+// TrailingInput is the error of a parse whose start rule does not match the
+// whole input, with the RequireTrailingEOF option.
+type TrailingInput struct{}
+
+func (*TrailingInput) Error() string { return "unexpected input after the start rule" }
+
+// newParser creates a parser with the specified input source and options.
+func newParser(filename string, b []byte, opts ...Option) *parser {
+	p := new(parser)
+	p.init(filename, b, opts)
+	return p
+}
+
+// init sets the parser to a new parser with the specified input source and
+// options.
+func (p *parser) init(filename string, b []byte, opts []Option) {
+	*p = parser{
+		filename: filename,
+		errs: new(errList),
+		data: b,
+		pt: savepoint{position: position{line: 1}},
+		recover: true,
+		contextLines: -1,
+	}
+	p.cur.parser = p
+	p.setOptions(defaultOptions)
+	p.setOptions(opts)
+}
+
+// reset sets the parser to a new parser with the specified input source
+// and options, and keeps the stacks, the rules table and the memoization
+// table of the previous parse.
+func (p *parser) reset(filename string, b []byte, opts []Option) {
+	vstack, rstack, rules := p.vstack[:0], p.rstack[:0], p.rules
+	memo, _ := p.memoStore.(memoTable)
+	p.init(filename, b, opts)
+	p.vstack, p.rstack, p.rules = vstack, rstack, rules
+	if memo != nil && p.memoStore == nil {
+		memo.reset()
+		p.memoStore = memo
+	}
+}
+
+// setOptions applies the options to the parser.
+func (p *parser) setOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+type resultTuple struct {
+	v interface{}
+	b bool
+	end savepoint
+}
+
+// ownEntry records the number of runes owned by a rule in a match. The
+// cum field is the total of runes owned by all entries up to this one.
+type ownEntry struct {
+	rule  *rule
+	runes int
+	cum   int
+}
+
+// matchEntry records a match of a rule, reported to OnMatch.
+type matchEntry struct {
+	rule       *rule
+	start, end position
+	val        interface{}
+}
+
+// seenEntry is a span of the input matched by a label of the @seen
+// expressions.
+type seenEntry struct {
+	label      string
+	start, end int
+}
+
+// seenKey indexes the log of the @seen spans by label, length and hash.
+type seenKey struct {
+	label string
+	n     int
+	hash  uint64
+}
+
+// hashBase is the base of the polynomial rolling hash, the arithmetic is
+// modulo 2^64.
+const hashBase = 1000003
+
+// rollingHash holds the hashes of the prefixes of the input, so that the
+// hash of any span of the input is computed in constant time.
+type rollingHash struct {
+	prefix []uint64
+	pow    []uint64
+}
+
+func newRollingHash(data []byte) *rollingHash {
+	h := &rollingHash{prefix: make([]uint64, len(data)+1), pow: make([]uint64, len(data)+1)}
+	h.pow[0] = 1
+	for i, b := range data {
+		h.prefix[i+1] = h.prefix[i]*hashBase + uint64(b)
+		h.pow[i+1] = h.pow[i] * hashBase
+	}
+	return h
+}
+
+// span returns the hash of the input from start to end.
+func (h *rollingHash) span(start, end int) uint64 {
+	return h.prefix[end] - h.prefix[start]*h.pow[end-start]
+}
+
+type backtrackKey struct {
+	rule   *rule
+	offset int
+}
+
+type parser struct {
+	filename string
+	pt       savepoint
+	cur      %[2]s
+
+	// errors
+	maxSavePoint savepoint
+	maxFound string
+	maxExpected []string
+	
+	data []byte
+	errs *errList
+
+	// number of lines of input in the error messages, -1 for none
+	contextLines int
+
+	// whether the errors are prefixed with the path of the rules, and the
+	// rule stack at maxSavePoint if they are
+	rulePath bool
+	maxRules []*rule
+
+	// whether the errors are formatted as JSON objects
+	jsonErrors bool
+	// window in bytes of the DedupeErrors option
+	errWindow int
+
+	// encoding of data, decoded before parsing, or the function that
+	// decodes it, and whether a leading byte order mark is removed
+	encoding string
+	decoder  func([]byte) ([]rune, error)
+	skipBOM  bool
+
+	// whether the line endings are converted to "\n", and the offsets in
+	// the converted input of the "\n" that replaced a "\r\n"
+	normalize bool
+	crlfs     []int
+	// mode of the folding of the accented Latin letters of the input
+	latinFold string
+	// offsets of the starts of the lines of the input, computed the first
+	// time that a position is computed from an offset
+	lineStarts []int
+
+	// whether the input is trusted to be valid UTF-8
+	assumeValid bool
+
+	recover bool
+	// whether a panic is raised again with its context if recover is
+	// false
+	panicContext bool
+	// whether the parse was stopped by an error, e.g. a limit of the
+	// options, that is in the errors
+	aborted bool
+	// whether the partial result is returned if the parse fails, and the
+	// value and end offset of the longest match at the start of the input
+	keepPartial bool
+	partial     interface{}
+	partialEnd  int
+	debug  bool
+	depth  int
+	logger Logger
+	tracer Tracer
+
+	memoize bool
+	// the expressions are memoized too, depending on the memoization
+	// level of the grammar
+	memoizeExprs bool
+	// memoization table for the packrat algorithm, set by WithMemoStore
+	// or a memoTable
+	memoStore MemoStore
+	// cache of the ReuseMemo option, version of the input set by
+	// MemoVersion, and end of the input examined by the current node
+	memoCache   *MemoCache
+	memoVersion interface{}
+	reach       int
+
+	// number of runes owned by rule, and the log of matches
+	owned  map[string]int
+	ownLog []ownEntry
+
+	// function called for the matches of the rules, and the log of matches
+	onMatch  func(string, Pos, Pos, interface{})
+	matchLog []matchEntry
+
+	// function called for the events of the parse, and the log of events
+	events   func(Event)
+	eventLog []Event
+
+	// destination of the longest prefix matched by the parse, and the
+	// farthest end of a match of an expression
+	prefix    *Prefix
+	prefixEnd position
+	// destination of the warnings, and the log of warnings
+	warnings *[]Warning
+	warnLog  []Warning
+	// log of the errors of the error productions
+	errLog []*productionError
+	// log of the spans matched by the labels of the @seen expressions,
+	// indexed by hash, the lengths logged for each label, in increasing
+	// order, and the hashes of the input
+	seenLog   []seenEntry
+	seenIndex map[seenKey][]int
+	seenLens  map[string][]int
+	hashes    *rollingHash
+	// prefixes of the errors raised in each rule, "rule NAME"
+	rulePrefixes map[*rule]string
+
+	// destination of the trace of the evaluated expressions
+	trace *[]string
+	// evaluations of the parse of the Explain method
+	explain *explainer
+
+	// words matched by the keyword matcher
+	keywords []string
+
+	// function that decides the runes skipped instead of the skip rule
+	skipFunc func(rune) bool
+	// prefix of the line comments skipped with the whitespace
+	lineComment string
+	// skip the whitespace before the start rule, and require the end of
+	// the input after it
+	skipLeading bool
+	requireEOF  bool
+
+	// words matched by the word list matcher, and their trie
+	wordList []string
+	wordTrie *wordNode
+
+	// flags of the @when expressions that are set
+	flags map[string]bool
+	// Unicode range tables of the @table matchers, by name
+	tables map[string]*unicode.RangeTable
+	// converters of the convert expressions set by the Converter option,
+	// by name
+	converters map[string]func(string) (interface{}, error)
+	// Unicode range tables of the classes of the character classes, by
+	// class name, and the copies of the character classes that use them
+	classTables map[string]*unicode.RangeTable
+	classCopies map[*charClassMatcher]*charClassMatcher
+
+	// functions that transform the value of the rules, by rule name
+	transforms map[string]func(interface{}) (interface{}, error)
+	// whether the values of the rules are checked against their @type
+	strictNodes bool
+	// functions that receive the values of the repetitions of the rules
+	// instead of collecting them, by rule name
+	streams map[string]func(interface{}) error
+	// function that rewrites the text of the matches of the actions
+	normalizer func(string, string) string
+
+	// input tokens of ParseTokens, the offset of the position is the
+	// index of the current token in token mode
+	tokMode bool
+	toks    []Token
+
+	maxBacktrack int
+	// number of times each rule backtracked to an offset
+	backtracks map[backtrackKey]int
+
+	// maximum number of runes of the input, 0 for no limit
+	maxInputRunes int
+	// maximum number of nested rules, 0 for no limit
+	maxDepth int
+	// maximum number of matches of a repetition, 0 for no limit
+	maxRepeat int
+
+	// name of the start rule, the first rule of the grammar if empty
+	entry string
+
+	// grammar of the parse, for the sub-parses of the code blocks
+	grammar *grammar
+	// the innermost rule is marked with @nlsignificant, and the skip rule
+	// is parsed up to the next newline
+	nlSig      bool
+	inSkipLine bool
+	// rules table, maps the rule identifier to the rule node
+	rules  map[string]*rule
+	// variables stack, map of label to value
+	vstack []map[string]interface{}
+	// index in vstack of the variable set of the current rule
+	vbase int
+	// rule stack, allows identification of the current rule in errors
+	rstack []*rule
+	// initial capacity of vstack and rstack
+	stackCap int
+
+	// stats
+	exprCnt int
+	// the budgeted rule whose budget ends first, and the expression count
+	// at which it ends
+	budgetRule *rule
+	budgetEnd  int
+	// destination of the statistics of the rules, and the counts of each
+	// rule in it
+	stats     *Stats
+	ruleStats map[*rule]*RuleStats
+	// number of nested expressions, for the Statistics option
+	exprDepth int
+	// destination of the counts of the lexical rules
+	tokenCounts map[string]int
+
+	// destination of the ambiguities, and the choices already reported at
+	// each offset
+	ambiguities *[]Ambiguity
+	ambiguous   map[ambiguityKey]bool
+}
+
+// ambiguityKey identifies an ordered choice at an offset of the input.
+type ambiguityKey struct {
+	choice *choiceExpr
+	offset int
+}
+
+func (p *parser) setMaxSavePoint(current string, expected string) {
+	if p.pt.offset > p.maxSavePoint.offset {
+		p.maxFound = current
+		p.maxSavePoint = p.pt
+		p.maxExpected = []string{expected}
+		if p.rulePath {
+			p.maxRules = append([]*rule(nil), p.rstack...)
+		}
+	} else if p.pt.offset == p.maxSavePoint.offset {
+		for _, e := range p.maxExpected {
+			if e == expected {
+				return
+			}
+		}
+		p.maxExpected = append(p.maxExpected, expected)
+	}
+}
+
+// restoreMaxSavePoint restores the farthest failure of the parse after a
+// silent rule, so that its matchers are not in the expected set. The
+// capacity of the expected set is limited to its length, as the rule may
+// have appended to it in place.
+func (p *parser) restoreMaxSavePoint(pt savepoint, found string, expected []string, rules []*rule) {
+	p.maxSavePoint, p.maxFound = pt, found
+	p.maxExpected = expected[:len(expected):len(expected)]
+	p.maxRules = rules
+}
+
+// push a variable set on the vstack.
+func (p *parser) pushV() {
+	if cap(p.vstack) == len(p.vstack) {
+		// create new empty slot in the stack
+		p.vstack = append(p.vstack, nil)
+	} else {
+		// slice to 1 more
+		p.vstack = p.vstack[:len(p.vstack)+1]
+	}
+
+	// get the last args set
+	m := p.vstack[len(p.vstack)-1]
+	if m != nil && len(m) == 0 {
+		// empty map, all good
+		return
+	}
+
+	m = make(map[string]interface{})
+	p.vstack[len(p.vstack)-1] = m
+}
+
+// peakStacks records the depths of the stacks in the statistics if they
+// are the deepest so far.
+func (p *parser) peakStacks() {
+	st := p.stats
+	if n := len(p.rstack); n > st.PeakRuleStack {
+		st.PeakRuleStack = n
+	}
+	if p.exprDepth > st.PeakExprStack {
+		st.PeakExprStack = p.exprDepth
+	}
+	if n := len(p.vstack); n > st.PeakValueStack {
+		st.PeakValueStack = n
+	}
+}
+
+// pop a variable set from the vstack.
+func (p *parser) popV() {
+	// if the map is not empty, clear it
+	m := p.vstack[len(p.vstack)-1]
+	if len(m) > 0 {
+		// GC that map
+		p.vstack[len(p.vstack)-1] = nil
+	}
+	p.vstack = p.vstack[:len(p.vstack)-1]
+}
+
+func (p *parser) print(prefix, s string) string {
+	if !p.debug {
+		return s
+	}
+
+	if p.logger != nil {
+		p.logger.Printf("%%s %%d:%%d:%%d: %%s [%%#U]",
+			prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+		return s
+	}
+	fmt.Printf("%%s %%d:%%d:%%d: %%s [%%#U]\n",
+		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+	return s
+}
+
+func (p *parser) traceExpr(s string) {
+	*p.trace = append(*p.trace, fmt.Sprintf("%%s %%d:%%d", s, p.pt.line, p.pt.col))
+}
+
+// explainStep is an expression evaluated by the parse of the Explain
+// method, at depth in the nesting of the evaluations, and its result.
+type explainStep struct {
+	depth      int
+	desc       string
+	start, end position
+	ok         bool
+	choice     bool
+	children   int
+}
+
+// explainer records the evaluations of the parse of the Explain method.
+type explainer struct {
+	steps []explainStep
+	// indexes of the steps being evaluated
+	open []int
+}
+
+// enter records the start of the evaluation of expr at pos, and returns
+// the index of its step.
+func (e *explainer) enter(expr interface{}, pos position) int {
+	st := explainStep{depth: len(e.open), desc: describeExpr(expr), start: pos}
+	_, st.choice = expr.(*choiceExpr)
+	if len(e.open) > 0 {
+		parent := &e.steps[e.open[len(e.open)-1]]
+		parent.children++
+		if parent.choice {
+			st.desc = fmt.Sprintf("alternative %%d: %%s", parent.children, st.desc)
+		}
+	}
+	e.steps = append(e.steps, st)
+	e.open = append(e.open, len(e.steps)-1)
+	return len(e.steps) - 1
+}
+
+// exit records the result of the evaluation of the step ix, that ended at
+// end.
+func (e *explainer) exit(ix int, ok bool, end position) {
+	e.steps[ix].ok, e.steps[ix].end = ok, end
+	e.open = e.open[:len(e.open)-1]
+}
+
+// format returns the account of the parse of rule that returned err.
+func (e *explainer) format(p *parser, rule string, err error) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "explain rule %%s:\n", rule)
+	farthest := -1
+	for i, st := range e.steps {
+		fmt.Fprintf(&buf, "%%s%%s %%d:%%d: ", strings.Repeat("  ", st.depth+1), st.desc, st.start.line, st.start.col)
+		switch {
+		case st.ok:
+			fmt.Fprintf(&buf, "matched %%q\n", p.data[st.start.offset:st.end.offset])
+		case st.children == 0:
+			// a matcher fails at the input that it does not match
+			fmt.Fprintf(&buf, "failed at %%s\n", p.inputAt(st.start.offset))
+		default:
+			buf.WriteString("failed\n")
+		}
+		if !st.ok && (farthest < 0 || st.start.offset >= e.steps[farthest].start.offset) {
+			farthest = i
+		}
+	}
+	if err != nil {
+		if farthest >= 0 {
+			st := e.steps[farthest]
+			fmt.Fprintf(&buf, "farthest failure: %%s %%d:%%d, at %%s\n", st.desc, st.start.line, st.start.col, p.inputAt(st.start.offset))
+		}
+		fmt.Fprintf(&buf, "rule %%s failed: %%v\n", rule, err)
+	} else {
+		fmt.Fprintf(&buf, "rule %%s matched %%q\n", rule, p.data[:p.pt.offset])
+	}
+	return buf.String()
+}
+
+// inputAt returns the rune of the input at offset as a quoted string, or
+// "end of input".
+func (p *parser) inputAt(offset int) string {
+	if offset >= len(p.data) {
+		return "end of input"
+	}
+	rn, _ := utf8.DecodeRune(p.data[offset:])
+	return fmt.Sprintf("%%q", string(rn))
+}
+
+// describeExpr returns the description of expr in the account of the
+// Explain method.
+func describeExpr(expr interface{}) string {
+	switch expr := expr.(type) {
+	case *actionExpr:
+		return "action"
+	case *andExpr:
+		return "and predicate"
+	case *peekExpr:
+		return "peek"
+	case *anyMatcher:
+		return "any character"
+	case *charClassMatcher:
+		return "class " + expr.val
+	case *choiceExpr:
+		return "choice"
+	case *labeledExpr:
+		if expr.label != "" {
+			return "label " + expr.label
+		}
+	case *litMatcher:
+		if expr.ignoreCase {
+			return fmt.Sprintf("literal %%qi", expr.val)
+		}
+		return fmt.Sprintf("literal %%q", expr.val)
+	case *notExpr:
+		return "not predicate"
+	case *oneOrMoreExpr:
+		return "one or more"
+	case *ruleRefExpr:
+		return "rule " + expr.name
+	case *seqExpr:
+		return "sequence"
+	case *zeroOrMoreExpr:
+		return "zero or more"
+	case *zeroOrOneExpr:
+		return "optional"
+	}
+	kind := fmt.Sprintf("%%T", expr)
+	return kind[strings.LastIndex(kind, ".")+1:]
+}
+
+func (p *parser) in(s string) string {
+	p.depth++
+	return p.print(strings.Repeat(" ", p.depth) + ">", s)
+}
+
+func (p *parser) out(s string) string {
+	p.depth--
+	return p.print(strings.Repeat(" ", p.depth) + "<", s)
+}
+
+func (p *parser) addErr(err error) {
+	p.addErrAt(err, p.pt.position)
+}
+
+func (p *parser) addErrAt(err error, pos position) {
+	p.addStackErrAt(err, pos, p.rstack)
+}
+
+// addStackErrAt adds err at position pos to the list of errors, prefixed
+// with the name of the innermost rule of stack, or with the path of its
+// rules if the RulePath option is set.
+func (p *parser) addStackErrAt(err error, pos position, stack []*rule) {
+	if len(stack) == 0 {
+		p.addRuleErrAt(err, pos, nil)
+		return
+	}
+	if !p.rulePath {
+		p.addRuleErrAt(err, pos, stack[len(stack)-1])
+		return
+	}
+	names := make([]string, len(stack))
+	for i, r := range stack {
+		names[i] = r.name
+		if r.displayName != "" {
+			names[i] = r.displayName
+		}
+	}
+	p.addPrefixErrAt(err, pos, "rule "+strings.Join(names, " > "))
+}
+
+// addRuleErrAt adds err at position pos to the list of errors, prefixed
+// with the name of rule unless it is nil.
+func (p *parser) addRuleErrAt(err error, pos position, rule *rule) {
+	var prefix string
+	if rule != nil {
+		prefix = p.ruleErrPrefix(rule)
+	}
+	p.addPrefixErrAt(err, pos, prefix)
+}
+
+// addPrefixErrAt adds err at position pos to the list of errors, prefixed
+// with the position and with prefix unless it is empty.
+func (p *parser) addPrefixErrAt(err error, pos position, prefix string) {
+	var context string
+	if p.contextLines >= 0 && !p.tokMode {
+		context = p.errContext(pos.offset)
+	}
+	pos.offset = p.origOffset(pos.offset)
+
+	var buf bytes.Buffer
+	if p.filename != "" {
+		buf.WriteString(p.filename)
+	}
+	if buf.Len() > 0 {
+		buf.WriteString(":")
+	}
+	fmt.Fprintf(&buf, "%%d:%%d (%%d)", pos.line, pos.col, pos.offset)
+	if prefix != "" {
+		if buf.Len() > 0 {
+			buf.WriteString(": ")
+		}
+		buf.WriteString(prefix)
+	}
+	if p.errWindow > 0 && p.repeatedErr(err, pos, prefix) {
+		return
+	}
+	if p.jsonErrors {
+		names := strings.Split(strings.TrimPrefix(prefix, "rule "), " > ")
+		for i, nm := range names {
+			// the display names are quoted
+			var s string
+			if json.Unmarshal([]byte(nm), &s) == nil {
+				names[i] = s
+			}
+		}
+		p.errs.add(&parserError{Inner: err, pos: pos, rule: strings.Join(names, " > "), json: true, rulePrefix: prefix})
+		return
+	}
+	p.errs.add(&parserError{Inner: err, pos: pos, prefix: buf.String(), context: context, rulePrefix: prefix})
+}
+
+// repeatedErr returns true if err, at position pos in the rule of prefix,
+// repeats the last error of the list within the window of the
+// DedupeErrors option.
+func (p *parser) repeatedErr(err error, pos position, prefix string) bool {
+	if len(*p.errs) == 0 {
+		return false
+	}
+	last, ok := (*p.errs)[len(*p.errs)-1].(*parserError)
+	if !ok || last.rulePrefix != prefix || last.Inner.Error() != err.Error() {
+		return false
+	}
+	d := pos.offset - last.pos.offset
+	return d >= 0 && d <= p.errWindow
+}
+
+// ruleErrPrefix returns the prefix of the errors raised in rule r, with its
+// display name if it has one. It is formatted once per rule and parse, as
+// errors are raised repeatedly in the same rules when the parser
+// backtracks.
+func (p *parser) ruleErrPrefix(r *rule) string {
+	if s, ok := p.rulePrefixes[r]; ok {
+		return s
+	}
+	nm := r.name
+	if r.displayName != "" {
+		nm = r.displayName
+	}
+	if p.rulePrefixes == nil {
+		p.rulePrefixes = make(map[*rule]string)
+	}
+	s := "rule " + nm
+	p.rulePrefixes[r] = s
+	return s
+}
+
+// errContext returns the lines of the input around offset, as set by the
+// ContextLines option, with a caret under offset.
+func (p *parser) errContext(offset int) string {
+	if offset > len(p.data) {
+		offset = len(p.data)
+	}
+	ix := p.lineIndex(offset)
+	start, line := p.lineStarts[ix], ix+1
+
+	// the lines before the error line, and the error line and those after
+	first, n := start, 0
+	for first > 0 && n < p.contextLines {
+		first = bytes.LastIndexByte(p.data[:first-1], '\n') + 1
+		n++
+	}
+	end := start
+	for i := 0; i <= p.contextLines && end < len(p.data); i++ {
+		if ix := bytes.IndexByte(p.data[end:], '\n'); ix >= 0 {
+			end += ix + 1
+		} else {
+			end = len(p.data)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(string(p.data[first:end]), "\n"), "\n")
+	width := len(fmt.Sprint(line - n + len(lines) - 1))
+
+	var buf bytes.Buffer
+	for i, l := range lines {
+		fmt.Fprintf(&buf, "\n%%*d | %%s", width, line-n+i, l)
+		if i != n {
+			continue
+		}
+		// the caret is aligned with the same tabs as the error line
+		fmt.Fprintf(&buf, "\n%%*s | ", width, "")
+		for _, rn := range string(p.data[start:offset]) {
+			if rn == '\t' {
+				buf.WriteRune('\t')
+			} else {
+				buf.WriteRune(' ')
+			}
+		}
+		buf.WriteRune('^')
+	}
+	return buf.String()
+}
+
+// lineIndex returns the 0-based index of the line of the byte offset off
+// of the input. The offsets of the starts of the lines are computed the
+// first time it is called, so that the line of an offset is then found by
+// a binary search instead of counting the newlines that precede it.
+func (p *parser) lineIndex(off int) int {
+	if p.lineStarts == nil {
+		p.lineStarts = []int{0}
+		for i, b := range p.data {
+			if b == '\n' {
+				p.lineStarts = append(p.lineStarts, i+1)
+			}
+		}
+	}
+	return sort.SearchInts(p.lineStarts, off+1) - 1
+}
+
+// offsetPos returns the position of the byte offset off of the input, as
+// the parser would report it once it got to that offset.
+func (p *parser) offsetPos(off int) Pos {
+	if off > len(p.data) {
+		off = len(p.data)
+	}
+	ix := p.lineIndex(off)
+	col := utf8.RuneCount(p.data[p.lineStarts[ix]:off]) + 1
+	return Pos{Line: ix + 1, Col: col, Offset: p.origOffset(off)}
+}
+
+// read advances the parser to the next rune.
+func (p *parser) read() {
+	if p.tokMode {
+		p.readToken()
+		return
+	}
+	p.pt.offset += p.pt.w
+	var rn rune
+	var n int
+	if p.assumeValid && p.pt.offset < len(p.data) && p.data[p.pt.offset] < utf8.RuneSelf {
+		rn, n = rune(p.data[p.pt.offset]), 1
+	} else {
+		rn, n = utf8.DecodeRune(p.data[p.pt.offset:])
+	}
+	p.pt.rn = rn
+	p.pt.w = n
+	p.pt.col++
+	if p.memoCache != nil {
+		p.examineRune()
+	}
+	if rn == '\n' {
+		p.pt.line++
+		p.pt.col = 0
+	}
+
+	if rn == utf8.RuneError && !p.assumeValid {
+		if n == 1 {
+			p.addErr(errInvalidEncoding)
+		}
+	}
+}
+
+// readToken advances the parser's position to the next token in token
+// mode. The current rune is never valid, so that the rune matchers don't
+// match.
+func (p *parser) readToken() {
+	p.pt.offset += p.pt.w
+	p.pt.rn = utf8.RuneError
+	p.pt.w = 0
+	if p.pt.offset < len(p.toks) {
+		p.pt.w = 1
+		pos := p.toks[p.pt.offset].Pos()
+		p.pt.line, p.pt.col = pos.Line, pos.Col
+	}
+}
+
+// skip advances the parser's position by n bytes, regardless of the
+// encoding of the input. Each byte counts as a column.
+func (p *parser) skip(n int) {
+	if n == 0 {
+		return
+	}
+	p.pt.offset += n - p.pt.w
+	p.pt.col += n - 1
+	p.read()
+}
+
+// atInvalidOrEOF returns true if the parser is at the end of the input or
+// at an invalid UTF-8 encoding. A valid U+FFFD replacement character in the
+// input is a regular character.
+func (p *parser) atInvalidOrEOF() bool {
+	return p.pt.rn == utf8.RuneError && p.pt.w <= 1
+}
+
+// restore parser position to the savepoint pt.
+func (p *parser) restore(pt savepoint) {
+	if p.debug {
+		defer p.out(p.in("restore"))
+	}
+	if pt.offset == p.pt.offset {
+		p.pt.indents = pt.indents
+		p.pt.owned = pt.owned
+		p.pt.matched = pt.matched
+		p.pt.warned = pt.warned
+		p.pt.errored = pt.errored
+		p.pt.evented = pt.evented
+		p.pt.seen = pt.seen
+		return
+	}
+	if p.maxBacktrack > 0 && pt.offset < p.pt.offset && len(p.rstack) > 0 {
+		p.countBacktrack(pt)
+	}
+	p.pt = pt
+}
+
+// abort stops the parse with the error err: the expressions fail from then
+// on, their results are not memoized, and the parse returns the errors.
+func (p *parser) abort(err error) {
+	p.addErr(err)
+	p.aborted = true
+	p.memoize = false
+	p.memoizeExprs = false
+}
+
+// countBacktrack records that the current rule backtracked to pt, and
+// aborts the parse if the rule exceeded the maximum number of backtracks to
+// this offset.
+func (p *parser) countBacktrack(pt savepoint) {
+	if p.backtracks == nil {
+		p.backtracks = make(map[backtrackKey]int)
+	}
+	key := backtrackKey{rule: p.rstack[len(p.rstack)-1], offset: pt.offset}
+	p.backtracks[key]++
+	if n := p.backtracks[key]; n > p.maxBacktrack {
+		p.abort(fmt.Errorf("backtracked %%d times to %%s, maximum is %%d", n, pt.position, p.maxBacktrack))
+	}
+}
+
+// get the slice of bytes from the savepoint start to the current position.
+func (p *parser) sliceFrom(start savepoint) []byte {
+	if p.tokMode {
+		var buf bytes.Buffer
+		for _, tok := range p.toks[start.position.offset:p.pt.position.offset] {
+			buf.WriteString(tok.Text())
+		}
+		return buf.Bytes()
+	}
+	return p.data[start.position.offset:p.pt.position.offset]
+}
+
+func (p *parser) getMemoized(node interface{}) (resultTuple, bool) {
+	if p.memoStore == nil {
+		return resultTuple{}, false
+	}
+	res, ok := p.memoStore.Get(node, p.pt.offset)
+	if ok && p.memoCache != nil {
+		p.examine(res.reach)
+	}
+	return res.tuple, ok
+}
+
+func (p *parser) setMemoized(pt savepoint, node interface{}, tuple resultTuple, reach int) {
+	if p.memoStore == nil {
+		p.memoStore = make(memoTable)
+	}
+	p.memoStore.Set(node, pt.offset, MemoResult{tuple: tuple, reach: reach})
+}
+
+// enterReach starts recording the end of the input examined by a node at
+// the current position for the ReuseMemo option, and returns the end
+// recorded for the enclosing nodes.
+func (p *parser) enterReach() int {
+	outer := p.reach
+	p.reach = 0
+	p.examineRune()
+	return outer
+}
+
+// exitReach returns the end of the input examined by the node started by
+// enterReach, and restores that of the enclosing nodes, which includes it.
+func (p *parser) exitReach(outer int) int {
+	reach := p.reach
+	p.examine(outer)
+	return reach
+}
+
+// examine records that the input is examined up to the offset end,
+// excluded, where the end of the input is at offset len(p.data)+1.
+func (p *parser) examine(end int) {
+	if end > len(p.data)+1 {
+		end = len(p.data) + 1
+	}
+	if end > p.reach {
+		p.reach = end
+	}
+}
+
+// examineRune records that the current rune is examined.
+func (p *parser) examineRune() {
+	if p.pt.w == 0 {
+		p.examine(p.pt.offset + 1)
+		return
+	}
+	p.examine(p.pt.offset + p.pt.w)
+}
+
+// memoTable is the default MemoStore:
+// map[offset in source] map[expression or rule] {value, match}
+type memoTable map[int]map[interface{}]MemoResult
+
+func (t memoTable) Get(node interface{}, offset int) (MemoResult, bool) {
+	res, ok := t[offset][node]
+	return res, ok
+}
+
+func (t memoTable) Set(node interface{}, offset int, res MemoResult) {
+	m := t[offset]
+	if m == nil {
+		m = make(map[interface{}]MemoResult)
+		t[offset] = m
+	}
+	m[node] = res
+}
+
+// reset removes the results of the table, and keeps the maps of the
+// offsets for the next parse.
+func (t memoTable) reset() {
+	for _, m := range t {
+		for node := range m {
+			delete(m, node)
+		}
+	}
+}
+
+func (p *parser) buildRulesTable(g *grammar) {
+	if p.rules == nil {
+		p.rules = make(map[string]*rule, len(g.rules))
+	}
+	for _, r := range g.rules {
+		p.rules[r.name] = r
+	}
+}
+
+// buildStatsTable resets the statistics of the Statistics option to a
+// zero count for each rule of g.
+func (p *parser) buildStatsTable(g *grammar) {
+	*p.stats = Stats{Rules: make([]RuleStats, len(g.rules))}
+	p.exprDepth = 0
+	p.ruleStats = make(map[*rule]*RuleStats, len(g.rules))
+	for i, r := range g.rules {
+		p.stats.Rules[i].Name = r.name
+		p.ruleStats[r] = &p.stats.Rules[i]
+	}
+}
+
+// countTokens records the success counts of the lexical rules of g in the
+// counts of the TokenCounts option.
+func (p *parser) countTokens(g *grammar) {
+	for i, r := range g.rules {
+		if r.lexical {
+			p.tokenCounts[r.name] = p.stats.Rules[i].Success
+		}
+	}
+}
+
+// countRule counts a match or a failure of r in the statistics.
+func (p *parser) countRule(r *rule, ok bool) {
+	st := p.ruleStats[r]
+	if st == nil {
+		return
+	}
+	if ok {
+		st.Success++
+	} else {
+		st.Fail++
+	}
+}
+
+func (p *parser) parse(g *grammar) (val interface{}, err error) {
+	if len(g.rules) == 0 {
+		p.addErr(errNoRule)
+		return nil, p.errs.err()
+	}
+
+	// TODO : not super critical but this could be generated
+	p.grammar = g
+	p.buildRulesTable(g)
+	if p.stats == nil && p.tokenCounts != nil {
+		p.stats = new(Stats)
+	}
+	if p.stats != nil {
+		p.buildStatsTable(g)
+	}
+	if p.tokenCounts != nil {
+		defer p.countTokens(g)
+	}
+
+	if err := p.decodeInput(); err != nil {
+		p.addErr(err)
+		return nil, p.errs.err()
+	}
+	if p.inputTooLarge() {
+		p.addErr(errInputTooLarge)
+		return nil, p.errs.err()
+	}
+	if p.memoCache != nil && !p.tokMode {
+		p.memoCache.prepare(p.data, p.memoVersion)
+		p.memoStore = cacheStore{p.memoCache}
+		p.memoize = true
+	} else {
+		p.memoCache = nil
+	}
+	if g.memoLevel == memoNone {
+		p.memoize = false
+	}
+	p.memoizeExprs = p.memoize && g.memoLevel == memoExprs
+
+	// start rule is rule [0], unless an entrypoint is set
+	start := g.rules[0]
+	if p.entry != "" {
+		if start = p.rules[p.entry]; start == nil {
+			p.addErr(fmt.Errorf("undefined entrypoint rule %%s", p.entry))
+			return nil, p.errs.err()
+		}
+	}
+	if p.tracer != nil {
+		// registered before the panic handler, so that the span gets the
+		// error of a panic
+		span := p.tracer.StartSpan(start.name)
+		defer func() {
+			span.End(err)
+		}()
+	}
+
+	if p.recover || p.panicContext {
+		defer p.handlePanic(&val, &err)
+	}
+	if p.stackCap > cap(p.vstack) {
+		p.vstack = make([]map[string]interface{}, 0, p.stackCap)
+	}
+	if p.stackCap > cap(p.rstack) {
+		p.rstack = make([]*rule, 0, p.stackCap)
+	}
+	if p.cur.state == nil {
+		p.cur.state = new(parseState)
+	}
+
+	p.read() // advance to first rune
+	if p.skipLeading {
+		p.skipSpace()
+	}
+	val, ok := p.parseRule(start)
+	if p.aborted {
+		return nil, p.errs.err()
+	}
+	if p.prefix != nil && !p.tokMode {
+		defer p.setPrefix(ok, p.pt.position)
+	}
+	if ok && p.requireEOF {
+		p.skipSpace()
+		end := len(p.data)
+		if p.tokMode {
+			end = len(p.toks)
+		}
+		if p.pt.offset < end {
+			p.addErr(errTrailingInput)
+			return nil, p.errs.err()
+		}
+	}
+	if !ok {
+		if len(*p.errs) == 0 {
+			// make sure this doesn't go out silently
+			if len(p.maxExpected) > 0 {
+				found := p.maxFound
+				if len(p.maxFound) == 0 {
+					found = string(p.maxSavePoint.rn)
+				}
+
+				end := len(p.data)
+				if p.tokMode {
+					end = len(p.toks)
+				}
+				var serr error = &UnexpectedToken{Found: found, Expected: p.maxExpected}
+				if p.maxSavePoint.offset >= end {
+					serr = &UnexpectedEOF{Expected: p.maxExpected, found: found}
+				}
+				p.addStackErrAt(serr, p.maxSavePoint.position, p.maxRules)
+			} else {
+				p.addErr(errNoMatch)
+			}
+		}
+		// the partial result is nil unless the KeepPartial option is set
+		return p.partial, p.errs.err()
+	}
+	if p.owned != nil {
+		for _, e := range p.ownLog[:p.pt.owned] {
+			p.owned[e.rule.name] += e.runes
+		}
+	}
+	if p.onMatch != nil {
+		for _, e := range p.matchLog[:p.pt.matched] {
+			p.onMatch(e.rule.name, p.exportPos(e.start), p.exportPos(e.end), e.val)
+		}
+	}
+	if p.events != nil {
+		for _, e := range p.eventLog[:p.pt.evented] {
+			p.events(e)
+		}
+	}
+	if p.warnings != nil {
+		*p.warnings = append([]Warning(nil), p.warnLog[:p.pt.warned]...)
+	}
+	if p.pt.errored > 0 {
+		// only the errors of the error productions of the successful
+		// parse are returned.
+		p.errs = new(errList)
+		for _, e := range p.errLog[:p.pt.errored] {
+			p.addRuleErrAt(e, e.pos, e.rule)
+		}
+		return val, p.errs.err()
+	}
+	return val, nil
+}
+
+// setPrefix sets the prefix of the LongestPrefix option at the end of the
+// parse, ok is true if the start rule matched up to end.
+func (p *parser) setPrefix(ok bool, end position) {
+	if !ok && p.prefixEnd.offset > end.offset {
+		end = p.prefixEnd
+	}
+	*p.prefix = Prefix{Text: string(p.data[:end.offset]), End: p.exportPos(end)}
+}
+
+// skipSpace skips the whitespace at the current position for the
+// SkipLeading and RequireTrailingEOF options: the runes of the SkipFunc
+// option if it is set, else the skip rule of the grammar if it has one,
+// else the Unicode white space, and the comments of the LineComment
+// option.
+func (p *parser) skipSpace() {
+	for {
+		p.skipWhitespace()
+		if !p.skipLineComment() {
+			return
+		}
+	}
+}
+
+// skipLineComment skips the comment of the LineComment option at the
+// current position up to the end of the line, and returns true if there
+// is one.
+func (p *parser) skipLineComment() bool {
+	if p.lineComment == "" || p.tokMode || !bytes.HasPrefix(p.data[p.pt.offset:], []byte(p.lineComment)) {
+		return false
+	}
+	for !p.atInvalidOrEOF() && p.pt.rn != '\n' {
+		p.read()
+	}
+	return true
+}
+
+func (p *parser) skipWhitespace() {
+	if p.skipFunc == nil || p.tokMode {
+		if r := p.rules[p.grammar.skip]; r != nil {
+			pt := p.pt
+			if _, ok := p.parseRule(r); !ok {
+				p.restore(pt)
+			}
+			return
+		}
+	}
+	if p.tokMode {
+		return
+	}
+	skip := p.skipFunc
+	if skip == nil {
+		skip = unicode.IsSpace
+	}
+	for !p.atInvalidOrEOF() && skip(p.pt.rn) {
+		p.read()
+	}
+}
+
+// inputTooLarge returns true if the input exceeds the limit set by the
+// MaxInputRunes option.
+func (p *parser) inputTooLarge() bool {
+	if p.maxInputRunes <= 0 {
+		return false
+	}
+	if p.tokMode {
+		return len(p.toks) > p.maxInputRunes
+	}
+	// a rune is at least one byte
+	return len(p.data) > p.maxInputRunes && utf8.RuneCount(p.data) > p.maxInputRunes
+}
+
+// decodeInput decodes the input to UTF-8 according to the Decoder or the
+// Encoding option, and removes its byte order mark if the SkipBOM option
+// is set.
+func (p *parser) decodeInput() error {
+	if p.tokMode {
+		return nil
+	}
+	enc := strings.ToLower(p.encoding)
+	switch {
+	case p.decoder != nil:
+		rns, err := p.decoder(p.data)
+		if err != nil {
+			return err
+		}
+		p.data = []byte(string(rns))
+	case enc == "", enc == "utf-8", enc == "utf8":
+	case enc == "latin1", enc == "iso-8859-1":
+		var buf bytes.Buffer
+		for _, b := range p.data {
+			buf.WriteRune(rune(b))
+		}
+		p.data = buf.Bytes()
+	case enc == "utf-16", enc == "utf-16be", enc == "utf-16le":
+		if len(p.data)%%2 != 0 {
+			return errors.New("invalid UTF-16 input: odd number of bytes")
+		}
+		var order binary.ByteOrder = binary.BigEndian
+		if enc == "utf-16le" || (enc == "utf-16" && bytes.HasPrefix(p.data, []byte{0xff, 0xfe})) {
+			order = binary.LittleEndian
+		}
+		units := make([]uint16, len(p.data)/2)
+		for i := range units {
+			units[i] = order.Uint16(p.data[2*i:])
+		}
+		var buf bytes.Buffer
+		for _, rn := range utf16.Decode(units) {
+			buf.WriteRune(rn)
+		}
+		p.data = buf.Bytes()
+	default:
+		return fmt.Errorf("unknown encoding %%q", p.encoding)
+	}
+	if p.skipBOM {
+		p.data = bytes.TrimPrefix(p.data, []byte("\uFEFF"))
+	}
+	if p.latinFold != "" {
+		if err := p.foldLatin(); err != nil {
+			return err
+		}
+	}
+	if p.normalize && bytes.IndexByte(p.data, '\r') >= 0 {
+		buf := make([]byte, 0, len(p.data))
+		for i, b := range p.data {
+			if b == '\r' {
+				if i+1 < len(p.data) && p.data[i+1] == '\n' {
+					p.crlfs = append(p.crlfs, len(buf))
+					continue
+				}
+				b = '\n'
+			}
+			buf = append(buf, b)
+		}
+		p.data = buf
+	}
+	return nil
+}
+
+// latinMarks lists by combining mark the letters that decompose to an
+// ASCII letter and the mark, as pairs of the letter and the ASCII letter.
+var latinMarks = []struct {
+	mark  rune
+	pairs string
+}{
+	{0x0300, "ÀAÈEÌIÒOÙUàaèeìiòoùu"}, // combining grave accent
+	{0x0301, "ÁAÉEÍIÓOÚUÝYáaéeíióoúuýyĆCćcĹLĺlŃNńnŔRŕrŚSśsŹZźz"}, // combining acute accent
+	{0x0302, "ÂAÊEÎIÔOÛUâaêeîiôoûuĈCĉcĜGĝgĤHĥhĴJĵjŜSŝsŴWŵwŶYŷy"}, // combining circumflex accent
+	{0x0303, "ÃAÑNÕOãañnõoĨIĩiŨUũu"}, // combining tilde
+	{0x0304, "ĀAāaĒEēeĪIīiŌOōoŪUūu"}, // combining macron
+	{0x0306, "ĂAăaĔEĕeĞGğgĬIĭiŎOŏoŬUŭu"}, // combining breve
+	{0x0307, "ĊCċcĖEėeĠGġgİIŻZżz"}, // combining dot above
+	{0x0308, "ÄAËEÏIÖOÜUäaëeïiöoüuÿyŸY"}, // combining diaeresis
+	{0x030a, "ÅAåaŮUůu"}, // combining ring above
+	{0x030b, "ŐOőoŰUűu"}, // combining double acute accent
+	{0x030c, "ČCčcĎDďdĚEěeĽLľlŇNňnŘRřrŠSšsŤTťtŽZžz"}, // combining caron
+	{0x0327, "ÇCçcĢGģgĶKķkĻLļlŅNņnŖRŗrŞSşsŢTţt"}, // combining cedilla
+	{0x0328, "ĄAąaĘEęeĮIįiŲUųu"}, // combining ogonek
+}
+
+// foldLatin folds the accented Latin letters of the input with the mode
+// of the LatinFold option.
+func (p *parser) foldLatin() error {
+	mode := strings.ToLower(p.latinFold)
+	if mode != "compose" && mode != "decompose" {
+		return fmt.Errorf("unknown Latin fold %%q", p.latinFold)
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(p.data); {
+		rn, n := utf8.DecodeRune(p.data[i:])
+		i += n
+		if rn == utf8.RuneError && n == 1 {
+			// keep the invalid byte, for the error of the parse
+			buf.WriteByte(p.data[i-1])
+			continue
+		}
+		if mode == "decompose" {
+			if base, mark, ok := decomposeLatin(rn); ok {
+				buf.WriteRune(base)
+				rn = mark
+			}
+		} else if i < len(p.data) {
+			mark, m := utf8.DecodeRune(p.data[i:])
+			if comp, ok := composeLatin(rn, mark); ok {
+				rn = comp
+				i += m
+			}
+		}
+		buf.WriteRune(rn)
+	}
+	p.data = buf.Bytes()
+	return nil
+}
+
+// decomposeLatin returns the ASCII letter and the combining mark that are
+// the decomposition of rn, if it is in latinMarks.
+func decomposeLatin(rn rune) (rune, rune, bool) {
+	if rn < 0xc0 || rn >= 0x180 {
+		return 0, 0, false
+	}
+	for _, lm := range latinMarks {
+		pairs := []rune(lm.pairs)
+		for i := 0; i < len(pairs); i += 2 {
+			if pairs[i] == rn {
+				return pairs[i+1], lm.mark, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// composeLatin returns the letter that is the composition of the ASCII
+// letter base and the combining mark, if it is in latinMarks.
+func composeLatin(base, mark rune) (rune, bool) {
+	if base >= utf8.RuneSelf || mark < 0x300 || mark > 0x36f {
+		return 0, false
+	}
+	for _, lm := range latinMarks {
+		if lm.mark != mark {
+			continue
+		}
+		pairs := []rune(lm.pairs)
+		for i := 0; i < len(pairs); i += 2 {
+			if pairs[i+1] == base {
+				return pairs[i], true
+			}
+		}
+	}
+	return 0, false
+}
+
+// origOffset returns the offset in the input before the conversion of the
+// NormalizeNewlines option of the offset off of the converted input. The
+// offset of a "\n" that replaced a "\r\n" is that of the "\r".
+func (p *parser) origOffset(off int) int {
+	return off + sort.SearchInts(p.crlfs, off)
+}
+
+// exportPos returns the Pos of pos reported to the user.
+func (p *parser) exportPos(pos position) Pos {
+	return Pos{pos.line, pos.col, p.origOffset(pos.offset)}
+}
+
+func (p *parser) parseRule(rule *rule) (interface{}, bool) {
+	if p.grammar.nlSignificant && !p.tokMode && !p.inSkipLine {
+		if rule.name == p.grammar.skip {
+			if p.nlSig {
+				return p.parseSkipLine(rule)
+			}
+		} else if p.nlSig != rule.nlSignificant {
+			// the rules referenced by the rule see their own flag
+			p.nlSig = rule.nlSignificant
+			val, ok := p.parseRule(rule)
+			p.nlSig = !rule.nlSignificant
+			return val, ok
+		}
+	}
+
+	if p.debug {
+		defer p.out(p.in("parseRule " + rule.name))
+	}
+	if p.trace != nil {
+		p.traceExpr("rule " + rule.name)
+	}
+
+	if p.memoize {
+		res, ok := p.getMemoized(rule)
+		if ok {
+			p.restore(res.end)
+			if p.stats != nil {
+				p.countRule(rule, res.b)
+			}
+			return res.v, res.b
+		}
+	}
+
+	if p.maxDepth > 0 && len(p.rstack) >= p.maxDepth {
+		p.abort(errMaxDepth)
+		return nil, false
+	}
+
+	start := p.pt
+	var outer int
+	if p.memoCache != nil {
+		outer = p.enterReach()
+	}
+	if p.events != nil {
+		p.addEvent(EventStart, rule.name, start.position, "")
+	}
+	p.rstack = append(p.rstack, rule)
+	if rule.silent {
+		defer p.restoreMaxSavePoint(p.maxSavePoint, p.maxFound, p.maxExpected, p.maxRules)
+	}
+	budgetRule, budgetEnd := p.budgetRule, p.budgetEnd
+	if end := p.exprCnt + rule.budget; rule.budget > 0 && (p.budgetRule == nil || end < p.budgetEnd) {
+		p.budgetRule, p.budgetEnd = rule, end
+	}
+	p.pushV()
+	vbase := p.vbase
+	p.vbase = len(p.vstack) - 1
+	var val interface{}
+	var ok bool
+	if rule.tail && p.loopTail() {
+		val, ok = p.parseTailRule(rule)
+	} else {
+		val, ok = p.parseExpr(rule.expr)
+	}
+	p.vbase = vbase
+	p.popV()
+	p.budgetRule, p.budgetEnd = budgetRule, budgetEnd
+	if fn := p.transforms[rule.name]; ok && fn != nil {
+		v, err := fn(val)
+		if err != nil {
+			p.addErrAt(err, start.position)
+			ok = false
+		} else {
+			val = v
+		}
+	}
+	if ok && p.strictNodes && rule.isType != nil && val != nil && !rule.isType(val) {
+		p.addErrAt(fmt.Errorf("value of type %%T, want %%s", val, rule.typ), start.position)
+		ok = false
+	}
+	p.rstack = p.rstack[:len(p.rstack)-1]
+	if ok && p.keepPartial && len(p.rstack) > 0 && start.offset == 0 && p.pt.offset >= p.partialEnd {
+		p.partial, p.partialEnd = val, p.pt.offset
+	}
+	if ok && p.owned != nil {
+		p.addOwnership(rule, start)
+	}
+	if ok && p.onMatch != nil {
+		p.matchLog = append(p.matchLog[:p.pt.matched], matchEntry{rule: rule, start: start.position, end: p.pt.position, val: val})
+		p.pt.matched = len(p.matchLog)
+	}
+	if p.events != nil {
+		if ok {
+			p.addEvent(EventEnd, rule.name, p.pt.position, "")
+		} else {
+			p.pt.evented = start.evented
+		}
+	}
+	if ok && p.debug {
+		p.print(strings.Repeat(" ", p.depth) + "MATCH", string(p.sliceFrom(start)))
+	}
+	if p.stats != nil {
+		p.countRule(rule, ok)
+	}
+
+	if p.memoize {
+		var reach int
+		if p.memoCache != nil {
+			reach = p.exitReach(outer)
+		}
+		p.setMemoized(start, rule, resultTuple{val, ok, p.pt}, reach)
+	}
+	return val, ok
+}
+
+// parseSkipLine parses the skip rule in a rule marked with @nlsignificant.
+// The input is cut at the next newline, so that none of the matchers of
+// the skip rule, or of the rules it references, can match it. Their
+// results are not memoized, as they depend on the rule that references the
+// skip rule.
+func (p *parser) parseSkipLine(rule *rule) (interface{}, bool) {
+	start, data := p.pt, p.data
+	cut := len(data)
+	if ix := bytes.IndexByte(data[start.offset:], '\n'); ix >= 0 {
+		cut = start.offset + ix
+	}
+	p.data = data[:cut]
+	if cut == start.offset {
+		// the current rune is the newline
+		p.pt.rn, p.pt.w = utf8.RuneError, 0
+	}
+	memoize, memoizeExprs := p.memoize, p.memoizeExprs
+	p.memoize, p.memoizeExprs, p.inSkipLine = false, false, true
+	val, ok := p.parseRule(rule)
+	p.memoize, p.memoizeExprs, p.inSkipLine = memoize, memoizeExprs, false
+	p.data = data
+
+	if p.pt.offset == cut && cut < len(data) {
+		if cut == start.offset {
+			p.pt.rn, p.pt.w = start.rn, start.w
+		} else {
+			// read the newline that the cut input ended at
+			p.pt.rn, p.pt.w = '\n', 1
+			p.pt.line++
+			p.pt.col = 0
+		}
+	}
+	return val, ok
+}
+
+// loopTail returns true if the tail-recursive rules are parsed as loops.
+// The options that observe each match of a rule or each expression need
+// the nested calls.
+func (p *parser) loopTail() bool {
+	return !p.debug && p.trace == nil && p.explain == nil && p.ambiguities == nil && p.events == nil &&
+		p.onMatch == nil && p.owned == nil && p.stats == nil && !p.keepPartial &&
+		len(p.transforms) == 0 && !p.strictNodes
+}
+
+// tailFrame is a level of a tail-recursive rule parsed as a loop: the
+// alternative whose sequence matched up to the reference to the rule, the
+// position where the level started and the values of the sequence.
+type tailFrame struct {
+	alt   int
+	start savepoint
+	vals  []interface{}
+}
+
+// parseTailRule parses the choice of the tail-recursive rule as a loop.
+// When an alternative matches up to the reference to the rule that ends
+// it, the next level starts at the current position instead of calling
+// the rule, and its labels stay on the vstack. When a level matches an
+// alternative that does not end with the rule, the sequences and actions
+// of the pending levels are completed from the innermost one outwards. A
+// level that does not match backtracks to the next alternative of the
+// level that started it, as the nested calls would.
+func (p *parser) parseTailRule(rule *rule) (interface{}, bool) {
+	ch, ok := rule.expr.(*choiceExpr)
+	if !ok {
+		return p.parseExpr(rule.expr)
+	}
+	var frames []tailFrame
+	alt := 0
+	for {
+		start := p.pt
+		var val interface{}
+		matched, next := false, false
+		for ; alt < len(ch.alternatives) && !matched && !next; alt++ {
+			p.pushV()
+			p.vbase = len(p.vstack) - 1
+			seq, _, _ := tailAlt(rule, ch.alternatives[alt])
+			if seq == nil {
+				val, matched = p.parseExpr(ch.alternatives[alt])
+				p.popV()
+				continue
+			}
+			vals := make([]interface{}, 0, len(seq.exprs))
+			for _, expr := range seq.exprs[:len(seq.exprs)-1] {
+				v, ok := p.parseExpr(expr)
+				if !ok {
+					break
+				}
+				vals = append(vals, v)
+			}
+			if len(vals) < len(seq.exprs)-1 {
+				p.restore(start)
+				p.popV()
+				continue
+			}
+			// the labels of the level stay on the vstack until it is
+			// completed
+			frames = append(frames, tailFrame{alt: alt, start: start, vals: vals})
+			next = true
+		}
+		if next {
+			alt = 0
+			continue
+		}
+
+		for matched && len(frames) > 0 {
+			f := frames[len(frames)-1]
+			_, act, label := tailAlt(rule, ch.alternatives[f.alt])
+			if label != "" {
+				p.vstack[len(p.vstack)-1][label] = val
+			}
+			val = append(f.vals, val)
+			if act != nil {
+				val, matched = p.runAction(act, f.start)
+			}
+			p.popV()
+			frames = frames[:len(frames)-1]
+			if !matched {
+				// as for the nested calls, the next alternatives are tried
+				// from where the failed action ended
+				alt = f.alt + 1
+			}
+		}
+		if matched {
+			return val, true
+		}
+		if alt < len(ch.alternatives) {
+			// the action of a level failed, its next alternatives are tried
+			continue
+		}
+		if len(frames) == 0 {
+			return nil, false
+		}
+		f := frames[len(frames)-1]
+		frames = frames[:len(frames)-1]
+		p.popV()
+		p.restore(f.start)
+		alt = f.alt + 1
+	}
+}
+
+// tailAlt returns the sequence of the alternative alt of the tail-recursive
+// rule, with its action if it has one and the label of the reference to
+// the rule that ends it, or nil if alt does not end with the rule.
+func tailAlt(rule *rule, alt interface{}) (*seqExpr, *actionExpr, string) {
+	act, _ := alt.(*actionExpr)
+	if act != nil {
+		alt = act.expr
+	}
+	seq, ok := alt.(*seqExpr)
+	if !ok || len(seq.exprs) < 2 {
+		return nil, nil, ""
+	}
+	var label string
+	last := seq.exprs[len(seq.exprs)-1]
+	if lab, ok := last.(*labeledExpr); ok {
+		if lab.capture || lab.span {
+			return nil, nil, ""
+		}
+		label, last = lab.label, lab.expr
+	}
+	if ref, ok := last.(*ruleRefExpr); !ok || ref.name != rule.name {
+		return nil, nil, ""
+	}
+	return seq, act, label
+}
+
+// addEvent records an event of the current derivation, reported to the
+// Events function if the parse succeeds.
+func (p *parser) addEvent(kind EventKind, rule string, pos position, text string) {
+	p.eventLog = append(p.eventLog[:p.pt.evented], Event{Kind: kind, Rule: rule, Pos: p.exportPos(pos), Text: text})
+	p.pt.evented = len(p.eventLog)
+}
+
+// addOwnership records the runes matched by rule since start that are not
+// owned by the rules it references.
+func (p *parser) addOwnership(rule *rule, start savepoint) {
+	cumAt := func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		return p.ownLog[n-1].cum
+	}
+
+	runes := utf8.RuneCount(p.sliceFrom(start))
+	cum := cumAt(p.pt.owned)
+	own := runes - (cum - cumAt(start.owned))
+	if own == 0 {
+		return
+	}
+	p.ownLog = append(p.ownLog[:p.pt.owned], ownEntry{rule: rule, runes: own, cum: cum + own})
+	p.pt.owned = len(p.ownLog)
+}
+
+func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
+	var ok bool
+
+	if p.aborted {
+		return nil, false
+	}
+
+	if p.memoizeExprs {
+		res, ok := p.getMemoized(expr)
+		if ok {
+			p.restore(res.end)
+			if res.b && p.prefix != nil && p.pt.offset > p.prefixEnd.offset {
+				p.prefixEnd = p.pt.position
+			}
+			return res.v, res.b
+		}
+	}
+
+	p.exprCnt++
+	if p.budgetRule != nil && p.exprCnt > p.budgetEnd {
+		p.abort(fmt.Errorf("budget of %%d expressions of rule %%s exceeded", p.budgetRule.budget, p.budgetRule.name))
+		return nil, false
+	}
+	pt := p.pt
+	var outer int
+	if p.memoCache != nil {
+		outer = p.enterReach()
+	}
+	if p.trace != nil {
+		kind := fmt.Sprintf("%%T", expr)
+		p.traceExpr(kind[strings.LastIndex(kind, ".")+1:])
+	}
+	var step int
+	if p.explain != nil {
+		step = p.explain.enter(expr, pt.position)
+	}
+	if p.stats != nil {
+		p.exprDepth++
+		p.peakStacks()
+	}
+	var val interface{}
+	switch expr := expr.(type) {
+	case *actionExpr:
+		val, ok = p.parseActionExpr(expr)
+	case *andCodeExpr:
+		val, ok = p.parseAndCodeExpr(expr)
+	case *andExpr:
+		val, ok = p.parseAndExpr(expr)
+	case *peekExpr:
+		val, ok = p.parsePeekExpr(expr)
+	case *anyMatcher:
+		val, ok = p.parseAnyMatcher(expr)
+	case *backRefExpr:
+		val, ok = p.parseBackRefExpr(expr)
+	case *seenExpr:
+		val, ok = p.parseSeenExpr(expr)
+	case *byteMatcher:
+		val, ok = p.parseByteMatcher(expr)
+	case *bytesMatcher:
+		val, ok = p.parseBytesMatcher(expr)
+	case *charClassMatcher:
+		val, ok = p.parseCharClassMatcher(expr)
+	case *choiceExpr:
+		val, ok = p.parseChoiceExpr(expr)
+	case *foldExpr:
+		val, ok = p.parseFoldExpr(expr)
+	case *indentMatcher:
+		val, ok = p.parseIndentMatcher(expr)
+	case *compactExpr:
+		val, ok = p.parseCompactExpr(expr)
+	case *trimExpr:
+		val, ok = p.parseTrimExpr(expr)
+	case *convertExpr:
+		val, ok = p.parseConvertExpr(expr)
+	case *arrayExpr:
+		val, ok = p.parseArrayExpr(expr)
+	case *mapExpr:
+		val, ok = p.parseMapExpr(expr)
+	case *keywordMatcher:
+		val, ok = p.parseKeywordMatcher(expr)
+	case *wordListMatcher:
+		val, ok = p.parseWordListMatcher(expr)
+	case *tableMatcher:
+		val, ok = p.parseTableMatcher(expr)
+	case *labeledExpr:
+		val, ok = p.parseLabeledExpr(expr)
+	case *litMatcher:
+		val, ok = p.parseLitMatcher(expr)
+	case *litSetMatcher:
+		val, ok = p.parseLitSetMatcher(expr)
+	case *lookbehindExpr:
+		val, ok = p.parseLookbehindExpr(expr)
+	case *notCodeExpr:
+		val, ok = p.parseNotCodeExpr(expr)
+	case *notExpr:
+		val, ok = p.parseNotExpr(expr)
+	case *nestedMatcher:
+		val, ok = p.parseNestedMatcher(expr)
+	case *numberMatcher:
+		val, ok = p.parseNumberMatcher(expr)
+	case *oneOrMoreExpr:
+		val, ok = p.parseOneOrMoreExpr(expr)
+	case *operatorsExpr:
+		val, ok = p.parseOperatorsExpr(expr)
+	case *ruleRefExpr:
+		val, ok = p.parseRuleRefExpr(expr)
+	case *sepExpr:
+		val, ok = p.parseSepExpr(expr)
+	case *seqExpr:
+		val, ok = p.parseSeqExpr(expr)
+	case *skipExpr:
+		val, ok = p.parseSkipExpr(expr)
+	case *tokenMatcher:
+		val, ok = p.parseTokenMatcher(expr)
+	case *unreservedExpr:
+		val, ok = p.parseUnreservedExpr(expr)
+	case *restOfLineMatcher:
+		val, ok = p.parseRestOfLineMatcher(expr)
+	case *untilMatcher:
+		val, ok = p.parseUntilMatcher(expr)
+	case *whenExpr:
+		val, ok = p.parseWhenExpr(expr)
+	case *zeroOrMoreExpr:
+		val, ok = p.parseZeroOrMoreExpr(expr)
+	case *zeroOrOneExpr:
+		val, ok = p.parseZeroOrOneExpr(expr)
+	default:
+		p.abort(fmt.Errorf("unknown expression type %%T", expr))
+	}
+	if p.stats != nil {
+		p.exprDepth--
+	}
+	if p.explain != nil {
+		p.explain.exit(step, ok, p.pt.position)
+	}
+	if ok && p.prefix != nil && p.pt.offset > p.prefixEnd.offset {
+		p.prefixEnd = p.pt.position
+	}
+	if ok && p.events != nil && len(p.rstack) > 0 {
+		switch expr.(type) {
+		case *anyMatcher, *byteMatcher, *bytesMatcher, *charClassMatcher, *keywordMatcher,
+			*litMatcher, *litSetMatcher, *nestedMatcher, *numberMatcher, *restOfLineMatcher,
+			*tableMatcher, *tokenMatcher, *untilMatcher, *wordListMatcher:
+			p.addEvent(EventText, p.rstack[len(p.rstack)-1].name, pt.position, string(p.sliceFrom(pt)))
+		}
+	}
+	if p.memoizeExprs {
+		var reach int
+		if p.memoCache != nil {
+			reach = p.exitReach(outer)
+		}
+		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt}, reach)
+	}
+	return val, ok
+}
+
+func (p *parser) parseActionExpr(act *actionExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseActionExpr"))
+	}
+
+	start := p.pt
+	val, ok := p.parseExpr(act.expr)
+	if ok {
+		val, ok = p.runAction(act, start)
+	}
+	if ok && p.debug {
+		p.print(strings.Repeat(" ", p.depth) + "MATCH", string(p.sliceFrom(start)))
+	}
+	return val, ok
+}
+
+// runAction runs the code block of the action act, whose expression matched
+// from start to the current position.
+func (p *parser) runAction(act *actionExpr, start savepoint) (interface{}, bool) {
+	p.cur.pos = start.position
+	p.cur.end = p.pt.position
+	p.cur.text = p.sliceFrom(start)
+	if p.normalizer != nil && len(p.rstack) > 0 {
+		name := p.rstack[len(p.rstack)-1].name
+		p.cur.text = []byte(p.normalizer(name, string(p.cur.text)))
+	}
+	actVal, err := act.run(p)
+	if perr, isProd := err.(*productionError); isProd {
+		// an error production matches, its error is reported at the
+		// end of the parse unless the match is backtracked over.
+		p.errLog = append(p.errLog[:p.pt.errored], perr)
+		p.pt.errored = len(p.errLog)
+	} else if err == errStopRepeat {
+		p.restore(start)
+		return nil, false
+	} else if err != nil {
+		p.addErrAt(err, start.position)
+		return nil, false
+	}
+	return actVal, true
+}
+
+func (p *parser) parseAndCodeExpr(and *andCodeExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndCodeExpr"))
+	}
+
+	ok, err := and.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, ok
+}
+
+func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndExpr"))
+	}
+
+	pt, end := p.pt, p.prefixEnd
+	p.pushV()
+	_, ok := p.parseExpr(and.expr)
+	p.popV()
+	p.restore(pt)
+	// the text ahead is not part of the longest prefix
+	p.prefixEnd = end
+	return nil, ok
+}
+
+// parsePeekExpr matches the expression of peek like an and predicate, and
+// keeps its value, so that the text ahead can be captured without being
+// consumed.
+func (p *parser) parsePeekExpr(peek *peekExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parsePeekExpr"))
+	}
+
+	pt, end := p.pt, p.prefixEnd
+	p.pushV()
+	val, ok := p.parseExpr(peek.expr)
+	p.popV()
+	p.restore(pt)
+	p.prefixEnd = end
+	return val, ok
+}
+
+func (p *parser) parseAnyMatcher(any *anyMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAnyMatcher"))
+	}
+
+	if !p.atInvalidOrEOF() {
+		start := p.pt
+		p.read()
+		return p.sliceFrom(start), true
+	}
+	return nil, false
+}
+
+func (p *parser) parseBackRefExpr(ref *backRefExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseBackRefExpr"))
+	}
+
+	var text []byte
+	found := false
+	for i := len(p.vstack) - 1; i >= p.vbase && !found; i-- {
+		var v interface{}
+		if v, found = p.vstack[i]["="+ref.label]; found {
+			text = v.([]byte)
+		}
+	}
+	if !found {
+		// the label did not match in this rule
+		return nil, false
+	}
+
+	start := p.pt
+	for _, want := range string(text) {
+		if p.pt.rn != want {
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+// parseSeenExpr matches the longest of the spans logged for the label
+// that the input at the current position repeats. The candidate spans of
+// each length are found by the hash of the input, and compared to it.
+func (p *parser) parseSeenExpr(seen *seenExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSeenExpr"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	start := p.pt
+	lens := p.seenLens[seen.label]
+	for i := len(lens) - 1; i >= 0; i-- {
+		n := lens[i]
+		end := start.offset + n
+		if p.memoCache != nil {
+			p.examine(end)
+		}
+		if end > len(p.data) {
+			continue
+		}
+		key := seenKey{label: seen.label, n: n, hash: p.hashes.span(start.offset, end)}
+		for _, ix := range p.seenIndex[key] {
+			// the index keeps the entries of the backtracked spans, that
+			// may have been replaced in the log
+			if ix >= p.pt.seen {
+				continue
+			}
+			e := p.seenLog[ix]
+			if e.label != seen.label || e.end-e.start != n || !bytes.Equal(p.data[e.start:e.end], p.data[start.offset:end]) {
+				continue
+			}
+			for p.pt.offset < end {
+				p.read()
+			}
+			return p.sliceFrom(start), true
+		}
+	}
+	return nil, false
+}
+
+// addSeen logs the span from start to end matched by the label, for the
+// @seen expressions.
+func (p *parser) addSeen(label string, start, end int) {
+	if p.hashes == nil {
+		p.hashes = newRollingHash(p.data)
+		p.seenIndex = make(map[seenKey][]int)
+		p.seenLens = make(map[string][]int)
+	}
+	ix := p.pt.seen
+	p.seenLog = append(p.seenLog[:ix], seenEntry{label: label, start: start, end: end})
+	p.pt.seen = len(p.seenLog)
+
+	n := end - start
+	key := seenKey{label: label, n: n, hash: p.hashes.span(start, end)}
+	if ixs := p.seenIndex[key]; len(ixs) == 0 || ixs[len(ixs)-1] != ix {
+		p.seenIndex[key] = append(ixs, ix)
+	}
+	lens := p.seenLens[label]
+	if i := sort.SearchInts(lens, n); i == len(lens) || lens[i] != n {
+		lens = append(lens, 0)
+		copy(lens[i+1:], lens[i:])
+		lens[i] = n
+		p.seenLens[label] = lens
+	}
+}
+
+func (p *parser) parseByteMatcher(by *byteMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseByteMatcher"))
+	}
+
+	if p.pt.offset < len(p.data) && p.data[p.pt.offset] == by.val {
+		start := p.pt
+		p.skip(1)
+		return p.sliceFrom(start), true
+	}
+	return nil, false
+}
+
+func (p *parser) parseBytesMatcher(by *bytesMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseBytesMatcher"))
+	}
+
+	n := by.n
+	if by.label != "" {
+		var ok bool
+		if n, ok = p.labelInt(by.label); !ok || n < 0 {
+			p.addErr(fmt.Errorf("invalid number of bytes for label %%s", by.label))
+			return nil, false
+		}
+	}
+	if len(p.data)-p.pt.offset < n {
+		if p.memoCache != nil {
+			p.examine(len(p.data) + 1)
+		}
+		return nil, false
+	}
+	start := p.pt
+	p.skip(n)
+	return p.sliceFrom(start), true
+}
+
+// labelInt returns the value of label in the current rule as an int, and
+// false if it is not set or is not an integer.
+func (p *parser) labelInt(label string) (int, bool) {
+	for i := len(p.vstack) - 1; i >= p.vbase; i-- {
+		v, ok := p.vstack[i][label]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case int:
+			return v, true
+		case int8:
+			return int(v), true
+		case int16:
+			return int(v), true
+		case int32:
+			return int(v), true
+		case int64:
+			return int(v), true
+		case uint:
+			return int(v), true
+		case uint8:
+			return int(v), true
+		case uint16:
+			return int(v), true
+		case uint32:
+			return int(v), true
+		case uint64:
+			return int(v), true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseCharClassMatcher"))
+	}
+
+	cur := p.pt.rn
+	// can't match EOF
+	if p.atInvalidOrEOF() {
+		return nil, false
+	}
+	start := p.pt
+	if !p.classMatcher(chr).accepts(cur) {
+		return nil, false
+	}
+	p.read()
+	return p.sliceFrom(start), true
+}
+
+// classMatcher returns the character class chr with the Unicode range
+// tables of the ClassTable option, or chr itself if none of its classes
+// has one. The copy is made once per parse.
+func (p *parser) classMatcher(chr *charClassMatcher) *charClassMatcher {
+	if len(p.classTables) == 0 || len(chr.classNames) == 0 {
+		return chr
+	}
+	if cp, ok := p.classCopies[chr]; ok {
+		return cp
+	}
+	cp := *chr
+	cp.classes = make([]*unicode.RangeTable, len(chr.classes))
+	for i, nm := range chr.classNames {
+		cp.classes[i] = chr.classes[i]
+		if t := p.classTables[nm]; t != nil {
+			cp.classes[i] = t
+		}
+	}
+	if p.classCopies == nil {
+		p.classCopies = make(map[*charClassMatcher]*charClassMatcher)
+	}
+	p.classCopies[chr] = &cp
+	return &cp
+}
+
+// accepts returns true if the character class matches rn, taking its case
+// insensitivity and its inversion into account.
+func (chr *charClassMatcher) accepts(rn rune) bool {
+	matched := chr.matches(rn)
+	if !matched && chr.ignoreCase {
+		// try the runes that are equivalent under simple case folding
+		matched = chr.matches(unicode.ToLower(rn))
+		for f := unicode.SimpleFold(rn); f != rn && !matched; f = unicode.SimpleFold(f) {
+			matched = chr.matches(f)
+		}
+	}
+	return matched != chr.inverted
+}
+
+// matches returns true if rn is in the chars, ranges or Unicode classes
+// of the character class, ignoring its inversion.
+func (chr *charClassMatcher) matches(rn rune) bool {
+	for _, c := range chr.chars {
+		if c == rn {
+			return true
+		}
+	}
+	for i := 0; i < len(chr.ranges); i += 2 {
+		if rn >= chr.ranges[i] && rn <= chr.ranges[i+1] {
+			return true
+		}
+	}
+	for _, cl := range chr.classes {
+		if unicode.Is(cl, rn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseChoiceExpr"))
+	}
+
+	if ch.longest {
+		return p.parseLongestChoice(ch)
+	}
+
+	start := p.pt
+	for i, alt := range ch.alternatives {
+		p.pushV()
+		val, ok := p.parseExpr(alt)
+		p.popV()
+		if ok {
+			if p.ambiguities != nil {
+				p.checkAmbiguity(ch, i, start)
+			}
+			if ch.branches != nil {
+				return Branch{Index: i, Name: ch.branches[i], Value: val}, ok
+			}
+			return val, ok
+		}
+	}
+	return nil, false
+}
+
+// parseLongestChoice tries all the alternatives of the @longest choice ch,
+// and matches the first one of those that consume the most input.
+func (p *parser) parseLongestChoice(ch *choiceExpr) (interface{}, bool) {
+	start := p.pt
+	best, end := -1, start
+	var val interface{}
+	for i, alt := range ch.alternatives {
+		p.restore(start)
+		p.pushV()
+		v, ok := p.parseExpr(alt)
+		p.popV()
+		if ok && (best < 0 || p.pt.offset > end.offset) {
+			best, end, val = i, p.pt, v
+		}
+	}
+	if best < 0 {
+		return nil, false
+	}
+	if best < len(ch.alternatives)-1 {
+		// the alternatives tried after it may have overwritten the state
+		// recorded by its match, it is matched again
+		p.restore(start)
+		p.pushV()
+		val, _ = p.parseExpr(ch.alternatives[best])
+		p.popV()
+	}
+	if ch.branches != nil {
+		return Branch{Index: best, Name: ch.branches[best], Value: val}, true
+	}
+	return val, true
+}
+
+// checkAmbiguity tries the alternatives of ch that follow the alternative
+// i, that matched from start, and records an Ambiguity if any of them also
+// matches. The parser is back at the end of the match of i on return.
+func (p *parser) checkAmbiguity(ch *choiceExpr, i int, start savepoint) {
+	key := ambiguityKey{choice: ch, offset: start.offset}
+	if p.ambiguous[key] {
+		return
+	}
+	end := p.pt
+	alts := []int{i + 1}
+	for j := i + 1; j < len(ch.alternatives); j++ {
+		p.restore(start)
+		p.pushV()
+		_, ok := p.parseExpr(ch.alternatives[j])
+		p.popV()
+		if ok {
+			alts = append(alts, j+1)
+		}
+	}
+	p.restore(end)
+	if len(alts) == 1 {
+		return
+	}
+
+	if p.ambiguous == nil {
+		p.ambiguous = make(map[ambiguityKey]bool)
+	}
+	p.ambiguous[key] = true
+	var name string
+	if len(p.rstack) > 0 {
+		name = p.rstack[len(p.rstack)-1].name
+	}
+	*p.ambiguities = append(*p.ambiguities, Ambiguity{Rule: name, Pos: p.exportPos(start.position), Alts: alts})
+}
+
+// parseFoldExpr folds the value of the sequence {first, {{op, operand}...}}
+// into binary operations, with the value []interface{}{left, op, right}.
+func (p *parser) parseFoldExpr(fold *foldExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseFoldExpr"))
+	}
+
+	val, ok := p.parseExpr(fold.expr)
+	if !ok {
+		return nil, false
+	}
+	vals := val.([]interface{})
+	rest, _ := vals[1].([]interface{})
+	if !fold.right {
+		left := vals[0]
+		for _, v := range rest {
+			pair := v.([]interface{})
+			left = []interface{}{left, pair[0], pair[1]}
+		}
+		return left, true
+	}
+
+	if len(rest) == 0 {
+		return vals[0], true
+	}
+	right := rest[len(rest)-1].([]interface{})[1]
+	for i := len(rest) - 1; i >= 0; i-- {
+		left := vals[0]
+		if i > 0 {
+			left = rest[i-1].([]interface{})[1]
+		}
+		right = []interface{}{left, rest[i].([]interface{})[0], right}
+	}
+	return right, true
+}
+
+func (p *parser) parseIndentMatcher(ind *indentMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseIndentMatcher " + ind.val))
+	}
+
+	// only match at the start of a line, never in token mode
+	if p.tokMode || p.pt.col != 1 && p.pt.offset < len(p.data) {
+		return nil, false
+	}
+	width := 0
+	for p.pt.offset+width < len(p.data) {
+		if b := p.data[p.pt.offset+width]; b != ' ' && b != '\t' {
+			break
+		}
+		width++
+	}
+	if p.memoCache != nil {
+		p.examine(p.pt.offset + width + 1)
+	}
+	if p.pt.offset+width == len(p.data) {
+		// no more line, the indentation is back at the top level
+		width = 0
+	}
+	cur := 0
+	if p.pt.indents != nil {
+		cur = p.pt.indents.width
+	}
+
+	switch ind.val {
+	case "indent":
+		if width <= cur {
+			return nil, false
+		}
+		p.pt.indents = &indentLevel{width: width, prev: p.pt.indents}
+	case "samedent":
+		if width != cur {
+			return nil, false
+		}
+		start := p.pt
+		for p.pt.offset < start.offset+width {
+			p.read()
+		}
+		return p.sliceFrom(start), true
+	case "dedent":
+		if width >= cur {
+			return nil, false
+		}
+		p.pt.indents = p.pt.indents.prev
+	default:
+		p.abort(fmt.Errorf("%%s: invalid indentation matcher: %%s", ind.pos, ind.val))
+		return nil, false
+	}
+	return nil, true
+}
+
+func (p *parser) parseLabeledExpr(lab *labeledExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLabeledExpr"))
+	}
+
+	start := p.pt
+	p.pushV()
+	val, ok := p.parseExpr(lab.expr)
+	p.popV()
+	if ok && lab.label != "" {
+		m := p.vstack[len(p.vstack)-1]
+		m[lab.label] = val
+		if lab.capture {
+			// the matched text is stored under a key that is not a valid
+			// label, for the back-references to the label
+			m["="+lab.label] = p.sliceFrom(start)
+		}
+		if lab.seen {
+			p.addSeen(lab.label, start.offset, p.pt.offset)
+		}
+		if lab.span {
+			m["@"+lab.label] = [2]position{start.position, p.pt.position}
+		}
+	}
+	return val, ok
+}
+
+func (p *parser) parseKeywordMatcher(kw *keywordMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseKeywordMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	rest := p.data[p.pt.offset:]
+	n := 0
+	for _, word := range p.keywords {
+		if p.memoCache != nil {
+			p.examine(p.pt.offset + len(word) + utf8.UTFMax)
+		}
+		if len(word) <= n || !bytes.HasPrefix(rest, []byte(word)) {
+			continue
+		}
+		rn, _ := utf8.DecodeRune(rest[len(word):])
+		if len(rest) > len(word) && (rn == '_' || unicode.IsLetter(rn) || unicode.IsDigit(rn)) {
+			continue
+		}
+		n = len(word)
+	}
+	if n == 0 {
+		return nil, false
+	}
+	start := p.pt
+	for p.pt.offset < start.offset+n {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitMatcher"))
+	}
+
+	start := p.pt
+	for _, want := range lit.val {
+		cur := p.pt.rn
+		if cur != want && !(lit.ignoreCase && foldEqual(cur, want)) {
+			p.setMaxSavePoint(string(p.sliceFrom(start)) + string(cur), lit.val)
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseLitSetMatcher(set *litSetMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitSetMatcher"))
+	}
+
+	start := p.pt
+	// offset of the next rune to match in each literal, -1 once the
+	// literal does not match
+	offs := make([]int, len(set.alts))
+	best := -1
+	var end savepoint
+	for live := true; live; {
+		live = false
+		for i, alt := range set.alts {
+			if best >= 0 && i >= best {
+				break
+			}
+			if offs[i] < 0 {
+				continue
+			}
+			if offs[i] == len(alt.val) {
+				// the literals that follow cannot be the first to match
+				best, end = i, p.pt
+				break
+			}
+			want, n := utf8.DecodeRuneInString(alt.val[offs[i]:])
+			if cur := p.pt.rn; cur == want || (alt.ignoreCase && foldEqual(cur, want)) {
+				offs[i] += n
+				live = true
+			} else {
+				offs[i] = -1
+			}
+		}
+		if live {
+			p.read()
+		}
+	}
+	if best < 0 {
+		// record the expected literals for the error message
+		p.restore(start)
+		for _, alt := range set.alts {
+			p.parseLitMatcher(alt)
+		}
+		return nil, false
+	}
+
+	p.pt = end
+	b := p.sliceFrom(start)
+	if set.parts == nil {
+		return b, true
+	}
+	vals := make([]interface{}, len(set.parts[best]))
+	for i, n := range set.parts[best] {
+		m := 0
+		for ; n > 0; n-- {
+			_, w := utf8.DecodeRune(b[m:])
+			m += w
+		}
+		vals[i] = b[:m]
+		b = b[m:]
+	}
+	return vals, true
+}
+
+// foldEqual returns true if rn is equal to the lowercase rune want under
+// simple Unicode case folding, e.g. 'É' and 'é', or 'Σ', 'ς' and 'σ'.
+func foldEqual(rn, want rune) bool {
+	if unicode.ToLower(rn) == want {
+		return true
+	}
+	for f := unicode.SimpleFold(rn); f != rn; f = unicode.SimpleFold(f) {
+		if f == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseLookbehindExpr(lb *lookbehindExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLookbehindExpr"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	before := p.data[:p.pt.offset]
+	switch m := lb.expr.(type) {
+	case *anyMatcher:
+		return nil, len(before) > 0
+	case *charClassMatcher:
+		rn, n := utf8.DecodeLastRune(before)
+		return nil, n > 0 && p.classMatcher(m).accepts(rn)
+	case *litMatcher:
+		want := []rune(m.val)
+		for i := len(want) - 1; i >= 0; i-- {
+			rn, n := utf8.DecodeLastRune(before)
+			if n == 0 || (rn != want[i] && !(m.ignoreCase && foldEqual(rn, want[i]))) {
+				return nil, false
+			}
+			before = before[:len(before)-n]
+		}
+		return nil, true
+	}
+	p.abort(fmt.Errorf("unknown lookbehind expression type %%T", lb.expr))
+	return nil, false
+}
+
+func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotCodeExpr"))
+	}
+
+	ok, err := not.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, !ok
+}
+
+func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotExpr"))
+	}
+
+	pt, end := p.pt, p.prefixEnd
+	p.pushV()
+	_, ok := p.parseExpr(not.expr)
+	p.popV()
+	p.restore(pt)
+	p.prefixEnd = end
+	return nil, !ok
+}
+
+// parseNestedMatcher matches the open delimiter of nest, then the input up
+// to the close delimiter that matches it, counting the nested open and
+// close delimiters. It fails if the input ends before the block.
+func (p *parser) parseNestedMatcher(nest *nestedMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNestedMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	if p.memoCache != nil {
+		p.examine(p.pt.offset + len(nest.open))
+	}
+	if !bytes.HasPrefix(p.data[p.pt.offset:], []byte(nest.open)) {
+		p.setMaxSavePoint(string(p.pt.rn), nest.open)
+		return nil, false
+	}
+	// find the end of the block in a single pass, then advance rune by
+	// rune up to it so that the position information stays accurate.
+	start := p.pt
+	end := -1
+	depth := 0
+	for off := start.offset; off < len(p.data); {
+		rest := p.data[off:]
+		switch {
+		case bytes.HasPrefix(rest, []byte(nest.close)) && depth > 0:
+			depth--
+			off += len(nest.close)
+			if depth == 0 {
+				end = off
+			}
+		case bytes.HasPrefix(rest, []byte(nest.open)):
+			depth++
+			off += len(nest.open)
+		default:
+			_, n := utf8.DecodeRune(rest)
+			off += n
+		}
+		if end >= 0 {
+			break
+		}
+	}
+	if end < 0 {
+		// report the missing close delimiter at the end of the input
+		for p.pt.offset < len(p.data) {
+			p.read()
+		}
+		p.setMaxSavePoint(string(p.pt.rn), nest.close)
+		p.restore(start)
+		return nil, false
+	}
+	if p.memoCache != nil {
+		p.examine(end + len(nest.open) + len(nest.close))
+	}
+	for p.pt.offset < end {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+// parseNumberMatcher matches the digits of a number in the radix of num,
+// or in the radix of its prefix, with the optional sign, thousands
+// separators, fraction and exponent allowed by num. Its value is an int64,
+// or a float64 for a float number.
+func (p *parser) parseNumberMatcher(num *numberMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNumberMatcher"))
+	}
+
+	start := p.pt
+	neg := false
+	if num.sign && (p.pt.rn == '-' || p.pt.rn == '+') {
+		neg = p.pt.rn == '-'
+		p.read()
+	}
+	radix := num.radix
+	if num.prefix {
+		radix = p.readRadixPrefix()
+	}
+	digits := p.pt
+	n := 0
+	if radix != 0 {
+		n = p.readDigits(radix)
+	}
+	if n == 0 {
+		p.setMaxSavePoint(string(p.sliceFrom(start))+string(p.pt.rn), "number")
+		p.restore(start)
+		return nil, false
+	}
+	if num.thousands != 0 && n <= 3 {
+		// groups of three digits after the separator
+		for p.pt.rn == num.thousands {
+			sep := p.pt
+			p.read()
+			if p.readDigits(10) != 3 {
+				p.restore(sep)
+				break
+			}
+		}
+	}
+	if !num.float {
+		n, ok := parseInt(removeRune(p.sliceFrom(digits), num.thousands), radix, neg)
+		if !ok {
+			p.addErrAt(errNumberRange, start.position)
+			p.restore(start)
+			return nil, false
+		}
+		return n, true
+	}
+
+	dec := num.decimal
+	if dec == 0 {
+		dec = '.'
+	}
+	if p.pt.rn == dec {
+		dot := p.pt
+		p.read()
+		if p.readDigits(10) == 0 {
+			p.restore(dot)
+		}
+	}
+	if p.pt.rn == 'e' || p.pt.rn == 'E' {
+		exp := p.pt
+		p.read()
+		if p.pt.rn == '-' || p.pt.rn == '+' {
+			p.read()
+		}
+		if p.readDigits(10) == 0 {
+			p.restore(exp)
+		}
 	}
-	m := p.memo[p.pt.offset]
-	if len(m) == 0 {
-		return resultTuple{}, false
+	text := string(removeRune(p.sliceFrom(start), num.thousands))
+	if dec != '.' {
+		text = strings.Replace(text, string(dec), ".", 1)
 	}
-	res, ok := m[node]
-	return res, ok
+	var f float64
+	if _, err := fmt.Sscan(text, &f); err != nil {
+		p.addErrAt(errNumberRange, start.position)
+		p.restore(start)
+		return nil, false
+	}
+	return f, true
 }
 
-func (p *parser) setMemoized(pt savepoint, node interface{}, tuple resultTuple) {
-	if p.memo == nil {
-		p.memo = make(map[int]map[interface{}]resultTuple)
+// readRadixPrefix reads the radix prefix "0x", "0b" or "0o" of a number at
+// the current position, in either case, and returns its radix. It returns
+// 0 and reads nothing if there is no prefix.
+func (p *parser) readRadixPrefix() int {
+	if p.pt.rn != '0' {
+		return 0
 	}
-	m := p.memo[pt.offset]
-	if m == nil {
-		m = make(map[interface{}]resultTuple)
-		p.memo[pt.offset] = m
+	start := p.pt
+	p.read()
+	var radix int
+	switch p.pt.rn {
+	case 'x', 'X':
+		radix = 16
+	case 'b', 'B':
+		radix = 2
+	case 'o', 'O':
+		radix = 8
+	default:
+		p.restore(start)
+		return 0
 	}
-	m[node] = tuple
+	p.read()
+	return radix
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// readDigits reads the digits of radix at the current position and
+// returns their number.
+func (p *parser) readDigits(radix int) int {
+	n := 0
+	for digitVal(p.pt.rn) < radix {
+		p.read()
+		n++
 	}
+	return n
 }
 
-func (p *parser) parse(g *grammar) (val interface{}, err error) {
-	if len(g.rules) == 0 {
-		p.addErr(errNoRule)
-		return nil, p.errs.err()
+// digitVal returns the value of the digit rn in a radix up to 36, or 36
+// if rn is not a digit.
+func digitVal(rn rune) int {
+	switch {
+	case '0' <= rn && rn <= '9':
+		return int(rn - '0')
+	case 'a' <= rn && rn <= 'z':
+		return int(rn-'a') + 10
+	case 'A' <= rn && rn <= 'Z':
+		return int(rn-'A') + 10
 	}
+	return 36
+}
 
-	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
-
-	if p.recover {
-		// panic can be used in action code to stop parsing immediately
-		// and return the panic as an error.
-		defer func() {
-			if e := recover(); e != nil {
-				if p.debug {
-					defer p.out(p.in("panic handler"))
-				}
-				val = nil
-				switch e := e.(type) {
-				case error:
-					p.addErr(e)
-				default:
-					p.addErr(fmt.Errorf("%%v", e))
-				}
-				err = p.errs.err()
-			}
-		}()
+// removeRune returns text without the occurrences of rn, text itself if
+// rn is 0.
+func removeRune(text []byte, rn rune) []byte {
+	if rn == 0 {
+		return text
 	}
+	return bytes.Replace(text, []byte(string(rn)), nil, -1)
+}
 
-	// start rule is rule [0]
-	p.read() // advance to first rune
-	val, ok := p.parseRule(g.rules[0])
-	if !ok {
-		if len(*p.errs) == 0 {
-			// make sure this doesn't go out silently
-			if len(p.maxExpected) > 0 {
-				expected := "'" + p.maxExpected[0] + "'"
-				for i := 1; i < len(p.maxExpected) && i < 5; i++ {
-					expected += ", '" + p.maxExpected[i] + "'"
-				}
-				if len(p.maxExpected) > 5 {
-					expected += fmt.Sprintf(", and %%d others", len(p.maxExpected) - 5) 
-				}
-
-				found := p.maxFound
-				if len(p.maxFound) == 0 {
-					found = string(p.maxSavePoint.rn)
-				}
-
-				p.addErrAt(fmt.Errorf("syntax error, unexpected '%%s', expecting %%s", found, expected), p.maxSavePoint.position)
-			} else {
-				p.addErr(errNoMatch)
-			}
+// parseInt returns the value of the digits of text in radix, negated if
+// neg is set, and false if it does not fit in an int64.
+func parseInt(text []byte, radix int, neg bool) (int64, bool) {
+	max := uint64(1<<63 - 1)
+	if neg {
+		max++
+	}
+	var n uint64
+	for _, c := range text {
+		d := uint64(digitVal(rune(c)))
+		if n > (max-d)/uint64(radix) {
+			return 0, false
 		}
-		return nil, p.errs.err()
+		n = n*uint64(radix) + d
 	}
-	return val, nil
+	if neg {
+		return -int64(n), true
+	}
+	return int64(n), true
 }
 
-func (p *parser) parseRule(rule *rule) (interface{}, bool) {
+func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseRule " + rule.name))
+		defer p.out(p.in("parseOneOrMoreExpr"))
 	}
 
-	if p.memoize {
-		res, ok := p.getMemoized(rule)
-		if ok {
-			p.restore(res.end)
-			return res.v, res.b
+	var vals []interface{}
+	var n int
+	stream := p.streamFunc(expr.expr)
+
+	for {
+		if !p.repeatWhile(expr.while, vals) {
+			if n == 0 {
+				return nil, false
+			}
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(expr.expr)
+		p.popV()
+		if !ok {
+			if n == 0 {
+				// did not match once, no match
+				return nil, false
+			}
+			return vals, true
+		}
+		if !p.collect(stream, &vals, val) {
+			return nil, false
+		}
+		if n++; p.maxRepeat > 0 && n > p.maxRepeat {
+			p.abort(errMaxRepeat)
+			return nil, false
 		}
 	}
+}
 
-	start := p.pt
-	p.rstack = append(p.rstack, rule)
-	p.pushV()
-	val, ok := p.parseExpr(rule.expr)
-	p.popV()
-	p.rstack = p.rstack[:len(p.rstack)-1]
-	if ok && p.debug {
-		p.print(strings.Repeat(" ", p.depth) + "MATCH", string(p.sliceFrom(start)))
+func (p *parser) parseOperatorsExpr(ops *operatorsExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseOperatorsExpr"))
 	}
 
-	if p.memoize {
-		p.setMemoized(start, rule, resultTuple{val, ok, p.pt})
-	}
-	return val, ok
+	return p.parseOperatorsPrec(ops, 0)
 }
 
-func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
-	var pt savepoint
-	var ok bool
+// parseOperatorsPrec parses operands separated by operators of precedence
+// minPrec or higher using precedence climbing. A binary operation has the
+// value []interface{}{left, op, right}, where op is the matched operator.
+func (p *parser) parseOperatorsPrec(ops *operatorsExpr, minPrec int) (interface{}, bool) {
+	left, ok := p.parseExpr(ops.operand)
+	if !ok {
+		return nil, false
+	}
 
-	if p.memoize {
-		res, ok := p.getMemoized(expr)
-		if ok {
-			p.restore(res.end)
-			return res.v, res.b
+	for {
+		pt := p.pt
+		var op *binaryOp
+		var opVal interface{}
+		for _, cur := range ops.ops {
+			if opVal, ok = p.parseLitMatcher(cur.lit); ok {
+				op = cur
+				break
+			}
+		}
+		if op == nil || op.prec < minPrec {
+			p.restore(pt)
+			return left, true
 		}
-		pt = p.pt
-	}
 
-	p.exprCnt++
-	var val interface{}
-	switch expr := expr.(type) {
-	case *actionExpr:
-		val, ok = p.parseActionExpr(expr)
-	case *andCodeExpr:
-		val, ok = p.parseAndCodeExpr(expr)
-	case *andExpr:
-		val, ok = p.parseAndExpr(expr)
-	case *anyMatcher:
-		val, ok = p.parseAnyMatcher(expr)
-	case *charClassMatcher:
-		val, ok = p.parseCharClassMatcher(expr)
-	case *choiceExpr:
-		val, ok = p.parseChoiceExpr(expr)
-	case *labeledExpr:
-		val, ok = p.parseLabeledExpr(expr)
-	case *litMatcher:
-		val, ok = p.parseLitMatcher(expr)
-	case *notCodeExpr:
-		val, ok = p.parseNotCodeExpr(expr)
-	case *notExpr:
-		val, ok = p.parseNotExpr(expr)
-	case *oneOrMoreExpr:
-		val, ok = p.parseOneOrMoreExpr(expr)
-	case *ruleRefExpr:
-		val, ok = p.parseRuleRefExpr(expr)
-	case *seqExpr:
-		val, ok = p.parseSeqExpr(expr)
-	case *zeroOrMoreExpr:
-		val, ok = p.parseZeroOrMoreExpr(expr)
-	case *zeroOrOneExpr:
-		val, ok = p.parseZeroOrOneExpr(expr)
-	default:
-		panic(fmt.Sprintf("unknown expression type %%T", expr))
-	}
-	if p.memoize {
-		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
+		nextPrec := op.prec + 1
+		if op.rightAssoc {
+			nextPrec = op.prec
+		}
+		right, ok := p.parseOperatorsPrec(ops, nextPrec)
+		if !ok {
+			// the operator is not followed by an operand, it is not
+			// part of this expression.
+			p.restore(pt)
+			return left, true
+		}
+		left = []interface{}{left, opVal, right}
 	}
-	return val, ok
 }
 
-func (p *parser) parseActionExpr(act *actionExpr) (interface{}, bool) {
+// parseRestOfLineMatcher matches the input up to, but not including, the
+// next "\n" or "\r\n", or up to the end of the input.
+func (p *parser) parseRestOfLineMatcher(rest *restOfLineMatcher) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseActionExpr"))
+		defer p.out(p.in("parseRestOfLineMatcher"))
 	}
 
+	if p.tokMode {
+		return nil, false
+	}
 	start := p.pt
-	val, ok := p.parseExpr(act.expr)
-	if ok {
-		p.cur.pos = start.position
-		p.cur.text = p.sliceFrom(start)
-		actVal, err := act.run(p)
-		if err != nil {
-			p.addErrAt(err, start.position)
-			ok = false
-		} else {
-			val = actVal
+	end := len(p.data)
+	if ix := bytes.IndexByte(p.data[start.offset:], '\n'); ix >= 0 {
+		if p.memoCache != nil {
+			p.examine(start.offset + ix + 1)
+		}
+		end = start.offset + ix
+		if end > start.offset && p.data[end-1] == '\r' {
+			end--
 		}
 	}
-	if ok && p.debug {
-		p.print(strings.Repeat(" ", p.depth) + "MATCH", string(p.sliceFrom(start)))
+	for p.pt.offset < end {
+		p.read()
 	}
-	return val, ok
+	return p.sliceFrom(start), true
 }
 
-func (p *parser) parseAndCodeExpr(and *andCodeExpr) (interface{}, bool) {
+func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseAndCodeExpr"))
+		defer p.out(p.in("parseRuleRefExpr " + ref.name))
 	}
 
-	ok, err := and.run(p)
-	if err != nil {
-		p.addErr(err)
+	if ref.name == "" {
+		p.abort(fmt.Errorf("%%s: invalid rule: missing name", ref.pos))
+		return nil, false
 	}
-	return nil, ok
+
+	rule := p.rules[ref.name]
+	if rule == nil {
+		p.addErr(fmt.Errorf("undefined rule: %%s", ref.name))
+		return nil, false
+	}
+	return p.parseRule(rule)
 }
 
-func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
+// parseSepExpr parses one or more expressions separated by the separator,
+// its value is the slice of the values of the expressions.
+func (p *parser) parseSepExpr(sep *sepExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseAndExpr"))
+		defer p.out(p.in("parseSepExpr"))
 	}
 
-	pt := p.pt
+	start := p.pt
 	p.pushV()
-	_, ok := p.parseExpr(and.expr)
+	val, ok := p.parseExpr(sep.expr)
 	p.popV()
-	p.restore(pt)
-	return nil, ok
+	if !ok {
+		return nil, false
+	}
+	vals := []interface{}{val}
+
+	// start of the last expression, which is not part of the list if the
+	// separator is a terminator that does not follow it
+	last := start
+	for {
+		pt := p.pt
+		p.pushV()
+		sepVal, ok := p.parseExpr(sep.sep)
+		p.popV()
+		if !ok {
+			if sep.terminated {
+				p.restore(last)
+				vals = vals[:len(vals)-1]
+				if len(vals) == 0 {
+					return nil, false
+				}
+			}
+			return vals, true
+		}
+		next := p.pt
+		p.pushV()
+		val, ok := p.parseExpr(sep.expr)
+		p.popV()
+		if !ok {
+			if !sep.trailing && !sep.terminated {
+				// the separator is not part of the list
+				p.restore(pt)
+			} else if sep.keep {
+				vals = append(vals, sepVal)
+			}
+			return vals, true
+		}
+		if sep.keep {
+			vals = append(vals, sepVal)
+		}
+		last = next
+		vals = append(vals, val)
+	}
 }
 
-func (p *parser) parseAnyMatcher(any *anyMatcher) (interface{}, bool) {
+func (p *parser) parseSeqExpr(seq *seqExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseAnyMatcher"))
+		defer p.out(p.in("parseSeqExpr"))
 	}
 
-	if p.pt.rn != utf8.RuneError {
-		start := p.pt
-		p.read()
-		return p.sliceFrom(start), true
+	vals := make([]interface{}, 0, len(seq.exprs))
+
+	pt := p.pt
+	for _, expr := range seq.exprs {
+		val, ok := p.parseExpr(expr)
+		if !ok {
+			p.restore(pt)
+			return nil, false
+		}
+		vals = append(vals, val)
 	}
-	return nil, false
+	return vals, true
 }
 
-func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (interface{}, bool) {
+func (p *parser) parseSkipExpr(skip *skipExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseCharClassMatcher"))
+		defer p.out(p.in("parseSkipExpr"))
 	}
 
-	cur := p.pt.rn
-	// can't match EOF
-	if cur == utf8.RuneError {
-		return nil, false
+	pt := p.pt
+	for {
+		if p.skipFunc != nil && !p.tokMode {
+			for p.pt.offset < len(p.data) && p.skipFunc(p.pt.rn) {
+				p.read()
+			}
+		} else {
+			p.parseExpr(skip.skip)
+		}
+		if !p.skipLineComment() {
+			break
+		}
 	}
-	start := p.pt
-	if chr.ignoreCase {
-		cur = unicode.ToLower(cur)
+	val, ok := p.parseExpr(skip.expr)
+	if !ok {
+		p.restore(pt)
+		return nil, false
 	}
+	return val, true
+}
 
-	// try to match in the list of available chars
-	for _, rn := range chr.chars {
-		if rn == cur {
-			if chr.inverted {
-				return nil, false
-			}
-			p.read()
-			return p.sliceFrom(start), true
-		}
+// parseTableMatcher matches a rune of the table of the Table option named
+// by tm.
+func (p *parser) parseTableMatcher(tm *tableMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseTableMatcher " + tm.name))
 	}
 
-	// try to match in the list of ranges
-	for i := 0; i < len(chr.ranges); i += 2 {
-		if cur >= chr.ranges[i] && cur <= chr.ranges[i+1] {
-			if chr.inverted {
-				return nil, false
-			}
-			p.read()
-			return p.sliceFrom(start), true
-		}
+	t := p.tables[tm.name]
+	if t == nil || p.atInvalidOrEOF() || !unicode.Is(t, p.pt.rn) {
+		return nil, false
 	}
+	start := p.pt
+	p.read()
+	return p.sliceFrom(start), true
+}
 
-	// try to match in the list of Unicode classes
-	for _, cl := range chr.classes {
-		if unicode.Is(cl, cur) {
-			if chr.inverted {
-				return nil, false
-			}
-			p.read()
-			return p.sliceFrom(start), true
-		}
+func (p *parser) parseTokenMatcher(tm *tokenMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseTokenMatcher " + tm.name))
 	}
 
-	if chr.inverted {
-		p.read()
-		return p.sliceFrom(start), true
+	if !p.tokMode || p.pt.offset >= len(p.toks) {
+		p.setMaxSavePoint("", tm.name)
+		return nil, false
 	}
-	return nil, false
+	tok := p.toks[p.pt.offset]
+	if !tm.any && tok.Kind() != tm.kind {
+		p.setMaxSavePoint(tok.Text(), tm.name)
+		return nil, false
+	}
+	p.read()
+	return tok, true
 }
 
-func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
+// parseCompactExpr matches the expression of comp, and removes the nil
+// values from its value if it is a slice.
+func (p *parser) parseCompactExpr(comp *compactExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseChoiceExpr"))
+		defer p.out(p.in("parseCompactExpr"))
 	}
 
-	for _, alt := range ch.alternatives {
-		p.pushV()
-		val, ok := p.parseExpr(alt)
-		p.popV()
-		if ok {
-			return val, ok
+	val, ok := p.parseExpr(comp.expr)
+	if !ok {
+		return nil, false
+	}
+	vals, isSlice := val.([]interface{})
+	if !isSlice {
+		return val, true
+	}
+	compact := make([]interface{}, 0, len(vals))
+	for _, v := range vals {
+		if v != nil {
+			compact = append(compact, v)
 		}
 	}
-	return nil, false
+	return compact, true
 }
 
-func (p *parser) parseLabeledExpr(lab *labeledExpr) (interface{}, bool) {
+// parseTrimExpr matches the expression of trim, its value is the text of
+// the match without its leading and trailing whitespace.
+func (p *parser) parseTrimExpr(trim *trimExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseLabeledExpr"))
+		defer p.out(p.in("parseTrimExpr"))
 	}
 
-	p.pushV()
-	val, ok := p.parseExpr(lab.expr)
-	p.popV()
-	if ok && lab.label != "" {
-		m := p.vstack[len(p.vstack)-1]
-		m[lab.label] = val
+	start := p.pt
+	if _, ok := p.parseExpr(trim.expr); !ok {
+		return nil, false
 	}
-	return val, ok
+	return strings.TrimSpace(string(p.sliceFrom(start))), true
 }
 
-func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
+// builtinConverters are the converters of the convert expressions that
+// the Converter option does not replace.
+var builtinConverters = map[string]func(string) (interface{}, error){
+	"int": func(s string) (interface{}, error) {
+		var n int
+		err := scanText(s, &n)
+		return n, err
+	},
+	"float": func(s string) (interface{}, error) {
+		var f float64
+		err := scanText(s, &f)
+		return f, err
+	},
+	"bool": func(s string) (interface{}, error) {
+		var b bool
+		err := scanText(s, &b)
+		return b, err
+	},
+}
+
+// scanText scans the value pointed to by v from s, that must have nothing
+// else than the value.
+func scanText(s string, v interface{}) error {
+	var rest string
+	switch n, err := fmt.Sscan(s, v, &rest); n {
+	case 0:
+		return fmt.Errorf("invalid value %%q: %%v", s, err)
+	case 2:
+		return fmt.Errorf("invalid value %%q", s)
+	}
+	return nil
+}
+
+// parseConvertExpr matches the expression of conv, its value is the text
+// of the match converted by the converter of conv. The expression fails
+// with an error if the converter does not exist or returns an error.
+func (p *parser) parseConvertExpr(conv *convertExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseLitMatcher"))
+		defer p.out(p.in("parseConvertExpr " + conv.name))
 	}
 
 	start := p.pt
-	for _, want := range lit.val {
-		cur := p.pt.rn
-		if lit.ignoreCase {
-			cur = unicode.ToLower(cur)
+	if _, ok := p.parseExpr(conv.expr); !ok {
+		return nil, false
+	}
+	fn := p.converters[conv.name]
+	if fn == nil {
+		fn = builtinConverters[conv.name]
+	}
+	if fn == nil {
+		p.addErrAt(fmt.Errorf("undefined converter %%s", conv.name), start.position)
+		p.restore(start)
+		return nil, false
+	}
+	val, err := fn(string(p.sliceFrom(start)))
+	if err != nil {
+		p.addErrAt(err, start.position)
+		p.restore(start)
+		return nil, false
+	}
+	return val, true
+}
+
+// parseWordListMatcher matches the longest of the words of the WordList
+// option at the current position, following the trie of the words.
+func (p *parser) parseWordListMatcher(wl *wordListMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseWordListMatcher"))
+	}
+
+	if p.tokMode || p.wordTrie == nil {
+		return nil, false
+	}
+	rest := p.data[p.pt.offset:]
+	n := 0
+	node := p.wordTrie
+	for i := 0; i < len(rest) && node != nil; i++ {
+		if p.memoCache != nil {
+			p.examine(p.pt.offset + i + 1 + utf8.UTFMax)
 		}
-		if cur != want {
-			p.setMaxSavePoint(string(p.sliceFrom(start)) + string(cur), lit.val)
-			p.restore(start)
-			return nil, false
+		node = node.next[rest[i]]
+		if node == nil || !node.word {
+			continue
 		}
+		rn, _ := utf8.DecodeRune(rest[i+1:])
+		if i+1 < len(rest) && (rn == '_' || unicode.IsLetter(rn) || unicode.IsDigit(rn)) {
+			continue
+		}
+		n = i + 1
+	}
+	if n == 0 {
+		return nil, false
+	}
+	start := p.pt
+	for p.pt.offset < start.offset+n {
 		p.read()
 	}
 	return p.sliceFrom(start), true
 }
 
-func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
+// parseArrayExpr matches the elements of arr with its generated collect
+// function, that stores their values directly in an array of its type and
+// returns the array, or the value of the element that is not of that type.
+func (p *parser) parseArrayExpr(arr *arrayExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseNotCodeExpr"))
+		defer p.out(p.in("parseArrayExpr"))
 	}
 
-	ok, err := not.run(p)
-	if err != nil {
-		p.addErr(err)
+	start := p.pt
+	val, bad, ok := arr.collect(p, arr)
+	if bad != nil {
+		p.addErrAt(fmt.Errorf("array element of type %%T is not assignable to %%s", bad, arr.typ), start.position)
 	}
-	return nil, !ok
-}
-
-func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
-	if p.debug {
-		defer p.out(p.in("parseNotExpr"))
+	if !ok {
+		p.restore(start)
+		return nil, false
 	}
+	return val, true
+}
 
-	pt := p.pt
+// parseArrayElem matches the expression of an element of arr.
+func (p *parser) parseArrayElem(arr *arrayExpr) (interface{}, bool) {
 	p.pushV()
-	_, ok := p.parseExpr(not.expr)
+	val, ok := p.parseExpr(arr.expr)
 	p.popV()
-	p.restore(pt)
-	return nil, !ok
+	return val, ok
 }
 
-func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (interface{}, bool) {
+// parseMapExpr matches the expression of m zero or more times, its value is
+// a map of the values of the value label of the matches keyed by the text
+// of their key label. It fails on a duplicate key, unless the value of the
+// last match is kept.
+func (p *parser) parseMapExpr(m *mapExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseOneOrMoreExpr"))
+		defer p.out(p.in("parseMapExpr"))
 	}
 
-	var vals []interface{}
-
+	start := p.pt
+	vals := make(map[string]interface{})
 	for {
+		pt := p.pt
 		p.pushV()
-		val, ok := p.parseExpr(expr.expr)
+		_, ok := p.parseExpr(m.expr)
+		labels := p.vstack[len(p.vstack)-1]
+		key, _ := labels["="+m.key].([]byte)
+		val := labels[m.val]
 		p.popV()
 		if !ok {
-			if len(vals) == 0 {
-				// did not match once, no match
-				return nil, false
-			}
 			return vals, true
 		}
-		vals = append(vals, val)
+		if _, dup := vals[string(key)]; dup && !m.last {
+			p.addErrAt(fmt.Errorf("duplicate key %%q", key), pt.position)
+			p.restore(start)
+			return nil, false
+		}
+		vals[string(key)] = val
 	}
 }
 
-func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (interface{}, bool) {
+// parseUnreservedExpr matches the expression of un, and fails if the text
+// of the match is one of the keywords.
+func (p *parser) parseUnreservedExpr(un *unreservedExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+		defer p.out(p.in("parseUnreservedExpr"))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%%s: invalid rule: missing name", ref.pos))
+	start := p.pt
+	val, ok := p.parseExpr(un.expr)
+	if !ok {
+		return nil, false
+	}
+	text := string(p.sliceFrom(start))
+	for _, word := range p.keywords {
+		if text == word {
+			p.restore(start)
+			return nil, false
+		}
 	}
+	return val, true
+}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %%s", ref.name))
+// parseUntilMatcher matches the input up to, but not including, the first
+// occurrence of the delimiter of until. If the delimiter is not found, it
+// matches up to the end of the input, so it only fails in token mode, and
+// a missing delimiter is reported by the expression that follows it, if
+// any.
+func (p *parser) parseUntilMatcher(until *untilMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseUntilMatcher"))
+	}
+
+	if p.tokMode {
 		return nil, false
 	}
-	return p.parseRule(rule)
+	// scan for the delimiter in a single pass, then advance rune by rune
+	// up to it so that the position information stays accurate.
+	start := p.pt
+	end := len(p.data)
+	if ix := bytes.Index(p.data[start.offset:], []byte(until.val)); ix >= 0 {
+		end = start.offset + ix
+		if p.memoCache != nil {
+			p.examine(end + len(until.val))
+		}
+	}
+	for p.pt.offset < end {
+		p.read()
+	}
+	return p.sliceFrom(start), true
 }
 
-func (p *parser) parseSeqExpr(seq *seqExpr) (interface{}, bool) {
+// parseWhenExpr matches the expression of when if its flag is set, and
+// fails otherwise.
+func (p *parser) parseWhenExpr(when *whenExpr) (interface{}, bool) {
 	if p.debug {
-		defer p.out(p.in("parseSeqExpr"))
+		defer p.out(p.in("parseWhenExpr"))
 	}
 
-	var vals []interface{}
-
-	pt := p.pt
-	for _, expr := range seq.exprs {
-		val, ok := p.parseExpr(expr)
-		if !ok {
-			p.restore(pt)
-			return nil, false
-		}
-		vals = append(vals, val)
+	if !p.flags[when.flag] {
+		return nil, false
 	}
-	return vals, true
+	return p.parseExpr(when.expr)
 }
 
 func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (interface{}, bool) {
@@ -866,16 +5322,67 @@ func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (interface{}, bool) {
 	}
 
 	var vals []interface{}
+	var n int
+	stream := p.streamFunc(expr.expr)
 
 	for {
+		if !p.repeatWhile(expr.while, vals) {
+			return vals, true
+		}
 		p.pushV()
 		val, ok := p.parseExpr(expr.expr)
 		p.popV()
 		if !ok {
 			return vals, true
 		}
-		vals = append(vals, val)
+		if !p.collect(stream, &vals, val) {
+			return nil, false
+		}
+		if n++; p.maxRepeat > 0 && n > p.maxRepeat {
+			p.abort(errMaxRepeat)
+			return nil, false
+		}
+	}
+}
+
+// streamFunc returns the function of the Stream option that receives the
+// values of a repetition of expr, or nil if they are collected.
+func (p *parser) streamFunc(expr interface{}) func(interface{}) error {
+	if len(p.streams) == 0 {
+		return nil
+	}
+	ref, ok := expr.(*ruleRefExpr)
+	if !ok {
+		return nil
+	}
+	return p.streams[ref.name]
+}
+
+// collect appends the value of a match of a repetition to vals, or passes
+// it to stream if it is not nil. It returns false if stream fails.
+func (p *parser) collect(stream func(interface{}) error, vals *[]interface{}, val interface{}) bool {
+	if stream == nil {
+		*vals = append(*vals, val)
+		return true
+	}
+	if err := stream(val); err != nil {
+		p.addErr(err)
+		return false
+	}
+	return true
+}
+
+// repeatWhile reports whether a repetition may try another match, given
+// its condition and the values accumulated so far.
+func (p *parser) repeatWhile(while func(*parser, []interface{}) (bool, error), vals []interface{}) bool {
+	if while == nil {
+		return true
+	}
+	ok, err := while(p, vals)
+	if err != nil {
+		p.addErr(err)
 	}
+	return ok
 }
 
 func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (interface{}, bool) {
@@ -884,8 +5391,17 @@ func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (interface{}, bool) {
 	}
 
 	p.pushV()
-	val, _ := p.parseExpr(expr.expr)
+	val, ok := p.parseExpr(expr.expr)
 	p.popV()
+	if !ok && expr.dflt != nil {
+		// the default value fails the match with its error, like an
+		// action
+		var err error
+		if val, err = expr.dflt(p); err != nil {
+			p.addErr(err)
+			return nil, false
+		}
+	}
 	// whether it matched or not, consider it a match
 	return val, true
 }
@@ -901,7 +5417,239 @@ func rangeTable(class string) *unicode.RangeTable {
 		return rt
 	}
 
-	// cannot happen
-	panic(fmt.Sprintf("invalid Unicode class: %%s", class))
+	// cannot happen, the builder only writes the valid classes
+	return nil
+}
+`
+
+// panicCode is the panic handler of the Recover and PanicContext options.
+var panicCode = `
+// handlePanic is deferred by the parse with the Recover or the
+// PanicContext option. With Recover, a panic, e.g. in action code to stop
+// parsing immediately, is returned as an error.
+func (p *parser) handlePanic(val *interface{}, err *error) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	if p.recover {
+		if p.debug {
+			defer p.out(p.in("panic handler"))
+		}
+		*val = nil
+		switch e := e.(type) {
+		case error:
+			p.addErr(e)
+		default:
+			p.addErr(fmt.Errorf("%v", e))
+		}
+		*err = p.errs.err()
+		return
+	}
+	var name string
+	if len(p.rstack) > 0 {
+		name = p.rstack[len(p.rstack)-1].name
+	}
+	panic(&RulePanic{Rule: name, Pos: p.exportPos(p.pt.position), Value: e})
+}
+`
+
+// noPanicCode replaces panicCode with the NoPanic option.
+var noPanicCode = `
+// handlePanic does nothing, the parser is generated without panic
+// handler.
+func (p *parser) handlePanic(val *interface{}, err *error) {}
+`
+
+// stateCode is the state struct of a grammar with a @state block, with
+// the fields of the block.
+var stateCode = `
+// parseState is the state declared by the @state block of the grammar,
+// shared by the code blocks of a parse as c.state. Like the fields of the
+// current type, it is not restored when the parser backtracks.
+type parseState struct {
+%s}
+
+// WithState creates an Option to set the state of the parse to s, so that
+// the code blocks start from the values of s, and the caller gets the
+// values at the end of the parse.
+//
+// The default is a new zero state for each parse.
+func WithState(s *parseState) Option {
+	return func(p *parser) Option {
+		old := p.cur.state
+		p.cur.state = s
+		return WithState(old)
+	}
+}
+`
+
+// noStateCode is the state struct of a grammar without a @state block.
+var noStateCode = `
+// parseState is empty, the grammar has no @state block.
+type parseState struct{}
+`
+
+// mainCode is the main function written with the EmitMain option.
+var mainCode = `
+// main parses the file named by the first argument, or the standard input
+// if there is none, and prints the value of the parse to the standard
+// output, or its errors to the standard error with an exit status of 1.
+func main() {
+	var val interface{}
+	var err error
+	if len(os.Args) > 1 {
+		val, err = ParseFile(os.Args[1])
+	} else {
+		val, err = ParseReader("stdin", os.Stdin)
+	}
+	if err != nil {
+		errs, ok := err.(errList)
+		if !ok {
+			errs = errList{err}
+		}
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		os.Exit(1)
+	}
+	fmt.Println(val)
+}
+`
+
+// serveParseCode is the code of the HTTP handler written with the
+// EmitHTTPHandler option.
+var serveParseCode = `
+// parseResponse is the JSON body of the responses of ServeParse.
+type parseResponse struct {
+	Result interface{}  ` + "`json:\"result,omitempty\"`" + `
+	Errors []parseIssue ` + "`json:\"errors,omitempty\"`" + `
+}
+
+// parseIssue is an error of the parse in the responses of ServeParse. The
+// position is the zero value if the error is not at a position of the
+// input.
+type parseIssue struct {
+	Line    int    ` + "`json:\"line\"`" + `
+	Col     int    ` + "`json:\"col\"`" + `
+	Offset  int    ` + "`json:\"offset\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// ServeParse is an HTTP handler that parses the body of a POST request and
+// writes the result as a JSON object, with the value of the parse in its
+// "result" field, or an "errors" field that lists the errors with their
+// line, col, offset and message if the input is invalid, with a status of
+// 422. The value of the parse must be encodable as JSON.
+func ServeParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp parseResponse
+	status := http.StatusOK
+	val, err := Parse("request", b)
+	if err != nil {
+		status = http.StatusUnprocessableEntity
+		errs, ok := err.(errList)
+		if !ok {
+			errs = errList{err}
+		}
+		for _, e := range errs {
+			issue := parseIssue{Message: e.Error()}
+			if pe, ok := e.(*parserError); ok {
+				issue.Line, issue.Col, issue.Offset = pe.pos.line, pe.pos.col, pe.pos.offset
+				issue.Message = pe.Inner.Error()
+			}
+			resp.Errors = append(resp.Errors, issue)
+		}
+	} else {
+		resp.Result = val
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+`
+
+// tokenizeCode is the code of the tokenizer written with the EmitLexer
+// option, after the lexicalRules variable that lists its rules.
+var tokenizeCode = `
+// Lexeme is a token of the input returned by Tokenize. Kind is the name of
+// the lexical rule that matched it.
+type Lexeme struct {
+	Kind string
+	Text string
+	Pos  Pos
+}
+
+// Tokenize splits the data from b into the tokens matched by the lexical
+// rules of the grammar, skipping the whitespace between them, or the runes
+// of the SkipFunc option if it is set. At each position, the lexical rules
+// are tried in the order of the grammar and the first one that matches a
+// non-empty text produces the token. The tokens up to the first position
+// where no lexical rule matches are returned with the error.
+func Tokenize(filename string, b []byte, opts ...Option) ([]Lexeme, error) {
+	p := newParser(filename, b, opts...)
+	return p.tokenize(g)
+}
+
+func (p *parser) tokenize(g *grammar) ([]Lexeme, error) {
+	p.buildRulesTable(g)
+	if err := p.decodeInput(); err != nil {
+		p.addErr(err)
+		return nil, p.errs.err()
+	}
+	rules := make([]*rule, 0, len(lexicalRules))
+	for _, name := range lexicalRules {
+		rules = append(rules, p.rules[name])
+	}
+	skip := p.skipFunc
+	if skip == nil {
+		skip = unicode.IsSpace
+	}
+
+	var toks []Lexeme
+	p.read() // advance to first rune
+	for {
+		for {
+			for !p.atInvalidOrEOF() && skip(p.pt.rn) {
+				p.read()
+			}
+			if !p.skipLineComment() {
+				break
+			}
+		}
+		if p.pt.offset >= len(p.data) {
+			return toks, nil
+		}
+		start := p.pt
+		var kind string
+		for _, r := range rules {
+			if _, ok := p.parseRule(r); ok && p.pt.offset > start.offset {
+				kind = r.name
+				break
+			}
+			p.restore(start)
+		}
+		if kind == "" {
+			p.addErrAt(errNoMatch, start.position)
+			return toks, p.errs.err()
+		}
+		toks = append(toks, Lexeme{Kind: kind, Text: string(p.sliceFrom(start)), Pos: p.exportPos(start.position)})
+	}
 }
 `
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -19,6 +20,39 @@ func compareGrammars(t *testing.T, src string, exp, got *ast.Grammar) bool {
 		}
 	}
 
+	if (exp.Fields != nil) != (got.Fields != nil) {
+		t.Errorf("%q: want Fields? %t, got %t", src, exp.Fields != nil, got.Fields != nil)
+		return false
+	}
+	if exp.Fields != nil {
+		if exp.Fields.Val != got.Fields.Val {
+			t.Errorf("%q: want Fields %q, got %q", src, exp.Fields.Val, got.Fields.Val)
+			return false
+		}
+	}
+
+	if (exp.State != nil) != (got.State != nil) {
+		t.Errorf("%q: want State? %t, got %t", src, exp.State != nil, got.State != nil)
+		return false
+	}
+	if exp.State != nil {
+		if exp.State.Val != got.State.Val {
+			t.Errorf("%q: want State %q, got %q", src, exp.State.Val, got.State.Val)
+			return false
+		}
+	}
+
+	if len(exp.Examples) != len(got.Examples) {
+		t.Errorf("%q: want %d examples, got %d", src, len(exp.Examples), len(got.Examples))
+		return false
+	}
+	for i, ex := range exp.Examples {
+		if ex.Val != got.Examples[i].Val {
+			t.Errorf("%q: want example %d %s, got %s", src, i, ex.Val, got.Examples[i].Val)
+			return false
+		}
+	}
+
 	rn, rm := len(exp.Rules), len(got.Rules)
 	if rn != rm {
 		t.Errorf("%q: want %d rules, got %d", src, rn, rm)
@@ -49,6 +83,48 @@ func compareRule(t *testing.T, prefix string, exp, got *ast.Rule) bool {
 			return false
 		}
 	}
+	if (exp.Cond != nil) != (got.Cond != nil) {
+		t.Errorf("%q: want Cond? %t, got %t", prefix, exp.Cond != nil, got.Cond != nil)
+		return false
+	}
+	if exp.Cond != nil {
+		if exp.Cond.Val != got.Cond.Val {
+			t.Errorf("%q: want Cond %q, got %q", prefix, exp.Cond.Val, got.Cond.Val)
+			return false
+		}
+	}
+	if exp.Entry != got.Entry {
+		t.Errorf("%q: want Entry %t, got %t", prefix, exp.Entry, got.Entry)
+		return false
+	}
+	if exp.Inline != got.Inline {
+		t.Errorf("%q: want Inline %t, got %t", prefix, exp.Inline, got.Inline)
+		return false
+	}
+	if exp.Lexical != got.Lexical {
+		t.Errorf("%q: want Lexical %t, got %t", prefix, exp.Lexical, got.Lexical)
+		return false
+	}
+	if exp.Silent != got.Silent {
+		t.Errorf("%q: want Silent %t, got %t", prefix, exp.Silent, got.Silent)
+		return false
+	}
+	if exp.NLSignificant != got.NLSignificant {
+		t.Errorf("%q: want NLSignificant %t, got %t", prefix, exp.NLSignificant, got.NLSignificant)
+		return false
+	}
+	if exp.Type != got.Type {
+		t.Errorf("%q: want Type %q, got %q", prefix, exp.Type, got.Type)
+		return false
+	}
+	if exp.Budget != got.Budget {
+		t.Errorf("%q: want Budget %d, got %d", prefix, exp.Budget, got.Budget)
+		return false
+	}
+	if !reflect.DeepEqual(exp.Meta, got.Meta) {
+		t.Errorf("%q: want Meta %v, got %v", prefix, exp.Meta, got.Meta)
+		return false
+	}
 	return compareExpr(t, prefix, 0, exp.Expr, got.Expr)
 }
 
@@ -97,6 +173,10 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
 			return false
 		}
+		if exp.Peek != got.Peek {
+			t.Errorf("%q: want Peek %t, got %t", ixPrefix, exp.Peek, got.Peek)
+			return false
+		}
 		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
 
 	case *ast.AnyMatcher:
@@ -172,6 +252,10 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			t.Errorf("%q: want %d Alternatives, got %d", ixPrefix, ne, ng)
 			return false
 		}
+		if exp.Longest != got.Longest {
+			t.Errorf("%q: want Longest %t, got %t", ixPrefix, exp.Longest, got.Longest)
+			return false
+		}
 
 		for i, alt := range exp.Alternatives {
 			if !compareExpr(t, prefix, ix+1, alt, got.Alternatives[i]) {
@@ -179,6 +263,123 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			}
 		}
 
+	case *ast.IfExpr:
+		got, ok := got.(*ast.IfExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Cond.Val != got.Cond.Val {
+			t.Errorf("%q: want Cond %q, got %q", ixPrefix, exp.Cond.Val, got.Cond.Val)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.IndentMatcher:
+		got, ok := got.(*ast.IndentMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Val != got.Val {
+			t.Errorf("%q: want value %q, got %q", ixPrefix, exp.Val, got.Val)
+			return false
+		}
+
+	case *ast.ByteMatcher:
+		got, ok := got.(*ast.ByteMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Val != got.Val {
+			t.Errorf("%q: want value %#02x, got %#02x", ixPrefix, exp.Val, got.Val)
+			return false
+		}
+
+	case *ast.BytesMatcher:
+		got, ok := got.(*ast.BytesMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.N != got.N {
+			t.Errorf("%q: want N %d, got %d", ixPrefix, exp.N, got.N)
+			return false
+		}
+		if (exp.Label == nil) != (got.Label == nil) || exp.Label != nil && exp.Label.Val != got.Label.Val {
+			t.Errorf("%q: want Label %v, got %v", ixPrefix, exp.Label, got.Label)
+			return false
+		}
+
+	case *ast.NestedMatcher:
+		got, ok := got.(*ast.NestedMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Open != got.Open || exp.Close != got.Close {
+			t.Errorf("%q: want Open %q, Close %q, got %q, %q", ixPrefix, exp.Open, exp.Close, got.Open, got.Close)
+			return false
+		}
+
+	case *ast.NumberMatcher:
+		got, ok := got.(*ast.NumberMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Float != got.Float || exp.Sign != got.Sign || exp.Prefix != got.Prefix || exp.Radix != got.Radix {
+			t.Errorf("%q: want Float %t, Sign %t, Prefix %t, Radix %d, got %t, %t, %t, %d", ixPrefix,
+				exp.Float, exp.Sign, exp.Prefix, exp.Radix, got.Float, got.Sign, got.Prefix, got.Radix)
+			return false
+		}
+		if exp.Thousands != got.Thousands || exp.Decimal != got.Decimal {
+			t.Errorf("%q: want Thousands %q, Decimal %q, got %q, %q", ixPrefix,
+				exp.Thousands, exp.Decimal, got.Thousands, got.Decimal)
+			return false
+		}
+
+	case *ast.TokenMatcher:
+		got, ok := got.(*ast.TokenMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Val != got.Val {
+			t.Errorf("%q: want value %q, got %q", ixPrefix, exp.Val, got.Val)
+			return false
+		}
+
+	case *ast.KeywordMatcher:
+		if _, ok := got.(*ast.KeywordMatcher); !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+
+	case *ast.WordListMatcher:
+		if _, ok := got.(*ast.WordListMatcher); !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+
+	case *ast.TableMatcher:
+		got, ok := got.(*ast.TableMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Val != got.Val {
+			t.Errorf("%q: want value %q, got %q", ixPrefix, exp.Val, got.Val)
+			return false
+		}
+
+	case *ast.RestOfLineMatcher:
+		if _, ok := got.(*ast.RestOfLineMatcher); !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+
 	case *ast.LabeledExpr:
 		got, ok := got.(*ast.LabeledExpr)
 		if !ok {
@@ -213,6 +414,14 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			return false
 		}
 
+	case *ast.LookbehindExpr:
+		got, ok := got.(*ast.LookbehindExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
 	case *ast.NotCodeExpr:
 		got, ok := got.(*ast.NotCodeExpr)
 		if !ok {
@@ -244,8 +453,165 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
 			return false
 		}
+		if (exp.While != nil) != (got.While != nil) {
+			t.Errorf("%q: want While?: %t, got %t", ixPrefix, exp.While != nil, got.While != nil)
+			return false
+		}
+		if exp.While != nil && !compareExpr(t, prefix, ix+1, exp.While, got.While) {
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.BackRefExpr:
+		got, ok := got.(*ast.BackRefExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Label.Val != got.Label.Val {
+			t.Errorf("%q: want label %q, got %q", ixPrefix, exp.Label.Val, got.Label.Val)
+			return false
+		}
+
+	case *ast.UnreservedExpr:
+		got, ok := got.(*ast.UnreservedExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.VerbatimExpr:
+		got, ok := got.(*ast.VerbatimExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.CompactExpr:
+		got, ok := got.(*ast.CompactExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.SeenExpr:
+		got, ok := got.(*ast.SeenExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Label.Val != got.Label.Val {
+			t.Errorf("%q: want label %q, got %q", ixPrefix, exp.Label.Val, got.Label.Val)
+			return false
+		}
+
+	case *ast.TrimExpr:
+		got, ok := got.(*ast.TrimExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.ConvertExpr:
+		got, ok := got.(*ast.ConvertExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Name.Val != got.Name.Val {
+			t.Errorf("%q: want converter %q, got %q", ixPrefix, exp.Name.Val, got.Name.Val)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.MapExpr:
+		got, ok := got.(*ast.MapExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Key.Val != got.Key.Val || exp.Val.Val != got.Val.Val || exp.Last != got.Last {
+			t.Errorf("%q: want Key %q, Val %q, Last %t, got %q, %q, %t", ixPrefix, exp.Key.Val, exp.Val.Val, exp.Last, got.Key.Val, got.Val.Val, got.Last)
+			return false
+		}
 		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
 
+	case *ast.ArrayExpr:
+		got, ok := got.(*ast.ArrayExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.N != got.N || exp.Type != got.Type {
+			t.Errorf("%q: want N %d, Type %q, got %d, %q", ixPrefix, exp.N, exp.Type, got.N, got.Type)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.SepExpr:
+		got, ok := got.(*ast.SepExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Trailing != got.Trailing {
+			t.Errorf("%q: want Trailing %t, got %t", ixPrefix, exp.Trailing, got.Trailing)
+			return false
+		}
+		if exp.Terminated != got.Terminated {
+			t.Errorf("%q: want Terminated %t, got %t", ixPrefix, exp.Terminated, got.Terminated)
+			return false
+		}
+		if exp.Keep != got.Keep {
+			t.Errorf("%q: want Keep %t, got %t", ixPrefix, exp.Keep, got.Keep)
+			return false
+		}
+		if !compareExpr(t, prefix, ix+1, exp.Expr, got.Expr) {
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Sep, got.Sep)
+
+	case *ast.FoldExpr:
+		got, ok := got.(*ast.FoldExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Right != got.Right {
+			t.Errorf("%q: want Right %t, got %t", ixPrefix, exp.Right, got.Right)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
+	case *ast.OperatorsExpr:
+		got, ok := got.(*ast.OperatorsExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if !compareExpr(t, prefix, ix+1, exp.Operand, got.Operand) {
+			return false
+		}
+		if len(exp.Operators) != len(got.Operators) {
+			t.Errorf("%q: want %d operators, got %d", ixPrefix, len(exp.Operators), len(got.Operators))
+			return false
+		}
+		for i, op := range exp.Operators {
+			gop := got.Operators[i]
+			if op.Prec != gop.Prec || op.RightAssoc != gop.RightAssoc {
+				t.Errorf("%q: operator %d: want precedence %d, right %t, got %d, %t",
+					ixPrefix, i, op.Prec, op.RightAssoc, gop.Prec, gop.RightAssoc)
+				return false
+			}
+			if !compareExpr(t, prefix, ix+1, op.Lit, gop.Lit) {
+				return false
+			}
+		}
+
 	case *ast.RuleRefExpr:
 		got, ok := got.(*ast.RuleRefExpr)
 		if !ok {
@@ -281,12 +647,42 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			}
 		}
 
+	case *ast.UntilMatcher:
+		got, ok := got.(*ast.UntilMatcher)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Val != got.Val {
+			t.Errorf("%q: want value %q, got %q", ixPrefix, exp.Val, got.Val)
+			return false
+		}
+
+	case *ast.WhenExpr:
+		got, ok := got.(*ast.WhenExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Flag.Val != got.Flag.Val {
+			t.Errorf("%q: want Flag %q, got %q", ixPrefix, exp.Flag.Val, got.Flag.Val)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
 	case *ast.ZeroOrMoreExpr:
 		got, ok := got.(*ast.ZeroOrMoreExpr)
 		if !ok {
 			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
 			return false
 		}
+		if (exp.While != nil) != (got.While != nil) {
+			t.Errorf("%q: want While?: %t, got %t", ixPrefix, exp.While != nil, got.While != nil)
+			return false
+		}
+		if exp.While != nil && !compareExpr(t, prefix, ix+1, exp.While, got.While) {
+			return false
+		}
 		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
 
 	case *ast.ZeroOrOneExpr:
@@ -295,6 +691,14 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
 			return false
 		}
+		if (exp.Default != nil) != (got.Default != nil) {
+			t.Errorf("%q: want Default?: %t, got %t", ixPrefix, exp.Default != nil, got.Default != nil)
+			return false
+		}
+		if exp.Default != nil && exp.Default.Val != got.Default.Val {
+			t.Errorf("%q: want default code %q, got %q", ixPrefix, exp.Default.Val, got.Default.Val)
+			return false
+		}
 		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
 
 	default:
@@ -33,8 +33,109 @@ The following options can be specified:
 	pathological cases. Can make the parsing slower for typical
 	cases and uses more memory (default: false).
 
+	-capture-spans : boolean, if set, the generated parser records the start
+	and end positions of the match of each labeled expression, returned by
+	the span method of the current struct, see "Code block"
+	(default: false).
+
+	-check-labels : boolean, if set, report the identifiers used in the
+	code blocks that are not declared, e.g. a label that is not in the scope
+	of the code block, as an error with their position in the grammar
+	instead of a compilation error of the generated code. The identifiers
+	declared in other files of the package are reported too, so it is only
+	useful for grammars whose code blocks are self-contained (default: false).
+
+	-comments : boolean, if set, add comments to the generated code with
+	the line of the grammar that corresponds to each rule and code block
+	(default: false).
+
+	-context-type=NAME : string, name of the type of the receiver of the
+	code blocks, for a package that declares a type with the default name
+	(default: current).
+
 	-debug : boolean, print debugging info to stdout (default: false).
 
+	-default-memoize : boolean, if set, the generated parser memoizes its
+	results by default, as if the Memoize(true) option was passed to its
+	Parse functions, see "Using the generated parser". Callers can still
+	pass Memoize(false) to disable it (default: false).
+
+	-define=FEATURES : string, comma-separated list of features to define,
+	see the section "Conditional rules and alternatives" (default: none).
+
+	-dir=DIR : string, write the generated parser to several files in the
+	existing directory DIR instead of a single file, for large grammars that
+	generate files too big for editors: parser.go holds the initializer, the
+	generated types and the entrypoint functions, parser_grammar.go the
+	grammar tables, parser_actions.go the code blocks and parser_runtime.go
+	the code common to all parsers. As each file gets all the imports of the
+	initializer, run goimports -w on the files instead of piping the output
+	through goimports. The -o flag is ignored (default: none).
+
+	-dispatch : boolean, if set, generate the Dispatch function, see
+	"Entrypoint rules" (default: false).
+
+	-embed-source : boolean, if set, embed the source text of the grammar
+	in the generated parser as the grammarSource constant, also returned by
+	the generated GrammarSource function (default: false).
+
+	-emit-main : boolean, if set, generate a main function so that the
+	parser is a command, see "Using the generated parser". The package
+	of the parser must be main (default: false).
+
+	-examples=FILE : string, write to FILE a table-driven test that parses
+	each input declared with @example in the grammar, in addition to the
+	generated parser, see "Examples" (default: none).
+
+	-fail-undefined : boolean, if set, pigeon returns an error for the first
+	reference to a rule that the grammar does not define, with the names of
+	the referencing rule and of the missing one, instead of generating a
+	parser in which the reference fails to match (default: false).
+
+	-go-version=VERSION : string, version of Go that the generated code must
+	compile with, e.g. 1.18. Pigeon returns an error if the generated code,
+	including the code blocks of the grammar, uses a package of the standard
+	library or a language feature such as generics that is more recent than
+	that version. The code generated by pigeon itself compiles with Go 1.5
+	and later (default: none).
+
+	-http-handler : boolean, if set, generate the ServeParse HTTP handler,
+	see "Using the generated parser" (default: false).
+
+	-imports=PATHS : string, comma-separated list of the import paths of
+	the packages used by the code blocks, e.g. a package that goimports
+	does not find. They are imported after the package clause, except for
+	those that the initializer already imports (default: none).
+
+	-lexer : boolean, if set, generate the Tokenize function, see "Lexical
+	rules" (default: false).
+
+	-listing=FILE : string, write to FILE a text listing of the rules of the
+	grammar, with a line per expression of each rule indented by its depth,
+	its kind, its operands and its position in the grammar, for
+	documentation and review, e.g. to parser.pvm (default: none).
+
+	-memo-level=LEVEL : string, what the generated parser memoizes with the
+	Memoize option: "none" for nothing, so that the option has no effect,
+	"rule" for the results of the rules only, to save the memory of the
+	results of their expressions, or "expr" for the results of the rules
+	and of their expressions (default: expr).
+
+	-namespaces=NS=FILE[,NS=FILE...] : string, comma-separated list of
+	the grammars whose rules are added to the parser in the namespace NS,
+	see "Namespaces" (default: none).
+
+	-no-inline : boolean, if set, the references to the rules that consist
+	of a single matcher are not replaced by the matcher, so that the OnMatch
+	and Events options of the generated parser report the matches of those
+	rules (default: false).
+
+	-no-panic : boolean, if set, the generated parser is written without
+	panic handler, so that its code never calls panic nor recover. The
+	limits of its options still stop the parse with an error, but its
+	Recover and PanicContext options have no effect and a panic in a code
+	block is not recovered (default: false).
+
 	-no-recover : boolean, if set, do not recover from a panic. Useful
 	to access the panic stack when debugging, otherwise the panic
 	is converted to an error (default: false).
@@ -42,6 +143,52 @@ The following options can be specified:
 	-o=FILE : string, output file where the generated parser will be
 	written (default: stdout).
 
+	-package=NAME : string, package name of the generated parser. If set,
+	the package clause is written by pigeon and any package clause in the
+	initializer code block is removed (default: use the initializer's
+	package clause).
+
+	-rules=RULES : string, comma-separated list of the rules to generate,
+	along with the rules they reference, directly or indirectly, to generate
+	a parser for a part of the grammar. The first rule is the start rule of
+	the parser (default: all the rules).
+
+	-skip=RULE : string, name of a rule that is matched before each matcher
+	and each reference to a lexical rule in the non-lexical rules, typically
+	to skip whitespace. See "Lexical rules" below (default: none).
+
+	-spans : boolean, if set with -structs, add the fields Pos and End to the
+	struct types, set to the start and end positions of the match of the
+	rule (default: false).
+
+	-stringers : boolean, if set with -structs, generate a String method for
+	the struct types, that formats a value as e.g. "Point{X:1, Y:2}"
+	(default: false).
+
+	-strip-actions : boolean, if set, generate a recognizer that ignores
+	the code blocks of the grammar: the action code blocks are not run, the
+	code predicates always match and the initializer and @fields code blocks
+	are not written, except for the package clause. The value of a match is
+	the default value of its expression (default: false).
+
+	-structs : boolean, if set, generate a struct type for each rule whose
+	expression is a sequence with labeled expressions and no action. The
+	type is named after the rule and has an exported field of type
+	interface{} for each label; the value of the rule is an instance of that
+	type with the values of the labeled expressions. A labeled optional
+	expression with "??" sets its field to the value of its code block when
+	it does not match, e.g. port:( ':' p:Port { return p, nil } ) ?? {
+	return 8080, nil } (default: false).
+
+	-tag-branches : boolean, if set, the value of each iteration of a choice
+	repeated by "*" or "+" is a Branch, with the index of the alternative
+	that matched, see "Repeating expressions" (default: false).
+
+	-visitor : boolean, if set with -structs, generate a Visitor interface
+	with an EnterX and an ExitX method for each struct type X, and a Walk
+	function that calls them for the struct values of a parse result
+	(default: false).
+
 	-x : boolean, if set, do not build the parser, just parse the input grammar
 	(default: false).
 
@@ -97,6 +244,14 @@ be specified after the rule identifier. E.g.:
 The rule definition operator can be any one of those:
 	=, <-, ← (U+2190), ⟵ (U+27F5)
 
+A rule that is a choice with alternatives that end with a reference to the
+rule itself, such as List = Item ',' List / Item, is parsed as a loop
+instead of nested calls, so that a long list neither grows the Go stack
+nor counts towards the MaxDepth option. Its value is the same. The calls
+are still nested with the options that observe each match of a rule or
+each expression, such as Debug, Trace, Statistics or OnMatch. The
+TailRecursive field of ast.Metrics reports these rules.
+
 Expressions
 
 A rule is defined by an expression. The following sections describe the
@@ -115,6 +270,24 @@ of expressions. For example, in this rule, "<=" would never be used because
 the "<" expression comes first:
 	BadChoiceExpr = "<" / "<="
 
+Pigeon prints a warning when an alternative starts with the literal that a
+preceding alternative always matches, like "<=" in the rule above. The
+grammar is still generated.
+
+A choice of literals, and a sequence of literals that ends with a choice
+of literals, such as '.' ( "go" / "mod" / "sum" ), are generated as a
+single matcher that tries all the literals in one pass over the input.
+The match and its value are the same as those of the expression.
+
+A choice in a @longest( ... ) expression tries all its alternatives
+instead, and matches the first one of those that consume the most input,
+like the longest match rule of lexers. E.g. this rule matches "<=" as a
+whole:
+	Op = @longest( "<" / "<=" )
+The alternatives are all tried at each match of the choice, and the one
+that wins is matched again unless it is the last one, so that its state is
+the one that is kept. The expression of @longest must be a choice.
+
 Sequence expression
 
 The sequence expression is a list of expressions that must all match in
@@ -122,6 +295,32 @@ that same order for the sequence expression to be considered a match.
 Expressions are separated by whitespace. E.g.:
 	SeqExpr = "A" "b" "c" // matches "Abc", but not "Acb"
 
+The value of a sequence is a slice of empty interfaces with the value of
+each of its expressions, nil for an optional expression that did not match.
+The compact expression "@compact(expr)" matches expr and removes the nil
+values from its value if it is such a slice, so that the action gets only
+the values that matched. E.g., with ws unmatched, pair is the slice of the
+values of 'a' and 'b':
+	Pair = pair:@compact( 'a' ws? 'b' ) { return pair, nil }
+
+The trim expression "$trim(expr)" matches expr, its value is the text
+of the match as a string, with the leading and trailing whitespace removed,
+so that no action is needed to trim it. E.g., Name matches "  hello  " with
+the value "hello":
+	Name = $trim( [ a-z]+ )
+
+The convert expression "@name(expr)" matches expr, its value is the text
+of the match converted by the converter called name, so that no action is
+needed to convert it. The converters int, float and bool are built in and
+convert the text to an int, a float64 and a bool as fmt.Sscan does, the
+Converter option of the generated parser registers the others. If the
+converter returns an error, or if it is not registered, the expression
+fails with that error. The names of the annotations with arguments, such
+as sep or array, cannot name a converter. E.g., Port has an int value and
+Date the value returned by the date converter:
+	Port = @int( [0-9]+ )
+	Date = @date( [0-9]+ '-' [0-9]+ '-' [0-9]+ )
+
 Labeled expression
 
 A labeled expression consists of an identifier followed by a colon ":"
@@ -144,6 +343,10 @@ the any matcher), the value is []byte. E.g.:
 For predicates (& and !), the value is always nil. E.g.:
 	Rule = label:&'a' { // label is nil }
 
+For a peek expression (@peek), the value is that of its expression, even
+though no input is consumed. E.g.:
+	Rule = label:@peek('a') { // label is []byte }
+
 For a sequence, the value is a slice of empty interfaces, one for each
 expression value in the sequence. The underlying types of each value
 in the slice follow the same rules described here, recursively. E.g.:
@@ -161,6 +364,12 @@ For the optional expression (?), the value is nil or the value of the
 expression. E.g.:
 	Rule = label:'a'? { // label is nil or []byte }
 
+With "??" followed by a code block instead of "?", the value is that of
+the code block when the expression does not match, so that the action
+does not check for nil. The code block returns the value and an error, as
+an action code block; an error fails the match. E.g.:
+	Rule = n:Number ?? { return 0, nil } { // n is the number or 0 }
+
 Of course, the type of the value can be anything once an action code block
 is used. E.g.:
 	RuleA = label:'3' {
@@ -168,6 +377,30 @@ is used. E.g.:
 	}
 	RuleB = label:RuleA { // label is int }
 
+The generated parser only stores the value of a label that a code block
+in its scope references, so that a label used for documentation or by a
+code predicate that ignores it does not cost an allocation.
+
+Back-reference
+
+The "@=" prefix followed by a label matches the same text as the last
+match of the labeled expression with that label in the rule, e.g. the
+closing tag of an element. Its value is the matched text as []byte. It
+does not match if the labeled expression did not match yet. The text is
+matched as runes, so it cannot match tokens in token mode. E.g.:
+	Element = '<' tag:Name '>' ( !"</" . )* "</" @=tag '>'
+
+The "@seen=" prefix followed by a label matches the longest of the texts
+matched so far in the parse by the labeled expressions with that label, in
+any rule, that the input repeats. Its value is the matched text as []byte.
+The matches that the parser backtracked over are not considered. The
+candidate texts of each length are found with a rolling hash of the input,
+so that the expression does not compare the input to every earlier match.
+It does not match in token mode. E.g.:
+	Block = Repeat / Def
+	Def = text:Words ';'
+	Repeat = '*' @seen=text ';'
+
 And and not expressions
 
 An expression prefixed with the ampersand "&" is the "and" predicate
@@ -180,6 +413,12 @@ a match, but it does not consume any input. E.g.:
 	AndExpr = "A" &"B" // matches "A" if followed by a "B" (does not consume "B")
 	NotExpr = "A" !"B" // matches "A" if not followed by a "B" (does not consume "B")
 
+The peek expression "@peek(expr)" matches like the and predicate "&expr"
+and does not consume any input either, but its value is that of expr
+instead of nil, so that it can be used to look at the value of a rule
+ahead without consuming its input. E.g.:
+	Sized = size:@peek(Number) Number Data // size is the value of Number
+
 The expression following the & and ! operators can be a code block. In that
 case, the code block must return a bool and an error. The operator's semantic
 is the same, & is a match if the code block returns true, ! is a match if the
@@ -190,6 +429,16 @@ defined in its scope. E.g.:
 		return true, nil
 	}
 
+Lookbehind expressions
+
+A literal, character class or any matcher prefixed with "<=" is the
+lookbehind expression: it is considered a match if the input that
+precedes the current position is matched by the matcher, but it does not
+consume any input and its value is nil. Nothing precedes the start of the
+input, so the lookbehind does not match there, and !<=. matches only at
+the start of the input. E.g.:
+	Neg = ( <=[ \t] / !<=. ) '-' Num // a minus preceded by whitespace or at the start
+
 Repeating expressions
 
 An expression followed by "*", "?" or "+" is a match if the expression
@@ -198,6 +447,66 @@ occurs zero or more times ("*"), zero or one time "?" or one or more times
 possible. E.g.
 	ZeroOrMoreAs = "A"*
 
+The expression repeated by "*" or "+" must not be able to match the empty
+string, as the repetition would never end: pigeon reports an error for
+such a grammar, e.g. for "A"?* or for B* if the rule B can match the
+empty string.
+
+A "*" or "+" repetition may be followed by a condition, a code block
+predicate in braces, e.g. "expr*{ &{ code } }". The condition is evaluated
+before each repetition and the repetition stops as soon as it returns
+false. Its function receives the values matched so far as its last
+argument, acc, of type []interface{}, after the labeled expressions that
+are in scope as for the code block predicates. A "+" repetition fails if
+its condition stops it before the first match. E.g.:
+	Args = Arg*{ &{ return len(acc) < 10, nil } } // at most 10 arguments
+
+An action code block of an iteration can also end the repetition once it
+sees the value of its match, by returning the errStopRepeat error: the
+match of the action fails without an error and without consuming the
+input, so the repetition ends before it and the input is left to the
+following expressions. E.g.:
+	Body = ( line:Line { if line == "END" { return nil, errStopRepeat }; return line, nil } )* End
+
+The Stream option of the generated parser passes the values of a
+repetition of a rule to a function as the rule matches, instead of
+collecting them in the value of the repetition, which is then nil, so that
+the memory used by a long list of items at the top level of a large input
+stays flat. The values are passed even if the parser backtracks before
+the repetition afterwards, and the condition of the repetition receives no
+values in acc. E.g., for File = Item* !.:
+	Parse("", b, Stream("Item", func(v interface{}) error {
+		return store(v.(*Item))
+	}))
+
+If the parser is generated with the -tag-branches flag, the value of each
+iteration of a choice repeated by "*" or "+" is a Branch: its Index is the
+0-based index of the alternative that matched, its Name the name of the
+rule referenced by the alternative, if any, and its Value the value of the
+alternative, so that the action of the repetition knows which alternative
+matched each value. The alternatives disabled by @if are not counted. E.g.:
+	Items = items:( Word / Number )* {
+		// items is []interface{}{Branch{Index: 0, Name: "Word", ...}, ...}
+		return items, nil
+	}
+
+The array expression "@array(expr, N)" matches expr exactly N times, and
+its value is an array of N empty interfaces, [N]interface{}, instead of a
+slice. With a Go type as a string literal, "@array(expr, N, "T")", the
+values are stored directly in an array of type [N]T, without an
+intermediate slice, and a value that is not of type T fails the match with
+an error. A nil value leaves the zero value of T. E.g.:
+	Color = '#' rgb:@array( Hex, 3, "int" ) { return rgb, nil } // rgb is a [3]int
+
+The map expression "@map(expr, key, val)" matches expr zero or more times,
+like "expr*", and its value is a map[string]interface{} of the values of
+the label val of the matches, keyed by the text matched by their label
+key. Both labels must be defined in expr. A duplicate key fails the match
+with an error, unless the string literal "last" follows the labels,
+"@map(expr, key, val, "last")", to keep the value of the last match of the
+key ("error" is the default). E.g.:
+	Pairs = @map( key:Ident _ '=' _ val:Value _, key, val ) // "a=1 b=2" is map[a:1 b:2]
+
 Literal matcher
 
 A literal matcher tries to match the input against a single character or a
@@ -209,6 +518,19 @@ The literal may be followed by a lowercase "i" (outside the ending quote)
 to indicate that the match is case-insensitive. E.g.:
 	LiteralMatch = "Awesome\n"i // matches "awesome" followed by a newline
 
+The literals inside an "@ignorecase(expr)" region are all case-insensitive,
+without an "i" after each of them, e.g. for the keywords of a language
+whose identifiers are case-sensitive. The region only applies to the
+literals written inside it, not to those of the rules that it references,
+and it is expanded when the grammar is parsed. E.g.:
+	Select = @ignorecase( "select" _ Columns _ "from" ) _ Table
+
+The case-insensitive match uses the simple Unicode case folding, rune by
+rune, so that e.g. 'é'i matches "É" and 'σ'i matches "Σ" and "ς". The
+foldings that change the number of runes are not supported: "straße"i
+matches "STRAßE" but not "STRASSE", and a ligature such as "ﬁ" only matches
+itself. The same folding applies to case-insensitive character classes.
+
 Character class matcher
 
 A character class matcher tries to match the input against a class of characters
@@ -222,6 +544,13 @@ classes can be specified using the "[\pL]" notation, where L is a
 single-letter Unicode class of characters, or using the "[\p{Class}]"
 notation where Class is a valid Unicode class (e.g. "Latin").
 
+The range tables of the Unicode classes are those of the unicode package
+of the Go version that compiles the parser. The ClassTable option of the
+generated parser replaces the table of a class at parse time, e.g. with
+a richer table built with golang.org/x/text/unicode/rangetable, so that
+"[\pL]" also matches the combining marks of the identifiers:
+	Parse("", b, ClassTable("L", rangetable.Merge(unicode.L, unicode.M)))
+
 As for string literals, a lowercase "i" may follow the matcher (outside
 the ending square bracket) to indicate that the match is case-insensitive.
 A "^" as first character inside the square brackets indicates that the match
@@ -229,6 +558,16 @@ is inverted (it is a match if the input does not match the character class
 matcher). E.g.:
 	NotAZ = [^a-z]i
 
+Character classes can be given a name with "@alias NAME = [...]" before
+the rules of the grammar. The name of an alias can be used as a matcher,
+and as a word separated by spaces inside another character class, where
+it is replaced by the characters of its class and the spaces of the class
+are removed. The aliases are expanded when the grammar is parsed, the
+generated parser only has the expanded classes. E.g.:
+	@alias DIGIT = [0-9]
+	HexDigit = [DIGIT A-F] // same as [0-9A-F]
+	Number = DIGIT+
+
 Any matcher
 
 The any matcher is represented by the dot ".". It matches any character
@@ -237,6 +576,418 @@ the end of file". E.g.:
 	AnyChar = . // match a single character
 	EOF = !.
 
+Until matcher
+
+The until matcher is written "Until(delimiter)" where the delimiter is a
+string literal. It consumes all characters up to, but not including, the
+first occurrence of the delimiter, or up to the end of file if the delimiter
+is not found, so it never fails: a missing delimiter is not an error of
+the matcher, it must be matched after it, as in the example below, to be
+reported. It is equivalent to "( !delimiter . )*", but the input is
+scanned once for the delimiter instead of trying the lookahead at each
+character. Note that "Until(" must be written without whitespace before the
+opening parenthesis, otherwise it is a reference to a rule named Until. E.g.:
+	HTMLComment = "<!--" Until("-->") "-->"
+
+Nested matcher
+
+The nested matcher is written "Nested(open, close)" where open and close
+are non-empty string literals. It matches a block that starts with the open
+delimiter and ends with the close delimiter that matches it, counting the
+blocks nested inside, such as block comments that nest. Its value is the
+slice of bytes matched, delimiters included. It fails if the input ends
+before the block is closed. Like "Until(", it must be written without
+whitespace before the opening parenthesis. E.g.:
+	Comment = Nested("{-", "-}") // matches "{- a {- b -} c -}"
+
+Rest of line matcher
+
+The rest of line matcher is written "RestOfLine()". It consumes all
+characters up to, but not including, the next newline, "\n" or "\r\n", or up
+to the end of file if there is no newline. Its value is the slice of bytes
+matched, which may be empty. E.g.:
+	Entry = key:Key ':' val:RestOfLine() ( "\r"? "\n" / !. )
+
+Number matcher
+
+The number matcher "Number()" matches an integer written with decimal
+digits and its value is that integer as an int64. It accepts options
+written as name: value and separated by commas, e.g. "Number(sign: true)":
+"sign" allows a leading '-' or '+', "radix" sets the radix of the digits
+from 2 to 36, with the letters as the digits above 9, and "float" matches
+an optional fraction and exponent after the digits, in which case the
+value is a float64 and the radix must be 10. "prefix" matches an integer
+whose digits follow a radix prefix, "0x" for hexadecimal, "0b" for binary
+or "0o" for octal, in either case, that sets their radix; it cannot be
+used with "radix" or "float", and a prefix not followed by a digit of its
+radix fails to match. "thousands" sets a separator of the groups of three
+digits, as in "1,234", with a radix of 10, and "decimal" sets the separator
+of the fraction of a float instead of '.', both as a string literal of a
+single character. A number that is out of the range of its type fails to
+match with an error. Like "Until(", it must be written without whitespace
+before the opening parenthesis. E.g.:
+	Temp = Number(float: true, sign: true) 'C' // matches "-1.5e3C", value -1500
+	Color = '#' Number(radix: 16)
+	Int = Number(prefix: true) / Number() // matches "0xFF", value 255
+	Amount = Number(float: true, thousands: '.', decimal: ',') // matches "1.234,56", value 1234.56
+
+Byte matchers
+
+The byte matchers support grammars for binary formats, and match the
+input byte by byte regardless of its encoding. "Byte(" followed by a
+decimal or hexadecimal integer and ")" matches a single byte with that
+value, and "Bytes(" followed by a number and ")" matches that number of
+bytes. The number of bytes can also be the label of an expression that
+precedes the matcher in the same rule, whose value must be an integer. The
+value of the matchers is the slice of bytes matched. Like "Until(", they
+must be written without whitespace before the opening parenthesis.
+
+The generated parser provides the bigEndianUint and littleEndianUint
+functions, that return the unsigned integer (uint64) encoded in a slice of
+up to 8 bytes, for use in the code blocks. E.g.:
+	Record = n:Length data:Bytes(n) Byte(0x0A)
+	Length = Bytes(4) { return bigEndianUint(c.text), nil }
+
+Note that the input of the generated parser is still decoded as UTF-8 for
+the other matchers, and that invalid UTF-8 sequences are reported as errors
+only if the parse fails. Each byte counts as a column in the position of the
+matches.
+
+Indentation matchers
+
+The indentation matchers support grammars where the structure is defined
+by the indentation of the lines, like Python or YAML. The parser keeps a
+stack of indentation levels, starting with a single level of width 0. The
+width of the indentation of a line is its number of leading spaces and
+tabs. The matchers only match at the start of a line:
+	@indent   : zero-width match if the line is indented more than the
+	            current level, which becomes the level of the line.
+	@samedent : matches the indentation of the line if it is the same as
+	            the current level.
+	@dedent   : zero-width match if the line is indented less than the
+	            current level, which is removed from the stack.
+
+The end of the input is considered as a line with no indentation, and
+blank lines must be consumed by the grammar. The stack of indentation levels
+is restored when the parser backtracks, but it is not taken into account by
+the memoization of the -cache option. E.g.:
+	Block = @samedent Header ':' EOL @indent Block+ @dedent
+	      / @samedent Line EOL
+
+Keyword matcher
+
+The keyword matcher "@keyword" matches one of the words provided to the
+generated parser with the Keywords option, so that the set of reserved
+words can be configured at parse time without generating the parser again.
+It matches the longest of the words found at the current position that is
+not immediately followed by a letter, a digit or an underscore, and it never
+matches if no word is provided. E.g.:
+	Identifier = !@keyword [a-z]+
+
+The unreserved expression "@unreserved(expr)" matches expr and then fails
+if the text of the match is exactly one of the words of the Keywords
+option. It checks the whole match instead of a prefix, so that it works
+whatever characters the identifiers are made of. E.g., with the words "if"
+and "else", "if" is a keyword while "iffy" and "if-else" are identifiers:
+	Token = Keyword / Identifier
+	Keyword = @keyword !'-'
+	Identifier = @unreserved( [a-z]+ ( '-' [a-z]+ )* )
+
+The word list matcher "@wordlist" matches the words provided to the
+generated parser with the WordList option like "@keyword" matches those of
+the Keywords option, but it follows a trie of the words built when the
+option is applied instead of testing each word in turn, so that it stays
+fast with large sets of words, such as the keywords of a language chosen
+at parse time. E.g.:
+	Keyword = @wordlist
+
+Table matcher
+
+The table matcher "@table(NAME)" matches a rune of the Unicode range table
+provided to the generated parser with the Table option under that name,
+so that a set of characters specific to a domain is chosen at parse time.
+It never matches if no table is provided. Its value is the matched rune
+as []byte. E.g.:
+	Ident = @table(IdentStart) ( @table(IdentStart) / [0-9] )*
+with Table("IdentStart", unicode.Greek) for identifiers in Greek letters.
+
+Token matcher
+
+The token matcher supports parsing the tokens of an external lexer instead
+of text, with the generated ParseTokens function. The tokens implement the
+generated Token interface:
+	type Token interface {
+		Kind() int
+		Text() string
+		Pos() TokenPos
+	}
+
+"@token(" followed by a Go expression of the kind, either an integer or an
+identifier, possibly qualified by a package name, and ")" matches a token of
+that kind, and "@token" alone matches any token. The value of the matcher is
+the Token. In this mode, the other matchers never match, the text of a match
+is the concatenation of the text of its tokens and the positions are those
+of the tokens. E.g.:
+	Sum = Num ( @token(kindPlus) Num )* !@token
+	Num = tok:@token(kindNum) { return strconv.Atoi(tok.(Token).Text()) }
+
+Operators expression
+
+The operators expression matches one or more operands separated by binary
+operators, and groups them according to the precedence and associativity
+of the operators, without having to write one rule per precedence level.
+It is written "@operators" followed by the operand expression and a block
+that lists the operators by precedence level, separated by semicolons.
+Each level is a list of string literals followed by the associativity,
+"left" or "right", and the precedence; operators with a higher precedence
+bind tighter. E.g.:
+	Expr = @operators Term {
+		'+' '-' left 1;
+		'*' '/' left 2;
+		"**" right 3;
+	}
+
+The value of a binary operation is a slice of empty interfaces with the
+value of the left operand, the matched operator as []byte and the value
+of the right operand, so "1+2*3" results in {1, "+", {2, "*", 3}} (given
+that Term returns the value of the integer). If there is a single operand,
+the value is that of the operand. Operators are matched literally, so any
+whitespace must be consumed by the operand expression.
+
+Separated list expressions
+
+The separated list expression matches one or more expressions separated by
+a separator expression. It is written "@sep(expr, sep)", or
+"@sep(expr, sep, trailing)" to also match an optional trailing separator.
+Its value is a slice of empty interfaces with the values of the
+expressions, the values of the separators are dropped. E.g.:
+	Args = '(' args:@sep(Arg, ',', trailing)? ')' // matches "(a,b)" and "(a,b,)"
+
+With the "keep" flag, e.g. "@sep(expr, sep, keep)", the values of the
+separators are interleaved with those of the expressions instead, so "a,b"
+results in {a, sep, b}. The flags can be combined in any order.
+
+With the "terminated" flag, the separator is a terminator that must follow
+each expression, including the last one, as for statements terminated by a
+semicolon or a newline. The terminator can be a predicate, so that the end
+of a block or of the input terminates the last statement without being
+consumed. E.g.:
+	Block = '{' @sep(Stmt, Term, terminated) '}' // matches "{a;b}" and "{a;b;}"
+	Term = ';' / '\n' / &'}' / !.
+An expression that is not followed by a terminator is not part of the
+list, and the list does not match if the first one is not.
+
+Fold expressions
+
+A sequence of an operand followed by a repetition of operator and operand
+pairs can be suffixed with "@left" or "@right" to fold its value into
+binary operations of the same form as those of the operators expression,
+grouped to the left or to the right. E.g.:
+	Diff = e:( Num ( '-' Num )* @left ) { // 1-2-3 is {{1, "-", 2}, "-", 3} }
+	Pow = e:( Num ( '^' Num )* @right ) { // 1^2^3 is {1, "^", {2, "^", 3}} }
+
+Conditional rules and alternatives
+
+A rule or an alternative of a choice expression can be prefixed with
+"@if(feature)", where feature is an identifier. It is then only generated
+if the feature is defined, using the -define command-line option. This
+allows a single grammar to generate parsers for different dialects of
+a language. A reference to a rule that is not generated fails to match
+at runtime. By default, so does a reference to a rule that the grammar
+does not define at all, with an "undefined rule" error of the parse; it
+is an error when the parser is generated only with the -fail-undefined
+flag. E.g.:
+	Stmt = Assign / @if(loops) While / Expr
+	@if(loops) While = "while" Cond Block
+
+An alternative can also be prefixed with "@when(flag)", where flag is an
+identifier. Such an alternative is always generated, but it only matches
+if the flag is set when parsing, using the Flag option of the generated
+parser, so that the dialect can be selected without regenerating the
+parser. E.g.:
+	Stmt = Assign / @when(loops) While / Expr
+
+Lexical rules
+
+When the -skip option is set, the rule it names is matched before each
+matcher and each reference to a lexical rule in the other rules, so that
+the grammar doesn't have to mention whitespace explicitly. A rule prefixed
+with "@lexical" is left as is: it is matched as a single token and nothing
+is skipped inside it. The skip rule itself should be lexical or only contain
+matchers. E.g., with -skip=_:
+	Sum = Number ( '+' Number )* EOF
+	@lexical Number = [0-9]+
+	@lexical EOF = !.
+	_ = [ \t\n]*
+
+A rule prefixed with "@nlsignificant", after any "@silent", sees the
+newlines: the skip rule is matched before its matchers as in the other
+rules, but it cannot match a newline, so that the rule can match it
+explicitly, e.g. to end a statement. The rules that it references skip
+the newlines unless they are marked too. E.g., with -skip=_:
+	Program = Stmt* EOF
+	@nlsignificant Stmt = Name '=' Expr ( '\n'+ / &EOF )
+	Expr = Term ( '+' Term )* // may continue on the next line
+	_ = [ \t\n]*
+
+Within a rule, the verbatim expression "@verbatim(expr)" leaves expr as is,
+so that nothing is skipped before its matchers and the text it matches is
+captured exactly, whitespace included. The rules it references are still
+generated with the skip rule, unless they are lexical. E.g., with -skip=_:
+	Template = ( Text / Tag )* EOF
+	Tag = "{{" body:@verbatim( Until("}}") ) "}}"
+
+With the -lexer flag, the generated parser also gets a Tokenize function
+that splits the input into tokens without parsing it, for simple lexers.
+At each position, it skips the whitespace, or the runes of the SkipFunc
+option, and tries the lexical rules in the order of the grammar, except
+the skip rule; the first one that matches a non-empty text produces a
+Lexeme with the name of the rule as its Kind, its Text and its Pos:
+	type Lexeme struct {
+		Kind string
+		Text string
+		Pos  Pos
+	}
+
+The SkipFunc option of the generated parser replaces the skip rule at
+parse time with a function that decides the runes that are skipped, e.g.
+to also skip the underscores with a grammar written for whitespace:
+	Parse("", []byte("a_+_b"), SkipFunc(func(r rune) bool {
+		return r == '_' || unicode.IsSpace(r)
+	}))
+
+The LineComment option also skips the line comments that start with its
+prefix wherever the whitespace is skipped, e.g. for the configuration
+files. With LineComment("#") and -skip=_, "a # note\n + b" parses like
+"a + b".
+
+Entrypoint rules
+
+The parser starts at the first rule of the grammar. A rule prefixed with
+"@entry", after any "@if" condition, gets a ParseX function in the
+generated parser, where X is the name of the rule with its first letter in
+upper case. ParseX has the same signature as Parse but starts at that rule,
+so that a grammar can parse several kinds of documents. E.g.:
+	Module = Decl* EOF
+	@entry Expr = Term ( '+' Term )*
+	@entry Decl = "let" Name '=' Expr
+generates ParseExpr and ParseDecl, in addition to Parse, which starts at
+Module.
+
+With the -dispatch flag, the generated parser also gets a Dispatch function
+with the same signature as Parse, that routes the input to the ParseX
+function of the entrypoint rule whose matches start with a literal that the
+input starts with, such as the type of a message in a protocol. The
+literal is found by following the rule references at the start of the
+rule, and the longest literal is tried first. Entrypoint rules that do not
+start with a literal are ignored, and Dispatch calls Parse if no literal
+matches. E.g.:
+	Request = Get / Post
+	@entry Get = "GET " Path EOL
+	@entry Post = "POST " Path EOL Body
+generates a Dispatch that calls ParseGet for "GET /index.html\n" and
+ParsePost for "POST /form\n...".
+
+The SkipLeading option of the generated parser skips the whitespace at
+the start of the input before the start rule, and the RequireTrailingEOF
+option skips the whitespace after it and fails the parse if the input
+does not end there. The whitespace is that of the SkipFunc option, or of
+the skip rule, or the Unicode white space. E.g., with -skip=_ and both
+options, A = 'a' matches "  a  " but not "  a  b".
+
+Namespaces
+
+A grammar can use the rules of other grammars, e.g. of a language embedded
+in strings, with the -namespaces flag: the rules of each grammar FILE are
+added to the parser as NS::Rule, and the grammar references them with that
+qualified name. The references between the rules of FILE are qualified
+the same way, so the rules of the grammars do not collide. The
+initializer, the fields and the entrypoints of FILE are ignored, so its
+code blocks can only use the imports, which goimports adds, and the
+initializer of the main grammar. E.g., with -namespaces=sql=sql.peg:
+	Stmt = "query" _ '"' q:sql::Query '"'
+The ast.MergeNamespace function merges the grammars for other tools.
+
+Silent rules
+
+A rule can be prefixed with "@silent", after any "@lexical", so that its
+matchers, and those of the rules it references, are not listed in the
+expected set of the syntax error, e.g. for the whitespace and the
+punctuation rules that would clutter the messages. The farthest failure
+of the parse is restored after each match attempt of the rule, so a
+syntax error inside a silent rule is reported at the farthest failure
+outside of it. Silent rules are not inlined. E.g.:
+	Sum = Number _ '+' _ Number
+	@silent _ = ( ' ' / '\t' )*
+reports "expecting '+'" instead of "expecting ' ', '\t', '+'" for "1 x".
+
+Inline rules
+
+A rule can be prefixed with "@inline", after any "@entry", so that it is
+not generated: its expression is written in place of each of its
+references, so that the rule has no call frame and does not appear in the
+statistics, the traces and the errors. It is meant for the helper rules
+that exist for the readability of the grammar. An @inline rule cannot be
+the first rule, have a display name, other annotations than "@if" and
+"@lexical", code blocks or labels, and cannot reference itself, directly
+or through other @inline rules. E.g., Digits is matched as if its
+expression was written twice in List:
+	List = Digits ( ',' Digits )*
+	@inline Digits = '0' / [1-9] [0-9]*
+
+The rules that consist of a single matcher, such as a literal, a
+character class or the any matcher, are inlined the same way, unless they
+have a display name, they are @silent or @nlsignificant, or the parser is
+generated with the -no-inline flag. An inlined rule is never tried as a
+rule: it does not count towards the MaxDepth option of the generated
+parser, its runes are owned by the referencing rule for the Ownership
+option, it is not counted by the Statistics and TokenCounts options nor
+reported by the OnMatch and Events options, and its value is not checked
+by the StrictNodes option, transformed by the Transform option nor
+streamed by the Stream option.
+
+Typed rules
+
+A rule can be prefixed with "@type", after any "@nlsignificant", to
+declare the Go type of its value as a string literal. If the first rule of
+the grammar has a type T, the generated parser has a ParseInto function
+that parses like Parse and stores the value in a *T, so that the caller
+doesn't need a type assertion. Likewise, an entrypoint rule X with a type
+gets a ParseXInto function. The functions return an error if the value is
+not of that type. E.g.:
+	@type("*Module") Module = decls:Decl* EOF { return newModule(decls) }
+generates:
+	func ParseInto(filename string, b []byte, v **Module, opts ...Option) error
+
+The StrictNodes option of the generated parser checks the value of each
+match of a rule with a type, so that an action that returns a value of
+another type fails the match with an error that names the rule, e.g.
+"rule Module: value of type []interface {}, want *Module", instead of
+failing later in the code that uses the value. The check is meant for
+development, as it runs on each match.
+
+Rule budgets
+
+A rule can be prefixed with "@budget(N)", after any "@type", to bound the
+number of expressions that a match of the rule evaluates to N, including
+the expressions of the rules it references, so that a rule that
+misbehaves on some input, e.g. with exponential backtracking, stops the
+parse with an error that names it without limiting the whole parse. The
+expressions of the memoized results are not evaluated again and do not
+count. E.g.:
+	@budget(10000) Expr = Term ( Op Term )*
+
+Rule metadata
+
+A rule can be preceded by one or more "@meta" annotations, that attach
+key-value pairs to the rule for documentation and other tools. The keys are
+identifiers and the values are string literals. The metadata is available
+in the Meta field of the rule in the AST and does not change the generated
+parser, except that it is added to the comments of the rule when the
+comments are enabled. E.g.:
+	@meta(doc="an integer literal", kind="token")
+	Integer = [0-9]+
+
 Code block
 
 Code blocks can be added to generate custom Go code. There are three kinds
@@ -258,6 +1009,59 @@ clause). E.g.:
 		}
 	}
 
+The fields block "@fields" followed by a code block may appear after the
+initializer, before any rule. Its content (minus the wrapping curly braces)
+is added to the fields of the "current" type, so that the action and
+predicate code blocks can share state, like a symbol table, through their
+receiver. The fields can be initialized by an Option declared in the
+initializer, which sets them on the "cur" field of the parser. E.g.:
+	{
+		package main
+
+		func Symbols(m map[string]int) Option {
+			return func(p *parser) Option {
+				old := p.cur.symbols
+				p.cur.symbols = m
+				return Symbols(old)
+			}
+		}
+	}
+
+	@fields {
+		symbols map[string]int
+	}
+
+The state block "@state" followed by a code block may appear after the
+fields block, before any rule. Its content (minus the wrapping curly
+braces) is the fields of the parseState struct, that the code blocks
+share as c.state, so that the accesses to the state are checked by the
+compiler. The state is a new zero value for each parse, unless the
+WithState option, generated only for a grammar with a state block, sets
+it, which also gives the caller its values at the end of the parse. Like
+the fields, the state is not restored when the parser backtracks. E.g.:
+	@state {
+		depth int
+	}
+
+	Open ← '(' {
+		c.state.depth++
+		return nil, nil
+	}
+
+Examples
+
+Example inputs of the grammar may be declared with "@example" followed
+by a string literal, after the aliases and before the rules. When the
+-examples flag is set, pigeon writes a test named TestGrammarExamples to
+the file of the flag, that parses each example with the Parse function of
+the generated parser and fails if one of them does not parse, so that the
+examples of the grammar are checked by go test. The test is in the
+package of the parser. E.g.:
+	@example "1+2"
+	@example "(1 + 2) * 3"
+
+	Expr = Term ( _ [+-] _ Term )*
+
 Action code blocks are code blocks declared after an expression in a rule.
 Those code blocks are turned into a method on the "*current" type in the
 generated source code. The method receives any labeled expression's value
@@ -282,32 +1086,309 @@ parser will return. E.g.:
 		return true, nil
 	}
 
-The current type is a struct that provides two useful fields that can be
-accessed in action and predicate code blocks: "pos" and "text".
+The methods generated for code blocks are named "on" followed by the rule
+name and the index of the expression in the rule, e.g. the action code block
+of RuleA above is the "onRuleA1" method. Those names only depend on the
+grammar, so that the stack trace of a panic in a code block identifies the
+rule that it belongs to.
+
+The current type is a struct that provides three useful fields that can be
+accessed in action and predicate code blocks: "pos", "end" and "text".
 
 The "pos" field indicates the current position of the parser in the source
 input. It is itself a struct with three fields: "line", "col" and "offset".
 Line is a 1-based line number, col is a 1-based column number that counts
 runes from the start of the line, and offset is a 0-based byte offset.
 
+The "end" field is the position following the current match, it is only set
+in an action code block.
+
 The "text" field is the slice of bytes of the current match. It is empty
 in a predicate code block.
 
+The current type also has a "warn" method, that records a warning with a
+message at the start position of the current match, e.g. to accept a
+deprecated syntax. The warnings of the successful parse are returned with
+the Warnings option, the warnings recorded by the matches that were
+backtracked over are dropped. E.g.:
+	Decl = "let " n:Name { c.warn("let is deprecated"); return n, nil }
+
+The "parseRule" method of the current type parses a string starting at a
+rule of the grammar, like the ParseX function of an entrypoint, and
+returns its value and error, e.g. to parse the contents of a captured
+string with another rule. The sub-parse has the flags, the keywords, the
+word list, the skip function and the remaining depth of the current parse,
+and the positions in its errors are relative to the string. E.g.:
+	Attr = name:Name '=' val:Quoted {
+		n, err := c.parseRule("Number", val.(string))
+		return attr(name, n), err
+	}
+
+If the parser is generated with the -capture-spans flag, the current type
+also has a "span" method that returns the start and end positions of the
+match of a label of the rule, as Pos values, so that the code blocks get
+the value and the span of each labeled expression without special syntax.
+E.g.:
+	Assign = n:Name "=" v:Value {
+		start, _ := c.span("n")
+		_, end := c.span("v")
+		return assign(n, v, start, end), nil
+	}
+
+The "posAt" method of the current type returns the position of a byte
+offset of the input, e.g. of an offset computed by a code block. The
+starts of the lines of the input are indexed the first time a position
+is computed, so that the line of each offset is then found by a binary
+search instead of a scan of the input, as for the error context lines.
+
+Error productions
+
+For resilient parsing, an alternative can match malformed input on purpose
+and report it, with the "error" method of the current type. It returns an
+error with a message at the start position of the current match. When an
+action code block returns it, the match succeeds with the value returned
+along with it instead of failing, so that the parse continues after the
+malformed input. If the parse succeeds, it returns its value and the
+errors of the error productions that were not backtracked over, in the
+order they were matched. E.g.:
+	Stmt = GoodStmt / badStmt:( !';' . )+ ';' { return nil, c.error("unexpected statement") }
+
 Using the generated parser
 
 The parser generated by pigeon exports a few symbols so that it can be used
 as a package with public functions to parse input text. The exported API is:
 	- Parse(string, []byte, ...Option) (interface{}, error)
+	- NewParser(...Option) *Parser
+	- NewReusableParser(...Option) *ReusableParser
 	- ParseFile(string, ...Option) (interface{}, error)
+	- ParsePartial(string, []byte, ...Option) (interface{}, []byte, error)
 	- ParseReader(string, io.Reader, ...Option) (interface{}, error)
+	- ParseTokens(string, []Token, ...Option) (interface{}, error)
+	- Dispatch(string, []byte, ...Option) (interface{}, error), with the
+	  -dispatch flag
+	- Tokenize(string, []byte, ...Option) ([]Lexeme, error), with the
+	  -lexer flag
+	- ParseX(string, []byte, ...Option) (interface{}, error), for each
+	  rule X marked with @entry
+	- ParseInto(string, []byte, *T, ...Option) error and ParseXInto, if
+	  the first rule or the entrypoint X has the type T
+	- Ambiguities(*[]Ambiguity) Option
+	- AssumeValidUTF8(bool) Option
+	- ClassTable(string, *unicode.RangeTable) Option
+	- ContextLines(int) Option
+	- Converter(string, func(string) (interface{}, error)) Option
 	- Debug(bool) Option
+	- Decoder(func([]byte) ([]rune, error)) Option
+	- DedupeErrors(int) Option
+	- Encoding(string) Option
+	- Events(func(Event)) Option
+	- Flag(string, bool) Option
+	- InitialStackCap(int) Option
+	- JSONErrors(bool) Option
+	- KeepPartial(bool) Option
+	- Keywords(...string) Option
+	- LatinFold(string) Option
+	- LineComment(string) Option
+	- LongestPrefix(*Prefix) Option
+	- MaxBacktrack(int) Option
+	- MaxDepth(int) Option
+	- MaxInputRunes(int) Option
+	- MaxRepeat(int) Option
 	- Memoize(bool) Option
+	- MemoVersion(interface{}) Option
+	- NormalizeNewlines(bool) Option
+	- OnMatch(func(string, Pos, Pos, interface{})) Option
+	- Ownership(map[string]int) Option
+	- PanicContext(bool) Option
 	- Recover(bool) Option
+	- RequireTrailingEOF(bool) Option
+	- ReuseMemo(*MemoCache) Option
+	- RulePath(bool) Option
+	- SkipBOM(bool) Option
+	- SkipFunc(func(rune) bool) Option
+	- SkipLeading(bool) Option
+	- Statistics(*Stats) Option
+	- Stream(string, func(interface{}) error) Option
+	- StrictNodes(bool) Option
+	- Table(string, *unicode.RangeTable) Option
+	- TextNormalizer(func(string, string) string) Option
+	- TokenCounts(map[string]int) Option
+	- Trace(*[]string) Option
+	- Transform(string, func(interface{}) (interface{}, error)) Option
+	- Warnings(*[]Warning) Option
+	- WithLogger(Logger) Option
+	- WithMemoStore(MemoStore) Option
+	- WithState(*parseState) Option, with a @state block
+	- WithTracer(Tracer) Option
+	- WordList(...string) Option
 
 See the godoc page of the generated parser for the test/predicates grammar
 for an example documentation page of the exported API:
 http://godoc.org/github.com/PuerkitoBio/pigeon/test/predicates.
 
+The WithMemoStore option replaces the map of the results of the Memoize
+option with a MemoStore, e.g. to log or count its accesses. The results
+are opaque values that the store returns as they were set.
+
+The Ownership option fills a map with the number of runes that each rule
+owned in the successful parse, i.e. the runes it matched that were not
+matched by a rule it references. Matches that were backtracked are not
+counted, so the numbers sum to the number of runes consumed by the parse.
+
+The debugging information of the Debug option is printed to stdout, or to
+the Logger interface set with the WithLogger option, e.g. a *log.Logger or
+an adapter to another logging package, that receives one Printf call for
+each line.
+
+The Trace option records a line for each rule and expression evaluated
+by the parser, with the kind of expression and its position, e.g.
+"rule A 1:1" then "litMatcher 1:1" for the rule A = 'a' and the input
+"a". Tests can compare the trace with a golden file to detect changes in
+the way the generated code parses an input, see test/trace.
+
+With the -http-handler command-line option, the generated parser also has
+a ServeParse(http.ResponseWriter, *http.Request) function, an HTTP handler
+that parses the body of a POST request with the default options. It
+responds with a JSON object with the value of the parse in its "result"
+field, or with a status of 422 and an "errors" field that lists each error
+with its "line", "col", "offset" and "message". The value of the parse
+must be encodable as JSON, e.g. it should not contain []byte values.
+
+With the -emit-main command-line option, the generated parser also has a
+main function, so that the grammar is a command for quick tools: it
+parses the file named by its first argument, or the standard input if
+there is none, with the default options, and prints the value of the
+parse, or each error on a line of the standard error with an exit status
+of 1. The package of the parser must be main, e.g. with -package main.
+
+The ContextLines option adds the line of the input where an error
+occurred to its message, with the lines around it and a caret under the
+column of the error, as compilers do:
+	file:2:3 (6): rule Value: value is not a number
+	2 | b=x
+	  |   ^
+
+The RulePath option prefixes the errors with the path of the rules that
+were being parsed, from the start rule to the rule of the error, to
+locate an error in nested rules. The syntax error gets the path at the
+farthest position of the input that the parser reached:
+	file:1:9 (8): rule Program > Stmt > Expr: syntax error, unexpected ...
+
+The JSONErrors option formats the errors as JSON objects for tools, with
+the "offset", "line" and "col" of the error, the "rule" where it
+occurred, its "message", and for the syntax error the input "found" and
+the matchers "expected" at the farthest position that the parser reached:
+	{"offset":4,"line":1,"col":5,"rule":"Item","message":"syntax error, ...","found":"x","expected":["0"]}
+A list of errors is formatted as a JSON array of those objects.
+
+The DedupeErrors option collapses the errors that repeat the message and
+rule of the last error kept within a window of bytes of the input, e.g.
+the errors of an error production that fails the same way on each
+statement of a malformed block: DedupeErrors(80) reports one error for
+the statements of the same line or so.
+
+The Encoding option decodes the input from UTF-16 or Latin-1 to UTF-8
+before parsing, and the SkipBOM option removes a byte order mark at the
+start of the input, that would otherwise be matched by the grammar. The
+Decoder option sets a function that decodes the input instead, for other
+encodings such as EBCDIC.
+
+The NormalizeNewlines option converts the "\r\n" and "\r" line endings of
+the input to "\n" before parsing, so that a grammar written for "\n"
+matches the input of any platform. The offsets of the positions reported
+in the errors refer to the input before the conversion.
+
+The LatinFold option replaces an ASCII letter followed by a combining
+mark with the precomposed Latin letter, e.g. "e\u0301" with 'é', or the
+reverse, so that a grammar written with accented letters matches both
+spellings. It is a limited fold of the Latin-1 Supplement and Latin
+Extended-A letters, not a Unicode normalization: the other sequences are
+kept as they are.
+
+The ParsePartial function returns the remainder of the input that follows
+the match of the start rule along with its value, so that the parser can
+be used to parse a prefix of a larger input, e.g. in hand-written code.
+
+A Parser returned by NewParser parses with the options it was created
+with, from a slice of bytes with its Parse method or from an io.Reader
+with its ParseReader method. It can be created once and used by multiple
+goroutines at the same time: the grammar is only read by the parse, and
+all the state of a parse, such as the memoization cache, belongs to the
+call.
+
+The Explain method of a Parser parses an input starting at a rule, and
+returns a human-readable account of how the parse proceeded, e.g. for a
+teaching tool: each expression that was evaluated, with its position and
+what it matched or where it failed, the numbered alternatives of the
+choices and, if the rule did not match, the farthest failure and the error.
+E.g., for Number = [0-9]+ !. and the input "12a":
+	explain rule Number:
+	  sequence 1:1: failed
+	    one or more 1:1: matched "12"
+	      class [0-9] 1:1: matched "1"
+	      class [0-9] 1:2: matched "2"
+	      class [0-9] 1:3: failed at "a"
+	    not predicate 1:3: failed
+	      any character 1:3: matched "a"
+	farthest failure: not predicate 1:3, at "a"
+	rule Number failed: ...
+
+A ReusableParser returned by NewReusableParser parses the input set by
+its Reset method with its Parse method, and keeps the buffers allocated
+by a parse, such as the stacks and the memoization table, for the next
+one, so that parsing many inputs in a hot path allocates less than with
+fresh parsers. Reset clears the state of the previous parse, and unlike
+a Parser, a ReusableParser must not be used by multiple goroutines at the
+same time. E.g.:
+	p := NewReusableParser(Memoize(true))
+	for _, in := range inputs {
+		p.Reset("", in)
+		v, err := p.Parse()
+		// ...
+	}
+
+The Statistics option records the number of matches and failed matches
+of each rule of a parse in a Stats, and the peak depths of the stacks of
+the parser. The Add method of Stats accumulates the statistics of several
+parses, and its Prometheus method formats them in the Prometheus text
+exposition format, e.g. for a metrics endpoint of a long-running service.
+E.g.:
+	var total, st Stats
+	for _, in := range inputs {
+		Parse("", in, Statistics(&st))
+		total.Add(st)
+	}
+	fmt.Print(total.Prometheus())
+
+The OnMatch option sets a function that is called for each match of
+a rule in the successful parse, with its start and end positions and its
+value, e.g. to collect the symbols of a document. Unlike the debug output,
+it does not report the matches that were backtracked.
+
+The Events option sets a function that is called for the start and the end
+of each match of a rule in the successful parse, and for the text matched
+by its matchers in between, in the order of the input, as a SAX parser
+does. For the grammar
+	A = B B
+	B = 'x'
+generated with -no-inline and the input "xx", the events are the start of
+A, the start of B, the text "x", the end of B, the same three events for
+the second B, and the end of A. The events are recorded as the input is
+parsed and reported once it is parsed, so that the matches that were
+backtracked are not reported.
+
+The Transform option sets a function that post-processes the value of
+each match of a rule, after its action, without changing the grammar.
+
+The generated parser does not copy its input, so a large file can be
+mapped in memory and parsed without reading it, with the ParseFile function
+of the github.com/craiggwilson/pigeon/mmap package. The values returned by
+the parser must then not retain slices of the input, like the text of a
+match, as it is unmapped when ParseFile returns. E.g.:
+	v, err := mmap.ParseFile("input.txt", func(nm string, b []byte) (interface{}, error) {
+		return Parse(nm, b)
+	})
+
 Like the grammar used to generate the parser, the input text must be
 UTF-8-encoded Unicode.
 
@@ -345,6 +1426,19 @@ The original error can be accessed this way:
 		}
 	}
 
+The errors of the parser itself have exported types, so that the callers
+can tell them apart with errors.As, errList and *parserError unwrapping to
+their errors: *UnexpectedToken for a syntax error before the end of the
+input, with the input Found and the Expected matchers, *UnexpectedEOF for a
+syntax error at the end of the input, *MaxDepthExceeded,
+*MaxRepeatExceeded and *InputTooLarge for the limits of the MaxDepth,
+MaxRepeat and MaxInputRunes options, and *TrailingInput for the
+RequireTrailingEOF option. E.g.:
+	var eof *UnexpectedEOF
+	if _, err := Parse("", b); errors.As(err, &eof) {
+		// ask for more input
+	}
+
 By defaut the parser will continue after an error is returned and will
 cumulate all errors found during parsing. If the grammar reaches a point
 where it shouldn't continue, a panic statement can be used to terminate
@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -22,12 +23,42 @@ func main() {
 	// define command-line flags
 	var (
 		cacheFlag     = fs.Bool("cache", false, "cache parsing results")
+		captureFlag   = fs.Bool("capture-spans", false, "record the positions of the labeled expressions")
+		checkFlag     = fs.Bool("check-labels", false, "report the undefined identifiers of the code blocks")
+		commentsFlag  = fs.Bool("comments", false, "add grammar position comments to the generated code")
+		ctxTypeFlag   = fs.String("context-type", "current", "name of the type of the receiver of the generated methods")
 		dbgFlag       = fs.Bool("debug", false, "set debug mode")
+		defineFlag    = fs.String("define", "", "comma-separated list of features to define")
+		dirFlag       = fs.String("dir", "", "output directory of the parser split in several files")
+		dispatchFlag  = fs.Bool("dispatch", false, "generate the Dispatch function for the entrypoint rules")
+		memoFlag      = fs.Bool("default-memoize", false, "make the generated parser memoize by default")
+		embedSrcFlag  = fs.Bool("embed-source", false, "embed the grammar source in the generated parser")
+		emitMainFlag  = fs.Bool("emit-main", false, "generate a main function that parses the file argument or stdin")
+		examplesFlag  = fs.String("examples", "", "output file of the test of the @example inputs of the grammar")
+		failUndefFlag = fs.Bool("fail-undefined", false, "return an error for the first reference to an undefined rule")
 		shortHelpFlag = fs.Bool("h", false, "show help page")
 		longHelpFlag  = fs.Bool("help", false, "show help page")
+		goVersionFlag = fs.String("go-version", "", "version of Go that the generated code must compile with")
+		httpFlag      = fs.Bool("http-handler", false, "generate the ServeParse HTTP handler")
+		importsFlag   = fs.String("imports", "", "comma-separated list of the import paths of the packages used by the code blocks")
+		lexerFlag     = fs.Bool("lexer", false, "generate the Tokenize function for the lexical rules")
+		listingFlag   = fs.String("listing", "", "output file of the text listing of the rules of the grammar")
+		memoLvlFlag   = fs.String("memo-level", "", "what the generated parser memoizes: none, rule or expr")
+		nsFlag        = fs.String("namespaces", "", "comma-separated list of NS=FILE grammars whose rules are referenced as NS::Rule")
+		noInlineFlag  = fs.Bool("no-inline", false, "do not inline the rules that consist of a single matcher")
+		noPanicFlag   = fs.Bool("no-panic", false, "generate a parser that never calls panic nor recover")
 		noRecoverFlag = fs.Bool("no-recover", false, "do not recover from panic")
 		outputFlag    = fs.String("o", "", "output file, defaults to stdout")
+		pkgNmFlag     = fs.String("package", "", "package name of the generated parser")
 		recvrNmFlag   = fs.String("receiver-name", "c", "receiver name for the generated methods")
+		rulesFlag     = fs.String("rules", "", "comma-separated list of the rules to generate, with their dependencies")
+		skipFlag      = fs.String("skip", "", "name of the rule to skip before the matchers of the non-lexical rules")
+		spansFlag     = fs.Bool("spans", false, "add the start and end positions to the struct types")
+		stripFlag     = fs.Bool("strip-actions", false, "generate a recognizer without the code blocks of the grammar")
+		stringersFlag = fs.Bool("stringers", false, "generate String methods for the struct types")
+		structsFlag   = fs.Bool("structs", false, "generate struct types for the labeled sequences without action")
+		tagFlag       = fs.Bool("tag-branches", false, "tag the values of the repeated choices with the alternative that matched")
+		visitorFlag   = fs.Bool("visitor", false, "generate a visitor interface and a walk function for the struct types")
 		noBuildFlag   = fs.Bool("x", false, "do not build, only parse")
 	)
 
@@ -50,21 +81,159 @@ func main() {
 	}
 	nm, rc := input(infile)
 	defer rc.Close()
+	src, err := ioutil.ReadAll(rc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "parse error(s):\n", err)
+		exit(3)
+	}
 
 	// parse input
-	g, err := ParseReader(nm, rc, Debug(*dbgFlag), Memoize(*cacheFlag), Recover(!*noRecoverFlag))
+	g, err := Parse(nm, src, Debug(*dbgFlag), Memoize(*cacheFlag), Recover(!*noRecoverFlag))
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "parse error(s):\n", err)
 		exit(3)
 	}
+	for _, def := range strings.Split(*nsFlag, ",") {
+		if def = strings.TrimSpace(def); def == "" {
+			continue
+		}
+		ix := strings.Index(def, "=")
+		if ix < 0 {
+			argError(1, "invalid namespace %q, want NS=FILE", def)
+		}
+		merged, err := parseNamespace(g.(*ast.Grammar), def[:ix], def[ix+1:], *dbgFlag, *cacheFlag, !*noRecoverFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "parse error(s):\n", err)
+			exit(3)
+		}
+		g = merged
+	}
+	for _, sh := range ast.Shadows(g.(*ast.Grammar)) {
+		fmt.Fprintln(os.Stderr, "warning:", sh)
+	}
 
 	if !*noBuildFlag {
 		// generate parser
+		curNmOpt := builder.ReceiverName(*recvrNmFlag)
+		opts := []builder.Option{curNmOpt, builder.ContextType(*ctxTypeFlag), builder.Source(src)}
+		if *pkgNmFlag != "" {
+			opts = append(opts, builder.PackageName(*pkgNmFlag))
+		}
+		for _, nm := range strings.Split(*defineFlag, ",") {
+			if nm = strings.TrimSpace(nm); nm != "" {
+				opts = append(opts, builder.Define(nm))
+			}
+		}
+		var imports []string
+		for _, path := range strings.Split(*importsFlag, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				imports = append(imports, path)
+			}
+		}
+		if len(imports) > 0 {
+			opts = append(opts, builder.Imports(imports...))
+		}
+		var rules []string
+		for _, nm := range strings.Split(*rulesFlag, ",") {
+			if nm = strings.TrimSpace(nm); nm != "" {
+				rules = append(rules, nm)
+			}
+		}
+		if len(rules) > 0 {
+			opts = append(opts, builder.OnlyRules(rules...))
+		}
+		if *checkFlag {
+			opts = append(opts, builder.CheckLabels(true))
+		}
+		if *failUndefFlag {
+			opts = append(opts, builder.FailUndefinedRules(true))
+		}
+		if *lexerFlag {
+			opts = append(opts, builder.EmitLexer(true))
+		}
+		if *httpFlag {
+			opts = append(opts, builder.EmitHTTPHandler(true))
+		}
+		if *emitMainFlag {
+			opts = append(opts, builder.EmitMain(true))
+		}
+		if *goVersionFlag != "" {
+			opts = append(opts, builder.GoVersion(*goVersionFlag))
+		}
+		if *embedSrcFlag {
+			opts = append(opts, builder.EmbedSource(true))
+		}
+		if *commentsFlag {
+			opts = append(opts, builder.Comments(true))
+		}
+		if *skipFlag != "" {
+			opts = append(opts, builder.Skip(*skipFlag))
+		}
+		if *structsFlag {
+			opts = append(opts, builder.Structs(true))
+		}
+		if *stringersFlag {
+			opts = append(opts, builder.EmitStringers(true))
+		}
+		if *tagFlag {
+			opts = append(opts, builder.TagBranches(true))
+		}
+		if *visitorFlag {
+			opts = append(opts, builder.EmitVisitor(true))
+		}
+		if *spansFlag {
+			opts = append(opts, builder.StructSpans(true))
+		}
+		if *captureFlag {
+			opts = append(opts, builder.CaptureSpans(true))
+		}
+		if *stripFlag {
+			opts = append(opts, builder.StripActions(true))
+		}
+		if *noInlineFlag {
+			opts = append(opts, builder.NoInline(true))
+		}
+		if *noPanicFlag {
+			opts = append(opts, builder.NoPanic(true))
+		}
+		if *dispatchFlag {
+			opts = append(opts, builder.EmitDispatch(true))
+		}
+		if *memoFlag {
+			opts = append(opts, builder.DefaultMemoize(true))
+		}
+		if *memoLvlFlag != "" {
+			opts = append(opts, builder.MemoLevel(*memoLvlFlag))
+		}
+		if *examplesFlag != "" {
+			out := output(*examplesFlag)
+			err := builder.BuildExamplesTest(out, g.(*ast.Grammar), opts...)
+			out.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "build error: ", err)
+				exit(5)
+			}
+		}
+		if *listingFlag != "" {
+			out := output(*listingFlag)
+			err := builder.BuildListing(out, g.(*ast.Grammar), opts...)
+			out.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "build error: ", err)
+				exit(5)
+			}
+		}
+		if *dirFlag != "" {
+			if err := builder.BuildParserDir(*dirFlag, g.(*ast.Grammar), opts...); err != nil {
+				fmt.Fprintln(os.Stderr, "build error: ", err)
+				exit(5)
+			}
+			return
+		}
+
 		out := output(*outputFlag)
 		defer out.Close()
-
-		curNmOpt := builder.ReceiverName(*recvrNmFlag)
-		if err := builder.BuildParser(out, g.(*ast.Grammar), curNmOpt); err != nil {
+		if err := builder.BuildParser(out, g.(*ast.Grammar), opts...); err != nil {
 			fmt.Fprintln(os.Stderr, "build error: ", err)
 			exit(5)
 		}
@@ -93,18 +262,114 @@ the generated code is written to this file instead.
 		cache parser results to avoid exponential parsing time in
 		pathological cases. Can make the parsing slower for typical
 		cases and uses more memory.
+	-capture-spans
+		record the start and end positions of the match of each labeled
+		expression, returned by c.span("label") in the code blocks.
+	-check-labels
+		report the identifiers of the code blocks that are not declared,
+		e.g. a label that is not in scope, as an error.
+	-comments
+		add comments to the generated code with the line of the grammar
+		that corresponds to each rule and code block.
+	-context-type NAME
+		use NAME as the name of the type of the receiver of the
+		generated methods instead of current, e.g. if the package
+		of the parser declares a current type.
 	-debug
 		output debugging information while parsing the grammar.
+	-default-memoize
+		make the generated parser memoize its results by default, as if
+		the Memoize(true) option was passed to the Parse functions. It
+		can be overridden with Memoize(false).
+	-define FEATURES
+		comma-separated list of features to define, so that the rules
+		and alternatives conditioned on them with @if are generated.
+	-dir DIR
+		write the parser to several files in the existing directory
+		DIR instead of a single file: parser.go, parser_grammar.go,
+		parser_actions.go and parser_runtime.go. Run goimports -w on
+		the files to add their imports.
+	-dispatch
+		generate the Dispatch function, that parses the input starting
+		at the entrypoint rule whose literal prefix the input starts
+		with.
+	-embed-source
+		embed the source text of the grammar in the generated parser,
+		available from the generated GrammarSource function.
+	-emit-main
+		generate a main function, that parses the file named by its
+		first argument or the standard input and prints the result.
+	-examples FILE
+		write to FILE a test that parses each @example input of the
+		grammar with the generated parser, e.g. parser_examples_test.go.
+	-fail-undefined
+		return an error naming the rules for the first reference to a
+		rule that the grammar does not define.
+	-go-version VERSION
+		return an error if the generated code, including the code
+		blocks, uses a package or a language feature that is not in
+		the version of Go VERSION, e.g. 1.18.
 	-h -help
 		display this help message.
+	-http-handler
+		generate the ServeParse HTTP handler, that parses the body of
+		the request and writes the result or the errors as JSON.
+	-imports PATHS
+		comma-separated list of the import paths of the packages used
+		by the code blocks, imported after the package clause unless
+		the initializer imports them.
+	-lexer
+		generate the Tokenize function, that splits the input into the
+		tokens matched by the lexical rules.
+	-listing FILE
+		write to FILE a text listing of the rules of the grammar, with
+		a line per expression, e.g. parser.pvm.
+	-memo-level LEVEL
+		set what the generated parser memoizes with the Memoize option:
+		none, rule for the rules only, or expr for the rules and their
+		expressions, the default.
+	-namespaces NS=FILE[,NS=FILE...]
+		add the rules of the grammar FILE in the namespace NS, so that
+		the grammar can reference them as NS::Rule.
+	-no-inline
+		do not inline the rules that consist of a single matcher, so
+		that the OnMatch and Events options report their matches.
+	-no-panic
+		generate a parser whose code never calls panic nor recover, so
+		that its Recover and PanicContext options have no effect.
 	-no-recover
 		do not recover from a panic. Useful to access the panic stack
 		when debugging, otherwise the panic is converted to an error.
 	-o OUTPUT_FILE
 		write the generated parser to OUTPUT_FILE. Defaults to stdout.
+	-package NAME
+		use NAME as the package name of the generated parser, replacing
+		the package clause of the grammar's initializer, if any.
 	-receiver-name NAME
 		use NAME as for the receiver name of the generated methods
 		for the grammar's code blocks. Defaults to "c".
+	-rules RULES
+		comma-separated list of the rules to generate, along with the
+		rules they reference. The first rule is the start rule.
+	-skip RULE
+		match the rule RULE automatically before the matchers and the
+		references to lexical rules in the rules not marked @lexical.
+	-spans
+		add the fields Pos and End to the struct types of -structs.
+	-strip-actions
+		generate a recognizer that ignores the initializer, the actions
+		and the code predicates of the grammar (that always match).
+	-stringers
+		generate a String method for the struct types of -structs.
+	-structs
+		generate a struct type for each rule that is a sequence of
+		labeled expressions without action, returned as its value.
+	-tag-branches
+		make the value of each iteration of a choice repeated by "*" or
+		"+" a Branch with the index of the alternative that matched.
+	-visitor
+		generate a Visitor interface and a Walk function for the struct
+		types of -structs.
 	-x
 		do not generate the parser, only parse the grammar.
 
@@ -126,6 +391,20 @@ func argError(exitCode int, msg string, args ...interface{}) {
 	exit(exitCode)
 }
 
+// parseNamespace parses the grammar file and merges its rules in g in the
+// namespace ns.
+func parseNamespace(g *ast.Grammar, ns, file string, debug, memoize, recover bool) (*ast.Grammar, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := Parse(file, src, Debug(debug), Memoize(memoize), Recover(recover))
+	if err != nil {
+		return nil, err
+	}
+	return ast.MergeNamespace(g, ns, sub.(*ast.Grammar))
+}
+
 // input gets the name and reader to get input text from.
 func input(filename string) (nm string, rc io.ReadCloser) {
 	nm = "stdin"
@@ -0,0 +1,21 @@
+// Package mmap maps files in memory, so that a generated parser can parse
+// a large file without reading it in memory first. The parsers work on a
+// slice of bytes and don't copy the input, so the bytes of a mapped file
+// can be passed directly to the generated Parse function.
+//
+// The data of a mapped file is only valid until the file is closed: the
+// values returned by the parser must not retain slices of the input, like
+// the text of a match in a code block, without copying them.
+package mmap
+
+// ParseFile maps the file at path in memory, calls parse with path and the
+// bytes of the file, and unmaps the file before returning the result of
+// parse, including when parse panics.
+func ParseFile(path string, parse func(string, []byte) (interface{}, error)) (interface{}, error) {
+	f, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(path, f.Bytes())
+}
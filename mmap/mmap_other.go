@@ -0,0 +1,34 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package mmap
+
+import "io/ioutil"
+
+// File is a file mapped in memory. On this platform, the file is read in
+// memory instead.
+type File struct {
+	data []byte
+}
+
+// Open reads the file at path in memory.
+func Open(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{data: data}, nil
+}
+
+// Bytes returns the content of the file. It is only valid until the file
+// is closed.
+func (f *File) Bytes() []byte {
+	return f.data
+}
+
+// Close releases the content of the file. It is safe to call it more than
+// once.
+func (f *File) Close() error {
+	f.data = nil
+	return nil
+}
@@ -0,0 +1,74 @@
+package mmap
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "mmap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "input")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	for _, content := range []string{"", "abc\ndef\n"} {
+		path := tempFile(t, content)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		f, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(f.Bytes()); got != content {
+			t.Errorf("want %q, got %q", content, got)
+		}
+		if err := f.Close(); err != nil {
+			t.Error(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("second close: %v", err)
+		}
+		if f.Bytes() != nil {
+			t.Errorf("want no data after close")
+		}
+	}
+
+	if _, err := Open(filepath.Join(os.TempDir(), "does-not-exist")); err == nil {
+		t.Errorf("want error for a missing file, got none")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := tempFile(t, "abc")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	got, err := ParseFile(path, func(nm string, b []byte) (interface{}, error) {
+		if nm != path {
+			t.Errorf("want file name %q, got %q", path, nm)
+		}
+		return string(b), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "abc" {
+		t.Errorf("want %q, got %q", "abc", got)
+	}
+
+	want := errors.New("parse error")
+	if _, err := ParseFile(path, func(string, []byte) (interface{}, error) {
+		return nil, want
+	}); err != want {
+		t.Errorf("want error %v, got %v", want, err)
+	}
+}
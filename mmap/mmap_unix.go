@@ -0,0 +1,57 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// File is a file mapped in memory.
+type File struct {
+	data []byte
+}
+
+// Open maps the file at path in memory, read-only.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		// an empty file cannot be mapped
+		return &File{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, &os.PathError{Op: "mmap", Path: path, Err: syscall.EFBIG}
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+	return &File{data: data}, nil
+}
+
+// Bytes returns the content of the file. It is only valid until the file
+// is closed.
+func (f *File) Bytes() []byte {
+	return f.data
+}
+
+// Close unmaps the file. It is safe to call it more than once.
+func (f *File) Close() error {
+	if f.data == nil {
+		return nil
+	}
+	data := f.data
+	f.data = nil
+	return syscall.Munmap(data)
+}
@@ -1,24 +1,31 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/craiggwilson/pigeon/ast"
+	"github.com/craiggwilson/pigeon/builder"
 )
 
 var invalidParseCases = map[string]string{
-	"":           "file:1:1 (0): no match found",
-	"a":          "file:1:1 (0): no match found",
-	"abc":        "file:1:1 (0): no match found",
-	" ":          "file:1:1 (0): no match found",
-	`a = +`:      "file:1:1 (0): no match found",
-	`a = *`:      "file:1:1 (0): no match found",
-	`a = ?`:      "file:1:1 (0): no match found",
-	"a ←":        "file:1:1 (0): no match found",
-	"a ← b\nb ←": "file:1:1 (0): no match found",
-	"a ← nil:b":  "file:1:5 (6): rule Identifier: identifier is a reserved word",
-	"\xfe":       "file:1:1 (0): invalid encoding",
-	"{}{}":       "file:1:1 (0): no match found",
+	"":                                  "file:1:1 (0): no match found",
+	"a":                                 "file:1:1 (0): no match found",
+	"abc":                               "file:1:1 (0): no match found",
+	" ":                                 "file:1:1 (0): no match found",
+	`a = +`:                             "file:1:1 (0): no match found",
+	`a = *`:                             "file:1:1 (0): no match found",
+	`a = ?`:                             "file:1:1 (0): no match found",
+	"a ←":                               "file:1:1 (0): no match found",
+	"a ← b\nb ←":                        "file:1:1 (0): no match found",
+	"a ← nil:b":                         "file:1:5 (6): rule Identifier: identifier is a reserved word",
+	"\xfe":                              "file:1:1 (0): invalid encoding",
+	"a = Byte(256)":                     "file:1:10 (9): rule ByteValue: invalid byte value",
+	"@meta(k='a') @meta(k='b') a = 'a'": "file:1:1 (0): rule Rule: duplicate metadata key \"k\"",
+	"@type(\" \") a = 'a'":              "file:1:1 (0): rule RuleType: the @type of a rule must not be empty",
+	"@budget(0) a = 'a'":                "file:1:1 (0): rule RuleBudget: the @budget of a rule must be a positive number",
+	"{}{}":                              "file:1:1 (0): no match found",
 
 	// non-terminated, empty, EOF "quoted" tokens
 	"{":         "file:1:1 (0): rule CodeBlock: code block not terminated",
@@ -165,6 +172,32 @@ file:1:26 (25): rule ShortUnicodeEscape: invalid Unicode escape`,
 	`a = "\U0000DFFF"`: "file:1:7 (6): rule LongUnicodeEscape: invalid Unicode escape",
 	`a = "\U0000D800"`: "file:1:7 (6): rule LongUnicodeEscape: invalid Unicode escape",
 	`a = "\U0000D801"`: "file:1:7 (6): rule LongUnicodeEscape: invalid Unicode escape",
+
+	// number matcher options
+	`a = Nested("", "*/")`:                    "file:1:5 (4): rule NestedMatcher: Nested delimiters must not be empty",
+	`a = Number(base: 2)`:                     "file:1:5 (4): rule NumberMatcher: unknown Number option base",
+	`a = Number(sign: 1)`:                     "file:1:5 (4): rule NumberMatcher: Number option sign must be true or false",
+	`a = Number(radix: 37)`:                   "file:1:5 (4): rule NumberMatcher: invalid Number radix",
+	`a = Number(float: true, radix: 2)`:       "file:1:5 (4): rule NumberMatcher: Number float option requires a radix of 10",
+	`a = Number(prefix: true, radix: 8)`:      "file:1:5 (4): rule NumberMatcher: Number prefix option cannot be used with the float and radix options",
+	`a = Number(thousands: "ab")`:             "file:1:5 (4): rule NumberMatcher: Number option thousands must be a single character that is not a digit, a letter or a sign",
+	`a = Number(decimal: '1')`:                "file:1:5 (4): rule NumberMatcher: Number option decimal must be a single character that is not a digit, a letter or a sign",
+	`a = Number(decimal: ',')`:                "file:1:5 (4): rule NumberMatcher: Number decimal option requires the float option",
+	`a = Number(radix: 16, thousands: ',')`:   "file:1:5 (4): rule NumberMatcher: Number thousands option requires a radix of 10",
+	`a = Number(float: true, thousands: '.')`: "file:1:5 (4): rule NumberMatcher: Number thousands and decimal separators must differ",
+
+	// array expressions
+	`a = @array(b, 2, " ")`: "file:1:5 (4): rule ArrayExpr: the type of an @array must not be empty",
+
+	// map expressions
+	`a = @map(k:b v:c, k, v, "first")`: "file:1:5 (4): rule MapExpr: the duplicate keys of a @map must be \"error\" or \"last\"",
+
+	// longest choices
+	`a = @longest( 'a' )`: "file:1:5 (4): rule LongestExpr: the expression of @longest must be a choice",
+
+	// repetition conditions only apply to * and +
+	`a = b?{ &{ } }`:                     "file:1:5 (4): rule SuffixedExpr: repetition condition on a ? expression",
+	`a = b ?? { return 0, nil }{ &{ } }`: "file:1:5 (4): rule SuffixedExpr: repetition condition on a ?? expression",
 }
 
 func TestInvalidParseCases(t *testing.T) {
@@ -195,6 +228,14 @@ var validParseCases = map[string]*ast.Grammar{
 			},
 		},
 	},
+	"a = b::Expr": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b::Expr")},
+			},
+		},
+	},
 	"a ← b\nc=d \n e <- f \ng\u27f5h": &ast.Grammar{
 		Rules: []*ast.Rule{
 			{
@@ -234,6 +275,38 @@ var validParseCases = map[string]*ast.Grammar{
 			},
 		},
 	},
+	"{ init }\n@fields { n int }\na ← b": &ast.Grammar{
+		Init:   ast.NewCodeBlock(ast.Pos{}, "{ init }"),
+		Fields: ast.NewCodeBlock(ast.Pos{}, "{ n int }"),
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+		},
+	},
+	"@fields { n int }\n@state { depth int }\na ← b": &ast.Grammar{
+		Fields: ast.NewCodeBlock(ast.Pos{}, "{ n int }"),
+		State:  ast.NewCodeBlock(ast.Pos{}, "{ depth int }"),
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+		},
+	},
+	"@example \"1+2\"\n@example `3`\na ← b": &ast.Grammar{
+		Examples: []*ast.StringLit{
+			ast.NewStringLit(ast.Pos{}, `"1+2"`),
+			ast.NewStringLit(ast.Pos{}, "`3`"),
+		},
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+		},
+	},
 	"a\n<-\nb": &ast.Grammar{
 		Rules: []*ast.Rule{
 			{
@@ -414,6 +487,751 @@ var validParseCases = map[string]*ast.Grammar{
 			},
 		},
 	},
+	`a = "/*" Until("*/") "*/"`: &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewLitMatcher(ast.Pos{}, "/*"),
+						ast.NewUntilMatcher(ast.Pos{}, "*/"),
+						ast.NewLitMatcher(ast.Pos{}, "*/"),
+					},
+				},
+			},
+		},
+	},
+	"a = Until( '\\n' )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: ast.NewUntilMatcher(ast.Pos{}, "\n"),
+			},
+		},
+	},
+	"@meta(doc=\"the a rule\", tag=`x`)\n@meta( kind = 'k' ) @if(x) a = 'a'\nb = 'b'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Cond: ast.NewIdentifier(ast.Pos{}, "x"),
+				Meta: map[string]string{"doc": "the a rule", "tag": "x", "kind": "k"},
+				Expr: ast.NewLitMatcher(ast.Pos{}, "a"),
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "b"),
+				Expr: ast.NewLitMatcher(ast.Pos{}, "b"),
+			},
+		},
+	},
+	"a = b\n@entry b = 'b'\n@if(x) @entry @lexical c = 'c'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+			{
+				Name:  ast.NewIdentifier(ast.Pos{}, "b"),
+				Entry: true,
+				Expr:  ast.NewLitMatcher(ast.Pos{}, "b"),
+			},
+			{
+				Name:    ast.NewIdentifier(ast.Pos{}, "c"),
+				Cond:    ast.NewIdentifier(ast.Pos{}, "x"),
+				Entry:   true,
+				Lexical: true,
+				Expr:    ast.NewLitMatcher(ast.Pos{}, "c"),
+			},
+		},
+	},
+	"@type(\"*AST\") a = b\n@entry @lexical @type(`[]string`) b = 'b'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Type: "*AST",
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+			{
+				Name:    ast.NewIdentifier(ast.Pos{}, "b"),
+				Entry:   true,
+				Lexical: true,
+				Type:    "[]string",
+				Expr:    ast.NewLitMatcher(ast.Pos{}, "b"),
+			},
+		},
+	},
+	"@lexical @silent _ = [ \t]\n@silent b = 'b'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name:    ast.NewIdentifier(ast.Pos{}, "_"),
+				Lexical: true,
+				Silent:  true,
+				Expr:    ast.NewCharClassMatcher(ast.Pos{}, "[ \t]"),
+			},
+			{
+				Name:   ast.NewIdentifier(ast.Pos{}, "b"),
+				Silent: true,
+				Expr:   ast.NewLitMatcher(ast.Pos{}, "b"),
+			},
+		},
+	},
+	"a = b b\n@inline b = 'b' / 'c'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+			{
+				Name:   ast.NewIdentifier(ast.Pos{}, "b"),
+				Inline: true,
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						ast.NewLitMatcher(ast.Pos{}, "b"),
+						ast.NewLitMatcher(ast.Pos{}, "c"),
+					},
+				},
+			},
+		},
+	},
+	"@silent @nlsignificant a = 'a'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name:          ast.NewIdentifier(ast.Pos{}, "a"),
+				Silent:        true,
+				NLSignificant: true,
+				Expr:          ast.NewLitMatcher(ast.Pos{}, "a"),
+			},
+		},
+	},
+	"@entry @budget( 100 ) a = 'a'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name:   ast.NewIdentifier(ast.Pos{}, "a"),
+				Entry:  true,
+				Budget: 100,
+				Expr:   ast.NewLitMatcher(ast.Pos{}, "a"),
+			},
+		},
+	},
+	"@lexical a = 'a'\n@if(x) @lexical b = 'b'\nc = a": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name:    ast.NewIdentifier(ast.Pos{}, "a"),
+				Lexical: true,
+				Expr:    ast.NewLitMatcher(ast.Pos{}, "a"),
+			},
+			{
+				Name:    ast.NewIdentifier(ast.Pos{}, "b"),
+				Cond:    ast.NewIdentifier(ast.Pos{}, "x"),
+				Lexical: true,
+				Expr:    ast.NewLitMatcher(ast.Pos{}, "b"),
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "a")},
+			},
+		},
+	},
+	"@if(x) a = 'a' / @if(y) 'b' / 'c'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Cond: ast.NewIdentifier(ast.Pos{}, "x"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						ast.NewLitMatcher(ast.Pos{}, "a"),
+						&ast.IfExpr{
+							Cond: ast.NewIdentifier(ast.Pos{}, "y"),
+							Expr: ast.NewLitMatcher(ast.Pos{}, "b"),
+						},
+						ast.NewLitMatcher(ast.Pos{}, "c"),
+					},
+				},
+			},
+		},
+	},
+	"a = @when(strict) 'a' { return nil, nil } / @when( x ) 'b'": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						&ast.WhenExpr{
+							Flag: ast.NewIdentifier(ast.Pos{}, "strict"),
+							Expr: &ast.ActionExpr{
+								Expr: ast.NewLitMatcher(ast.Pos{}, "a"),
+								Code: ast.NewCodeBlock(ast.Pos{}, "{ return nil, nil }"),
+							},
+						},
+						&ast.WhenExpr{
+							Flag: ast.NewIdentifier(ast.Pos{}, "x"),
+							Expr: ast.NewLitMatcher(ast.Pos{}, "b"),
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = @samedent 'a' ':' @indent b+ @dedent": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewIndentMatcher(ast.Pos{}, "samedent"),
+						ast.NewLitMatcher(ast.Pos{}, "a"),
+						ast.NewLitMatcher(ast.Pos{}, ":"),
+						ast.NewIndentMatcher(ast.Pos{}, "indent"),
+						&ast.OneOrMoreExpr{
+							Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+						},
+						ast.NewIndentMatcher(ast.Pos{}, "dedent"),
+					},
+				},
+			},
+		},
+	},
+	"a = Nested(\"/*\", \"*/\") Nested( '(' , `)` )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewNestedMatcher(ast.Pos{}, "/*", "*/"),
+						ast.NewNestedMatcher(ast.Pos{}, "(", ")"),
+					},
+				},
+			},
+		},
+	},
+	"a = key ':' RestOfLine() RestOfLine( )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "key")},
+						ast.NewLitMatcher(ast.Pos{}, ":"),
+						ast.NewRestOfLineMatcher(ast.Pos{}),
+						ast.NewRestOfLineMatcher(ast.Pos{}),
+					},
+				},
+			},
+		},
+	},
+	"a = Number() Number( float : true, sign: true ) Number(radix: 16, sign: false)": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.NumberMatcher{Radix: 10},
+						&ast.NumberMatcher{Float: true, Sign: true, Radix: 10},
+						&ast.NumberMatcher{Radix: 16},
+					},
+				},
+			},
+		},
+	},
+	"a = Number(float: true, thousands: '.', decimal: ',')": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.NumberMatcher{Float: true, Radix: 10, Thousands: '.', Decimal: ','},
+			},
+		},
+	},
+	"a = Number(prefix: true, sign: true)": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.NumberMatcher{Sign: true, Prefix: true, Radix: 10},
+			},
+		},
+	},
+	"a = n:Bytes(4) Bytes(n) Byte(0x0A) Byte(255)": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.LabeledExpr{
+							Label: ast.NewIdentifier(ast.Pos{}, "n"),
+							Expr:  &ast.BytesMatcher{N: 4},
+						},
+						&ast.BytesMatcher{Label: ast.NewIdentifier(ast.Pos{}, "n")},
+						ast.NewByteMatcher(ast.Pos{}, 0x0a),
+						ast.NewByteMatcher(ast.Pos{}, 0xff),
+					},
+				},
+			},
+		},
+	},
+	"a = @token(kindNum) @token( lex.Plus ) @token(3) !@token": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewTokenMatcher(ast.Pos{}, "kindNum"),
+						ast.NewTokenMatcher(ast.Pos{}, "lex.Plus"),
+						ast.NewTokenMatcher(ast.Pos{}, "3"),
+						&ast.NotExpr{Expr: ast.NewTokenMatcher(ast.Pos{}, "")},
+					},
+				},
+			},
+		},
+	},
+	"a = !@keyword b": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.NotExpr{Expr: ast.NewKeywordMatcher(ast.Pos{})},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+		},
+	},
+	"a = \"{{\" @verbatim( Until(\"}}\") ) \"}}\"": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewLitMatcher(ast.Pos{}, "{{"),
+						&ast.VerbatimExpr{Expr: ast.NewUntilMatcher(ast.Pos{}, "}}")},
+						ast.NewLitMatcher(ast.Pos{}, "}}"),
+					},
+				},
+			},
+		},
+	},
+	"@alias DIGIT = [0-9]\n@alias HEX = [a-f]\na = [DIGIT A-F] DIGIT [HEX DIGIT]i [D G]": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewCharClassMatcher(ast.Pos{}, "[0-9A-F]"),
+						ast.NewCharClassMatcher(ast.Pos{}, "[0-9]"),
+						ast.NewCharClassMatcher(ast.Pos{}, "[a-f0-9]i"),
+						ast.NewCharClassMatcher(ast.Pos{}, "[D G]"),
+					},
+				},
+			},
+		},
+	},
+	"a = @wordlist / b": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						ast.NewWordListMatcher(ast.Pos{}),
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+		},
+	},
+	"a = @table(IdentStart) @table( Digit )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewTableMatcher(ast.Pos{}, "IdentStart"),
+						ast.NewTableMatcher(ast.Pos{}, "Digit"),
+					},
+				},
+			},
+		},
+	},
+	"a = @array( b, 3 ) @array(Byte(0), 2, \"uint8\")": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.ArrayExpr{Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")}, N: 3},
+						&ast.ArrayExpr{Expr: &ast.ByteMatcher{}, N: 2, Type: "uint8"},
+					},
+				},
+			},
+		},
+	},
+	"a = @map( k:b '=' v:c, k, v ) @map(k:b v:c, k, v, \"last\")": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.MapExpr{
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									&ast.LabeledExpr{Label: ast.NewIdentifier(ast.Pos{}, "k"), Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")}},
+									ast.NewLitMatcher(ast.Pos{}, "="),
+									&ast.LabeledExpr{Label: ast.NewIdentifier(ast.Pos{}, "v"), Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "c")}},
+								},
+							},
+							Key: ast.NewIdentifier(ast.Pos{}, "k"),
+							Val: ast.NewIdentifier(ast.Pos{}, "v"),
+						},
+						&ast.MapExpr{
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									&ast.LabeledExpr{Label: ast.NewIdentifier(ast.Pos{}, "k"), Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")}},
+									&ast.LabeledExpr{Label: ast.NewIdentifier(ast.Pos{}, "v"), Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "c")}},
+								},
+							},
+							Key:  ast.NewIdentifier(ast.Pos{}, "k"),
+							Val:  ast.NewIdentifier(ast.Pos{}, "v"),
+							Last: true,
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = @longest( 'a' / \"ab\" )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						ast.NewLitMatcher(ast.Pos{}, "a"),
+						ast.NewLitMatcher(ast.Pos{}, "ab"),
+					},
+					Longest: true,
+				},
+			},
+		},
+	},
+	"a = @compact( 'a' b? 'c' )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.CompactExpr{
+					Expr: &ast.SeqExpr{
+						Exprs: []ast.Expression{
+							ast.NewLitMatcher(ast.Pos{}, "a"),
+							&ast.ZeroOrOneExpr{Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")}},
+							ast.NewLitMatcher(ast.Pos{}, "c"),
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = $trim( 'a' b* )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.TrimExpr{
+					Expr: &ast.SeqExpr{
+						Exprs: []ast.Expression{
+							ast.NewLitMatcher(ast.Pos{}, "a"),
+							&ast.ZeroOrMoreExpr{Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")}},
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = @int( [0-9]+ ) @date(b)": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.ConvertExpr{
+							Name: ast.NewIdentifier(ast.Pos{}, "int"),
+							Expr: &ast.OneOrMoreExpr{Expr: ast.NewCharClassMatcher(ast.Pos{}, "[0-9]")},
+						},
+						&ast.ConvertExpr{
+							Name: ast.NewIdentifier(ast.Pos{}, "date"),
+							Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = x:b ?? { return 0, nil } c?": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.LabeledExpr{
+							Label: ast.NewIdentifier(ast.Pos{}, "x"),
+							Expr: &ast.ZeroOrOneExpr{
+								Expr:    &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+								Default: ast.NewCodeBlock(ast.Pos{}, "{ return 0, nil }"),
+							},
+						},
+						&ast.ZeroOrOneExpr{Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "c")}},
+					},
+				},
+			},
+		},
+	},
+	"a = @keyword / @unreserved( [a-z] b* )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						ast.NewKeywordMatcher(ast.Pos{}),
+						&ast.UnreservedExpr{
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									ast.NewCharClassMatcher(ast.Pos{}, "[a-z]"),
+									&ast.ZeroOrMoreExpr{
+										Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = b*{ &{ return true, nil } }\nc = b+ { &{ } } { }": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ZeroOrMoreExpr{
+					Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					While: &ast.AndCodeExpr{
+						Code: ast.NewCodeBlock(ast.Pos{}, "{ return true, nil }"),
+					},
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.ActionExpr{
+					Expr: &ast.OneOrMoreExpr{
+						Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+						While: &ast.AndCodeExpr{
+							Code: ast.NewCodeBlock(ast.Pos{}, "{ }"),
+						},
+					},
+					Code: ast.NewCodeBlock(ast.Pos{}, "{ }"),
+				},
+			},
+		},
+	},
+	"a = <=[ \\t] '-' / !<= . <= \"ab\"": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						&ast.SeqExpr{
+							Exprs: []ast.Expression{
+								&ast.LookbehindExpr{Expr: ast.NewCharClassMatcher(ast.Pos{}, "[ \\t]")},
+								ast.NewLitMatcher(ast.Pos{}, "-"),
+							},
+						},
+						&ast.SeqExpr{
+							Exprs: []ast.Expression{
+								&ast.NotExpr{Expr: &ast.LookbehindExpr{Expr: ast.NewAnyMatcher(ast.Pos{}, ".")}},
+								&ast.LookbehindExpr{Expr: ast.NewLitMatcher(ast.Pos{}, "ab")},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	"a = x:b @=x\nc = @=x": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.LabeledExpr{
+							Label: ast.NewIdentifier(ast.Pos{}, "x"),
+							Expr:  &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+						},
+						&ast.BackRefExpr{Label: ast.NewIdentifier(ast.Pos{}, "x")},
+					},
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.BackRefExpr{Label: ast.NewIdentifier(ast.Pos{}, "x")},
+			},
+		},
+	},
+	"a = x:b\nc = @seen=x": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.LabeledExpr{
+					Label: ast.NewIdentifier(ast.Pos{}, "x"),
+					Expr:  &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.SeenExpr{Label: ast.NewIdentifier(ast.Pos{}, "x")},
+			},
+		},
+	},
+	"a = @sep(b, ',')\nc = @sep( b / 'x' , ( _ ';' ) , trailing )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SepExpr{
+					Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					Sep:  ast.NewLitMatcher(ast.Pos{}, ","),
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.SepExpr{
+					Expr: &ast.ChoiceExpr{
+						Alternatives: []ast.Expression{
+							&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+							ast.NewLitMatcher(ast.Pos{}, "x"),
+						},
+					},
+					Sep: &ast.SeqExpr{
+						Exprs: []ast.Expression{
+							&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "_")},
+							ast.NewLitMatcher(ast.Pos{}, ";"),
+						},
+					},
+					Trailing: true,
+				},
+			},
+		},
+	},
+	"a = @sep(b, ',', keep)\nc = @sep( b / 'x' , ( _ ';' ) , trailing , keep )": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SepExpr{
+					Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					Sep:  ast.NewLitMatcher(ast.Pos{}, ","),
+					Keep: true,
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.SepExpr{
+					Expr: &ast.ChoiceExpr{
+						Alternatives: []ast.Expression{
+							&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+							ast.NewLitMatcher(ast.Pos{}, "x"),
+						},
+					},
+					Sep: &ast.SeqExpr{
+						Exprs: []ast.Expression{
+							&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "_")},
+							ast.NewLitMatcher(ast.Pos{}, ";"),
+						},
+					},
+					Trailing: true,
+					Keep:     true,
+				},
+			},
+		},
+	},
+	"a = @sep(b, ( ';' / &'}' / !. ), terminated)": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SepExpr{
+					Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					Sep: &ast.ChoiceExpr{
+						Alternatives: []ast.Expression{
+							ast.NewLitMatcher(ast.Pos{}, ";"),
+							&ast.AndExpr{Expr: ast.NewLitMatcher(ast.Pos{}, "}")},
+							&ast.NotExpr{Expr: ast.NewAnyMatcher(ast.Pos{}, ".")},
+						},
+					},
+					Terminated: true,
+				},
+			},
+		},
+	},
+	"a = x:@peek(b) b": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.LabeledExpr{
+							Label: ast.NewIdentifier(ast.Pos{}, "x"),
+							Expr: &ast.AndExpr{
+								Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+								Peek: true,
+							},
+						},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+		},
+	},
+	"a = b ('-' b)* @left\nc = b @right { }": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.FoldExpr{
+					Expr: &ast.SeqExpr{
+						Exprs: []ast.Expression{
+							&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+							&ast.ZeroOrMoreExpr{
+								Expr: &ast.SeqExpr{
+									Exprs: []ast.Expression{
+										ast.NewLitMatcher(ast.Pos{}, "-"),
+										&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "c"),
+				Expr: &ast.ActionExpr{
+					Expr: &ast.FoldExpr{
+						Expr:  &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+						Right: true,
+					},
+					Code: ast.NewCodeBlock(ast.Pos{}, "{ }"),
+				},
+			},
+		},
+	},
+	"a = @operators b { '+' \"-\" left 1; '^' right 2; }": &ast.Grammar{
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.OperatorsExpr{
+					Operand: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					Operators: []*ast.Operator{
+						ast.NewOperator(ast.Pos{}, ast.NewLitMatcher(ast.Pos{}, "+"), 1, false),
+						ast.NewOperator(ast.Pos{}, ast.NewLitMatcher(ast.Pos{}, "-"), 1, false),
+						ast.NewOperator(ast.Pos{}, ast.NewLitMatcher(ast.Pos{}, "^"), 2, true),
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestValidParseCases(t *testing.T) {
@@ -437,3 +1255,99 @@ again:
 		goto again
 	}
 }
+
+func TestParseSignatures(t *testing.T) {
+	src := "A \"alpha\" = 'a'\nB = A\n  'b' // comment\n"
+	got, err := Parse("", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ast.RuleSignature{
+		{Name: "A", DisplayName: "alpha", Pos: ast.Pos{Line: 1, Col: 1, Off: 0}, End: ast.Pos{Line: 2, Col: 0, Off: 15}},
+		{Name: "B", Pos: ast.Pos{Line: 2, Col: 1, Off: 16}, End: ast.Pos{Line: 3, Col: 6, Off: 27}},
+	}
+	sigs := ast.Signatures(got.(*ast.Grammar))
+	if len(sigs) != len(want) {
+		t.Fatalf("want %d signatures, got %d", len(want), len(sigs))
+	}
+	for i, w := range want {
+		if *sigs[i] != w {
+			t.Errorf("%d: want %+v, got %+v", i, w, *sigs[i])
+		}
+	}
+}
+
+func TestParseFingerprint(t *testing.T) {
+	fingerprint := func(src string) string {
+		got, err := Parse("", []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ast.Fingerprint(got.(*ast.Grammar))
+	}
+
+	want := fingerprint("A = B 'a'* / [a-z]\nB = \"b\"\n")
+	if got := fingerprint("// rule A\nA ←\n\tB ( 'a' )* // a's\n\t/ [a-z]\n\n/* rule B */\nB ← \"b\"\n"); got != want {
+		t.Errorf("want fingerprint %s, got %s", want, got)
+	}
+	if got := fingerprint("A = B 'a'* / [a-z]\nB = \"c\"\n"); got == want {
+		t.Errorf("want a fingerprint different from %s", want)
+	}
+}
+
+func TestParseAliasCode(t *testing.T) {
+	src := "{ package p }\n@alias DIGIT = [0-9]\nhex = [DIGIT A-F]+ / DIGIT"
+	g, err := Parse("", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := builder.BuildParser(&buf, g.(*ast.Grammar)); err != nil {
+		t.Fatal(err)
+	}
+	code := buf.String()
+	for _, want := range []string{`val: "[0-9A-F]",`, `val: "[0-9]",`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("want the generated code to contain %s", want)
+		}
+	}
+	if strings.Contains(code, "DIGIT") {
+		t.Errorf("want no reference to the alias in the generated code")
+	}
+}
+
+func TestParseAliasErrors(t *testing.T) {
+	cases := map[string]string{
+		"@alias D = [0-9]\n@alias D = [a-f]\na = D": "file:1:1 (0): rule Grammar: alias D redeclared",
+		"@alias a = [0-9]\na = 'a'":                 "file:1:1 (0): rule Grammar: alias a has the name of a rule",
+		"@alias NOT = [^0-9]\na = NOT [NOT a]":      "file:1:1 (0): rule Grammar: rule a: alias NOT is inverted or case-insensitive and cannot be used in a character class",
+		"@alias D = 'a'\na = D":                     "file:1:1 (0): no match found",
+	}
+	for src, want := range cases {
+		_, err := Parse("file", []byte(src))
+		if err == nil {
+			t.Errorf("%q: want error, got none", src)
+			continue
+		}
+		if err.Error() != want {
+			t.Errorf("%q: want \n%s\n, got \n%s\n", src, want, err)
+		}
+	}
+}
+
+func TestParseIgnoreCase(t *testing.T) {
+	src := "a = 'x' @ignorecase( 'a' ( b / \"c\" @compact( 'd' ) ) ) 'e'\nb = 'b'"
+	g, err := Parse("", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"x": false, "a": true, "c": true, "d": true, "e": false, "b": false}
+	for _, r := range g.(*ast.Grammar).Rules {
+		ast.Walk(r.Expr, func(expr ast.Expression) {
+			if lit, ok := expr.(*ast.LitMatcher); ok && lit.IgnoreCase != want[lit.Val] {
+				t.Errorf("%s: want IgnoreCase %t, got %t", lit.Val, want[lit.Val], lit.IgnoreCase)
+			}
+		})
+	}
+}
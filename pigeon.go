@@ -52,18 +52,98 @@ var g = &grammar{
 						},
 						&labeledExpr{
 							pos:   position{line: 5, col: 46, offset: 65},
+							label: "fields",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 5, col: 53, offset: 72},
+								expr: &seqExpr{
+									pos: position{line: 5, col: 55, offset: 74},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 55, offset: 74},
+											name: "Fields",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 62, offset: 81},
+											name: "__",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 5, col: 68, offset: 87},
+							label: "state",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 5, col: 74, offset: 93},
+								expr: &seqExpr{
+									pos: position{line: 5, col: 76, offset: 95},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 76, offset: 95},
+											name: "State",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 82, offset: 101},
+											name: "__",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 5, col: 88, offset: 107},
+							label: "aliases",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 5, col: 96, offset: 115},
+								expr: &seqExpr{
+									pos: position{line: 5, col: 98, offset: 117},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 98, offset: 117},
+											name: "Alias",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 104, offset: 123},
+											name: "__",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 5, col: 110, offset: 129},
+							label: "examples",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 5, col: 119, offset: 138},
+								expr: &seqExpr{
+									pos: position{line: 5, col: 121, offset: 140},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 121, offset: 140},
+											name: "Example",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 5, col: 129, offset: 148},
+											name: "__",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 5, col: 135, offset: 154},
 							label: "rules",
 							expr: &oneOrMoreExpr{
-								pos: position{line: 5, col: 52, offset: 71},
+								pos: position{line: 5, col: 141, offset: 160},
 								expr: &seqExpr{
-									pos: position{line: 5, col: 54, offset: 73},
+									pos: position{line: 5, col: 143, offset: 162},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 54, offset: 73},
+											pos:  position{line: 5, col: 143, offset: 162},
 											name: "Rule",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 59, offset: 78},
+											pos:  position{line: 5, col: 148, offset: 167},
 											name: "__",
 										},
 									},
@@ -71,7 +151,7 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 5, col: 65, offset: 84},
+							pos:  position{line: 5, col: 154, offset: 173},
 							name: "EOF",
 						},
 					},
@@ -80,23 +160,23 @@ var g = &grammar{
 		},
 		{
 			name: "Initializer",
-			pos:  position{line: 24, col: 1, offset: 525},
+			pos:  position{line: 44, col: 1, offset: 1249},
 			expr: &actionExpr{
-				pos: position{line: 24, col: 15, offset: 541},
+				pos: position{line: 44, col: 15, offset: 1265},
 				run: (*parser).callonInitializer1,
 				expr: &seqExpr{
-					pos: position{line: 24, col: 15, offset: 541},
+					pos: position{line: 44, col: 15, offset: 1265},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 24, col: 15, offset: 541},
+							pos:   position{line: 44, col: 15, offset: 1265},
 							label: "code",
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 20, offset: 546},
+								pos:  position{line: 44, col: 20, offset: 1270},
 								name: "CodeBlock",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 24, col: 30, offset: 556},
+							pos:  position{line: 44, col: 30, offset: 1280},
 							name: "EOS",
 						},
 					},
@@ -104,64 +184,121 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "Rule",
-			pos:  position{line: 28, col: 1, offset: 586},
+			name: "Fields",
+			pos:  position{line: 48, col: 1, offset: 1310},
 			expr: &actionExpr{
-				pos: position{line: 28, col: 8, offset: 595},
-				run: (*parser).callonRule1,
+				pos: position{line: 48, col: 10, offset: 1321},
+				run: (*parser).callonFields1,
 				expr: &seqExpr{
-					pos: position{line: 28, col: 8, offset: 595},
+					pos: position{line: 48, col: 10, offset: 1321},
 					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 48, col: 10, offset: 1321},
+							val:        "@fields",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 48, col: 20, offset: 1331},
+							name: "__",
+						},
 						&labeledExpr{
-							pos:   position{line: 28, col: 8, offset: 595},
-							label: "name",
+							pos:   position{line: 48, col: 23, offset: 1334},
+							label: "code",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 13, offset: 600},
-								name: "IdentifierName",
+								pos:  position{line: 48, col: 28, offset: 1339},
+								name: "CodeBlock",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 28, offset: 615},
+							pos:  position{line: 48, col: 38, offset: 1349},
+							name: "EOS",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "State",
+			pos:  position{line: 52, col: 1, offset: 1379},
+			expr: &actionExpr{
+				pos: position{line: 52, col: 9, offset: 1389},
+				run: (*parser).callonState1,
+				expr: &seqExpr{
+					pos: position{line: 52, col: 9, offset: 1389},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 52, col: 9, offset: 1389},
+							val:        "@state",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 52, col: 18, offset: 1398},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 28, col: 31, offset: 618},
-							label: "display",
-							expr: &zeroOrOneExpr{
-								pos: position{line: 28, col: 39, offset: 626},
-								expr: &seqExpr{
-									pos: position{line: 28, col: 41, offset: 628},
-									exprs: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 28, col: 41, offset: 628},
-											name: "StringLiteral",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 28, col: 55, offset: 642},
-											name: "__",
-										},
-									},
-								},
+							pos:   position{line: 52, col: 21, offset: 1401},
+							label: "code",
+							expr: &ruleRefExpr{
+								pos:  position{line: 52, col: 26, offset: 1406},
+								name: "CodeBlock",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 52, col: 36, offset: 1416},
+							name: "EOS",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Alias",
+			pos:  position{line: 56, col: 1, offset: 1446},
+			expr: &actionExpr{
+				pos: position{line: 56, col: 9, offset: 1456},
+				run: (*parser).callonAlias1,
+				expr: &seqExpr{
+					pos: position{line: 56, col: 9, offset: 1456},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 56, col: 9, offset: 1456},
+							val:        "@alias",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 56, col: 18, offset: 1465},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 56, col: 21, offset: 1468},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 56, col: 26, offset: 1473},
+								name: "IdentifierName",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 61, offset: 648},
+							pos:  position{line: 56, col: 41, offset: 1488},
+							name: "__",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 56, col: 44, offset: 1491},
 							name: "RuleDefOp",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 71, offset: 658},
+							pos:  position{line: 56, col: 54, offset: 1501},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 28, col: 74, offset: 661},
-							label: "expr",
+							pos:   position{line: 56, col: 57, offset: 1504},
+							label: "class",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 79, offset: 666},
-								name: "Expression",
+								pos:  position{line: 56, col: 63, offset: 1510},
+								name: "CharClassMatcher",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 90, offset: 677},
+							pos:  position{line: 56, col: 80, offset: 1527},
 							name: "EOS",
 						},
 					},
@@ -169,255 +306,371 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "Expression",
-			pos:  position{line: 41, col: 1, offset: 961},
-			expr: &ruleRefExpr{
-				pos:  position{line: 41, col: 14, offset: 976},
-				name: "ChoiceExpr",
+			name: "Example",
+			pos:  position{line: 60, col: 1, offset: 1632},
+			expr: &actionExpr{
+				pos: position{line: 60, col: 11, offset: 1644},
+				run: (*parser).callonExample1,
+				expr: &seqExpr{
+					pos: position{line: 60, col: 11, offset: 1644},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 60, col: 11, offset: 1644},
+							val:        "@example",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 60, col: 22, offset: 1655},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 60, col: 25, offset: 1658},
+							label: "input",
+							expr: &ruleRefExpr{
+								pos:  position{line: 60, col: 31, offset: 1664},
+								name: "StringLiteral",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 60, col: 45, offset: 1678},
+							name: "EOS",
+						},
+					},
+				},
 			},
 		},
 		{
-			name: "ChoiceExpr",
-			pos:  position{line: 43, col: 1, offset: 988},
+			name: "Rule",
+			pos:  position{line: 64, col: 1, offset: 1709},
 			expr: &actionExpr{
-				pos: position{line: 43, col: 14, offset: 1003},
-				run: (*parser).callonChoiceExpr1,
+				pos: position{line: 64, col: 8, offset: 1718},
+				run: (*parser).callonRule1,
 				expr: &seqExpr{
-					pos: position{line: 43, col: 14, offset: 1003},
+					pos: position{line: 64, col: 8, offset: 1718},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 43, col: 14, offset: 1003},
-							label: "first",
-							expr: &ruleRefExpr{
-								pos:  position{line: 43, col: 20, offset: 1009},
-								name: "ActionExpr",
+							pos:   position{line: 64, col: 8, offset: 1718},
+							label: "meta",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 64, col: 13, offset: 1723},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 15, offset: 1725},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 15, offset: 1725},
+											name: "RuleMeta",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 24, offset: 1734},
+											name: "__",
+										},
+									},
+								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 43, col: 31, offset: 1020},
-							label: "rest",
-							expr: &zeroOrMoreExpr{
-								pos: position{line: 43, col: 36, offset: 1025},
+							pos:   position{line: 64, col: 30, offset: 1740},
+							label: "cond",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 35, offset: 1745},
 								expr: &seqExpr{
-									pos: position{line: 43, col: 38, offset: 1027},
+									pos: position{line: 64, col: 37, offset: 1747},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 38, offset: 1027},
+											pos:  position{line: 64, col: 37, offset: 1747},
+											name: "IfCond",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 44, offset: 1754},
 											name: "__",
 										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 64, col: 50, offset: 1760},
+							label: "entry",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 56, offset: 1766},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 58, offset: 1768},
+									exprs: []interface{}{
 										&litMatcher{
-											pos:        position{line: 43, col: 41, offset: 1030},
-											val:        "/",
+											pos:        position{line: 64, col: 58, offset: 1768},
+											val:        "@entry",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 45, offset: 1034},
+											pos:  position{line: 64, col: 67, offset: 1777},
 											name: "__",
 										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 64, col: 73, offset: 1783},
+							label: "inline",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 80, offset: 1790},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 82, offset: 1792},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 64, col: 82, offset: 1792},
+											val:        "@inline",
+											ignoreCase: false,
+										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 48, offset: 1037},
-											name: "ActionExpr",
+											pos:  position{line: 64, col: 92, offset: 1802},
+											name: "__",
 										},
 									},
 								},
 							},
 						},
-					},
-				},
-			},
-		},
-		{
-			name: "ActionExpr",
-			pos:  position{line: 58, col: 1, offset: 1442},
-			expr: &actionExpr{
-				pos: position{line: 58, col: 14, offset: 1457},
-				run: (*parser).callonActionExpr1,
-				expr: &seqExpr{
-					pos: position{line: 58, col: 14, offset: 1457},
-					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 58, col: 14, offset: 1457},
-							label: "expr",
-							expr: &ruleRefExpr{
-								pos:  position{line: 58, col: 19, offset: 1462},
-								name: "SeqExpr",
+							pos:   position{line: 64, col: 98, offset: 1808},
+							label: "lexical",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 106, offset: 1816},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 108, offset: 1818},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 64, col: 108, offset: 1818},
+											val:        "@lexical",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 119, offset: 1829},
+											name: "__",
+										},
+									},
+								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 58, col: 27, offset: 1470},
-							label: "code",
+							pos:   position{line: 64, col: 125, offset: 1835},
+							label: "silent",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 58, col: 32, offset: 1475},
+								pos: position{line: 64, col: 132, offset: 1842},
 								expr: &seqExpr{
-									pos: position{line: 58, col: 34, offset: 1477},
+									pos: position{line: 64, col: 134, offset: 1844},
 									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 64, col: 134, offset: 1844},
+											val:        "@silent",
+											ignoreCase: false,
+										},
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 34, offset: 1477},
+											pos:  position{line: 64, col: 144, offset: 1854},
 											name: "__",
 										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 64, col: 150, offset: 1860},
+							label: "nl",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 153, offset: 1863},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 155, offset: 1865},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 64, col: 155, offset: 1865},
+											val:        "@nlsignificant",
+											ignoreCase: false,
+										},
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 37, offset: 1480},
-											name: "CodeBlock",
+											pos:  position{line: 64, col: 172, offset: 1882},
+											name: "__",
 										},
 									},
 								},
 							},
 						},
-					},
-				},
-			},
-		},
-		{
-			name: "SeqExpr",
-			pos:  position{line: 72, col: 1, offset: 1746},
-			expr: &actionExpr{
-				pos: position{line: 72, col: 11, offset: 1758},
-				run: (*parser).callonSeqExpr1,
-				expr: &seqExpr{
-					pos: position{line: 72, col: 11, offset: 1758},
-					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 72, col: 11, offset: 1758},
-							label: "first",
-							expr: &ruleRefExpr{
-								pos:  position{line: 72, col: 17, offset: 1764},
-								name: "LabeledExpr",
+							pos:   position{line: 64, col: 178, offset: 1888},
+							label: "typ",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 182, offset: 1892},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 184, offset: 1894},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 184, offset: 1894},
+											name: "RuleType",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 193, offset: 1903},
+											name: "__",
+										},
+									},
+								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 72, col: 29, offset: 1776},
-							label: "rest",
-							expr: &zeroOrMoreExpr{
-								pos: position{line: 72, col: 34, offset: 1781},
+							pos:   position{line: 64, col: 199, offset: 1909},
+							label: "budget",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 206, offset: 1916},
 								expr: &seqExpr{
-									pos: position{line: 72, col: 36, offset: 1783},
+									pos: position{line: 64, col: 208, offset: 1918},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 72, col: 36, offset: 1783},
-											name: "__",
+											pos:  position{line: 64, col: 208, offset: 1918},
+											name: "RuleBudget",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 72, col: 39, offset: 1786},
-											name: "LabeledExpr",
+											pos:  position{line: 64, col: 219, offset: 1929},
+											name: "__",
 										},
 									},
 								},
 							},
 						},
-					},
-				},
-			},
-		},
-		{
-			name: "LabeledExpr",
-			pos:  position{line: 85, col: 1, offset: 2137},
-			expr: &choiceExpr{
-				pos: position{line: 85, col: 15, offset: 2153},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 85, col: 15, offset: 2153},
-						run: (*parser).callonLabeledExpr2,
-						expr: &seqExpr{
-							pos: position{line: 85, col: 15, offset: 2153},
-							exprs: []interface{}{
-								&labeledExpr{
-									pos:   position{line: 85, col: 15, offset: 2153},
-									label: "label",
-									expr: &ruleRefExpr{
-										pos:  position{line: 85, col: 21, offset: 2159},
-										name: "Identifier",
-									},
-								},
-								&ruleRefExpr{
-									pos:  position{line: 85, col: 32, offset: 2170},
-									name: "__",
-								},
-								&litMatcher{
-									pos:        position{line: 85, col: 35, offset: 2173},
-									val:        ":",
-									ignoreCase: false,
-								},
-								&ruleRefExpr{
-									pos:  position{line: 85, col: 39, offset: 2177},
-									name: "__",
-								},
-								&labeledExpr{
-									pos:   position{line: 85, col: 42, offset: 2180},
-									label: "expr",
-									expr: &ruleRefExpr{
-										pos:  position{line: 85, col: 47, offset: 2185},
-										name: "PrefixedExpr",
+						&labeledExpr{
+							pos:   position{line: 64, col: 225, offset: 1935},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 64, col: 230, offset: 1940},
+								name: "IdentifierName",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 64, col: 245, offset: 1955},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 64, col: 248, offset: 1958},
+							label: "display",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 64, col: 256, offset: 1966},
+								expr: &seqExpr{
+									pos: position{line: 64, col: 258, offset: 1968},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 258, offset: 1968},
+											name: "StringLiteral",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 64, col: 272, offset: 1982},
+											name: "__",
+										},
 									},
 								},
 							},
 						},
-					},
-					&ruleRefExpr{
-						pos:  position{line: 91, col: 5, offset: 2358},
-						name: "PrefixedExpr",
+						&ruleRefExpr{
+							pos:  position{line: 64, col: 278, offset: 1988},
+							name: "RuleDefOp",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 64, col: 288, offset: 1998},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 64, col: 291, offset: 2001},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 64, col: 296, offset: 2006},
+								name: "Expression",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 64, col: 307, offset: 2017},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 64, col: 311, offset: 2021},
+								name: "RuleEnd",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 64, col: 319, offset: 2029},
+							name: "EOS",
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "PrefixedExpr",
-			pos:  position{line: 93, col: 1, offset: 2372},
-			expr: &choiceExpr{
-				pos: position{line: 93, col: 16, offset: 2389},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 93, col: 16, offset: 2389},
-						run: (*parser).callonPrefixedExpr2,
-						expr: &seqExpr{
-							pos: position{line: 93, col: 16, offset: 2389},
-							exprs: []interface{}{
-								&labeledExpr{
-									pos:   position{line: 93, col: 16, offset: 2389},
-									label: "op",
-									expr: &ruleRefExpr{
-										pos:  position{line: 93, col: 19, offset: 2392},
-										name: "PrefixedOp",
-									},
-								},
-								&ruleRefExpr{
-									pos:  position{line: 93, col: 30, offset: 2403},
-									name: "__",
-								},
-								&labeledExpr{
-									pos:   position{line: 93, col: 33, offset: 2406},
-									label: "expr",
-									expr: &ruleRefExpr{
-										pos:  position{line: 93, col: 38, offset: 2411},
-										name: "SuffixedExpr",
-									},
-								},
+			name: "RuleType",
+			pos:  position{line: 104, col: 1, offset: 3246},
+			expr: &actionExpr{
+				pos: position{line: 104, col: 12, offset: 3259},
+				run: (*parser).callonRuleType1,
+				expr: &seqExpr{
+					pos: position{line: 104, col: 12, offset: 3259},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 104, col: 12, offset: 3259},
+							val:        "@type(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 104, col: 21, offset: 3268},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 104, col: 24, offset: 3271},
+							label: "val",
+							expr: &ruleRefExpr{
+								pos:  position{line: 104, col: 28, offset: 3275},
+								name: "StringLiteral",
 							},
 						},
-					},
-					&ruleRefExpr{
-						pos:  position{line: 104, col: 5, offset: 2693},
-						name: "SuffixedExpr",
+						&ruleRefExpr{
+							pos:  position{line: 104, col: 42, offset: 3289},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 104, col: 45, offset: 3292},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "PrefixedOp",
-			pos:  position{line: 106, col: 1, offset: 2707},
+			name: "RuleBudget",
+			pos:  position{line: 112, col: 1, offset: 3485},
 			expr: &actionExpr{
-				pos: position{line: 106, col: 14, offset: 2722},
-				run: (*parser).callonPrefixedOp1,
-				expr: &choiceExpr{
-					pos: position{line: 106, col: 16, offset: 2724},
-					alternatives: []interface{}{
+				pos: position{line: 112, col: 14, offset: 3500},
+				run: (*parser).callonRuleBudget1,
+				expr: &seqExpr{
+					pos: position{line: 112, col: 14, offset: 3500},
+					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 106, col: 16, offset: 2724},
-							val:        "&",
+							pos:        position{line: 112, col: 14, offset: 3500},
+							val:        "@budget(",
 							ignoreCase: false,
 						},
+						&ruleRefExpr{
+							pos:  position{line: 112, col: 25, offset: 3511},
+							name: "__",
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 112, col: 28, offset: 3514},
+							expr: &charClassMatcher{
+								pos:        position{line: 546, col: 16, offset: 17966},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 112, col: 42, offset: 3528},
+							name: "__",
+						},
 						&litMatcher{
-							pos:        position{line: 106, col: 22, offset: 2730},
-							val:        "!",
+							pos:        position{line: 112, col: 45, offset: 3531},
+							val:        ")",
 							ignoreCase: false,
 						},
 					},
@@ -425,219 +678,122 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "SuffixedExpr",
-			pos:  position{line: 110, col: 1, offset: 2772},
-			expr: &choiceExpr{
-				pos: position{line: 110, col: 16, offset: 2789},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 110, col: 16, offset: 2789},
-						run: (*parser).callonSuffixedExpr2,
-						expr: &seqExpr{
-							pos: position{line: 110, col: 16, offset: 2789},
-							exprs: []interface{}{
-								&labeledExpr{
-									pos:   position{line: 110, col: 16, offset: 2789},
-									label: "expr",
-									expr: &ruleRefExpr{
-										pos:  position{line: 110, col: 21, offset: 2794},
-										name: "PrimaryExpr",
-									},
-								},
-								&ruleRefExpr{
-									pos:  position{line: 110, col: 33, offset: 2806},
-									name: "__",
-								},
-								&labeledExpr{
-									pos:   position{line: 110, col: 36, offset: 2809},
-									label: "op",
-									expr: &ruleRefExpr{
-										pos:  position{line: 110, col: 39, offset: 2812},
-										name: "SuffixedOp",
-									},
-								},
-							},
-						},
-					},
-					&ruleRefExpr{
-						pos:  position{line: 129, col: 5, offset: 3342},
-						name: "PrimaryExpr",
-					},
+			name: "RuleEnd",
+			pos:  position{line: 120, col: 1, offset: 3769},
+			expr: &actionExpr{
+				pos: position{line: 120, col: 11, offset: 3781},
+				run: (*parser).callonRuleEnd1,
+				expr: &litMatcher{
+					pos:        position{line: 120, col: 11, offset: 3781},
+					val:        "",
+					ignoreCase: false,
 				},
 			},
 		},
 		{
-			name: "SuffixedOp",
-			pos:  position{line: 131, col: 1, offset: 3356},
+			name: "RuleMeta",
+			pos:  position{line: 124, col: 1, offset: 3816},
 			expr: &actionExpr{
-				pos: position{line: 131, col: 14, offset: 3371},
-				run: (*parser).callonSuffixedOp1,
-				expr: &choiceExpr{
-					pos: position{line: 131, col: 16, offset: 3373},
-					alternatives: []interface{}{
+				pos: position{line: 124, col: 12, offset: 3829},
+				run: (*parser).callonRuleMeta1,
+				expr: &seqExpr{
+					pos: position{line: 124, col: 12, offset: 3829},
+					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 131, col: 16, offset: 3373},
-							val:        "?",
+							pos:        position{line: 124, col: 12, offset: 3829},
+							val:        "@meta(",
 							ignoreCase: false,
 						},
-						&litMatcher{
-							pos:        position{line: 131, col: 22, offset: 3379},
-							val:        "*",
-							ignoreCase: false,
+						&ruleRefExpr{
+							pos:  position{line: 124, col: 21, offset: 3838},
+							name: "__",
 						},
-						&litMatcher{
-							pos:        position{line: 131, col: 28, offset: 3385},
-							val:        "+",
-							ignoreCase: false,
+						&labeledExpr{
+							pos:   position{line: 124, col: 24, offset: 3841},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 124, col: 30, offset: 3847},
+								name: "MetaPair",
+							},
 						},
-					},
-				},
-			},
-		},
-		{
-			name: "PrimaryExpr",
-			pos:  position{line: 135, col: 1, offset: 3427},
-			expr: &choiceExpr{
-				pos: position{line: 135, col: 15, offset: 3443},
-				alternatives: []interface{}{
-					&ruleRefExpr{
-						pos:  position{line: 135, col: 15, offset: 3443},
-						name: "LitMatcher",
-					},
-					&ruleRefExpr{
-						pos:  position{line: 135, col: 28, offset: 3456},
-						name: "CharClassMatcher",
-					},
-					&ruleRefExpr{
-						pos:  position{line: 135, col: 47, offset: 3475},
-						name: "AnyMatcher",
-					},
-					&ruleRefExpr{
-						pos:  position{line: 135, col: 60, offset: 3488},
-						name: "RuleRefExpr",
-					},
-					&ruleRefExpr{
-						pos:  position{line: 135, col: 74, offset: 3502},
-						name: "SemanticPredExpr",
-					},
-					&actionExpr{
-						pos: position{line: 135, col: 93, offset: 3521},
-						run: (*parser).callonPrimaryExpr7,
-						expr: &seqExpr{
-							pos: position{line: 135, col: 93, offset: 3521},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 135, col: 93, offset: 3521},
-									val:        "(",
-									ignoreCase: false,
-								},
-								&ruleRefExpr{
-									pos:  position{line: 135, col: 97, offset: 3525},
-									name: "__",
-								},
-								&labeledExpr{
-									pos:   position{line: 135, col: 100, offset: 3528},
-									label: "expr",
-									expr: &ruleRefExpr{
-										pos:  position{line: 135, col: 105, offset: 3533},
-										name: "Expression",
+						&labeledExpr{
+							pos:   position{line: 124, col: 39, offset: 3856},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 124, col: 44, offset: 3861},
+								expr: &seqExpr{
+									pos: position{line: 124, col: 46, offset: 3863},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 124, col: 46, offset: 3863},
+											name: "__",
+										},
+										&litMatcher{
+											pos:        position{line: 124, col: 49, offset: 3866},
+											val:        ",",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 124, col: 53, offset: 3870},
+											name: "__",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 124, col: 56, offset: 3873},
+											name: "MetaPair",
+										},
 									},
 								},
-								&ruleRefExpr{
-									pos:  position{line: 135, col: 116, offset: 3544},
-									name: "__",
-								},
-								&litMatcher{
-									pos:        position{line: 135, col: 119, offset: 3547},
-									val:        ")",
-									ignoreCase: false,
-								},
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 124, col: 68, offset: 3885},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 124, col: 71, offset: 3888},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "RuleRefExpr",
-			pos:  position{line: 138, col: 1, offset: 3576},
+			name: "MetaPair",
+			pos:  position{line: 131, col: 1, offset: 4077},
 			expr: &actionExpr{
-				pos: position{line: 138, col: 15, offset: 3592},
-				run: (*parser).callonRuleRefExpr1,
+				pos: position{line: 131, col: 12, offset: 4090},
+				run: (*parser).callonMetaPair1,
 				expr: &seqExpr{
-					pos: position{line: 138, col: 15, offset: 3592},
+					pos: position{line: 131, col: 12, offset: 4090},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 138, col: 15, offset: 3592},
-							label: "name",
+							pos:   position{line: 131, col: 12, offset: 4090},
+							label: "key",
 							expr: &ruleRefExpr{
-								pos:  position{line: 138, col: 20, offset: 3597},
+								pos:  position{line: 131, col: 16, offset: 4094},
 								name: "IdentifierName",
 							},
 						},
-						&notExpr{
-							pos: position{line: 138, col: 35, offset: 3612},
-							expr: &seqExpr{
-								pos: position{line: 138, col: 38, offset: 3615},
-								exprs: []interface{}{
-									&ruleRefExpr{
-										pos:  position{line: 138, col: 38, offset: 3615},
-										name: "__",
-									},
-									&zeroOrOneExpr{
-										pos: position{line: 138, col: 41, offset: 3618},
-										expr: &seqExpr{
-											pos: position{line: 138, col: 43, offset: 3620},
-											exprs: []interface{}{
-												&ruleRefExpr{
-													pos:  position{line: 138, col: 43, offset: 3620},
-													name: "StringLiteral",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 138, col: 57, offset: 3634},
-													name: "__",
-												},
-											},
-										},
-									},
-									&ruleRefExpr{
-										pos:  position{line: 138, col: 63, offset: 3640},
-										name: "RuleDefOp",
-									},
-								},
-							},
+						&ruleRefExpr{
+							pos:  position{line: 131, col: 31, offset: 4109},
+							name: "__",
 						},
-					},
-				},
-			},
-		},
-		{
-			name: "SemanticPredExpr",
-			pos:  position{line: 143, col: 1, offset: 3756},
-			expr: &actionExpr{
-				pos: position{line: 143, col: 20, offset: 3777},
-				run: (*parser).callonSemanticPredExpr1,
-				expr: &seqExpr{
-					pos: position{line: 143, col: 20, offset: 3777},
-					exprs: []interface{}{
-						&labeledExpr{
-							pos:   position{line: 143, col: 20, offset: 3777},
-							label: "op",
-							expr: &ruleRefExpr{
-								pos:  position{line: 143, col: 23, offset: 3780},
-								name: "SemanticPredOp",
-							},
+						&litMatcher{
+							pos:        position{line: 131, col: 34, offset: 4112},
+							val:        "=",
+							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 143, col: 38, offset: 3795},
+							pos:  position{line: 131, col: 38, offset: 4116},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 143, col: 41, offset: 3798},
-							label: "code",
+							pos:   position{line: 131, col: 41, offset: 4119},
+							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 143, col: 46, offset: 3803},
-								name: "CodeBlock",
+								pos:  position{line: 131, col: 45, offset: 4123},
+								name: "StringLiteral",
 							},
 						},
 					},
@@ -645,299 +801,246 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "SemanticPredOp",
-			pos:  position{line: 154, col: 1, offset: 4080},
-			expr: &actionExpr{
-				pos: position{line: 154, col: 18, offset: 4099},
-				run: (*parser).callonSemanticPredOp1,
-				expr: &choiceExpr{
-					pos: position{line: 154, col: 20, offset: 4101},
-					alternatives: []interface{}{
-						&litMatcher{
-							pos:        position{line: 154, col: 20, offset: 4101},
-							val:        "&",
-							ignoreCase: false,
-						},
-						&litMatcher{
-							pos:        position{line: 154, col: 26, offset: 4107},
-							val:        "!",
-							ignoreCase: false,
-						},
-					},
-				},
-			},
-		},
-		{
-			name: "RuleDefOp",
-			pos:  position{line: 158, col: 1, offset: 4149},
-			expr: &choiceExpr{
-				pos: position{line: 158, col: 13, offset: 4163},
-				alternatives: []interface{}{
-					&litMatcher{
-						pos:        position{line: 158, col: 13, offset: 4163},
-						val:        "=",
-						ignoreCase: false,
-					},
-					&litMatcher{
-						pos:        position{line: 158, col: 19, offset: 4169},
-						val:        "<-",
-						ignoreCase: false,
-					},
-					&litMatcher{
-						pos:        position{line: 158, col: 26, offset: 4176},
-						val:        "←",
-						ignoreCase: false,
-					},
-					&litMatcher{
-						pos:        position{line: 158, col: 37, offset: 4187},
-						val:        "⟵",
-						ignoreCase: false,
-					},
-				},
-			},
-		},
-		{
-			name: "SourceChar",
-			pos:  position{line: 160, col: 1, offset: 4197},
-			expr: &anyMatcher{
-				line: 160, col: 14, offset: 4212,
-			},
-		},
-		{
-			name: "Comment",
-			pos:  position{line: 161, col: 1, offset: 4214},
-			expr: &choiceExpr{
-				pos: position{line: 161, col: 11, offset: 4226},
-				alternatives: []interface{}{
-					&ruleRefExpr{
-						pos:  position{line: 161, col: 11, offset: 4226},
-						name: "MultiLineComment",
-					},
-					&ruleRefExpr{
-						pos:  position{line: 161, col: 30, offset: 4245},
-						name: "SingleLineComment",
-					},
-				},
+			name: "Expression",
+			pos:  position{line: 139, col: 1, offset: 4304},
+			expr: &ruleRefExpr{
+				pos:  position{line: 139, col: 14, offset: 4319},
+				name: "ChoiceExpr",
 			},
 		},
 		{
-			name: "MultiLineComment",
-			pos:  position{line: 162, col: 1, offset: 4263},
-			expr: &seqExpr{
-				pos: position{line: 162, col: 20, offset: 4284},
-				exprs: []interface{}{
-					&litMatcher{
-						pos:        position{line: 162, col: 20, offset: 4284},
-						val:        "/*",
-						ignoreCase: false,
-					},
-					&zeroOrMoreExpr{
-						pos: position{line: 162, col: 25, offset: 4289},
-						expr: &seqExpr{
-							pos: position{line: 162, col: 27, offset: 4291},
-							exprs: []interface{}{
-								&notExpr{
-									pos: position{line: 162, col: 27, offset: 4291},
-									expr: &litMatcher{
-										pos:        position{line: 162, col: 28, offset: 4292},
-										val:        "*/",
-										ignoreCase: false,
+			name: "ChoiceExpr",
+			pos:  position{line: 141, col: 1, offset: 4331},
+			expr: &actionExpr{
+				pos: position{line: 141, col: 14, offset: 4346},
+				run: (*parser).callonChoiceExpr1,
+				expr: &seqExpr{
+					pos: position{line: 141, col: 14, offset: 4346},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 141, col: 14, offset: 4346},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 141, col: 20, offset: 4352},
+								name: "AltExpr",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 141, col: 28, offset: 4360},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 141, col: 33, offset: 4365},
+								expr: &seqExpr{
+									pos: position{line: 141, col: 35, offset: 4367},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 141, col: 35, offset: 4367},
+											name: "__",
+										},
+										&litMatcher{
+											pos:        position{line: 141, col: 38, offset: 4370},
+											val:        "/",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 141, col: 42, offset: 4374},
+											name: "__",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 141, col: 45, offset: 4377},
+											name: "AltExpr",
+										},
 									},
 								},
-								&ruleRefExpr{
-									pos:  position{line: 162, col: 33, offset: 4297},
-									name: "SourceChar",
-								},
 							},
 						},
 					},
-					&litMatcher{
-						pos:        position{line: 162, col: 47, offset: 4311},
-						val:        "*/",
-						ignoreCase: false,
-					},
 				},
 			},
 		},
 		{
-			name: "MultiLineCommentNoLineTerminator",
-			pos:  position{line: 163, col: 1, offset: 4316},
-			expr: &seqExpr{
-				pos: position{line: 163, col: 36, offset: 4353},
-				exprs: []interface{}{
-					&litMatcher{
-						pos:        position{line: 163, col: 36, offset: 4353},
-						val:        "/*",
-						ignoreCase: false,
-					},
-					&zeroOrMoreExpr{
-						pos: position{line: 163, col: 41, offset: 4358},
+			name: "AltExpr",
+			pos:  position{line: 156, col: 1, offset: 4779},
+			expr: &choiceExpr{
+				pos: position{line: 156, col: 11, offset: 4791},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 156, col: 11, offset: 4791},
+						run: (*parser).callonAltExpr2,
 						expr: &seqExpr{
-							pos: position{line: 163, col: 43, offset: 4360},
+							pos: position{line: 156, col: 11, offset: 4791},
 							exprs: []interface{}{
-								&notExpr{
-									pos: position{line: 163, col: 43, offset: 4360},
-									expr: &choiceExpr{
-										pos: position{line: 163, col: 46, offset: 4363},
-										alternatives: []interface{}{
-											&litMatcher{
-												pos:        position{line: 163, col: 46, offset: 4363},
-												val:        "*/",
-												ignoreCase: false,
-											},
-											&ruleRefExpr{
-												pos:  position{line: 163, col: 53, offset: 4370},
-												name: "EOL",
-											},
-										},
+								&labeledExpr{
+									pos:   position{line: 156, col: 11, offset: 4791},
+									label: "cond",
+									expr: &ruleRefExpr{
+										pos:  position{line: 156, col: 16, offset: 4796},
+										name: "IfCond",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 163, col: 59, offset: 4376},
-									name: "SourceChar",
+									pos:  position{line: 156, col: 23, offset: 4803},
+									name: "__",
+								},
+								&labeledExpr{
+									pos:   position{line: 156, col: 26, offset: 4806},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:  position{line: 156, col: 31, offset: 4811},
+										name: "ActionExpr",
+									},
 								},
 							},
 						},
 					},
-					&litMatcher{
-						pos:        position{line: 163, col: 73, offset: 4390},
-						val:        "*/",
-						ignoreCase: false,
-					},
-				},
-			},
-		},
-		{
-			name: "SingleLineComment",
-			pos:  position{line: 164, col: 1, offset: 4395},
-			expr: &seqExpr{
-				pos: position{line: 164, col: 21, offset: 4417},
-				exprs: []interface{}{
-					&litMatcher{
-						pos:        position{line: 164, col: 21, offset: 4417},
-						val:        "//",
-						ignoreCase: false,
-					},
-					&zeroOrMoreExpr{
-						pos: position{line: 164, col: 26, offset: 4422},
+					&actionExpr{
+						pos: position{line: 161, col: 5, offset: 4960},
+						run: (*parser).callonAltExpr9,
 						expr: &seqExpr{
-							pos: position{line: 164, col: 28, offset: 4424},
+							pos: position{line: 161, col: 5, offset: 4960},
 							exprs: []interface{}{
-								&notExpr{
-									pos: position{line: 164, col: 28, offset: 4424},
+								&labeledExpr{
+									pos:   position{line: 161, col: 5, offset: 4960},
+									label: "flag",
 									expr: &ruleRefExpr{
-										pos:  position{line: 164, col: 29, offset: 4425},
-										name: "EOL",
+										pos:  position{line: 161, col: 10, offset: 4965},
+										name: "WhenFlag",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 164, col: 33, offset: 4429},
-									name: "SourceChar",
+									pos:  position{line: 161, col: 19, offset: 4974},
+									name: "__",
+								},
+								&labeledExpr{
+									pos:   position{line: 161, col: 22, offset: 4977},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:  position{line: 161, col: 27, offset: 4982},
+										name: "ActionExpr",
+									},
 								},
 							},
 						},
 					},
+					&ruleRefExpr{
+						pos:  position{line: 166, col: 5, offset: 5137},
+						name: "ActionExpr",
+					},
 				},
 			},
 		},
 		{
-			name: "Identifier",
-			pos:  position{line: 166, col: 1, offset: 4444},
+			name: "IfCond",
+			pos:  position{line: 168, col: 1, offset: 5149},
 			expr: &actionExpr{
-				pos: position{line: 166, col: 14, offset: 4459},
-				run: (*parser).callonIdentifier1,
-				expr: &labeledExpr{
-					pos:   position{line: 166, col: 14, offset: 4459},
-					label: "ident",
-					expr: &ruleRefExpr{
-						pos:  position{line: 166, col: 20, offset: 4465},
-						name: "IdentifierName",
+				pos: position{line: 168, col: 10, offset: 5160},
+				run: (*parser).callonIfCond1,
+				expr: &seqExpr{
+					pos: position{line: 168, col: 10, offset: 5160},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 168, col: 10, offset: 5160},
+							val:        "@if(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 168, col: 17, offset: 5167},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 168, col: 20, offset: 5170},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 168, col: 25, offset: 5175},
+								name: "IdentifierName",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 168, col: 40, offset: 5190},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 168, col: 43, offset: 5193},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "IdentifierName",
-			pos:  position{line: 174, col: 1, offset: 4684},
+			name: "WhenFlag",
+			pos:  position{line: 172, col: 1, offset: 5223},
 			expr: &actionExpr{
-				pos: position{line: 174, col: 18, offset: 4703},
-				run: (*parser).callonIdentifierName1,
+				pos: position{line: 172, col: 12, offset: 5236},
+				run: (*parser).callonWhenFlag1,
 				expr: &seqExpr{
-					pos: position{line: 174, col: 18, offset: 4703},
+					pos: position{line: 172, col: 12, offset: 5236},
 					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 172, col: 12, offset: 5236},
+							val:        "@when(",
+							ignoreCase: false,
+						},
 						&ruleRefExpr{
-							pos:  position{line: 174, col: 18, offset: 4703},
-							name: "IdentifierStart",
+							pos:  position{line: 172, col: 21, offset: 5245},
+							name: "__",
 						},
-						&zeroOrMoreExpr{
-							pos: position{line: 174, col: 34, offset: 4719},
+						&labeledExpr{
+							pos:   position{line: 172, col: 24, offset: 5248},
+							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 174, col: 34, offset: 4719},
-								name: "IdentifierPart",
+								pos:  position{line: 172, col: 29, offset: 5253},
+								name: "IdentifierName",
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 172, col: 44, offset: 5268},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 172, col: 47, offset: 5271},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "IdentifierStart",
-			pos:  position{line: 177, col: 1, offset: 4801},
-			expr: &charClassMatcher{
-				pos:        position{line: 177, col: 19, offset: 4821},
-				val:        "[\\pL_]",
-				chars:      []rune{'_'},
-				classes:    []*unicode.RangeTable{rangeTable("L")},
-				ignoreCase: false,
-				inverted:   false,
-			},
-		},
-		{
-			name: "IdentifierPart",
-			pos:  position{line: 178, col: 1, offset: 4828},
-			expr: &choiceExpr{
-				pos: position{line: 178, col: 18, offset: 4847},
-				alternatives: []interface{}{
-					&ruleRefExpr{
-						pos:  position{line: 178, col: 18, offset: 4847},
-						name: "IdentifierStart",
-					},
-					&charClassMatcher{
-						pos:        position{line: 178, col: 36, offset: 4865},
-						val:        "[\\p{Nd}]",
-						classes:    []*unicode.RangeTable{rangeTable("Nd")},
-						ignoreCase: false,
-						inverted:   false,
-					},
-				},
-			},
-		},
-		{
-			name: "LitMatcher",
-			pos:  position{line: 180, col: 1, offset: 4875},
+			name: "ActionExpr",
+			pos:  position{line: 176, col: 1, offset: 5301},
 			expr: &actionExpr{
-				pos: position{line: 180, col: 14, offset: 4890},
-				run: (*parser).callonLitMatcher1,
+				pos: position{line: 176, col: 14, offset: 5316},
+				run: (*parser).callonActionExpr1,
 				expr: &seqExpr{
-					pos: position{line: 180, col: 14, offset: 4890},
+					pos: position{line: 176, col: 14, offset: 5316},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 180, col: 14, offset: 4890},
-							label: "lit",
+							pos:   position{line: 176, col: 14, offset: 5316},
+							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 180, col: 18, offset: 4894},
-								name: "StringLiteral",
+								pos:  position{line: 176, col: 19, offset: 5321},
+								name: "SeqExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 180, col: 32, offset: 4908},
-							label: "ignore",
+							pos:   position{line: 176, col: 27, offset: 5329},
+							label: "code",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 180, col: 39, offset: 4915},
-								expr: &litMatcher{
-									pos:        position{line: 180, col: 39, offset: 4915},
-									val:        "i",
-									ignoreCase: false,
+								pos: position{line: 176, col: 32, offset: 5334},
+								expr: &seqExpr{
+									pos: position{line: 176, col: 34, offset: 5336},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 176, col: 34, offset: 5336},
+											name: "__",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 176, col: 37, offset: 5339},
+											name: "CodeBlock",
+										},
+									},
 								},
 							},
 						},
@@ -946,167 +1049,57 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "StringLiteral",
-			pos:  position{line: 193, col: 1, offset: 5314},
-			expr: &choiceExpr{
-				pos: position{line: 193, col: 17, offset: 5332},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 193, col: 17, offset: 5332},
-						run: (*parser).callonStringLiteral2,
-						expr: &choiceExpr{
-							pos: position{line: 193, col: 19, offset: 5334},
-							alternatives: []interface{}{
-								&seqExpr{
-									pos: position{line: 193, col: 19, offset: 5334},
-									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 193, col: 19, offset: 5334},
-											val:        "\"",
-											ignoreCase: false,
-										},
-										&zeroOrMoreExpr{
-											pos: position{line: 193, col: 23, offset: 5338},
-											expr: &ruleRefExpr{
-												pos:  position{line: 193, col: 23, offset: 5338},
-												name: "DoubleStringChar",
-											},
-										},
-										&litMatcher{
-											pos:        position{line: 193, col: 41, offset: 5356},
-											val:        "\"",
-											ignoreCase: false,
-										},
-									},
-								},
-								&seqExpr{
-									pos: position{line: 193, col: 47, offset: 5362},
+			name: "SeqExpr",
+			pos:  position{line: 190, col: 1, offset: 5605},
+			expr: &actionExpr{
+				pos: position{line: 190, col: 11, offset: 5617},
+				run: (*parser).callonSeqExpr1,
+				expr: &seqExpr{
+					pos: position{line: 190, col: 11, offset: 5617},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 190, col: 11, offset: 5617},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 190, col: 17, offset: 5623},
+								name: "LabeledExpr",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 190, col: 29, offset: 5635},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 190, col: 34, offset: 5640},
+								expr: &seqExpr{
+									pos: position{line: 190, col: 36, offset: 5642},
 									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 193, col: 47, offset: 5362},
-											val:        "'",
-											ignoreCase: false,
-										},
 										&ruleRefExpr{
-											pos:  position{line: 193, col: 51, offset: 5366},
-											name: "SingleStringChar",
-										},
-										&litMatcher{
-											pos:        position{line: 193, col: 68, offset: 5383},
-											val:        "'",
-											ignoreCase: false,
-										},
-									},
-								},
-								&seqExpr{
-									pos: position{line: 193, col: 74, offset: 5389},
-									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 193, col: 74, offset: 5389},
-											val:        "`",
-											ignoreCase: false,
-										},
-										&zeroOrMoreExpr{
-											pos: position{line: 193, col: 78, offset: 5393},
-											expr: &ruleRefExpr{
-												pos:  position{line: 193, col: 78, offset: 5393},
-												name: "RawStringChar",
-											},
+											pos:  position{line: 190, col: 36, offset: 5642},
+											name: "__",
 										},
-										&litMatcher{
-											pos:        position{line: 193, col: 93, offset: 5408},
-											val:        "`",
-											ignoreCase: false,
+										&ruleRefExpr{
+											pos:  position{line: 190, col: 39, offset: 5645},
+											name: "LabeledExpr",
 										},
 									},
 								},
 							},
 						},
-					},
-					&actionExpr{
-						pos: position{line: 195, col: 5, offset: 5481},
-						run: (*parser).callonStringLiteral18,
-						expr: &choiceExpr{
-							pos: position{line: 195, col: 7, offset: 5483},
-							alternatives: []interface{}{
-								&seqExpr{
-									pos: position{line: 195, col: 9, offset: 5485},
-									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 195, col: 9, offset: 5485},
-											val:        "\"",
-											ignoreCase: false,
-										},
-										&zeroOrMoreExpr{
-											pos: position{line: 195, col: 13, offset: 5489},
-											expr: &ruleRefExpr{
-												pos:  position{line: 195, col: 13, offset: 5489},
-												name: "DoubleStringChar",
-											},
-										},
-										&choiceExpr{
-											pos: position{line: 195, col: 33, offset: 5509},
-											alternatives: []interface{}{
-												&ruleRefExpr{
-													pos:  position{line: 195, col: 33, offset: 5509},
-													name: "EOL",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 195, col: 39, offset: 5515},
-													name: "EOF",
-												},
-											},
-										},
-									},
-								},
-								&seqExpr{
-									pos: position{line: 195, col: 51, offset: 5527},
-									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 195, col: 51, offset: 5527},
-											val:        "'",
-											ignoreCase: false,
-										},
-										&zeroOrOneExpr{
-											pos: position{line: 195, col: 55, offset: 5531},
-											expr: &ruleRefExpr{
-												pos:  position{line: 195, col: 55, offset: 5531},
-												name: "SingleStringChar",
-											},
-										},
-										&choiceExpr{
-											pos: position{line: 195, col: 75, offset: 5551},
-											alternatives: []interface{}{
-												&ruleRefExpr{
-													pos:  position{line: 195, col: 75, offset: 5551},
-													name: "EOL",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 195, col: 81, offset: 5557},
-													name: "EOF",
-												},
-											},
-										},
-									},
-								},
-								&seqExpr{
-									pos: position{line: 195, col: 91, offset: 5567},
+						&labeledExpr{
+							pos:   position{line: 190, col: 54, offset: 5660},
+							label: "assoc",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 190, col: 60, offset: 5666},
+								expr: &seqExpr{
+									pos: position{line: 190, col: 62, offset: 5668},
 									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 195, col: 91, offset: 5567},
-											val:        "`",
-											ignoreCase: false,
-										},
-										&zeroOrMoreExpr{
-											pos: position{line: 195, col: 95, offset: 5571},
-											expr: &ruleRefExpr{
-												pos:  position{line: 195, col: 95, offset: 5571},
-												name: "RawStringChar",
-											},
+										&ruleRefExpr{
+											pos:  position{line: 190, col: 62, offset: 5668},
+											name: "__",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 195, col: 110, offset: 5586},
-											name: "EOF",
+											pos:  position{line: 190, col: 65, offset: 5671},
+											name: "FoldAssoc",
 										},
 									},
 								},
@@ -1117,53 +1110,34 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "DoubleStringChar",
-			pos:  position{line: 199, col: 1, offset: 5688},
-			expr: &choiceExpr{
-				pos: position{line: 199, col: 20, offset: 5709},
-				alternatives: []interface{}{
-					&seqExpr{
-						pos: position{line: 199, col: 20, offset: 5709},
-						exprs: []interface{}{
-							&notExpr{
-								pos: position{line: 199, col: 20, offset: 5709},
-								expr: &choiceExpr{
-									pos: position{line: 199, col: 23, offset: 5712},
-									alternatives: []interface{}{
-										&litMatcher{
-											pos:        position{line: 199, col: 23, offset: 5712},
-											val:        "\"",
-											ignoreCase: false,
-										},
-										&litMatcher{
-											pos:        position{line: 199, col: 29, offset: 5718},
-											val:        "\\",
-											ignoreCase: false,
-										},
-										&ruleRefExpr{
-											pos:  position{line: 199, col: 36, offset: 5725},
-											name: "EOL",
-										},
-									},
+			name: "FoldAssoc",
+			pos:  position{line: 210, col: 1, offset: 6243},
+			expr: &actionExpr{
+				pos: position{line: 210, col: 13, offset: 6257},
+				run: (*parser).callonFoldAssoc1,
+				expr: &seqExpr{
+					pos: position{line: 210, col: 13, offset: 6257},
+					exprs: []interface{}{
+						&litSetMatcher{
+							pos: position{line: 210, col: 15, offset: 6259},
+							alts: []*litMatcher{
+								&litMatcher{
+									pos:        position{line: 210, col: 15, offset: 6259},
+									val:        "@left",
+									ignoreCase: false,
+								},
+								&litMatcher{
+									pos:        position{line: 210, col: 25, offset: 6269},
+									val:        "@right",
+									ignoreCase: false,
 								},
-							},
-							&ruleRefExpr{
-								pos:  position{line: 199, col: 42, offset: 5731},
-								name: "SourceChar",
 							},
 						},
-					},
-					&seqExpr{
-						pos: position{line: 199, col: 55, offset: 5744},
-						exprs: []interface{}{
-							&litMatcher{
-								pos:        position{line: 199, col: 55, offset: 5744},
-								val:        "\\",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 199, col: 60, offset: 5749},
-								name: "DoubleStringEscape",
+						&notExpr{
+							pos: position{line: 210, col: 36, offset: 6280},
+							expr: &ruleRefExpr{
+								pos:  position{line: 210, col: 37, offset: 6281},
+								name: "IdentifierPart",
 							},
 						},
 					},
@@ -1171,296 +1145,460 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "SingleStringChar",
-			pos:  position{line: 200, col: 1, offset: 5768},
+			name: "LabeledExpr",
+			pos:  position{line: 214, col: 1, offset: 6332},
 			expr: &choiceExpr{
-				pos: position{line: 200, col: 20, offset: 5789},
+				pos: position{line: 214, col: 15, offset: 6348},
 				alternatives: []interface{}{
-					&seqExpr{
-						pos: position{line: 200, col: 20, offset: 5789},
-						exprs: []interface{}{
-							&notExpr{
-								pos: position{line: 200, col: 20, offset: 5789},
-								expr: &choiceExpr{
-									pos: position{line: 200, col: 23, offset: 5792},
-									alternatives: []interface{}{
-										&litMatcher{
-											pos:        position{line: 200, col: 23, offset: 5792},
-											val:        "'",
-											ignoreCase: false,
-										},
-										&litMatcher{
-											pos:        position{line: 200, col: 29, offset: 5798},
-											val:        "\\",
-											ignoreCase: false,
-										},
-										&ruleRefExpr{
-											pos:  position{line: 200, col: 36, offset: 5805},
-											name: "EOL",
-										},
+					&actionExpr{
+						pos: position{line: 214, col: 15, offset: 6348},
+						run: (*parser).callonLabeledExpr2,
+						expr: &seqExpr{
+							pos: position{line: 214, col: 15, offset: 6348},
+							exprs: []interface{}{
+								&labeledExpr{
+									pos:   position{line: 214, col: 15, offset: 6348},
+									label: "label",
+									expr: &ruleRefExpr{
+										pos:  position{line: 214, col: 21, offset: 6354},
+										name: "Identifier",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 214, col: 32, offset: 6365},
+									name: "__",
+								},
+								&litMatcher{
+									pos:        position{line: 214, col: 35, offset: 6368},
+									val:        ":",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 214, col: 39, offset: 6372},
+									name: "__",
+								},
+								&labeledExpr{
+									pos:   position{line: 214, col: 42, offset: 6375},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:  position{line: 214, col: 47, offset: 6380},
+										name: "PrefixedExpr",
 									},
 								},
-							},
-							&ruleRefExpr{
-								pos:  position{line: 200, col: 42, offset: 5811},
-								name: "SourceChar",
 							},
 						},
 					},
-					&seqExpr{
-						pos: position{line: 200, col: 55, offset: 5824},
-						exprs: []interface{}{
-							&litMatcher{
-								pos:        position{line: 200, col: 55, offset: 5824},
-								val:        "\\",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 200, col: 60, offset: 5829},
-								name: "SingleStringEscape",
+					&ruleRefExpr{
+						pos:  position{line: 220, col: 5, offset: 6553},
+						name: "PrefixedExpr",
+					},
+				},
+			},
+		},
+		{
+			name: "PrefixedExpr",
+			pos:  position{line: 222, col: 1, offset: 6567},
+			expr: &choiceExpr{
+				pos: position{line: 222, col: 16, offset: 6584},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 222, col: 16, offset: 6584},
+						run: (*parser).callonPrefixedExpr2,
+						expr: &seqExpr{
+							pos: position{line: 222, col: 16, offset: 6584},
+							exprs: []interface{}{
+								&labeledExpr{
+									pos:   position{line: 222, col: 16, offset: 6584},
+									label: "op",
+									expr: &ruleRefExpr{
+										pos:  position{line: 222, col: 19, offset: 6587},
+										name: "PrefixedOp",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 222, col: 30, offset: 6598},
+									name: "__",
+								},
+								&labeledExpr{
+									pos:   position{line: 222, col: 33, offset: 6601},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:  position{line: 222, col: 38, offset: 6606},
+										name: "SuffixedExpr",
+									},
+								},
 							},
 						},
 					},
+					&ruleRefExpr{
+						pos:  position{line: 233, col: 5, offset: 6888},
+						name: "SuffixedExpr",
+					},
 				},
 			},
 		},
 		{
-			name: "RawStringChar",
-			pos:  position{line: 201, col: 1, offset: 5848},
-			expr: &seqExpr{
-				pos: position{line: 201, col: 17, offset: 5866},
-				exprs: []interface{}{
-					&notExpr{
-						pos: position{line: 201, col: 17, offset: 5866},
-						expr: &litMatcher{
-							pos:        position{line: 201, col: 18, offset: 5867},
-							val:        "`",
+			name: "PrefixedOp",
+			pos:  position{line: 235, col: 1, offset: 6902},
+			expr: &actionExpr{
+				pos: position{line: 235, col: 14, offset: 6917},
+				run: (*parser).callonPrefixedOp1,
+				expr: &litSetMatcher{
+					pos: position{line: 235, col: 16, offset: 6919},
+					alts: []*litMatcher{
+						&litMatcher{
+							pos:        position{line: 235, col: 16, offset: 6919},
+							val:        "&",
+							ignoreCase: false,
+						},
+						&litMatcher{
+							pos:        position{line: 235, col: 22, offset: 6925},
+							val:        "!",
 							ignoreCase: false,
 						},
-					},
-					&ruleRefExpr{
-						pos:  position{line: 201, col: 22, offset: 5871},
-						name: "SourceChar",
 					},
 				},
 			},
 		},
 		{
-			name: "DoubleStringEscape",
-			pos:  position{line: 203, col: 1, offset: 5883},
+			name: "SuffixedExpr",
+			pos:  position{line: 239, col: 1, offset: 6967},
 			expr: &choiceExpr{
-				pos: position{line: 203, col: 22, offset: 5906},
+				pos: position{line: 239, col: 16, offset: 6984},
 				alternatives: []interface{}{
-					&choiceExpr{
-						pos: position{line: 203, col: 24, offset: 5908},
-						alternatives: []interface{}{
-							&litMatcher{
-								pos:        position{line: 203, col: 24, offset: 5908},
-								val:        "\"",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 203, col: 30, offset: 5914},
-								name: "CommonEscapeSequence",
-							},
-						},
-					},
 					&actionExpr{
-						pos: position{line: 204, col: 7, offset: 5943},
-						run: (*parser).callonDoubleStringEscape5,
-						expr: &choiceExpr{
-							pos: position{line: 204, col: 9, offset: 5945},
-							alternatives: []interface{}{
-								&ruleRefExpr{
-									pos:  position{line: 204, col: 9, offset: 5945},
-									name: "SourceChar",
+						pos: position{line: 239, col: 16, offset: 6984},
+						run: (*parser).callonSuffixedExpr2,
+						expr: &seqExpr{
+							pos: position{line: 239, col: 16, offset: 6984},
+							exprs: []interface{}{
+								&labeledExpr{
+									pos:   position{line: 239, col: 16, offset: 6984},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:  position{line: 239, col: 21, offset: 6989},
+										name: "PrimaryExpr",
+									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 204, col: 22, offset: 5958},
-									name: "EOL",
+									pos:  position{line: 239, col: 33, offset: 7001},
+									name: "__",
 								},
-								&ruleRefExpr{
-									pos:  position{line: 204, col: 28, offset: 5964},
-									name: "EOF",
+								&labeledExpr{
+									pos:   position{line: 239, col: 36, offset: 7004},
+									label: "op",
+									expr: &choiceExpr{
+										pos: position{line: 239, col: 41, offset: 7009},
+										alternatives: []interface{}{
+											&ruleRefExpr{
+												pos:  position{line: 239, col: 41, offset: 7009},
+												name: "DefaultOp",
+											},
+											&ruleRefExpr{
+												pos:  position{line: 239, col: 53, offset: 7021},
+												name: "SuffixedOp",
+											},
+										},
+									},
+								},
+								&labeledExpr{
+									pos:   position{line: 239, col: 66, offset: 7034},
+									label: "cond",
+									expr: &zeroOrOneExpr{
+										pos: position{line: 239, col: 71, offset: 7039},
+										expr: &seqExpr{
+											pos: position{line: 239, col: 73, offset: 7041},
+											exprs: []interface{}{
+												&ruleRefExpr{
+													pos:  position{line: 239, col: 73, offset: 7041},
+													name: "__",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 239, col: 76, offset: 7044},
+													name: "RepeatCond",
+												},
+											},
+										},
+									},
 								},
 							},
 						},
 					},
+					&ruleRefExpr{
+						pos:  position{line: 276, col: 5, offset: 8183},
+						name: "PrimaryExpr",
+					},
 				},
 			},
 		},
 		{
-			name: "SingleStringEscape",
-			pos:  position{line: 207, col: 1, offset: 6029},
-			expr: &choiceExpr{
-				pos: position{line: 207, col: 22, offset: 6052},
-				alternatives: []interface{}{
-					&choiceExpr{
-						pos: position{line: 207, col: 24, offset: 6054},
-						alternatives: []interface{}{
-							&litMatcher{
-								pos:        position{line: 207, col: 24, offset: 6054},
-								val:        "'",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 207, col: 30, offset: 6060},
-								name: "CommonEscapeSequence",
+			name: "SuffixedOp",
+			pos:  position{line: 278, col: 1, offset: 8197},
+			expr: &actionExpr{
+				pos: position{line: 278, col: 14, offset: 8212},
+				run: (*parser).callonSuffixedOp1,
+				expr: &litSetMatcher{
+					pos: position{line: 278, col: 16, offset: 8214},
+					alts: []*litMatcher{
+						&litMatcher{
+							pos:        position{line: 278, col: 16, offset: 8214},
+							val:        "?",
+							ignoreCase: false,
+						},
+						&litMatcher{
+							pos:        position{line: 278, col: 22, offset: 8220},
+							val:        "*",
+							ignoreCase: false,
+						},
+						&litMatcher{
+							pos:        position{line: 278, col: 28, offset: 8226},
+							val:        "+",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "DefaultOp",
+			pos:  position{line: 282, col: 1, offset: 8268},
+			expr: &actionExpr{
+				pos: position{line: 282, col: 13, offset: 8282},
+				run: (*parser).callonDefaultOp1,
+				expr: &seqExpr{
+					pos: position{line: 282, col: 13, offset: 8282},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 282, col: 13, offset: 8282},
+							val:        "??",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 282, col: 18, offset: 8287},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 282, col: 21, offset: 8290},
+							label: "code",
+							expr: &ruleRefExpr{
+								pos:  position{line: 282, col: 26, offset: 8295},
+								name: "CodeBlock",
 							},
 						},
 					},
-					&actionExpr{
-						pos: position{line: 208, col: 7, offset: 6089},
-						run: (*parser).callonSingleStringEscape5,
-						expr: &choiceExpr{
-							pos: position{line: 208, col: 9, offset: 6091},
-							alternatives: []interface{}{
-								&ruleRefExpr{
-									pos:  position{line: 208, col: 9, offset: 6091},
-									name: "SourceChar",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 208, col: 22, offset: 6104},
-									name: "EOL",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 208, col: 28, offset: 6110},
-									name: "EOF",
-								},
+				},
+			},
+		},
+		{
+			name: "RepeatCond",
+			pos:  position{line: 286, col: 1, offset: 8331},
+			expr: &actionExpr{
+				pos: position{line: 286, col: 14, offset: 8346},
+				run: (*parser).callonRepeatCond1,
+				expr: &seqExpr{
+					pos: position{line: 286, col: 14, offset: 8346},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 286, col: 14, offset: 8346},
+							val:        "{",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 286, col: 18, offset: 8350},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 286, col: 21, offset: 8353},
+							val:        "&",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 286, col: 25, offset: 8357},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 286, col: 28, offset: 8360},
+							label: "code",
+							expr: &ruleRefExpr{
+								pos:  position{line: 286, col: 33, offset: 8365},
+								name: "CodeBlock",
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 286, col: 43, offset: 8375},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 286, col: 46, offset: 8378},
+							val:        "}",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "CommonEscapeSequence",
-			pos:  position{line: 212, col: 1, offset: 6176},
+			name: "PrimaryExpr",
+			pos:  position{line: 292, col: 1, offset: 8486},
 			expr: &choiceExpr{
-				pos: position{line: 212, col: 24, offset: 6201},
+				pos: position{line: 292, col: 15, offset: 8502},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 212, col: 24, offset: 6201},
-						name: "SingleCharEscape",
+						pos:  position{line: 292, col: 15, offset: 8502},
+						name: "LitMatcher",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 212, col: 43, offset: 6220},
-						name: "OctalEscape",
+						pos:  position{line: 292, col: 28, offset: 8515},
+						name: "CharClassMatcher",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 212, col: 57, offset: 6234},
-						name: "HexEscape",
+						pos:  position{line: 292, col: 47, offset: 8534},
+						name: "AnyMatcher",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 212, col: 69, offset: 6246},
-						name: "LongUnicodeEscape",
+						pos:  position{line: 292, col: 60, offset: 8547},
+						name: "UntilMatcher",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 212, col: 89, offset: 6266},
-						name: "ShortUnicodeEscape",
+						pos:  position{line: 292, col: 75, offset: 8562},
+						name: "NestedMatcher",
 					},
-				},
-			},
-		},
-		{
-			name: "SingleCharEscape",
-			pos:  position{line: 213, col: 1, offset: 6285},
-			expr: &choiceExpr{
-				pos: position{line: 213, col: 20, offset: 6306},
-				alternatives: []interface{}{
-					&litMatcher{
-						pos:        position{line: 213, col: 20, offset: 6306},
-						val:        "a",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 91, offset: 8578},
+						name: "RestOfLineMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 26, offset: 6312},
-						val:        "b",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 111, offset: 8598},
+						name: "ByteMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 32, offset: 6318},
-						val:        "n",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 125, offset: 8612},
+						name: "BytesMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 38, offset: 6324},
-						val:        "f",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 140, offset: 8627},
+						name: "NumberMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 44, offset: 6330},
-						val:        "r",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 156, offset: 8643},
+						name: "IndentMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 50, offset: 6336},
-						val:        "t",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 172, offset: 8659},
+						name: "KeywordMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 56, offset: 6342},
-						val:        "v",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 189, offset: 8676},
+						name: "WordListMatcher",
 					},
-					&litMatcher{
-						pos:        position{line: 213, col: 62, offset: 6348},
-						val:        "\\",
-						ignoreCase: false,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 207, offset: 8694},
+						name: "TableMatcher",
 					},
-				},
-			},
-		},
-		{
-			name: "OctalEscape",
-			pos:  position{line: 214, col: 1, offset: 6353},
-			expr: &choiceExpr{
-				pos: position{line: 214, col: 15, offset: 6369},
-				alternatives: []interface{}{
-					&seqExpr{
-						pos: position{line: 214, col: 15, offset: 6369},
-						exprs: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 214, col: 15, offset: 6369},
-								name: "OctalDigit",
-							},
-							&ruleRefExpr{
-								pos:  position{line: 214, col: 26, offset: 6380},
-								name: "OctalDigit",
-							},
-							&ruleRefExpr{
-								pos:  position{line: 214, col: 37, offset: 6391},
-								name: "OctalDigit",
-							},
-						},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 222, offset: 8709},
+						name: "TokenMatcher",
 					},
-					&actionExpr{
-						pos: position{line: 215, col: 7, offset: 6408},
-						run: (*parser).callonOctalEscape6,
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 237, offset: 8724},
+						name: "OperatorsExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 253, offset: 8740},
+						name: "SepExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 263, offset: 8750},
+						name: "UnreservedExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 280, offset: 8767},
+						name: "VerbatimExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 295, offset: 8782},
+						name: "CompactExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 309, offset: 8796},
+						name: "TrimExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 320, offset: 8807},
+						name: "IgnoreCaseExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 337, offset: 8824},
+						name: "LongestExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 351, offset: 8838},
+						name: "ArrayExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 363, offset: 8850},
+						name: "MapExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 373, offset: 8860},
+						name: "PeekExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 384, offset: 8871},
+						name: "ConvertExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 398, offset: 8885},
+						name: "SeenExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 409, offset: 8896},
+						name: "BackRefExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 423, offset: 8910},
+						name: "LookbehindExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 440, offset: 8927},
+						name: "RuleRefExpr",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 292, col: 454, offset: 8941},
+						name: "SemanticPredExpr",
+					},
+					&actionExpr{
+						pos: position{line: 292, col: 473, offset: 8960},
+						run: (*parser).callonPrimaryExpr33,
 						expr: &seqExpr{
-							pos: position{line: 215, col: 7, offset: 6408},
+							pos: position{line: 292, col: 473, offset: 8960},
 							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 292, col: 473, offset: 8960},
+									val:        "(",
+									ignoreCase: false,
+								},
 								&ruleRefExpr{
-									pos:  position{line: 215, col: 7, offset: 6408},
-									name: "OctalDigit",
+									pos:  position{line: 292, col: 477, offset: 8964},
+									name: "__",
 								},
-								&choiceExpr{
-									pos: position{line: 215, col: 20, offset: 6421},
-									alternatives: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 215, col: 20, offset: 6421},
-											name: "SourceChar",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 215, col: 33, offset: 6434},
-											name: "EOL",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 215, col: 39, offset: 6440},
-											name: "EOF",
-										},
+								&labeledExpr{
+									pos:   position{line: 292, col: 480, offset: 8967},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:  position{line: 292, col: 485, offset: 8972},
+										name: "Expression",
 									},
 								},
+								&ruleRefExpr{
+									pos:  position{line: 292, col: 496, offset: 8983},
+									name: "__",
+								},
+								&litMatcher{
+									pos:        position{line: 292, col: 499, offset: 8986},
+									val:        ")",
+									ignoreCase: false,
+								},
 							},
 						},
 					},
@@ -1468,213 +1606,281 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "HexEscape",
-			pos:  position{line: 218, col: 1, offset: 6501},
-			expr: &choiceExpr{
-				pos: position{line: 218, col: 13, offset: 6515},
-				alternatives: []interface{}{
-					&seqExpr{
-						pos: position{line: 218, col: 13, offset: 6515},
-						exprs: []interface{}{
-							&litMatcher{
-								pos:        position{line: 218, col: 13, offset: 6515},
-								val:        "x",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 218, col: 17, offset: 6519},
-								name: "HexDigit",
+			name: "RuleRefExpr",
+			pos:  position{line: 295, col: 1, offset: 9015},
+			expr: &actionExpr{
+				pos: position{line: 295, col: 15, offset: 9031},
+				run: (*parser).callonRuleRefExpr1,
+				expr: &seqExpr{
+					pos: position{line: 295, col: 15, offset: 9031},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 295, col: 15, offset: 9031},
+							label: "name",
+							expr: &choiceExpr{
+								pos: position{line: 295, col: 22, offset: 9038},
+								alternatives: []interface{}{
+									&ruleRefExpr{
+										pos:  position{line: 295, col: 22, offset: 9038},
+										name: "QualifiedName",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 295, col: 38, offset: 9054},
+										name: "IdentifierName",
+									},
+								},
 							},
-							&ruleRefExpr{
-								pos:  position{line: 218, col: 26, offset: 6528},
-								name: "HexDigit",
+						},
+						&notExpr{
+							pos: position{line: 295, col: 55, offset: 9071},
+							expr: &seqExpr{
+								pos: position{line: 295, col: 58, offset: 9074},
+								exprs: []interface{}{
+									&ruleRefExpr{
+										pos:  position{line: 295, col: 58, offset: 9074},
+										name: "__",
+									},
+									&zeroOrOneExpr{
+										pos: position{line: 295, col: 61, offset: 9077},
+										expr: &seqExpr{
+											pos: position{line: 295, col: 63, offset: 9079},
+											exprs: []interface{}{
+												&ruleRefExpr{
+													pos:  position{line: 295, col: 63, offset: 9079},
+													name: "StringLiteral",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 295, col: 77, offset: 9093},
+													name: "__",
+												},
+											},
+										},
+									},
+									&ruleRefExpr{
+										pos:  position{line: 295, col: 83, offset: 9099},
+										name: "RuleDefOp",
+									},
+								},
 							},
 						},
 					},
-					&actionExpr{
-						pos: position{line: 219, col: 7, offset: 6543},
-						run: (*parser).callonHexEscape6,
-						expr: &seqExpr{
-							pos: position{line: 219, col: 7, offset: 6543},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 219, col: 7, offset: 6543},
-									val:        "x",
-									ignoreCase: false,
-								},
-								&choiceExpr{
-									pos: position{line: 219, col: 13, offset: 6549},
-									alternatives: []interface{}{
+				},
+			},
+		},
+		{
+			name: "QualifiedName",
+			pos:  position{line: 300, col: 1, offset: 9215},
+			expr: &actionExpr{
+				pos: position{line: 300, col: 17, offset: 9233},
+				run: (*parser).callonQualifiedName1,
+				expr: &seqExpr{
+					pos: position{line: 300, col: 17, offset: 9233},
+					exprs: []interface{}{
+						&ruleRefExpr{
+							pos:  position{line: 300, col: 17, offset: 9233},
+							name: "IdentifierName",
+						},
+						&litMatcher{
+							pos:        position{line: 300, col: 32, offset: 9248},
+							val:        "::",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 300, col: 37, offset: 9253},
+							name: "IdentifierName",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "OperatorsExpr",
+			pos:  position{line: 303, col: 1, offset: 9334},
+			expr: &actionExpr{
+				pos: position{line: 303, col: 17, offset: 9352},
+				run: (*parser).callonOperatorsExpr1,
+				expr: &seqExpr{
+					pos: position{line: 303, col: 17, offset: 9352},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 303, col: 17, offset: 9352},
+							val:        "@operators",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 303, col: 30, offset: 9365},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 303, col: 33, offset: 9368},
+							label: "operand",
+							expr: &ruleRefExpr{
+								pos:  position{line: 303, col: 41, offset: 9376},
+								name: "PrimaryExpr",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 303, col: 53, offset: 9388},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 303, col: 56, offset: 9391},
+							val:        "{",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 303, col: 60, offset: 9395},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 303, col: 63, offset: 9398},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 303, col: 69, offset: 9404},
+								name: "OperatorLevel",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 303, col: 83, offset: 9418},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 303, col: 88, offset: 9423},
+								expr: &seqExpr{
+									pos: position{line: 303, col: 90, offset: 9425},
+									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 219, col: 13, offset: 6549},
-											name: "SourceChar",
+											pos:  position{line: 303, col: 90, offset: 9425},
+											name: "__",
+										},
+										&litMatcher{
+											pos:        position{line: 303, col: 93, offset: 9428},
+											val:        ";",
+											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 219, col: 26, offset: 6562},
-											name: "EOL",
+											pos:  position{line: 303, col: 97, offset: 9432},
+											name: "__",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 219, col: 32, offset: 6568},
-											name: "EOF",
+											pos:  position{line: 303, col: 100, offset: 9435},
+											name: "OperatorLevel",
 										},
 									},
 								},
 							},
 						},
+						&zeroOrOneExpr{
+							pos: position{line: 303, col: 117, offset: 9452},
+							expr: &seqExpr{
+								pos: position{line: 303, col: 119, offset: 9454},
+								exprs: []interface{}{
+									&ruleRefExpr{
+										pos:  position{line: 303, col: 119, offset: 9454},
+										name: "__",
+									},
+									&litMatcher{
+										pos:        position{line: 303, col: 122, offset: 9457},
+										val:        ";",
+										ignoreCase: false,
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 303, col: 129, offset: 9464},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 303, col: 132, offset: 9467},
+							val:        "}",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "LongUnicodeEscape",
-			pos:  position{line: 222, col: 1, offset: 6635},
-			expr: &choiceExpr{
-				pos: position{line: 223, col: 5, offset: 6662},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 223, col: 5, offset: 6662},
-						run: (*parser).callonLongUnicodeEscape2,
-						expr: &seqExpr{
-							pos: position{line: 223, col: 5, offset: 6662},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 223, col: 5, offset: 6662},
-									val:        "U",
-									ignoreCase: false,
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 9, offset: 6666},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 18, offset: 6675},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 27, offset: 6684},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 36, offset: 6693},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 45, offset: 6702},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 54, offset: 6711},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 63, offset: 6720},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 223, col: 72, offset: 6729},
-									name: "HexDigit",
-								},
-							},
-						},
-					},
-					&actionExpr{
-						pos: position{line: 226, col: 7, offset: 6831},
-						run: (*parser).callonLongUnicodeEscape13,
-						expr: &seqExpr{
-							pos: position{line: 226, col: 7, offset: 6831},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 226, col: 7, offset: 6831},
-									val:        "U",
-									ignoreCase: false,
-								},
-								&choiceExpr{
-									pos: position{line: 226, col: 13, offset: 6837},
-									alternatives: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 226, col: 13, offset: 6837},
-											name: "SourceChar",
-										},
+			name: "OperatorLevel",
+			pos:  position{line: 312, col: 1, offset: 9766},
+			expr: &actionExpr{
+				pos: position{line: 312, col: 17, offset: 9784},
+				run: (*parser).callonOperatorLevel1,
+				expr: &seqExpr{
+					pos: position{line: 312, col: 17, offset: 9784},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 312, col: 17, offset: 9784},
+							label: "lits",
+							expr: &oneOrMoreExpr{
+								pos: position{line: 312, col: 22, offset: 9789},
+								expr: &seqExpr{
+									pos: position{line: 312, col: 24, offset: 9791},
+									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 226, col: 26, offset: 6850},
-											name: "EOL",
+											pos:  position{line: 312, col: 24, offset: 9791},
+											name: "LitMatcher",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 226, col: 32, offset: 6856},
-											name: "EOF",
+											pos:  position{line: 312, col: 35, offset: 9802},
+											name: "__",
 										},
 									},
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 312, col: 41, offset: 9808},
+							label: "assoc",
+							expr: &ruleRefExpr{
+								pos:  position{line: 312, col: 47, offset: 9814},
+								name: "OperatorAssoc",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 312, col: 61, offset: 9828},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 312, col: 64, offset: 9831},
+							label: "prec",
+							expr: &ruleRefExpr{
+								pos:  position{line: 312, col: 69, offset: 9836},
+								name: "OperatorPrec",
+							},
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "ShortUnicodeEscape",
-			pos:  position{line: 229, col: 1, offset: 6919},
-			expr: &choiceExpr{
-				pos: position{line: 230, col: 5, offset: 6947},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 230, col: 5, offset: 6947},
-						run: (*parser).callonShortUnicodeEscape2,
-						expr: &seqExpr{
-							pos: position{line: 230, col: 5, offset: 6947},
-							exprs: []interface{}{
+			name: "OperatorAssoc",
+			pos:  position{line: 321, col: 1, offset: 10142},
+			expr: &actionExpr{
+				pos: position{line: 321, col: 17, offset: 10160},
+				run: (*parser).callonOperatorAssoc1,
+				expr: &seqExpr{
+					pos: position{line: 321, col: 17, offset: 10160},
+					exprs: []interface{}{
+						&litSetMatcher{
+							pos: position{line: 321, col: 19, offset: 10162},
+							alts: []*litMatcher{
 								&litMatcher{
-									pos:        position{line: 230, col: 5, offset: 6947},
-									val:        "u",
+									pos:        position{line: 321, col: 19, offset: 10162},
+									val:        "left",
 									ignoreCase: false,
 								},
-								&ruleRefExpr{
-									pos:  position{line: 230, col: 9, offset: 6951},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 230, col: 18, offset: 6960},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 230, col: 27, offset: 6969},
-									name: "HexDigit",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 230, col: 36, offset: 6978},
-									name: "HexDigit",
-								},
-							},
-						},
-					},
-					&actionExpr{
-						pos: position{line: 233, col: 7, offset: 7080},
-						run: (*parser).callonShortUnicodeEscape9,
-						expr: &seqExpr{
-							pos: position{line: 233, col: 7, offset: 7080},
-							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 233, col: 7, offset: 7080},
-									val:        "u",
+									pos:        position{line: 321, col: 28, offset: 10171},
+									val:        "right",
 									ignoreCase: false,
 								},
-								&choiceExpr{
-									pos: position{line: 233, col: 13, offset: 7086},
-									alternatives: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 233, col: 13, offset: 7086},
-											name: "SourceChar",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 233, col: 26, offset: 7099},
-											name: "EOL",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 233, col: 32, offset: 7105},
-											name: "EOF",
-										},
-									},
-								},
+							},
+						},
+						&notExpr{
+							pos: position{line: 321, col: 38, offset: 10181},
+							expr: &ruleRefExpr{
+								pos:  position{line: 321, col: 39, offset: 10182},
+								name: "IdentifierPart",
 							},
 						},
 					},
@@ -1682,881 +1888,4156 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "OctalDigit",
-			pos:  position{line: 237, col: 1, offset: 7169},
-			expr: &charClassMatcher{
-				pos:        position{line: 237, col: 14, offset: 7184},
-				val:        "[0-7]",
-				ranges:     []rune{'0', '7'},
-				ignoreCase: false,
-				inverted:   false,
-			},
-		},
-		{
-			name: "DecimalDigit",
-			pos:  position{line: 238, col: 1, offset: 7190},
-			expr: &charClassMatcher{
-				pos:        position{line: 238, col: 16, offset: 7207},
-				val:        "[0-9]",
-				ranges:     []rune{'0', '9'},
-				ignoreCase: false,
-				inverted:   false,
-			},
-		},
-		{
-			name: "HexDigit",
-			pos:  position{line: 239, col: 1, offset: 7213},
-			expr: &charClassMatcher{
-				pos:        position{line: 239, col: 12, offset: 7226},
-				val:        "[0-9a-f]i",
-				ranges:     []rune{'0', '9', 'a', 'f'},
-				ignoreCase: true,
-				inverted:   false,
+			name: "OperatorPrec",
+			pos:  position{line: 324, col: 1, offset: 10232},
+			expr: &actionExpr{
+				pos: position{line: 324, col: 16, offset: 10249},
+				run: (*parser).callonOperatorPrec1,
+				expr: &oneOrMoreExpr{
+					pos: position{line: 324, col: 16, offset: 10249},
+					expr: &charClassMatcher{
+						pos:        position{line: 546, col: 16, offset: 17966},
+						val:        "[0-9]",
+						ranges:     []rune{'0', '9'},
+						ignoreCase: false,
+						inverted:   false,
+					},
+				},
 			},
 		},
 		{
-			name: "CharClassMatcher",
-			pos:  position{line: 241, col: 1, offset: 7237},
-			expr: &choiceExpr{
-				pos: position{line: 241, col: 20, offset: 7258},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 241, col: 20, offset: 7258},
-						run: (*parser).callonCharClassMatcher2,
-						expr: &seqExpr{
-							pos: position{line: 241, col: 20, offset: 7258},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 241, col: 20, offset: 7258},
-									val:        "[",
-									ignoreCase: false,
-								},
-								&zeroOrMoreExpr{
-									pos: position{line: 241, col: 24, offset: 7262},
-									expr: &choiceExpr{
-										pos: position{line: 241, col: 26, offset: 7264},
-										alternatives: []interface{}{
-											&ruleRefExpr{
-												pos:  position{line: 241, col: 26, offset: 7264},
-												name: "ClassCharRange",
-											},
-											&ruleRefExpr{
-												pos:  position{line: 241, col: 43, offset: 7281},
-												name: "ClassChar",
-											},
-											&seqExpr{
-												pos: position{line: 241, col: 55, offset: 7293},
-												exprs: []interface{}{
-													&litMatcher{
-														pos:        position{line: 241, col: 55, offset: 7293},
-														val:        "\\",
-														ignoreCase: false,
-													},
-													&ruleRefExpr{
-														pos:  position{line: 241, col: 60, offset: 7298},
-														name: "UnicodeClassEscape",
-													},
-												},
-											},
-										},
-									},
-								},
-								&litMatcher{
-									pos:        position{line: 241, col: 82, offset: 7320},
-									val:        "]",
-									ignoreCase: false,
-								},
-								&zeroOrOneExpr{
-									pos: position{line: 241, col: 86, offset: 7324},
-									expr: &litMatcher{
-										pos:        position{line: 241, col: 86, offset: 7324},
-										val:        "i",
-										ignoreCase: false,
-									},
-								},
-							},
+			name: "UnreservedExpr",
+			pos:  position{line: 331, col: 1, offset: 10414},
+			expr: &actionExpr{
+				pos: position{line: 331, col: 18, offset: 10433},
+				run: (*parser).callonUnreservedExpr1,
+				expr: &seqExpr{
+					pos: position{line: 331, col: 18, offset: 10433},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 331, col: 18, offset: 10433},
+							val:        "@unreserved(",
+							ignoreCase: false,
 						},
-					},
-					&actionExpr{
-						pos: position{line: 245, col: 5, offset: 7431},
-						run: (*parser).callonCharClassMatcher15,
-						expr: &seqExpr{
-							pos: position{line: 245, col: 5, offset: 7431},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 245, col: 5, offset: 7431},
-									val:        "[",
-									ignoreCase: false,
-								},
-								&zeroOrMoreExpr{
-									pos: position{line: 245, col: 9, offset: 7435},
-									expr: &seqExpr{
-										pos: position{line: 245, col: 11, offset: 7437},
-										exprs: []interface{}{
-											&notExpr{
-												pos: position{line: 245, col: 11, offset: 7437},
-												expr: &ruleRefExpr{
-													pos:  position{line: 245, col: 14, offset: 7440},
-													name: "EOL",
-												},
-											},
-											&ruleRefExpr{
-												pos:  position{line: 245, col: 20, offset: 7446},
-												name: "SourceChar",
-											},
-										},
-									},
-								},
-								&choiceExpr{
-									pos: position{line: 245, col: 36, offset: 7462},
-									alternatives: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 245, col: 36, offset: 7462},
-											name: "EOL",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 245, col: 42, offset: 7468},
-											name: "EOF",
-										},
-									},
-								},
+						&ruleRefExpr{
+							pos:  position{line: 331, col: 33, offset: 10448},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 331, col: 36, offset: 10451},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 331, col: 41, offset: 10456},
+								name: "Expression",
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 331, col: 52, offset: 10467},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 331, col: 55, offset: 10470},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "ClassCharRange",
-			pos:  position{line: 249, col: 1, offset: 7578},
-			expr: &seqExpr{
-				pos: position{line: 249, col: 18, offset: 7597},
-				exprs: []interface{}{
-					&ruleRefExpr{
-						pos:  position{line: 249, col: 18, offset: 7597},
-						name: "ClassChar",
-					},
-					&litMatcher{
-						pos:        position{line: 249, col: 28, offset: 7607},
-						val:        "-",
-						ignoreCase: false,
-					},
-					&ruleRefExpr{
-						pos:  position{line: 249, col: 32, offset: 7611},
-						name: "ClassChar",
+			name: "VerbatimExpr",
+			pos:  position{line: 336, col: 1, offset: 10577},
+			expr: &actionExpr{
+				pos: position{line: 336, col: 16, offset: 10594},
+				run: (*parser).callonVerbatimExpr1,
+				expr: &seqExpr{
+					pos: position{line: 336, col: 16, offset: 10594},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 336, col: 16, offset: 10594},
+							val:        "@verbatim(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 336, col: 29, offset: 10607},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 336, col: 32, offset: 10610},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 336, col: 37, offset: 10615},
+								name: "Expression",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 336, col: 48, offset: 10626},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 336, col: 51, offset: 10629},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "ClassChar",
-			pos:  position{line: 250, col: 1, offset: 7621},
-			expr: &choiceExpr{
-				pos: position{line: 250, col: 13, offset: 7635},
-				alternatives: []interface{}{
-					&seqExpr{
-						pos: position{line: 250, col: 13, offset: 7635},
-						exprs: []interface{}{
-							&notExpr{
-								pos: position{line: 250, col: 13, offset: 7635},
-								expr: &choiceExpr{
-									pos: position{line: 250, col: 16, offset: 7638},
-									alternatives: []interface{}{
-										&litMatcher{
-											pos:        position{line: 250, col: 16, offset: 7638},
-											val:        "]",
-											ignoreCase: false,
-										},
-										&litMatcher{
-											pos:        position{line: 250, col: 22, offset: 7644},
-											val:        "\\",
-											ignoreCase: false,
-										},
-										&ruleRefExpr{
-											pos:  position{line: 250, col: 29, offset: 7651},
-											name: "EOL",
-										},
-									},
-								},
-							},
-							&ruleRefExpr{
-								pos:  position{line: 250, col: 35, offset: 7657},
-								name: "SourceChar",
-							},
+			name: "CompactExpr",
+			pos:  position{line: 341, col: 1, offset: 10740},
+			expr: &actionExpr{
+				pos: position{line: 341, col: 15, offset: 10756},
+				run: (*parser).callonCompactExpr1,
+				expr: &seqExpr{
+					pos: position{line: 341, col: 15, offset: 10756},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 341, col: 15, offset: 10756},
+							val:        "@compact(",
+							ignoreCase: false,
 						},
-					},
-					&seqExpr{
-						pos: position{line: 250, col: 48, offset: 7670},
-						exprs: []interface{}{
-							&litMatcher{
-								pos:        position{line: 250, col: 48, offset: 7670},
-								val:        "\\",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 250, col: 53, offset: 7675},
-								name: "CharClassEscape",
+						&ruleRefExpr{
+							pos:  position{line: 341, col: 27, offset: 10768},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 341, col: 30, offset: 10771},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 341, col: 35, offset: 10776},
+								name: "Expression",
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 341, col: 46, offset: 10787},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 341, col: 49, offset: 10790},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "CharClassEscape",
-			pos:  position{line: 251, col: 1, offset: 7691},
-			expr: &choiceExpr{
-				pos: position{line: 251, col: 19, offset: 7711},
-				alternatives: []interface{}{
-					&choiceExpr{
-						pos: position{line: 251, col: 21, offset: 7713},
-						alternatives: []interface{}{
-							&litMatcher{
-								pos:        position{line: 251, col: 21, offset: 7713},
-								val:        "]",
-								ignoreCase: false,
-							},
-							&ruleRefExpr{
-								pos:  position{line: 251, col: 27, offset: 7719},
-								name: "CommonEscapeSequence",
-							},
+			name: "TrimExpr",
+			pos:  position{line: 346, col: 1, offset: 10900},
+			expr: &actionExpr{
+				pos: position{line: 346, col: 12, offset: 10913},
+				run: (*parser).callonTrimExpr1,
+				expr: &seqExpr{
+					pos: position{line: 346, col: 12, offset: 10913},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 346, col: 12, offset: 10913},
+							val:        "$trim(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 346, col: 21, offset: 10922},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 346, col: 24, offset: 10925},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 346, col: 29, offset: 10930},
+								name: "Expression",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 346, col: 40, offset: 10941},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 346, col: 43, offset: 10944},
+							val:        ")",
+							ignoreCase: false,
 						},
 					},
-					&actionExpr{
-						pos: position{line: 252, col: 7, offset: 7748},
-						run: (*parser).callonCharClassEscape5,
-						expr: &seqExpr{
-							pos: position{line: 252, col: 7, offset: 7748},
-							exprs: []interface{}{
-								&notExpr{
-									pos: position{line: 252, col: 7, offset: 7748},
-									expr: &litMatcher{
-										pos:        position{line: 252, col: 8, offset: 7749},
-										val:        "p",
-										ignoreCase: false,
-									},
-								},
-								&choiceExpr{
-									pos: position{line: 252, col: 14, offset: 7755},
-									alternatives: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 252, col: 14, offset: 7755},
-											name: "SourceChar",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 252, col: 27, offset: 7768},
-											name: "EOL",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 252, col: 33, offset: 7774},
-											name: "EOF",
-										},
-									},
-								},
+				},
+			},
+		},
+		{
+			name: "IgnoreCaseExpr",
+			pos:  position{line: 351, col: 1, offset: 11051},
+			expr: &actionExpr{
+				pos: position{line: 351, col: 18, offset: 11070},
+				run: (*parser).callonIgnoreCaseExpr1,
+				expr: &seqExpr{
+					pos: position{line: 351, col: 18, offset: 11070},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 351, col: 18, offset: 11070},
+							val:        "@ignorecase(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 351, col: 33, offset: 11085},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 351, col: 36, offset: 11088},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 351, col: 41, offset: 11093},
+								name: "Expression",
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 351, col: 52, offset: 11104},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 351, col: 55, offset: 11107},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "UnicodeClassEscape",
-			pos:  position{line: 256, col: 1, offset: 7840},
-			expr: &seqExpr{
-				pos: position{line: 256, col: 22, offset: 7863},
-				exprs: []interface{}{
-					&litMatcher{
-						pos:        position{line: 256, col: 22, offset: 7863},
-						val:        "p",
-						ignoreCase: false,
+			name: "LongestExpr",
+			pos:  position{line: 357, col: 1, offset: 11259},
+			expr: &actionExpr{
+				pos: position{line: 357, col: 15, offset: 11275},
+				run: (*parser).callonLongestExpr1,
+				expr: &seqExpr{
+					pos: position{line: 357, col: 15, offset: 11275},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 357, col: 15, offset: 11275},
+							val:        "@longest(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 357, col: 27, offset: 11287},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 357, col: 30, offset: 11290},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 357, col: 35, offset: 11295},
+								name: "Expression",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 357, col: 46, offset: 11306},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 357, col: 49, offset: 11309},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
-					&choiceExpr{
-						pos: position{line: 257, col: 7, offset: 7876},
-						alternatives: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 257, col: 7, offset: 7876},
-								name: "SingleCharUnicodeClass",
+				},
+			},
+		},
+		{
+			name: "ArrayExpr",
+			pos:  position{line: 365, col: 1, offset: 11493},
+			expr: &actionExpr{
+				pos: position{line: 365, col: 13, offset: 11507},
+				run: (*parser).callonArrayExpr1,
+				expr: &seqExpr{
+					pos: position{line: 365, col: 13, offset: 11507},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 365, col: 13, offset: 11507},
+							val:        "@array(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 365, col: 23, offset: 11517},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 365, col: 26, offset: 11520},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 365, col: 31, offset: 11525},
+								name: "Expression",
 							},
-							&actionExpr{
-								pos: position{line: 258, col: 7, offset: 7905},
-								run: (*parser).callonUnicodeClassEscape5,
-								expr: &seqExpr{
-									pos: position{line: 258, col: 7, offset: 7905},
-									exprs: []interface{}{
-										&notExpr{
-											pos: position{line: 258, col: 7, offset: 7905},
-											expr: &litMatcher{
-												pos:        position{line: 258, col: 8, offset: 7906},
-												val:        "{",
-												ignoreCase: false,
-											},
-										},
-										&choiceExpr{
-											pos: position{line: 258, col: 14, offset: 7912},
-											alternatives: []interface{}{
-												&ruleRefExpr{
-													pos:  position{line: 258, col: 14, offset: 7912},
-													name: "SourceChar",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 258, col: 27, offset: 7925},
-													name: "EOL",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 258, col: 33, offset: 7931},
-													name: "EOF",
-												},
-											},
-										},
-									},
-								},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 365, col: 42, offset: 11536},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 365, col: 45, offset: 11539},
+							val:        ",",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 365, col: 49, offset: 11543},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 365, col: 52, offset: 11546},
+							label: "n",
+							expr: &ruleRefExpr{
+								pos:  position{line: 365, col: 54, offset: 11548},
+								name: "ArrayLen",
 							},
-							&actionExpr{
-								pos: position{line: 259, col: 7, offset: 8002},
-								run: (*parser).callonUnicodeClassEscape13,
+						},
+						&labeledExpr{
+							pos:   position{line: 365, col: 63, offset: 11557},
+							label: "typ",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 365, col: 67, offset: 11561},
 								expr: &seqExpr{
-									pos: position{line: 259, col: 7, offset: 8002},
+									pos: position{line: 365, col: 69, offset: 11563},
 									exprs: []interface{}{
-										&litMatcher{
-											pos:        position{line: 259, col: 7, offset: 8002},
-											val:        "{",
-											ignoreCase: false,
-										},
-										&labeledExpr{
-											pos:   position{line: 259, col: 11, offset: 8006},
-											label: "ident",
-											expr: &ruleRefExpr{
-												pos:  position{line: 259, col: 17, offset: 8012},
-												name: "IdentifierName",
-											},
-										},
-										&litMatcher{
-											pos:        position{line: 259, col: 32, offset: 8027},
-											val:        "}",
-											ignoreCase: false,
+										&ruleRefExpr{
+											pos:  position{line: 365, col: 69, offset: 11563},
+											name: "__",
 										},
-									},
-								},
-							},
-							&actionExpr{
-								pos: position{line: 265, col: 7, offset: 8204},
-								run: (*parser).callonUnicodeClassEscape19,
-								expr: &seqExpr{
-									pos: position{line: 265, col: 7, offset: 8204},
-									exprs: []interface{}{
 										&litMatcher{
-											pos:        position{line: 265, col: 7, offset: 8204},
-											val:        "{",
+											pos:        position{line: 365, col: 72, offset: 11566},
+											val:        ",",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 265, col: 11, offset: 8208},
-											name: "IdentifierName",
+											pos:  position{line: 365, col: 76, offset: 11570},
+											name: "__",
 										},
-										&choiceExpr{
-											pos: position{line: 265, col: 28, offset: 8225},
-											alternatives: []interface{}{
-												&litMatcher{
-													pos:        position{line: 265, col: 28, offset: 8225},
-													val:        "]",
-													ignoreCase: false,
-												},
-												&ruleRefExpr{
-													pos:  position{line: 265, col: 34, offset: 8231},
-													name: "EOL",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 265, col: 40, offset: 8237},
-													name: "EOF",
-												},
-											},
+										&ruleRefExpr{
+											pos:  position{line: 365, col: 79, offset: 11573},
+											name: "StringLiteral",
 										},
 									},
 								},
 							},
 						},
+						&ruleRefExpr{
+							pos:  position{line: 365, col: 96, offset: 11590},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 365, col: 99, offset: 11593},
+							val:        ")",
+							ignoreCase: false,
+						},
 					},
 				},
 			},
 		},
 		{
-			name: "SingleCharUnicodeClass",
-			pos:  position{line: 269, col: 1, offset: 8320},
-			expr: &charClassMatcher{
-				pos:        position{line: 269, col: 26, offset: 8347},
-				val:        "[LMNCPZS]",
-				chars:      []rune{'L', 'M', 'N', 'C', 'P', 'Z', 'S'},
-				ignoreCase: false,
-				inverted:   false,
-			},
-		},
-		{
-			name: "AnyMatcher",
-			pos:  position{line: 271, col: 1, offset: 8358},
+			name: "ArrayLen",
+			pos:  position{line: 378, col: 1, offset: 11970},
 			expr: &actionExpr{
-				pos: position{line: 271, col: 14, offset: 8373},
-				run: (*parser).callonAnyMatcher1,
-				expr: &litMatcher{
-					pos:        position{line: 271, col: 14, offset: 8373},
-					val:        ".",
-					ignoreCase: false,
+				pos: position{line: 378, col: 12, offset: 11983},
+				run: (*parser).callonArrayLen1,
+				expr: &oneOrMoreExpr{
+					pos: position{line: 378, col: 12, offset: 11983},
+					expr: &charClassMatcher{
+						pos:        position{line: 546, col: 16, offset: 17966},
+						val:        "[0-9]",
+						ranges:     []rune{'0', '9'},
+						ignoreCase: false,
+						inverted:   false,
+					},
 				},
 			},
 		},
 		{
-			name: "CodeBlock",
-			pos:  position{line: 276, col: 1, offset: 8448},
-			expr: &choiceExpr{
-				pos: position{line: 276, col: 13, offset: 8462},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 276, col: 13, offset: 8462},
-						run: (*parser).callonCodeBlock2,
-						expr: &seqExpr{
-							pos: position{line: 276, col: 13, offset: 8462},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 276, col: 13, offset: 8462},
-									val:        "{",
-									ignoreCase: false,
-								},
-								&ruleRefExpr{
-									pos:  position{line: 276, col: 17, offset: 8466},
-									name: "Code",
-								},
-								&litMatcher{
-									pos:        position{line: 276, col: 22, offset: 8471},
-									val:        "}",
-									ignoreCase: false,
-								},
-							},
+			name: "MapExpr",
+			pos:  position{line: 385, col: 1, offset: 12145},
+			expr: &actionExpr{
+				pos: position{line: 385, col: 11, offset: 12157},
+				run: (*parser).callonMapExpr1,
+				expr: &seqExpr{
+					pos: position{line: 385, col: 11, offset: 12157},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 385, col: 11, offset: 12157},
+							val:        "@map(",
+							ignoreCase: false,
 						},
-					},
-					&actionExpr{
-						pos: position{line: 280, col: 5, offset: 8570},
-						run: (*parser).callonCodeBlock7,
-						expr: &seqExpr{
-							pos: position{line: 280, col: 5, offset: 8570},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 280, col: 5, offset: 8570},
-									val:        "{",
-									ignoreCase: false,
-								},
-								&ruleRefExpr{
-									pos:  position{line: 280, col: 9, offset: 8574},
-									name: "Code",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 280, col: 14, offset: 8579},
-									name: "EOF",
-								},
-							},
+						&ruleRefExpr{
+							pos:  position{line: 385, col: 19, offset: 12165},
+							name: "__",
 						},
-					},
-				},
-			},
-		},
-		{
-			name: "Code",
-			pos:  position{line: 284, col: 1, offset: 8644},
-			expr: &zeroOrMoreExpr{
-				pos: position{line: 284, col: 8, offset: 8653},
-				expr: &choiceExpr{
-					pos: position{line: 284, col: 10, offset: 8655},
-					alternatives: []interface{}{
-						&oneOrMoreExpr{
-							pos: position{line: 284, col: 10, offset: 8655},
-							expr: &seqExpr{
-								pos: position{line: 284, col: 12, offset: 8657},
-								exprs: []interface{}{
-									&notExpr{
-										pos: position{line: 284, col: 12, offset: 8657},
-										expr: &charClassMatcher{
-											pos:        position{line: 284, col: 13, offset: 8658},
-											val:        "[{}]",
-											chars:      []rune{'{', '}'},
+						&labeledExpr{
+							pos:   position{line: 385, col: 22, offset: 12168},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 385, col: 27, offset: 12173},
+								name: "Expression",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 385, col: 38, offset: 12184},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 385, col: 41, offset: 12187},
+							val:        ",",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 385, col: 45, offset: 12191},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 385, col: 48, offset: 12194},
+							label: "key",
+							expr: &ruleRefExpr{
+								pos:  position{line: 385, col: 52, offset: 12198},
+								name: "IdentifierName",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 385, col: 67, offset: 12213},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 385, col: 70, offset: 12216},
+							val:        ",",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 385, col: 74, offset: 12220},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 385, col: 77, offset: 12223},
+							label: "val",
+							expr: &ruleRefExpr{
+								pos:  position{line: 385, col: 81, offset: 12227},
+								name: "IdentifierName",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 385, col: 96, offset: 12242},
+							label: "dup",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 385, col: 100, offset: 12246},
+								expr: &seqExpr{
+									pos: position{line: 385, col: 102, offset: 12248},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 385, col: 102, offset: 12248},
+											name: "__",
+										},
+										&litMatcher{
+											pos:        position{line: 385, col: 105, offset: 12251},
+											val:        ",",
 											ignoreCase: false,
-											inverted:   false,
 										},
-									},
-									&ruleRefExpr{
-										pos:  position{line: 284, col: 18, offset: 8663},
-										name: "SourceChar",
+										&ruleRefExpr{
+											pos:  position{line: 385, col: 109, offset: 12255},
+											name: "__",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 385, col: 112, offset: 12258},
+											name: "StringLiteral",
+										},
 									},
 								},
 							},
 						},
-						&seqExpr{
-							pos: position{line: 284, col: 34, offset: 8679},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 284, col: 34, offset: 8679},
-									val:        "{",
-									ignoreCase: false,
-								},
-								&ruleRefExpr{
-									pos:  position{line: 284, col: 38, offset: 8683},
-									name: "Code",
-								},
-								&litMatcher{
-									pos:        position{line: 284, col: 43, offset: 8688},
-									val:        "}",
-									ignoreCase: false,
-								},
-							},
+						&ruleRefExpr{
+							pos:  position{line: 385, col: 129, offset: 12275},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 385, col: 132, offset: 12278},
+							val:        ")",
+							ignoreCase: false,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "__",
-			pos:  position{line: 286, col: 1, offset: 8696},
-			expr: &zeroOrMoreExpr{
-				pos: position{line: 286, col: 6, offset: 8703},
-				expr: &choiceExpr{
-					pos: position{line: 286, col: 8, offset: 8705},
-					alternatives: []interface{}{
-						&ruleRefExpr{
-							pos:  position{line: 286, col: 8, offset: 8705},
-							name: "Whitespace",
+			name: "PeekExpr",
+			pos:  position{line: 402, col: 1, offset: 12756},
+			expr: &actionExpr{
+				pos: position{line: 402, col: 12, offset: 12769},
+				run: (*parser).callonPeekExpr1,
+				expr: &seqExpr{
+					pos: position{line: 402, col: 12, offset: 12769},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 402, col: 12, offset: 12769},
+							val:        "@peek(",
+							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 286, col: 21, offset: 8718},
-							name: "EOL",
+							pos:  position{line: 402, col: 21, offset: 12778},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 402, col: 24, offset: 12781},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 402, col: 29, offset: 12786},
+								name: "Expression",
+							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 286, col: 27, offset: 8724},
-							name: "Comment",
+							pos:  position{line: 402, col: 40, offset: 12797},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 402, col: 43, offset: 12800},
+							val:        ")",
+							ignoreCase: false,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "_",
-			pos:  position{line: 287, col: 1, offset: 8735},
-			expr: &zeroOrMoreExpr{
-				pos: position{line: 287, col: 5, offset: 8741},
-				expr: &choiceExpr{
-					pos: position{line: 287, col: 7, offset: 8743},
-					alternatives: []interface{}{
+			name: "ConvertExpr",
+			pos:  position{line: 408, col: 1, offset: 12923},
+			expr: &actionExpr{
+				pos: position{line: 408, col: 15, offset: 12939},
+				run: (*parser).callonConvertExpr1,
+				expr: &seqExpr{
+					pos: position{line: 408, col: 15, offset: 12939},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 408, col: 15, offset: 12939},
+							val:        "@",
+							ignoreCase: false,
+						},
+						&notExpr{
+							pos: position{line: 408, col: 19, offset: 12943},
+							expr: &ruleRefExpr{
+								pos:  position{line: 408, col: 20, offset: 12944},
+								name: "ReservedAnnotation",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 408, col: 39, offset: 12963},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 408, col: 44, offset: 12968},
+								name: "IdentifierName",
+							},
+						},
+						&litMatcher{
+							pos:        position{line: 408, col: 59, offset: 12983},
+							val:        "(",
+							ignoreCase: false,
+						},
 						&ruleRefExpr{
-							pos:  position{line: 287, col: 7, offset: 8743},
-							name: "Whitespace",
+							pos:  position{line: 408, col: 63, offset: 12987},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 408, col: 66, offset: 12990},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 408, col: 71, offset: 12995},
+								name: "Expression",
+							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 287, col: 20, offset: 8756},
-							name: "MultiLineCommentNoLineTerminator",
+							pos:  position{line: 408, col: 82, offset: 13006},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 408, col: 85, offset: 13009},
+							val:        ")",
+							ignoreCase: false,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "Whitespace",
-			pos:  position{line: 289, col: 1, offset: 8793},
-			expr: &charClassMatcher{
-				pos:        position{line: 289, col: 14, offset: 8808},
-				val:        "[ \\t\\r]",
-				chars:      []rune{' ', '\t', '\r'},
-				ignoreCase: false,
-				inverted:   false,
-			},
-		},
-		{
-			name: "EOL",
-			pos:  position{line: 290, col: 1, offset: 8816},
-			expr: &litMatcher{
-				pos:        position{line: 290, col: 7, offset: 8824},
-				val:        "\n",
-				ignoreCase: false,
-			},
-		},
-		{
-			name: "EOS",
-			pos:  position{line: 291, col: 1, offset: 8829},
-			expr: &choiceExpr{
-				pos: position{line: 291, col: 7, offset: 8837},
-				alternatives: []interface{}{
-					&seqExpr{
-						pos: position{line: 291, col: 7, offset: 8837},
-						exprs: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 291, col: 7, offset: 8837},
-								name: "__",
+			name: "ReservedAnnotation",
+			pos:  position{line: 415, col: 1, offset: 13216},
+			expr: &seqExpr{
+				pos: position{line: 415, col: 22, offset: 13239},
+				exprs: []interface{}{
+					&litSetMatcher{
+						pos: position{line: 415, col: 24, offset: 13241},
+						alts: []*litMatcher{
+							&litMatcher{
+								pos:        position{line: 415, col: 24, offset: 13241},
+								val:        "array",
+								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 291, col: 10, offset: 8840},
-								val:        ";",
+								pos:        position{line: 415, col: 34, offset: 13251},
+								val:        "budget",
 								ignoreCase: false,
 							},
-						},
-					},
-					&seqExpr{
-						pos: position{line: 291, col: 16, offset: 8846},
-						exprs: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 291, col: 16, offset: 8846},
-								name: "_",
+							&litMatcher{
+								pos:        position{line: 415, col: 45, offset: 13262},
+								val:        "compact",
+								ignoreCase: false,
 							},
-							&zeroOrOneExpr{
-								pos: position{line: 291, col: 18, offset: 8848},
-								expr: &ruleRefExpr{
-									pos:  position{line: 291, col: 18, offset: 8848},
-									name: "SingleLineComment",
-								},
+							&litMatcher{
+								pos:        position{line: 415, col: 57, offset: 13274},
+								val:        "ignorecase",
+								ignoreCase: false,
 							},
-							&ruleRefExpr{
-								pos:  position{line: 291, col: 37, offset: 8867},
-								name: "EOL",
+							&litMatcher{
+								pos:        position{line: 415, col: 72, offset: 13289},
+								val:        "if",
+								ignoreCase: false,
 							},
-						},
-					},
-					&seqExpr{
-						pos: position{line: 291, col: 43, offset: 8873},
-						exprs: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 291, col: 43, offset: 8873},
-								name: "__",
+							&litMatcher{
+								pos:        position{line: 415, col: 79, offset: 13296},
+								val:        "longest",
+								ignoreCase: false,
 							},
-							&ruleRefExpr{
-								pos:  position{line: 291, col: 46, offset: 8876},
-								name: "EOF",
+							&litMatcher{
+								pos:        position{line: 415, col: 91, offset: 13308},
+								val:        "map",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 99, offset: 13316},
+								val:        "meta",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 108, offset: 13325},
+								val:        "peek",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 117, offset: 13334},
+								val:        "sep",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 125, offset: 13342},
+								val:        "table",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 135, offset: 13352},
+								val:        "token",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 145, offset: 13362},
+								val:        "type",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 154, offset: 13371},
+								val:        "unreserved",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 169, offset: 13386},
+								val:        "verbatim",
+								ignoreCase: false,
+							},
+							&litMatcher{
+								pos:        position{line: 415, col: 182, offset: 13399},
+								val:        "when",
+								ignoreCase: false,
 							},
 						},
 					},
+					&litMatcher{
+						pos:        position{line: 415, col: 191, offset: 13408},
+						val:        "(",
+						ignoreCase: false,
+					},
 				},
 			},
 		},
 		{
-			name: "EOF",
-			pos:  position{line: 293, col: 1, offset: 8881},
-			expr: &notExpr{
-				pos: position{line: 293, col: 7, offset: 8889},
-				expr: &anyMatcher{
-					line: 293, col: 8, offset: 8890,
+			name: "SeenExpr",
+			pos:  position{line: 416, col: 1, offset: 13412},
+			expr: &actionExpr{
+				pos: position{line: 416, col: 12, offset: 13425},
+				run: (*parser).callonSeenExpr1,
+				expr: &seqExpr{
+					pos: position{line: 416, col: 12, offset: 13425},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 416, col: 12, offset: 13425},
+							val:        "@seen=",
+							ignoreCase: false,
+						},
+						&labeledExpr{
+							pos:   position{line: 416, col: 21, offset: 13434},
+							label: "label",
+							expr: &ruleRefExpr{
+								pos:  position{line: 416, col: 27, offset: 13440},
+								name: "IdentifierName",
+							},
+						},
+					},
 				},
 			},
 		},
-	},
-}
-
-func (c *current) onGrammar1(initializer, rules interface{}) (interface{}, error) {
-	pos := c.astPos()
-
-	// create the grammar, assign its initializer
-	g := ast.NewGrammar(pos)
-	initSlice := toIfaceSlice(initializer)
-	if len(initSlice) > 0 {
-		g.Init = initSlice[0].(*ast.CodeBlock)
-	}
-
+		{
+			name: "BackRefExpr",
+			pos:  position{line: 421, col: 1, offset: 13561},
+			expr: &actionExpr{
+				pos: position{line: 421, col: 15, offset: 13577},
+				run: (*parser).callonBackRefExpr1,
+				expr: &seqExpr{
+					pos: position{line: 421, col: 15, offset: 13577},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 421, col: 15, offset: 13577},
+							val:        "@=",
+							ignoreCase: false,
+						},
+						&labeledExpr{
+							pos:   position{line: 421, col: 20, offset: 13582},
+							label: "label",
+							expr: &ruleRefExpr{
+								pos:  position{line: 421, col: 26, offset: 13588},
+								name: "IdentifierName",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "LookbehindExpr",
+			pos:  position{line: 426, col: 1, offset: 13709},
+			expr: &actionExpr{
+				pos: position{line: 426, col: 18, offset: 13728},
+				run: (*parser).callonLookbehindExpr1,
+				expr: &seqExpr{
+					pos: position{line: 426, col: 18, offset: 13728},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 426, col: 18, offset: 13728},
+							val:        "<=",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 426, col: 23, offset: 13733},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 426, col: 26, offset: 13736},
+							label: "expr",
+							expr: &choiceExpr{
+								pos: position{line: 426, col: 33, offset: 13743},
+								alternatives: []interface{}{
+									&ruleRefExpr{
+										pos:  position{line: 426, col: 33, offset: 13743},
+										name: "LitMatcher",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 426, col: 46, offset: 13756},
+										name: "CharClassMatcher",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 426, col: 65, offset: 13775},
+										name: "AnyMatcher",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SepExpr",
+			pos:  position{line: 431, col: 1, offset: 13891},
+			expr: &actionExpr{
+				pos: position{line: 431, col: 11, offset: 13903},
+				run: (*parser).callonSepExpr1,
+				expr: &seqExpr{
+					pos: position{line: 431, col: 11, offset: 13903},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 431, col: 11, offset: 13903},
+							val:        "@sep(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 431, col: 19, offset: 13911},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 431, col: 22, offset: 13914},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 431, col: 27, offset: 13919},
+								name: "Expression",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 431, col: 38, offset: 13930},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 431, col: 41, offset: 13933},
+							val:        ",",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 431, col: 45, offset: 13937},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 431, col: 48, offset: 13940},
+							label: "sep",
+							expr: &ruleRefExpr{
+								pos:  position{line: 431, col: 52, offset: 13944},
+								name: "Expression",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 431, col: 63, offset: 13955},
+							label: "flags",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 431, col: 69, offset: 13961},
+								expr: &seqExpr{
+									pos: position{line: 431, col: 71, offset: 13963},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 431, col: 71, offset: 13963},
+											name: "__",
+										},
+										&litMatcher{
+											pos:        position{line: 431, col: 74, offset: 13966},
+											val:        ",",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 431, col: 78, offset: 13970},
+											name: "__",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 431, col: 81, offset: 13973},
+											name: "SepFlag",
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 431, col: 92, offset: 13984},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 431, col: 95, offset: 13987},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SepFlag",
+			pos:  position{line: 447, col: 1, offset: 14412},
+			expr: &actionExpr{
+				pos: position{line: 447, col: 11, offset: 14424},
+				run: (*parser).callonSepFlag1,
+				expr: &seqExpr{
+					pos: position{line: 447, col: 11, offset: 14424},
+					exprs: []interface{}{
+						&litSetMatcher{
+							pos: position{line: 447, col: 13, offset: 14426},
+							alts: []*litMatcher{
+								&litMatcher{
+									pos:        position{line: 447, col: 13, offset: 14426},
+									val:        "trailing",
+									ignoreCase: false,
+								},
+								&litMatcher{
+									pos:        position{line: 447, col: 26, offset: 14439},
+									val:        "terminated",
+									ignoreCase: false,
+								},
+								&litMatcher{
+									pos:        position{line: 447, col: 41, offset: 14454},
+									val:        "keep",
+									ignoreCase: false,
+								},
+							},
+						},
+						&notExpr{
+							pos: position{line: 447, col: 50, offset: 14463},
+							expr: &ruleRefExpr{
+								pos:  position{line: 447, col: 51, offset: 14464},
+								name: "IdentifierPart",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SemanticPredExpr",
+			pos:  position{line: 451, col: 1, offset: 14515},
+			expr: &actionExpr{
+				pos: position{line: 451, col: 20, offset: 14536},
+				run: (*parser).callonSemanticPredExpr1,
+				expr: &seqExpr{
+					pos: position{line: 451, col: 20, offset: 14536},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 451, col: 20, offset: 14536},
+							label: "op",
+							expr: &ruleRefExpr{
+								pos:  position{line: 451, col: 23, offset: 14539},
+								name: "SemanticPredOp",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 451, col: 38, offset: 14554},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 451, col: 41, offset: 14557},
+							label: "code",
+							expr: &ruleRefExpr{
+								pos:  position{line: 451, col: 46, offset: 14562},
+								name: "CodeBlock",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SemanticPredOp",
+			pos:  position{line: 462, col: 1, offset: 14839},
+			expr: &actionExpr{
+				pos: position{line: 462, col: 18, offset: 14858},
+				run: (*parser).callonSemanticPredOp1,
+				expr: &litSetMatcher{
+					pos: position{line: 462, col: 20, offset: 14860},
+					alts: []*litMatcher{
+						&litMatcher{
+							pos:        position{line: 462, col: 20, offset: 14860},
+							val:        "&",
+							ignoreCase: false,
+						},
+						&litMatcher{
+							pos:        position{line: 462, col: 26, offset: 14866},
+							val:        "!",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RuleDefOp",
+			pos:  position{line: 466, col: 1, offset: 14908},
+			expr: &litSetMatcher{
+				pos: position{line: 466, col: 13, offset: 14922},
+				alts: []*litMatcher{
+					&litMatcher{
+						pos:        position{line: 466, col: 13, offset: 14922},
+						val:        "=",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 466, col: 19, offset: 14928},
+						val:        "<-",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 466, col: 26, offset: 14935},
+						val:        "←",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 466, col: 37, offset: 14946},
+						val:        "⟵",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
+		{
+			name: "SourceChar",
+			pos:  position{line: 468, col: 1, offset: 14956},
+			expr: &anyMatcher{
+				line: 468, col: 14, offset: 14971,
+			},
+		},
+		{
+			name: "Comment",
+			pos:  position{line: 469, col: 1, offset: 14973},
+			expr: &choiceExpr{
+				pos: position{line: 469, col: 11, offset: 14985},
+				alternatives: []interface{}{
+					&ruleRefExpr{
+						pos:  position{line: 469, col: 11, offset: 14985},
+						name: "MultiLineComment",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 469, col: 30, offset: 15004},
+						name: "SingleLineComment",
+					},
+				},
+			},
+		},
+		{
+			name: "MultiLineComment",
+			pos:  position{line: 470, col: 1, offset: 15022},
+			expr: &seqExpr{
+				pos: position{line: 470, col: 20, offset: 15043},
+				exprs: []interface{}{
+					&litMatcher{
+						pos:        position{line: 470, col: 20, offset: 15043},
+						val:        "/*",
+						ignoreCase: false,
+					},
+					&zeroOrMoreExpr{
+						pos: position{line: 470, col: 25, offset: 15048},
+						expr: &seqExpr{
+							pos: position{line: 470, col: 27, offset: 15050},
+							exprs: []interface{}{
+								&notExpr{
+									pos: position{line: 470, col: 27, offset: 15050},
+									expr: &litMatcher{
+										pos:        position{line: 470, col: 28, offset: 15051},
+										val:        "*/",
+										ignoreCase: false,
+									},
+								},
+								&anyMatcher{
+									line: 468, col: 14, offset: 14971,
+								},
+							},
+						},
+					},
+					&litMatcher{
+						pos:        position{line: 470, col: 47, offset: 15070},
+						val:        "*/",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
+		{
+			name: "MultiLineCommentNoLineTerminator",
+			pos:  position{line: 471, col: 1, offset: 15075},
+			expr: &seqExpr{
+				pos: position{line: 471, col: 36, offset: 15112},
+				exprs: []interface{}{
+					&litMatcher{
+						pos:        position{line: 471, col: 36, offset: 15112},
+						val:        "/*",
+						ignoreCase: false,
+					},
+					&zeroOrMoreExpr{
+						pos: position{line: 471, col: 41, offset: 15117},
+						expr: &seqExpr{
+							pos: position{line: 471, col: 43, offset: 15119},
+							exprs: []interface{}{
+								&notExpr{
+									pos: position{line: 471, col: 43, offset: 15119},
+									expr: &choiceExpr{
+										pos: position{line: 471, col: 46, offset: 15122},
+										alternatives: []interface{}{
+											&litMatcher{
+												pos:        position{line: 471, col: 46, offset: 15122},
+												val:        "*/",
+												ignoreCase: false,
+											},
+											&litMatcher{
+												pos:        position{line: 757, col: 7, offset: 25216},
+												val:        "\n",
+												ignoreCase: false,
+											},
+										},
+									},
+								},
+								&anyMatcher{
+									line: 468, col: 14, offset: 14971,
+								},
+							},
+						},
+					},
+					&litMatcher{
+						pos:        position{line: 471, col: 73, offset: 15149},
+						val:        "*/",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
+		{
+			name: "SingleLineComment",
+			pos:  position{line: 472, col: 1, offset: 15154},
+			expr: &seqExpr{
+				pos: position{line: 472, col: 21, offset: 15176},
+				exprs: []interface{}{
+					&litMatcher{
+						pos:        position{line: 472, col: 21, offset: 15176},
+						val:        "//",
+						ignoreCase: false,
+					},
+					&zeroOrMoreExpr{
+						pos: position{line: 472, col: 26, offset: 15181},
+						expr: &seqExpr{
+							pos: position{line: 472, col: 28, offset: 15183},
+							exprs: []interface{}{
+								&notExpr{
+									pos: position{line: 472, col: 28, offset: 15183},
+									expr: &litMatcher{
+										pos:        position{line: 757, col: 7, offset: 25216},
+										val:        "\n",
+										ignoreCase: false,
+									},
+								},
+								&anyMatcher{
+									line: 468, col: 14, offset: 14971,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Identifier",
+			pos:  position{line: 474, col: 1, offset: 15203},
+			expr: &actionExpr{
+				pos: position{line: 474, col: 14, offset: 15218},
+				run: (*parser).callonIdentifier1,
+				expr: &ruleRefExpr{
+					pos:  position{line: 474, col: 20, offset: 15224},
+					name: "IdentifierName",
+				},
+			},
+		},
+		{
+			name: "IdentifierName",
+			pos:  position{line: 482, col: 1, offset: 15443},
+			expr: &actionExpr{
+				pos: position{line: 482, col: 18, offset: 15462},
+				run: (*parser).callonIdentifierName1,
+				expr: &seqExpr{
+					pos: position{line: 482, col: 18, offset: 15462},
+					exprs: []interface{}{
+						&charClassMatcher{
+							pos:        position{line: 485, col: 19, offset: 15580},
+							val:        "[\\pL_]",
+							chars:      []rune{'_'},
+							classes:    []*unicode.RangeTable{rangeTable("L")},
+							classNames: []string{"L"},
+							ignoreCase: false,
+							inverted:   false,
+						},
+						&zeroOrMoreExpr{
+							pos: position{line: 482, col: 34, offset: 15478},
+							expr: &ruleRefExpr{
+								pos:  position{line: 482, col: 34, offset: 15478},
+								name: "IdentifierPart",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "IdentifierStart",
+			pos:  position{line: 485, col: 1, offset: 15560},
+			expr: &charClassMatcher{
+				pos:        position{line: 485, col: 19, offset: 15580},
+				val:        "[\\pL_]",
+				chars:      []rune{'_'},
+				classes:    []*unicode.RangeTable{rangeTable("L")},
+				classNames: []string{"L"},
+				ignoreCase: false,
+				inverted:   false,
+			},
+		},
+		{
+			name: "IdentifierPart",
+			pos:  position{line: 486, col: 1, offset: 15587},
+			expr: &choiceExpr{
+				pos: position{line: 486, col: 18, offset: 15606},
+				alternatives: []interface{}{
+					&charClassMatcher{
+						pos:        position{line: 485, col: 19, offset: 15580},
+						val:        "[\\pL_]",
+						chars:      []rune{'_'},
+						classes:    []*unicode.RangeTable{rangeTable("L")},
+						classNames: []string{"L"},
+						ignoreCase: false,
+						inverted:   false,
+					},
+					&charClassMatcher{
+						pos:        position{line: 486, col: 36, offset: 15624},
+						val:        "[\\p{Nd}]",
+						classes:    []*unicode.RangeTable{rangeTable("Nd")},
+						classNames: []string{"Nd"},
+						ignoreCase: false,
+						inverted:   false,
+					},
+				},
+			},
+		},
+		{
+			name: "LitMatcher",
+			pos:  position{line: 488, col: 1, offset: 15634},
+			expr: &actionExpr{
+				pos: position{line: 488, col: 14, offset: 15649},
+				run: (*parser).callonLitMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 488, col: 14, offset: 15649},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 488, col: 14, offset: 15649},
+							label: "lit",
+							expr: &ruleRefExpr{
+								pos:  position{line: 488, col: 18, offset: 15653},
+								name: "StringLiteral",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 488, col: 32, offset: 15667},
+							label: "ignore",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 488, col: 39, offset: 15674},
+								expr: &litMatcher{
+									pos:        position{line: 488, col: 39, offset: 15674},
+									val:        "i",
+									ignoreCase: false,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "StringLiteral",
+			pos:  position{line: 501, col: 1, offset: 16073},
+			expr: &choiceExpr{
+				pos: position{line: 501, col: 17, offset: 16091},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 501, col: 17, offset: 16091},
+						run: (*parser).callonStringLiteral2,
+						expr: &choiceExpr{
+							pos: position{line: 501, col: 19, offset: 16093},
+							alternatives: []interface{}{
+								&seqExpr{
+									pos: position{line: 501, col: 19, offset: 16093},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 501, col: 19, offset: 16093},
+											val:        "\"",
+											ignoreCase: false,
+										},
+										&zeroOrMoreExpr{
+											pos: position{line: 501, col: 23, offset: 16097},
+											expr: &ruleRefExpr{
+												pos:  position{line: 501, col: 23, offset: 16097},
+												name: "DoubleStringChar",
+											},
+										},
+										&litMatcher{
+											pos:        position{line: 501, col: 41, offset: 16115},
+											val:        "\"",
+											ignoreCase: false,
+										},
+									},
+								},
+								&seqExpr{
+									pos: position{line: 501, col: 47, offset: 16121},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 501, col: 47, offset: 16121},
+											val:        "'",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 501, col: 51, offset: 16125},
+											name: "SingleStringChar",
+										},
+										&litMatcher{
+											pos:        position{line: 501, col: 68, offset: 16142},
+											val:        "'",
+											ignoreCase: false,
+										},
+									},
+								},
+								&seqExpr{
+									pos: position{line: 501, col: 74, offset: 16148},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 501, col: 74, offset: 16148},
+											val:        "`",
+											ignoreCase: false,
+										},
+										&zeroOrMoreExpr{
+											pos: position{line: 501, col: 78, offset: 16152},
+											expr: &ruleRefExpr{
+												pos:  position{line: 501, col: 78, offset: 16152},
+												name: "RawStringChar",
+											},
+										},
+										&litMatcher{
+											pos:        position{line: 501, col: 93, offset: 16167},
+											val:        "`",
+											ignoreCase: false,
+										},
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 503, col: 5, offset: 16240},
+						run: (*parser).callonStringLiteral18,
+						expr: &choiceExpr{
+							pos: position{line: 503, col: 7, offset: 16242},
+							alternatives: []interface{}{
+								&seqExpr{
+									pos: position{line: 503, col: 9, offset: 16244},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 503, col: 9, offset: 16244},
+											val:        "\"",
+											ignoreCase: false,
+										},
+										&zeroOrMoreExpr{
+											pos: position{line: 503, col: 13, offset: 16248},
+											expr: &ruleRefExpr{
+												pos:  position{line: 503, col: 13, offset: 16248},
+												name: "DoubleStringChar",
+											},
+										},
+										&choiceExpr{
+											pos: position{line: 503, col: 33, offset: 16268},
+											alternatives: []interface{}{
+												&litMatcher{
+													pos:        position{line: 757, col: 7, offset: 25216},
+													val:        "\n",
+													ignoreCase: false,
+												},
+												&ruleRefExpr{
+													pos:  position{line: 503, col: 39, offset: 16274},
+													name: "EOF",
+												},
+											},
+										},
+									},
+								},
+								&seqExpr{
+									pos: position{line: 503, col: 51, offset: 16286},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 503, col: 51, offset: 16286},
+											val:        "'",
+											ignoreCase: false,
+										},
+										&zeroOrOneExpr{
+											pos: position{line: 503, col: 55, offset: 16290},
+											expr: &ruleRefExpr{
+												pos:  position{line: 503, col: 55, offset: 16290},
+												name: "SingleStringChar",
+											},
+										},
+										&choiceExpr{
+											pos: position{line: 503, col: 75, offset: 16310},
+											alternatives: []interface{}{
+												&litMatcher{
+													pos:        position{line: 757, col: 7, offset: 25216},
+													val:        "\n",
+													ignoreCase: false,
+												},
+												&ruleRefExpr{
+													pos:  position{line: 503, col: 81, offset: 16316},
+													name: "EOF",
+												},
+											},
+										},
+									},
+								},
+								&seqExpr{
+									pos: position{line: 503, col: 91, offset: 16326},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 503, col: 91, offset: 16326},
+											val:        "`",
+											ignoreCase: false,
+										},
+										&zeroOrMoreExpr{
+											pos: position{line: 503, col: 95, offset: 16330},
+											expr: &ruleRefExpr{
+												pos:  position{line: 503, col: 95, offset: 16330},
+												name: "RawStringChar",
+											},
+										},
+										&ruleRefExpr{
+											pos:  position{line: 503, col: 110, offset: 16345},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "DoubleStringChar",
+			pos:  position{line: 507, col: 1, offset: 16447},
+			expr: &choiceExpr{
+				pos: position{line: 507, col: 20, offset: 16468},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 507, col: 20, offset: 16468},
+						exprs: []interface{}{
+							&notExpr{
+								pos: position{line: 507, col: 20, offset: 16468},
+								expr: &choiceExpr{
+									pos: position{line: 507, col: 23, offset: 16471},
+									alternatives: []interface{}{
+										&litMatcher{
+											pos:        position{line: 507, col: 23, offset: 16471},
+											val:        "\"",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 507, col: 29, offset: 16477},
+											val:        "\\",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+									},
+								},
+							},
+							&anyMatcher{
+								line: 468, col: 14, offset: 14971,
+							},
+						},
+					},
+					&seqExpr{
+						pos: position{line: 507, col: 55, offset: 16503},
+						exprs: []interface{}{
+							&litMatcher{
+								pos:        position{line: 507, col: 55, offset: 16503},
+								val:        "\\",
+								ignoreCase: false,
+							},
+							&ruleRefExpr{
+								pos:  position{line: 507, col: 60, offset: 16508},
+								name: "DoubleStringEscape",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SingleStringChar",
+			pos:  position{line: 508, col: 1, offset: 16527},
+			expr: &choiceExpr{
+				pos: position{line: 508, col: 20, offset: 16548},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 508, col: 20, offset: 16548},
+						exprs: []interface{}{
+							&notExpr{
+								pos: position{line: 508, col: 20, offset: 16548},
+								expr: &choiceExpr{
+									pos: position{line: 508, col: 23, offset: 16551},
+									alternatives: []interface{}{
+										&litMatcher{
+											pos:        position{line: 508, col: 23, offset: 16551},
+											val:        "'",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 508, col: 29, offset: 16557},
+											val:        "\\",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+									},
+								},
+							},
+							&anyMatcher{
+								line: 468, col: 14, offset: 14971,
+							},
+						},
+					},
+					&seqExpr{
+						pos: position{line: 508, col: 55, offset: 16583},
+						exprs: []interface{}{
+							&litMatcher{
+								pos:        position{line: 508, col: 55, offset: 16583},
+								val:        "\\",
+								ignoreCase: false,
+							},
+							&ruleRefExpr{
+								pos:  position{line: 508, col: 60, offset: 16588},
+								name: "SingleStringEscape",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RawStringChar",
+			pos:  position{line: 509, col: 1, offset: 16607},
+			expr: &seqExpr{
+				pos: position{line: 509, col: 17, offset: 16625},
+				exprs: []interface{}{
+					&notExpr{
+						pos: position{line: 509, col: 17, offset: 16625},
+						expr: &litMatcher{
+							pos:        position{line: 509, col: 18, offset: 16626},
+							val:        "`",
+							ignoreCase: false,
+						},
+					},
+					&anyMatcher{
+						line: 468, col: 14, offset: 14971,
+					},
+				},
+			},
+		},
+		{
+			name: "DoubleStringEscape",
+			pos:  position{line: 511, col: 1, offset: 16642},
+			expr: &choiceExpr{
+				pos: position{line: 511, col: 22, offset: 16665},
+				alternatives: []interface{}{
+					&choiceExpr{
+						pos: position{line: 511, col: 24, offset: 16667},
+						alternatives: []interface{}{
+							&litMatcher{
+								pos:        position{line: 511, col: 24, offset: 16667},
+								val:        "\"",
+								ignoreCase: false,
+							},
+							&ruleRefExpr{
+								pos:  position{line: 511, col: 30, offset: 16673},
+								name: "CommonEscapeSequence",
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 512, col: 7, offset: 16702},
+						run: (*parser).callonDoubleStringEscape5,
+						expr: &choiceExpr{
+							pos: position{line: 512, col: 9, offset: 16704},
+							alternatives: []interface{}{
+								&anyMatcher{
+									line: 468, col: 14, offset: 14971,
+								},
+								&litMatcher{
+									pos:        position{line: 757, col: 7, offset: 25216},
+									val:        "\n",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 512, col: 28, offset: 16723},
+									name: "EOF",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SingleStringEscape",
+			pos:  position{line: 515, col: 1, offset: 16788},
+			expr: &choiceExpr{
+				pos: position{line: 515, col: 22, offset: 16811},
+				alternatives: []interface{}{
+					&choiceExpr{
+						pos: position{line: 515, col: 24, offset: 16813},
+						alternatives: []interface{}{
+							&litMatcher{
+								pos:        position{line: 515, col: 24, offset: 16813},
+								val:        "'",
+								ignoreCase: false,
+							},
+							&ruleRefExpr{
+								pos:  position{line: 515, col: 30, offset: 16819},
+								name: "CommonEscapeSequence",
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 516, col: 7, offset: 16848},
+						run: (*parser).callonSingleStringEscape5,
+						expr: &choiceExpr{
+							pos: position{line: 516, col: 9, offset: 16850},
+							alternatives: []interface{}{
+								&anyMatcher{
+									line: 468, col: 14, offset: 14971,
+								},
+								&litMatcher{
+									pos:        position{line: 757, col: 7, offset: 25216},
+									val:        "\n",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 516, col: 28, offset: 16869},
+									name: "EOF",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CommonEscapeSequence",
+			pos:  position{line: 520, col: 1, offset: 16935},
+			expr: &choiceExpr{
+				pos: position{line: 520, col: 24, offset: 16960},
+				alternatives: []interface{}{
+					&ruleRefExpr{
+						pos:  position{line: 520, col: 24, offset: 16960},
+						name: "SingleCharEscape",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 520, col: 43, offset: 16979},
+						name: "OctalEscape",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 520, col: 57, offset: 16993},
+						name: "HexEscape",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 520, col: 69, offset: 17005},
+						name: "LongUnicodeEscape",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 520, col: 89, offset: 17025},
+						name: "ShortUnicodeEscape",
+					},
+				},
+			},
+		},
+		{
+			name: "SingleCharEscape",
+			pos:  position{line: 521, col: 1, offset: 17044},
+			expr: &litSetMatcher{
+				pos: position{line: 521, col: 20, offset: 17065},
+				alts: []*litMatcher{
+					&litMatcher{
+						pos:        position{line: 521, col: 20, offset: 17065},
+						val:        "a",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 26, offset: 17071},
+						val:        "b",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 32, offset: 17077},
+						val:        "n",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 38, offset: 17083},
+						val:        "f",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 44, offset: 17089},
+						val:        "r",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 50, offset: 17095},
+						val:        "t",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 56, offset: 17101},
+						val:        "v",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 521, col: 62, offset: 17107},
+						val:        "\\",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
+		{
+			name: "OctalEscape",
+			pos:  position{line: 522, col: 1, offset: 17112},
+			expr: &choiceExpr{
+				pos: position{line: 522, col: 15, offset: 17128},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 522, col: 15, offset: 17128},
+						exprs: []interface{}{
+							&charClassMatcher{
+								pos:        position{line: 545, col: 14, offset: 17943},
+								val:        "[0-7]",
+								ranges:     []rune{'0', '7'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+							&charClassMatcher{
+								pos:        position{line: 545, col: 14, offset: 17943},
+								val:        "[0-7]",
+								ranges:     []rune{'0', '7'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+							&charClassMatcher{
+								pos:        position{line: 545, col: 14, offset: 17943},
+								val:        "[0-7]",
+								ranges:     []rune{'0', '7'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 523, col: 7, offset: 17167},
+						run: (*parser).callonOctalEscape6,
+						expr: &seqExpr{
+							pos: position{line: 523, col: 7, offset: 17167},
+							exprs: []interface{}{
+								&charClassMatcher{
+									pos:        position{line: 545, col: 14, offset: 17943},
+									val:        "[0-7]",
+									ranges:     []rune{'0', '7'},
+									ignoreCase: false,
+									inverted:   false,
+								},
+								&choiceExpr{
+									pos: position{line: 523, col: 20, offset: 17180},
+									alternatives: []interface{}{
+										&anyMatcher{
+											line: 468, col: 14, offset: 14971,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 523, col: 39, offset: 17199},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "HexEscape",
+			pos:  position{line: 526, col: 1, offset: 17260},
+			expr: &choiceExpr{
+				pos: position{line: 526, col: 13, offset: 17274},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 526, col: 13, offset: 17274},
+						exprs: []interface{}{
+							&litMatcher{
+								pos:        position{line: 526, col: 13, offset: 17274},
+								val:        "x",
+								ignoreCase: false,
+							},
+							&charClassMatcher{
+								pos:        position{line: 547, col: 12, offset: 17985},
+								val:        "[0-9a-f]i",
+								ranges:     []rune{'0', '9', 'a', 'f'},
+								ignoreCase: true,
+								inverted:   false,
+							},
+							&charClassMatcher{
+								pos:        position{line: 547, col: 12, offset: 17985},
+								val:        "[0-9a-f]i",
+								ranges:     []rune{'0', '9', 'a', 'f'},
+								ignoreCase: true,
+								inverted:   false,
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 527, col: 7, offset: 17302},
+						run: (*parser).callonHexEscape6,
+						expr: &seqExpr{
+							pos: position{line: 527, col: 7, offset: 17302},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 527, col: 7, offset: 17302},
+									val:        "x",
+									ignoreCase: false,
+								},
+								&choiceExpr{
+									pos: position{line: 527, col: 13, offset: 17308},
+									alternatives: []interface{}{
+										&anyMatcher{
+											line: 468, col: 14, offset: 14971,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 527, col: 32, offset: 17327},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "LongUnicodeEscape",
+			pos:  position{line: 530, col: 1, offset: 17394},
+			expr: &choiceExpr{
+				pos: position{line: 531, col: 5, offset: 17421},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 531, col: 5, offset: 17421},
+						run: (*parser).callonLongUnicodeEscape2,
+						expr: &seqExpr{
+							pos: position{line: 531, col: 5, offset: 17421},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 531, col: 5, offset: 17421},
+									val:        "U",
+									ignoreCase: false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 534, col: 7, offset: 17590},
+						run: (*parser).callonLongUnicodeEscape13,
+						expr: &seqExpr{
+							pos: position{line: 534, col: 7, offset: 17590},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 534, col: 7, offset: 17590},
+									val:        "U",
+									ignoreCase: false,
+								},
+								&choiceExpr{
+									pos: position{line: 534, col: 13, offset: 17596},
+									alternatives: []interface{}{
+										&anyMatcher{
+											line: 468, col: 14, offset: 14971,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 534, col: 32, offset: 17615},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ShortUnicodeEscape",
+			pos:  position{line: 537, col: 1, offset: 17678},
+			expr: &choiceExpr{
+				pos: position{line: 538, col: 5, offset: 17706},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 538, col: 5, offset: 17706},
+						run: (*parser).callonShortUnicodeEscape2,
+						expr: &seqExpr{
+							pos: position{line: 538, col: 5, offset: 17706},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 538, col: 5, offset: 17706},
+									val:        "u",
+									ignoreCase: false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+								&charClassMatcher{
+									pos:        position{line: 547, col: 12, offset: 17985},
+									val:        "[0-9a-f]i",
+									ranges:     []rune{'0', '9', 'a', 'f'},
+									ignoreCase: true,
+									inverted:   false,
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 541, col: 7, offset: 17839},
+						run: (*parser).callonShortUnicodeEscape9,
+						expr: &seqExpr{
+							pos: position{line: 541, col: 7, offset: 17839},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 541, col: 7, offset: 17839},
+									val:        "u",
+									ignoreCase: false,
+								},
+								&choiceExpr{
+									pos: position{line: 541, col: 13, offset: 17845},
+									alternatives: []interface{}{
+										&anyMatcher{
+											line: 468, col: 14, offset: 14971,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 541, col: 32, offset: 17864},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "OctalDigit",
+			pos:  position{line: 545, col: 1, offset: 17928},
+			expr: &charClassMatcher{
+				pos:        position{line: 545, col: 14, offset: 17943},
+				val:        "[0-7]",
+				ranges:     []rune{'0', '7'},
+				ignoreCase: false,
+				inverted:   false,
+			},
+		},
+		{
+			name: "DecimalDigit",
+			pos:  position{line: 546, col: 1, offset: 17949},
+			expr: &charClassMatcher{
+				pos:        position{line: 546, col: 16, offset: 17966},
+				val:        "[0-9]",
+				ranges:     []rune{'0', '9'},
+				ignoreCase: false,
+				inverted:   false,
+			},
+		},
+		{
+			name: "HexDigit",
+			pos:  position{line: 547, col: 1, offset: 17972},
+			expr: &charClassMatcher{
+				pos:        position{line: 547, col: 12, offset: 17985},
+				val:        "[0-9a-f]i",
+				ranges:     []rune{'0', '9', 'a', 'f'},
+				ignoreCase: true,
+				inverted:   false,
+			},
+		},
+		{
+			name: "CharClassMatcher",
+			pos:  position{line: 549, col: 1, offset: 17996},
+			expr: &choiceExpr{
+				pos: position{line: 549, col: 20, offset: 18017},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 549, col: 20, offset: 18017},
+						run: (*parser).callonCharClassMatcher2,
+						expr: &seqExpr{
+							pos: position{line: 549, col: 20, offset: 18017},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 549, col: 20, offset: 18017},
+									val:        "[",
+									ignoreCase: false,
+								},
+								&zeroOrMoreExpr{
+									pos: position{line: 549, col: 24, offset: 18021},
+									expr: &choiceExpr{
+										pos: position{line: 549, col: 26, offset: 18023},
+										alternatives: []interface{}{
+											&ruleRefExpr{
+												pos:  position{line: 549, col: 26, offset: 18023},
+												name: "ClassCharRange",
+											},
+											&ruleRefExpr{
+												pos:  position{line: 549, col: 43, offset: 18040},
+												name: "ClassChar",
+											},
+											&seqExpr{
+												pos: position{line: 549, col: 55, offset: 18052},
+												exprs: []interface{}{
+													&litMatcher{
+														pos:        position{line: 549, col: 55, offset: 18052},
+														val:        "\\",
+														ignoreCase: false,
+													},
+													&ruleRefExpr{
+														pos:  position{line: 549, col: 60, offset: 18057},
+														name: "UnicodeClassEscape",
+													},
+												},
+											},
+										},
+									},
+								},
+								&litMatcher{
+									pos:        position{line: 549, col: 82, offset: 18079},
+									val:        "]",
+									ignoreCase: false,
+								},
+								&zeroOrOneExpr{
+									pos: position{line: 549, col: 86, offset: 18083},
+									expr: &litMatcher{
+										pos:        position{line: 549, col: 86, offset: 18083},
+										val:        "i",
+										ignoreCase: false,
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 553, col: 5, offset: 18190},
+						run: (*parser).callonCharClassMatcher15,
+						expr: &seqExpr{
+							pos: position{line: 553, col: 5, offset: 18190},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 553, col: 5, offset: 18190},
+									val:        "[",
+									ignoreCase: false,
+								},
+								&zeroOrMoreExpr{
+									pos: position{line: 553, col: 9, offset: 18194},
+									expr: &seqExpr{
+										pos: position{line: 553, col: 11, offset: 18196},
+										exprs: []interface{}{
+											&notExpr{
+												pos: position{line: 553, col: 11, offset: 18196},
+												expr: &litMatcher{
+													pos:        position{line: 757, col: 7, offset: 25216},
+													val:        "\n",
+													ignoreCase: false,
+												},
+											},
+											&anyMatcher{
+												line: 468, col: 14, offset: 14971,
+											},
+										},
+									},
+								},
+								&choiceExpr{
+									pos: position{line: 553, col: 36, offset: 18221},
+									alternatives: []interface{}{
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 553, col: 42, offset: 18227},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ClassCharRange",
+			pos:  position{line: 557, col: 1, offset: 18337},
+			expr: &seqExpr{
+				pos: position{line: 557, col: 18, offset: 18356},
+				exprs: []interface{}{
+					&ruleRefExpr{
+						pos:  position{line: 557, col: 18, offset: 18356},
+						name: "ClassChar",
+					},
+					&litMatcher{
+						pos:        position{line: 557, col: 28, offset: 18366},
+						val:        "-",
+						ignoreCase: false,
+					},
+					&ruleRefExpr{
+						pos:  position{line: 557, col: 32, offset: 18370},
+						name: "ClassChar",
+					},
+				},
+			},
+		},
+		{
+			name: "ClassChar",
+			pos:  position{line: 558, col: 1, offset: 18380},
+			expr: &choiceExpr{
+				pos: position{line: 558, col: 13, offset: 18394},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 558, col: 13, offset: 18394},
+						exprs: []interface{}{
+							&notExpr{
+								pos: position{line: 558, col: 13, offset: 18394},
+								expr: &choiceExpr{
+									pos: position{line: 558, col: 16, offset: 18397},
+									alternatives: []interface{}{
+										&litMatcher{
+											pos:        position{line: 558, col: 16, offset: 18397},
+											val:        "]",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 558, col: 22, offset: 18403},
+											val:        "\\",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+									},
+								},
+							},
+							&anyMatcher{
+								line: 468, col: 14, offset: 14971,
+							},
+						},
+					},
+					&seqExpr{
+						pos: position{line: 558, col: 48, offset: 18429},
+						exprs: []interface{}{
+							&litMatcher{
+								pos:        position{line: 558, col: 48, offset: 18429},
+								val:        "\\",
+								ignoreCase: false,
+							},
+							&ruleRefExpr{
+								pos:  position{line: 558, col: 53, offset: 18434},
+								name: "CharClassEscape",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CharClassEscape",
+			pos:  position{line: 559, col: 1, offset: 18450},
+			expr: &choiceExpr{
+				pos: position{line: 559, col: 19, offset: 18470},
+				alternatives: []interface{}{
+					&choiceExpr{
+						pos: position{line: 559, col: 21, offset: 18472},
+						alternatives: []interface{}{
+							&litMatcher{
+								pos:        position{line: 559, col: 21, offset: 18472},
+								val:        "]",
+								ignoreCase: false,
+							},
+							&ruleRefExpr{
+								pos:  position{line: 559, col: 27, offset: 18478},
+								name: "CommonEscapeSequence",
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 560, col: 7, offset: 18507},
+						run: (*parser).callonCharClassEscape5,
+						expr: &seqExpr{
+							pos: position{line: 560, col: 7, offset: 18507},
+							exprs: []interface{}{
+								&notExpr{
+									pos: position{line: 560, col: 7, offset: 18507},
+									expr: &litMatcher{
+										pos:        position{line: 560, col: 8, offset: 18508},
+										val:        "p",
+										ignoreCase: false,
+									},
+								},
+								&choiceExpr{
+									pos: position{line: 560, col: 14, offset: 18514},
+									alternatives: []interface{}{
+										&anyMatcher{
+											line: 468, col: 14, offset: 14971,
+										},
+										&litMatcher{
+											pos:        position{line: 757, col: 7, offset: 25216},
+											val:        "\n",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 560, col: 33, offset: 18533},
+											name: "EOF",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "UnicodeClassEscape",
+			pos:  position{line: 564, col: 1, offset: 18599},
+			expr: &seqExpr{
+				pos: position{line: 564, col: 22, offset: 18622},
+				exprs: []interface{}{
+					&litMatcher{
+						pos:        position{line: 564, col: 22, offset: 18622},
+						val:        "p",
+						ignoreCase: false,
+					},
+					&choiceExpr{
+						pos: position{line: 565, col: 7, offset: 18635},
+						alternatives: []interface{}{
+							&charClassMatcher{
+								pos:        position{line: 577, col: 26, offset: 19106},
+								val:        "[LMNCPZS]",
+								chars:      []rune{'L', 'M', 'N', 'C', 'P', 'Z', 'S'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+							&actionExpr{
+								pos: position{line: 566, col: 7, offset: 18664},
+								run: (*parser).callonUnicodeClassEscape5,
+								expr: &seqExpr{
+									pos: position{line: 566, col: 7, offset: 18664},
+									exprs: []interface{}{
+										&notExpr{
+											pos: position{line: 566, col: 7, offset: 18664},
+											expr: &litMatcher{
+												pos:        position{line: 566, col: 8, offset: 18665},
+												val:        "{",
+												ignoreCase: false,
+											},
+										},
+										&choiceExpr{
+											pos: position{line: 566, col: 14, offset: 18671},
+											alternatives: []interface{}{
+												&anyMatcher{
+													line: 468, col: 14, offset: 14971,
+												},
+												&litMatcher{
+													pos:        position{line: 757, col: 7, offset: 25216},
+													val:        "\n",
+													ignoreCase: false,
+												},
+												&ruleRefExpr{
+													pos:  position{line: 566, col: 33, offset: 18690},
+													name: "EOF",
+												},
+											},
+										},
+									},
+								},
+							},
+							&actionExpr{
+								pos: position{line: 567, col: 7, offset: 18761},
+								run: (*parser).callonUnicodeClassEscape13,
+								expr: &seqExpr{
+									pos: position{line: 567, col: 7, offset: 18761},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 567, col: 7, offset: 18761},
+											val:        "{",
+											ignoreCase: false,
+										},
+										&labeledExpr{
+											pos:   position{line: 567, col: 11, offset: 18765},
+											label: "ident",
+											expr: &ruleRefExpr{
+												pos:  position{line: 567, col: 17, offset: 18771},
+												name: "IdentifierName",
+											},
+										},
+										&litMatcher{
+											pos:        position{line: 567, col: 32, offset: 18786},
+											val:        "}",
+											ignoreCase: false,
+										},
+									},
+								},
+							},
+							&actionExpr{
+								pos: position{line: 573, col: 7, offset: 18963},
+								run: (*parser).callonUnicodeClassEscape19,
+								expr: &seqExpr{
+									pos: position{line: 573, col: 7, offset: 18963},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 573, col: 7, offset: 18963},
+											val:        "{",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 573, col: 11, offset: 18967},
+											name: "IdentifierName",
+										},
+										&choiceExpr{
+											pos: position{line: 573, col: 28, offset: 18984},
+											alternatives: []interface{}{
+												&litMatcher{
+													pos:        position{line: 573, col: 28, offset: 18984},
+													val:        "]",
+													ignoreCase: false,
+												},
+												&litMatcher{
+													pos:        position{line: 757, col: 7, offset: 25216},
+													val:        "\n",
+													ignoreCase: false,
+												},
+												&ruleRefExpr{
+													pos:  position{line: 573, col: 40, offset: 18996},
+													name: "EOF",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SingleCharUnicodeClass",
+			pos:  position{line: 577, col: 1, offset: 19079},
+			expr: &charClassMatcher{
+				pos:        position{line: 577, col: 26, offset: 19106},
+				val:        "[LMNCPZS]",
+				chars:      []rune{'L', 'M', 'N', 'C', 'P', 'Z', 'S'},
+				ignoreCase: false,
+				inverted:   false,
+			},
+		},
+		{
+			name: "AnyMatcher",
+			pos:  position{line: 579, col: 1, offset: 19117},
+			expr: &actionExpr{
+				pos: position{line: 579, col: 14, offset: 19132},
+				run: (*parser).callonAnyMatcher1,
+				expr: &litMatcher{
+					pos:        position{line: 579, col: 14, offset: 19132},
+					val:        ".",
+					ignoreCase: false,
+				},
+			},
+		},
+		{
+			name: "UntilMatcher",
+			pos:  position{line: 584, col: 1, offset: 19207},
+			expr: &actionExpr{
+				pos: position{line: 584, col: 16, offset: 19224},
+				run: (*parser).callonUntilMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 584, col: 16, offset: 19224},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 584, col: 16, offset: 19224},
+							val:        "Until(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 584, col: 25, offset: 19233},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 584, col: 28, offset: 19236},
+							label: "lit",
+							expr: &ruleRefExpr{
+								pos:  position{line: 584, col: 32, offset: 19240},
+								name: "StringLiteral",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 584, col: 46, offset: 19254},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 584, col: 49, offset: 19257},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NestedMatcher",
+			pos:  position{line: 596, col: 1, offset: 19619},
+			expr: &actionExpr{
+				pos: position{line: 596, col: 17, offset: 19637},
+				run: (*parser).callonNestedMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 596, col: 17, offset: 19637},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 596, col: 17, offset: 19637},
+							val:        "Nested(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 596, col: 27, offset: 19647},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 596, col: 30, offset: 19650},
+							label: "open",
+							expr: &ruleRefExpr{
+								pos:  position{line: 596, col: 35, offset: 19655},
+								name: "StringLiteral",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 596, col: 49, offset: 19669},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 596, col: 52, offset: 19672},
+							val:        ",",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 596, col: 56, offset: 19676},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 596, col: 59, offset: 19679},
+							label: "close",
+							expr: &ruleRefExpr{
+								pos:  position{line: 596, col: 65, offset: 19685},
+								name: "StringLiteral",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 596, col: 79, offset: 19699},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 596, col: 82, offset: 19702},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RestOfLineMatcher",
+			pos:  position{line: 608, col: 1, offset: 20174},
+			expr: &actionExpr{
+				pos: position{line: 608, col: 21, offset: 20196},
+				run: (*parser).callonRestOfLineMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 608, col: 21, offset: 20196},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 608, col: 21, offset: 20196},
+							val:        "RestOfLine(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 608, col: 35, offset: 20210},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 608, col: 38, offset: 20213},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ByteMatcher",
+			pos:  position{line: 612, col: 1, offset: 20275},
+			expr: &actionExpr{
+				pos: position{line: 612, col: 15, offset: 20291},
+				run: (*parser).callonByteMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 612, col: 15, offset: 20291},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 612, col: 15, offset: 20291},
+							val:        "Byte(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 612, col: 23, offset: 20299},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 612, col: 26, offset: 20302},
+							label: "val",
+							expr: &ruleRefExpr{
+								pos:  position{line: 612, col: 30, offset: 20306},
+								name: "ByteValue",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 612, col: 40, offset: 20316},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 612, col: 43, offset: 20319},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ByteValue",
+			pos:  position{line: 615, col: 1, offset: 20386},
+			expr: &choiceExpr{
+				pos: position{line: 615, col: 13, offset: 20400},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 615, col: 13, offset: 20400},
+						run: (*parser).callonByteValue2,
+						expr: &seqExpr{
+							pos: position{line: 615, col: 13, offset: 20400},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 615, col: 13, offset: 20400},
+									val:        "0x",
+									ignoreCase: false,
+								},
+								&oneOrMoreExpr{
+									pos: position{line: 615, col: 18, offset: 20405},
+									expr: &charClassMatcher{
+										pos:        position{line: 547, col: 12, offset: 17985},
+										val:        "[0-9a-f]i",
+										ranges:     []rune{'0', '9', 'a', 'f'},
+										ignoreCase: true,
+										inverted:   false,
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 621, col: 5, offset: 20587},
+						run: (*parser).callonByteValue7,
+						expr: &oneOrMoreExpr{
+							pos: position{line: 621, col: 5, offset: 20587},
+							expr: &charClassMatcher{
+								pos:        position{line: 546, col: 16, offset: 17966},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "BytesMatcher",
+			pos:  position{line: 629, col: 1, offset: 20768},
+			expr: &actionExpr{
+				pos: position{line: 629, col: 16, offset: 20785},
+				run: (*parser).callonBytesMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 629, col: 16, offset: 20785},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 629, col: 16, offset: 20785},
+							val:        "Bytes(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 629, col: 25, offset: 20794},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 629, col: 28, offset: 20797},
+							label: "n",
+							expr: &choiceExpr{
+								pos: position{line: 629, col: 32, offset: 20801},
+								alternatives: []interface{}{
+									&ruleRefExpr{
+										pos:  position{line: 629, col: 32, offset: 20801},
+										name: "BytesCount",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 629, col: 45, offset: 20814},
+										name: "IdentifierName",
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 629, col: 62, offset: 20831},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 629, col: 65, offset: 20834},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "BytesCount",
+			pos:  position{line: 639, col: 1, offset: 21014},
+			expr: &actionExpr{
+				pos: position{line: 639, col: 14, offset: 21029},
+				run: (*parser).callonBytesCount1,
+				expr: &oneOrMoreExpr{
+					pos: position{line: 639, col: 14, offset: 21029},
+					expr: &charClassMatcher{
+						pos:        position{line: 546, col: 16, offset: 17966},
+						val:        "[0-9]",
+						ranges:     []rune{'0', '9'},
+						ignoreCase: false,
+						inverted:   false,
+					},
+				},
+			},
+		},
+		{
+			name: "NumberMatcher",
+			pos:  position{line: 647, col: 1, offset: 21191},
+			expr: &actionExpr{
+				pos: position{line: 647, col: 17, offset: 21209},
+				run: (*parser).callonNumberMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 647, col: 17, offset: 21209},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 647, col: 17, offset: 21209},
+							val:        "Number(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 647, col: 27, offset: 21219},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 647, col: 30, offset: 21222},
+							label: "opts",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 647, col: 35, offset: 21227},
+								expr: &seqExpr{
+									pos: position{line: 647, col: 37, offset: 21229},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 647, col: 37, offset: 21229},
+											name: "NumberOption",
+										},
+										&zeroOrMoreExpr{
+											pos: position{line: 647, col: 50, offset: 21242},
+											expr: &seqExpr{
+												pos: position{line: 647, col: 52, offset: 21244},
+												exprs: []interface{}{
+													&ruleRefExpr{
+														pos:  position{line: 647, col: 52, offset: 21244},
+														name: "__",
+													},
+													&litMatcher{
+														pos:        position{line: 647, col: 55, offset: 21247},
+														val:        ",",
+														ignoreCase: false,
+													},
+													&ruleRefExpr{
+														pos:  position{line: 647, col: 59, offset: 21251},
+														name: "__",
+													},
+													&ruleRefExpr{
+														pos:  position{line: 647, col: 62, offset: 21254},
+														name: "NumberOption",
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 647, col: 81, offset: 21273},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 647, col: 84, offset: 21276},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NumberOption",
+			pos:  position{line: 711, col: 1, offset: 23793},
+			expr: &actionExpr{
+				pos: position{line: 711, col: 16, offset: 23810},
+				run: (*parser).callonNumberOption1,
+				expr: &seqExpr{
+					pos: position{line: 711, col: 16, offset: 23810},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 711, col: 16, offset: 23810},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 711, col: 21, offset: 23815},
+								name: "IdentifierName",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 711, col: 36, offset: 23830},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 711, col: 39, offset: 23833},
+							val:        ":",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 711, col: 43, offset: 23837},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 711, col: 46, offset: 23840},
+							label: "val",
+							expr: &ruleRefExpr{
+								pos:  position{line: 711, col: 50, offset: 23844},
+								name: "NumberOptionValue",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NumberOptionValue",
+			pos:  position{line: 714, col: 1, offset: 23907},
+			expr: &actionExpr{
+				pos: position{line: 714, col: 21, offset: 23929},
+				run: (*parser).callonNumberOptionValue1,
+				expr: &choiceExpr{
+					pos: position{line: 714, col: 23, offset: 23931},
+					alternatives: []interface{}{
+						&seqExpr{
+							pos: position{line: 714, col: 23, offset: 23931},
+							exprs: []interface{}{
+								&choiceExpr{
+									pos: position{line: 714, col: 25, offset: 23933},
+									alternatives: []interface{}{
+										&litMatcher{
+											pos:        position{line: 714, col: 25, offset: 23933},
+											val:        "true",
+											ignoreCase: false,
+										},
+										&litMatcher{
+											pos:        position{line: 714, col: 34, offset: 23942},
+											val:        "false",
+											ignoreCase: false,
+										},
+										&oneOrMoreExpr{
+											pos: position{line: 714, col: 44, offset: 23952},
+											expr: &charClassMatcher{
+												pos:        position{line: 546, col: 16, offset: 17966},
+												val:        "[0-9]",
+												ranges:     []rune{'0', '9'},
+												ignoreCase: false,
+												inverted:   false,
+											},
+										},
+									},
+								},
+								&notExpr{
+									pos: position{line: 714, col: 60, offset: 23968},
+									expr: &ruleRefExpr{
+										pos:  position{line: 714, col: 61, offset: 23969},
+										name: "IdentifierPart",
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 714, col: 78, offset: 23986},
+							name: "StringLiteral",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "IndentMatcher",
+			pos:  position{line: 718, col: 1, offset: 24038},
+			expr: &actionExpr{
+				pos: position{line: 718, col: 17, offset: 24056},
+				run: (*parser).callonIndentMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 718, col: 17, offset: 24056},
+					exprs: []interface{}{
+						&litSetMatcher{
+							pos: position{line: 718, col: 19, offset: 24058},
+							alts: []*litMatcher{
+								&litMatcher{
+									pos:        position{line: 718, col: 19, offset: 24058},
+									val:        "@indent",
+									ignoreCase: false,
+								},
+								&litMatcher{
+									pos:        position{line: 718, col: 31, offset: 24070},
+									val:        "@samedent",
+									ignoreCase: false,
+								},
+								&litMatcher{
+									pos:        position{line: 718, col: 45, offset: 24084},
+									val:        "@dedent",
+									ignoreCase: false,
+								},
+							},
+						},
+						&notExpr{
+							pos: position{line: 718, col: 57, offset: 24096},
+							expr: &ruleRefExpr{
+								pos:  position{line: 718, col: 58, offset: 24097},
+								name: "IdentifierPart",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "KeywordMatcher",
+			pos:  position{line: 722, col: 1, offset: 24186},
+			expr: &actionExpr{
+				pos: position{line: 722, col: 18, offset: 24205},
+				run: (*parser).callonKeywordMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 722, col: 18, offset: 24205},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 722, col: 18, offset: 24205},
+							val:        "@keyword",
+							ignoreCase: false,
+						},
+						&notExpr{
+							pos: position{line: 722, col: 29, offset: 24216},
+							expr: &ruleRefExpr{
+								pos:  position{line: 722, col: 30, offset: 24217},
+								name: "IdentifierPart",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "WordListMatcher",
+			pos:  position{line: 726, col: 1, offset: 24287},
+			expr: &actionExpr{
+				pos: position{line: 726, col: 19, offset: 24307},
+				run: (*parser).callonWordListMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 726, col: 19, offset: 24307},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 726, col: 19, offset: 24307},
+							val:        "@wordlist",
+							ignoreCase: false,
+						},
+						&notExpr{
+							pos: position{line: 726, col: 31, offset: 24319},
+							expr: &ruleRefExpr{
+								pos:  position{line: 726, col: 32, offset: 24320},
+								name: "IdentifierPart",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TableMatcher",
+			pos:  position{line: 730, col: 1, offset: 24391},
+			expr: &actionExpr{
+				pos: position{line: 730, col: 16, offset: 24408},
+				run: (*parser).callonTableMatcher1,
+				expr: &seqExpr{
+					pos: position{line: 730, col: 16, offset: 24408},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 730, col: 16, offset: 24408},
+							val:        "@table(",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 730, col: 26, offset: 24418},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 730, col: 29, offset: 24421},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 730, col: 34, offset: 24426},
+								name: "IdentifierName",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 730, col: 49, offset: 24441},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 730, col: 52, offset: 24444},
+							val:        ")",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TokenMatcher",
+			pos:  position{line: 734, col: 1, offset: 24529},
+			expr: &choiceExpr{
+				pos: position{line: 734, col: 16, offset: 24546},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 734, col: 16, offset: 24546},
+						run: (*parser).callonTokenMatcher2,
+						expr: &seqExpr{
+							pos: position{line: 734, col: 16, offset: 24546},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 734, col: 16, offset: 24546},
+									val:        "@token(",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 734, col: 26, offset: 24556},
+									name: "__",
+								},
+								&labeledExpr{
+									pos:   position{line: 734, col: 29, offset: 24559},
+									label: "kind",
+									expr: &ruleRefExpr{
+										pos:  position{line: 734, col: 34, offset: 24564},
+										name: "TokenKind",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 734, col: 44, offset: 24574},
+									name: "__",
+								},
+								&litMatcher{
+									pos:        position{line: 734, col: 47, offset: 24577},
+									val:        ")",
+									ignoreCase: false,
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 736, col: 5, offset: 24650},
+						run: (*parser).callonTokenMatcher10,
+						expr: &seqExpr{
+							pos: position{line: 736, col: 5, offset: 24650},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 736, col: 5, offset: 24650},
+									val:        "@token",
+									ignoreCase: false,
+								},
+								&notExpr{
+									pos: position{line: 736, col: 14, offset: 24659},
+									expr: &ruleRefExpr{
+										pos:  position{line: 736, col: 15, offset: 24660},
+										name: "IdentifierPart",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TokenKind",
+			pos:  position{line: 739, col: 1, offset: 24731},
+			expr: &actionExpr{
+				pos: position{line: 739, col: 13, offset: 24745},
+				run: (*parser).callonTokenKind1,
+				expr: &choiceExpr{
+					pos: position{line: 739, col: 15, offset: 24747},
+					alternatives: []interface{}{
+						&seqExpr{
+							pos: position{line: 739, col: 15, offset: 24747},
+							exprs: []interface{}{
+								&ruleRefExpr{
+									pos:  position{line: 739, col: 15, offset: 24747},
+									name: "IdentifierName",
+								},
+								&zeroOrOneExpr{
+									pos: position{line: 739, col: 30, offset: 24762},
+									expr: &seqExpr{
+										pos: position{line: 739, col: 32, offset: 24764},
+										exprs: []interface{}{
+											&litMatcher{
+												pos:        position{line: 739, col: 32, offset: 24764},
+												val:        ".",
+												ignoreCase: false,
+											},
+											&ruleRefExpr{
+												pos:  position{line: 739, col: 36, offset: 24768},
+												name: "IdentifierName",
+											},
+										},
+									},
+								},
+							},
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 739, col: 56, offset: 24788},
+							expr: &charClassMatcher{
+								pos:        position{line: 546, col: 16, offset: 17966},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CodeBlock",
+			pos:  position{line: 743, col: 1, offset: 24840},
+			expr: &choiceExpr{
+				pos: position{line: 743, col: 13, offset: 24854},
+				alternatives: []interface{}{
+					&actionExpr{
+						pos: position{line: 743, col: 13, offset: 24854},
+						run: (*parser).callonCodeBlock2,
+						expr: &seqExpr{
+							pos: position{line: 743, col: 13, offset: 24854},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 743, col: 13, offset: 24854},
+									val:        "{",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 743, col: 17, offset: 24858},
+									name: "Code",
+								},
+								&litMatcher{
+									pos:        position{line: 743, col: 22, offset: 24863},
+									val:        "}",
+									ignoreCase: false,
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 747, col: 5, offset: 24962},
+						run: (*parser).callonCodeBlock7,
+						expr: &seqExpr{
+							pos: position{line: 747, col: 5, offset: 24962},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 747, col: 5, offset: 24962},
+									val:        "{",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 747, col: 9, offset: 24966},
+									name: "Code",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 747, col: 14, offset: 24971},
+									name: "EOF",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Code",
+			pos:  position{line: 751, col: 1, offset: 25036},
+			expr: &zeroOrMoreExpr{
+				pos: position{line: 751, col: 8, offset: 25045},
+				expr: &choiceExpr{
+					pos: position{line: 751, col: 10, offset: 25047},
+					alternatives: []interface{}{
+						&oneOrMoreExpr{
+							pos: position{line: 751, col: 10, offset: 25047},
+							expr: &seqExpr{
+								pos: position{line: 751, col: 12, offset: 25049},
+								exprs: []interface{}{
+									&notExpr{
+										pos: position{line: 751, col: 12, offset: 25049},
+										expr: &charClassMatcher{
+											pos:        position{line: 751, col: 13, offset: 25050},
+											val:        "[{}]",
+											chars:      []rune{'{', '}'},
+											ignoreCase: false,
+											inverted:   false,
+										},
+									},
+									&anyMatcher{
+										line: 468, col: 14, offset: 14971,
+									},
+								},
+							},
+						},
+						&seqExpr{
+							pos: position{line: 751, col: 34, offset: 25071},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 751, col: 34, offset: 25071},
+									val:        "{",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 751, col: 38, offset: 25075},
+									name: "Code",
+								},
+								&litMatcher{
+									pos:        position{line: 751, col: 43, offset: 25080},
+									val:        "}",
+									ignoreCase: false,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "__",
+			pos:  position{line: 753, col: 1, offset: 25088},
+			expr: &zeroOrMoreExpr{
+				pos: position{line: 753, col: 6, offset: 25095},
+				expr: &choiceExpr{
+					pos: position{line: 753, col: 8, offset: 25097},
+					alternatives: []interface{}{
+						&charClassMatcher{
+							pos:        position{line: 756, col: 14, offset: 25200},
+							val:        "[ \\t\\r]",
+							chars:      []rune{' ', '\t', '\r'},
+							ignoreCase: false,
+							inverted:   false,
+						},
+						&litMatcher{
+							pos:        position{line: 757, col: 7, offset: 25216},
+							val:        "\n",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 753, col: 27, offset: 25116},
+							name: "Comment",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "_",
+			pos:  position{line: 754, col: 1, offset: 25127},
+			expr: &zeroOrMoreExpr{
+				pos: position{line: 754, col: 5, offset: 25133},
+				expr: &choiceExpr{
+					pos: position{line: 754, col: 7, offset: 25135},
+					alternatives: []interface{}{
+						&charClassMatcher{
+							pos:        position{line: 756, col: 14, offset: 25200},
+							val:        "[ \\t\\r]",
+							chars:      []rune{' ', '\t', '\r'},
+							ignoreCase: false,
+							inverted:   false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 754, col: 20, offset: 25148},
+							name: "MultiLineCommentNoLineTerminator",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Whitespace",
+			pos:  position{line: 756, col: 1, offset: 25185},
+			expr: &charClassMatcher{
+				pos:        position{line: 756, col: 14, offset: 25200},
+				val:        "[ \\t\\r]",
+				chars:      []rune{' ', '\t', '\r'},
+				ignoreCase: false,
+				inverted:   false,
+			},
+		},
+		{
+			name: "EOL",
+			pos:  position{line: 757, col: 1, offset: 25208},
+			expr: &litMatcher{
+				pos:        position{line: 757, col: 7, offset: 25216},
+				val:        "\n",
+				ignoreCase: false,
+			},
+		},
+		{
+			name: "EOS",
+			pos:  position{line: 758, col: 1, offset: 25221},
+			expr: &choiceExpr{
+				pos: position{line: 758, col: 7, offset: 25229},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 758, col: 7, offset: 25229},
+						exprs: []interface{}{
+							&ruleRefExpr{
+								pos:  position{line: 758, col: 7, offset: 25229},
+								name: "__",
+							},
+							&litMatcher{
+								pos:        position{line: 758, col: 10, offset: 25232},
+								val:        ";",
+								ignoreCase: false,
+							},
+						},
+					},
+					&seqExpr{
+						pos: position{line: 758, col: 16, offset: 25238},
+						exprs: []interface{}{
+							&ruleRefExpr{
+								pos:  position{line: 758, col: 16, offset: 25238},
+								name: "_",
+							},
+							&zeroOrOneExpr{
+								pos: position{line: 758, col: 18, offset: 25240},
+								expr: &ruleRefExpr{
+									pos:  position{line: 758, col: 18, offset: 25240},
+									name: "SingleLineComment",
+								},
+							},
+							&litMatcher{
+								pos:        position{line: 757, col: 7, offset: 25216},
+								val:        "\n",
+								ignoreCase: false,
+							},
+						},
+					},
+					&seqExpr{
+						pos: position{line: 758, col: 43, offset: 25265},
+						exprs: []interface{}{
+							&ruleRefExpr{
+								pos:  position{line: 758, col: 43, offset: 25265},
+								name: "__",
+							},
+							&ruleRefExpr{
+								pos:  position{line: 758, col: 46, offset: 25268},
+								name: "EOF",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "EOF",
+			pos:  position{line: 760, col: 1, offset: 25273},
+			expr: &notExpr{
+				pos: position{line: 760, col: 7, offset: 25281},
+				expr: &anyMatcher{
+					line: 760, col: 8, offset: 25282,
+				},
+			},
+		},
+	},
+}
+var defaultOptions []Option
+
+func (c *current) onGrammar1(initializer, fields, state, aliases, examples, rules interface{}) (interface{}, error) {
+	pos := c.astPos()
+
+	// create the grammar, assign its initializer
+	g := ast.NewGrammar(pos)
+	initSlice := toIfaceSlice(initializer)
+	if len(initSlice) > 0 {
+		g.Init = initSlice[0].(*ast.CodeBlock)
+	}
+	fieldsSlice := toIfaceSlice(fields)
+	if len(fieldsSlice) > 0 {
+		g.Fields = fieldsSlice[0].(*ast.CodeBlock)
+	}
+	stateSlice := toIfaceSlice(state)
+	if len(stateSlice) > 0 {
+		g.State = stateSlice[0].(*ast.CodeBlock)
+	}
+
+	for _, duo := range toIfaceSlice(examples) {
+		g.Examples = append(g.Examples, duo.([]interface{})[0].(*ast.StringLit))
+	}
+
 	rulesSlice := toIfaceSlice(rules)
 	g.Rules = make([]*ast.Rule, len(rulesSlice))
 	for i, duo := range rulesSlice {
 		g.Rules[i] = duo.([]interface{})[0].(*ast.Rule)
 	}
 
-	return g, nil
+	var aliasList []*ast.Alias
+	for _, duo := range toIfaceSlice(aliases) {
+		aliasList = append(aliasList, duo.([]interface{})[0].(*ast.Alias))
+	}
+	if err := ast.ExpandAliases(g, aliasList); err != nil {
+		return g, err
+	}
+
+	return g, nil
+}
+
+func (p *parser) callonGrammar1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onGrammar1(stack["initializer"], stack["fields"], stack["state"], stack["aliases"], stack["examples"], stack["rules"])
+}
+
+func (c *current) onInitializer1(code interface{}) (interface{}, error) {
+	return code, nil
+}
+
+func (p *parser) callonInitializer1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onInitializer1(stack["code"])
+}
+
+func (c *current) onFields1(code interface{}) (interface{}, error) {
+	return code, nil
+}
+
+func (p *parser) callonFields1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFields1(stack["code"])
+}
+
+func (c *current) onState1(code interface{}) (interface{}, error) {
+	return code, nil
+}
+
+func (p *parser) callonState1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onState1(stack["code"])
+}
+
+func (c *current) onAlias1(name, class interface{}) (interface{}, error) {
+	return ast.NewAlias(c.astPos(), name.(*ast.Identifier), class.(*ast.CharClassMatcher)), nil
+}
+
+func (p *parser) callonAlias1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onAlias1(stack["name"], stack["class"])
+}
+
+func (c *current) onExample1(input interface{}) (interface{}, error) {
+	return input, nil
+}
+
+func (p *parser) callonExample1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onExample1(stack["input"])
+}
+
+func (c *current) onRule1(meta, cond, entry, inline, lexical, silent, nl, typ, budget, name, display, expr, end interface{}) (interface{}, error) {
+	pos := c.astPos()
+
+	rule := ast.NewRule(pos, name.(*ast.Identifier))
+	displaySlice := toIfaceSlice(display)
+	if len(displaySlice) > 0 {
+		rule.DisplayName = displaySlice[0].(*ast.StringLit)
+	}
+	condSlice := toIfaceSlice(cond)
+	if len(condSlice) > 0 {
+		rule.Cond = condSlice[0].(*ast.Identifier)
+	}
+	rule.Entry = entry != nil
+	rule.Inline = inline != nil
+	rule.Lexical = lexical != nil
+	rule.Silent = silent != nil
+	rule.NLSignificant = nl != nil
+	if typSlice := toIfaceSlice(typ); len(typSlice) > 0 {
+		rule.Type = typSlice[0].(string)
+	}
+	if budgetSlice := toIfaceSlice(budget); len(budgetSlice) > 0 {
+		rule.Budget = budgetSlice[0].(int)
+	}
+	rule.Expr = expr.(ast.Expression)
+	rule.End = end.(ast.Pos)
+	for _, sl := range toIfaceSlice(meta) {
+		for _, kv := range sl.([]interface{})[0].([][2]string) {
+			if rule.Meta == nil {
+				rule.Meta = make(map[string]string)
+			}
+			if _, ok := rule.Meta[kv[0]]; ok {
+				return rule, fmt.Errorf("duplicate metadata key %q", kv[0])
+			}
+			rule.Meta[kv[0]] = kv[1]
+		}
+	}
+
+	return rule, nil
+}
+
+func (p *parser) callonRule1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRule1(stack["meta"], stack["cond"], stack["entry"], stack["inline"], stack["lexical"], stack["silent"], stack["nl"], stack["typ"], stack["budget"], stack["name"], stack["display"], stack["expr"], stack["end"])
+}
+
+func (c *current) onRuleType1(val interface{}) (interface{}, error) {
+	s, _ := strconv.Unquote(val.(*ast.StringLit).Val)
+	if strings.TrimSpace(s) == "" {
+		return s, errors.New("the @type of a rule must not be empty")
+	}
+	return s, nil
+}
+
+func (p *parser) callonRuleType1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleType1(stack["val"])
+}
+
+func (c *current) onRuleBudget1() (interface{}, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(string(c.text[len("@budget(") : len(c.text)-1])))
+	if err != nil || n == 0 {
+		return 0, errors.New("the @budget of a rule must be a positive number")
+	}
+	return n, nil
+}
+
+func (p *parser) callonRuleBudget1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleBudget1()
+}
+
+func (c *current) onRuleEnd1() (interface{}, error) {
+	return c.astPos(), nil
+}
+
+func (p *parser) callonRuleEnd1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleEnd1()
+}
+
+func (c *current) onRuleMeta1(first, rest interface{}) (interface{}, error) {
+	pairs := [][2]string{first.([2]string)}
+	for _, sl := range toIfaceSlice(rest) {
+		pairs = append(pairs, sl.([]interface{})[3].([2]string))
+	}
+	return pairs, nil
+}
+
+func (p *parser) callonRuleMeta1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleMeta1(stack["first"], stack["rest"])
+}
+
+func (c *current) onMetaPair1(key, val interface{}) (interface{}, error) {
+	s, err := strconv.Unquote(val.(*ast.StringLit).Val)
+	if err != nil {
+		return nil, err
+	}
+	return [2]string{key.(*ast.Identifier).Val, s}, nil
+}
+
+func (p *parser) callonMetaPair1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onMetaPair1(stack["key"], stack["val"])
+}
+
+func (c *current) onChoiceExpr1(first, rest interface{}) (interface{}, error) {
+	restSlice := toIfaceSlice(rest)
+	if len(restSlice) == 0 {
+		return first, nil
+	}
+
+	pos := c.astPos()
+	choice := ast.NewChoiceExpr(pos)
+	choice.Alternatives = []ast.Expression{first.(ast.Expression)}
+	for _, sl := range restSlice {
+		choice.Alternatives = append(choice.Alternatives, sl.([]interface{})[3].(ast.Expression))
+	}
+	return choice, nil
 }
 
-func (p *parser) callonGrammar1() (interface{}, error) {
+func (p *parser) callonChoiceExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onGrammar1(stack["initializer"], stack["rules"])
+	return p.cur.onChoiceExpr1(stack["first"], stack["rest"])
 }
 
-func (c *current) onInitializer1(code interface{}) (interface{}, error) {
-	return code, nil
+func (c *current) onAltExpr2(cond, expr interface{}) (interface{}, error) {
+	ifx := ast.NewIfExpr(c.astPos())
+	ifx.Cond = cond.(*ast.Identifier)
+	ifx.Expr = expr.(ast.Expression)
+	return ifx, nil
 }
 
-func (p *parser) callonInitializer1() (interface{}, error) {
+func (p *parser) callonAltExpr2() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onInitializer1(stack["code"])
+	return p.cur.onAltExpr2(stack["cond"], stack["expr"])
+}
+
+func (c *current) onAltExpr9(flag, expr interface{}) (interface{}, error) {
+	when := ast.NewWhenExpr(c.astPos())
+	when.Flag = flag.(*ast.Identifier)
+	when.Expr = expr.(ast.Expression)
+	return when, nil
+}
+
+func (p *parser) callonAltExpr9() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onAltExpr9(stack["flag"], stack["expr"])
+}
+
+func (c *current) onIfCond1(name interface{}) (interface{}, error) {
+	return name, nil
+}
+
+func (p *parser) callonIfCond1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onIfCond1(stack["name"])
+}
+
+func (c *current) onWhenFlag1(name interface{}) (interface{}, error) {
+	return name, nil
+}
+
+func (p *parser) callonWhenFlag1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onWhenFlag1(stack["name"])
 }
 
-func (c *current) onRule1(name, display, expr interface{}) (interface{}, error) {
+func (c *current) onActionExpr1(expr, code interface{}) (interface{}, error) {
+	if code == nil {
+		return expr, nil
+	}
+
 	pos := c.astPos()
+	act := ast.NewActionExpr(pos)
+	act.Expr = expr.(ast.Expression)
+	codeSlice := toIfaceSlice(code)
+	act.Code = codeSlice[1].(*ast.CodeBlock)
 
-	rule := ast.NewRule(pos, name.(*ast.Identifier))
-	displaySlice := toIfaceSlice(display)
-	if len(displaySlice) > 0 {
-		rule.DisplayName = displaySlice[0].(*ast.StringLit)
+	return act, nil
+}
+
+func (p *parser) callonActionExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onActionExpr1(stack["expr"], stack["code"])
+}
+
+func (c *current) onSeqExpr1(first, rest, assoc interface{}) (interface{}, error) {
+	var expr ast.Expression = first.(ast.Expression)
+	restSlice := toIfaceSlice(rest)
+	if len(restSlice) > 0 {
+		seq := ast.NewSeqExpr(c.astPos())
+		seq.Exprs = []ast.Expression{expr}
+		for _, sl := range restSlice {
+			seq.Exprs = append(seq.Exprs, sl.([]interface{})[1].(ast.Expression))
+		}
+		expr = seq
 	}
-	rule.Expr = expr.(ast.Expression)
+	if assoc == nil {
+		return expr, nil
+	}
+	fold := ast.NewFoldExpr(c.astPos())
+	fold.Expr = expr
+	fold.Right = toIfaceSlice(assoc)[1].(string) == "@right"
+	return fold, nil
+}
 
-	return rule, nil
+func (p *parser) callonSeqExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSeqExpr1(stack["first"], stack["rest"], stack["assoc"])
 }
 
-func (p *parser) callonRule1() (interface{}, error) {
+func (c *current) onFoldAssoc1() (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonFoldAssoc1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFoldAssoc1()
+}
+
+func (c *current) onLabeledExpr2(label, expr interface{}) (interface{}, error) {
+	pos := c.astPos()
+	lab := ast.NewLabeledExpr(pos)
+	lab.Label = label.(*ast.Identifier)
+	lab.Expr = expr.(ast.Expression)
+	return lab, nil
+}
+
+func (p *parser) callonLabeledExpr2() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onLabeledExpr2(stack["label"], stack["expr"])
+}
+
+func (c *current) onPrefixedExpr2(op, expr interface{}) (interface{}, error) {
+	pos := c.astPos()
+	opStr := op.(string)
+	if opStr == "&" {
+		and := ast.NewAndExpr(pos)
+		and.Expr = expr.(ast.Expression)
+		return and, nil
+	}
+	not := ast.NewNotExpr(pos)
+	not.Expr = expr.(ast.Expression)
+	return not, nil
+}
+
+func (p *parser) callonPrefixedExpr2() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onPrefixedExpr2(stack["op"], stack["expr"])
+}
+
+func (c *current) onPrefixedOp1() (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonPrefixedOp1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onPrefixedOp1()
+}
+
+func (c *current) onSuffixedExpr2(expr, op, cond interface{}) (interface{}, error) {
+	pos := c.astPos()
+	var while *ast.AndCodeExpr
+	if condSlice, ok := cond.([]interface{}); ok {
+		while = condSlice[1].(*ast.AndCodeExpr)
+	}
+	if code, ok := op.(*ast.CodeBlock); ok {
+		zero := ast.NewZeroOrOneExpr(pos)
+		zero.Expr = expr.(ast.Expression)
+		zero.Default = code
+		if while != nil {
+			return zero, errors.New("repetition condition on a ?? expression")
+		}
+		return zero, nil
+	}
+	opStr := op.(string)
+	switch opStr {
+	case "?":
+		zero := ast.NewZeroOrOneExpr(pos)
+		zero.Expr = expr.(ast.Expression)
+		if while != nil {
+			return zero, errors.New("repetition condition on a ? expression")
+		}
+		return zero, nil
+	case "*":
+		zero := ast.NewZeroOrMoreExpr(pos)
+		zero.Expr = expr.(ast.Expression)
+		zero.While = while
+		return zero, nil
+	case "+":
+		one := ast.NewOneOrMoreExpr(pos)
+		one.Expr = expr.(ast.Expression)
+		one.While = while
+		return one, nil
+	default:
+		return nil, errors.New("unknown operator: " + opStr)
+	}
+}
+
+func (p *parser) callonSuffixedExpr2() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSuffixedExpr2(stack["expr"], stack["op"], stack["cond"])
+}
+
+func (c *current) onSuffixedOp1() (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonSuffixedOp1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSuffixedOp1()
+}
+
+func (c *current) onDefaultOp1(code interface{}) (interface{}, error) {
+	return code, nil
+}
+
+func (p *parser) callonDefaultOp1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onDefaultOp1(stack["code"])
+}
+
+func (c *current) onRepeatCond1(code interface{}) (interface{}, error) {
+	and := ast.NewAndCodeExpr(c.astPos())
+	and.Code = code.(*ast.CodeBlock)
+	return and, nil
+}
+
+func (p *parser) callonRepeatCond1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRepeatCond1(stack["code"])
+}
+
+func (c *current) onPrimaryExpr33(expr interface{}) (interface{}, error) {
+	return expr, nil
+}
+
+func (p *parser) callonPrimaryExpr33() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onPrimaryExpr33(stack["expr"])
+}
+
+func (c *current) onRuleRefExpr1(name interface{}) (interface{}, error) {
+	ref := ast.NewRuleRefExpr(c.astPos())
+	ref.Name = name.(*ast.Identifier)
+	return ref, nil
+}
+
+func (p *parser) callonRuleRefExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleRefExpr1(stack["name"])
+}
+
+func (c *current) onQualifiedName1() (interface{}, error) {
+	return ast.NewIdentifier(c.astPos(), string(c.text)), nil
+}
+
+func (p *parser) callonQualifiedName1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onQualifiedName1()
+}
+
+func (c *current) onOperatorsExpr1(operand, first, rest interface{}) (interface{}, error) {
+	ops := ast.NewOperatorsExpr(c.astPos())
+	ops.Operand = operand.(ast.Expression)
+	ops.Operators = first.([]*ast.Operator)
+	for _, sl := range toIfaceSlice(rest) {
+		ops.Operators = append(ops.Operators, sl.([]interface{})[3].([]*ast.Operator)...)
+	}
+	return ops, nil
+}
+
+func (p *parser) callonOperatorsExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOperatorsExpr1(stack["operand"], stack["first"], stack["rest"])
+}
+
+func (c *current) onOperatorLevel1(lits, assoc, prec interface{}) (interface{}, error) {
+	litsSlice := toIfaceSlice(lits)
+	ops := make([]*ast.Operator, len(litsSlice))
+	for i, sl := range litsSlice {
+		lit := sl.([]interface{})[0].(*ast.LitMatcher)
+		ops[i] = ast.NewOperator(lit.Pos(), lit, prec.(int), assoc.(string) == "right")
+	}
+	return ops, nil
+}
+
+func (p *parser) callonOperatorLevel1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOperatorLevel1(stack["lits"], stack["assoc"], stack["prec"])
+}
+
+func (c *current) onOperatorAssoc1() (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonOperatorAssoc1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOperatorAssoc1()
+}
+
+func (c *current) onOperatorPrec1() (interface{}, error) {
+	n, err := strconv.Atoi(string(c.text))
+	if err != nil {
+		return 0, errors.New("invalid operator precedence")
+	}
+	return n, nil
+}
+
+func (p *parser) callonOperatorPrec1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOperatorPrec1()
+}
+
+func (c *current) onUnreservedExpr1(expr interface{}) (interface{}, error) {
+	un := ast.NewUnreservedExpr(c.astPos())
+	un.Expr = expr.(ast.Expression)
+	return un, nil
+}
+
+func (p *parser) callonUnreservedExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onUnreservedExpr1(stack["expr"])
+}
+
+func (c *current) onVerbatimExpr1(expr interface{}) (interface{}, error) {
+	verb := ast.NewVerbatimExpr(c.astPos())
+	verb.Expr = expr.(ast.Expression)
+	return verb, nil
+}
+
+func (p *parser) callonVerbatimExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onVerbatimExpr1(stack["expr"])
+}
+
+func (c *current) onCompactExpr1(expr interface{}) (interface{}, error) {
+	comp := ast.NewCompactExpr(c.astPos())
+	comp.Expr = expr.(ast.Expression)
+	return comp, nil
+}
+
+func (p *parser) callonCompactExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onCompactExpr1(stack["expr"])
+}
+
+func (c *current) onTrimExpr1(expr interface{}) (interface{}, error) {
+	trim := ast.NewTrimExpr(c.astPos())
+	trim.Expr = expr.(ast.Expression)
+	return trim, nil
+}
+
+func (p *parser) callonTrimExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTrimExpr1(stack["expr"])
+}
+
+func (c *current) onIgnoreCaseExpr1(expr interface{}) (interface{}, error) {
+	// the region is expanded when the grammar is parsed, it has no node
+	e := expr.(ast.Expression)
+	ast.IgnoreCase(e)
+	return e, nil
+}
+
+func (p *parser) callonIgnoreCaseExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onRule1(stack["name"], stack["display"], stack["expr"])
+	return p.cur.onIgnoreCaseExpr1(stack["expr"])
 }
 
-func (c *current) onChoiceExpr1(first, rest interface{}) (interface{}, error) {
-	restSlice := toIfaceSlice(rest)
-	if len(restSlice) == 0 {
-		return first, nil
-	}
-
-	pos := c.astPos()
-	choice := ast.NewChoiceExpr(pos)
-	choice.Alternatives = []ast.Expression{first.(ast.Expression)}
-	for _, sl := range restSlice {
-		choice.Alternatives = append(choice.Alternatives, sl.([]interface{})[3].(ast.Expression))
+func (c *current) onLongestExpr1(expr interface{}) (interface{}, error) {
+	ch, ok := expr.(*ast.ChoiceExpr)
+	if !ok {
+		return expr, errors.New("the expression of @longest must be a choice")
 	}
-	return choice, nil
+	ch.Longest = true
+	return ch, nil
 }
 
-func (p *parser) callonChoiceExpr1() (interface{}, error) {
+func (p *parser) callonLongestExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onChoiceExpr1(stack["first"], stack["rest"])
+	return p.cur.onLongestExpr1(stack["expr"])
 }
 
-func (c *current) onActionExpr1(expr, code interface{}) (interface{}, error) {
-	if code == nil {
-		return expr, nil
+func (c *current) onArrayExpr1(expr, n, typ interface{}) (interface{}, error) {
+	arr := ast.NewArrayExpr(c.astPos())
+	arr.Expr = expr.(ast.Expression)
+	arr.N = n.(int)
+	if typ != nil {
+		s, _ := strconv.Unquote(typ.([]interface{})[3].(*ast.StringLit).Val)
+		if strings.TrimSpace(s) == "" {
+			return arr, errors.New("the type of an @array must not be empty")
+		}
+		arr.Type = s
 	}
-
-	pos := c.astPos()
-	act := ast.NewActionExpr(pos)
-	act.Expr = expr.(ast.Expression)
-	codeSlice := toIfaceSlice(code)
-	act.Code = codeSlice[1].(*ast.CodeBlock)
-
-	return act, nil
+	return arr, nil
 }
 
-func (p *parser) callonActionExpr1() (interface{}, error) {
+func (p *parser) callonArrayExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onActionExpr1(stack["expr"], stack["code"])
+	return p.cur.onArrayExpr1(stack["expr"], stack["n"], stack["typ"])
 }
 
-func (c *current) onSeqExpr1(first, rest interface{}) (interface{}, error) {
-	restSlice := toIfaceSlice(rest)
-	if len(restSlice) == 0 {
-		return first, nil
+func (c *current) onArrayLen1() (interface{}, error) {
+	n, err := strconv.Atoi(string(c.text))
+	if err != nil {
+		return 0, errors.New("invalid length of @array")
 	}
-	seq := ast.NewSeqExpr(c.astPos())
-	seq.Exprs = []ast.Expression{first.(ast.Expression)}
-	for _, sl := range restSlice {
-		seq.Exprs = append(seq.Exprs, sl.([]interface{})[1].(ast.Expression))
+	return n, nil
+}
+
+func (p *parser) callonArrayLen1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onArrayLen1()
+}
+
+func (c *current) onMapExpr1(expr, key, val, dup interface{}) (interface{}, error) {
+	m := ast.NewMapExpr(c.astPos())
+	m.Expr = expr.(ast.Expression)
+	m.Key = key.(*ast.Identifier)
+	m.Val = val.(*ast.Identifier)
+	if dup != nil {
+		s, _ := strconv.Unquote(dup.([]interface{})[3].(*ast.StringLit).Val)
+		switch s {
+		case "last":
+			m.Last = true
+		case "error":
+		default:
+			return m, errors.New("the duplicate keys of a @map must be \"error\" or \"last\"")
+		}
 	}
-	return seq, nil
+	return m, nil
 }
 
-func (p *parser) callonSeqExpr1() (interface{}, error) {
+func (p *parser) callonMapExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onSeqExpr1(stack["first"], stack["rest"])
+	return p.cur.onMapExpr1(stack["expr"], stack["key"], stack["val"], stack["dup"])
 }
 
-func (c *current) onLabeledExpr2(label, expr interface{}) (interface{}, error) {
-	pos := c.astPos()
-	lab := ast.NewLabeledExpr(pos)
-	lab.Label = label.(*ast.Identifier)
-	lab.Expr = expr.(ast.Expression)
-	return lab, nil
+func (c *current) onPeekExpr1(expr interface{}) (interface{}, error) {
+	and := ast.NewAndExpr(c.astPos())
+	and.Expr = expr.(ast.Expression)
+	and.Peek = true
+	return and, nil
 }
 
-func (p *parser) callonLabeledExpr2() (interface{}, error) {
+func (p *parser) callonPeekExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onLabeledExpr2(stack["label"], stack["expr"])
+	return p.cur.onPeekExpr1(stack["expr"])
 }
 
-func (c *current) onPrefixedExpr2(op, expr interface{}) (interface{}, error) {
-	pos := c.astPos()
-	opStr := op.(string)
-	if opStr == "&" {
-		and := ast.NewAndExpr(pos)
-		and.Expr = expr.(ast.Expression)
-		return and, nil
-	}
-	not := ast.NewNotExpr(pos)
-	not.Expr = expr.(ast.Expression)
-	return not, nil
+func (c *current) onConvertExpr1(name, expr interface{}) (interface{}, error) {
+	conv := ast.NewConvertExpr(c.astPos())
+	conv.Name = name.(*ast.Identifier)
+	conv.Expr = expr.(ast.Expression)
+	return conv, nil
 }
 
-func (p *parser) callonPrefixedExpr2() (interface{}, error) {
+func (p *parser) callonConvertExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onPrefixedExpr2(stack["op"], stack["expr"])
+	return p.cur.onConvertExpr1(stack["name"], stack["expr"])
 }
 
-func (c *current) onPrefixedOp1() (interface{}, error) {
-	return string(c.text), nil
+func (c *current) onSeenExpr1(label interface{}) (interface{}, error) {
+	seen := ast.NewSeenExpr(c.astPos())
+	seen.Label = label.(*ast.Identifier)
+	return seen, nil
 }
 
-func (p *parser) callonPrefixedOp1() (interface{}, error) {
+func (p *parser) callonSeenExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onPrefixedOp1()
+	return p.cur.onSeenExpr1(stack["label"])
 }
 
-func (c *current) onSuffixedExpr2(expr, op interface{}) (interface{}, error) {
-	pos := c.astPos()
-	opStr := op.(string)
-	switch opStr {
-	case "?":
-		zero := ast.NewZeroOrOneExpr(pos)
-		zero.Expr = expr.(ast.Expression)
-		return zero, nil
-	case "*":
-		zero := ast.NewZeroOrMoreExpr(pos)
-		zero.Expr = expr.(ast.Expression)
-		return zero, nil
-	case "+":
-		one := ast.NewOneOrMoreExpr(pos)
-		one.Expr = expr.(ast.Expression)
-		return one, nil
-	default:
-		return nil, errors.New("unknown operator: " + opStr)
-	}
+func (c *current) onBackRefExpr1(label interface{}) (interface{}, error) {
+	ref := ast.NewBackRefExpr(c.astPos())
+	ref.Label = label.(*ast.Identifier)
+	return ref, nil
 }
 
-func (p *parser) callonSuffixedExpr2() (interface{}, error) {
+func (p *parser) callonBackRefExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onSuffixedExpr2(stack["expr"], stack["op"])
+	return p.cur.onBackRefExpr1(stack["label"])
 }
 
-func (c *current) onSuffixedOp1() (interface{}, error) {
-	return string(c.text), nil
+func (c *current) onLookbehindExpr1(expr interface{}) (interface{}, error) {
+	lb := ast.NewLookbehindExpr(c.astPos())
+	lb.Expr = expr.(ast.Expression)
+	return lb, nil
 }
 
-func (p *parser) callonSuffixedOp1() (interface{}, error) {
+func (p *parser) callonLookbehindExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onSuffixedOp1()
-}
-
-func (c *current) onPrimaryExpr7(expr interface{}) (interface{}, error) {
-	return expr, nil
+	return p.cur.onLookbehindExpr1(stack["expr"])
+}
+
+func (c *current) onSepExpr1(expr, sep, flags interface{}) (interface{}, error) {
+	list := ast.NewSepExpr(c.astPos())
+	list.Expr = expr.(ast.Expression)
+	list.Sep = sep.(ast.Expression)
+	for _, flag := range toIfaceSlice(flags) {
+		switch flag.([]interface{})[3].(string) {
+		case "trailing":
+			list.Trailing = true
+		case "terminated":
+			list.Terminated = true
+		case "keep":
+			list.Keep = true
+		}
+	}
+	return list, nil
 }
 
-func (p *parser) callonPrimaryExpr7() (interface{}, error) {
+func (p *parser) callonSepExpr1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onPrimaryExpr7(stack["expr"])
+	return p.cur.onSepExpr1(stack["expr"], stack["sep"], stack["flags"])
 }
 
-func (c *current) onRuleRefExpr1(name interface{}) (interface{}, error) {
-	ref := ast.NewRuleRefExpr(c.astPos())
-	ref.Name = name.(*ast.Identifier)
-	return ref, nil
+func (c *current) onSepFlag1() (interface{}, error) {
+	return string(c.text), nil
 }
 
-func (p *parser) callonRuleRefExpr1() (interface{}, error) {
+func (p *parser) callonSepFlag1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onRuleRefExpr1(stack["name"])
+	return p.cur.onSepFlag1()
 }
 
 func (c *current) onSemanticPredExpr1(op, code interface{}) (interface{}, error) {
@@ -2811,6 +6292,282 @@ func (p *parser) callonAnyMatcher1() (interface{}, error) {
 	return p.cur.onAnyMatcher1()
 }
 
+func (c *current) onUntilMatcher1(lit interface{}) (interface{}, error) {
+	rawStr := lit.(*ast.StringLit).Val
+	s, err := strconv.Unquote(rawStr)
+	if err != nil {
+		// an invalid string literal raises an error in the escape rules,
+		// so simply replace the delimiter with an empty string here to
+		// avoid a cascade of errors.
+		s = ""
+	}
+	return ast.NewUntilMatcher(c.astPos(), s), nil
+}
+
+func (p *parser) callonUntilMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onUntilMatcher1(stack["lit"])
+}
+
+func (c *current) onNestedMatcher1(open, close interface{}) (interface{}, error) {
+	// an invalid string literal raises an error in the escape rules, so
+	// simply replace the delimiter with an empty string here.
+	openStr, _ := strconv.Unquote(open.(*ast.StringLit).Val)
+	closeStr, _ := strconv.Unquote(close.(*ast.StringLit).Val)
+	nest := ast.NewNestedMatcher(c.astPos(), openStr, closeStr)
+	if openStr == "" || closeStr == "" {
+		return nest, errors.New("Nested delimiters must not be empty")
+	}
+	return nest, nil
+}
+
+func (p *parser) callonNestedMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNestedMatcher1(stack["open"], stack["close"])
+}
+
+func (c *current) onRestOfLineMatcher1() (interface{}, error) {
+	return ast.NewRestOfLineMatcher(c.astPos()), nil
+}
+
+func (p *parser) callonRestOfLineMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRestOfLineMatcher1()
+}
+
+func (c *current) onByteMatcher1(val interface{}) (interface{}, error) {
+	return ast.NewByteMatcher(c.astPos(), val.(byte)), nil
+}
+
+func (p *parser) callonByteMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onByteMatcher1(stack["val"])
+}
+
+func (c *current) onByteValue2() (interface{}, error) {
+	n, err := strconv.ParseUint(string(c.text[2:]), 16, 8)
+	if err != nil {
+		return byte(0), errors.New("invalid byte value")
+	}
+	return byte(n), nil
+}
+
+func (p *parser) callonByteValue2() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onByteValue2()
+}
+
+func (c *current) onByteValue7() (interface{}, error) {
+	n, err := strconv.ParseUint(string(c.text), 10, 8)
+	if err != nil {
+		return byte(0), errors.New("invalid byte value")
+	}
+	return byte(n), nil
+}
+
+func (p *parser) callonByteValue7() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onByteValue7()
+}
+
+func (c *current) onBytesMatcher1(n interface{}) (interface{}, error) {
+	by := ast.NewBytesMatcher(c.astPos())
+	switch n := n.(type) {
+	case int:
+		by.N = n
+	case *ast.Identifier:
+		by.Label = n
+	}
+	return by, nil
+}
+
+func (p *parser) callonBytesMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onBytesMatcher1(stack["n"])
+}
+
+func (c *current) onBytesCount1() (interface{}, error) {
+	n, err := strconv.Atoi(string(c.text))
+	if err != nil {
+		return 0, errors.New("invalid number of bytes")
+	}
+	return n, nil
+}
+
+func (p *parser) callonBytesCount1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onBytesCount1()
+}
+
+func (c *current) onNumberMatcher1(opts interface{}) (interface{}, error) {
+	num := ast.NewNumberMatcher(c.astPos())
+	optsSlice := toIfaceSlice(opts)
+	if len(optsSlice) == 0 {
+		return num, nil
+	}
+	list := []interface{}{optsSlice[0]}
+	for _, opt := range toIfaceSlice(optsSlice[1]) {
+		list = append(list, opt.([]interface{})[3])
+	}
+	for _, opt := range list {
+		kv := opt.([]interface{})
+		name, val := kv[0].(*ast.Identifier).Val, kv[1].(string)
+		switch name {
+		case "float", "sign", "prefix":
+			if val != "true" && val != "false" {
+				return num, fmt.Errorf("Number option %s must be true or false", name)
+			}
+			switch name {
+			case "float":
+				num.Float = val == "true"
+			case "sign":
+				num.Sign = val == "true"
+			default:
+				num.Prefix = val == "true"
+			}
+		case "radix":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 2 || n > 36 {
+				return num, errors.New("invalid Number radix")
+			}
+			num.Radix = n
+		case "thousands", "decimal":
+			s, err := strconv.Unquote(val)
+			rn, n := utf8.DecodeRuneInString(s)
+			if err != nil || s == "" || n != len(s) || unicode.IsDigit(rn) || unicode.IsLetter(rn) || rn == '-' || rn == '+' {
+				return num, fmt.Errorf("Number option %s must be a single character that is not a digit, a letter or a sign", name)
+			}
+			if name == "thousands" {
+				num.Thousands = rn
+			} else {
+				num.Decimal = rn
+			}
+		default:
+			return num, fmt.Errorf("unknown Number option %s", name)
+		}
+	}
+	if num.Float && num.Radix != 10 {
+		return num, errors.New("Number float option requires a radix of 10")
+	}
+	if num.Prefix && (num.Float || num.Radix != 10) {
+		return num, errors.New("Number prefix option cannot be used with the float and radix options")
+	}
+	if num.Thousands != 0 && (num.Prefix || num.Radix != 10) {
+		return num, errors.New("Number thousands option requires a radix of 10")
+	}
+	if num.Decimal != 0 && !num.Float {
+		return num, errors.New("Number decimal option requires the float option")
+	}
+	if dec := num.Decimal; num.Thousands != 0 && (num.Thousands == dec || dec == 0 && num.Thousands == '.') {
+		return num, errors.New("Number thousands and decimal separators must differ")
+	}
+	return num, nil
+}
+
+func (p *parser) callonNumberMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNumberMatcher1(stack["opts"])
+}
+
+func (c *current) onNumberOption1(name, val interface{}) (interface{}, error) {
+	return []interface{}{name, val}, nil
+}
+
+func (p *parser) callonNumberOption1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNumberOption1(stack["name"], stack["val"])
+}
+
+func (c *current) onNumberOptionValue1() (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonNumberOptionValue1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNumberOptionValue1()
+}
+
+func (c *current) onIndentMatcher1() (interface{}, error) {
+	return ast.NewIndentMatcher(c.astPos(), string(c.text[1:])), nil
+}
+
+func (p *parser) callonIndentMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onIndentMatcher1()
+}
+
+func (c *current) onKeywordMatcher1() (interface{}, error) {
+	return ast.NewKeywordMatcher(c.astPos()), nil
+}
+
+func (p *parser) callonKeywordMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onKeywordMatcher1()
+}
+
+func (c *current) onWordListMatcher1() (interface{}, error) {
+	return ast.NewWordListMatcher(c.astPos()), nil
+}
+
+func (p *parser) callonWordListMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onWordListMatcher1()
+}
+
+func (c *current) onTableMatcher1(name interface{}) (interface{}, error) {
+	return ast.NewTableMatcher(c.astPos(), name.(*ast.Identifier).Val), nil
+}
+
+func (p *parser) callonTableMatcher1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTableMatcher1(stack["name"])
+}
+
+func (c *current) onTokenMatcher2(kind interface{}) (interface{}, error) {
+	return ast.NewTokenMatcher(c.astPos(), kind.(string)), nil
+}
+
+func (p *parser) callonTokenMatcher2() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTokenMatcher2(stack["kind"])
+}
+
+func (c *current) onTokenMatcher10() (interface{}, error) {
+	return ast.NewTokenMatcher(c.astPos(), ""), nil
+}
+
+func (p *parser) callonTokenMatcher10() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTokenMatcher10()
+}
+
+func (c *current) onTokenKind1() (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonTokenKind1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTokenKind1()
+}
+
 func (c *current) onCodeBlock2() (interface{}, error) {
 	pos := c.astPos()
 	cb := ast.NewCodeBlock(pos, string(c.text))
@@ -3006,12 +6763,19 @@ type litMatcher struct {
 	ignoreCase bool
 }
 
+type litSetMatcher struct {
+	pos   position
+	alts  []*litMatcher
+	parts [][]int
+}
+
 type charClassMatcher struct {
 	pos        position
 	val        string
 	chars      []rune
 	ranges     []rune
 	classes    []*unicode.RangeTable
+	classNames []string
 	ignoreCase bool
 	inverted   bool
 }
@@ -3380,6 +7144,8 @@ func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
 		val, ok = p.parseLabeledExpr(expr)
 	case *litMatcher:
 		val, ok = p.parseLitMatcher(expr)
+	case *litSetMatcher:
+		val, ok = p.parseLitSetMatcher(expr)
 	case *notCodeExpr:
 		val, ok = p.parseNotCodeExpr(expr)
 	case *notExpr:
@@ -3569,6 +7335,35 @@ func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
 	return p.sliceFrom(start), true
 }
 
+func (p *parser) parseLitSetMatcher(set *litSetMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitSetMatcher"))
+	}
+
+	for i, alt := range set.alts {
+		val, ok := p.parseLitMatcher(alt)
+		if !ok {
+			continue
+		}
+		if set.parts == nil {
+			return val, true
+		}
+		b := val.([]byte)
+		vals := make([]interface{}, len(set.parts[i]))
+		for j, n := range set.parts[i] {
+			m := 0
+			for ; n > 0; n-- {
+				_, w := utf8.DecodeRune(b[m:])
+				m += w
+			}
+			vals[j] = b[:m]
+			b = b[m:]
+		}
+		return vals, true
+	}
+	return nil, false
+}
+
 func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
 	if p.debug {
 		defer p.out(p.in("parseNotCodeExpr"))
@@ -0,0 +1,43 @@
+package ambiguity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAmbiguities(t *testing.T) {
+	var got []Ambiguity
+	if _, err := Parse("", []byte("f(),if,1,g"), Ambiguities(&got)); err != nil {
+		t.Fatal(err)
+	}
+	want := []Ambiguity{
+		{Rule: "Expr", Pos: Pos{Line: 1, Col: 1, Offset: 0}, Alts: []int{1, 2}},
+		{Rule: "Expr", Pos: Pos{Line: 1, Col: 5, Offset: 4}, Alts: []int{2, 3}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if s, want := got[1].String(), "1:5 (4): rule Expr: alternatives [2 3] match"; s != want {
+		t.Errorf("want %q, got %q", want, s)
+	}
+}
+
+func TestAmbiguitiesNone(t *testing.T) {
+	var got []Ambiguity
+	if _, err := Parse("", []byte("1,2,x"), Ambiguities(&got)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no ambiguity, got %v", got)
+	}
+}
+
+func TestAmbiguitiesFail(t *testing.T) {
+	var got []Ambiguity
+	if _, err := Parse("", []byte("f(),"), Ambiguities(&got)); err == nil {
+		t.Fatal("want error, got none")
+	}
+	if len(got) != 1 || got[0].Pos.Offset != 0 {
+		t.Errorf("want an ambiguity at offset 0, got %v", got)
+	}
+}
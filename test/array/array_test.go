@@ -0,0 +1,62 @@
+package array
+
+import "testing"
+
+func TestArray(t *testing.T) {
+	got, err := Parse("", []byte("#ff8000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [3]int{255, 128, 0}; got != want {
+		t.Errorf("want %v (%[1]T), got %v (%[2]T)", want, got)
+	}
+
+	for _, in := range []string{"#ff80", "#ff800000"} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
+
+func TestArrayInterface(t *testing.T) {
+	got, err := ParsePair("", []byte("xy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, ok := got.([2]interface{})
+	if !ok {
+		t.Fatalf("want [2]interface{}, got %T", got)
+	}
+	if string(pair[0].([]byte)) != "x" || string(pair[1].([]byte)) != "y" {
+		t.Errorf("want [x y], got %s", pair)
+	}
+}
+
+func TestArrayType(t *testing.T) {
+	_, err := ParseBad("", []byte("xy"))
+	want := "1:1 (0): rule Bad: array element of type []uint8 is not assignable to int"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestArrayAllocs(t *testing.T) {
+	// the array allocates no more than the same parse with a sequence,
+	// that allocates a slice of the values instead.
+	in := []byte("#ff8000")
+	array := testing.AllocsPerRun(100, func() { Parse("", in) })
+	seq := testing.AllocsPerRun(100, func() { ParseSeq("", in) })
+	if array > seq {
+		t.Errorf("want at most %v allocations, got %v", seq, array)
+	}
+}
+
+func BenchmarkArray(b *testing.B) {
+	in := []byte("#ff8000")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
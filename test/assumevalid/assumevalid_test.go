@@ -0,0 +1,50 @@
+package assumevalid
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssumeValidUTF8(t *testing.T) {
+	cases := []string{
+		"",
+		"abc",
+		"a\nbc\n\nd",
+		"héllo\nwörld ☺\n",
+		"\U0001F600 x\n",
+	}
+	for _, in := range cases {
+		want, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		got, err := Parse("", []byte(in), AssumeValidUTF8(true))
+		if err != nil {
+			t.Errorf("%q: AssumeValidUTF8: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func BenchmarkAssumeValidUTF8(b *testing.B) {
+	in := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog, é\n", 1000))
+	for _, assume := range []bool{false, true} {
+		name := "validate"
+		if assume {
+			name = "assume-valid"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(in)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse("", in, AssumeValidUTF8(assume)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1980 @@
+package backref
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var g = &grammar{
+	rules: []*rule{
+		{
+			name: "Start",
+			pos:  position{line: 5, col: 1, offset: 21},
+			expr: &choiceExpr{
+				pos: position{line: 5, col: 9, offset: 31},
+				alternatives: []interface{}{
+					&ruleRefExpr{
+						pos:  position{line: 5, col: 9, offset: 31},
+						name: "Element",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 5, col: 19, offset: 41},
+						name: "HereDoc",
+					},
+				},
+			},
+		},
+		{
+			name: "Element",
+			pos:  position{line: 7, col: 1, offset: 50},
+			expr: &actionExpr{
+				pos: position{line: 7, col: 11, offset: 62},
+				run: (*parser).callonElement1,
+				expr: &seqExpr{
+					pos: position{line: 7, col: 11, offset: 62},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 7, col: 11, offset: 62},
+							val:        "<",
+							ignoreCase: false,
+						},
+						&labeledExpr{
+							pos:     position{line: 7, col: 15, offset: 66},
+							label:   "open",
+							capture: true,
+							expr: &ruleRefExpr{
+								pos:  position{line: 7, col: 20, offset: 71},
+								name: "Name",
+							},
+						},
+						&litMatcher{
+							pos:        position{line: 7, col: 25, offset: 76},
+							val:        ">",
+							ignoreCase: false,
+						},
+						&labeledExpr{
+							pos:   position{line: 7, col: 29, offset: 80},
+							label: "body",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 7, col: 34, offset: 85},
+								expr: &seqExpr{
+									pos: position{line: 7, col: 36, offset: 87},
+									exprs: []interface{}{
+										&notExpr{
+											pos: position{line: 7, col: 36, offset: 87},
+											expr: &litMatcher{
+												pos:        position{line: 7, col: 37, offset: 88},
+												val:        "</",
+												ignoreCase: false,
+											},
+										},
+										&anyMatcher{
+											line: 7, col: 42, offset: 93,
+										},
+									},
+								},
+							},
+						},
+						&litMatcher{
+							pos:        position{line: 7, col: 47, offset: 98},
+							val:        "</",
+							ignoreCase: false,
+						},
+						&backRefExpr{
+							pos:   position{line: 7, col: 52, offset: 103},
+							label: "open",
+						},
+						&litMatcher{
+							pos:        position{line: 7, col: 59, offset: 110},
+							val:        ">",
+							ignoreCase: false,
+						},
+						&notExpr{
+							pos: position{line: 7, col: 63, offset: 114},
+							expr: &anyMatcher{
+								line: 7, col: 64, offset: 115,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "HereDoc",
+			pos:  position{line: 11, col: 1, offset: 153},
+			expr: &actionExpr{
+				pos: position{line: 11, col: 11, offset: 165},
+				run: (*parser).callonHereDoc1,
+				expr: &seqExpr{
+					pos: position{line: 11, col: 11, offset: 165},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 11, col: 11, offset: 165},
+							val:        "<<",
+							ignoreCase: false,
+						},
+						&labeledExpr{
+							pos:     position{line: 11, col: 16, offset: 170},
+							label:   "delim",
+							capture: true,
+							expr: &ruleRefExpr{
+								pos:  position{line: 11, col: 22, offset: 176},
+								name: "Name",
+							},
+						},
+						&litMatcher{
+							pos:        position{line: 11, col: 27, offset: 181},
+							val:        "\n",
+							ignoreCase: false,
+						},
+						&labeledExpr{
+							pos:   position{line: 11, col: 32, offset: 186},
+							label: "lines",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 11, col: 38, offset: 192},
+								expr: &seqExpr{
+									pos: position{line: 11, col: 40, offset: 194},
+									exprs: []interface{}{
+										&notExpr{
+											pos: position{line: 11, col: 40, offset: 194},
+											expr: &seqExpr{
+												pos: position{line: 11, col: 43, offset: 197},
+												exprs: []interface{}{
+													&backRefExpr{
+														pos:   position{line: 11, col: 43, offset: 197},
+														label: "delim",
+													},
+													&notExpr{
+														pos: position{line: 11, col: 51, offset: 205},
+														expr: &anyMatcher{
+															line: 11, col: 52, offset: 206,
+														},
+													},
+												},
+											},
+										},
+										&anyMatcher{
+											line: 11, col: 56, offset: 210,
+										},
+									},
+								},
+							},
+						},
+						&backRefExpr{
+							pos:   position{line: 11, col: 61, offset: 215},
+							label: "delim",
+						},
+						&notExpr{
+							pos: position{line: 11, col: 69, offset: 223},
+							expr: &anyMatcher{
+								line: 11, col: 70, offset: 224,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Name",
+			pos:  position{line: 15, col: 1, offset: 262},
+			expr: &oneOrMoreExpr{
+				pos: position{line: 15, col: 8, offset: 271},
+				expr: &charClassMatcher{
+					pos:        position{line: 15, col: 8, offset: 271},
+					val:        "[a-zA-Z]",
+					ranges:     []rune{'a', 'z', 'A', 'Z'},
+					ignoreCase: false,
+					inverted:   false,
+				},
+			},
+		},
+	},
+}
+
+func (c *current) onElement1(open, body interface{}) (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonElement1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onElement1(stack["open"], stack["body"])
+}
+
+func (c *current) onHereDoc1(delim, lines interface{}) (interface{}, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonHereDoc1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onHereDoc1(stack["delim"], stack["lines"])
+}
+
+var (
+	// errNoRule is returned when the grammar to parse has no rule.
+	errNoRule = errors.New("grammar has no rule")
+
+	// errInvalidEncoding is returned when the source is not properly
+	// utf8-encoded.
+	errInvalidEncoding = errors.New("invalid encoding")
+
+	// errNoMatch is returned if no match could be found.
+	errNoMatch = errors.New("no match found")
+)
+
+// Option is a function that can set an option on the parser. It returns
+// the previous setting as an Option.
+type Option func(*parser) Option
+
+// Debug creates an Option to set the debug flag to b. When set to true,
+// debugging information is printed to stdout while parsing.
+//
+// The default is false.
+func Debug(b bool) Option {
+	return func(p *parser) Option {
+		old := p.debug
+		p.debug = b
+		return Debug(old)
+	}
+}
+
+// Memoize creates an Option to set the memoize flag to b. When set to true,
+// the parser will cache all results so each expression is evaluated only
+// once. This guarantees linear parsing time even for pathological cases,
+// at the expense of more memory and slower times for typical cases.
+//
+// The default is false.
+func Memoize(b bool) Option {
+	return func(p *parser) Option {
+		old := p.memoize
+		p.memoize = b
+		return Memoize(old)
+	}
+}
+
+// Keywords creates an Option to set the words matched by the @keyword
+// matcher to words. The keyword matcher matches the longest of the words
+// found at the current position that is not immediately followed by a
+// letter, a digit or an underscore. The @unreserved expression fails if
+// its match is one of the words.
+//
+// The default is no word, the @keyword matcher never matches.
+func Keywords(words ...string) Option {
+	return func(p *parser) Option {
+		old := p.keywords
+		p.keywords = words
+		return Keywords(old...)
+	}
+}
+
+// MaxBacktrack creates an Option to set the maximum number of times a
+// rule can backtrack to the same offset to n. When this limit is exceeded,
+// parsing stops with an error identifying the rule. This is useful during
+// development to detect ambiguous rules that result in exponential parsing
+// time. A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxBacktrack(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxBacktrack
+		p.maxBacktrack = n
+		return MaxBacktrack(old)
+	}
+}
+
+// Ownership creates an Option to record in m the number of runes owned by
+// each rule in the successful parse, keyed by rule name. A rune is owned
+// by the innermost rule that matched it, so that the numbers of runes sum
+// to the number of runes consumed by the parse. The runes of the inlined
+// rules are owned by the referencing rule. The ownership is not accurate
+// if the Memoize option is set.
+//
+// The default is nil, the ownership is not recorded.
+func Ownership(m map[string]int) Option {
+	return func(p *parser) Option {
+		old := p.owned
+		p.owned = m
+		return Ownership(old)
+	}
+}
+
+// OnMatch creates an Option to set the function called for each match of
+// a rule in the successful parse, with the name of the rule, the start and
+// end positions of the match and its value. The matches of rules that were
+// backtracked over are not reported. The function is called in the order
+// the matches completed, once the input is parsed, so the matches of the
+// rules referenced by a rule are reported before the match of that rule.
+// The inlined rules are not reported. The matches are not accurate if the
+// Memoize option is set.
+//
+// The default is nil, the matches are not reported.
+func OnMatch(fn func(rule string, start, end Pos, value interface{})) Option {
+	return func(p *parser) Option {
+		old := p.onMatch
+		p.onMatch = fn
+		return OnMatch(old)
+	}
+}
+
+// Transform creates an Option to set the function that transforms the
+// value of the rule named rule to fn. The function is called with the
+// value of each match of the rule, after its action, and its result
+// replaces that value. If it returns an error, the match fails and the
+// error is added to the list of errors. The values of the inlined rules
+// described in the package documentation are not transformed. A nil fn
+// removes the transformation.
+//
+// The default is no transformation.
+func Transform(rule string, fn func(interface{}) (interface{}, error)) Option {
+	return func(p *parser) Option {
+		old := p.transforms[rule]
+		if p.transforms == nil {
+			p.transforms = make(map[string]func(interface{}) (interface{}, error))
+		}
+		p.transforms[rule] = fn
+		if fn == nil {
+			delete(p.transforms, rule)
+		}
+		return Transform(rule, old)
+	}
+}
+
+// Recover creates an Option to set the recover flag to b. When set to
+// true, this causes the parser to recover from panics and convert it
+// to an error. Setting it to false can be useful while debugging to
+// access the full stack trace.
+//
+// The default is true.
+func Recover(b bool) Option {
+	return func(p *parser) Option {
+		old := p.recover
+		p.recover = b
+		return Recover(old)
+	}
+}
+
+// ParseFile parses the file identified by filename.
+func ParseFile(filename string, opts ...Option) (interface{}, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseReader(filename, f, opts...)
+}
+
+// ParseReader parses the data from r using filename as information in the
+// error messages.
+func ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(filename, b, opts...)
+}
+
+// Parse parses the data from b using filename as information in the
+// error messages.
+func Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+	return newParser(filename, b, opts...).parse(g)
+}
+
+// ParsePartial parses the data from b like Parse, and also returns the
+// remainder of b that follows the match of the start rule. The start rule
+// does not have to match all of b, unless it ends with a not predicate on
+// the any matcher (!.). The remainder is nil if there is an error.
+func ParsePartial(filename string, b []byte, opts ...Option) (interface{}, []byte, error) {
+	p := newParser(filename, b, opts...)
+	val, err := p.parse(g)
+	if err != nil {
+		return val, nil, err
+	}
+	return val, b[p.pt.offset:], nil
+}
+
+// Parser parses inputs with a set of options fixed at construction. It is
+// safe for concurrent use by multiple goroutines: the grammar is shared
+// read-only, and the state of a parse, including the memoization cache,
+// is confined to the call.
+type Parser struct {
+	opts []Option
+}
+
+// NewParser returns a Parser that applies the options opts to each parse.
+func NewParser(opts ...Option) *Parser {
+	return &Parser{opts: append([]Option(nil), opts...)}
+}
+
+// Parse parses the data from b like the package's Parse function. The
+// options opts are applied after those of the Parser.
+func (pr *Parser) Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+	all := make([]Option, 0, len(pr.opts)+len(opts))
+	all = append(append(all, pr.opts...), opts...)
+	return Parse(filename, b, all...)
+}
+
+// Token is a token of the input of ParseTokens, as produced by an external
+// lexer. The @token matchers of the grammar match the tokens by kind.
+type Token interface {
+	Kind() int
+	Text() string
+	Pos() TokenPos
+}
+
+// TokenPos is the position of a token in the input of the lexer.
+type TokenPos struct {
+	Line, Col, Offset int
+}
+
+// ParseTokens parses the tokens toks using filename as information in the
+// error messages. The positions in the error messages are those of the
+// tokens. Only the @token matchers and the predicates match the tokens,
+// the value of a @token matcher is the Token it matched and the text of a
+// match is the concatenation of the text of its tokens.
+func ParseTokens(filename string, toks []Token, opts ...Option) (interface{}, error) {
+	p := newParser(filename, nil, opts...)
+	p.tokMode = true
+	p.toks = toks
+	return p.parse(g)
+}
+
+// Pos is the position of a match reported to the OnMatch function.
+type Pos struct {
+	Line, Col, Offset int
+}
+
+// position records a position in the text.
+type position struct {
+	line, col, offset int
+}
+
+func (p position) String() string {
+	return fmt.Sprintf("%d:%d [%d]", p.line, p.col, p.offset)
+}
+
+// savepoint stores all state required to go back to this point in the
+// parser.
+type savepoint struct {
+	position
+	rn rune
+	w  int
+
+	// indentation levels, nil at the top level
+	indents *indentLevel
+	// length of the ownership log
+	owned int
+	// length of the log of matches reported to OnMatch
+	matched int
+}
+
+// indentLevel is an immutable stack of indentation widths, so that it is
+// restored along with the savepoint when the parser backtracks.
+type indentLevel struct {
+	width int
+	prev  *indentLevel
+}
+
+type current struct {
+	pos  position // start position of the match
+	text []byte   // raw text of the match
+}
+
+// the AST types...
+
+type grammar struct {
+	pos   position
+	rules []*rule
+}
+
+type rule struct {
+	pos         position
+	name        string
+	displayName string
+	expr        interface{}
+}
+
+type choiceExpr struct {
+	pos          position
+	alternatives []interface{}
+}
+
+type actionExpr struct {
+	pos  position
+	expr interface{}
+	run  func(*parser) (interface{}, error)
+}
+
+type seqExpr struct {
+	pos   position
+	exprs []interface{}
+}
+
+type labeledExpr struct {
+	pos     position
+	label   string
+	capture bool
+	expr    interface{}
+}
+
+type backRefExpr struct {
+	pos   position
+	label string
+}
+
+type expr struct {
+	pos  position
+	expr interface{}
+}
+
+type andExpr expr
+type notExpr expr
+type zeroOrOneExpr expr
+type zeroOrMoreExpr struct {
+	pos   position
+	expr  interface{}
+	while func(*parser, []interface{}) (bool, error)
+}
+
+type oneOrMoreExpr struct {
+	pos   position
+	expr  interface{}
+	while func(*parser, []interface{}) (bool, error)
+}
+
+type ruleRefExpr struct {
+	pos  position
+	name string
+}
+
+type operatorsExpr struct {
+	pos     position
+	operand interface{}
+	ops     []*binaryOp
+}
+
+type unreservedExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type sepExpr struct {
+	pos      position
+	expr     interface{}
+	sep      interface{}
+	trailing bool
+}
+
+type foldExpr struct {
+	pos   position
+	expr  interface{}
+	right bool
+}
+
+type binaryOp struct {
+	lit        *litMatcher
+	prec       int
+	rightAssoc bool
+}
+
+type andCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+type notCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+type litMatcher struct {
+	pos        position
+	val        string
+	ignoreCase bool
+}
+
+type charClassMatcher struct {
+	pos        position
+	val        string
+	chars      []rune
+	ranges     []rune
+	classes    []*unicode.RangeTable
+	ignoreCase bool
+	inverted   bool
+}
+
+type anyMatcher position
+
+type untilMatcher struct {
+	pos position
+	val string
+}
+
+type keywordMatcher position
+
+type skipExpr struct {
+	pos  position
+	skip interface{}
+	expr interface{}
+}
+
+type tokenMatcher struct {
+	pos  position
+	kind int
+	name string
+	any  bool
+}
+
+type byteMatcher struct {
+	pos position
+	val byte
+}
+
+type bytesMatcher struct {
+	pos   position
+	n     int
+	label string
+}
+
+type indentMatcher struct {
+	pos position
+	val string
+}
+
+// bigEndianUint returns the unsigned integer encoded in big-endian order in
+// b, which is at most 8 bytes long. It can be used in the code blocks of
+// grammars for binary formats.
+func bigEndianUint(b []byte) uint64 {
+	var n uint64
+	for _, by := range b {
+		n = n<<8 | uint64(by)
+	}
+	return n
+}
+
+// littleEndianUint returns the unsigned integer encoded in little-endian
+// order in b, which is at most 8 bytes long. It can be used in the code
+// blocks of grammars for binary formats.
+func littleEndianUint(b []byte) uint64 {
+	var n uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		n = n<<8 | uint64(b[i])
+	}
+	return n
+}
+
+// errList cumulates the errors found by the parser.
+type errList []error
+
+func (e *errList) add(err error) {
+	*e = append(*e, err)
+}
+
+func (e errList) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	e.dedupe()
+	return e
+}
+
+func (e *errList) dedupe() {
+	var cleaned []error
+	set := make(map[string]bool)
+	for _, err := range *e {
+		if msg := err.Error(); !set[msg] {
+			set[msg] = true
+			cleaned = append(cleaned, err)
+		}
+	}
+	*e = cleaned
+}
+
+func (e errList) Error() string {
+	switch len(e) {
+	case 0:
+		return ""
+	case 1:
+		return e[0].Error()
+	default:
+		var buf bytes.Buffer
+
+		for i, err := range e {
+			if i > 0 {
+				buf.WriteRune('\n')
+			}
+			buf.WriteString(err.Error())
+		}
+		return buf.String()
+	}
+}
+
+// parserError wraps an error with a prefix indicating the rule in which
+// the error occurred. The original error is stored in the Inner field.
+type parserError struct {
+	Inner  error
+	pos    position
+	prefix string
+}
+
+// Error returns the error message.
+func (p *parserError) Error() string {
+	return p.prefix + ": " + p.Inner.Error()
+}
+
+// newParser creates a parser with the specified input source and options.
+func newParser(filename string, b []byte, opts ...Option) *parser {
+	p := &parser{
+		filename: filename,
+		errs:     new(errList),
+		data:     b,
+		pt:       savepoint{position: position{line: 1}},
+		recover:  true,
+	}
+	p.setOptions(opts)
+	return p
+}
+
+// setOptions applies the options to the parser.
+func (p *parser) setOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+type resultTuple struct {
+	v   interface{}
+	b   bool
+	end savepoint
+}
+
+// ownEntry records the number of runes owned by a rule in a match. The
+// cum field is the total of runes owned by all entries up to this one.
+type ownEntry struct {
+	rule  *rule
+	runes int
+	cum   int
+}
+
+// matchEntry records a match of a rule, reported to OnMatch.
+type matchEntry struct {
+	rule       *rule
+	start, end position
+	val        interface{}
+}
+
+type backtrackKey struct {
+	rule   *rule
+	offset int
+}
+
+type parser struct {
+	filename string
+	pt       savepoint
+	cur      current
+
+	// errors
+	maxSavePoint savepoint
+	maxFound     string
+	maxExpected  []string
+
+	data []byte
+	errs *errList
+
+	recover bool
+	debug   bool
+	depth   int
+
+	memoize bool
+	// memoization table for the packrat algorithm:
+	// map[offset in source] map[expression or rule] {value, match}
+	memo map[int]map[interface{}]resultTuple
+
+	// number of runes owned by rule, and the log of matches
+	owned  map[string]int
+	ownLog []ownEntry
+
+	// function called for the matches of the rules, and the log of matches
+	onMatch  func(string, Pos, Pos, interface{})
+	matchLog []matchEntry
+
+	// words matched by the keyword matcher
+	keywords []string
+
+	// functions that transform the value of the rules, by rule name
+	transforms map[string]func(interface{}) (interface{}, error)
+
+	// input tokens of ParseTokens, the offset of the position is the
+	// index of the current token in token mode
+	tokMode bool
+	toks    []Token
+
+	maxBacktrack int
+	// number of times each rule backtracked to an offset
+	backtracks map[backtrackKey]int
+
+	// rules table, maps the rule identifier to the rule node
+	rules map[string]*rule
+	// variables stack, map of label to value
+	vstack []map[string]interface{}
+	// index in vstack of the variable set of the current rule
+	vbase int
+	// rule stack, allows identification of the current rule in errors
+	rstack []*rule
+
+	// stats
+	exprCnt int
+}
+
+func (p *parser) setMaxSavePoint(current string, expected string) {
+	if p.pt.offset > p.maxSavePoint.offset {
+		p.maxFound = current
+		p.maxSavePoint = p.pt
+		p.maxExpected = []string{expected}
+	} else if p.pt.offset == p.maxSavePoint.offset {
+		for _, e := range p.maxExpected {
+			if e == expected {
+				return
+			}
+		}
+		p.maxExpected = append(p.maxExpected, expected)
+	}
+}
+
+// push a variable set on the vstack.
+func (p *parser) pushV() {
+	if cap(p.vstack) == len(p.vstack) {
+		// create new empty slot in the stack
+		p.vstack = append(p.vstack, nil)
+	} else {
+		// slice to 1 more
+		p.vstack = p.vstack[:len(p.vstack)+1]
+	}
+
+	// get the last args set
+	m := p.vstack[len(p.vstack)-1]
+	if m != nil && len(m) == 0 {
+		// empty map, all good
+		return
+	}
+
+	m = make(map[string]interface{})
+	p.vstack[len(p.vstack)-1] = m
+}
+
+// pop a variable set from the vstack.
+func (p *parser) popV() {
+	// if the map is not empty, clear it
+	m := p.vstack[len(p.vstack)-1]
+	if len(m) > 0 {
+		// GC that map
+		p.vstack[len(p.vstack)-1] = nil
+	}
+	p.vstack = p.vstack[:len(p.vstack)-1]
+}
+
+func (p *parser) print(prefix, s string) string {
+	if !p.debug {
+		return s
+	}
+
+	fmt.Printf("%s %d:%d:%d: %s [%#U]\n",
+		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+	return s
+}
+
+func (p *parser) in(s string) string {
+	p.depth++
+	return p.print(strings.Repeat(" ", p.depth)+">", s)
+}
+
+func (p *parser) out(s string) string {
+	p.depth--
+	return p.print(strings.Repeat(" ", p.depth)+"<", s)
+}
+
+func (p *parser) addErr(err error) {
+	p.addErrAt(err, p.pt.position)
+}
+
+func (p *parser) addErrAt(err error, pos position) {
+	var buf bytes.Buffer
+	if p.filename != "" {
+		buf.WriteString(p.filename)
+	}
+	if buf.Len() > 0 {
+		buf.WriteString(":")
+	}
+	buf.WriteString(fmt.Sprintf("%d:%d (%d)", pos.line, pos.col, pos.offset))
+	if len(p.rstack) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString(": ")
+		}
+		rule := p.rstack[len(p.rstack)-1]
+		if rule.displayName != "" {
+			buf.WriteString("rule " + rule.displayName)
+		} else {
+			buf.WriteString("rule " + rule.name)
+		}
+	}
+	pe := &parserError{Inner: err, pos: pos, prefix: buf.String()}
+	p.errs.add(pe)
+}
+
+// read advances the parser to the next rune.
+func (p *parser) read() {
+	if p.tokMode {
+		p.readToken()
+		return
+	}
+	p.pt.offset += p.pt.w
+	rn, n := utf8.DecodeRune(p.data[p.pt.offset:])
+	p.pt.rn = rn
+	p.pt.w = n
+	p.pt.col++
+	if rn == '\n' {
+		p.pt.line++
+		p.pt.col = 0
+	}
+
+	if rn == utf8.RuneError {
+		if n == 1 {
+			p.addErr(errInvalidEncoding)
+		}
+	}
+}
+
+// readToken advances the parser's position to the next token in token
+// mode. The current rune is never valid, so that the rune matchers don't
+// match.
+func (p *parser) readToken() {
+	p.pt.offset += p.pt.w
+	p.pt.rn = utf8.RuneError
+	p.pt.w = 0
+	if p.pt.offset < len(p.toks) {
+		p.pt.w = 1
+		pos := p.toks[p.pt.offset].Pos()
+		p.pt.line, p.pt.col = pos.Line, pos.Col
+	}
+}
+
+// skip advances the parser's position by n bytes, regardless of the
+// encoding of the input. Each byte counts as a column.
+func (p *parser) skip(n int) {
+	if n == 0 {
+		return
+	}
+	p.pt.offset += n - p.pt.w
+	p.pt.col += n - 1
+	p.read()
+}
+
+// atInvalidOrEOF returns true if the parser is at the end of the input or
+// at an invalid UTF-8 encoding. A valid U+FFFD replacement character in the
+// input is a regular character.
+func (p *parser) atInvalidOrEOF() bool {
+	return p.pt.rn == utf8.RuneError && p.pt.w <= 1
+}
+
+// restore parser position to the savepoint pt.
+func (p *parser) restore(pt savepoint) {
+	if p.debug {
+		defer p.out(p.in("restore"))
+	}
+	if pt.offset == p.pt.offset {
+		p.pt.indents = pt.indents
+		p.pt.owned = pt.owned
+		p.pt.matched = pt.matched
+		return
+	}
+	if p.maxBacktrack > 0 && pt.offset < p.pt.offset && len(p.rstack) > 0 {
+		p.countBacktrack(pt)
+	}
+	p.pt = pt
+}
+
+// countBacktrack records that the current rule backtracked to pt, and
+// panics if the rule exceeded the maximum number of backtracks to this
+// offset.
+func (p *parser) countBacktrack(pt savepoint) {
+	if p.backtracks == nil {
+		p.backtracks = make(map[backtrackKey]int)
+	}
+	key := backtrackKey{rule: p.rstack[len(p.rstack)-1], offset: pt.offset}
+	p.backtracks[key]++
+	if n := p.backtracks[key]; n > p.maxBacktrack {
+		panic(fmt.Errorf("backtracked %d times to %s, maximum is %d", n, pt.position, p.maxBacktrack))
+	}
+}
+
+// get the slice of bytes from the savepoint start to the current position.
+func (p *parser) sliceFrom(start savepoint) []byte {
+	if p.tokMode {
+		var buf bytes.Buffer
+		for _, tok := range p.toks[start.position.offset:p.pt.position.offset] {
+			buf.WriteString(tok.Text())
+		}
+		return buf.Bytes()
+	}
+	return p.data[start.position.offset:p.pt.position.offset]
+}
+
+func (p *parser) getMemoized(node interface{}) (resultTuple, bool) {
+	if len(p.memo) == 0 {
+		return resultTuple{}, false
+	}
+	m := p.memo[p.pt.offset]
+	if len(m) == 0 {
+		return resultTuple{}, false
+	}
+	res, ok := m[node]
+	return res, ok
+}
+
+func (p *parser) setMemoized(pt savepoint, node interface{}, tuple resultTuple) {
+	if p.memo == nil {
+		p.memo = make(map[int]map[interface{}]resultTuple)
+	}
+	m := p.memo[pt.offset]
+	if m == nil {
+		m = make(map[interface{}]resultTuple)
+		p.memo[pt.offset] = m
+	}
+	m[node] = tuple
+}
+
+func (p *parser) buildRulesTable(g *grammar) {
+	p.rules = make(map[string]*rule, len(g.rules))
+	for _, r := range g.rules {
+		p.rules[r.name] = r
+	}
+}
+
+func (p *parser) parse(g *grammar) (val interface{}, err error) {
+	if len(g.rules) == 0 {
+		p.addErr(errNoRule)
+		return nil, p.errs.err()
+	}
+
+	// TODO : not super critical but this could be generated
+	p.buildRulesTable(g)
+
+	if p.recover {
+		// panic can be used in action code to stop parsing immediately
+		// and return the panic as an error.
+		defer func() {
+			if e := recover(); e != nil {
+				if p.debug {
+					defer p.out(p.in("panic handler"))
+				}
+				val = nil
+				switch e := e.(type) {
+				case error:
+					p.addErr(e)
+				default:
+					p.addErr(fmt.Errorf("%v", e))
+				}
+				err = p.errs.err()
+			}
+		}()
+	}
+
+	// start rule is rule [0]
+	p.read() // advance to first rune
+	val, ok := p.parseRule(g.rules[0])
+	if !ok {
+		if len(*p.errs) == 0 {
+			// make sure this doesn't go out silently
+			if len(p.maxExpected) > 0 {
+				expected := "'" + p.maxExpected[0] + "'"
+				for i := 1; i < len(p.maxExpected) && i < 5; i++ {
+					expected += ", '" + p.maxExpected[i] + "'"
+				}
+				if len(p.maxExpected) > 5 {
+					expected += fmt.Sprintf(", and %d others", len(p.maxExpected)-5)
+				}
+
+				found := p.maxFound
+				if len(p.maxFound) == 0 {
+					found = string(p.maxSavePoint.rn)
+				}
+
+				p.addErrAt(fmt.Errorf("syntax error, unexpected '%s', expecting %s", found, expected), p.maxSavePoint.position)
+			} else {
+				p.addErr(errNoMatch)
+			}
+		}
+		return nil, p.errs.err()
+	}
+	if p.owned != nil {
+		for _, e := range p.ownLog[:p.pt.owned] {
+			p.owned[e.rule.name] += e.runes
+		}
+	}
+	if p.onMatch != nil {
+		for _, e := range p.matchLog[:p.pt.matched] {
+			p.onMatch(e.rule.name, Pos{e.start.line, e.start.col, e.start.offset}, Pos{e.end.line, e.end.col, e.end.offset}, e.val)
+		}
+	}
+	return val, nil
+}
+
+func (p *parser) parseRule(rule *rule) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRule " + rule.name))
+	}
+
+	if p.memoize {
+		res, ok := p.getMemoized(rule)
+		if ok {
+			p.restore(res.end)
+			return res.v, res.b
+		}
+	}
+
+	start := p.pt
+	p.rstack = append(p.rstack, rule)
+	p.pushV()
+	vbase := p.vbase
+	p.vbase = len(p.vstack) - 1
+	val, ok := p.parseExpr(rule.expr)
+	p.vbase = vbase
+	p.popV()
+	if fn := p.transforms[rule.name]; ok && fn != nil {
+		v, err := fn(val)
+		if err != nil {
+			p.addErrAt(err, start.position)
+			ok = false
+		} else {
+			val = v
+		}
+	}
+	p.rstack = p.rstack[:len(p.rstack)-1]
+	if ok && p.owned != nil {
+		p.addOwnership(rule, start)
+	}
+	if ok && p.onMatch != nil {
+		p.matchLog = append(p.matchLog[:p.pt.matched], matchEntry{rule: rule, start: start.position, end: p.pt.position, val: val})
+		p.pt.matched = len(p.matchLog)
+	}
+	if ok && p.debug {
+		p.print(strings.Repeat(" ", p.depth)+"MATCH", string(p.sliceFrom(start)))
+	}
+
+	if p.memoize {
+		p.setMemoized(start, rule, resultTuple{val, ok, p.pt})
+	}
+	return val, ok
+}
+
+// addOwnership records the runes matched by rule since start that are not
+// owned by the rules it references.
+func (p *parser) addOwnership(rule *rule, start savepoint) {
+	cumAt := func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		return p.ownLog[n-1].cum
+	}
+
+	runes := utf8.RuneCount(p.sliceFrom(start))
+	cum := cumAt(p.pt.owned)
+	own := runes - (cum - cumAt(start.owned))
+	if own == 0 {
+		return
+	}
+	p.ownLog = append(p.ownLog[:p.pt.owned], ownEntry{rule: rule, runes: own, cum: cum + own})
+	p.pt.owned = len(p.ownLog)
+}
+
+func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
+	var pt savepoint
+	var ok bool
+
+	if p.memoize {
+		res, ok := p.getMemoized(expr)
+		if ok {
+			p.restore(res.end)
+			return res.v, res.b
+		}
+		pt = p.pt
+	}
+
+	p.exprCnt++
+	var val interface{}
+	switch expr := expr.(type) {
+	case *actionExpr:
+		val, ok = p.parseActionExpr(expr)
+	case *andCodeExpr:
+		val, ok = p.parseAndCodeExpr(expr)
+	case *andExpr:
+		val, ok = p.parseAndExpr(expr)
+	case *anyMatcher:
+		val, ok = p.parseAnyMatcher(expr)
+	case *backRefExpr:
+		val, ok = p.parseBackRefExpr(expr)
+	case *byteMatcher:
+		val, ok = p.parseByteMatcher(expr)
+	case *bytesMatcher:
+		val, ok = p.parseBytesMatcher(expr)
+	case *charClassMatcher:
+		val, ok = p.parseCharClassMatcher(expr)
+	case *choiceExpr:
+		val, ok = p.parseChoiceExpr(expr)
+	case *foldExpr:
+		val, ok = p.parseFoldExpr(expr)
+	case *indentMatcher:
+		val, ok = p.parseIndentMatcher(expr)
+	case *keywordMatcher:
+		val, ok = p.parseKeywordMatcher(expr)
+	case *labeledExpr:
+		val, ok = p.parseLabeledExpr(expr)
+	case *litMatcher:
+		val, ok = p.parseLitMatcher(expr)
+	case *notCodeExpr:
+		val, ok = p.parseNotCodeExpr(expr)
+	case *notExpr:
+		val, ok = p.parseNotExpr(expr)
+	case *oneOrMoreExpr:
+		val, ok = p.parseOneOrMoreExpr(expr)
+	case *operatorsExpr:
+		val, ok = p.parseOperatorsExpr(expr)
+	case *ruleRefExpr:
+		val, ok = p.parseRuleRefExpr(expr)
+	case *sepExpr:
+		val, ok = p.parseSepExpr(expr)
+	case *seqExpr:
+		val, ok = p.parseSeqExpr(expr)
+	case *skipExpr:
+		val, ok = p.parseSkipExpr(expr)
+	case *tokenMatcher:
+		val, ok = p.parseTokenMatcher(expr)
+	case *unreservedExpr:
+		val, ok = p.parseUnreservedExpr(expr)
+	case *untilMatcher:
+		val, ok = p.parseUntilMatcher(expr)
+	case *zeroOrMoreExpr:
+		val, ok = p.parseZeroOrMoreExpr(expr)
+	case *zeroOrOneExpr:
+		val, ok = p.parseZeroOrOneExpr(expr)
+	default:
+		panic(fmt.Sprintf("unknown expression type %T", expr))
+	}
+	if p.memoize {
+		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
+	}
+	return val, ok
+}
+
+func (p *parser) parseActionExpr(act *actionExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseActionExpr"))
+	}
+
+	start := p.pt
+	val, ok := p.parseExpr(act.expr)
+	if ok {
+		p.cur.pos = start.position
+		p.cur.text = p.sliceFrom(start)
+		actVal, err := act.run(p)
+		if err != nil {
+			p.addErrAt(err, start.position)
+			ok = false
+		} else {
+			val = actVal
+		}
+	}
+	if ok && p.debug {
+		p.print(strings.Repeat(" ", p.depth)+"MATCH", string(p.sliceFrom(start)))
+	}
+	return val, ok
+}
+
+func (p *parser) parseAndCodeExpr(and *andCodeExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndCodeExpr"))
+	}
+
+	ok, err := and.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, ok
+}
+
+func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndExpr"))
+	}
+
+	// the value of the expression is kept, so that the text ahead can be
+	// captured without being consumed.
+	pt := p.pt
+	p.pushV()
+	val, ok := p.parseExpr(and.expr)
+	p.popV()
+	p.restore(pt)
+	return val, ok
+}
+
+func (p *parser) parseAnyMatcher(any *anyMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAnyMatcher"))
+	}
+
+	if !p.atInvalidOrEOF() {
+		start := p.pt
+		p.read()
+		return p.sliceFrom(start), true
+	}
+	return nil, false
+}
+
+func (p *parser) parseBackRefExpr(ref *backRefExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseBackRefExpr"))
+	}
+
+	var text []byte
+	found := false
+	for i := len(p.vstack) - 1; i >= p.vbase && !found; i-- {
+		var v interface{}
+		if v, found = p.vstack[i]["="+ref.label]; found {
+			text = v.([]byte)
+		}
+	}
+	if !found {
+		// the label did not match in this rule
+		return nil, false
+	}
+
+	start := p.pt
+	for _, want := range string(text) {
+		if p.pt.rn != want {
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseByteMatcher(by *byteMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseByteMatcher"))
+	}
+
+	if p.pt.offset < len(p.data) && p.data[p.pt.offset] == by.val {
+		start := p.pt
+		p.skip(1)
+		return p.sliceFrom(start), true
+	}
+	return nil, false
+}
+
+func (p *parser) parseBytesMatcher(by *bytesMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseBytesMatcher"))
+	}
+
+	n := by.n
+	if by.label != "" {
+		var ok bool
+		if n, ok = p.labelInt(by.label); !ok || n < 0 {
+			p.addErr(fmt.Errorf("invalid number of bytes for label %s", by.label))
+			return nil, false
+		}
+	}
+	if len(p.data)-p.pt.offset < n {
+		return nil, false
+	}
+	start := p.pt
+	p.skip(n)
+	return p.sliceFrom(start), true
+}
+
+// labelInt returns the value of label in the current rule as an int, and
+// false if it is not set or is not an integer.
+func (p *parser) labelInt(label string) (int, bool) {
+	for i := len(p.vstack) - 1; i >= p.vbase; i-- {
+		v, ok := p.vstack[i][label]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case int:
+			return v, true
+		case int8:
+			return int(v), true
+		case int16:
+			return int(v), true
+		case int32:
+			return int(v), true
+		case int64:
+			return int(v), true
+		case uint:
+			return int(v), true
+		case uint8:
+			return int(v), true
+		case uint16:
+			return int(v), true
+		case uint32:
+			return int(v), true
+		case uint64:
+			return int(v), true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseCharClassMatcher"))
+	}
+
+	cur := p.pt.rn
+	// can't match EOF
+	if p.atInvalidOrEOF() {
+		return nil, false
+	}
+	start := p.pt
+	matched := chr.matches(cur)
+	if !matched && chr.ignoreCase {
+		// try the runes that are equivalent under simple case folding
+		matched = chr.matches(unicode.ToLower(cur))
+		for f := unicode.SimpleFold(cur); f != cur && !matched; f = unicode.SimpleFold(f) {
+			matched = chr.matches(f)
+		}
+	}
+	if matched == chr.inverted {
+		return nil, false
+	}
+	p.read()
+	return p.sliceFrom(start), true
+}
+
+// matches returns true if rn is in the chars, ranges or Unicode classes
+// of the character class, ignoring its inversion.
+func (chr *charClassMatcher) matches(rn rune) bool {
+	for _, c := range chr.chars {
+		if c == rn {
+			return true
+		}
+	}
+	for i := 0; i < len(chr.ranges); i += 2 {
+		if rn >= chr.ranges[i] && rn <= chr.ranges[i+1] {
+			return true
+		}
+	}
+	for _, cl := range chr.classes {
+		if unicode.Is(cl, rn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseChoiceExpr"))
+	}
+
+	for _, alt := range ch.alternatives {
+		p.pushV()
+		val, ok := p.parseExpr(alt)
+		p.popV()
+		if ok {
+			return val, ok
+		}
+	}
+	return nil, false
+}
+
+// parseFoldExpr folds the value of the sequence {first, {{op, operand}...}}
+// into binary operations, with the value []interface{}{left, op, right}.
+func (p *parser) parseFoldExpr(fold *foldExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseFoldExpr"))
+	}
+
+	val, ok := p.parseExpr(fold.expr)
+	if !ok {
+		return nil, false
+	}
+	vals := val.([]interface{})
+	rest, _ := vals[1].([]interface{})
+	if !fold.right {
+		left := vals[0]
+		for _, v := range rest {
+			pair := v.([]interface{})
+			left = []interface{}{left, pair[0], pair[1]}
+		}
+		return left, true
+	}
+
+	if len(rest) == 0 {
+		return vals[0], true
+	}
+	right := rest[len(rest)-1].([]interface{})[1]
+	for i := len(rest) - 1; i >= 0; i-- {
+		left := vals[0]
+		if i > 0 {
+			left = rest[i-1].([]interface{})[1]
+		}
+		right = []interface{}{left, rest[i].([]interface{})[0], right}
+	}
+	return right, true
+}
+
+func (p *parser) parseIndentMatcher(ind *indentMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseIndentMatcher " + ind.val))
+	}
+
+	// only match at the start of a line, never in token mode
+	if p.tokMode || p.pt.col != 1 && p.pt.offset < len(p.data) {
+		return nil, false
+	}
+	width := 0
+	for p.pt.offset+width < len(p.data) {
+		if b := p.data[p.pt.offset+width]; b != ' ' && b != '\t' {
+			break
+		}
+		width++
+	}
+	if p.pt.offset+width == len(p.data) {
+		// no more line, the indentation is back at the top level
+		width = 0
+	}
+	cur := 0
+	if p.pt.indents != nil {
+		cur = p.pt.indents.width
+	}
+
+	switch ind.val {
+	case "indent":
+		if width <= cur {
+			return nil, false
+		}
+		p.pt.indents = &indentLevel{width: width, prev: p.pt.indents}
+	case "samedent":
+		if width != cur {
+			return nil, false
+		}
+		start := p.pt
+		for p.pt.offset < start.offset+width {
+			p.read()
+		}
+		return p.sliceFrom(start), true
+	case "dedent":
+		if width >= cur {
+			return nil, false
+		}
+		p.pt.indents = p.pt.indents.prev
+	default:
+		panic(fmt.Sprintf("%s: invalid indentation matcher: %s", ind.pos, ind.val))
+	}
+	return nil, true
+}
+
+func (p *parser) parseLabeledExpr(lab *labeledExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLabeledExpr"))
+	}
+
+	start := p.pt
+	p.pushV()
+	val, ok := p.parseExpr(lab.expr)
+	p.popV()
+	if ok && lab.label != "" {
+		m := p.vstack[len(p.vstack)-1]
+		m[lab.label] = val
+		if lab.capture {
+			// the matched text is stored under a key that is not a valid
+			// label, for the back-references to the label
+			m["="+lab.label] = p.sliceFrom(start)
+		}
+	}
+	return val, ok
+}
+
+func (p *parser) parseKeywordMatcher(kw *keywordMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseKeywordMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	rest := p.data[p.pt.offset:]
+	n := 0
+	for _, word := range p.keywords {
+		if len(word) <= n || !bytes.HasPrefix(rest, []byte(word)) {
+			continue
+		}
+		rn, _ := utf8.DecodeRune(rest[len(word):])
+		if len(rest) > len(word) && (rn == '_' || unicode.IsLetter(rn) || unicode.IsDigit(rn)) {
+			continue
+		}
+		n = len(word)
+	}
+	if n == 0 {
+		return nil, false
+	}
+	start := p.pt
+	for p.pt.offset < start.offset+n {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitMatcher"))
+	}
+
+	start := p.pt
+	for _, want := range lit.val {
+		cur := p.pt.rn
+		if cur != want && !(lit.ignoreCase && foldEqual(cur, want)) {
+			p.setMaxSavePoint(string(p.sliceFrom(start))+string(cur), lit.val)
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+// foldEqual returns true if rn is equal to the lowercase rune want under
+// simple Unicode case folding, e.g. 'É' and 'é', or 'Σ', 'ς' and 'σ'.
+func foldEqual(rn, want rune) bool {
+	if unicode.ToLower(rn) == want {
+		return true
+	}
+	for f := unicode.SimpleFold(rn); f != rn; f = unicode.SimpleFold(f) {
+		if f == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotCodeExpr"))
+	}
+
+	ok, err := not.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, !ok
+}
+
+func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotExpr"))
+	}
+
+	pt := p.pt
+	p.pushV()
+	_, ok := p.parseExpr(not.expr)
+	p.popV()
+	p.restore(pt)
+	return nil, !ok
+}
+
+func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseOneOrMoreExpr"))
+	}
+
+	var vals []interface{}
+
+	for {
+		if !p.repeatWhile(expr.while, vals) {
+			if len(vals) == 0 {
+				return nil, false
+			}
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(expr.expr)
+		p.popV()
+		if !ok {
+			if len(vals) == 0 {
+				// did not match once, no match
+				return nil, false
+			}
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+func (p *parser) parseOperatorsExpr(ops *operatorsExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseOperatorsExpr"))
+	}
+
+	return p.parseOperatorsPrec(ops, 0)
+}
+
+// parseOperatorsPrec parses operands separated by operators of precedence
+// minPrec or higher using precedence climbing. A binary operation has the
+// value []interface{}{left, op, right}, where op is the matched operator.
+func (p *parser) parseOperatorsPrec(ops *operatorsExpr, minPrec int) (interface{}, bool) {
+	left, ok := p.parseExpr(ops.operand)
+	if !ok {
+		return nil, false
+	}
+
+	for {
+		pt := p.pt
+		var op *binaryOp
+		var opVal interface{}
+		for _, cur := range ops.ops {
+			if opVal, ok = p.parseLitMatcher(cur.lit); ok {
+				op = cur
+				break
+			}
+		}
+		if op == nil || op.prec < minPrec {
+			p.restore(pt)
+			return left, true
+		}
+
+		nextPrec := op.prec + 1
+		if op.rightAssoc {
+			nextPrec = op.prec
+		}
+		right, ok := p.parseOperatorsPrec(ops, nextPrec)
+		if !ok {
+			// the operator is not followed by an operand, it is not
+			// part of this expression.
+			p.restore(pt)
+			return left, true
+		}
+		left = []interface{}{left, opVal, right}
+	}
+}
+
+func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	}
+
+	if ref.name == "" {
+		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	}
+
+	rule := p.rules[ref.name]
+	if rule == nil {
+		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
+		return nil, false
+	}
+	return p.parseRule(rule)
+}
+
+// parseSepExpr parses one or more expressions separated by the separator,
+// its value is the slice of the values of the expressions.
+func (p *parser) parseSepExpr(sep *sepExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSepExpr"))
+	}
+
+	p.pushV()
+	val, ok := p.parseExpr(sep.expr)
+	p.popV()
+	if !ok {
+		return nil, false
+	}
+	vals := []interface{}{val}
+
+	for {
+		pt := p.pt
+		p.pushV()
+		_, ok := p.parseExpr(sep.sep)
+		p.popV()
+		if !ok {
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(sep.expr)
+		p.popV()
+		if !ok {
+			if !sep.trailing {
+				// the separator is not part of the list
+				p.restore(pt)
+			}
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+func (p *parser) parseSeqExpr(seq *seqExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSeqExpr"))
+	}
+
+	vals := make([]interface{}, 0, len(seq.exprs))
+
+	pt := p.pt
+	for _, expr := range seq.exprs {
+		val, ok := p.parseExpr(expr)
+		if !ok {
+			p.restore(pt)
+			return nil, false
+		}
+		vals = append(vals, val)
+	}
+	return vals, true
+}
+
+func (p *parser) parseSkipExpr(skip *skipExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSkipExpr"))
+	}
+
+	pt := p.pt
+	p.parseExpr(skip.skip)
+	val, ok := p.parseExpr(skip.expr)
+	if !ok {
+		p.restore(pt)
+		return nil, false
+	}
+	return val, true
+}
+
+func (p *parser) parseTokenMatcher(tm *tokenMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseTokenMatcher " + tm.name))
+	}
+
+	if !p.tokMode || p.pt.offset >= len(p.toks) {
+		p.setMaxSavePoint("", tm.name)
+		return nil, false
+	}
+	tok := p.toks[p.pt.offset]
+	if !tm.any && tok.Kind() != tm.kind {
+		p.setMaxSavePoint(tok.Text(), tm.name)
+		return nil, false
+	}
+	p.read()
+	return tok, true
+}
+
+// parseUnreservedExpr matches the expression of un, and fails if the text
+// of the match is one of the keywords.
+func (p *parser) parseUnreservedExpr(un *unreservedExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseUnreservedExpr"))
+	}
+
+	start := p.pt
+	val, ok := p.parseExpr(un.expr)
+	if !ok {
+		return nil, false
+	}
+	text := string(p.sliceFrom(start))
+	for _, word := range p.keywords {
+		if text == word {
+			p.restore(start)
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+func (p *parser) parseUntilMatcher(until *untilMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseUntilMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	// scan for the delimiter in a single pass, then advance rune by rune
+	// up to it so that the position information stays accurate.
+	start := p.pt
+	end := len(p.data)
+	if ix := bytes.Index(p.data[start.offset:], []byte(until.val)); ix >= 0 {
+		end = start.offset + ix
+	}
+	for p.pt.offset < end {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseZeroOrMoreExpr"))
+	}
+
+	var vals []interface{}
+
+	for {
+		if !p.repeatWhile(expr.while, vals) {
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(expr.expr)
+		p.popV()
+		if !ok {
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+// repeatWhile reports whether a repetition may try another match, given
+// its condition and the values accumulated so far.
+func (p *parser) repeatWhile(while func(*parser, []interface{}) (bool, error), vals []interface{}) bool {
+	if while == nil {
+		return true
+	}
+	ok, err := while(p, vals)
+	if err != nil {
+		p.addErr(err)
+	}
+	return ok
+}
+
+func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseZeroOrOneExpr"))
+	}
+
+	p.pushV()
+	val, _ := p.parseExpr(expr.expr)
+	p.popV()
+	// whether it matched or not, consider it a match
+	return val, true
+}
+
+func rangeTable(class string) *unicode.RangeTable {
+	if rt, ok := unicode.Categories[class]; ok {
+		return rt
+	}
+	if rt, ok := unicode.Properties[class]; ok {
+		return rt
+	}
+	if rt, ok := unicode.Scripts[class]; ok {
+		return rt
+	}
+
+	// cannot happen
+	panic(fmt.Sprintf("invalid Unicode class: %s", class))
+}
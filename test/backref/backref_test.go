@@ -0,0 +1,35 @@
+package backref
+
+import "testing"
+
+func TestBackRef(t *testing.T) {
+	for _, in := range []string{
+		"<p>x</p>",
+		"<p></p>",
+		"<div>a<b>c</div>",
+		"<<EOF\nline\nEOF",
+		"<<END\nEN\nEND",
+	} {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != in {
+			t.Errorf("%q: want %q, got %q", in, in, got)
+		}
+	}
+}
+
+func TestBackRefInvalid(t *testing.T) {
+	for _, in := range []string{
+		"<p>x</q>",
+		"<p>x</pp>",
+		"<pp>x</p>",
+		"<<EOF\nline\nEO",
+	} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package backtrack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxBacktrack(t *testing.T) {
+	in := []byte(strings.Repeat("a", 10))
+
+	// without a limit, the input is parsed successfully
+	if _, err := Parse("", in); err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+
+	_, err := Parse("", in, MaxBacktrack(20))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "rule A") || !strings.Contains(msg, "backtracked 21 times") {
+		t.Errorf("want error naming rule A, got %q", msg)
+	}
+}
+
+func TestMaxBacktrackNotReached(t *testing.T) {
+	in := []byte("aa")
+	if _, err := Parse("", in, MaxBacktrack(20)); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	// memoization avoids the repeated parses of rule A
+	in = []byte(strings.Repeat("a", 10))
+	if _, err := Parse("", in, MaxBacktrack(20), Memoize(true)); err != nil {
+		t.Errorf("want no error with memoization, got %v", err)
+	}
+}
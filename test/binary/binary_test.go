@@ -0,0 +1,49 @@
+package binary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	cases := []struct {
+		in   []byte
+		want []interface{}
+	}{
+		{[]byte{0x02, 0x01}, []interface{}{uint64(0x0102)}},
+		{
+			[]byte{0x01, 0x00, 0, 0, 0, 3, 'a', 'b', 'c', 0x0A, 0, 0, 0, 0, 0x0A},
+			[]interface{}{uint64(1), "abc", ""},
+		},
+		// the payload is not valid UTF-8, and contains the terminator
+		{
+			[]byte{0x01, 0x00, 0, 0, 0, 4, 0xe2, 0x0A, 0xff, 0x80, 0x0A},
+			[]interface{}{uint64(1), "\xe2\x0a\xff\x80"},
+		},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", tc.in)
+		if err != nil {
+			t.Errorf("%x: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%x: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestBinaryInvalid(t *testing.T) {
+	cases := [][]byte{
+		{0x01},
+		// length exceeds the input
+		{0x01, 0x00, 0, 0, 0, 5, 'a', 'b', 0x0A},
+		// missing terminator
+		{0x01, 0x00, 0, 0, 0, 1, 'a', 'b'},
+	}
+	for _, in := range cases {
+		if got, err := Parse("", in); err == nil {
+			t.Errorf("%x: want error, got %q", in, got)
+		}
+	}
+}
@@ -0,0 +1,3612 @@
+package budget
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var g = &grammar{
+	rules: []*rule{
+		{
+			name: "List",
+			pos:  position{line: 5, col: 1, offset: 20},
+			expr: &seqExpr{
+				pos: position{line: 5, col: 8, offset: 29},
+				exprs: []interface{}{
+					&ruleRefExpr{
+						pos:  position{line: 5, col: 8, offset: 29},
+						name: "Item",
+					},
+					&zeroOrMoreExpr{
+						pos: position{line: 5, col: 13, offset: 34},
+						expr: &seqExpr{
+							pos: position{line: 5, col: 15, offset: 36},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 5, col: 15, offset: 36},
+									val:        ",",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 5, col: 19, offset: 40},
+									name: "Item",
+								},
+							},
+						},
+					},
+					&notExpr{
+						pos: position{line: 5, col: 27, offset: 48},
+						expr: &anyMatcher{
+							line: 5, col: 28, offset: 49,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Item",
+			pos:  position{line: 7, col: 1, offset: 52},
+			expr: &choiceExpr{
+				pos: position{line: 7, col: 8, offset: 61},
+				alternatives: []interface{}{
+					&ruleRefExpr{
+						pos:  position{line: 7, col: 8, offset: 61},
+						name: "Word",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 7, col: 15, offset: 68},
+						name: "Loop",
+					},
+				},
+			},
+		},
+		{
+			name: "Word",
+			pos:  position{line: 9, col: 1, offset: 74},
+			expr: &oneOrMoreExpr{
+				pos: position{line: 9, col: 8, offset: 83},
+				expr: &charClassMatcher{
+					pos:        position{line: 9, col: 8, offset: 83},
+					val:        "[a-z]",
+					ranges:     []rune{'a', 'z'},
+					ignoreCase: false,
+					inverted:   false,
+				},
+			},
+		},
+		{
+			name:   "Loop",
+			budget: 50,
+			pos:    position{line: 11, col: 1, offset: 91},
+			expr: &seqExpr{
+				pos: position{line: 11, col: 20, offset: 112},
+				exprs: []interface{}{
+					&litMatcher{
+						pos:        position{line: 11, col: 20, offset: 112},
+						val:        "(",
+						ignoreCase: false,
+					},
+					&zeroOrMoreExpr{
+						pos: position{line: 11, col: 24, offset: 116},
+						expr: &seqExpr{
+							pos: position{line: 11, col: 26, offset: 118},
+							exprs: []interface{}{
+								&notExpr{
+									pos: position{line: 11, col: 26, offset: 118},
+									expr: &litMatcher{
+										pos:        position{line: 11, col: 27, offset: 119},
+										val:        ")",
+										ignoreCase: false,
+									},
+								},
+								&anyMatcher{
+									line: 11, col: 31, offset: 123,
+								},
+							},
+						},
+					},
+					&litMatcher{
+						pos:        position{line: 11, col: 36, offset: 128},
+						val:        ")",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
+	},
+}
+var defaultOptions []Option
+
+var (
+	// errNoRule is returned when the grammar to parse has no rule.
+	errNoRule = errors.New("grammar has no rule")
+
+	// errInvalidEncoding is returned when the source is not properly
+	// utf8-encoded.
+	errInvalidEncoding = errors.New("invalid encoding")
+
+	// errNoMatch is returned if no match could be found.
+	errNoMatch = errors.New("no match found")
+
+	// errInputTooLarge is returned when the input exceeds the limit set
+	// by the MaxInputRunes option.
+	errInputTooLarge = errors.New("input too large")
+
+	// errNumberRange is returned when a number matched by the Number
+	// matcher is out of the range of its type.
+	errNumberRange = errors.New("number out of range")
+
+	// errMaxDepth is returned when the rules are nested deeper than the
+	// limit set by the MaxDepth option.
+	errMaxDepth = errors.New("max depth exceeded")
+)
+
+// Option is a function that can set an option on the parser. It returns
+// the previous setting as an Option.
+type Option func(*parser) Option
+
+// Debug creates an Option to set the debug flag to b. When set to true,
+// debugging information is printed to stdout while parsing, or to the
+// Logger set by the WithLogger option.
+//
+// The default is false.
+func Debug(b bool) Option {
+	return func(p *parser) Option {
+		old := p.debug
+		p.debug = b
+		return Debug(old)
+	}
+}
+
+// Logger is the interface of the destination of the debugging information
+// of the Debug option, such as a *log.Logger or an adapter to another
+// logging package. Printf is called once for each line.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// WithLogger creates an Option to set the Logger of the debugging
+// information to l.
+//
+// The default is nil, the debugging information is printed to stdout.
+func WithLogger(l Logger) Option {
+	return func(p *parser) Option {
+		old := p.logger
+		p.logger = l
+		return WithLogger(old)
+	}
+}
+
+// Tracer is the interface of the tracer of the WithTracer option, such as
+// an adapter to an OpenTelemetry tracer. StartSpan is called when the
+// parse starts at the entrypoint rule, and the End method of the span it
+// returns when the parse ends, with the error of the parse.
+type Tracer interface {
+	StartSpan(rule string) Span
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	End(err error)
+}
+
+// WithTracer creates an Option to set the Tracer of the parse to t, which
+// records a span for the entrypoint rule of each parse.
+//
+// The default is nil, no span is recorded.
+func WithTracer(t Tracer) Option {
+	return func(p *parser) Option {
+		old := p.tracer
+		p.tracer = t
+		return WithTracer(old)
+	}
+}
+
+// Memoize creates an Option to set the memoize flag to b. When set to true,
+// the parser will cache all results so each expression is evaluated only
+// once. This guarantees linear parsing time even for pathological cases,
+// at the expense of more memory and slower times for typical cases.
+//
+// The default is false, unless the parser is generated with the
+// -default-memoize flag.
+func Memoize(b bool) Option {
+	return func(p *parser) Option {
+		old := p.memoize
+		p.memoize = b
+		return Memoize(old)
+	}
+}
+
+// MemoStore is the interface of the memoization table used when the
+// Memoize option is set, such as a table that logs its accesses or that
+// persists the results. Get returns the result stored by Set for the node
+// at offset, where node is an opaque key that identifies a rule or an
+// expression of the grammar. A MemoStore must not be shared by parses.
+type MemoStore interface {
+	Get(node interface{}, offset int) (MemoResult, bool)
+	Set(node interface{}, offset int, res MemoResult)
+}
+
+// MemoResult is the result of the match of a node at an offset, stored in
+// a MemoStore. Its content is private to the parser.
+type MemoResult struct {
+	tuple resultTuple
+	// end of the input examined for the result, set with the ReuseMemo
+	// option, and parse of the MemoCache that stored the result
+	reach int
+	gen   int
+}
+
+// MemoCache keeps the memoization table of a parse for the next parses
+// with the ReuseMemo option. Its zero value is an empty cache.
+type MemoCache struct {
+	data  []byte
+	table memoTable
+	gen   int
+	hits  int
+}
+
+// Hits returns the number of results of the last parse that were taken
+// from the previous parses.
+func (c *MemoCache) Hits() int { return c.hits }
+
+// prepare removes from the cache the results that depend on the input
+// after the common prefix of data and of the input of the previous parse,
+// and starts the parse of data. Only the results of the rules are kept:
+// those of the expressions do not bind the labels of the rule that is
+// parsed again.
+func (c *MemoCache) prepare(data []byte) {
+	n := 0
+	for n < len(data) && n < len(c.data) && data[n] == c.data[n] {
+		n++
+	}
+	if n == len(data) && n == len(c.data) {
+		// the end of the input is the same too
+		n++
+	}
+	for off, m := range c.table {
+		for node, res := range m {
+			_, isRule := node.(*rule)
+			end := res.tuple.end
+			// the logs of the previous parse are not kept
+			if !isRule || res.reach > n || end.owned > 0 || end.matched > 0 || end.warned > 0 ||
+				end.errored > 0 || end.evented > 0 {
+				delete(m, node)
+			}
+		}
+		if len(m) == 0 {
+			delete(c.table, off)
+		}
+	}
+	if c.table == nil {
+		c.table = make(memoTable)
+	}
+	c.data = append(c.data[:0], data...)
+	c.gen++
+	c.hits = 0
+}
+
+// cacheStore is the MemoStore of a MemoCache.
+type cacheStore struct {
+	c *MemoCache
+}
+
+func (s cacheStore) Get(node interface{}, offset int) (MemoResult, bool) {
+	res, ok := s.c.table.Get(node, offset)
+	if ok && res.gen != s.c.gen {
+		s.c.hits++
+	}
+	return res, ok
+}
+
+func (s cacheStore) Set(node interface{}, offset int, res MemoResult) {
+	res.gen = s.c.gen
+	s.c.table.Set(node, offset, res)
+}
+
+// ReuseMemo creates an Option to keep the memoization table of the parse
+// in c and to reuse the results of the previous parses with c, so that
+// inputs that share a long prefix are parsed faster. A result is reused
+// only if the part of the input that was examined to compute it is the
+// same, so that the result of the parse does not change, but the values of
+// the reused results may refer to the input of the previous parses. The
+// parses must use the same options, and c must not be used by concurrent
+// parses. It sets the Memoize option, and replaces the WithMemoStore
+// option. It has no effect when parsing tokens.
+//
+// The default is nil, the results are not reused.
+func ReuseMemo(c *MemoCache) Option {
+	return func(p *parser) Option {
+		old := p.memoCache
+		p.memoCache = c
+		return ReuseMemo(old)
+	}
+}
+
+// WithMemoStore creates an Option to set the memoization table to s.
+//
+// The default is nil, the results are stored in a map.
+func WithMemoStore(s MemoStore) Option {
+	return func(p *parser) Option {
+		old := p.memoStore
+		p.memoStore = s
+		return WithMemoStore(old)
+	}
+}
+
+// Flag creates an Option to set the flag identified by name to b. The
+// alternatives prefixed with "@when(name)" in the grammar only match if
+// the flag is set, so that a single parser can support several dialects.
+//
+// The default is false for all flags.
+func Flag(name string, b bool) Option {
+	return func(p *parser) Option {
+		old := p.flags[name]
+		if p.flags == nil {
+			p.flags = make(map[string]bool)
+		}
+		p.flags[name] = b
+		return Flag(name, old)
+	}
+}
+
+// Keywords creates an Option to set the words matched by the @keyword
+// matcher to words. The keyword matcher matches the longest of the words
+// found at the current position that is not immediately followed by a
+// letter, a digit or an underscore. The @unreserved expression fails if
+// its match is one of the words.
+//
+// The default is no word, the @keyword matcher never matches.
+func Keywords(words ...string) Option {
+	return func(p *parser) Option {
+		old := p.keywords
+		p.keywords = words
+		return Keywords(old...)
+	}
+}
+
+// SkipFunc creates an Option to set the function that decides the runes
+// skipped before the matchers and the references to lexical rules when the
+// parser is generated with a skip rule. If fn is not nil, the parser skips
+// the runes for which fn returns true instead of matching the skip rule,
+// so that what is skipped can be chosen at parse time. It has no effect
+// when parsing tokens.
+//
+// The default is nil, the skip rule is matched.
+func SkipFunc(fn func(rune) bool) Option {
+	return func(p *parser) Option {
+		old := p.skipFunc
+		p.skipFunc = fn
+		return SkipFunc(old)
+	}
+}
+
+// WordList creates an Option to set the words matched by the @wordlist
+// matcher to words. The words are stored in a trie when the option is
+// applied, so that the matcher finds the longest of the words at the
+// current position in a single pass over the input, whatever their number.
+// As for @keyword, a word does not match if it is immediately followed by
+// a letter, a digit or an underscore. The empty words are ignored.
+//
+// The default is no word, the @wordlist matcher never matches.
+func WordList(words ...string) Option {
+	return func(p *parser) Option {
+		old := p.wordList
+		p.wordList = words
+		p.wordTrie = newWordNode(words)
+		return WordList(old...)
+	}
+}
+
+// MaxBacktrack creates an Option to set the maximum number of times a
+// rule can backtrack to the same offset to n. When this limit is exceeded,
+// parsing stops with an error identifying the rule. This is useful during
+// development to detect ambiguous rules that result in exponential parsing
+// time. A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxBacktrack(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxBacktrack
+		p.maxBacktrack = n
+		return MaxBacktrack(old)
+	}
+}
+
+// MaxDepth creates an Option to set the maximum number of rules that can
+// be nested during the parse to n. When this limit is exceeded, parsing
+// stops with the errMaxDepth error, so that a deeply nested input against
+// a recursive grammar cannot overflow the stack, e.g. on a server. The
+// inlined rules do not count. A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxDepth(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxDepth
+		p.maxDepth = n
+		return MaxDepth(old)
+	}
+}
+
+// MaxInputRunes creates an Option to set the maximum size of the input to
+// n runes, or n tokens in token mode. A larger input is rejected with an
+// error before parsing, e.g. to protect a server from oversized documents.
+// A value of 0 disables the limit.
+//
+// The default is 0.
+func MaxInputRunes(n int) Option {
+	return func(p *parser) Option {
+		old := p.maxInputRunes
+		p.maxInputRunes = n
+		return MaxInputRunes(old)
+	}
+}
+
+// Ownership creates an Option to record in m the number of runes owned by
+// each rule in the successful parse, keyed by rule name. A rune is owned
+// by the innermost rule that matched it, so that the numbers of runes sum
+// to the number of runes consumed by the parse. The runes of the inlined
+// rules are owned by the referencing rule. The ownership is not accurate
+// if the Memoize option is set.
+//
+// The default is nil, the ownership is not recorded.
+func Ownership(m map[string]int) Option {
+	return func(p *parser) Option {
+		old := p.owned
+		p.owned = m
+		return Ownership(old)
+	}
+}
+
+// Statistics creates an Option to record in *s the number of times each
+// rule of the grammar matched and failed to match during the parse, whether
+// it succeeds or not. A rule that is tried often but rarely matches is a
+// candidate for reordering the alternatives of a choice. Rules that consist
+// of a single matcher are inlined where they are referenced and are never
+// tried. With the Memoize option, a result taken from the memoization table
+// is counted like a new attempt.
+//
+// The default is nil, the statistics are not recorded.
+func Statistics(s *Stats) Option {
+	return func(p *parser) Option {
+		old := p.stats
+		p.stats = s
+		return Statistics(old)
+	}
+}
+
+// TokenCounts creates an Option to record in m the number of times each
+// lexical rule of the grammar, marked with @lexical, matched during the
+// parse, keyed by rule name, e.g. to get a histogram of the identifiers and
+// numbers of the input. The counts are the success counts of the
+// Statistics option, so the matches that were backtracked over are
+// counted, and the inlined lexical rules are not counted.
+//
+// The default is nil, the counts are not recorded.
+func TokenCounts(m map[string]int) Option {
+	return func(p *parser) Option {
+		old := p.tokenCounts
+		p.tokenCounts = m
+		return TokenCounts(old)
+	}
+}
+
+// Ambiguities creates an Option to append to *a an Ambiguity for each
+// ordered choice at which more than one alternative matches at the same
+// offset, which the ordered choice silently resolves in favor of the first
+// one. This is an instrumented mode for debugging a grammar: once an
+// alternative matches, the next ones are tried too and then backtracked
+// over, so that their code blocks run and the parse is slower. A choice is
+// reported once per offset.
+//
+// The default is nil, the ambiguities are not recorded.
+func Ambiguities(a *[]Ambiguity) Option {
+	return func(p *parser) Option {
+		old := p.ambiguities
+		p.ambiguities = a
+		return Ambiguities(old)
+	}
+}
+
+// OnMatch creates an Option to set the function called for each match of
+// a rule in the successful parse, with the name of the rule, the start and
+// end positions of the match and its value. The matches of rules that were
+// backtracked over are not reported. The function is called in the order
+// the matches completed, once the input is parsed, so the matches of the
+// rules referenced by a rule are reported before the match of that rule.
+// The inlined rules are not reported. The matches are not accurate if the
+// Memoize option is set.
+//
+// The default is nil, the matches are not reported.
+func OnMatch(fn func(rule string, start, end Pos, value interface{})) Option {
+	return func(p *parser) Option {
+		old := p.onMatch
+		p.onMatch = fn
+		return OnMatch(old)
+	}
+}
+
+// Events creates an Option to set the function called for the events of
+// the successful parse, to process the matches of the rules without
+// building a value for the whole input. The match of a rule is reported as
+// an EventStart, followed by the events of the rules it references and an
+// EventText for the text matched by each of its matchers, followed by an
+// EventEnd. The events of the rules that were backtracked over are not
+// reported. The function is called in the order of the events, once the
+// input is parsed. The inlined rules are not reported. The events are not
+// accurate if the Memoize option is set.
+//
+// The default is nil, the events are not reported.
+func Events(fn func(Event)) Option {
+	return func(p *parser) Option {
+		old := p.events
+		p.events = fn
+		return Events(old)
+	}
+}
+
+// Warnings creates an Option to set *w to the warnings recorded by the code
+// blocks of the grammar with c.warn in the successful parse, in the order
+// they were recorded. The warnings are not accurate if the Memoize option
+// is set.
+//
+// The default is nil, the warnings are not returned.
+func Warnings(w *[]Warning) Option {
+	return func(p *parser) Option {
+		old := p.warnings
+		p.warnings = w
+		return Warnings(old)
+	}
+}
+
+// Trace creates an Option to append to *t a line for each rule and each
+// expression evaluated by the parser, in the order of evaluation. A line
+// is the kind of expression, e.g. "litMatcher", or "rule" and the name of
+// the rule, followed by the position where it is evaluated. Comparing the
+// trace to a known one tells if the code generated for a grammar still
+// parses its input the same way. Memoized results are not evaluated again
+// and are not in the trace.
+//
+// The default is nil, no trace is recorded.
+func Trace(t *[]string) Option {
+	return func(p *parser) Option {
+		old := p.trace
+		p.trace = t
+		return Trace(old)
+	}
+}
+
+// TextNormalizer creates an Option to set the function that rewrites the
+// text of the current match before the action code blocks run to fn, e.g.
+// to lowercase the identifiers or to normalize them to Unicode NFC. The
+// function is called with the name of the rule of the action and the text
+// matched by its expression, and c.text is set to the text it returns.
+// The input and the positions are not changed.
+//
+// The default is nil, the text is not rewritten.
+func TextNormalizer(fn func(rule, text string) string) Option {
+	return func(p *parser) Option {
+		old := p.normalizer
+		p.normalizer = fn
+		return TextNormalizer(old)
+	}
+}
+
+// Transform creates an Option to set the function that transforms the
+// value of the rule named rule to fn. The function is called with the
+// value of each match of the rule, after its action, and its result
+// replaces that value. If it returns an error, the match fails and the
+// error is added to the list of errors. The values of the inlined rules
+// described in the package documentation are not transformed. A nil fn
+// removes the transformation.
+//
+// The default is no transformation.
+func Transform(rule string, fn func(interface{}) (interface{}, error)) Option {
+	return func(p *parser) Option {
+		old := p.transforms[rule]
+		if p.transforms == nil {
+			p.transforms = make(map[string]func(interface{}) (interface{}, error))
+		}
+		p.transforms[rule] = fn
+		if fn == nil {
+			delete(p.transforms, rule)
+		}
+		return Transform(rule, old)
+	}
+}
+
+// ContextLines creates an Option to set the number of context lines of
+// the error messages to n. When n is 0 or more, the message of each error
+// is followed by the line of the input where it occurred, up to n lines
+// before and after it, and a line with a caret (^) under the column of
+// the error. The lines are prefixed with their line number.
+//
+// The default is -1, the messages have no context.
+func ContextLines(n int) Option {
+	return func(p *parser) Option {
+		old := p.contextLines
+		p.contextLines = n
+		return ContextLines(old)
+	}
+}
+
+// SkipBOM creates an Option to set the skip BOM flag to b. When set to
+// true, a byte order mark (U+FEFF) at the start of the input is removed
+// before parsing, after the input is decoded if the Encoding option is
+// set.
+//
+// The default is false.
+func SkipBOM(b bool) Option {
+	return func(p *parser) Option {
+		old := p.skipBOM
+		p.skipBOM = b
+		return SkipBOM(old)
+	}
+}
+
+// NormalizeNewlines creates an Option to set the normalize newlines flag
+// to b. When set to true, the "\r\n" and "\r" line endings of the input
+// are converted to "\n" before parsing, after the input is decoded, so that
+// the grammar only has to match "\n". The offsets of the positions of the
+// errors, of the warnings and of the OnMatch and Events functions refer to
+// the input before the conversion, the positions of the matches in the
+// code blocks refer to the converted input.
+//
+// The default is false.
+func NormalizeNewlines(b bool) Option {
+	return func(p *parser) Option {
+		old := p.normalize
+		p.normalize = b
+		return NormalizeNewlines(old)
+	}
+}
+
+// AssumeValidUTF8 creates an Option to set the assume valid UTF-8 flag to
+// b. When set to true, the input is trusted to be valid UTF-8: it is not
+// validated as it is read, and ASCII characters are decoded without a call
+// to the utf8 package, which is faster for ASCII-heavy input. The result of
+// the parse of an invalid input is undefined.
+//
+// The default is false.
+func AssumeValidUTF8(b bool) Option {
+	return func(p *parser) Option {
+		old := p.assumeValid
+		p.assumeValid = b
+		return AssumeValidUTF8(old)
+	}
+}
+
+// Encoding creates an Option to set the encoding of the input to enc, one
+// of "utf-8", "utf-16le", "utf-16be", "utf-16" (big endian unless the input
+// starts with a little endian byte order mark) and "latin1" (ISO-8859-1).
+// The input is decoded to UTF-8 before parsing, so the positions and the
+// text of the matches refer to the decoded input. An unknown encoding is
+// reported as an error of the parse.
+//
+// The default is "utf-8", the input is not decoded.
+func Encoding(enc string) Option {
+	return func(p *parser) Option {
+		old := p.encoding
+		p.encoding = enc
+		return Encoding(old)
+	}
+}
+
+// Decoder creates an Option to set the function that decodes the input to
+// fn, for encodings that the Encoding option does not support. The input
+// is decoded to the runes returned by fn before parsing, and the Encoding
+// option is ignored. An error returned by fn is reported as an error of
+// the parse.
+//
+// The default is nil, the input is decoded according to the Encoding
+// option.
+func Decoder(fn func([]byte) ([]rune, error)) Option {
+	return func(p *parser) Option {
+		old := p.decoder
+		p.decoder = fn
+		return Decoder(old)
+	}
+}
+
+// Recover creates an Option to set the recover flag to b. When set to
+// true, this causes the parser to recover from panics and convert it
+// to an error. Setting it to false can be useful while debugging to
+// access the full stack trace.
+//
+// The default is true.
+func Recover(b bool) Option {
+	return func(p *parser) Option {
+		old := p.recover
+		p.recover = b
+		return Recover(old)
+	}
+}
+
+// ParseFile parses the file identified by filename.
+func ParseFile(filename string, opts ...Option) (interface{}, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseReader(filename, f, opts...)
+}
+
+// ParseReader parses the data from r using filename as information in the
+// error messages.
+func ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(filename, b, opts...)
+}
+
+// Parse parses the data from b using filename as information in the
+// error messages.
+func Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+	return newParser(filename, b, opts...).parse(g)
+}
+
+// ParsePartial parses the data from b like Parse, and also returns the
+// remainder of b that follows the match of the start rule. The start rule
+// does not have to match all of b, unless it ends with a not predicate on
+// the any matcher (!.). The remainder is nil if there is an error, and it
+// is decoded to UTF-8 if the Encoding option is set.
+func ParsePartial(filename string, b []byte, opts ...Option) (interface{}, []byte, error) {
+	p := newParser(filename, b, opts...)
+	val, err := p.parse(g)
+	if err != nil {
+		return val, nil, err
+	}
+	return val, p.data[p.pt.offset:], nil
+}
+
+// Parser parses inputs with a set of options fixed at construction. It is
+// safe for concurrent use by multiple goroutines: the grammar is shared
+// read-only, and the state of a parse, including the memoization cache,
+// is confined to the call.
+type Parser struct {
+	opts []Option
+}
+
+// NewParser returns a Parser that applies the options opts to each parse.
+func NewParser(opts ...Option) *Parser {
+	return &Parser{opts: append([]Option(nil), opts...)}
+}
+
+// Parse parses the data from b like the package's Parse function. The
+// options opts are applied after those of the Parser.
+func (pr *Parser) Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+	all := make([]Option, 0, len(pr.opts)+len(opts))
+	all = append(append(all, pr.opts...), opts...)
+	return Parse(filename, b, all...)
+}
+
+// ParseReader reads all the data from r and parses it like Parse.
+func (pr *Parser) ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return pr.Parse(filename, b, opts...)
+}
+
+// Token is a token of the input of ParseTokens, as produced by an external
+// lexer. The @token matchers of the grammar match the tokens by kind.
+type Token interface {
+	Kind() int
+	Text() string
+	Pos() TokenPos
+}
+
+// TokenPos is the position of a token in the input of the lexer.
+type TokenPos struct {
+	Line, Col, Offset int
+}
+
+// ParseTokens parses the tokens toks using filename as information in the
+// error messages. The positions in the error messages are those of the
+// tokens. Only the @token matchers and the predicates match the tokens,
+// the value of a @token matcher is the Token it matched and the text of a
+// match is the concatenation of the text of its tokens.
+func ParseTokens(filename string, toks []Token, opts ...Option) (interface{}, error) {
+	p := newParser(filename, nil, opts...)
+	p.tokMode = true
+	p.toks = toks
+	return p.parse(g)
+}
+
+// Pos is the position of a match reported to the OnMatch function.
+type Pos struct {
+	Line, Col, Offset int
+}
+
+// position records a position in the text.
+type position struct {
+	line, col, offset int
+}
+
+func (p position) String() string {
+	return fmt.Sprintf("%d:%d [%d]", p.line, p.col, p.offset)
+}
+
+// savepoint stores all state required to go back to this point in the
+// parser.
+type savepoint struct {
+	position
+	rn rune
+	w  int
+
+	// indentation levels, nil at the top level
+	indents *indentLevel
+	// length of the ownership log
+	owned int
+	// length of the log of matches reported to OnMatch
+	matched int
+	// length of the log of warnings
+	warned int
+	// length of the log of the errors of the error productions
+	errored int
+	// length of the log of events
+	evented int
+}
+
+// indentLevel is an immutable stack of indentation widths, so that it is
+// restored along with the savepoint when the parser backtracks.
+type indentLevel struct {
+	width int
+	prev  *indentLevel
+}
+
+type current struct {
+	pos  position // start position of the match
+	end  position // end position of the match
+	text []byte   // raw text of the match
+
+	// parser of the match, for the warn method
+	parser *parser
+}
+
+// warn records a warning with the message msg at the start position of the
+// current match. The warnings of the successful parse are returned with the
+// Warnings option, the warnings of the matches that were backtracked over
+// are dropped.
+func (cur *current) warn(msg string) {
+	p := cur.parser
+	w := Warning{Pos: p.exportPos(cur.pos), Msg: msg}
+	p.warnLog = append(p.warnLog[:p.pt.warned], w)
+	p.pt.warned = len(p.warnLog)
+}
+
+// parseRule parses text starting at the rule name, like the ParseX
+// function of an entrypoint, e.g. to parse the contents of a string
+// captured by the current match with another rule. The sub-parse has the
+// flags, the keywords, the word list and the skip function of the current
+// parse, and the remaining depth of the MaxDepth option, but not its other
+// options. The positions in its errors are relative to text.
+func (cur *current) parseRule(name, text string) (interface{}, error) {
+	p := cur.parser
+	sub := newParser(p.filename, []byte(text))
+	sub.entry = name
+	sub.debug = p.debug
+	sub.logger = p.logger
+	sub.recover = p.recover
+	sub.flags = p.flags
+	sub.keywords = p.keywords
+	sub.wordList = p.wordList
+	sub.wordTrie = p.wordTrie
+	sub.skipFunc = p.skipFunc
+	if p.maxDepth > 0 {
+		sub.maxDepth = p.maxDepth - len(p.rstack)
+		if sub.maxDepth <= 0 {
+			panic(errMaxDepth)
+		}
+	}
+	return sub.parse(p.grammar)
+}
+
+// span returns the start and end positions of the match of the label in
+// the code block. The positions are only recorded if the parser is
+// generated with the -capture-spans flag, they are the zero Pos otherwise,
+// as for a label that is not in scope.
+func (cur *current) span(label string) (start, end Pos) {
+	p := cur.parser
+	sp, ok := p.vstack[len(p.vstack)-1]["@"+label].([2]position)
+	if !ok {
+		return Pos{}, Pos{}
+	}
+	return p.exportPos(sp[0]), p.exportPos(sp[1])
+}
+
+// error returns an error with the message msg at the start position of the
+// current match, for the error productions of the grammar. An action code
+// block that returns it matches with the value returned with it, so that
+// the parse continues, and the error is returned by the parse along with
+// its value. The errors of the matches that were backtracked over are
+// dropped.
+func (cur *current) error(msg string) error {
+	p := cur.parser
+	var rule *rule
+	if len(p.rstack) > 0 {
+		rule = p.rstack[len(p.rstack)-1]
+	}
+	return &productionError{msg: msg, pos: cur.pos, rule: rule}
+}
+
+// productionError is an error returned by c.error, recorded in the log of
+// the errors of the error productions.
+type productionError struct {
+	msg  string
+	pos  position
+	rule *rule
+}
+
+func (e *productionError) Error() string {
+	return e.msg
+}
+
+// Stats holds the statistics of a parse, recorded with the Statistics
+// option.
+type Stats struct {
+	// Rules has the counts of each rule, in the order of the grammar.
+	Rules []RuleStats
+}
+
+// RuleStats holds the number of times the rule Name matched and failed to
+// match.
+type RuleStats struct {
+	Name    string
+	Success int
+	Fail    int
+}
+
+// Ambiguity is an ordered choice of a rule at which several alternatives
+// match at the same position, returned with the Ambiguities option. Alts
+// are the numbers of the alternatives that match, starting at 1.
+type Ambiguity struct {
+	Rule string
+	Pos  Pos
+	Alts []int
+}
+
+// String returns the ambiguity formatted as its position, its rule and the
+// alternatives that match.
+func (a Ambiguity) String() string {
+	return fmt.Sprintf("%d:%d (%d): rule %s: alternatives %v match", a.Pos.Line, a.Pos.Col, a.Pos.Offset, a.Rule, a.Alts)
+}
+
+// Warning is a warning recorded by a code block of the grammar, returned
+// with the Warnings option.
+type Warning struct {
+	Pos Pos
+	Msg string
+}
+
+// String returns the warning formatted as its position and its message.
+func (w Warning) String() string {
+	return fmt.Sprintf("%d:%d (%d): %s", w.Pos.Line, w.Pos.Col, w.Pos.Offset, w.Msg)
+}
+
+// EventKind is the kind of an event reported to the Events function.
+type EventKind int
+
+// The kinds of events.
+const (
+	// EventStart is the start of the match of a rule.
+	EventStart EventKind = iota
+	// EventEnd is the end of the match of a rule.
+	EventEnd
+	// EventText is the text matched by a matcher of a rule.
+	EventText
+)
+
+var eventKindNames = [...]string{
+	EventStart: "start",
+	EventEnd:   "end",
+	EventText:  "text",
+}
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	if k >= 0 && int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event is an event of the parse reported to the Events function. Pos is
+// the position of the event, the start or the end of the match of the rule
+// named Rule, or the start of the text. Text is the matched text of an
+// EventText, and is empty for the other kinds.
+type Event struct {
+	Kind EventKind
+	Rule string
+	Pos  Pos
+	Text string
+}
+
+// the AST types...
+
+type grammar struct {
+	pos   position
+	rules []*rule
+}
+
+type rule struct {
+	pos         position
+	name        string
+	displayName string
+	lexical     bool
+	budget      int
+	expr        interface{}
+}
+
+type choiceExpr struct {
+	pos          position
+	alternatives []interface{}
+}
+
+type actionExpr struct {
+	pos  position
+	expr interface{}
+	run  func(*parser) (interface{}, error)
+}
+
+type seqExpr struct {
+	pos   position
+	exprs []interface{}
+}
+
+type labeledExpr struct {
+	pos     position
+	label   string
+	capture bool
+	span    bool
+	expr    interface{}
+}
+
+type backRefExpr struct {
+	pos   position
+	label string
+}
+
+type expr struct {
+	pos  position
+	expr interface{}
+}
+
+type andExpr expr
+type notExpr expr
+type zeroOrOneExpr expr
+type zeroOrMoreExpr struct {
+	pos   position
+	expr  interface{}
+	while func(*parser, []interface{}) (bool, error)
+}
+
+type oneOrMoreExpr struct {
+	pos   position
+	expr  interface{}
+	while func(*parser, []interface{}) (bool, error)
+}
+
+type ruleRefExpr struct {
+	pos  position
+	name string
+}
+
+type operatorsExpr struct {
+	pos     position
+	operand interface{}
+	ops     []*binaryOp
+}
+
+type unreservedExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type compactExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type arrayExpr struct {
+	pos     position
+	typ     string
+	collect func(*parser, *arrayExpr) (interface{}, interface{}, bool)
+	expr    interface{}
+}
+
+type sepExpr struct {
+	pos      position
+	expr     interface{}
+	sep      interface{}
+	trailing bool
+	keep     bool
+}
+
+type foldExpr struct {
+	pos   position
+	expr  interface{}
+	right bool
+}
+
+type whenExpr struct {
+	pos  position
+	flag string
+	expr interface{}
+}
+
+type binaryOp struct {
+	lit        *litMatcher
+	prec       int
+	rightAssoc bool
+}
+
+type andCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+type notCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+type litMatcher struct {
+	pos        position
+	val        string
+	ignoreCase bool
+}
+
+// litSetMatcher matches the first of its literals that matches, in a
+// single pass over the input. It replaces a choice of literals, or a
+// sequence of literals that ends with a choice of literals, in which case
+// parts has the number of runes of each expression of the sequence for
+// each literal, so that the value is that of the sequence.
+type litSetMatcher struct {
+	pos   position
+	alts  []*litMatcher
+	parts [][]int
+}
+
+type charClassMatcher struct {
+	pos        position
+	val        string
+	chars      []rune
+	ranges     []rune
+	classes    []*unicode.RangeTable
+	ignoreCase bool
+	inverted   bool
+}
+
+type anyMatcher position
+
+type lookbehindExpr struct {
+	pos  position
+	expr interface{}
+}
+
+type untilMatcher struct {
+	pos position
+	val string
+}
+
+type nestedMatcher struct {
+	pos   position
+	open  string
+	close string
+}
+
+type keywordMatcher position
+
+type wordListMatcher position
+
+// wordNode is a node of the trie of the words of the WordList option,
+// with word set if the bytes that lead to it form one of the words.
+type wordNode struct {
+	next map[byte]*wordNode
+	word bool
+}
+
+// newWordNode returns the root of the trie of words, nil if there is no
+// word.
+func newWordNode(words []string) *wordNode {
+	var root *wordNode
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if root == nil {
+			root = &wordNode{}
+		}
+		n := root
+		for i := 0; i < len(word); i++ {
+			if n.next == nil {
+				n.next = make(map[byte]*wordNode)
+			}
+			next := n.next[word[i]]
+			if next == nil {
+				next = &wordNode{}
+				n.next[word[i]] = next
+			}
+			n = next
+		}
+		n.word = true
+	}
+	return root
+}
+
+type restOfLineMatcher position
+
+type numberMatcher struct {
+	pos    position
+	float  bool
+	sign   bool
+	prefix bool
+	radix  int
+}
+
+type skipExpr struct {
+	pos  position
+	skip interface{}
+	expr interface{}
+}
+
+type tokenMatcher struct {
+	pos  position
+	kind int
+	name string
+	any  bool
+}
+
+type byteMatcher struct {
+	pos position
+	val byte
+}
+
+type bytesMatcher struct {
+	pos   position
+	n     int
+	label string
+}
+
+type indentMatcher struct {
+	pos position
+	val string
+}
+
+// bigEndianUint returns the unsigned integer encoded in big-endian order in
+// b, which is at most 8 bytes long. It can be used in the code blocks of
+// grammars for binary formats.
+func bigEndianUint(b []byte) uint64 {
+	var n uint64
+	for _, by := range b {
+		n = n<<8 | uint64(by)
+	}
+	return n
+}
+
+// littleEndianUint returns the unsigned integer encoded in little-endian
+// order in b, which is at most 8 bytes long. It can be used in the code
+// blocks of grammars for binary formats.
+func littleEndianUint(b []byte) uint64 {
+	var n uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		n = n<<8 | uint64(b[i])
+	}
+	return n
+}
+
+// errList cumulates the errors found by the parser.
+type errList []error
+
+func (e *errList) add(err error) {
+	*e = append(*e, err)
+}
+
+func (e errList) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	e.dedupe()
+	return e
+}
+
+func (e *errList) dedupe() {
+	var cleaned []error
+	set := make(map[string]bool)
+	for _, err := range *e {
+		if msg := err.Error(); !set[msg] {
+			set[msg] = true
+			cleaned = append(cleaned, err)
+		}
+	}
+	*e = cleaned
+}
+
+func (e errList) Error() string {
+	switch len(e) {
+	case 0:
+		return ""
+	case 1:
+		return e[0].Error()
+	default:
+		var buf bytes.Buffer
+
+		for i, err := range e {
+			if i > 0 {
+				buf.WriteRune('\n')
+			}
+			buf.WriteString(err.Error())
+		}
+		return buf.String()
+	}
+}
+
+// parserError wraps an error with a prefix indicating the rule in which
+// the error occurred. The original error is stored in the Inner field.
+type parserError struct {
+	Inner   error
+	pos     position
+	prefix  string
+	context string
+}
+
+// Error returns the error message.
+func (p *parserError) Error() string {
+	return p.prefix + ": " + p.Inner.Error() + p.context
+}
+
+// newParser creates a parser with the specified input source and options.
+func newParser(filename string, b []byte, opts ...Option) *parser {
+	p := &parser{
+		filename:     filename,
+		errs:         new(errList),
+		data:         b,
+		pt:           savepoint{position: position{line: 1}},
+		recover:      true,
+		contextLines: -1,
+	}
+	p.cur.parser = p
+	p.setOptions(defaultOptions)
+	p.setOptions(opts)
+	return p
+}
+
+// setOptions applies the options to the parser.
+func (p *parser) setOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+type resultTuple struct {
+	v   interface{}
+	b   bool
+	end savepoint
+}
+
+// ownEntry records the number of runes owned by a rule in a match. The
+// cum field is the total of runes owned by all entries up to this one.
+type ownEntry struct {
+	rule  *rule
+	runes int
+	cum   int
+}
+
+// matchEntry records a match of a rule, reported to OnMatch.
+type matchEntry struct {
+	rule       *rule
+	start, end position
+	val        interface{}
+}
+
+type backtrackKey struct {
+	rule   *rule
+	offset int
+}
+
+type parser struct {
+	filename string
+	pt       savepoint
+	cur      current
+
+	// errors
+	maxSavePoint savepoint
+	maxFound     string
+	maxExpected  []string
+
+	data []byte
+	errs *errList
+
+	// number of lines of input in the error messages, -1 for none
+	contextLines int
+
+	// encoding of data, decoded before parsing, or the function that
+	// decodes it, and whether a leading byte order mark is removed
+	encoding string
+	decoder  func([]byte) ([]rune, error)
+	skipBOM  bool
+
+	// whether the line endings are converted to "\n", and the offsets in
+	// the converted input of the "\n" that replaced a "\r\n"
+	normalize bool
+	crlfs     []int
+
+	// whether the input is trusted to be valid UTF-8
+	assumeValid bool
+
+	recover bool
+	debug   bool
+	depth   int
+	logger  Logger
+	tracer  Tracer
+
+	memoize bool
+	// memoization table for the packrat algorithm, set by WithMemoStore
+	// or a memoTable
+	memoStore MemoStore
+	// cache of the ReuseMemo option, and end of the input examined by the
+	// current node
+	memoCache *MemoCache
+	reach     int
+
+	// number of runes owned by rule, and the log of matches
+	owned  map[string]int
+	ownLog []ownEntry
+
+	// function called for the matches of the rules, and the log of matches
+	onMatch  func(string, Pos, Pos, interface{})
+	matchLog []matchEntry
+
+	// function called for the events of the parse, and the log of events
+	events   func(Event)
+	eventLog []Event
+
+	// destination of the warnings, and the log of warnings
+	warnings *[]Warning
+	warnLog  []Warning
+	// log of the errors of the error productions
+	errLog []*productionError
+	// prefixes of the errors raised in each rule, "rule NAME"
+	rulePrefixes map[*rule]string
+
+	// destination of the trace of the evaluated expressions
+	trace *[]string
+
+	// words matched by the keyword matcher
+	keywords []string
+
+	// function that decides the runes skipped instead of the skip rule
+	skipFunc func(rune) bool
+
+	// words matched by the word list matcher, and their trie
+	wordList []string
+	wordTrie *wordNode
+
+	// flags of the @when expressions that are set
+	flags map[string]bool
+
+	// functions that transform the value of the rules, by rule name
+	transforms map[string]func(interface{}) (interface{}, error)
+	// function that rewrites the text of the matches of the actions
+	normalizer func(string, string) string
+
+	// input tokens of ParseTokens, the offset of the position is the
+	// index of the current token in token mode
+	tokMode bool
+	toks    []Token
+
+	maxBacktrack int
+	// number of times each rule backtracked to an offset
+	backtracks map[backtrackKey]int
+
+	// maximum number of runes of the input, 0 for no limit
+	maxInputRunes int
+	// maximum number of nested rules, 0 for no limit
+	maxDepth int
+
+	// name of the start rule, the first rule of the grammar if empty
+	entry string
+
+	// grammar of the parse, for the sub-parses of the code blocks
+	grammar *grammar
+	// rules table, maps the rule identifier to the rule node
+	rules map[string]*rule
+	// variables stack, map of label to value
+	vstack []map[string]interface{}
+	// index in vstack of the variable set of the current rule
+	vbase int
+	// rule stack, allows identification of the current rule in errors
+	rstack []*rule
+
+	// stats
+	exprCnt int
+	// the budgeted rule whose budget ends first, and the expression count
+	// at which it ends
+	budgetRule *rule
+	budgetEnd  int
+	// destination of the statistics of the rules, and the counts of each
+	// rule in it
+	stats     *Stats
+	ruleStats map[*rule]*RuleStats
+	// destination of the counts of the lexical rules
+	tokenCounts map[string]int
+
+	// destination of the ambiguities, and the choices already reported at
+	// each offset
+	ambiguities *[]Ambiguity
+	ambiguous   map[ambiguityKey]bool
+}
+
+// ambiguityKey identifies an ordered choice at an offset of the input.
+type ambiguityKey struct {
+	choice *choiceExpr
+	offset int
+}
+
+func (p *parser) setMaxSavePoint(current string, expected string) {
+	if p.pt.offset > p.maxSavePoint.offset {
+		p.maxFound = current
+		p.maxSavePoint = p.pt
+		p.maxExpected = []string{expected}
+	} else if p.pt.offset == p.maxSavePoint.offset {
+		for _, e := range p.maxExpected {
+			if e == expected {
+				return
+			}
+		}
+		p.maxExpected = append(p.maxExpected, expected)
+	}
+}
+
+// push a variable set on the vstack.
+func (p *parser) pushV() {
+	if cap(p.vstack) == len(p.vstack) {
+		// create new empty slot in the stack
+		p.vstack = append(p.vstack, nil)
+	} else {
+		// slice to 1 more
+		p.vstack = p.vstack[:len(p.vstack)+1]
+	}
+
+	// get the last args set
+	m := p.vstack[len(p.vstack)-1]
+	if m != nil && len(m) == 0 {
+		// empty map, all good
+		return
+	}
+
+	m = make(map[string]interface{})
+	p.vstack[len(p.vstack)-1] = m
+}
+
+// pop a variable set from the vstack.
+func (p *parser) popV() {
+	// if the map is not empty, clear it
+	m := p.vstack[len(p.vstack)-1]
+	if len(m) > 0 {
+		// GC that map
+		p.vstack[len(p.vstack)-1] = nil
+	}
+	p.vstack = p.vstack[:len(p.vstack)-1]
+}
+
+func (p *parser) print(prefix, s string) string {
+	if !p.debug {
+		return s
+	}
+
+	if p.logger != nil {
+		p.logger.Printf("%s %d:%d:%d: %s [%#U]",
+			prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+		return s
+	}
+	fmt.Printf("%s %d:%d:%d: %s [%#U]\n",
+		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+	return s
+}
+
+func (p *parser) traceExpr(s string) {
+	*p.trace = append(*p.trace, fmt.Sprintf("%s %d:%d", s, p.pt.line, p.pt.col))
+}
+
+func (p *parser) in(s string) string {
+	p.depth++
+	return p.print(strings.Repeat(" ", p.depth)+">", s)
+}
+
+func (p *parser) out(s string) string {
+	p.depth--
+	return p.print(strings.Repeat(" ", p.depth)+"<", s)
+}
+
+func (p *parser) addErr(err error) {
+	p.addErrAt(err, p.pt.position)
+}
+
+func (p *parser) addErrAt(err error, pos position) {
+	var rule *rule
+	if len(p.rstack) > 0 {
+		rule = p.rstack[len(p.rstack)-1]
+	}
+	p.addRuleErrAt(err, pos, rule)
+}
+
+// addRuleErrAt adds err at position pos to the list of errors, prefixed
+// with the name of rule unless it is nil.
+func (p *parser) addRuleErrAt(err error, pos position, rule *rule) {
+	var context string
+	if p.contextLines >= 0 && !p.tokMode {
+		context = p.errContext(pos.offset)
+	}
+	pos.offset = p.origOffset(pos.offset)
+
+	var buf bytes.Buffer
+	if p.filename != "" {
+		buf.WriteString(p.filename)
+	}
+	if buf.Len() > 0 {
+		buf.WriteString(":")
+	}
+	fmt.Fprintf(&buf, "%d:%d (%d)", pos.line, pos.col, pos.offset)
+	if rule != nil {
+		if buf.Len() > 0 {
+			buf.WriteString(": ")
+		}
+		buf.WriteString(p.ruleErrPrefix(rule))
+	}
+	p.errs.add(&parserError{Inner: err, pos: pos, prefix: buf.String(), context: context})
+}
+
+// ruleErrPrefix returns the prefix of the errors raised in rule r, with its
+// display name if it has one. It is formatted once per rule and parse, as
+// errors are raised repeatedly in the same rules when the parser
+// backtracks.
+func (p *parser) ruleErrPrefix(r *rule) string {
+	if s, ok := p.rulePrefixes[r]; ok {
+		return s
+	}
+	nm := r.name
+	if r.displayName != "" {
+		nm = r.displayName
+	}
+	if p.rulePrefixes == nil {
+		p.rulePrefixes = make(map[*rule]string)
+	}
+	s := "rule " + nm
+	p.rulePrefixes[r] = s
+	return s
+}
+
+// errContext returns the lines of the input around offset, as set by the
+// ContextLines option, with a caret under offset.
+func (p *parser) errContext(offset int) string {
+	if offset > len(p.data) {
+		offset = len(p.data)
+	}
+	start := bytes.LastIndexByte(p.data[:offset], '\n') + 1
+	line := bytes.Count(p.data[:start], []byte("\n")) + 1
+
+	// the lines before the error line, and the error line and those after
+	first, n := start, 0
+	for first > 0 && n < p.contextLines {
+		first = bytes.LastIndexByte(p.data[:first-1], '\n') + 1
+		n++
+	}
+	end := start
+	for i := 0; i <= p.contextLines && end < len(p.data); i++ {
+		if ix := bytes.IndexByte(p.data[end:], '\n'); ix >= 0 {
+			end += ix + 1
+		} else {
+			end = len(p.data)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(string(p.data[first:end]), "\n"), "\n")
+	width := len(fmt.Sprint(line - n + len(lines) - 1))
+
+	var buf bytes.Buffer
+	for i, l := range lines {
+		fmt.Fprintf(&buf, "\n%*d | %s", width, line-n+i, l)
+		if i != n {
+			continue
+		}
+		// the caret is aligned with the same tabs as the error line
+		fmt.Fprintf(&buf, "\n%*s | ", width, "")
+		for _, rn := range string(p.data[start:offset]) {
+			if rn == '\t' {
+				buf.WriteRune('\t')
+			} else {
+				buf.WriteRune(' ')
+			}
+		}
+		buf.WriteRune('^')
+	}
+	return buf.String()
+}
+
+// read advances the parser to the next rune.
+func (p *parser) read() {
+	if p.tokMode {
+		p.readToken()
+		return
+	}
+	p.pt.offset += p.pt.w
+	var rn rune
+	var n int
+	if p.assumeValid && p.pt.offset < len(p.data) && p.data[p.pt.offset] < utf8.RuneSelf {
+		rn, n = rune(p.data[p.pt.offset]), 1
+	} else {
+		rn, n = utf8.DecodeRune(p.data[p.pt.offset:])
+	}
+	p.pt.rn = rn
+	p.pt.w = n
+	p.pt.col++
+	if p.memoCache != nil {
+		p.examineRune()
+	}
+	if rn == '\n' {
+		p.pt.line++
+		p.pt.col = 0
+	}
+
+	if rn == utf8.RuneError && !p.assumeValid {
+		if n == 1 {
+			p.addErr(errInvalidEncoding)
+		}
+	}
+}
+
+// readToken advances the parser's position to the next token in token
+// mode. The current rune is never valid, so that the rune matchers don't
+// match.
+func (p *parser) readToken() {
+	p.pt.offset += p.pt.w
+	p.pt.rn = utf8.RuneError
+	p.pt.w = 0
+	if p.pt.offset < len(p.toks) {
+		p.pt.w = 1
+		pos := p.toks[p.pt.offset].Pos()
+		p.pt.line, p.pt.col = pos.Line, pos.Col
+	}
+}
+
+// skip advances the parser's position by n bytes, regardless of the
+// encoding of the input. Each byte counts as a column.
+func (p *parser) skip(n int) {
+	if n == 0 {
+		return
+	}
+	p.pt.offset += n - p.pt.w
+	p.pt.col += n - 1
+	p.read()
+}
+
+// atInvalidOrEOF returns true if the parser is at the end of the input or
+// at an invalid UTF-8 encoding. A valid U+FFFD replacement character in the
+// input is a regular character.
+func (p *parser) atInvalidOrEOF() bool {
+	return p.pt.rn == utf8.RuneError && p.pt.w <= 1
+}
+
+// restore parser position to the savepoint pt.
+func (p *parser) restore(pt savepoint) {
+	if p.debug {
+		defer p.out(p.in("restore"))
+	}
+	if pt.offset == p.pt.offset {
+		p.pt.indents = pt.indents
+		p.pt.owned = pt.owned
+		p.pt.matched = pt.matched
+		p.pt.warned = pt.warned
+		p.pt.errored = pt.errored
+		p.pt.evented = pt.evented
+		return
+	}
+	if p.maxBacktrack > 0 && pt.offset < p.pt.offset && len(p.rstack) > 0 {
+		p.countBacktrack(pt)
+	}
+	p.pt = pt
+}
+
+// countBacktrack records that the current rule backtracked to pt, and
+// panics if the rule exceeded the maximum number of backtracks to this
+// offset.
+func (p *parser) countBacktrack(pt savepoint) {
+	if p.backtracks == nil {
+		p.backtracks = make(map[backtrackKey]int)
+	}
+	key := backtrackKey{rule: p.rstack[len(p.rstack)-1], offset: pt.offset}
+	p.backtracks[key]++
+	if n := p.backtracks[key]; n > p.maxBacktrack {
+		panic(fmt.Errorf("backtracked %d times to %s, maximum is %d", n, pt.position, p.maxBacktrack))
+	}
+}
+
+// get the slice of bytes from the savepoint start to the current position.
+func (p *parser) sliceFrom(start savepoint) []byte {
+	if p.tokMode {
+		var buf bytes.Buffer
+		for _, tok := range p.toks[start.position.offset:p.pt.position.offset] {
+			buf.WriteString(tok.Text())
+		}
+		return buf.Bytes()
+	}
+	return p.data[start.position.offset:p.pt.position.offset]
+}
+
+func (p *parser) getMemoized(node interface{}) (resultTuple, bool) {
+	if p.memoStore == nil {
+		return resultTuple{}, false
+	}
+	res, ok := p.memoStore.Get(node, p.pt.offset)
+	if ok && p.memoCache != nil {
+		p.examine(res.reach)
+	}
+	return res.tuple, ok
+}
+
+func (p *parser) setMemoized(pt savepoint, node interface{}, tuple resultTuple, reach int) {
+	if p.memoStore == nil {
+		p.memoStore = make(memoTable)
+	}
+	p.memoStore.Set(node, pt.offset, MemoResult{tuple: tuple, reach: reach})
+}
+
+// enterReach starts recording the end of the input examined by a node at
+// the current position for the ReuseMemo option, and returns the end
+// recorded for the enclosing nodes.
+func (p *parser) enterReach() int {
+	outer := p.reach
+	p.reach = 0
+	p.examineRune()
+	return outer
+}
+
+// exitReach returns the end of the input examined by the node started by
+// enterReach, and restores that of the enclosing nodes, which includes it.
+func (p *parser) exitReach(outer int) int {
+	reach := p.reach
+	p.examine(outer)
+	return reach
+}
+
+// examine records that the input is examined up to the offset end,
+// excluded, where the end of the input is at offset len(p.data)+1.
+func (p *parser) examine(end int) {
+	if end > len(p.data)+1 {
+		end = len(p.data) + 1
+	}
+	if end > p.reach {
+		p.reach = end
+	}
+}
+
+// examineRune records that the current rune is examined.
+func (p *parser) examineRune() {
+	if p.pt.w == 0 {
+		p.examine(p.pt.offset + 1)
+		return
+	}
+	p.examine(p.pt.offset + p.pt.w)
+}
+
+// memoTable is the default MemoStore:
+// map[offset in source] map[expression or rule] {value, match}
+type memoTable map[int]map[interface{}]MemoResult
+
+func (t memoTable) Get(node interface{}, offset int) (MemoResult, bool) {
+	res, ok := t[offset][node]
+	return res, ok
+}
+
+func (t memoTable) Set(node interface{}, offset int, res MemoResult) {
+	m := t[offset]
+	if m == nil {
+		m = make(map[interface{}]MemoResult)
+		t[offset] = m
+	}
+	m[node] = res
+}
+
+func (p *parser) buildRulesTable(g *grammar) {
+	p.rules = make(map[string]*rule, len(g.rules))
+	for _, r := range g.rules {
+		p.rules[r.name] = r
+	}
+}
+
+// buildStatsTable resets the statistics of the Statistics option to a
+// zero count for each rule of g.
+func (p *parser) buildStatsTable(g *grammar) {
+	p.stats.Rules = make([]RuleStats, len(g.rules))
+	p.ruleStats = make(map[*rule]*RuleStats, len(g.rules))
+	for i, r := range g.rules {
+		p.stats.Rules[i].Name = r.name
+		p.ruleStats[r] = &p.stats.Rules[i]
+	}
+}
+
+// countTokens records the success counts of the lexical rules of g in the
+// counts of the TokenCounts option.
+func (p *parser) countTokens(g *grammar) {
+	for i, r := range g.rules {
+		if r.lexical {
+			p.tokenCounts[r.name] = p.stats.Rules[i].Success
+		}
+	}
+}
+
+// countRule counts a match or a failure of r in the statistics.
+func (p *parser) countRule(r *rule, ok bool) {
+	st := p.ruleStats[r]
+	if st == nil {
+		return
+	}
+	if ok {
+		st.Success++
+	} else {
+		st.Fail++
+	}
+}
+
+func (p *parser) parse(g *grammar) (val interface{}, err error) {
+	if len(g.rules) == 0 {
+		p.addErr(errNoRule)
+		return nil, p.errs.err()
+	}
+
+	// TODO : not super critical but this could be generated
+	p.grammar = g
+	p.buildRulesTable(g)
+	if p.stats == nil && p.tokenCounts != nil {
+		p.stats = new(Stats)
+	}
+	if p.stats != nil {
+		p.buildStatsTable(g)
+	}
+	if p.tokenCounts != nil {
+		defer p.countTokens(g)
+	}
+
+	if err := p.decodeInput(); err != nil {
+		p.addErr(err)
+		return nil, p.errs.err()
+	}
+	if p.inputTooLarge() {
+		p.addErr(errInputTooLarge)
+		return nil, p.errs.err()
+	}
+	if p.memoCache != nil && !p.tokMode {
+		p.memoCache.prepare(p.data)
+		p.memoStore = cacheStore{p.memoCache}
+		p.memoize = true
+	} else {
+		p.memoCache = nil
+	}
+
+	// start rule is rule [0], unless an entrypoint is set
+	start := g.rules[0]
+	if p.entry != "" {
+		if start = p.rules[p.entry]; start == nil {
+			p.addErr(fmt.Errorf("undefined entrypoint rule %s", p.entry))
+			return nil, p.errs.err()
+		}
+	}
+	if p.tracer != nil {
+		// registered before the panic handler, so that the span gets the
+		// error of a panic
+		span := p.tracer.StartSpan(start.name)
+		defer func() {
+			span.End(err)
+		}()
+	}
+
+	if p.recover {
+		// panic can be used in action code to stop parsing immediately
+		// and return the panic as an error.
+		defer func() {
+			if e := recover(); e != nil {
+				if p.debug {
+					defer p.out(p.in("panic handler"))
+				}
+				val = nil
+				switch e := e.(type) {
+				case error:
+					p.addErr(e)
+				default:
+					p.addErr(fmt.Errorf("%v", e))
+				}
+				err = p.errs.err()
+			}
+		}()
+	}
+
+	p.read() // advance to first rune
+	val, ok := p.parseRule(start)
+	if !ok {
+		if len(*p.errs) == 0 {
+			// make sure this doesn't go out silently
+			if len(p.maxExpected) > 0 {
+				expected := "'" + p.maxExpected[0] + "'"
+				for i := 1; i < len(p.maxExpected) && i < 5; i++ {
+					expected += ", '" + p.maxExpected[i] + "'"
+				}
+				if len(p.maxExpected) > 5 {
+					expected += fmt.Sprintf(", and %d others", len(p.maxExpected)-5)
+				}
+
+				found := p.maxFound
+				if len(p.maxFound) == 0 {
+					found = string(p.maxSavePoint.rn)
+				}
+
+				p.addErrAt(fmt.Errorf("syntax error, unexpected '%s', expecting %s", found, expected), p.maxSavePoint.position)
+			} else {
+				p.addErr(errNoMatch)
+			}
+		}
+		return nil, p.errs.err()
+	}
+	if p.owned != nil {
+		for _, e := range p.ownLog[:p.pt.owned] {
+			p.owned[e.rule.name] += e.runes
+		}
+	}
+	if p.onMatch != nil {
+		for _, e := range p.matchLog[:p.pt.matched] {
+			p.onMatch(e.rule.name, p.exportPos(e.start), p.exportPos(e.end), e.val)
+		}
+	}
+	if p.events != nil {
+		for _, e := range p.eventLog[:p.pt.evented] {
+			p.events(e)
+		}
+	}
+	if p.warnings != nil {
+		*p.warnings = append([]Warning(nil), p.warnLog[:p.pt.warned]...)
+	}
+	if p.pt.errored > 0 {
+		// only the errors of the error productions of the successful
+		// parse are returned.
+		p.errs = new(errList)
+		for _, e := range p.errLog[:p.pt.errored] {
+			p.addRuleErrAt(e, e.pos, e.rule)
+		}
+		return val, p.errs.err()
+	}
+	return val, nil
+}
+
+// inputTooLarge returns true if the input exceeds the limit set by the
+// MaxInputRunes option.
+func (p *parser) inputTooLarge() bool {
+	if p.maxInputRunes <= 0 {
+		return false
+	}
+	if p.tokMode {
+		return len(p.toks) > p.maxInputRunes
+	}
+	// a rune is at least one byte
+	return len(p.data) > p.maxInputRunes && utf8.RuneCount(p.data) > p.maxInputRunes
+}
+
+// decodeInput decodes the input to UTF-8 according to the Decoder or the
+// Encoding option, and removes its byte order mark if the SkipBOM option
+// is set.
+func (p *parser) decodeInput() error {
+	if p.tokMode {
+		return nil
+	}
+	enc := strings.ToLower(p.encoding)
+	switch {
+	case p.decoder != nil:
+		rns, err := p.decoder(p.data)
+		if err != nil {
+			return err
+		}
+		p.data = []byte(string(rns))
+	case enc == "", enc == "utf-8", enc == "utf8":
+	case enc == "latin1", enc == "iso-8859-1":
+		var buf bytes.Buffer
+		for _, b := range p.data {
+			buf.WriteRune(rune(b))
+		}
+		p.data = buf.Bytes()
+	case enc == "utf-16", enc == "utf-16be", enc == "utf-16le":
+		if len(p.data)%2 != 0 {
+			return errors.New("invalid UTF-16 input: odd number of bytes")
+		}
+		var order binary.ByteOrder = binary.BigEndian
+		if enc == "utf-16le" || (enc == "utf-16" && bytes.HasPrefix(p.data, []byte{0xff, 0xfe})) {
+			order = binary.LittleEndian
+		}
+		units := make([]uint16, len(p.data)/2)
+		for i := range units {
+			units[i] = order.Uint16(p.data[2*i:])
+		}
+		var buf bytes.Buffer
+		for _, rn := range utf16.Decode(units) {
+			buf.WriteRune(rn)
+		}
+		p.data = buf.Bytes()
+	default:
+		return fmt.Errorf("unknown encoding %q", p.encoding)
+	}
+	if p.skipBOM {
+		p.data = bytes.TrimPrefix(p.data, []byte("\uFEFF"))
+	}
+	if p.normalize && bytes.IndexByte(p.data, '\r') >= 0 {
+		buf := make([]byte, 0, len(p.data))
+		for i, b := range p.data {
+			if b == '\r' {
+				if i+1 < len(p.data) && p.data[i+1] == '\n' {
+					p.crlfs = append(p.crlfs, len(buf))
+					continue
+				}
+				b = '\n'
+			}
+			buf = append(buf, b)
+		}
+		p.data = buf
+	}
+	return nil
+}
+
+// origOffset returns the offset in the input before the conversion of the
+// NormalizeNewlines option of the offset off of the converted input. The
+// offset of a "\n" that replaced a "\r\n" is that of the "\r".
+func (p *parser) origOffset(off int) int {
+	return off + sort.SearchInts(p.crlfs, off)
+}
+
+// exportPos returns the Pos of pos reported to the user.
+func (p *parser) exportPos(pos position) Pos {
+	return Pos{pos.line, pos.col, p.origOffset(pos.offset)}
+}
+
+func (p *parser) parseRule(rule *rule) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRule " + rule.name))
+	}
+	if p.trace != nil {
+		p.traceExpr("rule " + rule.name)
+	}
+
+	if p.memoize {
+		res, ok := p.getMemoized(rule)
+		if ok {
+			p.restore(res.end)
+			if p.stats != nil {
+				p.countRule(rule, res.b)
+			}
+			return res.v, res.b
+		}
+	}
+
+	if p.maxDepth > 0 && len(p.rstack) >= p.maxDepth {
+		panic(errMaxDepth)
+	}
+
+	start := p.pt
+	var outer int
+	if p.memoCache != nil {
+		outer = p.enterReach()
+	}
+	if p.events != nil {
+		p.addEvent(EventStart, rule.name, start.position, "")
+	}
+	p.rstack = append(p.rstack, rule)
+	budgetRule, budgetEnd := p.budgetRule, p.budgetEnd
+	if end := p.exprCnt + rule.budget; rule.budget > 0 && (p.budgetRule == nil || end < p.budgetEnd) {
+		p.budgetRule, p.budgetEnd = rule, end
+	}
+	p.pushV()
+	vbase := p.vbase
+	p.vbase = len(p.vstack) - 1
+	val, ok := p.parseExpr(rule.expr)
+	p.vbase = vbase
+	p.popV()
+	p.budgetRule, p.budgetEnd = budgetRule, budgetEnd
+	if fn := p.transforms[rule.name]; ok && fn != nil {
+		v, err := fn(val)
+		if err != nil {
+			p.addErrAt(err, start.position)
+			ok = false
+		} else {
+			val = v
+		}
+	}
+	p.rstack = p.rstack[:len(p.rstack)-1]
+	if ok && p.owned != nil {
+		p.addOwnership(rule, start)
+	}
+	if ok && p.onMatch != nil {
+		p.matchLog = append(p.matchLog[:p.pt.matched], matchEntry{rule: rule, start: start.position, end: p.pt.position, val: val})
+		p.pt.matched = len(p.matchLog)
+	}
+	if p.events != nil {
+		if ok {
+			p.addEvent(EventEnd, rule.name, p.pt.position, "")
+		} else {
+			p.pt.evented = start.evented
+		}
+	}
+	if ok && p.debug {
+		p.print(strings.Repeat(" ", p.depth)+"MATCH", string(p.sliceFrom(start)))
+	}
+	if p.stats != nil {
+		p.countRule(rule, ok)
+	}
+
+	if p.memoize {
+		var reach int
+		if p.memoCache != nil {
+			reach = p.exitReach(outer)
+		}
+		p.setMemoized(start, rule, resultTuple{val, ok, p.pt}, reach)
+	}
+	return val, ok
+}
+
+// addEvent records an event of the current derivation, reported to the
+// Events function if the parse succeeds.
+func (p *parser) addEvent(kind EventKind, rule string, pos position, text string) {
+	p.eventLog = append(p.eventLog[:p.pt.evented], Event{Kind: kind, Rule: rule, Pos: p.exportPos(pos), Text: text})
+	p.pt.evented = len(p.eventLog)
+}
+
+// addOwnership records the runes matched by rule since start that are not
+// owned by the rules it references.
+func (p *parser) addOwnership(rule *rule, start savepoint) {
+	cumAt := func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		return p.ownLog[n-1].cum
+	}
+
+	runes := utf8.RuneCount(p.sliceFrom(start))
+	cum := cumAt(p.pt.owned)
+	own := runes - (cum - cumAt(start.owned))
+	if own == 0 {
+		return
+	}
+	p.ownLog = append(p.ownLog[:p.pt.owned], ownEntry{rule: rule, runes: own, cum: cum + own})
+	p.pt.owned = len(p.ownLog)
+}
+
+func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
+	var ok bool
+
+	if p.memoize {
+		res, ok := p.getMemoized(expr)
+		if ok {
+			p.restore(res.end)
+			return res.v, res.b
+		}
+	}
+
+	p.exprCnt++
+	if p.budgetRule != nil && p.exprCnt > p.budgetEnd {
+		panic(fmt.Errorf("budget of %d expressions of rule %s exceeded", p.budgetRule.budget, p.budgetRule.name))
+	}
+	pt := p.pt
+	var outer int
+	if p.memoCache != nil {
+		outer = p.enterReach()
+	}
+	if p.trace != nil {
+		kind := fmt.Sprintf("%T", expr)
+		p.traceExpr(kind[strings.LastIndex(kind, ".")+1:])
+	}
+	var val interface{}
+	switch expr := expr.(type) {
+	case *actionExpr:
+		val, ok = p.parseActionExpr(expr)
+	case *andCodeExpr:
+		val, ok = p.parseAndCodeExpr(expr)
+	case *andExpr:
+		val, ok = p.parseAndExpr(expr)
+	case *anyMatcher:
+		val, ok = p.parseAnyMatcher(expr)
+	case *backRefExpr:
+		val, ok = p.parseBackRefExpr(expr)
+	case *byteMatcher:
+		val, ok = p.parseByteMatcher(expr)
+	case *bytesMatcher:
+		val, ok = p.parseBytesMatcher(expr)
+	case *charClassMatcher:
+		val, ok = p.parseCharClassMatcher(expr)
+	case *choiceExpr:
+		val, ok = p.parseChoiceExpr(expr)
+	case *foldExpr:
+		val, ok = p.parseFoldExpr(expr)
+	case *indentMatcher:
+		val, ok = p.parseIndentMatcher(expr)
+	case *compactExpr:
+		val, ok = p.parseCompactExpr(expr)
+	case *arrayExpr:
+		val, ok = p.parseArrayExpr(expr)
+	case *keywordMatcher:
+		val, ok = p.parseKeywordMatcher(expr)
+	case *wordListMatcher:
+		val, ok = p.parseWordListMatcher(expr)
+	case *labeledExpr:
+		val, ok = p.parseLabeledExpr(expr)
+	case *litMatcher:
+		val, ok = p.parseLitMatcher(expr)
+	case *litSetMatcher:
+		val, ok = p.parseLitSetMatcher(expr)
+	case *lookbehindExpr:
+		val, ok = p.parseLookbehindExpr(expr)
+	case *notCodeExpr:
+		val, ok = p.parseNotCodeExpr(expr)
+	case *notExpr:
+		val, ok = p.parseNotExpr(expr)
+	case *nestedMatcher:
+		val, ok = p.parseNestedMatcher(expr)
+	case *numberMatcher:
+		val, ok = p.parseNumberMatcher(expr)
+	case *oneOrMoreExpr:
+		val, ok = p.parseOneOrMoreExpr(expr)
+	case *operatorsExpr:
+		val, ok = p.parseOperatorsExpr(expr)
+	case *ruleRefExpr:
+		val, ok = p.parseRuleRefExpr(expr)
+	case *sepExpr:
+		val, ok = p.parseSepExpr(expr)
+	case *seqExpr:
+		val, ok = p.parseSeqExpr(expr)
+	case *skipExpr:
+		val, ok = p.parseSkipExpr(expr)
+	case *tokenMatcher:
+		val, ok = p.parseTokenMatcher(expr)
+	case *unreservedExpr:
+		val, ok = p.parseUnreservedExpr(expr)
+	case *restOfLineMatcher:
+		val, ok = p.parseRestOfLineMatcher(expr)
+	case *untilMatcher:
+		val, ok = p.parseUntilMatcher(expr)
+	case *whenExpr:
+		val, ok = p.parseWhenExpr(expr)
+	case *zeroOrMoreExpr:
+		val, ok = p.parseZeroOrMoreExpr(expr)
+	case *zeroOrOneExpr:
+		val, ok = p.parseZeroOrOneExpr(expr)
+	default:
+		panic(fmt.Sprintf("unknown expression type %T", expr))
+	}
+	if ok && p.events != nil && len(p.rstack) > 0 {
+		switch expr.(type) {
+		case *anyMatcher, *byteMatcher, *bytesMatcher, *charClassMatcher, *keywordMatcher,
+			*litMatcher, *litSetMatcher, *nestedMatcher, *numberMatcher, *restOfLineMatcher,
+			*tokenMatcher, *untilMatcher, *wordListMatcher:
+			p.addEvent(EventText, p.rstack[len(p.rstack)-1].name, pt.position, string(p.sliceFrom(pt)))
+		}
+	}
+	if p.memoize {
+		var reach int
+		if p.memoCache != nil {
+			reach = p.exitReach(outer)
+		}
+		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt}, reach)
+	}
+	return val, ok
+}
+
+func (p *parser) parseActionExpr(act *actionExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseActionExpr"))
+	}
+
+	start := p.pt
+	val, ok := p.parseExpr(act.expr)
+	if ok {
+		p.cur.pos = start.position
+		p.cur.end = p.pt.position
+		p.cur.text = p.sliceFrom(start)
+		if p.normalizer != nil && len(p.rstack) > 0 {
+			name := p.rstack[len(p.rstack)-1].name
+			p.cur.text = []byte(p.normalizer(name, string(p.cur.text)))
+		}
+		actVal, err := act.run(p)
+		if perr, isProd := err.(*productionError); isProd {
+			// an error production matches, its error is reported at the
+			// end of the parse unless the match is backtracked over.
+			p.errLog = append(p.errLog[:p.pt.errored], perr)
+			p.pt.errored = len(p.errLog)
+			val = actVal
+		} else if err != nil {
+			p.addErrAt(err, start.position)
+			ok = false
+		} else {
+			val = actVal
+		}
+	}
+	if ok && p.debug {
+		p.print(strings.Repeat(" ", p.depth)+"MATCH", string(p.sliceFrom(start)))
+	}
+	return val, ok
+}
+
+func (p *parser) parseAndCodeExpr(and *andCodeExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndCodeExpr"))
+	}
+
+	ok, err := and.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, ok
+}
+
+func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndExpr"))
+	}
+
+	// the value of the expression is kept, so that the text ahead can be
+	// captured without being consumed.
+	pt := p.pt
+	p.pushV()
+	val, ok := p.parseExpr(and.expr)
+	p.popV()
+	p.restore(pt)
+	return val, ok
+}
+
+func (p *parser) parseAnyMatcher(any *anyMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAnyMatcher"))
+	}
+
+	if !p.atInvalidOrEOF() {
+		start := p.pt
+		p.read()
+		return p.sliceFrom(start), true
+	}
+	return nil, false
+}
+
+func (p *parser) parseBackRefExpr(ref *backRefExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseBackRefExpr"))
+	}
+
+	var text []byte
+	found := false
+	for i := len(p.vstack) - 1; i >= p.vbase && !found; i-- {
+		var v interface{}
+		if v, found = p.vstack[i]["="+ref.label]; found {
+			text = v.([]byte)
+		}
+	}
+	if !found {
+		// the label did not match in this rule
+		return nil, false
+	}
+
+	start := p.pt
+	for _, want := range string(text) {
+		if p.pt.rn != want {
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseByteMatcher(by *byteMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseByteMatcher"))
+	}
+
+	if p.pt.offset < len(p.data) && p.data[p.pt.offset] == by.val {
+		start := p.pt
+		p.skip(1)
+		return p.sliceFrom(start), true
+	}
+	return nil, false
+}
+
+func (p *parser) parseBytesMatcher(by *bytesMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseBytesMatcher"))
+	}
+
+	n := by.n
+	if by.label != "" {
+		var ok bool
+		if n, ok = p.labelInt(by.label); !ok || n < 0 {
+			p.addErr(fmt.Errorf("invalid number of bytes for label %s", by.label))
+			return nil, false
+		}
+	}
+	if len(p.data)-p.pt.offset < n {
+		if p.memoCache != nil {
+			p.examine(len(p.data) + 1)
+		}
+		return nil, false
+	}
+	start := p.pt
+	p.skip(n)
+	return p.sliceFrom(start), true
+}
+
+// labelInt returns the value of label in the current rule as an int, and
+// false if it is not set or is not an integer.
+func (p *parser) labelInt(label string) (int, bool) {
+	for i := len(p.vstack) - 1; i >= p.vbase; i-- {
+		v, ok := p.vstack[i][label]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case int:
+			return v, true
+		case int8:
+			return int(v), true
+		case int16:
+			return int(v), true
+		case int32:
+			return int(v), true
+		case int64:
+			return int(v), true
+		case uint:
+			return int(v), true
+		case uint8:
+			return int(v), true
+		case uint16:
+			return int(v), true
+		case uint32:
+			return int(v), true
+		case uint64:
+			return int(v), true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseCharClassMatcher"))
+	}
+
+	cur := p.pt.rn
+	// can't match EOF
+	if p.atInvalidOrEOF() {
+		return nil, false
+	}
+	start := p.pt
+	if !chr.accepts(cur) {
+		return nil, false
+	}
+	p.read()
+	return p.sliceFrom(start), true
+}
+
+// accepts returns true if the character class matches rn, taking its case
+// insensitivity and its inversion into account.
+func (chr *charClassMatcher) accepts(rn rune) bool {
+	matched := chr.matches(rn)
+	if !matched && chr.ignoreCase {
+		// try the runes that are equivalent under simple case folding
+		matched = chr.matches(unicode.ToLower(rn))
+		for f := unicode.SimpleFold(rn); f != rn && !matched; f = unicode.SimpleFold(f) {
+			matched = chr.matches(f)
+		}
+	}
+	return matched != chr.inverted
+}
+
+// matches returns true if rn is in the chars, ranges or Unicode classes
+// of the character class, ignoring its inversion.
+func (chr *charClassMatcher) matches(rn rune) bool {
+	for _, c := range chr.chars {
+		if c == rn {
+			return true
+		}
+	}
+	for i := 0; i < len(chr.ranges); i += 2 {
+		if rn >= chr.ranges[i] && rn <= chr.ranges[i+1] {
+			return true
+		}
+	}
+	for _, cl := range chr.classes {
+		if unicode.Is(cl, rn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseChoiceExpr"))
+	}
+
+	start := p.pt
+	for i, alt := range ch.alternatives {
+		p.pushV()
+		val, ok := p.parseExpr(alt)
+		p.popV()
+		if ok {
+			if p.ambiguities != nil {
+				p.checkAmbiguity(ch, i, start)
+			}
+			return val, ok
+		}
+	}
+	return nil, false
+}
+
+// checkAmbiguity tries the alternatives of ch that follow the alternative
+// i, that matched from start, and records an Ambiguity if any of them also
+// matches. The parser is back at the end of the match of i on return.
+func (p *parser) checkAmbiguity(ch *choiceExpr, i int, start savepoint) {
+	key := ambiguityKey{choice: ch, offset: start.offset}
+	if p.ambiguous[key] {
+		return
+	}
+	end := p.pt
+	alts := []int{i + 1}
+	for j := i + 1; j < len(ch.alternatives); j++ {
+		p.restore(start)
+		p.pushV()
+		_, ok := p.parseExpr(ch.alternatives[j])
+		p.popV()
+		if ok {
+			alts = append(alts, j+1)
+		}
+	}
+	p.restore(end)
+	if len(alts) == 1 {
+		return
+	}
+
+	if p.ambiguous == nil {
+		p.ambiguous = make(map[ambiguityKey]bool)
+	}
+	p.ambiguous[key] = true
+	var name string
+	if len(p.rstack) > 0 {
+		name = p.rstack[len(p.rstack)-1].name
+	}
+	*p.ambiguities = append(*p.ambiguities, Ambiguity{Rule: name, Pos: p.exportPos(start.position), Alts: alts})
+}
+
+// parseFoldExpr folds the value of the sequence {first, {{op, operand}...}}
+// into binary operations, with the value []interface{}{left, op, right}.
+func (p *parser) parseFoldExpr(fold *foldExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseFoldExpr"))
+	}
+
+	val, ok := p.parseExpr(fold.expr)
+	if !ok {
+		return nil, false
+	}
+	vals := val.([]interface{})
+	rest, _ := vals[1].([]interface{})
+	if !fold.right {
+		left := vals[0]
+		for _, v := range rest {
+			pair := v.([]interface{})
+			left = []interface{}{left, pair[0], pair[1]}
+		}
+		return left, true
+	}
+
+	if len(rest) == 0 {
+		return vals[0], true
+	}
+	right := rest[len(rest)-1].([]interface{})[1]
+	for i := len(rest) - 1; i >= 0; i-- {
+		left := vals[0]
+		if i > 0 {
+			left = rest[i-1].([]interface{})[1]
+		}
+		right = []interface{}{left, rest[i].([]interface{})[0], right}
+	}
+	return right, true
+}
+
+func (p *parser) parseIndentMatcher(ind *indentMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseIndentMatcher " + ind.val))
+	}
+
+	// only match at the start of a line, never in token mode
+	if p.tokMode || p.pt.col != 1 && p.pt.offset < len(p.data) {
+		return nil, false
+	}
+	width := 0
+	for p.pt.offset+width < len(p.data) {
+		if b := p.data[p.pt.offset+width]; b != ' ' && b != '\t' {
+			break
+		}
+		width++
+	}
+	if p.memoCache != nil {
+		p.examine(p.pt.offset + width + 1)
+	}
+	if p.pt.offset+width == len(p.data) {
+		// no more line, the indentation is back at the top level
+		width = 0
+	}
+	cur := 0
+	if p.pt.indents != nil {
+		cur = p.pt.indents.width
+	}
+
+	switch ind.val {
+	case "indent":
+		if width <= cur {
+			return nil, false
+		}
+		p.pt.indents = &indentLevel{width: width, prev: p.pt.indents}
+	case "samedent":
+		if width != cur {
+			return nil, false
+		}
+		start := p.pt
+		for p.pt.offset < start.offset+width {
+			p.read()
+		}
+		return p.sliceFrom(start), true
+	case "dedent":
+		if width >= cur {
+			return nil, false
+		}
+		p.pt.indents = p.pt.indents.prev
+	default:
+		panic(fmt.Sprintf("%s: invalid indentation matcher: %s", ind.pos, ind.val))
+	}
+	return nil, true
+}
+
+func (p *parser) parseLabeledExpr(lab *labeledExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLabeledExpr"))
+	}
+
+	start := p.pt
+	p.pushV()
+	val, ok := p.parseExpr(lab.expr)
+	p.popV()
+	if ok && lab.label != "" {
+		m := p.vstack[len(p.vstack)-1]
+		m[lab.label] = val
+		if lab.capture {
+			// the matched text is stored under a key that is not a valid
+			// label, for the back-references to the label
+			m["="+lab.label] = p.sliceFrom(start)
+		}
+		if lab.span {
+			m["@"+lab.label] = [2]position{start.position, p.pt.position}
+		}
+	}
+	return val, ok
+}
+
+func (p *parser) parseKeywordMatcher(kw *keywordMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseKeywordMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	rest := p.data[p.pt.offset:]
+	n := 0
+	for _, word := range p.keywords {
+		if p.memoCache != nil {
+			p.examine(p.pt.offset + len(word) + utf8.UTFMax)
+		}
+		if len(word) <= n || !bytes.HasPrefix(rest, []byte(word)) {
+			continue
+		}
+		rn, _ := utf8.DecodeRune(rest[len(word):])
+		if len(rest) > len(word) && (rn == '_' || unicode.IsLetter(rn) || unicode.IsDigit(rn)) {
+			continue
+		}
+		n = len(word)
+	}
+	if n == 0 {
+		return nil, false
+	}
+	start := p.pt
+	for p.pt.offset < start.offset+n {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitMatcher"))
+	}
+
+	start := p.pt
+	for _, want := range lit.val {
+		cur := p.pt.rn
+		if cur != want && !(lit.ignoreCase && foldEqual(cur, want)) {
+			p.setMaxSavePoint(string(p.sliceFrom(start))+string(cur), lit.val)
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseLitSetMatcher(set *litSetMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitSetMatcher"))
+	}
+
+	start := p.pt
+	// offset of the next rune to match in each literal, -1 once the
+	// literal does not match
+	offs := make([]int, len(set.alts))
+	best := -1
+	var end savepoint
+	for live := true; live; {
+		live = false
+		for i, alt := range set.alts {
+			if best >= 0 && i >= best {
+				break
+			}
+			if offs[i] < 0 {
+				continue
+			}
+			if offs[i] == len(alt.val) {
+				// the literals that follow cannot be the first to match
+				best, end = i, p.pt
+				break
+			}
+			want, n := utf8.DecodeRuneInString(alt.val[offs[i]:])
+			if cur := p.pt.rn; cur == want || (alt.ignoreCase && foldEqual(cur, want)) {
+				offs[i] += n
+				live = true
+			} else {
+				offs[i] = -1
+			}
+		}
+		if live {
+			p.read()
+		}
+	}
+	if best < 0 {
+		// record the expected literals for the error message
+		p.restore(start)
+		for _, alt := range set.alts {
+			p.parseLitMatcher(alt)
+		}
+		return nil, false
+	}
+
+	p.pt = end
+	b := p.sliceFrom(start)
+	if set.parts == nil {
+		return b, true
+	}
+	vals := make([]interface{}, len(set.parts[best]))
+	for i, n := range set.parts[best] {
+		m := 0
+		for ; n > 0; n-- {
+			_, w := utf8.DecodeRune(b[m:])
+			m += w
+		}
+		vals[i] = b[:m]
+		b = b[m:]
+	}
+	return vals, true
+}
+
+// foldEqual returns true if rn is equal to the lowercase rune want under
+// simple Unicode case folding, e.g. 'É' and 'é', or 'Σ', 'ς' and 'σ'.
+func foldEqual(rn, want rune) bool {
+	if unicode.ToLower(rn) == want {
+		return true
+	}
+	for f := unicode.SimpleFold(rn); f != rn; f = unicode.SimpleFold(f) {
+		if f == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseLookbehindExpr(lb *lookbehindExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLookbehindExpr"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	before := p.data[:p.pt.offset]
+	switch m := lb.expr.(type) {
+	case *anyMatcher:
+		return nil, len(before) > 0
+	case *charClassMatcher:
+		rn, n := utf8.DecodeLastRune(before)
+		return nil, n > 0 && m.accepts(rn)
+	case *litMatcher:
+		want := []rune(m.val)
+		for i := len(want) - 1; i >= 0; i-- {
+			rn, n := utf8.DecodeLastRune(before)
+			if n == 0 || (rn != want[i] && !(m.ignoreCase && foldEqual(rn, want[i]))) {
+				return nil, false
+			}
+			before = before[:len(before)-n]
+		}
+		return nil, true
+	}
+	panic(fmt.Sprintf("unknown lookbehind expression type %T", lb.expr))
+}
+
+func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotCodeExpr"))
+	}
+
+	ok, err := not.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, !ok
+}
+
+func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotExpr"))
+	}
+
+	pt := p.pt
+	p.pushV()
+	_, ok := p.parseExpr(not.expr)
+	p.popV()
+	p.restore(pt)
+	return nil, !ok
+}
+
+// parseNestedMatcher matches the open delimiter of nest, then the input up
+// to the close delimiter that matches it, counting the nested open and
+// close delimiters. It fails if the input ends before the block.
+func (p *parser) parseNestedMatcher(nest *nestedMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNestedMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	if p.memoCache != nil {
+		p.examine(p.pt.offset + len(nest.open))
+	}
+	if !bytes.HasPrefix(p.data[p.pt.offset:], []byte(nest.open)) {
+		p.setMaxSavePoint(string(p.pt.rn), nest.open)
+		return nil, false
+	}
+	// find the end of the block in a single pass, then advance rune by
+	// rune up to it so that the position information stays accurate.
+	start := p.pt
+	end := -1
+	depth := 0
+	for off := start.offset; off < len(p.data); {
+		rest := p.data[off:]
+		switch {
+		case bytes.HasPrefix(rest, []byte(nest.close)) && depth > 0:
+			depth--
+			off += len(nest.close)
+			if depth == 0 {
+				end = off
+			}
+		case bytes.HasPrefix(rest, []byte(nest.open)):
+			depth++
+			off += len(nest.open)
+		default:
+			_, n := utf8.DecodeRune(rest)
+			off += n
+		}
+		if end >= 0 {
+			break
+		}
+	}
+	if end < 0 {
+		// report the missing close delimiter at the end of the input
+		for p.pt.offset < len(p.data) {
+			p.read()
+		}
+		p.setMaxSavePoint(string(p.pt.rn), nest.close)
+		p.restore(start)
+		return nil, false
+	}
+	if p.memoCache != nil {
+		p.examine(end + len(nest.open) + len(nest.close))
+	}
+	for p.pt.offset < end {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+// parseNumberMatcher matches the digits of a number in the radix of num,
+// or in the radix of its prefix, with the optional sign, fraction and
+// exponent allowed by num. Its value is an int64, or a float64 for a float
+// number.
+func (p *parser) parseNumberMatcher(num *numberMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNumberMatcher"))
+	}
+
+	start := p.pt
+	neg := false
+	if num.sign && (p.pt.rn == '-' || p.pt.rn == '+') {
+		neg = p.pt.rn == '-'
+		p.read()
+	}
+	radix := num.radix
+	if num.prefix {
+		radix = p.readRadixPrefix()
+	}
+	digits := p.pt
+	if radix == 0 || p.readDigits(radix) == 0 {
+		p.setMaxSavePoint(string(p.sliceFrom(start))+string(p.pt.rn), "number")
+		p.restore(start)
+		return nil, false
+	}
+	if !num.float {
+		n, ok := parseInt(p.sliceFrom(digits), radix, neg)
+		if !ok {
+			p.addErrAt(errNumberRange, start.position)
+			p.restore(start)
+			return nil, false
+		}
+		return n, true
+	}
+
+	if p.pt.rn == '.' {
+		dot := p.pt
+		p.read()
+		if p.readDigits(10) == 0 {
+			p.restore(dot)
+		}
+	}
+	if p.pt.rn == 'e' || p.pt.rn == 'E' {
+		exp := p.pt
+		p.read()
+		if p.pt.rn == '-' || p.pt.rn == '+' {
+			p.read()
+		}
+		if p.readDigits(10) == 0 {
+			p.restore(exp)
+		}
+	}
+	var f float64
+	if _, err := fmt.Sscan(string(p.sliceFrom(start)), &f); err != nil {
+		p.addErrAt(errNumberRange, start.position)
+		p.restore(start)
+		return nil, false
+	}
+	return f, true
+}
+
+// readRadixPrefix reads the radix prefix "0x", "0b" or "0o" of a number at
+// the current position, in either case, and returns its radix. It returns
+// 0 and reads nothing if there is no prefix.
+func (p *parser) readRadixPrefix() int {
+	if p.pt.rn != '0' {
+		return 0
+	}
+	start := p.pt
+	p.read()
+	var radix int
+	switch p.pt.rn {
+	case 'x', 'X':
+		radix = 16
+	case 'b', 'B':
+		radix = 2
+	case 'o', 'O':
+		radix = 8
+	default:
+		p.restore(start)
+		return 0
+	}
+	p.read()
+	return radix
+}
+
+// readDigits reads the digits of radix at the current position and
+// returns their number.
+func (p *parser) readDigits(radix int) int {
+	n := 0
+	for digitVal(p.pt.rn) < radix {
+		p.read()
+		n++
+	}
+	return n
+}
+
+// digitVal returns the value of the digit rn in a radix up to 36, or 36
+// if rn is not a digit.
+func digitVal(rn rune) int {
+	switch {
+	case '0' <= rn && rn <= '9':
+		return int(rn - '0')
+	case 'a' <= rn && rn <= 'z':
+		return int(rn-'a') + 10
+	case 'A' <= rn && rn <= 'Z':
+		return int(rn-'A') + 10
+	}
+	return 36
+}
+
+// parseInt returns the value of the digits of text in radix, negated if
+// neg is set, and false if it does not fit in an int64.
+func parseInt(text []byte, radix int, neg bool) (int64, bool) {
+	max := uint64(1<<63 - 1)
+	if neg {
+		max++
+	}
+	var n uint64
+	for _, c := range text {
+		d := uint64(digitVal(rune(c)))
+		if n > (max-d)/uint64(radix) {
+			return 0, false
+		}
+		n = n*uint64(radix) + d
+	}
+	if neg {
+		return -int64(n), true
+	}
+	return int64(n), true
+}
+
+func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseOneOrMoreExpr"))
+	}
+
+	var vals []interface{}
+
+	for {
+		if !p.repeatWhile(expr.while, vals) {
+			if len(vals) == 0 {
+				return nil, false
+			}
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(expr.expr)
+		p.popV()
+		if !ok {
+			if len(vals) == 0 {
+				// did not match once, no match
+				return nil, false
+			}
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+func (p *parser) parseOperatorsExpr(ops *operatorsExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseOperatorsExpr"))
+	}
+
+	return p.parseOperatorsPrec(ops, 0)
+}
+
+// parseOperatorsPrec parses operands separated by operators of precedence
+// minPrec or higher using precedence climbing. A binary operation has the
+// value []interface{}{left, op, right}, where op is the matched operator.
+func (p *parser) parseOperatorsPrec(ops *operatorsExpr, minPrec int) (interface{}, bool) {
+	left, ok := p.parseExpr(ops.operand)
+	if !ok {
+		return nil, false
+	}
+
+	for {
+		pt := p.pt
+		var op *binaryOp
+		var opVal interface{}
+		for _, cur := range ops.ops {
+			if opVal, ok = p.parseLitMatcher(cur.lit); ok {
+				op = cur
+				break
+			}
+		}
+		if op == nil || op.prec < minPrec {
+			p.restore(pt)
+			return left, true
+		}
+
+		nextPrec := op.prec + 1
+		if op.rightAssoc {
+			nextPrec = op.prec
+		}
+		right, ok := p.parseOperatorsPrec(ops, nextPrec)
+		if !ok {
+			// the operator is not followed by an operand, it is not
+			// part of this expression.
+			p.restore(pt)
+			return left, true
+		}
+		left = []interface{}{left, opVal, right}
+	}
+}
+
+// parseRestOfLineMatcher matches the input up to, but not including, the
+// next "\n" or "\r\n", or up to the end of the input.
+func (p *parser) parseRestOfLineMatcher(rest *restOfLineMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRestOfLineMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	start := p.pt
+	end := len(p.data)
+	if ix := bytes.IndexByte(p.data[start.offset:], '\n'); ix >= 0 {
+		if p.memoCache != nil {
+			p.examine(start.offset + ix + 1)
+		}
+		end = start.offset + ix
+		if end > start.offset && p.data[end-1] == '\r' {
+			end--
+		}
+	}
+	for p.pt.offset < end {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	}
+
+	if ref.name == "" {
+		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	}
+
+	rule := p.rules[ref.name]
+	if rule == nil {
+		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
+		return nil, false
+	}
+	return p.parseRule(rule)
+}
+
+// parseSepExpr parses one or more expressions separated by the separator,
+// its value is the slice of the values of the expressions.
+func (p *parser) parseSepExpr(sep *sepExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSepExpr"))
+	}
+
+	p.pushV()
+	val, ok := p.parseExpr(sep.expr)
+	p.popV()
+	if !ok {
+		return nil, false
+	}
+	vals := []interface{}{val}
+
+	for {
+		pt := p.pt
+		p.pushV()
+		sepVal, ok := p.parseExpr(sep.sep)
+		p.popV()
+		if !ok {
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(sep.expr)
+		p.popV()
+		if !ok {
+			if !sep.trailing {
+				// the separator is not part of the list
+				p.restore(pt)
+			} else if sep.keep {
+				vals = append(vals, sepVal)
+			}
+			return vals, true
+		}
+		if sep.keep {
+			vals = append(vals, sepVal)
+		}
+		vals = append(vals, val)
+	}
+}
+
+func (p *parser) parseSeqExpr(seq *seqExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSeqExpr"))
+	}
+
+	vals := make([]interface{}, 0, len(seq.exprs))
+
+	pt := p.pt
+	for _, expr := range seq.exprs {
+		val, ok := p.parseExpr(expr)
+		if !ok {
+			p.restore(pt)
+			return nil, false
+		}
+		vals = append(vals, val)
+	}
+	return vals, true
+}
+
+func (p *parser) parseSkipExpr(skip *skipExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSkipExpr"))
+	}
+
+	pt := p.pt
+	if p.skipFunc != nil && !p.tokMode {
+		for p.pt.offset < len(p.data) && p.skipFunc(p.pt.rn) {
+			p.read()
+		}
+	} else {
+		p.parseExpr(skip.skip)
+	}
+	val, ok := p.parseExpr(skip.expr)
+	if !ok {
+		p.restore(pt)
+		return nil, false
+	}
+	return val, true
+}
+
+func (p *parser) parseTokenMatcher(tm *tokenMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseTokenMatcher " + tm.name))
+	}
+
+	if !p.tokMode || p.pt.offset >= len(p.toks) {
+		p.setMaxSavePoint("", tm.name)
+		return nil, false
+	}
+	tok := p.toks[p.pt.offset]
+	if !tm.any && tok.Kind() != tm.kind {
+		p.setMaxSavePoint(tok.Text(), tm.name)
+		return nil, false
+	}
+	p.read()
+	return tok, true
+}
+
+// parseCompactExpr matches the expression of comp, and removes the nil
+// values from its value if it is a slice.
+func (p *parser) parseCompactExpr(comp *compactExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseCompactExpr"))
+	}
+
+	val, ok := p.parseExpr(comp.expr)
+	if !ok {
+		return nil, false
+	}
+	vals, isSlice := val.([]interface{})
+	if !isSlice {
+		return val, true
+	}
+	compact := make([]interface{}, 0, len(vals))
+	for _, v := range vals {
+		if v != nil {
+			compact = append(compact, v)
+		}
+	}
+	return compact, true
+}
+
+// parseWordListMatcher matches the longest of the words of the WordList
+// option at the current position, following the trie of the words.
+func (p *parser) parseWordListMatcher(wl *wordListMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseWordListMatcher"))
+	}
+
+	if p.tokMode || p.wordTrie == nil {
+		return nil, false
+	}
+	rest := p.data[p.pt.offset:]
+	n := 0
+	node := p.wordTrie
+	for i := 0; i < len(rest) && node != nil; i++ {
+		if p.memoCache != nil {
+			p.examine(p.pt.offset + i + 1 + utf8.UTFMax)
+		}
+		node = node.next[rest[i]]
+		if node == nil || !node.word {
+			continue
+		}
+		rn, _ := utf8.DecodeRune(rest[i+1:])
+		if i+1 < len(rest) && (rn == '_' || unicode.IsLetter(rn) || unicode.IsDigit(rn)) {
+			continue
+		}
+		n = i + 1
+	}
+	if n == 0 {
+		return nil, false
+	}
+	start := p.pt
+	for p.pt.offset < start.offset+n {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+// parseArrayExpr matches the elements of arr with its generated collect
+// function, that stores their values directly in an array of its type and
+// returns the array, or the value of the element that is not of that type.
+func (p *parser) parseArrayExpr(arr *arrayExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseArrayExpr"))
+	}
+
+	start := p.pt
+	val, bad, ok := arr.collect(p, arr)
+	if bad != nil {
+		p.addErrAt(fmt.Errorf("array element of type %T is not assignable to %s", bad, arr.typ), start.position)
+	}
+	if !ok {
+		p.restore(start)
+		return nil, false
+	}
+	return val, true
+}
+
+// parseArrayElem matches the expression of an element of arr.
+func (p *parser) parseArrayElem(arr *arrayExpr) (interface{}, bool) {
+	p.pushV()
+	val, ok := p.parseExpr(arr.expr)
+	p.popV()
+	return val, ok
+}
+
+// parseUnreservedExpr matches the expression of un, and fails if the text
+// of the match is one of the keywords.
+func (p *parser) parseUnreservedExpr(un *unreservedExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseUnreservedExpr"))
+	}
+
+	start := p.pt
+	val, ok := p.parseExpr(un.expr)
+	if !ok {
+		return nil, false
+	}
+	text := string(p.sliceFrom(start))
+	for _, word := range p.keywords {
+		if text == word {
+			p.restore(start)
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+func (p *parser) parseUntilMatcher(until *untilMatcher) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseUntilMatcher"))
+	}
+
+	if p.tokMode {
+		return nil, false
+	}
+	// scan for the delimiter in a single pass, then advance rune by rune
+	// up to it so that the position information stays accurate.
+	start := p.pt
+	end := len(p.data)
+	if ix := bytes.Index(p.data[start.offset:], []byte(until.val)); ix >= 0 {
+		end = start.offset + ix
+		if p.memoCache != nil {
+			p.examine(end + len(until.val))
+		}
+	}
+	for p.pt.offset < end {
+		p.read()
+	}
+	return p.sliceFrom(start), true
+}
+
+// parseWhenExpr matches the expression of when if its flag is set, and
+// fails otherwise.
+func (p *parser) parseWhenExpr(when *whenExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseWhenExpr"))
+	}
+
+	if !p.flags[when.flag] {
+		return nil, false
+	}
+	return p.parseExpr(when.expr)
+}
+
+func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseZeroOrMoreExpr"))
+	}
+
+	var vals []interface{}
+
+	for {
+		if !p.repeatWhile(expr.while, vals) {
+			return vals, true
+		}
+		p.pushV()
+		val, ok := p.parseExpr(expr.expr)
+		p.popV()
+		if !ok {
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+// repeatWhile reports whether a repetition may try another match, given
+// its condition and the values accumulated so far.
+func (p *parser) repeatWhile(while func(*parser, []interface{}) (bool, error), vals []interface{}) bool {
+	if while == nil {
+		return true
+	}
+	ok, err := while(p, vals)
+	if err != nil {
+		p.addErr(err)
+	}
+	return ok
+}
+
+func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (interface{}, bool) {
+	if p.debug {
+		defer p.out(p.in("parseZeroOrOneExpr"))
+	}
+
+	p.pushV()
+	val, _ := p.parseExpr(expr.expr)
+	p.popV()
+	// whether it matched or not, consider it a match
+	return val, true
+}
+
+func rangeTable(class string) *unicode.RangeTable {
+	if rt, ok := unicode.Categories[class]; ok {
+		return rt
+	}
+	if rt, ok := unicode.Properties[class]; ok {
+		return rt
+	}
+	if rt, ok := unicode.Scripts[class]; ok {
+		return rt
+	}
+
+	// cannot happen
+	panic(fmt.Sprintf("invalid Unicode class: %s", class))
+}
@@ -0,0 +1,24 @@
+package budget
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	// the budget of Loop applies to each of its matches
+	if _, err := Parse("", []byte("a,(xyz),b,(uvw)")); err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+
+	_, err := Parse("", []byte("a,("+strings.Repeat("x", 100)+"),b"))
+	want := "budget of 50 expressions of rule Loop exceeded"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	// the other rules are not bounded
+	if _, err := Parse("", []byte(strings.Repeat("a,", 1000)+"a")); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package capturespans
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCaptureSpans(t *testing.T) {
+	got, err := Parse("", []byte("ab = 123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]Pos{
+		{{Line: 1, Col: 1, Offset: 0}, {Line: 1, Col: 3, Offset: 2}},
+		{{Line: 1, Col: 6, Offset: 5}, {Line: 1, Col: 9, Offset: 8}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestCaptureSpansNoLabel(t *testing.T) {
+	got, err := ParseValue("", []byte("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [2]Pos{}; got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,52 @@
+package casefold
+
+import "testing"
+
+func TestCaseFold(t *testing.T) {
+	cases := map[string]bool{
+		"élan": true,
+		"ÉLAN": true,
+		"ÉlAn": true,
+		"elan": false,
+
+		// capital, small and final small sigma fold to each other
+		"lit:σΣς": true,
+		"lit:s":   false,
+
+		"straße":  true,
+		"STRAßE":  true,
+		"STRASSE": false, // the folding of ß to SS changes the number of runes
+
+		"class:éÉèÈÿŸ": true,
+		"class:E":      false,
+	}
+	for in, want := range cases {
+		_, err := Parse("", []byte(in))
+		if got := err == nil; got != want {
+			t.Errorf("%q: want match %t, got %t (%v)", in, want, got, err)
+		}
+	}
+}
+
+func TestFoldEqual(t *testing.T) {
+	cases := []struct {
+		rn, want rune
+		eq       bool
+	}{
+		{'é', 'é', true},
+		{'É', 'é', true},
+		{'Ÿ', 'ÿ', true},
+		{'Σ', 'ς', true},
+		{'ς', 'σ', true},
+		{'ſ', 's', true},      // long s
+		{'\u212a', 'k', true}, // Kelvin sign
+		{'İ', 'i', true},      // lowercases to i, with no simple folding
+		{'E', 'é', false},     // accents are not removed
+		{'ß', 's', false},
+	}
+	for _, c := range cases {
+		if got := foldEqual(c.rn, c.want); got != c.eq {
+			t.Errorf("foldEqual(%q, %q): want %t, got %t", c.rn, c.want, c.eq, got)
+		}
+	}
+}
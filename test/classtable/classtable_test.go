@@ -0,0 +1,41 @@
+package classtable
+
+import (
+	"testing"
+	"unicode"
+)
+
+// devanagari is the Devanagari block, letters and combining marks, as a
+// table built with golang.org/x/text/unicode/rangetable would be.
+var devanagari = &unicode.RangeTable{
+	R16: []unicode.Range16{{Lo: 0x0900, Hi: 0x097f, Stride: 1}},
+}
+
+func TestClassTable(t *testing.T) {
+	// the vowel signs and the virama are marks, not letters
+	in := "हिन्दी"
+	if _, err := Parse("", []byte(in)); err == nil {
+		t.Errorf("%q: want error with the letters of the unicode package", in)
+	}
+	got, err := Parse("", []byte(in), ClassTable("L", devanagari))
+	if err != nil {
+		t.Fatalf("%q: want no error with the class table, got %v", in, err)
+	}
+	if got != in {
+		t.Errorf("want %q, got %q", in, got)
+	}
+}
+
+func TestClassTableDefault(t *testing.T) {
+	for _, in := range []string{"abc", "_x1", "héllo"} {
+		if _, err := Parse("", []byte(in)); err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+		}
+	}
+	if _, err := Parse("", []byte("abc"), ClassTable("L", devanagari)); err == nil {
+		t.Error("want the table of the option to replace the letters")
+	}
+	if _, err := Parse("", []byte("abc"), ClassTable("L", devanagari), ClassTable("L", nil)); err != nil {
+		t.Errorf("want a nil table to restore the letters, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package compact
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	cases := map[string][]interface{}{
+		"ab":   {[]byte("a"), []byte("b")},
+		"a b":  {[]byte("a"), " ", []byte("b")},
+		"cd":   {[]byte("c"), nil, []byte("d")},
+		"c  d": {[]byte("c"), "  ", []byte("d")},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package concurrent
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentParser(t *testing.T) {
+	p := NewParser(Memoize(true))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				in := strings.Repeat(fmt.Sprintf("%d + ", i), j) + "1"
+				got, err := p.Parse("", []byte(in))
+				if err != nil {
+					errs <- fmt.Errorf("%q: want no error, got %v", in, err)
+					return
+				}
+				if want := i*j + 1; got != want {
+					errs <- fmt.Errorf("%q: want %d, got %v", in, want, got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if _, err := p.Parse("", []byte("1 +")); err == nil {
+		t.Errorf("want error, got none")
+	}
+}
+
+func TestParserReader(t *testing.T) {
+	p := NewParser()
+
+	var buf bytes.Buffer
+	buf.WriteString("1 + 2 - 4")
+	got, err := p.ParseReader("", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != -1 {
+		t.Errorf("want -1, got %v", got)
+	}
+
+	if _, err := p.ParseReader("", strings.NewReader("1 -")); err == nil {
+		t.Errorf("want error, got none")
+	}
+}
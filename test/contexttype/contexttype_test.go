@@ -0,0 +1,17 @@
+package contexttype
+
+import "testing"
+
+func TestContextType(t *testing.T) {
+	got, err := Parse("", []byte("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (greeting{name: "world"}); got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := Parse("", []byte("hello x")); err == nil {
+		t.Errorf("want error for a one-letter name, got none")
+	}
+}
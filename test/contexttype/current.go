@@ -0,0 +1,9 @@
+package contexttype
+
+// current is a type of the package that would collide with the current
+// struct of the parser generated with the default context type.
+type current string
+
+type greeting struct {
+	name string
+}
@@ -0,0 +1,74 @@
+package convert
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) (interface{}, error) {
+	return time.Parse("2006-01-02", s)
+}
+
+func TestConvert(t *testing.T) {
+	day := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	for in, want := range map[string]interface{}{
+		"port = 8080":     8080,
+		"ratio = 0.25":    0.25,
+		"debug = true":    true,
+		"at = 2001-02-03": day,
+	} {
+		got, err := Parse("", []byte(in), Converter("date", date))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		val := got.([]interface{})[1]
+		if !reflect.DeepEqual(val, want) {
+			t.Errorf("%q: want %T %v, got %T %v", in, want, want, val, val)
+		}
+	}
+}
+
+func TestConvertReplaceBuiltin(t *testing.T) {
+	text := func(s string) (interface{}, error) {
+		return "int " + s, nil
+	}
+	got, err := Parse("", []byte("port = 8080"), Converter("int", text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := got.([]interface{})[1]; val != "int 8080" {
+		t.Errorf("want %q, got %v", "int 8080", val)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		opts []Option
+		want string
+	}{
+		"converter error": {
+			in:   "at = 2001-13-03",
+			opts: []Option{Converter("date", date)},
+			want: "1:6 (5): rule Value: parsing time",
+		},
+		"undefined converter": {
+			in:   "at = 2001-02-03",
+			want: "1:6 (5): rule Value: undefined converter date",
+		},
+		"removed converter": {
+			in:   "at = 2001-02-03",
+			opts: []Option{Converter("date", date), Converter("date", nil)},
+			want: "1:6 (5): rule Value: undefined converter date",
+		},
+	}
+	for nm, tc := range cases {
+		_, err := Parse("", []byte(tc.in), tc.opts...)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: want error containing %q, got %v", nm, tc.want, err)
+		}
+	}
+}
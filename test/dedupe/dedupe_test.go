@@ -0,0 +1,37 @@
+package dedupe
+
+import "testing"
+
+func TestDedupeErrors(t *testing.T) {
+	in := "let a; 1; 2; 3;\nlet b; 4;"
+	cases := []struct {
+		window int
+		want   int
+	}{
+		{0, 4},
+		{2, 4},
+		{3, 3},
+		{6, 2},
+		{16, 1},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(in), DedupeErrors(tc.window))
+		if err == nil {
+			t.Errorf("%d: want errors, got none", tc.window)
+			continue
+		}
+		errs, ok := err.(errList)
+		if !ok {
+			t.Errorf("%d: want errList, got %T", tc.window, err)
+			continue
+		}
+		if len(errs) != tc.want {
+			t.Errorf("%d: want %d errors, got %d: %v", tc.window, tc.want, len(errs), err)
+		}
+	}
+
+	_, err := Parse("", []byte(in), DedupeErrors(100))
+	if want := "1:8 (7): rule BadStmt: malformed statement"; err == nil || err.Error() != want {
+		t.Errorf("want the first error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,86 @@
+package defaults
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultMemoize(t *testing.T) {
+	cases := []struct {
+		opts []Option
+		want int
+	}{
+		{nil, 1},
+		{[]Option{Memoize(true)}, 1},
+		{[]Option{Memoize(false)}, 2},
+	}
+	for _, tc := range cases {
+		calls = 0
+		if _, err := Parse("", []byte("ay"), tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+		if calls != tc.want {
+			t.Errorf("%d options: want %d calls, got %d", len(tc.opts), tc.want, calls)
+		}
+	}
+}
+
+// countingStore is a MemoStore that counts its accesses.
+type countingStore struct {
+	table      map[int]map[interface{}]MemoResult
+	gets, hits int
+	sets       int
+}
+
+func (s *countingStore) Get(node interface{}, offset int) (MemoResult, bool) {
+	s.gets++
+	res, ok := s.table[offset][node]
+	if ok {
+		s.hits++
+	}
+	return res, ok
+}
+
+func (s *countingStore) Set(node interface{}, offset int, res MemoResult) {
+	s.sets++
+	if s.table[offset] == nil {
+		s.table[offset] = make(map[interface{}]MemoResult)
+	}
+	s.table[offset][node] = res
+}
+
+func TestMemoStore(t *testing.T) {
+	calls = 0
+	want, err := Parse("", []byte("ay"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls = 0
+	s := &countingStore{table: make(map[int]map[interface{}]MemoResult)}
+	got, err := Parse("", []byte("ay"), WithMemoStore(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+	if s.gets == 0 || s.sets == 0 || s.hits == 0 {
+		t.Errorf("want gets, sets and hits, got %d, %d, %d", s.gets, s.sets, s.hits)
+	}
+	if s.gets != s.sets+s.hits {
+		t.Errorf("want a set for each get that misses, got %d gets, %d sets, %d hits", s.gets, s.sets, s.hits)
+	}
+
+	// the store is not used without memoization
+	s = &countingStore{table: make(map[int]map[interface{}]MemoResult)}
+	if _, err := Parse("", []byte("ay"), Memoize(false), WithMemoStore(s)); err != nil {
+		t.Fatal(err)
+	}
+	if s.gets != 0 || s.sets != 0 {
+		t.Errorf("want no access, got %d gets, %d sets", s.gets, s.sets)
+	}
+}
@@ -0,0 +1,39 @@
+package dispatch
+
+import "testing"
+
+func TestDispatch(t *testing.T) {
+	cases := map[string]string{
+		"GET /index.html\n":   "get /index.html",
+		"POST /form\na=1&b=2": "post /form a=1&b=2",
+		"POST /empty\n":       "post /empty ",
+	}
+	for in, want := range cases {
+		got, err := Dispatch("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+
+	// the errors are those of the entrypoint rule that the input is
+	// routed to, or of the first rule if there is none.
+	errs := map[string]string{
+		"GET /a":   "1:7 (6): syntax error, unexpected '\ufffd', expecting '\n'",
+		"POST \n":  "1:1 (0): no match found",
+		"PUT /a\n": "1:2 (1): syntax error, unexpected 'PU', expecting 'POST '",
+	}
+	for in, want := range errs {
+		_, err := Dispatch("", []byte(in))
+		if err == nil {
+			t.Errorf("%q: want error, got none", in)
+			continue
+		}
+		if err.Error() != want {
+			t.Errorf("%q: want error %q, got %q", in, want, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// runMain runs main with the arguments args and the standard input in,
+// and returns its standard output.
+func runMain(t *testing.T, args []string, in string) string {
+	dir, err := ioutil.TempDir("", "emitmain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stdin := filepath.Join(dir, "stdin")
+	if err := ioutil.WriteFile(stdin, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inf, err := os.Open(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inf.Close()
+	outf, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outf.Close()
+
+	oldArgs, oldIn, oldOut := os.Args, os.Stdin, os.Stdout
+	defer func() { os.Args, os.Stdin, os.Stdout = oldArgs, oldIn, oldOut }()
+	os.Args, os.Stdin, os.Stdout = append([]string{"emitmain"}, args...), inf, outf
+	main()
+
+	out, err := ioutil.ReadFile(outf.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestMainStdin(t *testing.T) {
+	if got := runMain(t, nil, "1+2+3"); got != "6\n" {
+		t.Errorf("want %q, got %q", "6\n", got)
+	}
+}
+
+func TestMainFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "emitmain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("40+2"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if got := runMain(t, []string{f.Name()}, "1"); got != "42\n" {
+		t.Errorf("want %q, got %q", "42\n", got)
+	}
+}
@@ -0,0 +1,92 @@
+package encoding
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSkipBOM(t *testing.T) {
+	in := []byte("\uFEFFa")
+	if _, err := Parse("", in); err == nil {
+		t.Errorf("want error without SkipBOM, got none")
+	}
+	got, err := Parse("", in, SkipBOM(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a" {
+		t.Errorf("want %q, got %q", "a", got)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	cases := []struct {
+		enc  string
+		in   []byte
+		opts []Option
+		want string
+	}{
+		{"latin1", []byte("a caf\xe9"), nil, "a café"},
+		{"utf-16be", []byte{0, 'a', 0, ' ', 0, 0xe9}, nil, "a é"},
+		{"utf-16le", []byte{'a', 0, ' ', 0, 0xe9, 0}, nil, "a é"},
+		{"utf-16", []byte{0xff, 0xfe, 'a', 0}, []Option{SkipBOM(true)}, "a"},
+		{"utf-16", []byte{0xfe, 0xff, 0, 'a'}, []Option{SkipBOM(true)}, "a"},
+		{"UTF-8", []byte("a é"), nil, "a é"},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", tc.in, append(tc.opts, Encoding(tc.enc))...)
+		if err != nil {
+			t.Errorf("%s %q: want no error, got %v", tc.enc, tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s %q: want %q, got %q", tc.enc, tc.in, tc.want, got)
+		}
+	}
+
+	for enc, in := range map[string][]byte{
+		"utf-16":  {0, 'a', 0},
+		"ebcdic":  []byte("a"),
+		"latin1":  []byte("b"),
+	} {
+		if _, err := Parse("", in, Encoding(enc)); err == nil {
+			t.Errorf("%s %q: want error, got none", enc, in)
+		}
+	}
+}
+
+// rot13 decodes ROT13-encoded ASCII input.
+func rot13(b []byte) ([]rune, error) {
+	rns := make([]rune, len(b))
+	for i, c := range b {
+		switch {
+		case c >= 0x80:
+			return nil, errors.New("invalid ROT13 input")
+		case c >= 'a' && c <= 'z':
+			c = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + (c-'A'+13)%26
+		}
+		rns[i] = rune(c)
+	}
+	return rns, nil
+}
+
+func TestDecoder(t *testing.T) {
+	// "n" is "a" in ROT13, and "nop" is "abc"
+	got, err := Parse("", []byte("n nop"), Decoder(rot13), Encoding("utf-16"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a abc" {
+		t.Errorf("want %q, got %q", "a abc", got)
+	}
+
+	if _, err := Parse("", []byte("a"), Decoder(rot13)); err == nil {
+		t.Errorf("want error for the input that is not decoded to a match, got none")
+	}
+	if _, err := Parse("", []byte("n\xe9"), Decoder(rot13)); err == nil || !strings.Contains(err.Error(), "invalid ROT13 input") {
+		t.Errorf("want decoder error, got %v", err)
+	}
+}
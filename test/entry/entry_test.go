@@ -0,0 +1,40 @@
+package entry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntrypoints(t *testing.T) {
+	got, err := ParseExpr("", []byte("1+2+3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 6 {
+		t.Errorf("ParseExpr: want 6, got %v", got)
+	}
+
+	got, err = ParseDecl("", []byte("let x=1+1;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "x=2" {
+		t.Errorf("ParseDecl: want %q, got %v", "x=2", got)
+	}
+
+	got, err = Parse("", []byte("let x=1;let y=2+3;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"x=1", "y=5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse: want %v, got %v", want, got)
+	}
+
+	// the entrypoints only match their rule
+	if _, err := ParseExpr("", []byte("let x=1;")); err == nil {
+		t.Errorf("ParseExpr: want error for a declaration, got none")
+	}
+	if _, err := Parse("", []byte("1+2")); err == nil {
+		t.Errorf("Parse: want error for an expression, got none")
+	}
+}
@@ -0,0 +1,58 @@
+package entryspace
+
+import "testing"
+
+func TestEntryWhitespace(t *testing.T) {
+	cases := []struct {
+		parse    func(string, []byte, ...Option) (interface{}, error)
+		in       string
+		leading  bool
+		eof      bool
+		err      bool
+		trailing bool
+	}{
+		// A is matched with the skip rule before its literal
+		{parse: Parse, in: "  a  "},
+		{parse: Parse, in: "  a  ", leading: true},
+		{parse: Parse, in: "  a  ", eof: true},
+		{parse: Parse, in: "  a  ", leading: true, eof: true},
+		{parse: Parse, in: "  a  b"},
+		{parse: Parse, in: "  a  b", leading: true, eof: true, err: true, trailing: true},
+
+		// Word is lexical, nothing is skipped inside it
+		{parse: ParseWord, in: "  ab  ", err: true},
+		{parse: ParseWord, in: "  ab  ", leading: true},
+		{parse: ParseWord, in: "  ab  ", eof: true, err: true},
+		{parse: ParseWord, in: "  ab  ", leading: true, eof: true},
+		{parse: ParseWord, in: "ab  ", eof: true},
+		{parse: ParseWord, in: "ab cd", leading: true, eof: true, err: true, trailing: true},
+	}
+	for i, tc := range cases {
+		_, err := tc.parse("", []byte(tc.in), SkipLeading(tc.leading), RequireTrailingEOF(tc.eof))
+		if (err != nil) != tc.err {
+			t.Errorf("%d: %q, leading %t, eof %t: want error %t, got %v", i, tc.in, tc.leading, tc.eof, tc.err, err)
+			continue
+		}
+		if !tc.trailing {
+			continue
+		}
+		el, ok := err.(errList)
+		if !ok || len(el) == 0 || el[0].(*parserError).Inner != errTrailingInput {
+			t.Errorf("%d: %q: want error %v, got %v", i, tc.in, errTrailingInput, err)
+		}
+	}
+}
+
+func TestEntryWhitespaceSkipFunc(t *testing.T) {
+	underscore := SkipFunc(func(r rune) bool { return r == '_' })
+	got, err := ParseWord("", []byte("__ab__"), underscore, SkipLeading(true), RequireTrailingEOF(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "ab" {
+		t.Errorf("want %q, got %v", "ab", got)
+	}
+	if _, err := ParseWord("", []byte("  ab"), underscore, SkipLeading(true)); err == nil {
+		t.Error("want error with the white space that SkipFunc does not skip")
+	}
+}
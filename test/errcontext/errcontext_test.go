@@ -0,0 +1,39 @@
+package errcontext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContextLines(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"a=1\nb=x\nc=3\n", -1, "f:2:3 (6): rule Value: value is not a number"},
+		{"a=1\nb=x\nc=3\n", 0, `f:2:3 (6): rule Value: value is not a number
+2 | b=x
+  |   ^`},
+		{"a=1\nb=x\nc=3\n", 1, `f:2:3 (6): rule Value: value is not a number
+1 | a=1
+2 | b=x
+  |   ^
+3 | c=3`},
+		{"a=1\n\tb=x", 5, "f:2:4 (7): rule Value: value is not a number\n1 | a=1\n2 | \tb=x\n  | \t  ^"},
+		{strings.Repeat("a=1\n", 9) + "b=x\n", 1, `f:10:3 (38): rule Value: value is not a number
+ 9 | a=1
+10 | b=x
+   |   ^`},
+	}
+	for _, tc := range cases {
+		_, err := Parse("f", []byte(tc.in), ContextLines(tc.n))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("%q: want error\n%s\ngot\n%s", tc.in, tc.want, err)
+		}
+	}
+}
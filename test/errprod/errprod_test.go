@@ -0,0 +1,68 @@
+package errprod
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestErrorProductions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []interface{}
+		err  string
+	}{
+		{"let a; let b;", []interface{}{"let a;", "let b;"}, ""},
+		{"let a; foo;\nlet b; bar baz;", []interface{}{"let a;", nil, "let b;", nil},
+			"1:8 (7): rule \"statement\": unexpected foo;\n2:8 (19): rule \"statement\": unexpected bar baz;"},
+		{"let 1;", []interface{}{nil}, "1:1 (0): rule \"statement\": unexpected let 1;"},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if tc.err == "" && err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%q: want error %q, got %v", tc.in, tc.err, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, tc.want, got)
+		}
+	}
+
+	// the error of an alternative that is backtracked over is dropped
+	if _, err := ParseUnused("", []byte("x")); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+
+	// a parse that fails does not report the errors of the error
+	// productions
+	if _, err := Parse("", []byte("let a; oops")); err == nil || err.Error() != "1:12 (11): syntax error, unexpected '\ufffd', expecting ';'" {
+		t.Errorf("want syntax error, got %v", err)
+	}
+}
+
+func TestRuleErrPrefix(t *testing.T) {
+	p := newParser("", []byte("x"))
+	for _, r := range g.rules {
+		want := "rule " + r.name
+		if r.displayName != "" {
+			want = "rule " + r.displayName
+		}
+		if got := p.ruleErrPrefix(r); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+		if n := testing.AllocsPerRun(10, func() { p.ruleErrPrefix(r) }); n != 0 {
+			t.Errorf("%s: want no allocation for a cached prefix, got %v", r.name, n)
+		}
+	}
+}
+
+func BenchmarkRuleErrors(b *testing.B) {
+	p := newParser("", []byte("x"))
+	p.rstack = []*rule{g.rules[0]}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		*p.errs = (*p.errs)[:0]
+		p.addErr(errNoMatch)
+	}
+}
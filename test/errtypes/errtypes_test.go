@@ -0,0 +1,74 @@
+package errtypes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestErrorTypes(t *testing.T) {
+	cases := []struct {
+		in   string
+		opts []Option
+		// returns true if the error has the expected type
+		is func(error) bool
+	}{
+		{"(x", nil, func(err error) bool {
+			var e *UnexpectedEOF
+			return errors.As(err, &e) && reflect.DeepEqual(e.Expected, []string{")"})
+		}},
+		{"(y)", nil, func(err error) bool {
+			var e *UnexpectedToken
+			return errors.As(err, &e) && e.Found == "y" && reflect.DeepEqual(e.Expected, []string{"(", "x"})
+		}},
+		{"(((x)))", []Option{MaxDepth(2)}, func(err error) bool {
+			var e *MaxDepthExceeded
+			return errors.As(err, &e)
+		}},
+		{"x,x,x", []Option{MaxRepeat(1)}, func(err error) bool {
+			var e *MaxRepeatExceeded
+			return errors.As(err, &e)
+		}},
+		{"x,x", []Option{MaxInputRunes(2)}, func(err error) bool {
+			var e *InputTooLarge
+			return errors.As(err, &e)
+		}},
+		{"x;", []Option{RequireTrailingEOF(true)}, func(err error) bool {
+			var e *TrailingInput
+			return errors.As(err, &e)
+		}},
+	}
+	for _, c := range cases {
+		_, err := Parse("", []byte(c.in), c.opts...)
+		if err == nil {
+			t.Errorf("%q: want an error, got none", c.in)
+			continue
+		}
+		if !c.is(err) {
+			t.Errorf("%q: want an error of the expected type, got %#v", c.in, err)
+		}
+	}
+}
+
+func TestErrorTypesEOF(t *testing.T) {
+	// the end of the input and an unexpected input are distinguished
+	_, err := Parse("", []byte("(x"))
+	var tok *UnexpectedToken
+	if errors.As(err, &tok) {
+		t.Errorf("want no UnexpectedToken at the end of the input, got %v", tok)
+	}
+	_, err = Parse("", []byte("(x;"))
+	var eof *UnexpectedEOF
+	if errors.As(err, &eof) {
+		t.Errorf("want no UnexpectedEOF before the end of the input, got %v", eof)
+	}
+	if !errors.As(err, &tok) {
+		t.Fatalf("want an UnexpectedToken, got %v", err)
+	}
+
+	// the messages are unchanged
+	want := "1:3 (2): syntax error, unexpected ';', expecting ')'"
+	if err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+}
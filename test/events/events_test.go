@@ -0,0 +1,56 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	cases := map[string][]string{
+		"xx": {
+			"start A 1:1",
+			"start B 1:1",
+			`text B 1:1 "x"`,
+			"end B 1:2",
+			"start B 1:2",
+			`text B 1:2 "x"`,
+			"end B 1:3",
+			"end A 1:3",
+		},
+		// the events of the first alternative are backtracked over
+		"xy": {
+			"start A 1:1",
+			"start B 1:1",
+			`text B 1:1 "x"`,
+			"end B 1:2",
+			`text A 1:2 "y"`,
+			"end A 1:3",
+		},
+	}
+	for in, want := range cases {
+		var got []string
+		fn := func(e Event) {
+			s := fmt.Sprintf("%s %s %d:%d", e.Kind, e.Rule, e.Pos.Line, e.Pos.Col)
+			if e.Kind == EventText {
+				s += fmt.Sprintf(" %q", e.Text)
+			}
+			got = append(got, s)
+		}
+		if _, err := Parse("", []byte(in), Events(fn)); err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want events %q, got %q", in, want, got)
+		}
+	}
+
+	called := false
+	if _, err := Parse("", []byte("xz"), Events(func(Event) { called = true })); err == nil {
+		t.Errorf("want error, got none")
+	}
+	if called {
+		t.Errorf("want no events for a failed parse")
+	}
+}
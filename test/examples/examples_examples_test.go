@@ -0,0 +1,15 @@
+package examples
+
+import "testing"
+
+func TestGrammarExamples(t *testing.T) {
+	examples := []string{
+		"1+2",
+		"(1 + 2) * 3",
+	}
+	for _, ex := range examples {
+		if _, err := Parse("", []byte(ex)); err != nil {
+			t.Errorf("%q: %v", ex, err)
+		}
+	}
+}
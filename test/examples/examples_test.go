@@ -0,0 +1,19 @@
+package examples
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestExamplesTestUpToDate(t *testing.T) {
+	b, err := ioutil.ReadFile("examples_examples_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"1+2",`, `"(1 + 2) * 3",`} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("want the generated test to contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package explain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	got := NewParser().Explain([]byte("12a"), "Number")
+	for _, want := range []string{
+		"explain rule Number:\n",
+		"    one or more 1:1: matched \"12\"\n",
+		"      class [0-9] 1:3: failed at \"a\"\n",
+		"    not predicate 1:3: failed\n",
+		"farthest failure: not predicate 1:3, at \"a\"\n",
+		"rule Number failed: ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want explanation to contain %q, got\n%s", want, got)
+		}
+	}
+}
+
+func TestExplainChoice(t *testing.T) {
+	got := NewParser().Explain([]byte("ab"), "Value")
+	for _, want := range []string{
+		"  choice 1:1: matched \"ab\"\n",
+		"    alternative 1: rule Number 1:1: failed\n",
+		"          class [0-9] 1:1: failed at \"a\"\n",
+		"    alternative 2: rule Word 1:1: matched \"ab\"\n",
+		"          class [a-z] 1:3: failed at end of input\n",
+		"rule Value matched \"ab\"\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want explanation to contain %q, got\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "farthest failure") {
+		t.Errorf("want no farthest failure for a match, got\n%s", got)
+	}
+}
+
+func TestExplainUndefinedRule(t *testing.T) {
+	got := NewParser().Explain([]byte("1"), "Nope")
+	if want := "rule Nope failed: 1:0 (0): undefined entrypoint rule Nope\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("want explanation to end with %q, got\n%s", want, got)
+	}
+}
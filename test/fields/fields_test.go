@@ -0,0 +1,21 @@
+package fields
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	m := make(map[string]int)
+	got, err := Parse("", []byte("a = 2\nb = ?\na++\nc = ?\nb++"), Symbols(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"a": 3, "b": 2, "c": 2}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("want symbols %v, got %v", want, m)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want result %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,52 @@
+package fold
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	cases := map[string]string{
+		"diff 1":     "1",
+		"diff 1-2":   "[1 - 2]",
+		"diff 1-2-3": "[[1 - 2] - 3]",
+		"pow 1":      "1",
+		"pow 1^2":    "[1 ^ 2]",
+		"pow 1^2^3":  "[1 ^ [2 ^ 3]]",
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if s := format(got); s != want {
+			t.Errorf("%q: want %s, got %s", in, want, s)
+		}
+	}
+}
+
+func TestFoldEval(t *testing.T) {
+	got, err := Parse("", []byte("diff 10-2-3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := eval(got); n != 5 {
+		t.Errorf("want (10-2)-3 = 5, got %d", n)
+	}
+}
+
+// format formats v with the operators as strings.
+func format(v interface{}) string {
+	if op, ok := v.([]interface{}); ok {
+		return fmt.Sprintf("[%s %s %s]", format(op[0]), op[1], format(op[2]))
+	}
+	return fmt.Sprint(v)
+}
+
+func eval(v interface{}) int {
+	if op, ok := v.([]interface{}); ok {
+		return eval(op[0]) - eval(op[2])
+	}
+	return v.(int)
+}
@@ -0,0 +1,48 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeParse(t *testing.T) {
+	cases := []struct {
+		in     string
+		status int
+		want   string
+	}{
+		{"1+2+3", http.StatusOK, `{"result":6}`},
+		{"1+", http.StatusUnprocessableEntity, `{"errors":[{"line":1,"col":1,"offset":0,"message":"no match found"}]}`},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		ServeParse(rec, httptest.NewRequest("POST", "/parse", strings.NewReader(tc.in)))
+		if rec.Code != tc.status {
+			t.Errorf("%q: want status %d, got %d", tc.in, tc.status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%q: want content type application/json, got %q", tc.in, ct)
+		}
+		if got := rec.Body.String(); got != tc.want {
+			t.Errorf("%q: want body %s, got %s", tc.in, tc.want, got)
+		}
+		var v map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+			t.Errorf("%q: want valid JSON, got %v", tc.in, err)
+		}
+	}
+}
+
+func TestServeParseMethod(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServeParse(rec, httptest.NewRequest("GET", "/parse", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "POST" {
+		t.Errorf("want Allow header POST, got %q", allow)
+	}
+}
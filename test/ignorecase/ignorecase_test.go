@@ -0,0 +1,23 @@
+package ignorecase
+
+import "testing"
+
+func TestIgnoreCase(t *testing.T) {
+	cases := map[string]bool{
+		"let x be y end": true,
+		"LET x BE y end": true,
+		"Let X iS Y end": true,
+		// the literals outside the region are case-sensitive
+		"let x be y END": false,
+		"LET x IS y End": false,
+	}
+	for in, valid := range cases {
+		_, err := Parse("", []byte(in))
+		if valid && err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package indent
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIndent(t *testing.T) {
+	cases := map[string]string{
+		"a\n":                           "[a]",
+		"a\nb":                          "[a b]",
+		"a:\n  b\n":                     "[a{b}]",
+		"a:\n  b\n  c\nd\n":             "[a{b c} d]",
+		"a:\n  b:\n    c\n  d\ne":       "[a{b{c} d} e]",
+		"a:\n\tb:\n\t\tc\n":             "[a{b{c}}]",
+		"a:\n    b:\n      c\n    d\n": "[a{b{c} d}]",
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if s := fmt.Sprint(got); s != want {
+			t.Errorf("%q: want %s, got %s", in, want, s)
+		}
+	}
+}
+
+func TestIndentInvalid(t *testing.T) {
+	cases := []string{
+		" a\n",          // indented first line
+		"a:\nb\n",       // missing indented block
+		"a:\n  b\n c\n", // dedent to an unknown level
+		"a\n  b\n",      // unexpected indent
+	}
+	for _, in := range cases {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
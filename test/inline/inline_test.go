@@ -0,0 +1,62 @@
+package inline
+
+import "testing"
+
+func TestInline(t *testing.T) {
+	cases := []string{"1", "123", "12.34"}
+	for _, in := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != in {
+			t.Errorf("%q: want %q, got %q", in, in, got)
+		}
+	}
+	for _, in := range []string{"", "1.", "a"} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
+
+func TestInlineNoRuleRef(t *testing.T) {
+	var refs []string
+	var walk func(interface{})
+	walk = func(expr interface{}) {
+		switch expr := expr.(type) {
+		case *actionExpr:
+			walk(expr.expr)
+		case *seqExpr:
+			for _, e := range expr.exprs {
+				walk(e)
+			}
+		case *oneOrMoreExpr:
+			walk(expr.expr)
+		case *zeroOrOneExpr:
+			walk(expr.expr)
+		case *ruleRefExpr:
+			refs = append(refs, expr.name)
+		}
+	}
+	walk(g.rules[0].expr)
+
+	// Digit is inlined, EOF is not a single matcher
+	if len(refs) != 1 || refs[0] != "EOF" {
+		t.Errorf("want only a reference to EOF, got %v", refs)
+	}
+}
+
+func BenchmarkInline(b *testing.B) {
+	d := []byte("1234567890.1234567890")
+	var exprs int
+	for i := 0; i < b.N; i++ {
+		p := newParser("", d)
+		if _, err := p.parse(g); err != nil {
+			b.Fatal(err)
+		}
+		exprs += p.exprCnt
+	}
+	b.ReportMetric(float64(exprs)/float64(b.N), "exprs/op")
+}
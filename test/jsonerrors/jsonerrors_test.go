@@ -0,0 +1,60 @@
+package jsonerrors
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type jsonError struct {
+	Offset   int      `json:"offset"`
+	Line     int      `json:"line"`
+	Col      int      `json:"col"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+	Found    *string  `json:"found"`
+	Expected []string `json:"expected"`
+}
+
+func TestJSONErrors(t *testing.T) {
+	_, err := Parse("", []byte("1,2,x"), JSONErrors(true))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	var got jsonError
+	if err := json.Unmarshal([]byte(err.Error()), &got); err != nil {
+		t.Fatalf("want JSON error, got %q: %v", err.Error(), err)
+	}
+	if got.Offset != 4 || got.Line != 1 || got.Col != 5 {
+		t.Errorf("want position 1:5 (4), got %d:%d (%d)", got.Line, got.Col, got.Offset)
+	}
+	if got.Found == nil || *got.Found != "x" {
+		t.Errorf("want found %q, got %v", "x", got.Found)
+	}
+	if want := []string{"0"}; !reflect.DeepEqual(got.Expected, want) {
+		t.Errorf("want expected %q, got %q", want, got.Expected)
+	}
+
+	_, err = Parse("", []byte("1,1234"), JSONErrors(true))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	got = jsonError{}
+	if err := json.Unmarshal([]byte(err.Error()), &got); err != nil {
+		t.Fatalf("want JSON error, got %q: %v", err.Error(), err)
+	}
+	if got.Rule != "number" || got.Message != "number too large" || got.Offset != 2 {
+		t.Errorf("want the error of rule number at offset 2, got %+v", got)
+	}
+	if got.Found != nil || len(got.Expected) != 0 {
+		t.Errorf("want no found and expected, got %+v", got)
+	}
+
+	_, err = Parse("", []byte("1,2,x"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if json.Valid([]byte(err.Error())) {
+		t.Errorf("want a text error by default, got %q", err.Error())
+	}
+}
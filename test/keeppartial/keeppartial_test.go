@@ -0,0 +1,40 @@
+package keeppartial
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeepPartial(t *testing.T) {
+	cases := []struct {
+		in   string
+		want interface{}
+	}{
+		{in: "1 + ", want: "1"},
+		{in: "1 + 2 + ", want: []interface{}{"+", "1", "2"}},
+		{in: "1 + 2 3", want: []interface{}{"+", "1", "2"}},
+		{in: "+", want: nil},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), KeepPartial(true))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want partial result %v, got %v", tc.in, tc.want, got)
+		}
+
+		got, err = Parse("", []byte(tc.in))
+		if err == nil || got != nil {
+			t.Errorf("%q: without KeepPartial: want nil and an error, got %v, %v", tc.in, got, err)
+		}
+	}
+
+	got, err := Parse("", []byte("1 + 2"), KeepPartial(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"+", "1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,37 @@
+package keyword
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	cases := []struct {
+		in    string
+		words []string
+		want  []string
+	}{
+		{"goto", nil, []string{"", "goto"}},
+		{"goto end", nil, nil},
+		{"goto end", []string{"goto"}, []string{"goto", "end"}},
+		{"goto", []string{"goto"}, nil},
+		{"gotox", []string{"goto"}, []string{"", "gotox"}},
+		{"gotox end", []string{"go", "goto", "gotox"}, []string{"gotox", "end"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), Keywords(tc.words...))
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("%q %v: want error, got %v", tc.in, tc.words, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q %v: %v", tc.in, tc.words, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q %v: want %v, got %v", tc.in, tc.words, tc.want, got)
+		}
+	}
+}
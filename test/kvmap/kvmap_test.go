@@ -0,0 +1,50 @@
+package kvmap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"":                  {},
+		"a=1 b=2":           {"a": 1, "b": 2},
+		"name = \"x\"\nn=3": {"name": "x", "n": 3},
+	}
+	for in, want := range cases {
+		for entry, parse := range map[string]func(string, []byte, ...Option) (interface{}, error){
+			"Config":   Parse,
+			"Override": ParseOverride,
+		} {
+			got, err := parse("", []byte(in))
+			if err != nil {
+				t.Errorf("%s: %q: %v", entry, in, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %q: want %v, got %v", entry, in, want, got)
+			}
+		}
+	}
+}
+
+func TestMapDuplicateError(t *testing.T) {
+	_, err := Parse("", []byte("a=1 b=2 a=3"))
+	if err == nil {
+		t.Fatal("want an error for the duplicate key")
+	}
+	if want := `1:9 (8): rule Config: duplicate key "a"`; !strings.Contains(err.Error(), want) {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestMapDuplicateLast(t *testing.T) {
+	got, err := ParseOverride("", []byte("a=1 b=2 a=3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"a": 3, "b": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
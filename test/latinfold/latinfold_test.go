@@ -0,0 +1,73 @@
+package latinfold
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	composed   = "café"
+	decomposed = "café"
+)
+
+func TestLatinFoldCompose(t *testing.T) {
+	for _, in := range []string{composed, decomposed} {
+		got, err := Parse("", []byte(in), LatinFold("compose"))
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if got != composed {
+			t.Errorf("%q: want %q, got %q", in, composed, got)
+		}
+	}
+}
+
+func TestLatinFoldDecompose(t *testing.T) {
+	// the grammar is written with the precomposed letter, that does not
+	// match the decomposed input
+	for _, in := range []string{composed, decomposed} {
+		if _, err := Parse("", []byte(in), LatinFold("decompose")); err == nil {
+			t.Errorf("%q: want an error with the decompose mode", in)
+		}
+	}
+}
+
+func TestLatinFoldNone(t *testing.T) {
+	if _, err := Parse("", []byte(composed)); err != nil {
+		t.Errorf("%q: %v", composed, err)
+	}
+	if _, err := Parse("", []byte(decomposed)); err == nil {
+		t.Errorf("%q: want an error without folding", decomposed)
+	}
+}
+
+func TestLatinFoldUnsupported(t *testing.T) {
+	// sequences outside of the Latin table are kept, even those that a
+	// Unicode normalization would convert
+	cases := []struct {
+		in, mode string
+	}{
+		{in: "e\u0323\u0302", mode: "compose"}, // e with two marks
+		{in: "\u03b1\u0301", mode: "compose"},  // Greek alpha and acute
+		{in: "\u1ec7", mode: "decompose"},      // precomposed e with two marks
+		{in: "\u03ac", mode: "decompose"},      // precomposed Greek alpha
+	}
+	for _, tc := range cases {
+		got, err := ParseText("", []byte(tc.in), LatinFold(tc.mode))
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if got != tc.in {
+			t.Errorf("%q: want the input kept with %s, got %q", tc.in, tc.mode, got)
+		}
+	}
+}
+
+func TestLatinFoldUnknown(t *testing.T) {
+	_, err := Parse("", []byte(composed), LatinFold("NFKC"))
+	if err == nil || !strings.Contains(err.Error(), `unknown Latin fold "NFKC"`) {
+		t.Errorf("want an unknown mode error, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestTokenize(t *testing.T) {
+	got, err := Tokenize("", []byte("1+2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Lexeme{
+		{Kind: "Number", Text: "1", Pos: Pos{Line: 1, Col: 1, Offset: 0}},
+		{Kind: "Op", Text: "+", Pos: Pos{Line: 1, Col: 2, Offset: 1}},
+		{Kind: "Number", Text: "2", Pos: Pos{Line: 1, Col: 3, Offset: 2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTokenizeSpaces(t *testing.T) {
+	got, err := Tokenize("", []byte(" (x1 *\n 2.5) "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds, texts []string
+	for _, tok := range got {
+		kinds = append(kinds, tok.Kind)
+		texts = append(texts, tok.Text)
+	}
+	if want := []string{"Op", "Ident", "Op", "Number", "Op"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("want kinds %v, got %v", want, kinds)
+	}
+	if want := []string{"(", "x1", "*", "2.5", ")"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("want texts %v, got %v", want, texts)
+	}
+	if want := (Pos{Line: 2, Col: 2, Offset: 8}); got[3].Pos != want {
+		t.Errorf("want position %v, got %v", want, got[3].Pos)
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	got, err := Tokenize("", []byte("1 + $"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if len(got) != 2 {
+		t.Errorf("want 2 tokens before the error, got %v", got)
+	}
+	if want := "1:5 (4): no match found"; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+}
+
+func TestTokenizeSkipFunc(t *testing.T) {
+	skip := func(r rune) bool { return r == ',' || unicode.IsSpace(r) }
+	got, err := Tokenize("", []byte("1, 2,3"), SkipFunc(skip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("want 3 tokens, got %v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package linecomment
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineComment(t *testing.T) {
+	want := []string{"a", "b"}
+
+	// the comments are not skipped without the option
+	if _, err := Parse("", []byte("a # note\n + b")); err == nil {
+		t.Errorf("want an error without the LineComment option")
+	}
+
+	for _, in := range []string{
+		"a + b",
+		"a # note\n + b",
+		"a # one\n# two\n\t+ b # end",
+		"a +# note\nb\n",
+		"a + b #",
+	} {
+		got, err := Parse("", []byte(in), LineComment("#"))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+
+	// the comments are skipped with the runes of the SkipFunc option, and
+	// at the start of the input with SkipLeading
+	in := "// head\n_a_// x\n+_b"
+	space := func(r rune) bool { return r == '_' || r == '\n' }
+	got, err := Parse("", []byte(in), LineComment("//"), SkipFunc(space), SkipLeading(true))
+	if err != nil {
+		t.Fatalf("%q: want no error, got %v", in, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%q: want %q, got %q", in, want, got)
+	}
+
+	// the prefix must match exactly
+	if _, err := Parse("", []byte("a / note\n + b"), LineComment("//")); err == nil {
+		t.Errorf("want an error for a comment without its prefix")
+	}
+}
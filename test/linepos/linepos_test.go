@@ -0,0 +1,75 @@
+package linepos
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// naivePos computes the position of off by scanning the input from the
+// start, as the parser counts lines and columns.
+func naivePos(data []byte, off int) Pos {
+	line, col := 1, 0
+	for _, rn := range string(data[:off]) {
+		col++
+		if rn == '\n' {
+			line++
+			col = 0
+		}
+	}
+	return Pos{Line: line, Col: col + 1, Offset: off}
+}
+
+func bigInput(lines int) []byte {
+	words := []string{"a", "éé", "\tbc", "", "日本語 x", "yz"}
+	var buf []string
+	for i := 0; i < lines; i++ {
+		buf = append(buf, strings.Repeat(words[i%len(words)], i%7))
+	}
+	return []byte(strings.Join(buf, "\n") + "\n")
+}
+
+func TestOffsetPos(t *testing.T) {
+	data := bigInput(500)
+	p := newParser("", data)
+	for off := 0; off <= len(data); {
+		want := naivePos(data, off)
+		if got := p.offsetPos(off); got != want {
+			t.Fatalf("offset %d: want %v, got %v", off, want, got)
+		}
+		if off == len(data) {
+			break
+		}
+		_, n := utf8.DecodeRune(data[off:])
+		off += n
+	}
+}
+
+func TestOffsetPosContext(t *testing.T) {
+	// the error context gets the line of the error from the line index
+	data := append(bigInput(50), 'x')
+	_, err := Parse("", data, ContextLines(1))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := "\n51 | x\n   |  ^"; !strings.Contains(err.Error(), want) {
+		t.Errorf("want error context %q, got %q", want, err)
+	}
+}
+
+func BenchmarkOffsetPos(b *testing.B) {
+	data := bigInput(100000)
+	p := newParser("", data)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.offsetPos((i * 7919) % len(data))
+	}
+}
+
+func BenchmarkOffsetPosNaive(b *testing.B) {
+	data := bigInput(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		naivePos(data, (i*7919)%len(data))
+	}
+}
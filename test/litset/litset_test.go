@@ -0,0 +1,45 @@
+package litset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLitSet(t *testing.T) {
+	cases := map[string]interface{}{
+		"main.go":    []interface{}{[]byte("."), []byte("go")},
+		"go.mod":     []interface{}{[]byte("."), []byte("mod")},
+		"go.sum":     []interface{}{[]byte("."), []byte("sum")},
+		"x.ab":       []interface{}{[]byte("."), []byte("ab")},
+		"x.a":        []interface{}{[]byte("."), []byte("a")},
+		"x_Test":     []byte("_Test"),
+		"x_internal": []byte("_internal"),
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+
+	// the expected literals are those of the set
+	errs := map[string]string{
+		"x.b":   "1:3 (2): syntax error, unexpected '.b', expecting '.go', '.mod', '.sum', '.ab', '.a'",
+		"x.g":   "1:4 (3): syntax error, unexpected '.g\uFFFD', expecting '.go'",
+		"x_tes": "1:6 (5): syntax error, unexpected '_tes\uFFFD', expecting '_test'",
+	}
+	for in, want := range errs {
+		_, err := Parse("", []byte(in))
+		if err == nil {
+			t.Errorf("%q: want error, got none", in)
+			continue
+		}
+		if err.Error() != want {
+			t.Errorf("%q: want error %q, got %q", in, want, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package longest
+
+import "testing"
+
+func TestLongest(t *testing.T) {
+	cases := map[string]string{
+		"a":    "a",
+		"ab":   "ab",
+		"abc":  "abc",
+		"abcd": "abcd",
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %q, got %v", in, want, got)
+		}
+	}
+
+	if _, err := ParseOrdered("", []byte("ab")); err == nil {
+		t.Errorf("want the ordered choice to match the shorter alternative, got no error")
+	}
+}
+
+func TestLongestTie(t *testing.T) {
+	cases := map[string]string{
+		"if":  "keyword",
+		"in":  "keyword",
+		"ifx": "name",
+		"x":   "name",
+	}
+	for in, want := range cases {
+		got, err := ParseKind("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %q, got %v", in, want, got)
+		}
+	}
+}
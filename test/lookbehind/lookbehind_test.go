@@ -0,0 +1,34 @@
+package lookbehind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookbehind(t *testing.T) {
+	cases := map[string][]string{
+		"":              nil,
+		"a #b c#d\t#e":  {"tag #b", "tag #e"},
+		"#a b":          nil,
+		"@a b @c\n@d":   {"mention @a", "mention @d"},
+		"c abc aBc xbc": {"after c", "after c"},
+		"é#u":           nil,
+		"é #u":          {"tag #u"},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if want == nil {
+			if got != nil && len(got.([]string)) != 0 {
+				t.Errorf("%q: want no match, got %v", in, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
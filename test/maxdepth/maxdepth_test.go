@@ -0,0 +1,37 @@
+package maxdepth
+
+import (
+	"strings"
+	"testing"
+)
+
+func nested(n int) []byte {
+	return []byte(strings.Repeat("(", n) + "x" + strings.Repeat(")", n))
+}
+
+func TestMaxDepth(t *testing.T) {
+	// Input and the n+1 nested Expr rules
+	if _, err := Parse("", nested(8), MaxDepth(10)); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	if _, err := Parse("", nested(1000)); err != nil {
+		t.Errorf("without limit: want no error, got %v", err)
+	}
+
+	_, err := Parse("", nested(9), MaxDepth(10))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	el, ok := err.(errList)
+	if !ok || len(el) == 0 || el[0].(*parserError).Inner != errMaxDepth {
+		t.Errorf("want error %v, got %v", errMaxDepth, err)
+	}
+}
+
+func TestMaxDepthDeep(t *testing.T) {
+	_, err := Parse("", nested(1000000), MaxDepth(100))
+	el, ok := err.(errList)
+	if !ok || len(el) == 0 || el[0].(*parserError).Inner != errMaxDepth {
+		t.Errorf("want error %v, got %v", errMaxDepth, err)
+	}
+}
@@ -0,0 +1,39 @@
+package maxinput
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxInputRunes(t *testing.T) {
+	cases := []struct {
+		in  string
+		max int
+		err bool
+	}{
+		{"", 1, false},
+		{"abc", 0, false},
+		{"abc", 3, false},
+		{"abc", 4, false},
+		{"abcd", 3, true},
+		// é is 2 bytes but a single rune
+		{"ééé", 3, false},
+		{"éééé", 3, true},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in), MaxInputRunes(tc.max))
+		if tc.err != (err != nil) {
+			t.Errorf("%q with max %d: want error %t, got %v", tc.in, tc.max, tc.err, err)
+			continue
+		}
+		if err != nil && !strings.HasSuffix(err.Error(), "input too large") {
+			t.Errorf("%q with max %d: want input too large error, got %v", tc.in, tc.max, err)
+		}
+	}
+
+	// the limit applies before parsing, an invalid input is not parsed
+	_, err := Parse("", []byte("ABCD"), MaxInputRunes(3))
+	if err == nil || !strings.HasSuffix(err.Error(), "input too large") {
+		t.Errorf("want input too large error, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package maxrepeat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxRepeat(t *testing.T) {
+	cases := []struct {
+		in  string
+		max int
+		err bool
+	}{
+		{in: strings.Repeat("a", 10), max: 10},
+		{in: strings.Repeat("a", 11), max: 10, err: true},
+		{in: strings.Repeat("1", 10), max: 10},
+		{in: strings.Repeat("1", 11), max: 10, err: true},
+		{in: strings.Repeat("a", 10) + strings.Repeat("1", 10), max: 10},
+		{in: strings.Repeat("a", 100000)},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in), MaxRepeat(tc.max))
+		if !tc.err {
+			if err != nil {
+				t.Errorf("%d runes, max %d: want no error, got %v", len(tc.in), tc.max, err)
+			}
+			continue
+		}
+		el, ok := err.(errList)
+		if !ok || len(el) == 0 || el[0].(*parserError).Inner != errMaxRepeat {
+			t.Errorf("%d runes, max %d: want error %v, got %v", len(tc.in), tc.max, errMaxRepeat, err)
+		}
+	}
+}
+
+func TestMaxRepeatPathological(t *testing.T) {
+	_, err := Parse("", []byte(strings.Repeat("a", 1000000)), MaxRepeat(1000))
+	el, ok := err.(errList)
+	if !ok || len(el) == 0 || el[0].(*parserError).Inner != errMaxRepeat {
+		t.Errorf("want error %v, got %v", errMaxRepeat, err)
+	}
+}
@@ -0,0 +1,46 @@
+package memolevel
+
+import (
+	"reflect"
+	"testing"
+)
+
+// nodeStore is a MemoStore that records the kinds of the nodes that it
+// stores.
+type nodeStore struct {
+	memoTable
+	rules, exprs int
+}
+
+func (s *nodeStore) Set(node interface{}, offset int, res MemoResult) {
+	if _, ok := node.(*rule); ok {
+		s.rules++
+	} else {
+		s.exprs++
+	}
+	s.memoTable.Set(node, offset, res)
+}
+
+func TestMemoLevelRule(t *testing.T) {
+	in := []byte("a=b,c,d=e")
+	want, err := Parse("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &nodeStore{memoTable: make(memoTable)}
+	got, err := Parse("", in, Memoize(true), WithMemoStore(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	// the parser is generated with -memo-level=rule
+	if s.rules == 0 {
+		t.Errorf("want the results of the rules memoized")
+	}
+	if s.exprs != 0 {
+		t.Errorf("want no result of an expression memoized, got %d", s.exprs)
+	}
+}
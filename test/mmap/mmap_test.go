@@ -0,0 +1,69 @@
+package mmap
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/craiggwilson/pigeon/mmap"
+)
+
+// allocated returns the number of bytes allocated by fn.
+func allocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestParseMappedFile(t *testing.T) {
+	const lines = 1 << 18
+	f, err := ioutil.TempFile("", "mmap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	line := bytes.Repeat([]byte("x"), 63)
+	for i := 0; i < lines; i++ {
+		f.Write(line)
+		f.Write([]byte("\n"))
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	size := uint64(lines * 64)
+
+	var got interface{}
+	mapped := allocated(func() {
+		got, err = mmap.ParseFile(f.Name(), func(nm string, b []byte) (interface{}, error) {
+			return Parse(nm, b)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != lines {
+		t.Errorf("want %d lines, got %v", lines, got)
+	}
+
+	read := allocated(func() {
+		got, err = ParseFile(f.Name())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != lines {
+		t.Errorf("want %d lines, got %v", lines, got)
+	}
+
+	if read < size {
+		t.Errorf("want at least %d bytes allocated when reading the file, got %d", size, read)
+	}
+	if mapped > size/16 {
+		t.Errorf("want at most %d bytes allocated when mapping the file, got %d", size/16, mapped)
+	}
+}
@@ -0,0 +1,38 @@
+package namespace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	cases := []struct {
+		in   string
+		want map[string]int
+	}{
+		{in: "let x = 1", want: map[string]int{"x": 1}},
+		{in: "let x = 1 + 2", want: map[string]int{"x": 3}},
+		{in: "let abc = (1 + 2) + (3 + (4))", want: map[string]int{"abc": 10}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestNamespaceError(t *testing.T) {
+	_, err := Parse("", []byte("let x = 1 +"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if !strings.Contains(err.Error(), "1:12 (11)") {
+		t.Errorf("want the error after the + of the expression, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package negclass
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNegatedClass(t *testing.T) {
+	cases := map[string][]string{
+		"a":                  {"a"},
+		"ab\ncd\n":           {"ab", "cd"},
+		"é€\n𝄞 x":            {"é€", "𝄞 x"},
+		"�\n�z":    {"�", "�z"},
+		"tab\tand\rcr\nlast": {"tab\tand\rcr", "last"},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestNegatedClassStopsAtNewline(t *testing.T) {
+	p := newParser("", []byte("a€\nb"))
+	p.read()
+	m := &charClassMatcher{chars: []rune{'\n'}, inverted: true}
+	var n int
+	for {
+		if _, ok := p.parseCharClassMatcher(m); !ok {
+			break
+		}
+		n++
+	}
+	if n != 2 || p.pt.rn != '\n' || p.pt.offset != 4 {
+		t.Errorf("want 2 runes matched up to the newline at offset 4, got %d at offset %d (%q)", n, p.pt.offset, p.pt.rn)
+	}
+}
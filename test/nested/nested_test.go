@@ -0,0 +1,41 @@
+package nested
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNested(t *testing.T) {
+	cases := map[string][]string{
+		"/* a /* b */ c */":         {"/* a /* b */ c */"},
+		"/* a */ b":                 {"/* a */", "b"},
+		"a /**/ /* /* */ */ b":      {"a", "/**/", "/* /* */ */", "b"},
+		"/* a /* b */ c */ d /*e*/": {"/* a /* b */ c */", "d", "/*e*/"},
+		"/* é */":                   {"/* é */"},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+
+	errs := map[string]string{
+		"/* a /* b */ c": "1:15 (14): syntax error, unexpected '�', expecting '*/'",
+		"a */":           "1:3 (2): syntax error, unexpected '*', expecting '/*'",
+	}
+	for in, want := range errs {
+		_, err := Parse("", []byte(in))
+		if err == nil {
+			t.Errorf("%q: want error, got none", in)
+			continue
+		}
+		if err.Error() != want {
+			t.Errorf("%q: want error %q, got %q", in, want, err)
+		}
+	}
+}
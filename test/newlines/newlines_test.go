@@ -0,0 +1,57 @@
+package newlines
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeNewlines(t *testing.T) {
+	cases := map[string][]interface{}{
+		"ab\r\ncd\r\n": {"ab\n", "cd\n"},
+		"ab\rcd\n":     {"ab\n", "cd\n"},
+		"ab\ncd\r":     {"ab\n", "cd\n"},
+	}
+	for in := range cases {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error without NormalizeNewlines, got none", in)
+		}
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in), NormalizeNewlines(true))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestNormalizeNewlinesPositions(t *testing.T) {
+	// the offsets refer to the input before the conversion
+	var warns []Warning
+	if _, err := Parse("", []byte("ab\r\ncd\r\n"), NormalizeNewlines(true), Warnings(&warns)); err != nil {
+		t.Fatal(err)
+	}
+	want := []Warning{
+		{Pos: Pos{Line: 1, Col: 1, Offset: 0}, Msg: "line"},
+		{Pos: Pos{Line: 2, Col: 1, Offset: 4}, Msg: "line"},
+	}
+	if !reflect.DeepEqual(warns, want) {
+		t.Errorf("want warnings %v, got %v", want, warns)
+	}
+
+	cases := map[string]string{
+		"ab\r\ncd!\r\n":       "2:3 (6): syntax error, unexpected '!'",
+		"ab\r\ncd\r\nef!\r\n": "3:3 (10): syntax error, unexpected '!'",
+		"ab\rcd!\r":           "2:3 (5): syntax error, unexpected '!'",
+	}
+	for in, want := range cases {
+		_, err := Parse("", []byte(in), NormalizeNewlines(true))
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%q: want error %q, got %v", in, want, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package nlsignificant
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNLSignificant(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []interface{}
+	}{
+		{in: "a = b\nc = d", want: []interface{}{"a=b", "c=d"}},
+		{in: "a = b\n\n  c = d\n", want: []interface{}{"a=b", "c=d"}},
+		// the expressions skip the newlines
+		{in: "a = b +\n  c\nd = (e\n+ f)\n", want: []interface{}{"a=b+c", "d=(e+f)"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestNLSignificantError(t *testing.T) {
+	// the statements must end with a newline
+	in := "a = b c = d"
+	want := "1:7 (6): syntax error, unexpected 'c', expecting '+', '\n'"
+	if _, err := Parse("", []byte(in)); err == nil || err.Error() != want {
+		t.Errorf("%q: want error %q, got %v", in, want, err)
+	}
+}
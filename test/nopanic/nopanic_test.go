@@ -0,0 +1,60 @@
+package nopanic
+
+import (
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNoPanicCode(t *testing.T) {
+	src, err := ioutil.ReadFile("nopanic.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`\b(panic|recover)\(`)
+	if loc := re.FindIndex(src); loc != nil {
+		t.Errorf("want no call to panic nor recover, got %q", src[loc[0]:loc[1]])
+	}
+}
+
+func TestNoPanic(t *testing.T) {
+	got, err := Parse("", []byte("(1 (2 3) ())"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{1, []interface{}{2, 3}, []interface{}(nil)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestNoPanicErrors(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		opts []Option
+		err  error
+	}{
+		{in: "(1 x)"},
+		{in: "(1 (2)"},
+		{in: strings.Repeat("(", 20) + strings.Repeat(")", 20), opts: []Option{MaxDepth(10)}, err: errMaxDepth},
+		{in: "(1 2 3 4)", opts: []Option{MaxRepeat(2)}, err: errMaxRepeat},
+	} {
+		got, err := Parse("", []byte(tc.in), tc.opts...)
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		if got != nil {
+			t.Errorf("%q: want nil value, got %v", tc.in, got)
+		}
+		if tc.err == nil {
+			continue
+		}
+		el, ok := err.(errList)
+		if !ok || len(el) != 1 || el[0].(*parserError).Inner != tc.err {
+			t.Errorf("%q: want error %v, got %v", tc.in, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package normalizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTextNormalizer(t *testing.T) {
+	var rules []string
+	lower := func(rule, text string) string {
+		rules = append(rules, rule)
+		if rule == "Ident" {
+			return strings.ToLower(text)
+		}
+		return text
+	}
+	got, err := Parse("", []byte(`FOO foo "BAR"`), TextNormalizer(lower))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"foo", "foo", `"BAR"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want := []string{"Ident", "Ident", "String", "Input"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("want rules %v, got %v", want, rules)
+	}
+}
+
+func TestTextNormalizerNone(t *testing.T) {
+	got, err := Parse("", []byte(`FOO foo "BAR"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"FOO", "foo", `"BAR"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
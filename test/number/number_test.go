@@ -0,0 +1,93 @@
+package number
+
+import "testing"
+
+func TestNumber(t *testing.T) {
+	cases := map[string]interface{}{
+		"int 42":                   int64(42),
+		"int -42":                  int64(-42),
+		"int +7":                   int64(7),
+		"int 9223372036854775807":  int64(9223372036854775807),
+		"int -9223372036854775808": int64(-9223372036854775808),
+		"float -1.5e3":             float64(-1500),
+		"float 3":                  float64(3),
+		"float 0.25":               float64(0.25),
+		"float 2E-2":               float64(0.02),
+		"float +1e+2":              float64(100),
+		"hex ff":                   int64(255),
+		"hex 1A":                   int64(26),
+		"bin 1010":                 int64(10),
+		"nat 0":                    int64(0),
+		"radix 0xFF":               int64(255),
+		"radix 0Xff":               int64(255),
+		"radix -0x10":              int64(-16),
+		"radix 0b1010":             int64(10),
+		"radix 0B11":               int64(3),
+		"radix 0o17":               int64(15),
+		"radix +0O7":               int64(7),
+		"us 1,234.56":              float64(1234.56),
+		"us 12,345,678":            float64(12345678),
+		"us 1234.5":                float64(1234.5),
+		"us 0.5e3":                 float64(500),
+		"eu 1.234,56":              float64(1234.56),
+		"eu 1.234.567":             float64(1234567),
+		"eu 3,25":                  float64(3.25),
+		"count 1 000 000":          int64(1000000),
+		"count 999":                int64(999),
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %v (%T), got %v (%T)", in, want, want, got, got)
+		}
+	}
+}
+
+func TestNumberInvalid(t *testing.T) {
+	for _, in := range []string{
+		"nat -1",
+		"nat ",
+		"int -",
+		"bin 102",
+		"us 1,23",
+		"us 1234,567",
+		"us 1,234,5678",
+		"eu 1,234.56",
+		"count 1000 000",
+		"float 1.",
+		"float .5",
+		"float 1e",
+		"hex 0x1f",
+		"int 9223372036854775808",
+		"int -9223372036854775809",
+		"float 1e400",
+		"radix 0x",
+		"radix 0xG",
+		"radix 0b2",
+		"radix 0o8",
+		"radix 0z1",
+		"radix 0",
+		"radix 12",
+		"radix x1F",
+		"radix 0x-1",
+	} {
+		if got, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got %v", in, got)
+		}
+	}
+}
+
+func TestNumberRange(t *testing.T) {
+	_, err := Parse("", []byte("int 99999999999999999999"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	el, ok := err.(errList)
+	if !ok || len(el) == 0 || el[0].(*parserError).Inner != errNumberRange {
+		t.Errorf("want error %v, got %v", errNumberRange, err)
+	}
+}
@@ -0,0 +1,42 @@
+package onmatch
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestOnMatch(t *testing.T) {
+	cases := map[string][]string{
+		"xx": {
+			"B 1:1 [0] 1:2 [1] x",
+			"B 1:2 [1] 1:3 [2] x",
+			"A 1:1 [0] 1:3 [2] [x x]",
+			"Start 1:1 [0] 1:3 [2] [[x x] <nil>]",
+		},
+		// A backtracks after its first B, that is not reported
+		"xy": {
+			"B 1:1 [0] 1:2 [1] x",
+			"Start 1:1 [0] 1:3 [2] [x [121] <nil>]",
+		},
+		"y": nil,
+	}
+	for in, want := range cases {
+		var got []string
+		fn := func(rule string, start, end Pos, v interface{}) {
+			got = append(got, fmt.Sprintf("%s %d:%d [%d] %d:%d [%d] %v",
+				rule, start.Line, start.Col, start.Offset, end.Line, end.Col, end.Offset, v))
+		}
+		_, err := Parse("", []byte(in), OnMatch(fn))
+		if want == nil {
+			if err == nil {
+				t.Errorf("%q: want error, got none", in)
+			}
+		} else if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package operators
+
+import (
+	"fmt"
+	"testing"
+)
+
+// format returns the parenthesized representation of the tree of
+// binary operations.
+func format(v interface{}) string {
+	if op, ok := v.([]interface{}); ok {
+		return fmt.Sprintf("(%s %s %s)", format(op[0]), op[1], format(op[2]))
+	}
+	return fmt.Sprint(v)
+}
+
+func TestOperators(t *testing.T) {
+	cases := []struct {
+		in   string
+		tree string
+		val  int
+	}{
+		{"1", "1", 1},
+		{"1+2*3", "(1 + (2 * 3))", 7},
+		{"1*2+3", "((1 * 2) + 3)", 5},
+		{"10-4-3", "((10 - 4) - 3)", 3},
+		{"2**3**2", "(2 ** (3 ** 2))", 512},
+		{"2*3**2", "(2 * (3 ** 2))", 18},
+		{"(1+2)*3", "((1 + 2) * 3)", 9},
+		{"8/2/2+1*2**2", "(((8 / 2) / 2) + (1 * (2 ** 2)))", 6},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if tree := format(got); tree != tc.tree {
+			t.Errorf("%q: want tree %s, got %s", tc.in, tc.tree, tree)
+		}
+		if val := eval(got); val != tc.val {
+			t.Errorf("%q: want %d, got %d", tc.in, tc.val, val)
+		}
+	}
+}
+
+func TestOperatorsInvalid(t *testing.T) {
+	for _, in := range []string{"", "1+", "+1", "1**", "(1+2"} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
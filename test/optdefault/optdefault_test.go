@@ -0,0 +1,40 @@
+package optdefault
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	cases := map[string]interface{}{
+		"n 12": 12,
+		"n":    0,
+		"n ":   0,
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+
+	// without a default, the value is nil
+	got, err := ParseOpt("", []byte("n"))
+	if err != nil || got != nil {
+		t.Errorf("want nil, got %v, %v", got, err)
+	}
+}
+
+func TestDefaultError(t *testing.T) {
+	if _, err := ParseFail("", []byte("n 1")); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	_, err := ParseFail("", []byte("n"))
+	if err == nil || !strings.Contains(err.Error(), "number required") {
+		t.Errorf("want error %q, got %v", "number required", err)
+	}
+}
@@ -0,0 +1,48 @@
+package ownership
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOwnership(t *testing.T) {
+	in := "/* é */ abc\n/**/ déf"
+	m := make(map[string]int)
+	if _, err := Parse("", []byte(in), Ownership(m)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Letter is a single matcher, inlined in Word
+	want := map[string]int{"Comment": 11, "Word": 6, "Space": 3}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("want %v, got %v", want, m)
+	}
+	var sum int
+	for _, n := range m {
+		sum += n
+	}
+	if n := utf8.RuneCountInString(in); sum != n {
+		t.Errorf("want ownership to sum to %d, got %d", n, sum)
+	}
+}
+
+func TestOwnershipBacktrack(t *testing.T) {
+	// "/*" without a terminator backtracks out of Comment
+	m := make(map[string]int)
+	if _, err := Parse("", []byte("ab /*"), Ownership(m)); err == nil {
+		t.Fatal("want error, got none")
+	}
+	if len(m) != 0 {
+		t.Errorf("want no ownership recorded on failure, got %v", m)
+	}
+
+	m = make(map[string]int)
+	if _, err := Parse("", []byte("ab /**/"), Ownership(m)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"Comment": 4, "Word": 2, "Space": 1}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("want %v, got %v", want, m)
+	}
+}
@@ -0,0 +1,39 @@
+package panicctx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parsePanic(t *testing.T, in string, opts ...Option) (e interface{}) {
+	defer func() {
+		e = recover()
+	}()
+	if _, err := Parse("", []byte(in), opts...); err != nil {
+		t.Errorf("want a panic, got error %v", err)
+	}
+	return nil
+}
+
+func TestPanicContext(t *testing.T) {
+	got := parsePanic(t, "1,0,2", Recover(false), PanicContext(true))
+	want := &RulePanic{Rule: "Number", Pos: Pos{Line: 1, Col: 4, Offset: 3}, Value: "zero"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want panic %#v, got %#v", want, got)
+	}
+	if msg, want := got.(error).Error(), "1:4 (3): rule Number: panic: zero"; msg != want {
+		t.Errorf("want message %q, got %q", want, msg)
+	}
+}
+
+func TestPanicContextDisabled(t *testing.T) {
+	if got := parsePanic(t, "1,0,2", Recover(false)); got != "zero" {
+		t.Errorf("want the original panic value, got %#v", got)
+	}
+
+	// with recover, the panic is an error
+	_, err := Parse("", []byte("0"), PanicContext(true))
+	if want := "1:2 (1): rule Number: zero"; err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,39 @@
+package parseinto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInto(t *testing.T) {
+	var ast *AST
+	if err := ParseInto("", []byte("a=b;c=d"), &ast); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a": "b", "c": "d"}; ast == nil || !reflect.DeepEqual(ast.Vars, want) {
+		t.Errorf("want %v, got %v", want, ast)
+	}
+
+	var kv [2]string
+	if err := ParseAssignInto("", []byte("x=y"), &kv); err != nil {
+		t.Fatal(err)
+	}
+	if want := [2]string{"x", "y"}; kv != want {
+		t.Errorf("want %v, got %v", want, kv)
+	}
+
+	// the pointer is left unchanged if the parse fails
+	ast = nil
+	if err := ParseInto("", []byte("a="), &ast); err == nil {
+		t.Error("want error, got none")
+	}
+	if ast != nil {
+		t.Errorf("want nil, got %v", ast)
+	}
+
+	// a value that is not of the @type of the rule is an error
+	var n int
+	if err := ParseWrongInto("", []byte("x"), &n); err == nil || err.Error() != "rule Wrong: value of type string, want int" {
+		t.Errorf("want type error, got %v", err)
+	}
+}
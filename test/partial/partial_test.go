@@ -0,0 +1,35 @@
+package partial
+
+import "testing"
+
+func TestParsePartial(t *testing.T) {
+	cases := []struct {
+		in, val, rest string
+	}{
+		{"abXYZ", "ab", "XYZ"},
+		{"ab", "ab", ""},
+		{"ab,cd,XY", "ab,cd", ",XY"},
+		{"ab é", "ab", " é"},
+	}
+	for _, c := range cases {
+		val, rest, err := ParsePartial("", []byte(c.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", c.in, err)
+			continue
+		}
+		if val != c.val {
+			t.Errorf("%q: want value %q, got %q", c.in, c.val, val)
+		}
+		if string(rest) != c.rest {
+			t.Errorf("%q: want rest %q, got %q", c.in, c.rest, rest)
+		}
+	}
+
+	val, rest, err := ParsePartial("", []byte("XYZ"))
+	if err == nil {
+		t.Errorf("want error, got none")
+	}
+	if val != nil || rest != nil {
+		t.Errorf("want no value and rest, got %v and %q", val, rest)
+	}
+}
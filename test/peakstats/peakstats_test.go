@@ -0,0 +1,43 @@
+package peakstats
+
+import "testing"
+
+func TestPeakStacks(t *testing.T) {
+	cases := []struct {
+		in               string
+		rules, exprs, vs int
+	}{
+		// the choice of the rule, the sequence of its first alternative
+		// and the literal that starts it, and the variable sets of the rule
+		// and of the alternative
+		{"x", 1, 3, 2},
+		// each level adds a reference to the rule, its choice and the
+		// sequence of the first alternative, and two variable sets
+		{"(x)", 2, 6, 4},
+		{"((x))", 3, 9, 6},
+		{"(((((x)))))", 6, 18, 12},
+	}
+	for _, c := range cases {
+		var st Stats
+		if _, err := Parse("", []byte(c.in), Statistics(&st)); err != nil {
+			t.Errorf("%q: want no error, got %v", c.in, err)
+			continue
+		}
+		if st.PeakRuleStack != c.rules || st.PeakExprStack != c.exprs || st.PeakValueStack != c.vs {
+			t.Errorf("%q: want peaks %d, %d, %d, got %d, %d, %d", c.in, c.rules, c.exprs, c.vs,
+				st.PeakRuleStack, st.PeakExprStack, st.PeakValueStack)
+		}
+	}
+
+	// the peaks are reset for each parse
+	var st Stats
+	if _, err := Parse("", []byte("((x))"), Statistics(&st)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte("x"), Statistics(&st)); err != nil {
+		t.Fatal(err)
+	}
+	if st.PeakRuleStack != 1 {
+		t.Errorf("want a peak of 1 rule after a flat parse, got %d", st.PeakRuleStack)
+	}
+}
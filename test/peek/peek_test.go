@@ -0,0 +1,47 @@
+package peek
+
+import "testing"
+
+func TestPeek(t *testing.T) {
+	got, err := Parse("", []byte("123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := got.([]int)
+	if vals[0] != 123 || vals[1] != 123 {
+		t.Errorf("want [123 123], got %v", vals)
+	}
+}
+
+func TestPeekNoMatch(t *testing.T) {
+	if _, err := Parse("", []byte("abc")); err == nil {
+		t.Error("want error, got none")
+	}
+}
+
+func TestPeekAnd(t *testing.T) {
+	got, err := ParseAnd("", []byte("123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("want nil, got %v", got)
+	}
+}
+
+func TestPeekPosition(t *testing.T) {
+	p := newParser("", []byte("42x"))
+	p.grammar = g
+	p.buildRulesTable(g)
+	p.read()
+	val, ok := p.parsePeekExpr(&peekExpr{expr: &ruleRefExpr{name: "Number"}})
+	if !ok {
+		t.Fatal("want match, got none")
+	}
+	if val != 42 {
+		t.Errorf("want 42, got %v", val)
+	}
+	if p.pt.offset != 0 {
+		t.Errorf("want offset 0, got %d", p.pt.offset)
+	}
+}
@@ -0,0 +1,48 @@
+package predalloc
+
+import (
+	"strings"
+	"testing"
+)
+
+var input = []byte(strings.Repeat("a", 1000))
+
+func TestUnusedLabelAllocs(t *testing.T) {
+	labeled := testing.AllocsPerRun(10, func() { ParseLabeled("", input) })
+	plain := testing.AllocsPerRun(10, func() { ParsePlain("", input) })
+	if labeled > plain {
+		t.Errorf("want at most %v allocs with an unused label, got %v", plain, labeled)
+	}
+}
+
+func TestUsedLabel(t *testing.T) {
+	cases := []struct {
+		in  string
+		err bool
+	}{
+		{in: ""},
+		{in: "aaa"},
+		{in: "aab", err: true},
+		{in: "b", err: true},
+	}
+	for _, tc := range cases {
+		_, err := ParseUsed("", []byte(tc.in))
+		if (err != nil) != tc.err {
+			t.Errorf("%q: want error %t, got %v", tc.in, tc.err, err)
+		}
+	}
+}
+
+func BenchmarkLabeled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseLabeled("", input)
+	}
+}
+
+func BenchmarkPlain(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParsePlain("", input)
+	}
+}
@@ -0,0 +1,40 @@
+package prefix
+
+import "testing"
+
+func TestLongestPrefix(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "1 + 2", want: "1 + 2"},
+		{in: "1 + + 2", want: "1 + ", err: true},
+		{in: "1 + 2 3", want: "1 + 2 ", err: true},
+		{in: "+ 1", want: "", err: true},
+		{in: "", want: "", err: true},
+	}
+	for _, tc := range cases {
+		var got Prefix
+		_, err := Parse("", []byte(tc.in), LongestPrefix(&got))
+		if (err != nil) != tc.err {
+			t.Errorf("%q: want error %t, got %v", tc.in, tc.err, err)
+		}
+		if got.Text != tc.want {
+			t.Errorf("%q: want prefix %q, got %q", tc.in, tc.want, got.Text)
+		}
+		if got.End.Offset != len(tc.want) || got.End.Col != len(tc.want)+1 {
+			t.Errorf("%q: want prefix end at offset %d, got %+v", tc.in, len(tc.want), got.End)
+		}
+	}
+}
+
+func TestLongestPrefixTrailingInput(t *testing.T) {
+	var got Prefix
+	if _, err := ParseNumber("", []byte("1 + 2"), LongestPrefix(&got), RequireTrailingEOF(true)); err == nil {
+		t.Fatal("want error, got none")
+	}
+	if got.Text != "1" || got.End.Offset != 1 {
+		t.Errorf("want prefix %q ending at 1, got %q ending at %d", "1", got.Text, got.End.Offset)
+	}
+}
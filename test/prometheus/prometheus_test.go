@@ -0,0 +1,65 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrometheus(t *testing.T) {
+	var total, st Stats
+	for _, in := range []string{"a b c() d", "a ("} {
+		Parse("", []byte(in), Statistics(&st))
+		total.Add(st)
+	}
+	got := total.Prometheus()
+	for _, want := range []string{
+		"# HELP pigeon_rule_success_total Number of matches of the rule.\n" +
+			"# TYPE pigeon_rule_success_total counter\n" +
+			"pigeon_rule_success_total{rule=\"List\"} 1\n" +
+			"pigeon_rule_success_total{rule=\"Item\"} 5\n" +
+			"pigeon_rule_success_total{rule=\"Call\"} 1\n" +
+			"pigeon_rule_success_total{rule=\"Name\"} 9\n",
+		"# TYPE pigeon_rule_fail_total counter\n" +
+			"pigeon_rule_fail_total{rule=\"List\"} 1\n" +
+			"pigeon_rule_fail_total{rule=\"Item\"} 2\n" +
+			"pigeon_rule_fail_total{rule=\"Call\"} 6\n" +
+			"pigeon_rule_fail_total{rule=\"Name\"} 4\n",
+		"# TYPE pigeon_peak_rule_stack gauge\npigeon_peak_rule_stack 4\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want exposition to contain\n%s\ngot\n%s", want, got)
+		}
+	}
+}
+
+func TestPrometheusFormat(t *testing.T) {
+	var st Stats
+	if _, err := Parse("", []byte("a"), Statistics(&st)); err != nil {
+		t.Fatal(err)
+	}
+	// each line is a comment or a sample of a metric declared by a TYPE
+	// comment
+	types := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(st.Prometheus(), "\n"), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "# TYPE "):
+			if len(fields) != 4 || (fields[3] != "counter" && fields[3] != "gauge") {
+				t.Errorf("invalid TYPE line %q", line)
+			}
+			types[fields[2]] = true
+		case strings.HasPrefix(line, "# HELP "):
+		default:
+			name := fields[0]
+			if i := strings.IndexByte(name, '{'); i >= 0 {
+				name = name[:i]
+			}
+			if len(fields) != 2 || !types[name] {
+				t.Errorf("invalid sample line %q", line)
+			}
+		}
+	}
+	if len(types) != 5 {
+		t.Errorf("want 5 metrics, got %d", len(types))
+	}
+}
@@ -0,0 +1,44 @@
+package reserved
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReserved(t *testing.T) {
+	cases := map[string][]string{
+		"if":      {"keyword if"},
+		"iffy":    {"identifier iffy"},
+		"if-else": {"identifier if-else"},
+		"if iffy else elsewhere": {
+			"keyword if", "identifier iffy", "keyword else", "identifier elsewhere",
+		},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in), Keywords("if", "else"))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestReservedIdentifier(t *testing.T) {
+	// a reserved word is not an identifier, even if it is not matched as
+	// a keyword because of the following '-'
+	if _, err := Parse("", []byte("if-"), Keywords("if")); err == nil {
+		t.Errorf("want error, got none")
+	}
+
+	// without keywords, every word is an identifier
+	got, err := Parse("", []byte("if"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"identifier if"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
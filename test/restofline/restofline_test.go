@@ -0,0 +1,28 @@
+package restofline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestOfLine(t *testing.T) {
+	cases := map[string]map[string]string{
+		"":                       {},
+		"a: b":                   {"a": "b"},
+		"a: b\n":                 {"a": "b"},
+		"a:\nb: c d \n":          {"a": "", "b": "c d "},
+		"a: 1\r\nb: 2\r\n":       {"a": "1", "b": "2"},
+		"a: x\ry\nb: é: ü":       {"a": "x\ry", "b": "é: ü"},
+		"key : value: more\nz:z": {"key": "value: more", "z": "z"},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package reuse
+
+import (
+	"strings"
+	"testing"
+)
+
+var input = []byte(strings.Repeat("(1+", 10) + "1" + strings.Repeat(")", 10) + "+2")
+
+func TestReusableParser(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int
+		err  bool
+	}{
+		{"1+2", 3, false},
+		{string(input), 13, false},
+		{"1+", 0, true},
+		{"(4+5)+6", 15, false},
+		{"7", 7, false},
+	}
+
+	p := NewReusableParser(Memoize(true))
+	for i := 0; i < 3; i++ {
+		for _, tc := range cases {
+			p.Reset(tc.in, []byte(tc.in))
+			got, err := p.Parse()
+			if tc.err {
+				if err == nil {
+					t.Errorf("%d: %q: want an error", i, tc.in)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%d: %q: %v", i, tc.in, err)
+				continue
+			}
+			if got != tc.want {
+				t.Errorf("%d: %q: want %d, got %v", i, tc.in, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestReusableParserSameInput(t *testing.T) {
+	p := NewReusableParser()
+	p.Reset("", []byte("1+2"))
+	for i := 0; i < 2; i++ {
+		got, err := p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != 3 {
+			t.Errorf("%d: want 3, got %v", i, got)
+		}
+	}
+}
+
+func TestReusableParserErrors(t *testing.T) {
+	// the errors of a parse are not carried to the next one
+	p := NewReusableParser()
+	p.Reset("bad", []byte("1+"))
+	_, err := p.Parse()
+	if err == nil || !strings.HasPrefix(err.Error(), "bad:") {
+		t.Fatalf("want an error of the file bad, got %v", err)
+	}
+	p.Reset("good", []byte("1+1"))
+	if _, err := p.Parse(); err != nil {
+		t.Errorf("want no error after Reset, got %v", err)
+	}
+	if len(*p.p.errs) != 0 {
+		t.Errorf("want no errors kept, got %v", p.p.errs)
+	}
+}
+
+func TestReusableParserAllocs(t *testing.T) {
+	for _, memo := range []bool{false, true} {
+		fresh := testing.AllocsPerRun(10, func() { Parse("", input, Memoize(memo)) })
+		p := NewReusableParser(Memoize(memo))
+		reused := testing.AllocsPerRun(10, func() {
+			p.Reset("", input)
+			p.Parse()
+		})
+		if reused >= fresh {
+			t.Errorf("memoize %t: want less than %v allocs with Reset, got %v", memo, fresh, reused)
+		}
+	}
+}
+
+func BenchmarkFreshParser(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Parse("", input, Memoize(true))
+	}
+}
+
+func BenchmarkReusableParser(b *testing.B) {
+	b.ReportAllocs()
+	p := NewReusableParser(Memoize(true))
+	for i := 0; i < b.N; i++ {
+		p.Reset("", input)
+		p.Parse()
+	}
+}
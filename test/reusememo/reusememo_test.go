@@ -0,0 +1,119 @@
+package reusememo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReuseMemo(t *testing.T) {
+	var prefix string
+	for i := 0; i < 50; i++ {
+		prefix += "a = f(g(12));\n"
+	}
+
+	var c MemoCache
+	first, err := Parse("", []byte(prefix+"b = 1;"), ReuseMemo(&c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Hits() != 0 {
+		t.Errorf("want no hit on the first parse, got %d", c.Hits())
+	}
+	if n := len(first.([]interface{})); n != 51 {
+		t.Errorf("want 51 statements, got %d", n)
+	}
+
+	// the statements of the common prefix are reused, the rest is parsed
+	// again
+	cases := []string{
+		prefix + "b = 1;",
+		prefix + "b = 12;",
+		prefix + "b = h(1);",
+		prefix + "b = 1; c = 2;",
+		prefix,
+		strings.Replace(prefix, "12", "13", 1),
+	}
+	for _, in := range cases {
+		want, err := Parse("", []byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Parse("", []byte(in), ReuseMemo(&c))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+
+	// the input differs at its start, no result is reused
+	if _, err := Parse("", []byte("x = 1;\n"+prefix), ReuseMemo(&c)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Hits() != 0 {
+		t.Errorf("want no hit with a different start, got %d", c.Hits())
+	}
+	if _, err := Parse("", []byte("x = 1;\n"+prefix+"y = 2;"), ReuseMemo(&c)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Hits() == 0 {
+		t.Errorf("want hits with a common prefix, got none")
+	}
+}
+
+func TestReuseMemoPrefixHits(t *testing.T) {
+	var prefix string
+	for i := 0; i < 50; i++ {
+		prefix += "a = f(g(12));\n"
+	}
+
+	var c MemoCache
+	if _, err := Parse("", []byte(prefix+"b = 1;"), ReuseMemo(&c)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte(prefix+"c = 2;"), ReuseMemo(&c)); err != nil {
+		t.Fatal(err)
+	}
+	// the File rule examines the differing suffix, but each statement of
+	// the prefix is reused
+	if c.Hits() < 50 {
+		t.Errorf("want at least 50 hits, got %d", c.Hits())
+	}
+}
+
+func TestReuseMemoVersion(t *testing.T) {
+	var prefix string
+	for i := 0; i < 50; i++ {
+		prefix += "a = f(g(12));\n"
+	}
+
+	var c MemoCache
+	if _, err := Parse("", []byte(prefix+"b = 1;"), ReuseMemo(&c), MemoVersion(1)); err != nil {
+		t.Fatal(err)
+	}
+	// same input, but another version: nothing is reused
+	if _, err := Parse("", []byte(prefix+"b = 1;"), ReuseMemo(&c), MemoVersion(2)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Hits() != 0 {
+		t.Errorf("want no hit after a version change, got %d", c.Hits())
+	}
+	// the results of the same version are reused
+	got, err := Parse("", []byte(prefix+"c = 2;"), ReuseMemo(&c), MemoVersion(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Hits() == 0 {
+		t.Errorf("want hits with the same version, got none")
+	}
+	want, err := Parse("", []byte(prefix+"c = 2;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,36 @@
+package rulepath
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRulePath(t *testing.T) {
+	cases := []struct {
+		in   string
+		path string
+	}{
+		{in: "let x = (1 + ;", path: "rule Program > Stmt > Expr > Term > Expr > Term: "},
+		{in: "let x = 1;\nlet y = (2 + (3 + x));", path: "rule Program > Stmt > Expr > Term > Expr > Term > Expr > Term: "},
+		{in: "check 0;", path: "rule Program > Stmt > Check: zero check"},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in), RulePath(true))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.path) {
+			t.Errorf("%q: want the rule path %q, got %v", tc.in, tc.path, err)
+		}
+
+		_, err = Parse("", []byte(tc.in))
+		if err == nil {
+			t.Errorf("%q: want error without the rule path, got none", tc.in)
+			continue
+		}
+		if strings.Contains(err.Error(), " > ") {
+			t.Errorf("%q: want no rule path by default, got %v", tc.in, err)
+		}
+	}
+}
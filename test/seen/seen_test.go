@@ -0,0 +1,42 @@
+package seen
+
+import "testing"
+
+func TestSeen(t *testing.T) {
+	for in, want := range map[string]int{
+		"ab cd;":                    0,
+		"ab cd;*ab cd;":             1,
+		"ab;ab cd;*ab cd;*ab;":      2,
+		"ab cd;xy;*xy;*ab cd;*xy;":  3,
+		"ab.ab;*ab;":                1,
+		"ab cd ef;ab cd;*ab cd ef;": 1,
+	} {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %d repeats, got %v", in, want, got)
+		}
+	}
+}
+
+func TestSeenNearMiss(t *testing.T) {
+	for _, in := range []string{
+		// the same length as an earlier block, with another last letter
+		"ab cd;*ab ce;",
+		// a prefix of an earlier block
+		"ab cd;*ab c;",
+		// an earlier block, that is followed by more words
+		"ab;*ab cd;",
+		// the block of a new block that failed, and is a comment
+		"ab.*ab;",
+		// no earlier block
+		"*ab;",
+	} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
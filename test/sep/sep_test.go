@@ -0,0 +1,51 @@
+package sep
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSep(t *testing.T) {
+	cases := map[string][]interface{}{
+		"list()":          nil,
+		"list(a)":         {"a"},
+		"list(a,)":        {"a"},
+		"list(a,b,c)":     {"a", "b", "c"},
+		"list(a,b,c,)":    {"a", "b", "c"},
+		"strict(a)":       {"a"},
+		"strict(a ; b;c)": {"a", "b", "c"},
+		"kept(a)":         {"a"},
+		"kept(a,b,c)":     {"a", ",", "b", ",", "c"},
+		"kept(a,b,)":      {"a", ",", "b", ","},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if want == nil {
+			if got != nil {
+				t.Errorf("%q: want nil, got %v", in, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
+
+func TestSepInvalid(t *testing.T) {
+	for _, in := range []string{
+		"list(,)",
+		"list(a,,)",
+		"strict()",
+		"strict(a;)",
+		"strict(a ; )",
+	} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
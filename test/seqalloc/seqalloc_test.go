@@ -0,0 +1,55 @@
+package seqalloc
+
+import (
+	"strings"
+	"testing"
+)
+
+var input = []byte(strings.Repeat("abcdefgh", 1000))
+
+func TestSeqValues(t *testing.T) {
+	got, err := Parse("", []byte("abcdefghabcdefgh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := got.([]interface{})[0].([]interface{})
+	if len(items) != 2 {
+		t.Fatalf("want 2 items, got %d", len(items))
+	}
+	for _, item := range items {
+		vals := item.([]interface{})
+		if len(vals) != 8 || cap(vals) != 8 {
+			t.Errorf("want 8 values with a capacity of 8, got %d with %d", len(vals), cap(vals))
+		}
+	}
+}
+
+func TestSeqAllocs(t *testing.T) {
+	p := newParser("", []byte("abcdefgh"))
+	p.grammar = g
+	p.buildRulesTable(g)
+	p.read()
+	start := p.pt
+	seq := p.rules["Item"].expr.(*seqExpr)
+	exprs := testing.AllocsPerRun(10, func() {
+		p.restore(start)
+		for _, expr := range seq.exprs {
+			p.parseExpr(expr)
+		}
+	})
+	allocs := testing.AllocsPerRun(10, func() {
+		p.restore(start)
+		p.parseSeqExpr(seq)
+	})
+	// one for the slice of values, one to return it as an interface{}
+	if allocs-exprs > 2 {
+		t.Errorf("want at most 2 allocs for the values of a sequence, got %v", allocs-exprs)
+	}
+}
+
+func BenchmarkSeq(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Parse("", input)
+	}
+}
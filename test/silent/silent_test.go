@@ -0,0 +1,40 @@
+package silent
+
+import "testing"
+
+func TestSilent(t *testing.T) {
+	cases := []struct {
+		in    string
+		loud  string
+		quiet string
+	}{
+		{
+			in:    "1 x",
+			loud:  "1:3 (2): syntax error, unexpected 'x', expecting ' ', '\t', '+'",
+			quiet: "1:3 (2): syntax error, unexpected 'x', expecting '+'",
+		},
+		{
+			in:    "1  2",
+			loud:  "1:4 (3): syntax error, unexpected '2', expecting ' ', '\t', '+'",
+			quiet: "1:4 (3): syntax error, unexpected '2', expecting '+'",
+		},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in))
+		if err == nil || err.Error() != tc.loud {
+			t.Errorf("%q: want error %q, got %v", tc.in, tc.loud, err)
+		}
+		_, err = ParseQuiet("", []byte(tc.in))
+		if err == nil || err.Error() != tc.quiet {
+			t.Errorf("%q: want error %q with the silent rule, got %v", tc.in, tc.quiet, err)
+		}
+	}
+}
+
+func TestSilentMatch(t *testing.T) {
+	for _, in := range []string{"1+2", "1 + 2", "1\t+  2"} {
+		if _, err := ParseQuiet("", []byte(in)); err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+		}
+	}
+}
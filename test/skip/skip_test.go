@@ -0,0 +1,31 @@
+package skip
+
+import "testing"
+
+func TestSkip(t *testing.T) {
+	cases := map[string]int{
+		"1+2":            3,
+		" 1 + 2 ":        3,
+		"\t10\n+ 20+3  ": 33,
+		"12":             12,
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %d, got %v", in, want, got)
+		}
+	}
+}
+
+func TestSkipLexical(t *testing.T) {
+	// no whitespace is skipped inside the lexical rule Number
+	for _, in := range []string{"1 2", "1+2 3", "1 +"} {
+		if got, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got %v", in, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package skipfunc
+
+import (
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestSkipFunc(t *testing.T) {
+	underscore := func(r rune) bool {
+		return r == '_' || unicode.IsSpace(r)
+	}
+	want := []string{"a", "b"}
+
+	// the skip rule does not skip the underscores
+	if _, err := Parse("", []byte("a_+_b")); err == nil {
+		t.Errorf("want an error without the SkipFunc option")
+	}
+
+	for _, in := range []string{"a + b", "a_+_b", "_a\t+__ b_"} {
+		got, err := Parse("", []byte(in), SkipFunc(underscore))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+
+	// the function replaces the skip rule, the spaces are not skipped
+	// if it returns false for them
+	only := func(r rune) bool { return r == '_' }
+	if _, err := Parse("", []byte("a + b"), SkipFunc(only)); err == nil {
+		t.Errorf("want an error with a SkipFunc that only skips underscores")
+	}
+}
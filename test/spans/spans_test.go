@@ -0,0 +1,42 @@
+package spans
+
+import "testing"
+
+func TestSpans(t *testing.T) {
+	got, err := Parse("", []byte("ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair := got.(Pair)
+	if want := (position{line: 1, col: 1, offset: 0}); pair.Pos != want {
+		t.Errorf("want Pair to start at %v, got %v", want, pair.Pos)
+	}
+	if want := (position{line: 1, col: 3, offset: 2}); pair.End != want {
+		t.Errorf("want Pair to end at %v, got %v", want, pair.End)
+	}
+
+	want := []struct{ pos, end position }{
+		{position{line: 1, col: 1, offset: 0}, position{line: 1, col: 2, offset: 1}},
+		{position{line: 1, col: 2, offset: 1}, position{line: 1, col: 3, offset: 2}},
+	}
+	for i, v := range []interface{}{pair.First, pair.Second} {
+		ch := v.(Char)
+		if ch.Pos != want[i].pos || ch.End != want[i].end {
+			t.Errorf("%d: want Char to span %v-%v, got %v-%v", i, want[i].pos, want[i].end, ch.Pos, ch.End)
+		}
+	}
+}
+
+func TestSpansNewline(t *testing.T) {
+	got, err := Parse("", []byte("a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair := got.(Pair)
+	if want := (position{line: 2, col: 1, offset: 2}); pair.End != want {
+		t.Errorf("want Pair to end at %v, got %v", want, pair.End)
+	}
+	if ch := pair.Second.(Char); ch.Pos.offset != 1 || ch.End.offset != 2 {
+		t.Errorf("want second Char to span offsets 1-2, got %d-%d", ch.Pos.offset, ch.End.offset)
+	}
+}
@@ -0,0 +1,27 @@
+package split
+
+import "strings"
+
+func (c *current) onList1(first, rest interface{}) (interface{}, error) {
+	words := []string{first.(string)}
+	for _, r := range rest.([]interface{}) {
+		words = append(words, r.([]interface{})[1].(string))
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (p *parser) callonList1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onList1(stack["first"], stack["rest"])
+}
+
+func (c *current) onWord1() (interface{}, error) {
+	return strings.ToUpper(string(c.text)), nil
+}
+
+func (p *parser) callonWord1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onWord1()
+}
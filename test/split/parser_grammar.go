@@ -0,0 +1,73 @@
+package split
+
+var g = &grammar{
+	rules: []*rule{
+		{
+			name: "List",
+			pos:  position{line: 7, col: 1, offset: 37},
+			expr: &actionExpr{
+				pos: position{line: 7, col: 8, offset: 46},
+				run: (*parser).callonList1,
+				expr: &seqExpr{
+					pos: position{line: 7, col: 8, offset: 46},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 7, col: 8, offset: 46},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 7, col: 14, offset: 52},
+								name: "Word",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 7, col: 19, offset: 57},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 7, col: 24, offset: 62},
+								expr: &seqExpr{
+									pos: position{line: 7, col: 26, offset: 64},
+									exprs: []interface{}{
+										&litMatcher{
+											pos:        position{line: 7, col: 26, offset: 64},
+											val:        ",",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 7, col: 30, offset: 68},
+											name: "Word",
+										},
+									},
+								},
+							},
+						},
+						&notExpr{
+							pos: position{line: 7, col: 38, offset: 76},
+							expr: &anyMatcher{
+								line: 7, col: 39, offset: 77,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Word",
+			pos:  position{line: 15, col: 1, offset: 275},
+			expr: &actionExpr{
+				pos: position{line: 15, col: 8, offset: 284},
+				run: (*parser).callonWord1,
+				expr: &oneOrMoreExpr{
+					pos: position{line: 15, col: 8, offset: 284},
+					expr: &charClassMatcher{
+						pos:        position{line: 15, col: 8, offset: 284},
+						val:        "[a-z]",
+						ranges:     []rune{'a', 'z'},
+						ignoreCase: false,
+						inverted:   false,
+					},
+				},
+			},
+		},
+	},
+}
+var defaultOptions []Option
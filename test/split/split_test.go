@@ -0,0 +1,16 @@
+package split
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	got, err := Parse("", []byte("a,bc,d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A BC D"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if _, err := Parse("", []byte("a,")); err == nil {
+		t.Error("want error, got none")
+	}
+}
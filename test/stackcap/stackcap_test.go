@@ -0,0 +1,44 @@
+package stackcap
+
+import (
+	"strings"
+	"testing"
+)
+
+var input = []byte(strings.Repeat("(1+", 10) + "1" + strings.Repeat(")", 10))
+
+func TestInitialStackCap(t *testing.T) {
+	p := newParser("", input, InitialStackCap(64))
+	got, err := p.parse(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 11 {
+		t.Errorf("want 11, got %v", got)
+	}
+	if cap(p.vstack) != 64 || cap(p.rstack) != 64 {
+		t.Errorf("want stacks of capacity 64, got %d and %d", cap(p.vstack), cap(p.rstack))
+	}
+}
+
+func TestInitialStackCapAllocs(t *testing.T) {
+	grown := testing.AllocsPerRun(10, func() { Parse("", input) })
+	sized := testing.AllocsPerRun(10, func() { Parse("", input, InitialStackCap(64)) })
+	if sized >= grown {
+		t.Errorf("want less than %v allocs with an initial capacity, got %v", grown, sized)
+	}
+}
+
+func BenchmarkGrownStacks(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Parse("", input)
+	}
+}
+
+func BenchmarkInitialStackCap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Parse("", input, InitialStackCap(64))
+	}
+}
@@ -0,0 +1,47 @@
+package stacktrace
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStackTrace(t *testing.T) {
+	cases := map[string]string{
+		"a": "(*current).onA1",
+		"b": "(*current).onB3",
+	}
+	for in, want := range cases {
+		fns := panicFuncs(t, in)
+		var found bool
+		for _, fn := range fns {
+			if strings.HasSuffix(fn, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%q: want %s in stack trace, got %v", in, want, fns)
+		}
+	}
+}
+
+// panicFuncs parses in with recover disabled and returns the names of
+// the functions in the stack trace of the panic.
+func panicFuncs(t *testing.T, in string) (fns []string) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("%q: want panic, got none", in)
+		}
+		pcs := make([]uintptr, 64)
+		n := runtime.Callers(0, pcs)
+		for _, pc := range pcs[:n] {
+			if f := runtime.FuncForPC(pc); f != nil {
+				fns = append(fns, f.Name())
+			}
+		}
+	}()
+
+	Parse("", []byte(in), Recover(false))
+	return nil
+}
@@ -0,0 +1,30 @@
+package state
+
+import "testing"
+
+func TestState(t *testing.T) {
+	var s parseState
+	got, err := Parse("", []byte("(()(()))()"), WithState(&s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3 {
+		t.Errorf("want max depth 3, got %v", got)
+	}
+	if s.depth != 0 || s.max != 3 {
+		t.Errorf("want final state {0 3}, got %+v", s)
+	}
+}
+
+func TestStateNew(t *testing.T) {
+	// without WithState, each parse starts from a zero state
+	for i := 0; i < 2; i++ {
+		got, err := Parse("", []byte("(())"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != 2 {
+			t.Errorf("%d: want max depth 2, got %v", i, got)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatistics(t *testing.T) {
+	var st Stats
+	if _, err := Parse("", []byte("a b c() d"), Statistics(&st)); err != nil {
+		t.Fatal(err)
+	}
+	want := []RuleStats{
+		{Name: "List", Success: 1},
+		// the last attempt is at the end of the input
+		{Name: "Item", Success: 4, Fail: 1},
+		{Name: "Call", Success: 1, Fail: 4},
+		{Name: "Name", Success: 7, Fail: 2},
+	}
+	if !reflect.DeepEqual(st.Rules, want) {
+		t.Errorf("want %+v, got %+v", want, st.Rules)
+	}
+
+	// the statistics are reset for each parse, and recorded for a failed
+	// parse
+	if _, err := Parse("", []byte("a ("), Statistics(&st)); err == nil {
+		t.Fatal("want an error")
+	}
+	want = []RuleStats{
+		{Name: "List", Fail: 1},
+		{Name: "Item", Success: 1, Fail: 1},
+		{Name: "Call", Fail: 2},
+		{Name: "Name", Success: 2, Fail: 2},
+	}
+	if !reflect.DeepEqual(st.Rules, want) {
+		t.Errorf("want %+v, got %+v", want, st.Rules)
+	}
+}
@@ -0,0 +1,31 @@
+package stoprepeat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStopRepeat(t *testing.T) {
+	cases := []struct {
+		in    string
+		words []interface{}
+		rest  string
+	}{
+		{in: "", rest: ""},
+		{in: "a b c", words: []interface{}{"a", "b", "c"}, rest: ""},
+		{in: "a b end c", words: []interface{}{"a", "b"}, rest: "end c"},
+		{in: "end a", rest: "end a"},
+		{in: "a ending", words: []interface{}{"a", "ending"}, rest: ""},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		want := []interface{}{tc.words, tc.rest}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, want, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	n := 1000000
+	if testing.Short() {
+		n = 10000
+	}
+	input := bytes.Repeat([]byte("key=42\n"), n)
+
+	// the heap is measured after a tenth of the items and at the end, the
+	// values of the items must not accumulate in between
+	var count int
+	var first, last uint64
+	measure := func() uint64 {
+		var ms runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		return ms.HeapAlloc
+	}
+	got, err := Parse("", input, Stream("Item", func(v interface{}) error {
+		if kv := v.([2]string); kv != [2]string{"key", "42"} {
+			t.Fatalf("want key=42, got %v", kv)
+		}
+		count++
+		switch count {
+		case n / 10:
+			first = measure()
+		case n:
+			last = measure()
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != n {
+		t.Errorf("want %d items, got %d", n, count)
+	}
+	if items := got.([]interface{}); items != nil {
+		t.Errorf("want nil items, got %d", len(items))
+	}
+	if last > first && last-first > 1<<20 {
+		t.Errorf("want the heap not to grow with the items, grew by %d bytes", last-first)
+	}
+	t.Logf("heap %d bytes at %d items, %d bytes at %d items", first, n/10, last, n)
+}
+
+func TestStreamCollect(t *testing.T) {
+	got, err := Parse("", []byte("a=1\nb=2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := got.([]interface{}); len(items) != 2 {
+		t.Errorf("want 2 items without the Stream option, got %d", len(items))
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	errFull := errors.New("full")
+	var count int
+	_, err := Parse("", []byte("a=1\nb=2\nc=3\n"), Stream("Item", func(v interface{}) error {
+		if count++; count == 2 {
+			return errFull
+		}
+		return nil
+	}))
+	if err == nil || !strings.Contains(err.Error(), "full") {
+		t.Errorf("want the error of the function, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("want the items up to the error, got %d", count)
+	}
+}
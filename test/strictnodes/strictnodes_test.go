@@ -0,0 +1,32 @@
+package strictnodes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStrictNodes(t *testing.T) {
+	got, err := Parse("", []byte("a=1,b=2"), StrictNodes(true))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want := []*Pair{{Key: "a", Val: "1"}, {Key: "b", Val: "2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestStrictNodesMistyped(t *testing.T) {
+	want := "1:5 (4): rule Pair: value of type strictnodes.Pair, want *Pair"
+	_, err := Parse("", []byte("a=1,bad=2"), StrictNodes(true))
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	// without the check, the mistyped value fails in the action of Pairs
+	_, err = Parse("", []byte("a=1,bad=2"))
+	if err == nil || strings.Contains(err.Error(), "rule Pair:") {
+		t.Errorf("want an error not from the rule Pair, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package strip
+
+import "testing"
+
+func TestStripActions(t *testing.T) {
+	// the predicate that always fails is ignored
+	if _, err := Parse("", []byte("1+22+333")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte("1+")); err == nil {
+		t.Errorf("want error, got none")
+	}
+
+	// the value is that of the expression without the action
+	got, err := Parse("", []byte("12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, ok := got.([]interface{})
+	if !ok || len(seq) != 3 {
+		t.Fatalf("want the value of the sequence, got %#v", got)
+	}
+	num, ok := seq[0].([]interface{})
+	if !ok || len(num) != 2 || num[1] != nil {
+		t.Errorf("want the value of the number and predicate, got %#v", seq[0])
+	}
+}
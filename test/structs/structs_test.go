@@ -0,0 +1,46 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStructs(t *testing.T) {
+	got, err := Parse("", []byte("1,2;-3,40"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{{X: 1, Y: 2}, {X: -3, Y: 40}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestStringer(t *testing.T) {
+	got, err := Parse("", []byte("1,2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := got.([]Point)
+	if s := fmt.Sprint(pts[0]); s != "Point{X:1, Y:2}" {
+		t.Errorf("want %q, got %q", "Point{X:1, Y:2}", s)
+	}
+}
+
+func TestStructDefault(t *testing.T) {
+	cases := map[string]Server{
+		"localhost":      {Host: "localhost", Port: 8080},
+		"localhost:8000": {Host: "localhost", Port: 8000},
+	}
+	for in, want := range cases {
+		got, err := ParseServer("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %#v, got %#v", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package subparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubParse(t *testing.T) {
+	got, err := Parse("", []byte(`width="42"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"width", 42}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestSubParseError(t *testing.T) {
+	_, err := Parse("", []byte(`width="4x"`))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	// the error of the sub-parse is returned by the action of Attr
+	if want := "1:1 (0): rule Attr: 1:1 (0): no match found"; err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestSubParseMaxDepth(t *testing.T) {
+	if _, err := Parse("", []byte(`width="42"`), MaxDepth(1)); err == nil {
+		t.Error("want error, got none")
+	}
+	if _, err := Parse("", []byte(`width="42"`), MaxDepth(2)); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
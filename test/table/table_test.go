@@ -0,0 +1,47 @@
+package table
+
+import (
+	"testing"
+	"unicode"
+)
+
+// identStart is the letters a to f and the dollar sign.
+var identStart = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: '$', Hi: '$', Stride: 1},
+		{Lo: 'a', Hi: 'f', Stride: 1},
+	},
+}
+
+func TestTable(t *testing.T) {
+	cases := []struct {
+		in    string
+		table *unicode.RangeTable
+		err   bool
+	}{
+		{in: "abc", err: true},
+		{in: "abc", table: identStart},
+		{in: "$a1", table: identStart},
+		{in: "1a", table: identStart, err: true},
+		{in: "ghi", table: identStart, err: true},
+		{in: "ghi", table: unicode.Latin},
+		{in: "日本", table: unicode.Han},
+		{in: "日本", table: identStart, err: true},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), Table("IdentStart", tc.table))
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q: want error, got %v", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.in {
+			t.Errorf("want %q, got %v", tc.in, got)
+		}
+	}
+}
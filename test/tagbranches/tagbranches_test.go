@@ -0,0 +1,52 @@
+package tagbranches
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagBranches(t *testing.T) {
+	got, err := Parse("", []byte("ab,12,c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		Branch{Index: 0, Name: "Word", Value: "ab"},
+		Branch{Index: 2, Value: []byte(",")},
+		Branch{Index: 1, Name: "Number", Value: "12"},
+		Branch{Index: 2, Value: []byte(",")},
+		Branch{Index: 0, Name: "Word", Value: "c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTagBranchesOneOrMore(t *testing.T) {
+	got, err := ParseLetters("", []byte("aB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		Branch{Index: 0, Value: []byte("a")},
+		Branch{Index: 1, Value: []byte("B")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTagBranchesLiterals(t *testing.T) {
+	got, err := ParseKeywords("", []byte("ifelseif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		Branch{Index: 0, Value: []byte("if")},
+		Branch{Index: 1, Value: []byte("else")},
+		Branch{Index: 0, Value: []byte("if")},
+	}
+	if !reflect.DeepEqual(got.([]interface{})[0], want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
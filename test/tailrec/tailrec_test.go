@@ -0,0 +1,59 @@
+package tailrec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLongList(t *testing.T) {
+	const n = 100000
+	in := []byte(strings.TrimSuffix(strings.Repeat("ab,", n), ","))
+
+	// the loop does not nest the rule, unlike the recursive calls that the
+	// Statistics option requires
+	got, err := Parse("", in, MaxDepth(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != n {
+		t.Errorf("want %d items, got %v", n, got)
+	}
+	if _, err := Parse("", in, MaxDepth(10), Statistics(new(Stats))); err == nil {
+		t.Error("want max depth error with the recursive calls")
+	}
+}
+
+func TestLoopMatchesRecursion(t *testing.T) {
+	cases := []struct {
+		parse func(string, []byte, ...Option) (interface{}, error)
+		in    string
+	}{
+		{Parse, "a"},
+		{Parse, "a,b,c"},
+		{Parse, "a,b,"},
+		{Parse, ",a"},
+		{ParseNested, "b"},
+		{ParseNested, "aab"},
+		{ParseNested, "aax"},
+		{ParseNested, "ax"},
+		{ParseNested, "aa"},
+		{ParseNested, ""},
+		{ParseGuard, "a"},
+		{ParseGuard, "aa"},
+		{ParseGuard, "aaa"},
+		{ParseGuard, "aaaa"},
+		{ParseGuard, "aaaaa"},
+	}
+	for _, tc := range cases {
+		got, err := tc.parse("", []byte(tc.in))
+		want, wantErr := tc.parse("", []byte(tc.in), Statistics(new(Stats)))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, want, got)
+		}
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("%q: want error %v, got %v", tc.in, wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package terminator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerminated(t *testing.T) {
+	cases := []struct {
+		in   string
+		want interface{}
+	}{
+		{in: "a", want: []interface{}{"a"}},
+		{in: "a;b", want: []interface{}{"a", "b"}},
+		{in: "a;b;", want: []interface{}{"a", "b"}},
+		{in: "a\nb\n", want: []interface{}{"a", "b"}},
+		{in: "{a;b}", want: []interface{}{[]interface{}{"a", "b"}}},
+		{in: "{a;b;};c", want: []interface{}{[]interface{}{"a", "b"}, "c"}},
+		{in: "x;{a\n{b}}\ny", want: []interface{}{"x", []interface{}{"a", []interface{}{"b"}}, "y"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+
+	for _, in := range []string{"", "a b", "{a b}", "{a;b", "a;;b"} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
+
+func TestRepeatedTerminator(t *testing.T) {
+	got, err := ParseRepeated("", []byte("a;b}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestKeptTerminators(t *testing.T) {
+	got, err := ParseKept("", []byte("a;b}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"a", ";", "b", "end"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTerminatorMissing(t *testing.T) {
+	cases := []struct {
+		in   string
+		want interface{}
+	}{
+		{in: "a;b", want: []interface{}{[]interface{}{"a"}, "b"}},
+		{in: "a;b;c", want: []interface{}{[]interface{}{"a", "b"}, "c"}},
+		{in: "a;b;", want: []interface{}{[]interface{}{"a", "b"}, ""}},
+	}
+	for _, tc := range cases {
+		got, err := ParseUnterminated("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
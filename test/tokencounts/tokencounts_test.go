@@ -0,0 +1,31 @@
+package tokencounts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenCounts(t *testing.T) {
+	got := make(map[string]int)
+	if _, err := Parse("", []byte("x = 1 + foo * 2.5 + y2"), TokenCounts(got)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"Ident": 3, "Number": 2, "EOF": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTokenCountsStatistics(t *testing.T) {
+	var st Stats
+	got := make(map[string]int)
+	if _, err := Parse("", []byte("x = 1"), TokenCounts(got), Statistics(&st)); err != nil {
+		t.Fatal(err)
+	}
+	if got["Ident"] != 1 || got["Number"] != 1 {
+		t.Errorf("want 1 Ident and 1 Number, got %v", got)
+	}
+	if len(st.Rules) == 0 {
+		t.Error("want statistics, got none")
+	}
+}
@@ -0,0 +1,55 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+// token is a token of a hand-written lexer.
+type token struct {
+	kind int
+	text string
+	pos  TokenPos
+}
+
+func (t token) Kind() int     { return t.kind }
+func (t token) Text() string  { return t.text }
+func (t token) Pos() TokenPos { return t.pos }
+
+func TestParseTokens(t *testing.T) {
+	toks := []Token{
+		token{kindNum, "10", TokenPos{1, 1, 0}},
+		token{kindPlus, "+", TokenPos{1, 4, 3}},
+		token{kindNum, "5", TokenPos{1, 6, 5}},
+		token{kindMinus, "-", TokenPos{2, 1, 7}},
+		token{kindNum, "3", TokenPos{2, 3, 9}},
+	}
+	got, err := ParseTokens("", toks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 12 {
+		t.Errorf("want 12, got %v", got)
+	}
+
+	// a missing operand is reported at the position of the token
+	_, err = ParseTokens("", toks[:4])
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := "2:1"; !strings.Contains(err.Error(), want) {
+		t.Errorf("want error at %s, got %v", want, err)
+	}
+
+	// two numbers in a row
+	if _, err := ParseTokens("", []Token{toks[0], toks[2]}); err == nil {
+		t.Error("want error, got none")
+	}
+}
+
+func TestParseTokensNoRuneInput(t *testing.T) {
+	// the token matchers don't match text
+	if _, err := Parse("", []byte("1+2")); err == nil {
+		t.Error("want error, got none")
+	}
+}
@@ -0,0 +1,114 @@
+package trace
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden trace files")
+
+func TestTrace(t *testing.T) {
+	cases := map[string]string{
+		"a": "a.trace",
+	}
+	for in, golden := range cases {
+		var trace []string
+		if _, err := Parse("", []byte(in), Trace(&trace)); err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		checkTrace(t, filepath.Join("testdata", golden), trace)
+	}
+}
+
+// checkTrace compares the trace to the one in the golden file, one line per
+// expression, and reports the lines that differ. With the -update flag, the
+// golden file is written instead.
+func checkTrace(t *testing.T, golden string, trace []string) {
+	got := strings.Join(trace, "\n") + "\n"
+	if *update {
+		if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	b, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) == got {
+		return
+	}
+	t.Errorf("%s: trace differs from the golden trace:\n%s", golden, diffLines(string(b), got))
+}
+
+// diffLines returns the lines of want and got that differ, prefixed with
+// - and + respectively, and the lines they have in common before and after
+// the difference.
+func diffLines(want, got string) string {
+	wl := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	gl := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	pre := 0
+	for pre < len(wl) && pre < len(gl) && wl[pre] == gl[pre] {
+		pre++
+	}
+	post := 0
+	for post < len(wl)-pre && post < len(gl)-pre && wl[len(wl)-1-post] == gl[len(gl)-1-post] {
+		post++
+	}
+
+	var buf bytes.Buffer
+	for _, l := range wl[:pre] {
+		fmt.Fprintf(&buf, "  %s\n", l)
+	}
+	for _, l := range wl[pre : len(wl)-post] {
+		fmt.Fprintf(&buf, "- %s\n", l)
+	}
+	for _, l := range gl[pre : len(gl)-post] {
+		fmt.Fprintf(&buf, "+ %s\n", l)
+	}
+	for _, l := range wl[len(wl)-post:] {
+		fmt.Fprintf(&buf, "  %s\n", l)
+	}
+	return buf.String()
+}
+
+func TestDiffLines(t *testing.T) {
+	want := "rule A 1:1\nlitMatcher 1:1\n"
+	got := "rule A 1:1\nseqExpr 1:1\nlitMatcher 1:1\n"
+	diff := diffLines(want, got)
+	if exp := "  rule A 1:1\n+ seqExpr 1:1\n  litMatcher 1:1\n"; diff != exp {
+		t.Errorf("want diff %q, got %q", exp, diff)
+	}
+}
+
+// captureLogger records the lines of the debugging information.
+type captureLogger struct {
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	var l captureLogger
+	if _, err := Parse("", []byte("a"), Debug(true), WithLogger(&l)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		" > 1:1:0: parseRule A [U+0061 'a']",
+		"  > 1:1:0: parseLitMatcher [U+0061 'a']",
+		" < 1:2:1: parseLitMatcher [U+FFFD '�']",
+		" MATCH 1:2:1: a [U+FFFD '�']",
+		"< 1:2:1: parseRule A [U+FFFD '�']",
+	}
+	if strings.Join(l.lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want debug lines:\n%s", diffLines(strings.Join(want, "\n"), strings.Join(l.lines, "\n")))
+	}
+}
@@ -0,0 +1,72 @@
+package tracer
+
+import (
+	"testing"
+	"time"
+)
+
+type span struct {
+	rule  string
+	start time.Time
+	dur   time.Duration
+	err   error
+	ended bool
+}
+
+func (s *span) End(err error) {
+	s.dur = time.Since(s.start)
+	s.err = err
+	s.ended = true
+}
+
+type mockTracer struct {
+	spans []*span
+}
+
+func (m *mockTracer) StartSpan(rule string) Span {
+	s := &span{rule: rule, start: time.Now()}
+	m.spans = append(m.spans, s)
+	return s
+}
+
+func TestTracer(t *testing.T) {
+	var tr mockTracer
+	if _, err := Parse("", []byte("1,2,3"), WithTracer(&tr)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseNumber("", []byte("12"), WithTracer(&tr)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte("1,"), WithTracer(&tr)); err == nil {
+		t.Fatal("want an error")
+	}
+	if _, err := Parse("", []byte("1,0"), WithTracer(&tr)); err == nil {
+		t.Fatal("want an error")
+	}
+
+	want := []struct {
+		rule string
+		err  bool
+	}{
+		{"List", false},
+		{"Number", false},
+		{"List", true},
+		// the error of the panic is recorded
+		{"List", true},
+	}
+	if len(tr.spans) != len(want) {
+		t.Fatalf("want %d spans, got %d", len(want), len(tr.spans))
+	}
+	for i, w := range want {
+		s := tr.spans[i]
+		if s.rule != w.rule {
+			t.Errorf("%d: want rule %s, got %s", i, w.rule, s.rule)
+		}
+		if !s.ended || s.dur <= 0 {
+			t.Errorf("%d: want an ended span with a duration, got %v %s", i, s.ended, s.dur)
+		}
+		if (s.err != nil) != w.err {
+			t.Errorf("%d: want error %t, got %v", i, w.err, s.err)
+		}
+	}
+}
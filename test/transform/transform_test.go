@@ -0,0 +1,47 @@
+package transform
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func upper(v interface{}) (interface{}, error) {
+	return strings.ToUpper(v.(string)), nil
+}
+
+func TestTransform(t *testing.T) {
+	got, err := Parse("", []byte("ab,c,def"), Transform("Ident", upper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"AB", "C", "DEF"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// removing the transformation
+	got, err = Parse("", []byte("ab,c"), Transform("Ident", upper), Transform("Ident", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ab", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTransformError(t *testing.T) {
+	reject := func(v interface{}) (interface{}, error) {
+		if v == "c" {
+			return nil, errors.New("c is rejected")
+		}
+		return v, nil
+	}
+	_, err := Parse("", []byte("ab,c"), Transform("Ident", reject))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := "1:4 (3): rule Ident: c is rejected"; !strings.Contains(err.Error(), want) {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,26 @@
+package trim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrim(t *testing.T) {
+	cases := map[string][]interface{}{
+		"hello":          {"hello"},
+		"  hello  ":      {"hello"},
+		"\thello world ": {"hello world"},
+		"a , b,c ":       {"a", "b", "c"},
+		"a,  ,b":         {"a", "", "b"},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
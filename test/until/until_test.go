@@ -0,0 +1,82 @@
+package until
+
+import "testing"
+
+func TestUntil(t *testing.T) {
+	cases := map[string]string{
+		"/* ab */":        " ab ",
+		"/**/":            "",
+		"/* a * b / c */": " a * b / c ",
+		"/* ←\n */":       " ←\n ",
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestUntilNotTerminated(t *testing.T) {
+	p := newParser("", []byte("/* ab"))
+	if _, err := p.parse(g); err == nil {
+		t.Fatal("want error, got none")
+	}
+}
+
+func TestUntilMissingDelimiter(t *testing.T) {
+	// the matcher consumes the rest of the unterminated comment, and the
+	// "*/" that follows it fails at the end of the input
+	p := newParser("", []byte("/* ab"))
+	p.read()
+	p.read()
+	p.read()
+	got, ok := p.parseUntilMatcher(&untilMatcher{val: "*/"})
+	if !ok {
+		t.Fatal("want match, got none")
+	}
+	if s := string(got.([]byte)); s != " ab" {
+		t.Errorf("want %q, got %q", " ab", s)
+	}
+	if p.pt.offset != 5 {
+		t.Errorf("want offset 5, got %d", p.pt.offset)
+	}
+
+	_, err := Parse("", []byte("/* ab"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := "1:6 (5): syntax error, unexpected '�', expecting '*/'"; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+}
+
+func TestUntilPosition(t *testing.T) {
+	p := newParser("", []byte("a\nbc*/"))
+	p.read()
+	got, ok := p.parseUntilMatcher(&untilMatcher{val: "*/"})
+	if !ok {
+		t.Fatal("want match, got none")
+	}
+	if s := string(got.([]byte)); s != "a\nbc" {
+		t.Errorf("want %q, got %q", "a\nbc", s)
+	}
+	if p.pt.line != 2 || p.pt.col != 3 || p.pt.offset != 4 {
+		t.Errorf("want position 2:3 [4], got %s", p.pt.position)
+	}
+}
+
+func BenchmarkUntil(b *testing.B) {
+	d := []byte("/*" + string(make([]byte, 4096)) + "*/")
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", d); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
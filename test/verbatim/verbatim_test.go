@@ -0,0 +1,25 @@
+package verbatim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerbatim(t *testing.T) {
+	cases := map[string][]string{
+		"a {{  x  y }} b":    {"a", "tag:  x  y ", "b"},
+		"{{}}{{ }}":          {"tag:", "tag: "},
+		"{{\tx\n}} [[  y ]]": {"tag:\tx\n", "raw:y "},
+		" a [[ b ]] ":        {"a", "raw:b "},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", in, want, got)
+		}
+	}
+}
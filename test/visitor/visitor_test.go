@@ -0,0 +1,53 @@
+package visitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) EnterList(v List)   { r.events = append(r.events, "enter list") }
+func (r *recorder) ExitList(v List)    { r.events = append(r.events, "exit list") }
+func (r *recorder) EnterItems(v Items) { r.events = append(r.events, "enter items") }
+func (r *recorder) ExitItems(v Items)  { r.events = append(r.events, "exit items") }
+func (r *recorder) EnterItem(v Item)   { r.events = append(r.events, "enter item "+v.Key.(string)) }
+func (r *recorder) ExitItem(v Item)    { r.events = append(r.events, "exit item "+v.Key.(string)) }
+
+var _ Visitor = (*recorder)(nil)
+
+func TestVisitorMethods(t *testing.T) {
+	typ := reflect.TypeOf((*Visitor)(nil)).Elem()
+	want := []string{"EnterItem", "EnterItems", "EnterList", "ExitItem", "ExitItems", "ExitList"}
+	if typ.NumMethod() != len(want) {
+		t.Fatalf("want %d methods, got %d", len(want), typ.NumMethod())
+	}
+	for i, nm := range want {
+		if got := typ.Method(i).Name; got != nm {
+			t.Errorf("%d: want method %s, got %s", i, nm, got)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	got, err := Parse("", []byte("[a=b,c=d,e=f]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r recorder
+	Walk(&r, got)
+	want := []string{
+		"enter list",
+		"enter items",
+		"enter item a", "exit item a",
+		"enter item c", "exit item c",
+		"enter item e", "exit item e",
+		"exit items",
+		"exit list",
+	}
+	if !reflect.DeepEqual(r.events, want) {
+		t.Errorf("want events %v, got %v", want, r.events)
+	}
+}
@@ -0,0 +1,57 @@
+package warn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	cases := []struct {
+		in   string
+		val  []interface{}
+		want []string
+	}{
+		{"var a;", []interface{}{"a"}, nil},
+		{"var a;let b;", []interface{}{"a", "b"}, []string{"1:7 (6): let is deprecated, use var"}},
+		// the warning of the first alternative is backtracked over
+		{"old?", []interface{}{"old?"}, []string{"1:1 (0): old is deprecated"}},
+		{"let a;old!", []interface{}{"a", "old!"}, []string{
+			"1:1 (0): let is deprecated, use var",
+			"1:7 (6): old is deprecated",
+		}},
+	}
+	for _, tc := range cases {
+		var warns []Warning
+		got, err := Parse("", []byte(tc.in), Warnings(&warns))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.val) {
+			t.Errorf("%q: want value %v, got %v", tc.in, tc.val, got)
+		}
+		var msgs []string
+		for _, w := range warns {
+			msgs = append(msgs, w.String())
+		}
+		if !reflect.DeepEqual(msgs, tc.want) {
+			t.Errorf("%q: want warnings %q, got %q", tc.in, tc.want, msgs)
+		}
+	}
+}
+
+func TestWarningsFailedParse(t *testing.T) {
+	warns := []Warning{{Msg: "stale"}}
+	if _, err := Parse("", []byte("let a;x"), Warnings(&warns)); err == nil {
+		t.Fatal("want error, got none")
+	}
+	if len(warns) != 1 || warns[0].Msg != "stale" {
+		t.Errorf("want warnings unchanged on error, got %v", warns)
+	}
+}
+
+func TestWarningsDisabled(t *testing.T) {
+	if _, err := Parse("", []byte("let a;")); err != nil {
+		t.Fatal(err)
+	}
+}
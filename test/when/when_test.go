@@ -0,0 +1,48 @@
+package when
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhen(t *testing.T) {
+	cases := []struct {
+		in     string
+		strict bool
+		want   []interface{}
+		err    bool
+	}{
+		{in: "a;b;", want: []interface{}{"a", "b"}},
+		{in: "a;b;", strict: true, want: []interface{}{"a", "b"}},
+		{in: "a;b!;", err: true},
+		{in: "a;b!;", strict: true, want: []interface{}{"a", "b!"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), Flag("strict", tc.strict))
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q (strict %t): want error, got none", tc.in, tc.strict)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q (strict %t): want no error, got %v", tc.in, tc.strict, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q (strict %t): want %v, got %v", tc.in, tc.strict, tc.want, got)
+		}
+	}
+}
+
+func TestFlagRestore(t *testing.T) {
+	p := newParser("", nil)
+	prev := Flag("strict", true)(p)
+	if !p.flags["strict"] {
+		t.Fatal("want flag strict set")
+	}
+	prev(p)
+	if p.flags["strict"] {
+		t.Error("want flag strict unset after restoring the previous option")
+	}
+}
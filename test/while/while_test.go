@@ -0,0 +1,40 @@
+package while
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhile(t *testing.T) {
+	cases := map[string][]int{
+		"":                      {0, 0},
+		"xxx":                   {3, 0},
+		strings.Repeat("x", 10): {10, 0},
+		strings.Repeat("x", 15): {10, 5},
+		"1:y":                   {1, 0},
+		"3:yyyyy":               {3, 2},
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
+
+func TestWhileInvalid(t *testing.T) {
+	for _, in := range []string{
+		"0:y",
+		"2:",
+		"xy",
+	} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package wordlist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordList(t *testing.T) {
+	goWords := []string{"func", "for", "if", "interface"}
+	pyWords := []string{"def", "for", "if", "in"}
+
+	cases := []struct {
+		in    string
+		words []string
+		want  []string
+	}{
+		{"func for in", goWords, []string{"kw:func", "kw:for", "id:in"}},
+		{"func def in", pyWords, []string{"id:func", "kw:def", "kw:in"}},
+		{"interface in", goWords, []string{"kw:interface", "id:in"}},
+		{"interface in", pyWords, []string{"id:interface", "kw:in"}},
+		{"def iffy if", pyWords, []string{"kw:def", "id:iffy", "kw:if"}},
+		{"def iffy if", nil, []string{"id:def", "id:iffy", "id:if"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), WordList(tc.words...))
+		if err != nil {
+			t.Errorf("%q %v: want no error, got %v", tc.in, tc.words, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q %v: want %q, got %q", tc.in, tc.words, tc.want, got)
+		}
+	}
+}